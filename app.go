@@ -2,11 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/run-bigpig/jcp/internal/adk"
 	"github.com/run-bigpig/jcp/internal/adk/mcp"
+	"github.com/run-bigpig/jcp/internal/adk/openai"
+	"github.com/run-bigpig/jcp/internal/adk/prompts"
 	"github.com/run-bigpig/jcp/internal/adk/tools"
 	"github.com/run-bigpig/jcp/internal/agent"
 	"github.com/run-bigpig/jcp/internal/logger"
@@ -14,12 +21,20 @@ import (
 	"github.com/run-bigpig/jcp/internal/memory"
 	"github.com/run-bigpig/jcp/internal/models"
 	"github.com/run-bigpig/jcp/internal/openclaw"
+	"github.com/run-bigpig/jcp/internal/pkg/idempotency"
+	"github.com/run-bigpig/jcp/internal/pkg/lifecycle"
 	"github.com/run-bigpig/jcp/internal/pkg/paths"
+	"github.com/run-bigpig/jcp/internal/pkg/plaintext"
+	"github.com/run-bigpig/jcp/internal/pkg/postprocess"
 	"github.com/run-bigpig/jcp/internal/pkg/proxy"
+	"github.com/run-bigpig/jcp/internal/pkg/redact"
+	"github.com/run-bigpig/jcp/internal/pkg/textdiff"
 	"github.com/run-bigpig/jcp/internal/services"
 	"github.com/run-bigpig/jcp/internal/services/hottrend"
 
+	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"google.golang.org/adk/model"
 )
 
 var log = logger.New("app")
@@ -28,6 +43,8 @@ var log = logger.New("app")
 type App struct {
 	ctx               context.Context
 	configService     *services.ConfigService
+	settingsService   *services.SettingsService
+	lifecycle         *lifecycle.Manager
 	marketService     *services.MarketService
 	newsService       *services.NewsService
 	hotTrendService   *hottrend.HotTrendService
@@ -42,10 +59,34 @@ type App struct {
 	memoryManager     *memory.Manager
 	updateService     *services.UpdateService
 	openClawServer    *openclaw.Server
+	triggerService    *services.TriggerService
+	rankingService    *services.RankingService
+	notesService      *services.NotesService
+	userMemoryService *services.UserMemoryService
+	pipelineService   *services.PipelineService
+	checkpointService *services.CheckpointService
+	traceService      *services.TraceService
+	costService       *services.CostService
+	fxService         *services.FXService
+	batchService      *services.BatchService
+	notifyService     *services.NotifyService
+	digestService     *services.DigestService
+	documentService   *services.DocumentService
+	artifactService   *services.ArtifactService
+	signalService     *services.SignalService
+	instrumentService *services.InstrumentService
+	jobQueue          *services.JobQueue
+	modelCatalog      *adk.ModelCatalogService
 
 	// 会议取消管理
 	meetingCancels   map[string]context.CancelFunc
 	meetingCancelsMu sync.RWMutex
+	// 会议消息幂等提交：重复提交复用首次结果
+	meetingIdem *idempotency.Cache[[]models.ChatMessage]
+	// 未带幂等键的会议消息按内容合并重复提交，完成后只保留很短时间，不影响用户有意重复提问
+	meetingDedupe *idempotency.Cache[[]models.ChatMessage]
+	// 数据目录迁移互斥
+	dataDirMu sync.Mutex
 }
 
 // NewApp creates a new App application struct
@@ -57,6 +98,9 @@ func NewApp() *App {
 		log.Error("初始化文件日志失败: %v", err)
 	}
 	logger.SetGlobalLevel(logger.DEBUG)
+	if warning := paths.DataDirWarning(); warning != "" {
+		log.Warn("%s", warning)
+	}
 
 	// 初始化配置服务
 	configService, err := services.NewConfigService(dataDir)
@@ -64,6 +108,9 @@ func NewApp() *App {
 		panic(err)
 	}
 
+	// 初始化界面设置服务（主题、涨跌颜色、行情刷新间隔）
+	settingsService := services.NewSettingsService(dataDir, configService.GetConfig())
+
 	// 初始化研报服务
 	researchReportService := services.NewResearchReportService()
 
@@ -74,13 +121,24 @@ func NewApp() *App {
 	}
 
 	marketService := services.NewMarketService()
+	marketService.EnableSnapshot(dataDir)
 	newsService := services.NewNewsService()
 
 	// 初始化龙虎榜服务
 	longHuBangService := services.NewLongHuBangService()
 
+	// 初始化研究笔记服务
+	notesService := services.NewNotesService(dataDir)
+
+	// 初始化公告与文档索引服务
+	announcementService := services.NewAnnouncementService()
+	documentService := services.NewDocumentService(dataDir)
+	// 持久化后台任务队列（文档入库、定时批量分析、周报）
+	jobQueue := services.NewJobQueue(dataDir)
+	documentService.SetJobQueue(jobQueue)
+
 	// 初始化工具注册中心
-	toolRegistry := tools.NewRegistry(marketService, newsService, configService, researchReportService, hotTrendSvc, longHuBangService)
+	toolRegistry := tools.NewRegistry(marketService, newsService, configService, researchReportService, hotTrendSvc, longHuBangService, notesService, announcementService, documentService)
 
 	// 初始化 MCP 管理器
 	mcpManager := mcp.NewManager()
@@ -90,17 +148,32 @@ func NewApp() *App {
 
 	// 初始化会议室服务
 	meetingService := meeting.NewServiceFull(toolRegistry, mcpManager)
+	meetingService.SetNotesProvider(notesService.FormatForPrompt)
+	userMemoryService := services.NewUserMemoryService(dataDir)
+	meetingService.SetUserMemoryProvider(userMemoryService.FormatForPrompt)
+	signalService := services.NewSignalService()
+	meetingService.SetSignalProvider(signalService.FormatForPrompt)
+	meetingService.SetDocumentProvider(documentService.FormatForPrompt)
+	meetingService.SetDelegateConfig(configService.GetConfig().Delegate)
+	meetingService.SetRiskProfile(configService.GetConfig().RiskProfile)
+
+	// 初始化证券主表服务
+	instrumentService := services.NewInstrumentService(dataDir)
 
 	// 初始化记忆管理器
 	var memoryManager *memory.Manager
 	memConfig := configService.GetConfig().Memory
 	if memConfig.Enabled {
 		memoryManager = memory.NewManagerWithConfig(dataDir, memory.Config{
-			MaxRecentRounds:   memConfig.MaxRecentRounds,
-			MaxKeyFacts:       memConfig.MaxKeyFacts,
-			MaxSummaryLength:  memConfig.MaxSummaryLength,
-			CompressThreshold: memConfig.CompressThreshold,
+			MaxRecentRounds:    memConfig.MaxRecentRounds,
+			MaxKeyFacts:        memConfig.MaxKeyFacts,
+			MaxSummaryLength:   memConfig.MaxSummaryLength,
+			CompressThreshold:  memConfig.CompressThreshold,
+			CompressTokenRatio: memConfig.CompressTokenRatio,
+			Prompts:            services.MemoryPromptTemplates(memConfig.Prompts),
 		})
+		memoryManager.SetDegradePolicy(memoryDegradePolicy(memConfig.Degrade))
+		memoryManager.SetIndustryLookup(instrumentService.Industry)
 		meetingService.SetMemoryManager(memoryManager)
 
 		if memConfig.AIConfigID != "" {
@@ -128,6 +201,12 @@ func NewApp() *App {
 
 	// 初始化Session服务
 	sessionService := services.NewSessionService(dataDir)
+	sessionService.SetStripThinking(configService.GetConfig().Storage.StripThinking)
+	if memoryManager != nil {
+		memoryManager.SetPositionLookup(func(stockCode string) string {
+			return describePosition(sessionService.GetPosition(stockCode))
+		})
+	}
 
 	// 初始化策略服务
 	strategyService := services.NewStrategyService(dataDir)
@@ -139,6 +218,31 @@ func NewApp() *App {
 	// 初始化更新服务
 	updateService := services.NewUpdateService("run-bigpig", "jcp", Version)
 
+	// 初始化事件触发服务
+	triggerService := services.NewTriggerService(dataDir)
+
+	// 初始化批量评分服务
+	rankingService := services.NewRankingService(dataDir)
+
+	// 初始化会话检查点服务
+	checkpointService := services.NewCheckpointService(dataDir)
+
+	// 初始化执行轨迹服务
+	traceService := services.NewTraceService(dataDir)
+
+	// 初始化通知服务
+	notifyService := services.NewNotifyService(func() *models.NotifyConfig {
+		return &configService.GetConfig().Notify
+	})
+
+	// 初始化费用统计服务
+	costService := services.NewCostService(dataDir, func() *models.CostConfig {
+		return &configService.GetConfig().Cost
+	})
+
+	// 初始化声明式流水线服务
+	pipelineService := services.NewPipelineService(dataDir)
+
 	// 初始化 OpenClaw 服务
 	openClawServer := openclaw.NewServer(meetingService, agentContainer, func(aiConfigID string) *models.AIConfig {
 		cfg := configService.GetConfig()
@@ -161,11 +265,14 @@ func NewApp() *App {
 		}
 		return &stocks[0], nil
 	})
+	openClawServer.EnableAuditLog(dataDir)
 
 	log.Info("所有服务初始化完成")
 
 	return &App{
 		configService:     configService,
+		settingsService:   settingsService,
+		lifecycle:         lifecycle.NewManager(),
 		marketService:     marketService,
 		newsService:       newsService,
 		hotTrendService:   hotTrendSvc,
@@ -179,7 +286,27 @@ func NewApp() *App {
 		memoryManager:     memoryManager,
 		updateService:     updateService,
 		openClawServer:    openClawServer,
+		triggerService:    triggerService,
+		rankingService:    rankingService,
+		notesService:      notesService,
+		userMemoryService: userMemoryService,
+		pipelineService:   pipelineService,
+		checkpointService: checkpointService,
+		traceService:      traceService,
+		costService:       costService,
+		fxService:         services.NewFXService(dataDir),
+		batchService:      services.NewBatchService(dataDir),
+		notifyService:     notifyService,
+		digestService:     services.NewDigestService(dataDir),
+		documentService:   documentService,
+		artifactService:   services.NewArtifactService(dataDir),
+		signalService:     signalService,
+		instrumentService: instrumentService,
+		jobQueue:          jobQueue,
+		modelCatalog:      adk.NewModelCatalogService(),
 		meetingCancels:    make(map[string]context.CancelFunc),
+		meetingIdem:       idempotency.New[[]models.ChatMessage](10 * time.Minute),
+		meetingDedupe:     idempotency.New[[]models.ChatMessage](meetingContentDedupeWindow),
 	}
 }
 
@@ -187,49 +314,208 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	if warning := paths.DataDirWarning(); warning != "" {
+		runtime.EventsEmit(ctx, "datadir:warning", warning)
+	}
 
 	// 初始化代理配置
 	proxy.GetManager().SetConfig(&a.configService.GetConfig().Proxy)
-
-	// 初始化 MCP 管理器（绑定主 context，预创建 toolset）
-	if a.mcpManager != nil {
-		if err := a.mcpManager.Initialize(ctx); err != nil {
-			log.Warn("MCP 初始化失败: %v", err)
-		}
-	}
+	// 初始化敏感信息脱敏配置
+	redact.GetManager().SetConfig(&a.configService.GetConfig().Redaction)
+	// 初始化模型请求抓包
+	adk.SetDebugCapture(debugCaptureDir(), a.configService.GetConfig().DebugCapture)
 
 	// 设置 Meeting 服务的 AI 配置解析器
 	if a.meetingService != nil {
 		a.meetingService.SetAIConfigResolver(a.getAIConfigByID)
 	}
 
-	// 初始化更新服务
+	// 子系统按顺序启动，关闭时逆序停止
+	a.registerLifecycleHooks()
+	if err := a.lifecycle.Start(ctx); err != nil {
+		log.Warn("部分子系统启动失败: %v", err)
+	}
+}
+
+// registerLifecycleHooks 注册各子系统的启动与停止钩子
+// 后台协程以钩子收到的 ctx 为生命周期，停止时先取消 ctx 再等待退出
+func (a *App) registerLifecycleHooks() {
+	// 上下文缓存按存储时长计费，最后停止时删除
+	a.lifecycle.Register(lifecycle.Hook{
+		Name:  "context-cache",
+		Order: 0,
+		Stop: func(ctx context.Context) error {
+			if failed := adk.ContextCaches().Clear(ctx); failed > 0 {
+				return fmt.Errorf("%d 个上下文缓存删除失败", failed)
+			}
+			return nil
+		},
+		Timeout: 5 * time.Second,
+	})
+	// 绑定主 context，预创建 toolset
+	if a.mcpManager != nil {
+		a.lifecycle.Register(lifecycle.Hook{
+			Name:    "mcp",
+			Order:   10,
+			Start:   a.mcpManager.Initialize,
+			Timeout: 30 * time.Second,
+		})
+	}
+	// 记忆异步保存协程：停止时写完队列中的记忆
+	if a.memoryManager != nil {
+		a.lifecycle.Register(lifecycle.Hook{
+			Name:  "memory",
+			Order: 20,
+			Stop: func(context.Context) error {
+				a.memoryManager.Close()
+				return nil
+			},
+		})
+	}
 	if a.updateService != nil {
-		a.updateService.Startup(ctx)
+		a.lifecycle.Register(lifecycle.Hook{
+			Name:  "update",
+			Order: 30,
+			Start: func(ctx context.Context) error {
+				a.updateService.Startup(ctx)
+				return nil
+			},
+		})
 	}
+	a.lifecycle.Register(lifecycle.Hook{
+		Name:  "market-pusher",
+		Order: 40,
+		Start: func(ctx context.Context) error {
+			a.marketPusher = services.NewMarketDataPusher(a.marketService, a.configService, a.newsService)
+			a.marketPusher.SetRefreshInterval(time.Duration(a.settingsService.Get().RefreshInterval) * time.Second)
+			a.marketPusher.Start(ctx)
+			a.settingsService.OnChange(a.applyUISettings)
+			log.Info("市场数据推送服务已启动")
+			return nil
+		},
+		Stop: func(context.Context) error {
+			a.marketPusher.Stop()
+			return nil
+		},
+	})
+	// 定时任务：事件触发监控与自动分析、盘前预热、规则信号、证券主表、定时批量分析、周报
+	var scheduler sync.WaitGroup
+	a.lifecycle.Register(lifecycle.Hook{
+		Name:  "scheduler",
+		Order: 50,
+		Start: func(ctx context.Context) error {
+			for _, loop := range []func(context.Context){
+				a.triggerLoop, a.triggerWorker, a.warmCacheLoop, a.signalLoop,
+				a.instrumentLoop, a.batchLoop, a.digestLoop,
+			} {
+				scheduler.Add(1)
+				go func() {
+					defer scheduler.Done()
+					loop(ctx)
+				}()
+			}
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return waitGroup(ctx, &scheduler)
+		},
+	})
+	// 后台任务队列：恢复上次未完成的任务，停止时等待执行中的任务退出
+	var workers sync.WaitGroup
+	a.lifecycle.Register(lifecycle.Hook{
+		Name:  "job-queue",
+		Order: 60,
+		Start: func(ctx context.Context) error {
+			a.registerJobHandlers()
+			a.jobQueue.SetOnChange(func(job models.Job) {
+				runtime.EventsEmit(a.ctx, "job:update", job)
+			})
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				a.jobQueue.Run(ctx, jobWorkers)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			return waitGroup(ctx, &workers)
+		},
+	})
+	// 预热本地推理后端的模型
+	a.lifecycle.Register(lifecycle.Hook{
+		Name:  "local-models",
+		Order: 70,
+		Start: func(ctx context.Context) error {
+			go a.warmUpLocalModels(ctx)
+			return nil
+		},
+	})
+	// OpenClaw 服务（如果已启用）
+	a.lifecycle.Register(lifecycle.Hook{
+		Name:  "openclaw",
+		Order: 80,
+		Start: func(context.Context) error {
+			cfg := a.configService.GetConfig()
+			a.openClawServer.SetTokens(cfg.OpenClaw.Tokens)
+			// 启动失败不影响之后在设置中重启，停止钩子仍需执行
+			if cfg.OpenClaw.Enabled && cfg.OpenClaw.Port > 0 {
+				if err := a.openClawServer.Start(cfg.OpenClaw.Port, cfg.OpenClaw.APIKey); err != nil {
+					log.Warn("OpenClaw 启动失败: %v", err)
+				}
+			}
+			return nil
+		},
+		Stop: func(context.Context) error {
+			return a.openClawServer.Stop()
+		},
+	})
+}
 
-	// 初始化并启动市场数据推送服务（需要 context）
-	a.marketPusher = services.NewMarketDataPusher(a.marketService, a.configService, a.newsService)
-	a.marketPusher.Start(ctx)
-	log.Info("市场数据推送服务已启动")
+// waitGroup 等待协程退出，ctx 结束时放弃等待
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	// 启动 OpenClaw 服务（如果已启用）
-	cfg := a.configService.GetConfig()
-	if cfg.OpenClaw.Enabled && cfg.OpenClaw.Port > 0 {
-		if err := a.openClawServer.Start(cfg.OpenClaw.Port, cfg.OpenClaw.APIKey); err != nil {
-			log.Warn("OpenClaw 启动失败: %v", err)
+// GetSubsystemStatus 获取各子系统的启动状态
+func (a *App) GetSubsystemStatus() []lifecycle.Status {
+	return a.lifecycle.Statuses()
+}
+
+// warmUpLocalModels 预热开启了 WarmUp 的 AI 配置，避免首次对话等待本地模型加载
+func (a *App) warmUpLocalModels(ctx context.Context) {
+	factory := adk.NewModelFactory()
+	for _, cfg := range a.configService.GetConfig().AIConfigs {
+		if !cfg.WarmUp {
+			continue
+		}
+		warmCtx, cancel := context.WithTimeout(ctx, 3*time.Minute)
+		start := time.Now()
+		if err := factory.WarmUp(warmCtx, &cfg); err != nil {
+			log.Warn("模型预热失败 [%s]: %v", cfg.Name, err)
+		} else {
+			log.Info("模型预热完成 [%s] %s，耗时 %v", cfg.Name, cfg.ModelName, time.Since(start).Round(time.Millisecond))
 		}
+		cancel()
 	}
 }
 
-// shutdown 应用关闭时调用
+// shutdown 应用关闭时调用，逆序停止各子系统
 func (a *App) shutdown(ctx context.Context) {
 	log.Info("应用正在关闭...")
-	if a.openClawServer != nil {
-		a.openClawServer.Stop()
-	}
-	if a.marketPusher != nil {
-		a.marketPusher.Stop()
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	if err := a.lifecycle.Stop(stopCtx); err != nil {
+		log.Warn("部分子系统停止失败: %v", err)
 	}
 	logger.Close()
 }
@@ -246,9 +532,139 @@ func (a *App) GetConfig() *models.AppConfig {
 
 // UpdateConfig 更新配置
 func (a *App) UpdateConfig(config *models.AppConfig) string {
+	// 主题与涨跌颜色由界面设置服务校验并持久化
+	ui := a.settingsService.Get()
+	if config.Theme != "" {
+		ui.Theme = config.Theme
+	}
+	if config.CandleColorMode != "" {
+		ui.CandleColorMode = config.CandleColorMode
+	}
+	if _, err := services.ValidateUISettings(ui); err != nil {
+		return err.Error()
+	}
 	if err := a.configService.UpdateConfig(config); err != nil {
 		return err.Error()
 	}
+	if _, err := a.settingsService.Update(ui); err != nil {
+		return err.Error()
+	}
+	a.applyRuntimeConfig(config)
+	return "success"
+}
+
+// applyUISettings 界面设置生效：同步配置中的主题字段、调整行情刷新间隔并通知前端
+func (a *App) applyUISettings(settings models.UISettings) {
+	if err := a.configService.SyncUISettings(settings); err != nil {
+		log.Warn("同步界面设置到配置失败: %v", err)
+	}
+	if a.marketPusher != nil {
+		a.marketPusher.SetRefreshInterval(time.Duration(settings.RefreshInterval) * time.Second)
+	}
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, services.EventSettingsChanged, settings)
+	}
+}
+
+// GetUISettings 获取当前配置档的界面设置
+func (a *App) GetUISettings() models.UISettings {
+	return a.settingsService.Get()
+}
+
+// UpdateUISettings 修改当前配置档的界面设置，即时生效
+func (a *App) UpdateUISettings(settings models.UISettings) string {
+	if _, err := a.settingsService.Update(settings); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// GetUISettingsProfiles 获取全部界面设置配置档
+func (a *App) GetUISettingsProfiles() models.UISettingsProfiles {
+	return a.settingsService.Profiles()
+}
+
+// SwitchUISettingsProfile 切换界面设置配置档
+func (a *App) SwitchUISettingsProfile(name string) string {
+	if _, err := a.settingsService.SwitchProfile(name); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// SaveUISettingsProfile 将当前界面设置另存为配置档并切换过去
+func (a *App) SaveUISettingsProfile(name string) string {
+	if err := a.settingsService.SaveProfileAs(name); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// DeleteUISettingsProfile 删除界面设置配置档
+func (a *App) DeleteUISettingsProfile(name string) string {
+	if err := a.settingsService.DeleteProfile(name); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// HasConfigBackup 是否存在可回滚的上一版本配置
+func (a *App) HasConfigBackup() bool {
+	return a.configService.HasConfigBackup()
+}
+
+// RollbackConfig 一键回滚到上一版本配置
+func (a *App) RollbackConfig() string {
+	config, err := a.configService.RollbackConfig()
+	if err != nil {
+		return err.Error()
+	}
+	a.applyRuntimeConfig(config)
+	runtime.EventsEmit(a.ctx, "config:rollback", config)
+	return "success"
+}
+
+// GetRiskQuestionnaire 获取风险画像问卷
+func (a *App) GetRiskQuestionnaire() []models.RiskQuestion {
+	return models.RiskQuestionnaire
+}
+
+// SaveRiskProfile 保存用户风险画像，之后的专家分析会按画像给出建议
+func (a *App) SaveRiskProfile(profile models.RiskProfile) string {
+	if err := profile.Validate(); err != nil {
+		return err.Error()
+	}
+	config := *a.configService.GetConfig()
+	config.RiskProfile = profile
+	return a.UpdateConfig(&config)
+}
+
+// memoryDegradePolicy 将配置转换为记忆降级策略
+// describePosition 持仓描述（记忆提示词变量），未持仓时为空
+func describePosition(p *models.StockPosition) string {
+	if p == nil || p.Shares == 0 {
+		return ""
+	}
+	return fmt.Sprintf("持有 %d 股，成本价 %.2f", p.Shares, p.CostPrice)
+}
+
+func memoryDegradePolicy(cfg models.MemoryDegradeConfig) memory.DegradePolicy {
+	return memory.DegradePolicy{
+		Enabled:                cfg.Enabled,
+		DailyTokenBudget:       cfg.DailyTokenBudget,
+		SoftRatio:              cfg.SoftRatio,
+		HardRatio:              cfg.HardRatio,
+		RateLimitCooldown:      time.Duration(cfg.RateLimitCooldown) * time.Second,
+		CompressIntervalFactor: cfg.CompressIntervalFactor,
+	}
+}
+
+// applyRuntimeConfig 将配置变更应用到运行中的服务
+func (a *App) applyRuntimeConfig(config *models.AppConfig) {
+	if a.sessionService != nil {
+		a.sessionService.SetStripThinking(config.Storage.StripThinking)
+	}
+
 	// 重新加载 MCP 配置
 	if a.mcpManager != nil && config.MCPServers != nil {
 		if err := a.mcpManager.LoadConfigs(config.MCPServers); err != nil {
@@ -257,6 +673,17 @@ func (a *App) UpdateConfig(config *models.AppConfig) string {
 	}
 	// 更新代理配置
 	proxy.GetManager().SetConfig(&config.Proxy)
+	// 更新敏感信息脱敏配置
+	redact.GetManager().SetConfig(&config.Redaction)
+	// 更新模型请求抓包开关
+	adk.SetDebugCapture(debugCaptureDir(), config.DebugCapture)
+	// 更新记忆降级策略
+	if a.memoryManager != nil {
+		a.memoryManager.SetDegradePolicy(memoryDegradePolicy(config.Memory.Degrade))
+		if err := a.memoryManager.SetPromptTemplates(services.MemoryPromptTemplates(config.Memory.Prompts)); err != nil {
+			log.Warn("记忆提示词无效，沿用原设置: %v", err)
+		}
+	}
 	// 更新记忆管理器的 LLM 配置
 	if a.meetingService != nil && config.Memory.AIConfigID != "" {
 		for i := range config.AIConfigs {
@@ -275,9 +702,13 @@ func (a *App) UpdateConfig(config *models.AppConfig) string {
 			}
 		}
 	}
+	// 更新子代理委派配置与风险画像
+	if a.meetingService != nil {
+		a.meetingService.SetDelegateConfig(config.Delegate)
+		a.meetingService.SetRiskProfile(config.RiskProfile)
+	}
 	// 更新 OpenClaw 服务配置（热更新）
 	a.applyOpenClawConfig(&config.OpenClaw)
-	return "success"
 }
 
 // applyOpenClawConfig 应用 OpenClaw 配置变更
@@ -292,6 +723,7 @@ func (a *App) applyOpenClawConfig(cfg *models.OpenClawConfig) {
 	if cfg.Port <= 0 {
 		return
 	}
+	a.openClawServer.SetTokens(cfg.Tokens)
 	// 端口或密钥变更时重启
 	if a.openClawServer.IsRunning() {
 		if a.openClawServer.GetPort() != cfg.Port {
@@ -313,6 +745,14 @@ func (a *App) GetOpenClawStatus() map[string]any {
 	}
 }
 
+// GetOpenClawAudit 获取 OpenClaw 最近的外部调用审计记录
+func (a *App) GetOpenClawAudit(limit int) []models.OpenClawAuditEntry {
+	if a.openClawServer == nil {
+		return nil
+	}
+	return a.openClawServer.GetAuditLog(limit)
+}
+
 // mergeRealtimeStock 合并实时行情字段，保留本地静态字段
 func (a *App) mergeRealtimeStock(base models.Stock, rt models.Stock) models.Stock {
 	merged := base
@@ -446,6 +886,19 @@ func (a *App) getAIConfigByID(aiConfigID string) *models.AIConfig {
 	return a.getDefaultAIConfig(config)
 }
 
+// sessionAIConfig 返回会话使用的 AI 配置：已固定且仍存在时使用固定配置，否则使用默认配置
+func (a *App) sessionAIConfig(stockCode string, config *models.AppConfig) *models.AIConfig {
+	if pinned := a.sessionService.GetAIConfigID(stockCode); pinned != "" {
+		for i := range config.AIConfigs {
+			if config.AIConfigs[i].ID == pinned {
+				return &config.AIConfigs[i]
+			}
+		}
+		log.Warn("会话 %s 固定的 AI 配置 %s 已不存在，使用默认配置", stockCode, pinned)
+	}
+	return a.getDefaultAIConfig(config)
+}
+
 // ========== Session API ==========
 
 // GetOrCreateSession 获取或创建Session
@@ -453,6 +906,9 @@ func (a *App) GetOrCreateSession(stockCode, stockName string) *models.StockSessi
 	if a.sessionService == nil {
 		return nil
 	}
+	if stockName == "" {
+		stockName = a.instrumentService.Name(stockCode)
+	}
 	session, _ := a.sessionService.GetOrCreateSession(stockCode, stockName)
 	return session
 }
@@ -482,156 +938,502 @@ func (a *App) ClearSessionMessages(stockCode string) string {
 	return "success"
 }
 
-// UpdateStockPosition 更新股票持仓信息
-func (a *App) UpdateStockPosition(stockCode string, shares int64, costPrice float64) string {
-	if a.sessionService == nil {
-		return "service not ready"
+// inactiveSessionDays 批量清理时默认的无活动天数
+const inactiveSessionDays = 90
+
+// DeleteInactiveSessions 批量删除无持仓且 days 天内无活动的会话（days<=0 时为 90 天）
+// dryRun 为 true 时只返回将被删除的会话
+func (a *App) DeleteInactiveSessions(days int, dryRun bool) models.BulkReport {
+	if days <= 0 {
+		days = inactiveSessionDays
 	}
-	if err := a.sessionService.UpdatePosition(stockCode, shares, costPrice); err != nil {
-		return err.Error()
+	report, err := a.sessionService.DeleteInactiveSessions(time.Now().AddDate(0, 0, -days), dryRun)
+	if err != nil {
+		report.Error = err.Error()
+		return report
 	}
-	return "success"
-}
-
-// ========== Agent Config API ==========
-
-// GetAgentConfigs 获取所有已启用的Agent配置
-func (a *App) GetAgentConfigs() []models.AgentConfig {
-	return a.strategyService.GetEnabledAgents()
+	if dryRun {
+		return report
+	}
+	for _, item := range report.Items {
+		if item.Error != "" {
+			continue
+		}
+		a.cancelMeetingInternal(item.StockCode)
+		if a.memoryManager != nil {
+			if err := a.memoryManager.DeleteMemory(item.StockCode); err != nil {
+				log.Warn("delete memory %s error: %v", item.StockCode, err)
+			}
+		}
+	}
+	log.Info("批量删除无活动会话 %d 个", len(report.Items))
+	return report
 }
 
-// AddAgentConfig 添加Agent配置到当前策略
-func (a *App) AddAgentConfig(config models.AgentConfig) string {
-	agent := models.StrategyAgent{
-		ID:          config.ID,
-		Name:        config.Name,
-		Role:        config.Role,
-		Avatar:      config.Avatar,
-		Color:       config.Color,
-		Instruction: config.Instruction,
-		Tools:       config.Tools,
-		MCPServers:  config.MCPServers,
-		Enabled:     config.Enabled,
+// ClearMessagesBefore 批量清除所有会话中早于 before（毫秒时间戳）的消息
+// dryRun 为 true 时只统计将被清除的消息数
+func (a *App) ClearMessagesBefore(before int64, dryRun bool) models.BulkReport {
+	if before <= 0 {
+		return models.BulkReport{Action: models.BulkClearOldMessages, DryRun: dryRun, Error: "请指定截止日期"}
 	}
-	if err := a.strategyService.AddAgentToActiveStrategy(agent); err != nil {
-		return err.Error()
+	report, err := a.sessionService.ClearMessagesBefore(time.UnixMilli(before), dryRun)
+	if err != nil {
+		report.Error = err.Error()
 	}
-	a.agentContainer.LoadAgents(a.strategyService.GetAllAgents())
-	return "success"
+	return report
 }
 
-// UpdateAgentConfig 更新当前策略中的Agent配置
-func (a *App) UpdateAgentConfig(config models.AgentConfig) string {
-	agent := models.StrategyAgent{
-		ID:          config.ID,
-		Name:        config.Name,
-		Role:        config.Role,
-		Avatar:      config.Avatar,
-		Color:       config.Color,
-		Instruction: config.Instruction,
-		Tools:       config.Tools,
-		MCPServers:  config.MCPServers,
-		Enabled:     config.Enabled,
+// CompressAllMemories 对所有股票记忆重新执行压缩
+// dryRun 为 true 时只统计待压缩的轮次
+func (a *App) CompressAllMemories(dryRun bool) models.BulkReport {
+	report := models.BulkReport{Action: models.BulkCompressMemories, DryRun: dryRun, Items: []models.BulkItem{}}
+	if a.memoryManager == nil {
+		report.Error = "记忆功能未启用"
+		return report
 	}
-	if err := a.strategyService.UpdateAgentInActiveStrategy(agent); err != nil {
-		return err.Error()
+	results, err := a.memoryManager.CompressAll(a.ctx, dryRun)
+	if err != nil {
+		report.Error = err.Error()
 	}
-	a.agentContainer.LoadAgents(a.strategyService.GetAllAgents())
-	return "success"
+	for _, r := range results {
+		item := models.BulkItem{StockCode: r.StockCode, StockName: r.StockName, Count: r.Rounds}
+		if r.Err != nil {
+			item.Error = r.Err.Error()
+		}
+		report.Items = append(report.Items, item)
+		report.Total += r.Rounds
+	}
+	return report
 }
 
-// DeleteAgentConfig 从当前策略删除Agent配置
-func (a *App) DeleteAgentConfig(id string) string {
-	if err := a.strategyService.DeleteAgentFromActiveStrategy(id); err != nil {
+// CreateCheckpoint 为当前会话创建检查点（消息 + 记忆快照）
+func (a *App) CreateCheckpoint(stockCode string, name string) string {
+	var memSnapshot json.RawMessage
+	if a.memoryManager != nil {
+		snapshot, err := a.memoryManager.Snapshot(stockCode)
+		if err != nil {
+			return err.Error()
+		}
+		memSnapshot = snapshot
+	}
+	if _, err := a.checkpointService.Create(stockCode, name, a.sessionService.GetMessages(stockCode), memSnapshot); err != nil {
 		return err.Error()
 	}
-	a.agentContainer.LoadAgents(a.strategyService.GetAllAgents())
 	return "success"
 }
 
-// ========== Strategy API ==========
-
-// GetStrategies 获取所有策略
-func (a *App) GetStrategies() []models.Strategy {
-	return a.strategyService.GetAllStrategies()
+// GetCheckpoints 获取会话检查点列表
+func (a *App) GetCheckpoints(stockCode string) []models.CheckpointInfo {
+	return a.checkpointService.List(stockCode)
 }
 
-// GetActiveStrategyID 获取当前激活策略ID
-func (a *App) GetActiveStrategyID() string {
-	return a.strategyService.GetActiveID()
-}
+// RollbackToCheckpoint 回滚会话到指定检查点，进行中的会议会被取消
+func (a *App) RollbackToCheckpoint(stockCode string, checkpointID string) string {
+	cp, err := a.checkpointService.Get(stockCode, checkpointID)
+	if err != nil {
+		return err.Error()
+	}
+	a.cancelMeetingInternal(stockCode)
+	a.meetingService.CancelInterruptedMeeting(stockCode)
 
-// SetActiveStrategy 设置当前激活策略
-func (a *App) SetActiveStrategy(id string) string {
-	if err := a.strategyService.SetActiveStrategy(id); err != nil {
+	if err := a.sessionService.ReplaceMessages(stockCode, cp.Messages); err != nil {
 		return err.Error()
 	}
-	// 重新加载Agent容器
-	a.agentContainer.LoadAgents(a.strategyService.GetAllAgents())
-	// 通知前端策略已切换
-	runtime.EventsEmit(a.ctx, "strategy:changed", id)
+	if a.memoryManager != nil {
+		if err := a.memoryManager.Restore(stockCode, cp.Memory); err != nil {
+			return err.Error()
+		}
+	}
+	runtime.EventsEmit(a.ctx, "session:rollback:"+stockCode, cp.ID)
 	return "success"
 }
 
-// AddStrategy 添加策略
-func (a *App) AddStrategy(strategy models.Strategy) string {
-	if err := a.strategyService.AddStrategy(strategy); err != nil {
+// DeleteCheckpoint 删除会话检查点
+func (a *App) DeleteCheckpoint(stockCode string, checkpointID string) string {
+	if err := a.checkpointService.Delete(stockCode, checkpointID); err != nil {
 		return err.Error()
 	}
 	return "success"
 }
 
-// UpdateStrategy 更新策略
-func (a *App) UpdateStrategy(strategy models.Strategy) string {
-	if err := a.strategyService.UpdateStrategy(strategy); err != nil {
-		return err.Error()
+// GetTurnTraces 查询专家发言执行轨迹（最新在前），agentID 为空时不过滤
+func (a *App) GetTurnTraces(stockCode string, agentID string, limit int) []models.TurnTrace {
+	traces, err := a.traceService.Query(services.TraceQuery{StockCode: stockCode, AgentID: agentID, Limit: limit})
+	if err != nil {
+		log.Warn("query traces error: %v", err)
+		return []models.TurnTrace{}
 	}
-	return "success"
+	return traces
 }
 
-// DeleteStrategy 删除策略
-func (a *App) DeleteStrategy(id string) string {
-	if err := a.strategyService.DeleteStrategy(id); err != nil {
-		return err.Error()
+// GetToolStats 工具调用统计（次数、耗时、错误率、结果 token 开销）
+// stockCode 为空时统计全部股票，days <= 0 时统计全部历史
+func (a *App) GetToolStats(stockCode string, days int) []models.ToolStats {
+	var since int64
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days).UnixMilli()
 	}
-	return "success"
+	stats, err := a.traceService.ToolStats(stockCode, since)
+	if err != nil {
+		log.Warn("tool stats error: %v", err)
+		return []models.ToolStats{}
+	}
+	return stats
 }
 
-// GenerateStrategyRequest AI生成策略请求
-type GenerateStrategyRequest struct {
-	Prompt string `json:"prompt"`
+// GetTurnTrace 获取单条执行轨迹
+func (a *App) GetTurnTrace(stockCode string, traceID string) *models.TurnTrace {
+	trace, err := a.traceService.Get(stockCode, traceID)
+	if err != nil {
+		return nil
+	}
+	return trace
 }
 
-// GenerateStrategyResponse AI生成策略响应
-type GenerateStrategyResponse struct {
-	Success   bool            `json:"success"`
-	Error     string          `json:"error,omitempty"`
-	Strategy  models.Strategy `json:"strategy,omitempty"`
-	Reasoning string          `json:"reasoning,omitempty"`
+// GetSessionCost 获取会话（股票）的累计用量与费用
+func (a *App) GetSessionCost(stockCode string) models.CostTotals {
+	return a.costService.GetSessionCost(stockCode)
 }
 
-// GenerateStrategy AI生成策略
-func (a *App) GenerateStrategy(req GenerateStrategyRequest) GenerateStrategyResponse {
-	// 获取策略生成AI配置（优先使用 StrategyAIID，否则使用默认）
-	config := a.configService.GetConfig()
-	var aiConfig *models.AIConfig
-	targetAIID := config.StrategyAIID
-	if targetAIID == "" {
-		targetAIID = config.DefaultAIID
-	}
-	for i := range config.AIConfigs {
-		if config.AIConfigs[i].ID == targetAIID {
-			aiConfig = &config.AIConfigs[i]
+// GetCostSummary 获取按会话与 AI 配置汇总的累计费用
+func (a *App) GetCostSummary() models.CostSummary {
+	return a.costService.Summary()
+}
+
+// ReplayMessage 重放历史专家消息：用当时保存的请求快照重新调用模型，并与原回答逐行对比
+// aiConfigID 为空时使用会话当前的 AI 配置，可指定其他配置对比不同模型
+func (a *App) ReplayMessage(stockCode, messageID, aiConfigID string) models.ReplayResult {
+	result := models.ReplayResult{MessageID: messageID}
+	var msg *models.ChatMessage
+	for _, m := range a.sessionService.GetMessages(stockCode) {
+		if m.ID == messageID {
+			msg = &m
 			break
 		}
 	}
-	if aiConfig == nil && len(config.AIConfigs) > 0 {
-		aiConfig = &config.AIConfigs[0]
+	if msg == nil {
+		result.Error = "消息不存在"
+		return result
 	}
-	if aiConfig == nil {
-		return GenerateStrategyResponse{Success: false, Error: "未配置AI服务"}
+	result.TraceID = msg.TraceID
+	result.PromptVersion = msg.PromptVersion
+	result.Original = msg.Content
+	if msg.Attribution != nil {
+		result.OriginalModel = msg.Attribution.Model
+	} else if trace, err := a.traceService.Get(stockCode, msg.TraceID); err == nil {
+		result.OriginalModel = trace.Model
 	}
-
+	request, err := a.traceService.GetRequest(msg.TraceID)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	aiConfig := a.sessionAIConfig(stockCode, a.configService.GetConfig())
+	if aiConfigID != "" {
+		aiConfig = a.getAIConfigByID(aiConfigID)
+	}
+	if aiConfig == nil {
+		result.Error = "未配置 AI 模型"
+		return result
+	}
+	result.Model = aiConfig.ModelName
+
+	start := time.Now()
+	replayed, err := a.meetingService.Replay(a.ctx, aiConfig, request)
+	result.DurationMs = time.Since(start).Milliseconds()
+	result.Replayed = replayed
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Diff = textdiff.Lines(result.Original, result.Replayed)
+	return result
+}
+
+// recordTrace 持久化响应的执行轨迹，返回轨迹 ID
+func (a *App) recordTrace(stockCode string, resp meeting.ChatResponse) string {
+	if resp.Trace == nil {
+		return ""
+	}
+	resp.Trace.StockCode = stockCode
+	var aiConfig *models.AIConfig
+	if cfg := a.getAIConfigByID(resp.Trace.AIConfigID); cfg != nil && cfg.ID == resp.Trace.AIConfigID {
+		aiConfig = cfg
+	}
+	resp.Trace.Cost = a.costService.Calculate(aiConfig, resp.Trace)
+	if err := a.costService.Record(resp.Trace, resp.Trace.Cost); err != nil {
+		log.Warn("record cost error: %v", err)
+	}
+	if err := a.traceService.Append(resp.Trace); err != nil {
+		log.Warn("record trace error: %v", err)
+		return ""
+	}
+	return resp.Trace.ID
+}
+
+// promptVersionFor 返回会话使用的提示词版本：已固定时使用固定版本，否则使用默认版本
+func (a *App) promptVersionFor(stockCode string) string {
+	return prompts.Resolve(a.sessionService.GetPromptVersion(stockCode))
+}
+
+// sessionContext 返回带有股票会话 conversation 映射的 context
+// 启用 Conversations API 的配置会把历史保存在服务端并按会话复用
+func (a *App) sessionContext(stockCode string) context.Context {
+	return openai.WithConversationStore(a.ctx, a.sessionService.ConversationStore(stockCode))
+}
+
+// GetPromptVersions 列出内置提示词的所有版本
+func (a *App) GetPromptVersions() []prompts.VersionInfo {
+	return prompts.Versions()
+}
+
+// PinSessionPromptVersion 将会话固定到指定提示词版本，version 为空时取消固定
+func (a *App) PinSessionPromptVersion(stockCode, version string) string {
+	if version != "" && !prompts.Exists(version) {
+		return "提示词版本不存在: " + version
+	}
+	if err := a.sessionService.SetPromptVersion(stockCode, version); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// PinSessionAIConfig 将会话固定到指定 AI 配置，不再跟随默认配置变化；aiConfigID 为空时取消固定
+func (a *App) PinSessionAIConfig(stockCode, aiConfigID string) string {
+	if aiConfigID != "" {
+		found := false
+		for _, c := range a.configService.GetConfig().AIConfigs {
+			if c.ID == aiConfigID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "AI 配置不存在: " + aiConfigID
+		}
+	}
+	if err := a.sessionService.SetAIConfigID(stockCode, aiConfigID); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// UpdateStockPosition 更新股票持仓信息
+func (a *App) UpdateStockPosition(stockCode string, shares int64, costPrice float64) string {
+	if a.sessionService == nil {
+		return "service not ready"
+	}
+	if err := a.sessionService.UpdatePosition(stockCode, shares, costPrice); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// SetStockPositionCurrency 设置持仓的交易币种（CNY/HKD/USD），为空时按股票代码推断
+func (a *App) SetStockPositionCurrency(stockCode, currency string) string {
+	if a.sessionService == nil {
+		return "service not ready"
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	switch currency {
+	case "", models.CurrencyCNY, models.CurrencyHKD, models.CurrencyUSD:
+	default:
+		return "不支持的币种: " + currency
+	}
+	if err := a.sessionService.SetPositionCurrency(stockCode, currency); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// GetPortfolio 汇总所有会话的持仓，港股、美股按汇率折算为本位币（默认人民币）
+func (a *App) GetPortfolio() models.Portfolio {
+	config := a.configService.GetConfig()
+	rates := a.fxService.Rates()
+	codes, err := a.sessionService.ListSessionCodes()
+	if err != nil {
+		log.Warn("list sessions error: %v", err)
+	}
+
+	var positions []models.PortfolioPosition
+	var quoteCodes []string
+	for _, code := range codes {
+		session := a.sessionService.GetSession(code)
+		if session == nil || session.Position == nil || session.Position.Shares <= 0 {
+			continue
+		}
+		positions = append(positions, models.PortfolioPosition{
+			StockCode: code,
+			StockName: session.StockName,
+			Currency:  session.Position.Currency,
+			Shares:    session.Position.Shares,
+			CostPrice: session.Position.CostPrice,
+		})
+		quoteCodes = append(quoteCodes, code)
+	}
+	if len(quoteCodes) > 0 {
+		stocks, err := a.marketService.GetStockRealTimeData(quoteCodes...)
+		if err != nil {
+			log.Warn("portfolio quotes error: %v", err)
+		}
+		prices := make(map[string]float64, len(stocks))
+		for _, st := range stocks {
+			prices[st.Symbol] = st.Price
+		}
+		for i := range positions {
+			positions[i].Price = prices[positions[i].StockCode]
+		}
+	}
+	return services.BuildPortfolio(positions, config.BaseCurrency, rates)
+}
+
+// ========== Agent Config API ==========
+
+// GetAgentConfigs 获取所有已启用的Agent配置
+func (a *App) GetAgentConfigs() []models.AgentConfig {
+	return a.strategyService.GetEnabledAgents()
+}
+
+// AddAgentConfig 添加Agent配置到当前策略
+func (a *App) AddAgentConfig(config models.AgentConfig) string {
+	agent := models.StrategyAgent{
+		ID:             config.ID,
+		Name:           config.Name,
+		Role:           config.Role,
+		Avatar:         config.Avatar,
+		Color:          config.Color,
+		Instruction:    config.Instruction,
+		Tools:          config.Tools,
+		MCPServers:     config.MCPServers,
+		Enabled:        config.Enabled,
+		ResponseSchema: config.ResponseSchema,
+	}
+	if config.ResponseSchema != "" && !json.Valid([]byte(config.ResponseSchema)) {
+		return "输出 JSON Schema 格式无效"
+	}
+	if err := a.strategyService.AddAgentToActiveStrategy(agent); err != nil {
+		return err.Error()
+	}
+	a.agentContainer.LoadAgents(a.strategyService.GetAllAgents())
+	return "success"
+}
+
+// UpdateAgentConfig 更新当前策略中的Agent配置
+func (a *App) UpdateAgentConfig(config models.AgentConfig) string {
+	agent := models.StrategyAgent{
+		ID:             config.ID,
+		Name:           config.Name,
+		Role:           config.Role,
+		Avatar:         config.Avatar,
+		Color:          config.Color,
+		Instruction:    config.Instruction,
+		Tools:          config.Tools,
+		MCPServers:     config.MCPServers,
+		Enabled:        config.Enabled,
+		ResponseSchema: config.ResponseSchema,
+	}
+	if config.ResponseSchema != "" && !json.Valid([]byte(config.ResponseSchema)) {
+		return "输出 JSON Schema 格式无效"
+	}
+	if err := a.strategyService.UpdateAgentInActiveStrategy(agent); err != nil {
+		return err.Error()
+	}
+	a.agentContainer.LoadAgents(a.strategyService.GetAllAgents())
+	return "success"
+}
+
+// DeleteAgentConfig 从当前策略删除Agent配置
+func (a *App) DeleteAgentConfig(id string) string {
+	if err := a.strategyService.DeleteAgentFromActiveStrategy(id); err != nil {
+		return err.Error()
+	}
+	a.agentContainer.LoadAgents(a.strategyService.GetAllAgents())
+	return "success"
+}
+
+// ========== Strategy API ==========
+
+// GetStrategies 获取所有策略
+func (a *App) GetStrategies() []models.Strategy {
+	return a.strategyService.GetAllStrategies()
+}
+
+// GetActiveStrategyID 获取当前激活策略ID
+func (a *App) GetActiveStrategyID() string {
+	return a.strategyService.GetActiveID()
+}
+
+// SetActiveStrategy 设置当前激活策略
+func (a *App) SetActiveStrategy(id string) string {
+	if err := a.strategyService.SetActiveStrategy(id); err != nil {
+		return err.Error()
+	}
+	// 重新加载Agent容器
+	a.agentContainer.LoadAgents(a.strategyService.GetAllAgents())
+	// 通知前端策略已切换
+	runtime.EventsEmit(a.ctx, "strategy:changed", id)
+	return "success"
+}
+
+// AddStrategy 添加策略
+func (a *App) AddStrategy(strategy models.Strategy) string {
+	if err := a.strategyService.AddStrategy(strategy); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// UpdateStrategy 更新策略
+func (a *App) UpdateStrategy(strategy models.Strategy) string {
+	if err := a.strategyService.UpdateStrategy(strategy); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// DeleteStrategy 删除策略
+func (a *App) DeleteStrategy(id string) string {
+	if err := a.strategyService.DeleteStrategy(id); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// GenerateStrategyRequest AI生成策略请求
+type GenerateStrategyRequest struct {
+	Prompt string `json:"prompt"`
+}
+
+// GenerateStrategyResponse AI生成策略响应
+type GenerateStrategyResponse struct {
+	Success   bool            `json:"success"`
+	Error     string          `json:"error,omitempty"`
+	Strategy  models.Strategy `json:"strategy,omitempty"`
+	Reasoning string          `json:"reasoning,omitempty"`
+}
+
+// GenerateStrategy AI生成策略
+func (a *App) GenerateStrategy(req GenerateStrategyRequest) GenerateStrategyResponse {
+	// 获取策略生成AI配置（优先使用 StrategyAIID，否则使用默认）
+	config := a.configService.GetConfig()
+	var aiConfig *models.AIConfig
+	targetAIID := config.StrategyAIID
+	if targetAIID == "" {
+		targetAIID = config.DefaultAIID
+	}
+	for i := range config.AIConfigs {
+		if config.AIConfigs[i].ID == targetAIID {
+			aiConfig = &config.AIConfigs[i]
+			break
+		}
+	}
+	if aiConfig == nil && len(config.AIConfigs) > 0 {
+		aiConfig = &config.AIConfigs[0]
+	}
+	if aiConfig == nil {
+		return GenerateStrategyResponse{Success: false, Error: "未配置AI服务"}
+	}
+
 	// 创建LLM
 	ctx := context.Background()
 	factory := adk.NewModelFactory()
@@ -756,11 +1558,14 @@ func (a *App) EnhancePrompt(req EnhancePromptRequest) EnhancePromptResponse {
 
 // MeetingMessageRequest 会议室消息请求
 type MeetingMessageRequest struct {
-	StockCode    string   `json:"stockCode"`
-	Content      string   `json:"content"`
-	MentionIds   []string `json:"mentionIds"`
-	ReplyToId    string   `json:"replyToId"`
-	ReplyContent string   `json:"replyContent"`
+	StockCode    string             `json:"stockCode"`
+	Content      string             `json:"content"`
+	MentionIds   []string           `json:"mentionIds"`
+	ReplyToId    string             `json:"replyToId"`
+	ReplyContent string             `json:"replyContent"`
+	Images       []models.ChatImage `json:"images,omitempty"` // 附带的图片（如 K 线截图）
+	// 幂等键（前端每次提交生成，重试时复用），相同键的重复提交不会重复保存消息与生成回复
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
 }
 
 // cancelMeetingInternal 内部取消会议方法
@@ -782,6 +1587,39 @@ func (a *App) CancelMeeting(stockCode string) bool {
 
 // SendMeetingMessage 发送会议室消息（@指定成员回复）
 func (a *App) SendMeetingMessage(req MeetingMessageRequest) []models.ChatMessage {
+	if req.IdempotencyKey == "" {
+		// 前端未提供幂等键：执行中或刚完成的相同内容提交视为界面重试，复用首次结果
+		msgs, shared := a.meetingDedupe.Do(meetingContentKey(req), func() []models.ChatMessage {
+			return a.sendMeetingMessage(req)
+		})
+		if shared {
+			log.Info("重复提交的会议消息（内容相同），复用首次结果: %s", req.StockCode)
+		}
+		return msgs
+	}
+	msgs, shared := a.meetingIdem.Do(req.StockCode+":"+req.IdempotencyKey, func() []models.ChatMessage {
+		return a.sendMeetingMessage(req)
+	})
+	if shared {
+		log.Info("重复提交的会议消息，复用首次结果: %s %s", req.StockCode, req.IdempotencyKey)
+	}
+	return msgs
+}
+
+// meetingContentDedupeWindow 按内容合并重复会议消息的时间窗口（从首次提交完成起算）
+const meetingContentDedupeWindow = 10 * time.Second
+
+// meetingContentKey 由股票、内容、回复对象、@专家与图片派生幂等键
+func meetingContentKey(req MeetingMessageRequest) string {
+	parts := []string{req.StockCode, req.Content, req.ReplyToId, strings.Join(req.MentionIds, ",")}
+	for _, img := range req.Images {
+		parts = append(parts, img.MimeType, img.Data)
+	}
+	return idempotency.ContentKey(parts...)
+}
+
+// sendMeetingMessage 发送会议室消息
+func (a *App) sendMeetingMessage(req MeetingMessageRequest) []models.ChatMessage {
 	// 获取Session
 	session := a.sessionService.GetSession(req.StockCode)
 	if session == nil {
@@ -789,15 +1627,31 @@ func (a *App) SendMeetingMessage(req MeetingMessageRequest) []models.ChatMessage
 		return []models.ChatMessage{}
 	}
 
+	// 已保存过相同幂等键的消息（如重启后重试），不再重复生成
+	if a.sessionService.HasIdempotencyKey(req.StockCode, req.IdempotencyKey) {
+		log.Warn("重复提交的会议消息已忽略: %s %s", req.StockCode, req.IdempotencyKey)
+		return []models.ChatMessage{}
+	}
+
 	// 取消之前该股票的会议（如果有）
 	a.cancelMeetingInternal(req.StockCode)
 
-	// 创建可取消的 context
-	meetingCtx, cancel := context.WithCancel(a.ctx)
+	// 创建可取消的 context，并带上会话使用的提示词版本
+	meetingCtx, cancel := context.WithCancel(prompts.WithVersion(a.sessionContext(req.StockCode), a.promptVersionFor(req.StockCode)))
 	a.meetingCancelsMu.Lock()
 	a.meetingCancels[req.StockCode] = cancel
 	a.meetingCancelsMu.Unlock()
 
+	// 附带的图片随提问发送给专家
+	if len(req.Images) > 0 {
+		parts, err := meeting.ImageParts(req.Images)
+		if err != nil {
+			log.Warn("解析会议图片失败: %v", err)
+		} else {
+			meetingCtx = meeting.WithImages(meetingCtx, parts)
+		}
+	}
+
 	// 会议结束后清理
 	defer func() {
 		a.meetingCancelsMu.Lock()
@@ -807,13 +1661,16 @@ func (a *App) SendMeetingMessage(req MeetingMessageRequest) []models.ChatMessage
 
 	// 先保存用户消息
 	userMsg := models.ChatMessage{
-		AgentID:   "user",
-		AgentName: "老韭菜",
-		Content:   req.Content,
-		ReplyTo:   req.ReplyToId,
-		Mentions:  req.MentionIds,
+		AgentID:        "user",
+		AgentName:      "老韭菜",
+		Content:        req.Content,
+		ReplyTo:        req.ReplyToId,
+		Mentions:       req.MentionIds,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+	if err := a.sessionService.AddMessage(req.StockCode, userMsg); errors.Is(err, services.ErrDuplicateMessage) {
+		return []models.ChatMessage{}
 	}
-	a.sessionService.AddMessage(req.StockCode, userMsg)
 
 	// 获取股票数据
 	stocks, _ := a.marketService.GetStockRealTimeData(req.StockCode)
@@ -822,9 +1679,9 @@ func (a *App) SendMeetingMessage(req MeetingMessageRequest) []models.ChatMessage
 		stock = stocks[0]
 	}
 
-	// 获取默认AI配置
+	// 获取 AI 配置（会话固定了配置时使用固定配置）
 	config := a.configService.GetConfig()
-	aiConfig := a.getDefaultAIConfig(config)
+	aiConfig := a.sessionAIConfig(req.StockCode, config)
 	if aiConfig == nil {
 		log.Warn("no AI config found")
 		return []models.ChatMessage{}
@@ -854,18 +1711,27 @@ func (a *App) runSmartMeeting(ctx context.Context, stockCode string, stock model
 	}
 
 	// 响应回调：每次发言完成后推送
+	promptVersion := prompts.FromContext(ctx)
 	respCallback := func(resp meeting.ChatResponse) {
 		msg := models.ChatMessage{
-			AgentID:     resp.AgentID,
-			AgentName:   resp.AgentName,
-			Role:        resp.Role,
-			Content:     resp.Content,
-			Round:       resp.Round,
-			MsgType:     resp.MsgType,
-			Error:       resp.Error,
-			MeetingMode: resp.MeetingMode,
-		}
-		a.sessionService.AddMessage(stockCode, msg)
+			AgentID:       resp.AgentID,
+			AgentName:     resp.AgentName,
+			Role:          resp.Role,
+			Content:       resp.Content,
+			Round:         resp.Round,
+			MsgType:       resp.MsgType,
+			Error:         resp.Error,
+			MeetingMode:   resp.MeetingMode,
+			Thinking:      resp.Thinking,
+			Sources:       resp.Sources,
+			Metadata:      resp.Metadata,
+			Partial:       resp.Partial,
+			TraceID:       a.recordTrace(stockCode, resp),
+			Cost:          resp.Cost(),
+			Attribution:   resp.Attribution(),
+			PromptVersion: promptVersion,
+		}
+		a.saveAgentMessage(stockCode, &msg)
 		runtime.EventsEmit(a.ctx, "meeting:message:"+stockCode, msg)
 	}
 
@@ -884,14 +1750,22 @@ func (a *App) runSmartMeeting(ctx context.Context, stockCode string, stock model
 	var messages []models.ChatMessage
 	for _, resp := range responses {
 		messages = append(messages, models.ChatMessage{
-			AgentID:     resp.AgentID,
-			AgentName:   resp.AgentName,
-			Role:        resp.Role,
-			Content:     resp.Content,
-			Round:       resp.Round,
-			MsgType:     resp.MsgType,
-			Error:       resp.Error,
-			MeetingMode: resp.MeetingMode,
+			AgentID:       resp.AgentID,
+			AgentName:     resp.AgentName,
+			Role:          resp.Role,
+			Content:       resp.Content,
+			Round:         resp.Round,
+			MsgType:       resp.MsgType,
+			Error:         resp.Error,
+			MeetingMode:   resp.MeetingMode,
+			Thinking:      resp.Thinking,
+			Sources:       resp.Sources,
+			Metadata:      resp.Metadata,
+			Partial:       resp.Partial,
+			TraceID:       resp.TraceID(),
+			Cost:          resp.Cost(),
+			Attribution:   resp.Attribution(),
+			PromptVersion: promptVersion,
 		})
 	}
 	return messages
@@ -919,26 +1793,34 @@ func (a *App) runDirectMeeting(ctx context.Context, req MeetingMessageRequest, s
 	}
 
 	// 转换并保存响应，同时推送事件
-	return a.convertSaveAndEmitResponses(req.StockCode, responses, req.ReplyToId)
+	return a.convertSaveAndEmitResponses(req.StockCode, responses, req.ReplyToId, prompts.FromContext(ctx))
 }
 
 // convertSaveAndEmitResponses 转换响应、保存并推送事件（统一体验）
-func (a *App) convertSaveAndEmitResponses(stockCode string, responses []meeting.ChatResponse, replyTo string) []models.ChatMessage {
+func (a *App) convertSaveAndEmitResponses(stockCode string, responses []meeting.ChatResponse, replyTo string, promptVersion string) []models.ChatMessage {
 	var messages []models.ChatMessage
 	for _, resp := range responses {
 		msg := models.ChatMessage{
-			AgentID:     resp.AgentID,
-			AgentName:   resp.AgentName,
-			Role:        resp.Role,
-			Content:     resp.Content,
-			ReplyTo:     replyTo,
-			Round:       resp.Round,
-			MsgType:     resp.MsgType,
-			Error:       resp.Error,
-			MeetingMode: resp.MeetingMode,
+			AgentID:       resp.AgentID,
+			AgentName:     resp.AgentName,
+			Role:          resp.Role,
+			Content:       resp.Content,
+			ReplyTo:       replyTo,
+			Round:         resp.Round,
+			MsgType:       resp.MsgType,
+			Error:         resp.Error,
+			MeetingMode:   resp.MeetingMode,
+			Thinking:      resp.Thinking,
+			Sources:       resp.Sources,
+			Metadata:      resp.Metadata,
+			Partial:       resp.Partial,
+			TraceID:       a.recordTrace(stockCode, resp),
+			Cost:          resp.Cost(),
+			Attribution:   resp.Attribution(),
+			PromptVersion: promptVersion,
 		}
 		// 保存单条消息
-		a.sessionService.AddMessage(stockCode, msg)
+		a.saveAgentMessage(stockCode, &msg)
 		// 推送事件（与智能模式一致）
 		runtime.EventsEmit(a.ctx, "meeting:message:"+stockCode, msg)
 		messages = append(messages, msg)
@@ -946,59 +1828,257 @@ func (a *App) convertSaveAndEmitResponses(stockCode string, responses []meeting.
 	return messages
 }
 
-// RetryAgent 重试单个失败的专家（前端手动触发）
-func (a *App) RetryAgent(stockCode string, agentId string, query string) models.ChatMessage {
-	// 获取股票数据
-	stocks, _ := a.marketService.GetStockRealTimeData(stockCode)
-	var stock models.Stock
-	if len(stocks) > 0 {
-		stock = stocks[0]
-	}
-
-	// 获取 AI 配置
-	config := a.configService.GetConfig()
-	aiConfig := a.getDefaultAIConfig(config)
-	if aiConfig == nil {
-		log.Warn("RetryAgent: no AI config")
-		return models.ChatMessage{AgentID: agentId, Error: "未配置 AI 服务"}
-	}
+// ========== Pipeline API ==========
 
-	// 获取专家配置
-	agents := a.strategyService.GetAgentsByIDs([]string{agentId})
-	if len(agents) == 0 {
-		log.Warn("RetryAgent: agent not found: %s", agentId)
-		return models.ChatMessage{AgentID: agentId, Error: "专家不存在"}
-	}
-	agentCfg := agents[0]
+// GetPipelines 获取已加载的声明式流水线
+func (a *App) GetPipelines() []models.Pipeline {
+	return a.pipelineService.List()
+}
 
-	position := a.sessionService.GetPosition(stockCode)
+// ReloadPipelines 重新加载流水线目录，返回加载失败的文件及原因
+func (a *App) ReloadPipelines() map[string]string {
+	a.pipelineService.Reload()
+	return a.pipelineService.LoadErrors()
+}
 
-	// 进度回调
-	progressCallback := func(event meeting.ProgressEvent) {
-		runtime.EventsEmit(a.ctx, "meeting:progress:"+stockCode, event)
+// RunPipeline 对指定股票执行声明式流水线，各步骤结果实时推送并保存
+func (a *App) RunPipeline(stockCode string, pipelineID string, query string) []models.ChatMessage {
+	pipeline, ok := a.pipelineService.Get(pipelineID)
+	if !ok {
+		return []models.ChatMessage{{Error: "流水线不存在: " + pipelineID}}
 	}
 
-	resp, err := a.meetingService.RetrySingleAgent(a.ctx, aiConfig, &agentCfg, &stock, query, progressCallback, position)
-
-	msg := models.ChatMessage{
-		AgentID:     resp.AgentID,
-		AgentName:   resp.AgentName,
-		Role:        resp.Role,
-		Content:     resp.Content,
-		Round:       resp.Round,
-		MsgType:     resp.MsgType,
-		Error:       resp.Error,
-		MeetingMode: resp.MeetingMode,
+	config := a.configService.GetConfig()
+	aiConfig := a.sessionAIConfig(stockCode, config)
+	if aiConfig == nil {
+		return []models.ChatMessage{{Error: "未配置 AI 服务"}}
 	}
 
-	if err != nil {
+	a.cancelMeetingInternal(stockCode)
+	promptVersion := a.promptVersionFor(stockCode)
+	meetingCtx, cancel := context.WithCancel(prompts.WithVersion(a.sessionContext(stockCode), promptVersion))
+	a.meetingCancelsMu.Lock()
+	a.meetingCancels[stockCode] = cancel
+	a.meetingCancelsMu.Unlock()
+	defer func() {
+		a.meetingCancelsMu.Lock()
+		delete(a.meetingCancels, stockCode)
+		a.meetingCancelsMu.Unlock()
+	}()
+
+	a.sessionService.AddMessage(stockCode, models.ChatMessage{
+		AgentID:   "user",
+		AgentName: "老韭菜",
+		Content:   query,
+	})
+
+	stocks, _ := a.marketService.GetStockRealTimeData(stockCode)
+	var stock models.Stock
+	if len(stocks) > 0 {
+		stock = stocks[0]
+	}
+	position := a.sessionService.GetPosition(stockCode)
+
+	var messages []models.ChatMessage
+	respCallback := func(resp meeting.ChatResponse) {
+		messages = append(messages, a.convertSaveAndEmitResponses(stockCode, []meeting.ChatResponse{resp}, "", promptVersion)...)
+	}
+	progressCallback := func(event meeting.ProgressEvent) {
+		runtime.EventsEmit(a.ctx, "meeting:progress:"+stockCode, event)
+	}
+
+	if _, err := a.meetingService.RunPipeline(meetingCtx, aiConfig, pipeline, &stock, query, respCallback, progressCallback, position); err != nil {
+		log.Warn("RunPipeline %s failed: %v", pipelineID, err)
+	}
+	return messages
+}
+
+// RetryAgent 重试单个失败的专家（前端手动触发）
+func (a *App) RetryAgent(stockCode string, agentId string, query string) models.ChatMessage {
+	// 获取股票数据
+	stocks, _ := a.marketService.GetStockRealTimeData(stockCode)
+	var stock models.Stock
+	if len(stocks) > 0 {
+		stock = stocks[0]
+	}
+
+	// 获取 AI 配置
+	config := a.configService.GetConfig()
+	aiConfig := a.sessionAIConfig(stockCode, config)
+	if aiConfig == nil {
+		log.Warn("RetryAgent: no AI config")
+		return models.ChatMessage{AgentID: agentId, Error: "未配置 AI 服务"}
+	}
+
+	// 获取专家配置
+	agents := a.strategyService.GetAgentsByIDs([]string{agentId})
+	if len(agents) == 0 {
+		log.Warn("RetryAgent: agent not found: %s", agentId)
+		return models.ChatMessage{AgentID: agentId, Error: "专家不存在"}
+	}
+	agentCfg := agents[0]
+
+	position := a.sessionService.GetPosition(stockCode)
+
+	// 进度回调
+	progressCallback := func(event meeting.ProgressEvent) {
+		runtime.EventsEmit(a.ctx, "meeting:progress:"+stockCode, event)
+	}
+
+	promptVersion := a.promptVersionFor(stockCode)
+	ctx := prompts.WithVersion(a.sessionContext(stockCode), promptVersion)
+	resp, err := a.meetingService.RetrySingleAgent(ctx, aiConfig, &agentCfg, &stock, query, progressCallback, position)
+
+	msg := models.ChatMessage{
+		AgentID:       resp.AgentID,
+		AgentName:     resp.AgentName,
+		Role:          resp.Role,
+		Content:       resp.Content,
+		Round:         resp.Round,
+		MsgType:       resp.MsgType,
+		Error:         resp.Error,
+		MeetingMode:   resp.MeetingMode,
+		Thinking:      resp.Thinking,
+		Sources:       resp.Sources,
+		Metadata:      resp.Metadata,
+		Partial:       resp.Partial,
+		TraceID:       a.recordTrace(stockCode, resp),
+		Cost:          resp.Cost(),
+		Attribution:   resp.Attribution(),
+		PromptVersion: promptVersion,
+	}
+
+	if err != nil {
 		log.Error("RetryAgent failed: %v", err)
 		runtime.EventsEmit(a.ctx, "meeting:message:"+stockCode, msg)
 		return msg
 	}
 
 	// 成功：保存并推送
-	a.sessionService.AddMessage(stockCode, msg)
+	a.saveAgentMessage(stockCode, &msg)
+	runtime.EventsEmit(a.ctx, "meeting:message:"+stockCode, msg)
+	return msg
+}
+
+// DryRunAgent 试运行专家发言：组装将要发送的完整请求并估算 token 与费用，不调用模型、不保存消息
+// 用于调整提示词模板与 token 预算；费用按输出上限估算，为上界
+func (a *App) DryRunAgent(stockCode string, agentId string, query string) models.DryRunResult {
+	result := models.DryRunResult{AgentID: agentId}
+	aiConfig := a.sessionAIConfig(stockCode, a.configService.GetConfig())
+	if aiConfig == nil {
+		result.Error = "未配置 AI 服务"
+		return result
+	}
+	agents := a.strategyService.GetAgentsByIDs([]string{agentId})
+	if len(agents) == 0 {
+		result.Error = "专家不存在"
+		return result
+	}
+	agentCfg := agents[0]
+
+	stocks, _ := a.marketService.GetStockRealTimeData(stockCode)
+	var stock models.Stock
+	if len(stocks) > 0 {
+		stock = stocks[0]
+	}
+	position := a.sessionService.GetPosition(stockCode)
+
+	ctx := prompts.WithVersion(a.sessionContext(stockCode), a.promptVersionFor(stockCode))
+	result, err := a.meetingService.DryRunAgent(ctx, aiConfig, &agentCfg, &stock, query, position)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	// 价格按专家实际使用的 AI 配置（自定义单价优先）
+	priceConfig := aiConfig
+	if cfg := a.getAIConfigByID(agentCfg.AIConfigID); cfg != nil && agentCfg.AIConfigID != "" && cfg.ID == agentCfg.AIConfigID {
+		priceConfig = cfg
+	}
+	result.Cost = a.costService.Calculate(priceConfig, &models.TurnTrace{
+		Model:            result.Model,
+		PromptTokens:     result.InputTokens,
+		CompletionTokens: result.OutputTokens,
+		TotalTokens:      result.InputTokens + result.OutputTokens,
+	})
+	return result
+}
+
+// ResumeAgentMessage 续写因流式中断而未完成的专家发言
+// 成功后原地替换该消息并推送
+func (a *App) ResumeAgentMessage(stockCode string, messageID string, query string) models.ChatMessage {
+	var original *models.ChatMessage
+	for _, m := range a.sessionService.GetMessages(stockCode) {
+		if m.ID == messageID {
+			original = &m
+			break
+		}
+	}
+	if original == nil {
+		return models.ChatMessage{ID: messageID, Error: "消息不存在"}
+	}
+	if !original.Partial {
+		return models.ChatMessage{ID: messageID, Error: "该消息没有可续写的内容"}
+	}
+
+	stocks, _ := a.marketService.GetStockRealTimeData(stockCode)
+	var stock models.Stock
+	if len(stocks) > 0 {
+		stock = stocks[0]
+	}
+
+	config := a.configService.GetConfig()
+	aiConfig := a.sessionAIConfig(stockCode, config)
+	if aiConfig == nil {
+		return models.ChatMessage{ID: messageID, AgentID: original.AgentID, Error: "未配置 AI 服务"}
+	}
+
+	agents := a.strategyService.GetAgentsByIDs([]string{original.AgentID})
+	if len(agents) == 0 {
+		return models.ChatMessage{ID: messageID, AgentID: original.AgentID, Error: "专家不存在"}
+	}
+	agentCfg := agents[0]
+
+	var responseID string
+	if original.Metadata != nil {
+		responseID = original.Metadata.ResponseID
+	}
+
+	progressCallback := func(event meeting.ProgressEvent) {
+		runtime.EventsEmit(a.ctx, "meeting:progress:"+stockCode, event)
+	}
+	position := a.sessionService.GetPosition(stockCode)
+
+	// 续写沿用原消息的提示词版本，保证前后语气一致
+	promptVersion := original.PromptVersion
+	if promptVersion == "" {
+		promptVersion = a.promptVersionFor(stockCode)
+	}
+	ctx := prompts.WithVersion(a.sessionContext(stockCode), promptVersion)
+	resp, err := a.meetingService.ResumeSingleAgent(ctx, aiConfig, &agentCfg, &stock, query,
+		original.Content, responseID, progressCallback, position)
+
+	msg := *original
+	msg.Content = resp.Content
+	msg.Error = resp.Error
+	msg.Partial = resp.Partial
+	msg.Thinking = resp.Thinking
+	msg.PromptVersion = prompts.Resolve(promptVersion)
+	msg.Sources = append(msg.Sources, resp.Sources...)
+	if resp.Metadata != nil {
+		msg.Metadata = resp.Metadata
+	}
+	if traceID := a.recordTrace(stockCode, resp); traceID != "" {
+		msg.TraceID = traceID
+		msg.Cost = resp.Cost()
+		msg.Attribution = resp.Attribution()
+	}
+	if err != nil {
+		log.Error("ResumeAgentMessage failed: %v", err)
+	}
+
+	if updateErr := a.sessionService.UpdateMessage(stockCode, msg); updateErr != nil {
+		log.Warn("ResumeAgentMessage: update message failed: %v", updateErr)
+	}
 	runtime.EventsEmit(a.ctx, "meeting:message:"+stockCode, msg)
 	return msg
 }
@@ -1010,8 +2090,8 @@ func (a *App) RetryAgentAndContinue(stockCode string) []models.ChatMessage {
 		return []models.ChatMessage{}
 	}
 
-	// 创建可取消的 context
-	meetingCtx, cancel := context.WithCancel(a.ctx)
+	// 创建可取消的 context，并带上会话使用的提示词版本
+	meetingCtx, cancel := context.WithCancel(prompts.WithVersion(a.sessionContext(stockCode), a.promptVersionFor(stockCode)))
 	a.meetingCancelsMu.Lock()
 	a.meetingCancels[stockCode] = cancel
 	a.meetingCancelsMu.Unlock()
@@ -1023,18 +2103,27 @@ func (a *App) RetryAgentAndContinue(stockCode string) []models.ChatMessage {
 	}()
 
 	// 响应回调
+	promptVersion := prompts.FromContext(meetingCtx)
 	respCallback := func(resp meeting.ChatResponse) {
 		msg := models.ChatMessage{
-			AgentID:     resp.AgentID,
-			AgentName:   resp.AgentName,
-			Role:        resp.Role,
-			Content:     resp.Content,
-			Round:       resp.Round,
-			MsgType:     resp.MsgType,
-			Error:       resp.Error,
-			MeetingMode: resp.MeetingMode,
-		}
-		a.sessionService.AddMessage(stockCode, msg)
+			AgentID:       resp.AgentID,
+			AgentName:     resp.AgentName,
+			Role:          resp.Role,
+			Content:       resp.Content,
+			Round:         resp.Round,
+			MsgType:       resp.MsgType,
+			Error:         resp.Error,
+			MeetingMode:   resp.MeetingMode,
+			Thinking:      resp.Thinking,
+			Sources:       resp.Sources,
+			Metadata:      resp.Metadata,
+			Partial:       resp.Partial,
+			TraceID:       a.recordTrace(stockCode, resp),
+			Cost:          resp.Cost(),
+			Attribution:   resp.Attribution(),
+			PromptVersion: promptVersion,
+		}
+		a.saveAgentMessage(stockCode, &msg)
 		runtime.EventsEmit(a.ctx, "meeting:message:"+stockCode, msg)
 	}
 
@@ -1052,14 +2141,22 @@ func (a *App) RetryAgentAndContinue(stockCode string) []models.ChatMessage {
 	var messages []models.ChatMessage
 	for _, resp := range responses {
 		messages = append(messages, models.ChatMessage{
-			AgentID:     resp.AgentID,
-			AgentName:   resp.AgentName,
-			Role:        resp.Role,
-			Content:     resp.Content,
-			Round:       resp.Round,
-			MsgType:     resp.MsgType,
-			Error:       resp.Error,
-			MeetingMode: resp.MeetingMode,
+			AgentID:       resp.AgentID,
+			AgentName:     resp.AgentName,
+			Role:          resp.Role,
+			Content:       resp.Content,
+			Round:         resp.Round,
+			MsgType:       resp.MsgType,
+			Error:         resp.Error,
+			MeetingMode:   resp.MeetingMode,
+			Thinking:      resp.Thinking,
+			Sources:       resp.Sources,
+			Metadata:      resp.Metadata,
+			Partial:       resp.Partial,
+			TraceID:       resp.TraceID(),
+			Cost:          resp.Cost(),
+			Attribution:   resp.Attribution(),
+			PromptVersion: promptVersion,
 		})
 	}
 	return messages
@@ -1166,6 +2263,65 @@ func (a *App) TestMCPConnection(serverID string) *mcp.ServerStatus {
 	return a.mcpManager.TestConnection(serverID)
 }
 
+// GetAIProviders 获取支持的 AI 供应商列表
+func (a *App) GetAIProviders() []adk.ProviderInfo {
+	return adk.SupportedProviders()
+}
+
+// CheckAIConnection 检查 AI 配置连通性（不保存配置）
+// 返回耗时、模型可用性与归一化错误，供设置界面保存前校验
+func (a *App) CheckAIConnection(config models.AIConfig) models.ConnectionCheckResult {
+	result := adk.NewModelFactory().CheckConnection(context.Background(), &config)
+	if !result.OK {
+		log.Warn("AI 连接检查失败 [%s]: %s (%s)", config.Name, result.ErrorCode, result.Detail)
+	}
+	return result
+}
+
+// ListAIModels 查询配置对应供应商的可用模型列表（不保存配置）
+// 返回模型名称、上下文窗口与能力标签，供设置界面下拉选择模型
+func (a *App) ListAIModels(config models.AIConfig) models.ModelCatalogResult {
+	list, err := a.modelCatalog.ListModels(context.Background(), &config)
+	if err != nil {
+		log.Warn("获取模型列表失败 [%s]: %v", config.Name, err)
+		return models.ModelCatalogResult{Models: []models.ModelInfo{}, Error: err.Error()}
+	}
+	return models.ModelCatalogResult{Models: list}
+}
+
+// GetContextCaches 列出当前有效的 Gemini 上下文缓存
+func (a *App) GetContextCaches() []models.ContextCacheInfo {
+	return adk.ContextCaches().List()
+}
+
+// ClearContextCaches 删除全部 Gemini 上下文缓存
+func (a *App) ClearContextCaches() string {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if failed := adk.ContextCaches().Clear(ctx); failed > 0 {
+		return fmt.Sprintf("%d 个缓存删除失败，将在过期后自动清除", failed)
+	}
+	return "success"
+}
+
+// debugCaptureDir 模型请求抓包目录，按会话（股票代码）分子目录
+func debugCaptureDir() string {
+	return filepath.Join(paths.GetDataDir(), "debug")
+}
+
+// GetDebugCaptureDir 返回抓包目录，用户可将其中的 JSONL 附在问题反馈中
+func (a *App) GetDebugCaptureDir() string {
+	return debugCaptureDir()
+}
+
+// ClearDebugCaptures 删除全部抓包记录
+func (a *App) ClearDebugCaptures() string {
+	if err := adk.ClearDebugCaptures(); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
 // TestAIConnection 测试 AI 配置连通性
 // 连接成功后自动检测是否支持 system role，并持久化结果
 func (a *App) TestAIConnection(config models.AIConfig) string {
@@ -1225,6 +2381,196 @@ func (a *App) WindowClose() {
 	runtime.Quit(a.ctx)
 }
 
+// ========== Job Queue API ==========
+
+// jobWorkers 后台任务并发数
+const jobWorkers = 2
+
+// batchSubmitPayload 定时批量分析任务参数
+type batchSubmitPayload struct {
+	StockCodes []string `json:"stockCodes"`
+	Query      string   `json:"query"`
+	AIConfigID string   `json:"aiConfigId"`
+}
+
+// registerJobHandlers 注册依赖 App 的任务处理函数（文档入库由 DocumentService 自行注册）
+func (a *App) registerJobHandlers() {
+	a.jobQueue.Register(models.JobKindBatchSubmit, func(ctx context.Context, payload json.RawMessage) error {
+		var p batchSubmitPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return err
+		}
+		if job := a.submitBatch(p.StockCodes, p.Query, p.AIConfigID, true); job.Error != "" {
+			return errors.New(job.Error)
+		}
+		return nil
+	})
+	a.jobQueue.Register(models.JobKindDigest, func(ctx context.Context, payload json.RawMessage) error {
+		a.GenerateWeeklyDigest()
+		return nil
+	})
+}
+
+// GetJobs 获取后台任务列表（最新的在前）
+func (a *App) GetJobs() []models.Job {
+	return a.jobQueue.List()
+}
+
+// GetJobStats 获取各状态的后台任务数量
+func (a *App) GetJobStats() models.JobStats {
+	return a.jobQueue.Stats()
+}
+
+// RetryJob 重新执行失败或已取消的任务
+func (a *App) RetryJob(id string) string {
+	if err := a.jobQueue.Retry(id); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// CancelJob 取消等待中的任务
+func (a *App) CancelJob(id string) string {
+	if err := a.jobQueue.Cancel(id); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// ClearFinishedJobs 清除已结束的任务记录，返回清除数量
+func (a *App) ClearFinishedJobs() int {
+	return a.jobQueue.ClearFinished()
+}
+
+// ========== Data Directory API ==========
+
+// GetDataDirInfo 获取数据目录信息
+func (a *App) GetDataDirInfo() models.DataDirInfo {
+	current := paths.GetDataDir()
+	info := models.DataDirInfo{
+		Current: current,
+		Default: paths.DefaultDataDir(),
+		Custom:  paths.CustomDataDir(),
+	}
+	recorded := info.Custom
+	if recorded == "" {
+		recorded = info.Default
+	}
+	info.Warning = paths.DataDirWarning()
+	// 自定义目录不可用时回退到默认目录，此时重启并不能解决问题
+	info.Restart = info.Warning == "" && filepath.Clean(recorded) != filepath.Clean(current)
+	info.Files, info.Bytes = services.DataDirUsage(current)
+	return info
+}
+
+// SelectDataDir 打开目录选择框，返回选中的目录（取消时为空）
+func (a *App) SelectDataDir() string {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "选择新的数据目录",
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		log.Warn("选择数据目录失败: %v", err)
+		return ""
+	}
+	return dir
+}
+
+// CheckDataDirTarget 校验迁移目标目录，可用时返回 "success"
+func (a *App) CheckDataDirTarget(target string) string {
+	if err := services.CheckDataDirTarget(paths.GetDataDir(), target); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// MigrateDataDir 把当前数据复制到目标目录，校验通过后切换数据目录，重启应用后生效
+// 迁移过程通过 "datadir:progress" 事件推送进度
+func (a *App) MigrateDataDir(target string) models.DataDirMigration {
+	if !a.dataDirMu.TryLock() {
+		return models.DataDirMigration{To: target, Error: "已有迁移正在进行"}
+	}
+	defer a.dataDirMu.Unlock()
+
+	src := paths.GetDataDir()
+	result, err := services.MigrateDataDir(src, target, func(done, total int) {
+		runtime.EventsEmit(a.ctx, "datadir:progress", map[string]int{"done": done, "total": total})
+	})
+	if err != nil {
+		log.Error("数据目录迁移失败: %v", err)
+		return models.DataDirMigration{From: src, To: target, Error: err.Error()}
+	}
+	return *result
+}
+
+// ResetDataDir 清除自定义数据目录记录，重启后恢复使用默认目录（不复制数据）
+func (a *App) ResetDataDir() string {
+	if err := paths.SetCustomDataDir(""); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// ========== Research Bundle API ==========
+
+// researchBundleFilter 研究快照文件类型
+var researchBundleFilter = []runtime.FileFilter{{DisplayName: "研究快照 (*.jcpbundle)", Pattern: "*.jcpbundle"}}
+
+// SelectResearchBundleFile 弹出文件对话框：save 为 true 时选择导出位置，否则选择要打开的快照，取消时返回空字符串
+func (a *App) SelectResearchBundleFile(save bool) string {
+	var (
+		path string
+		err  error
+	)
+	if save {
+		path, err = runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+			Title:           "导出研究快照",
+			DefaultFilename: "jcp-research-" + time.Now().Format("20060102") + ".jcpbundle",
+			Filters:         researchBundleFilter,
+		})
+	} else {
+		path, err = runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+			Title:   "打开研究快照",
+			Filters: researchBundleFilter,
+		})
+	}
+	if err != nil {
+		log.Warn("选择研究快照文件失败: %v", err)
+		return ""
+	}
+	return path
+}
+
+// ExportResearchBundle 导出加密的研究快照（会话与持仓、笔记、产物、记忆、去除密钥的配置等）
+func (a *App) ExportResearchBundle(path, passphrase string) models.ResearchBundleInfo {
+	a.dataDirMu.Lock()
+	defer a.dataDirMu.Unlock()
+	cfg := a.configService.GetConfig()
+	info, err := services.ExportResearchBundle(paths.GetDataDir(), cfg, path, passphrase)
+	if err != nil {
+		info.Error = err.Error()
+	}
+	return info
+}
+
+// VerifyResearchBundle 校验研究快照的口令与完整性，返回快照概要
+func (a *App) VerifyResearchBundle(path, passphrase string) models.ResearchBundleInfo {
+	info, err := services.VerifyResearchBundle(path, passphrase)
+	if err != nil {
+		info.Error = err.Error()
+	}
+	return info
+}
+
+// ExtractResearchBundle 校验通过后把研究快照解包到指定目录（只读副本，不影响当前数据）
+func (a *App) ExtractResearchBundle(path, passphrase, target string) models.ResearchBundleInfo {
+	info, err := services.ExtractResearchBundle(path, passphrase, target)
+	if err != nil {
+		info.Error = err.Error()
+	}
+	return info
+}
+
 // ========== HotTrend API ==========
 
 // GetHotTrendPlatforms 获取支持的热点平台列表
@@ -1341,3 +2687,874 @@ func (a *App) NotifyFrontendReady() {
 		a.marketPusher.SetReady()
 	}
 }
+
+// ========== Trigger API ==========
+
+// triggerCheckInterval 触发器检测间隔
+const triggerCheckInterval = time.Minute
+
+// triggerLoop 定时评估事件触发器
+func (a *App) triggerLoop(ctx context.Context) {
+	ticker := time.NewTicker(triggerCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.evaluateTriggers()
+		}
+	}
+}
+
+// warmCacheLoop 每分钟检查一次，交易日到达预热时间后为自选股预热行情（每天一次，启动时已过预热时间也会补做）
+func (a *App) warmCacheLoop(ctx context.Context) {
+	lastDate := ""
+	check := func() {
+		today, due := a.marketService.WarmCacheDue(a.configService.GetConfig().WarmCache, time.Now(), lastDate)
+		if !due {
+			return
+		}
+		lastDate = today
+		a.WarmMarketCache()
+	}
+	check()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// WarmMarketCache 立即为自选股预热行情与K线快照
+func (a *App) WarmMarketCache() models.WarmCacheReport {
+	watchlist := a.configService.GetWatchlist()
+	codes := make([]string, len(watchlist))
+	for i, s := range watchlist {
+		codes[i] = s.Symbol
+	}
+	return a.marketService.WarmCache(codes)
+}
+
+// signalCheckInterval 规则信号计算间隔
+const signalCheckInterval = 5 * time.Minute
+
+// signalLoop 定期在自选股日K上计算规则信号；非交易时段每天只计算一次
+func (a *App) signalLoop(ctx context.Context) {
+	lastDate := ""
+	check := func() {
+		today := time.Now().Format("2006-01-02")
+		if a.marketService.GetMarketStatus().Status != "trading" && lastDate == today {
+			return
+		}
+		lastDate = today
+		a.evaluateSignals()
+	}
+	check()
+	ticker := time.NewTicker(signalCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// evaluateSignals 计算自选股规则信号，新出现的信号推送到前端
+func (a *App) evaluateSignals() {
+	var fresh []models.Signal
+	for _, stock := range a.configService.GetWatchlist() {
+		klines, err := a.marketService.GetKLineData(stock.Symbol, "1d", services.SignalKLineDays)
+		if err != nil {
+			log.Warn("规则信号获取K线失败 [%s]: %v", stock.Symbol, err)
+			continue
+		}
+		fresh = append(fresh, a.signalService.Refresh(stock, klines)...)
+	}
+	if len(fresh) > 0 {
+		runtime.EventsEmit(a.ctx, "signal:fired", fresh)
+	}
+}
+
+// GetSignals 获取个股最近一次计算的规则信号
+func (a *App) GetSignals(stockCode string) []models.Signal {
+	return a.signalService.GetSignals(stockCode)
+}
+
+// instrumentCheckInterval 证券主表刷新检查间隔（实际每周刷新一次）
+const instrumentCheckInterval = 6 * time.Hour
+
+// instrumentLoop 定期检查证券主表是否到期，到期后刷新并归档退市证券的会话
+func (a *App) instrumentLoop(ctx context.Context) {
+	check := func() {
+		if a.instrumentService.RefreshDue(time.Now()) {
+			a.RefreshInstruments()
+		}
+	}
+	check()
+	ticker := time.NewTicker(instrumentCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// RefreshInstruments 立即刷新证券主表，并归档已退市证券的会话
+func (a *App) RefreshInstruments() models.InstrumentRefreshReport {
+	report, err := a.instrumentService.Refresh()
+	if err != nil {
+		log.Warn("刷新证券主表失败: %v", err)
+		report.Error = err.Error()
+		return report
+	}
+	codes, err := a.sessionService.ListSessionCodes()
+	if err != nil {
+		return report
+	}
+	for _, code := range codes {
+		if !a.instrumentService.IsDelisted(code) {
+			continue
+		}
+		if err := a.sessionService.ArchiveSession(code); err != nil {
+			log.Warn("归档退市会话失败 [%s]: %v", code, err)
+			continue
+		}
+		report.Archived = append(report.Archived, code)
+	}
+	if len(report.Archived) > 0 {
+		log.Info("已归档退市证券会话: %v", report.Archived)
+		runtime.EventsEmit(a.ctx, "instrument:delisted", report.Archived)
+	}
+	return report
+}
+
+// GetInstrument 查询证券基础信息（名称、行业、上市日期、上市状态）
+func (a *App) GetInstrument(code string) *models.Instrument {
+	inst, ok := a.instrumentService.Get(code)
+	if !ok {
+		return nil
+	}
+	return &inst
+}
+
+// evaluateTriggers 拉取自选股行情和快讯，评估触发器
+func (a *App) evaluateTriggers() {
+	if a.triggerService == nil || len(a.triggerService.GetTriggers()) == 0 {
+		return
+	}
+	watchlist := a.configService.GetWatchlist()
+	if len(watchlist) == 0 {
+		return
+	}
+	codes := make([]string, len(watchlist))
+	for i, s := range watchlist {
+		codes[i] = s.Symbol
+	}
+	stocks, err := a.marketService.GetStockRealTimeData(codes...)
+	if err != nil {
+		log.Warn("触发器获取行情失败: %v", err)
+		return
+	}
+	telegraphs, _ := a.newsService.GetTelegraphList()
+	for _, task := range a.triggerService.Evaluate(stocks, telegraphs) {
+		log.Info("触发自动分析: %s %s (%s)", task.TriggerName, task.StockCode, task.Reason)
+		runtime.EventsEmit(a.ctx, "trigger:fired", task)
+	}
+}
+
+// triggerWorker 顺序执行自动分析任务
+func (a *App) triggerWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case task := <-a.triggerService.Queue():
+			a.runTriggeredAnalysis(ctx, task)
+		}
+	}
+}
+
+// runTriggeredAnalysis 执行单个自动分析任务
+func (a *App) runTriggeredAnalysis(ctx context.Context, task models.TriggeredAnalysis) {
+	if _, err := a.sessionService.GetOrCreateSession(task.StockCode, task.StockName); err != nil {
+		log.Error("自动分析创建Session失败: %v", err)
+		return
+	}
+	config := a.configService.GetConfig()
+	aiConfig := a.sessionAIConfig(task.StockCode, config)
+	if aiConfig == nil {
+		log.Warn("自动分析跳过：未配置AI服务")
+		return
+	}
+
+	userMsg := models.ChatMessage{
+		AgentID:   "user",
+		AgentName: "自动触发",
+		Content:   task.Query,
+	}
+	a.sessionService.AddMessage(task.StockCode, userMsg)
+	runtime.EventsEmit(a.ctx, "meeting:message:"+task.StockCode, userMsg)
+
+	stocks, _ := a.marketService.GetStockRealTimeData(task.StockCode)
+	var stock models.Stock
+	if len(stocks) > 0 {
+		stock = stocks[0]
+	}
+	position := a.sessionService.GetPosition(task.StockCode)
+	a.runSmartMeeting(ctx, task.StockCode, stock, task.Query, aiConfig, position)
+}
+
+// GetAnalysisTriggers 获取所有事件触发器
+func (a *App) GetAnalysisTriggers() []models.AnalysisTrigger {
+	if a.triggerService == nil {
+		return []models.AnalysisTrigger{}
+	}
+	return a.triggerService.GetTriggers()
+}
+
+// AddAnalysisTrigger 添加事件触发器
+func (a *App) AddAnalysisTrigger(trigger models.AnalysisTrigger) string {
+	if err := a.triggerService.AddTrigger(trigger); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// UpdateAnalysisTrigger 更新事件触发器
+func (a *App) UpdateAnalysisTrigger(trigger models.AnalysisTrigger) string {
+	if err := a.triggerService.UpdateTrigger(trigger); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// DeleteAnalysisTrigger 删除事件触发器
+func (a *App) DeleteAnalysisTrigger(id string) string {
+	if err := a.triggerService.DeleteTrigger(id); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// GetTriggerDailyCap 获取每日自动分析上限
+func (a *App) GetTriggerDailyCap() int {
+	return a.triggerService.GetDailyCap()
+}
+
+// SetTriggerDailyCap 设置每日自动分析上限
+func (a *App) SetTriggerDailyCap(cap int) string {
+	if err := a.triggerService.SetDailyCap(cap); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// ========== Batch API ==========
+
+// batchPollInterval 批量任务轮询间隔
+const batchPollInterval = 5 * time.Minute
+
+// batchLoop 到达定时时间后提交自选股批量分析，并定期轮询未完成的任务
+func (a *App) batchLoop(ctx context.Context) {
+	lastDate := ""
+	check := func() {
+		cfg := a.configService.GetConfig().Batch
+		if today, due := a.marketService.DailyTaskDue(cfg.Enabled, cfg.Time, "15:30", time.Now(), lastDate); due {
+			lastDate = today
+			watchlist := a.configService.GetWatchlist()
+			codes := make([]string, len(watchlist))
+			for i, st := range watchlist {
+				codes[i] = st.Symbol
+			}
+			payload := batchSubmitPayload{StockCodes: codes, Query: cfg.Query, AIConfigID: cfg.AIConfigID}
+			if _, err := a.jobQueue.Enqueue(models.JobKindBatchSubmit, "定时批量分析 "+today, "batch:"+today, payload, 0); err != nil {
+				log.Warn("定时批量分析任务保存失败: %v", err)
+			}
+		}
+		a.pollBatchJobs(ctx)
+	}
+	check()
+	ticker := time.NewTicker(batchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// SubmitBatchAnalysis 通过 OpenAI Batch API 提交多只股票的分析，结果在任务完成后写回各股票会话
+// aiConfigID 为空时使用批量配置中的 AI 配置，仍为空则使用默认配置
+func (a *App) SubmitBatchAnalysis(stockCodes []string, query string, aiConfigID string) models.BatchJob {
+	if aiConfigID == "" {
+		aiConfigID = a.configService.GetConfig().Batch.AIConfigID
+	}
+	return a.submitBatch(stockCodes, query, aiConfigID, false)
+}
+
+// submitBatch 构建请求并提交批量任务，提交失败时返回带 Error 的任务记录
+func (a *App) submitBatch(stockCodes []string, query, aiConfigID string, scheduled bool) models.BatchJob {
+	job := models.BatchJob{Query: query, StockCodes: stockCodes, Scheduled: scheduled, Status: "failed"}
+	if len(stockCodes) == 0 {
+		job.Error = "没有需要分析的股票"
+		return job
+	}
+	aiConfig := a.getAIConfigByID(aiConfigID)
+	if aiConfig == nil {
+		job.Error = "未配置 AI 模型"
+		return job
+	}
+	job.AIConfigID, job.Model = aiConfig.ID, aiConfig.ModelName
+	client, err := adk.NewModelFactory().CreateBatchClient(aiConfig)
+	if err != nil {
+		job.Error = err.Error()
+		return job
+	}
+
+	stocks, err := a.marketService.GetStockRealTimeData(stockCodes...)
+	if err != nil {
+		log.Warn("批量分析获取行情失败: %v", err)
+	}
+	bySymbol := make(map[string]models.Stock, len(stocks))
+	for _, st := range stocks {
+		bySymbol[st.Symbol] = st
+	}
+	prompts := make([]openai.BatchPrompt, 0, len(stockCodes))
+	for _, code := range stockCodes {
+		stock, ok := bySymbol[code]
+		if !ok {
+			stock = models.Stock{Symbol: code, Name: a.instrumentService.Name(code)}
+		}
+		system, user := services.BuildBatchPrompt(stock, a.sessionService.GetPosition(code), query)
+		prompts = append(prompts, openai.BatchPrompt{CustomID: code, System: system, User: user})
+	}
+
+	batch, err := client.Submit(a.ctx, prompts, map[string]string{"source": "jcp"})
+	if err != nil {
+		job.Error = err.Error()
+	} else {
+		job.BatchID, job.Status = batch.ID, batch.Status
+	}
+	created, saveErr := a.batchService.Create(job)
+	if saveErr != nil {
+		log.Warn("保存批量任务失败: %v", saveErr)
+	}
+	if job.Error == "" {
+		log.Info("批量分析已提交: %s, %d 只股票", batch.ID, len(stockCodes))
+		runtime.EventsEmit(a.ctx, "batch:update", created)
+	}
+	return created
+}
+
+// pollBatchJobs 轮询未完成的批量任务，结束后把结果写回会话
+func (a *App) pollBatchJobs(ctx context.Context) {
+	for _, job := range a.batchService.Pending() {
+		if ctx.Err() != nil {
+			return
+		}
+		a.pollBatchJob(ctx, job)
+	}
+}
+
+// pollBatchJob 查询单个任务状态，任务结束时写回结果
+func (a *App) pollBatchJob(ctx context.Context, job models.BatchJob) {
+	aiConfig := a.getAIConfigByID(job.AIConfigID)
+	if aiConfig == nil || aiConfig.ID != job.AIConfigID {
+		job.Error, job.Applied = "任务使用的 AI 配置已不存在", true
+		a.updateBatchJob(job)
+		return
+	}
+	client, err := adk.NewModelFactory().CreateBatchClient(aiConfig)
+	if err != nil {
+		job.Error, job.Applied = err.Error(), true
+		a.updateBatchJob(job)
+		return
+	}
+	batch, err := client.Get(ctx, job.BatchID)
+	if err != nil {
+		log.Warn("查询批量任务失败 [%s]: %v", job.BatchID, err)
+		return
+	}
+	job.Status = batch.Status
+	job.Completed, job.Failed = batch.RequestCounts.Completed, batch.RequestCounts.Failed
+	if msg := batch.ErrorMessage(); msg != "" {
+		job.Error = msg
+	}
+	if !openai.BatchTerminal(batch.Status) {
+		a.updateBatchJob(job)
+		return
+	}
+
+	results, err := client.Results(ctx, batch)
+	if err != nil {
+		// 下载失败下次轮询重试
+		log.Warn("下载批量结果失败 [%s]: %v", job.BatchID, err)
+		a.updateBatchJob(job)
+		return
+	}
+	for _, r := range results {
+		a.applyBatchResult(job, aiConfig, r)
+	}
+	job.Applied = true
+	job.CompletedAt = time.Now().UnixMilli()
+	log.Info("批量分析完成: %s, 成功 %d, 失败 %d", job.BatchID, job.Completed, job.Failed)
+	a.updateBatchJob(job)
+}
+
+// applyBatchResult 把单只股票的结果保存为会话消息，并按批量价格（实时调用的一半）计入费用
+func (a *App) applyBatchResult(job models.BatchJob, aiConfig *models.AIConfig, r openai.BatchResult) {
+	if _, err := a.sessionService.GetOrCreateSession(r.CustomID, a.instrumentService.Name(r.CustomID)); err != nil {
+		log.Warn("批量结果创建会话失败 [%s]: %v", r.CustomID, err)
+		return
+	}
+	trace := &models.TurnTrace{
+		StockCode:        r.CustomID,
+		AIConfigID:       aiConfig.ID,
+		Model:            job.Model,
+		PromptTokens:     r.PromptTokens,
+		CompletionTokens: r.CompletionTokens,
+		CachedTokens:     r.CachedTokens,
+		TotalTokens:      r.PromptTokens + r.CompletionTokens,
+	}
+	cost := a.costService.Calculate(aiConfig, trace)
+	if cost != nil {
+		cost.USD /= 2
+		cost.CNY /= 2
+	}
+	if r.Error == "" {
+		if err := a.costService.Record(trace, cost); err != nil {
+			log.Warn("record cost error: %v", err)
+		}
+	}
+
+	msg := models.ChatMessage{
+		AgentID:   "batch",
+		AgentName: "批量分析",
+		Role:      "批量分析",
+		Content:   r.Content,
+		Timestamp: time.Now().UnixMilli(),
+		Error:     r.Error,
+	}
+	if r.Error == "" {
+		msg.Cost = cost
+	}
+	a.saveAgentMessage(r.CustomID, &msg)
+	runtime.EventsEmit(a.ctx, "meeting:message:"+r.CustomID, msg)
+}
+
+// updateBatchJob 保存任务状态并通知前端
+func (a *App) updateBatchJob(job models.BatchJob) {
+	if err := a.batchService.Update(job); err != nil {
+		log.Warn("更新批量任务失败: %v", err)
+		return
+	}
+	runtime.EventsEmit(a.ctx, "batch:update", job)
+}
+
+// GetBatchJobs 列出批量分析任务（最新在前）
+func (a *App) GetBatchJobs() []models.BatchJob {
+	return a.batchService.List()
+}
+
+// RefreshBatchJob 立即查询任务状态（任务结束时写回结果）
+func (a *App) RefreshBatchJob(id string) models.BatchJob {
+	job, ok := a.batchService.Get(id)
+	if !ok {
+		return models.BatchJob{ID: id, Error: "批量任务不存在"}
+	}
+	if job.BatchID != "" && !job.Applied {
+		a.pollBatchJob(a.ctx, job)
+		job, _ = a.batchService.Get(id)
+	}
+	return job
+}
+
+// CancelBatchJob 取消未结束的批量任务
+func (a *App) CancelBatchJob(id string) string {
+	job, ok := a.batchService.Get(id)
+	if !ok {
+		return "批量任务不存在"
+	}
+	if job.BatchID == "" || openai.BatchTerminal(job.Status) {
+		return "任务已结束"
+	}
+	client, err := adk.NewModelFactory().CreateBatchClient(a.getAIConfigByID(job.AIConfigID))
+	if err != nil {
+		return err.Error()
+	}
+	batch, err := client.Cancel(a.ctx, job.BatchID)
+	if err != nil {
+		return err.Error()
+	}
+	job.Status = batch.Status
+	a.updateBatchJob(job)
+	return "success"
+}
+
+// ========== Digest API ==========
+
+// digestCheckInterval 周报到期检查间隔
+const digestCheckInterval = 10 * time.Minute
+
+// digestLoop 到达配置的生成时间后生成周报并推送
+func (a *App) digestLoop(ctx context.Context) {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if now := time.Now(); a.digestService.Due(a.configService.GetConfig().Digest, now) {
+				date := now.Format("2006-01-02")
+				if _, err := a.jobQueue.Enqueue(models.JobKindDigest, "周报 "+date, "digest:"+date, nil, 0); err != nil {
+					log.Warn("周报任务保存失败: %v", err)
+				}
+			}
+		}
+	}
+}
+
+// GenerateWeeklyDigest 立即生成最近 7 天的周报，使用记忆模型撰写并推送到已配置的通知渠道
+func (a *App) GenerateWeeklyDigest() models.Digest {
+	end := time.Now()
+	in := a.collectDigestInput(end.AddDate(0, 0, -7), end)
+
+	config := a.configService.GetConfig()
+	var llm model.LLM
+	if aiConfig := a.getAIConfigByID(config.Memory.AIConfigID); aiConfig != nil {
+		var err error
+		if llm, err = adk.NewModelFactory().CreateModel(a.ctx, aiConfig); err != nil {
+			log.Warn("周报创建模型失败，使用数据清单: %v", err)
+		}
+	}
+	digest := a.digestService.Generate(a.ctx, llm, in)
+
+	var failed []string
+	for _, r := range a.notifyService.Send(a.ctx, digest.Title, digest.Content) {
+		if r.Error != "" {
+			failed = append(failed, r.Sink+": "+r.Error)
+		} else {
+			digest.Delivered = append(digest.Delivered, r.Sink)
+		}
+	}
+	if len(failed) > 0 {
+		digest.Error = strings.TrimPrefix(digest.Error+"; 推送失败: "+strings.Join(failed, "; "), "; ")
+	}
+	if err := a.digestService.Save(digest); err != nil {
+		log.Warn("保存周报失败: %v", err)
+	}
+	runtime.EventsEmit(a.ctx, "digest:created", digest)
+	return digest
+}
+
+// collectDigestInput 收集周报数据：各会话本周的总结结论、事件提醒、规则信号与当前组合
+func (a *App) collectDigestInput(start, end time.Time) services.DigestInput {
+	in := services.DigestInput{
+		Start:  start,
+		End:    end,
+		Alerts: a.triggerService.RecentFirings(start),
+	}
+	codes, err := a.sessionService.ListSessionCodes()
+	if err != nil {
+		log.Warn("list sessions error: %v", err)
+	}
+	since := start.UnixMilli()
+	for _, code := range codes {
+		session := a.sessionService.GetSession(code)
+		if session == nil {
+			continue
+		}
+		ds := services.DigestSession{StockCode: code, StockName: session.StockName}
+		for _, msg := range session.Messages {
+			if msg.Timestamp < since {
+				continue
+			}
+			ds.Messages++
+			if msg.MsgType == "summary" && msg.Error == "" && msg.Content != "" {
+				ds.Summaries = append(ds.Summaries, msg.Content)
+			}
+		}
+		if ds.Messages > 0 {
+			in.Sessions = append(in.Sessions, ds)
+		}
+	}
+	startDate := start.Format("2006-01-02")
+	for _, st := range a.configService.GetWatchlist() {
+		for _, sig := range a.signalService.GetSignals(st.Symbol) {
+			if sig.Date >= startDate {
+				in.Signals = append(in.Signals, sig)
+			}
+		}
+	}
+	portfolio := a.GetPortfolio()
+	in.Portfolio = &portfolio
+	return in
+}
+
+// GetDigests 列出历史周报（最新在前）
+func (a *App) GetDigests() []models.Digest {
+	return a.digestService.List()
+}
+
+// TestNotification 向所有已启用的通知渠道发送测试消息
+func (a *App) TestNotification() []services.NotifyResult {
+	results := a.notifyService.Send(a.ctx, "韭菜盘通知测试", "收到这条消息说明通知渠道配置正确。")
+	if results == nil {
+		return []services.NotifyResult{}
+	}
+	return results
+}
+
+// RenderPlainText 把 Markdown 报告转换为纯文本（表格展开、去除链接），供语音朗读使用
+func (a *App) RenderPlainText(markdown string) string {
+	return plaintext.Render(markdown)
+}
+
+// ========== Ranking API ==========
+
+// RunWatchlistRanking 对全部自选股批量评分并生成排名报告
+func (a *App) RunWatchlistRanking(rubric []models.RubricCriterion) *models.RankingReport {
+	config := a.configService.GetConfig()
+	aiConfig := a.getDefaultAIConfig(config)
+	if aiConfig == nil {
+		log.Warn("批量评分跳过：未配置AI服务")
+		return nil
+	}
+	llm, err := adk.NewModelFactory().CreateModel(a.ctx, aiConfig)
+	if err != nil {
+		log.Error("批量评分创建模型失败: %v", err)
+		return nil
+	}
+
+	report, err := a.rankingService.Run(a.ctx, llm, services.RankingInput{
+		Stocks:    a.GetWatchlist(),
+		Rubric:    rubric,
+		ModelName: aiConfig.ModelName,
+		// 只关联已有会话，评分本身不创建会话，避免污染会话列表
+		SessionFor: func(code, _ string) string {
+			return a.sessionService.ExistingSessionID(code)
+		},
+		OnProgress: func(done, total int, row models.RankingRow) {
+			runtime.EventsEmit(a.ctx, "ranking:progress", map[string]any{
+				"done":  done,
+				"total": total,
+				"row":   row,
+			})
+		},
+	})
+	if err != nil {
+		log.Error("批量评分失败: %v", err)
+	}
+	return report
+}
+
+// GetDefaultRankingRubric 获取默认评分标准
+func (a *App) GetDefaultRankingRubric() []models.RubricCriterion {
+	return services.DefaultRubric
+}
+
+// GetRankingReports 获取历史排名报告列表
+func (a *App) GetRankingReports() []models.RankingReportSummary {
+	return a.rankingService.ListReports()
+}
+
+// GetRankingReport 获取指定排名报告
+func (a *App) GetRankingReport(id string) *models.RankingReport {
+	report, err := a.rankingService.GetReport(id)
+	if err != nil {
+		log.Warn("获取排名报告失败: %v", err)
+		return nil
+	}
+	return report
+}
+
+// DeleteRankingReport 删除排名报告
+func (a *App) DeleteRankingReport(id string) string {
+	if err := a.rankingService.DeleteReport(id); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// ========== Analysis Artifacts API ==========
+
+// saveAgentMessage 保存专家发言，并将其中的表格与 JSON 结构化输出提取为分析产物
+// 预先分配消息 ID，使推送给前端的消息与产物能够关联
+func (a *App) saveAgentMessage(stockCode string, msg *models.ChatMessage) {
+	msg.ID = uuid.New().String()
+	// 中断的部分内容保持原样，便于续写
+	if msg.Error == "" && !msg.Partial {
+		msg.Content = postprocess.Apply(msg.Content, &a.configService.GetConfig().PostProcess)
+	}
+	if err := a.sessionService.AddMessage(stockCode, *msg); err != nil {
+		log.Warn("保存消息失败: %v", err)
+		return
+	}
+	if msg.Error != "" || msg.Partial {
+		return
+	}
+	if _, err := a.artifactService.Add(services.ExtractArtifacts(stockCode, *msg)...); err != nil {
+		log.Warn("保存分析产物失败: %v", err)
+	}
+}
+
+// ListArtifacts 列出个股分析产物，messageID 非空时只返回该消息的产物
+func (a *App) ListArtifacts(stockCode, messageID string) []models.Artifact {
+	return a.artifactService.List(stockCode, messageID)
+}
+
+// SaveArtifact 保存分析产物（前端生成的图表、文件等）
+func (a *App) SaveArtifact(artifact models.Artifact) string {
+	if _, err := a.artifactService.Add(artifact); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// DeleteArtifact 删除分析产物
+func (a *App) DeleteArtifact(stockCode, id string) string {
+	if err := a.artifactService.Delete(stockCode, id); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// ExportArtifact 导出分析产物（表格为 CSV，结构化数据为 JSON）
+func (a *App) ExportArtifact(stockCode, id string) models.ArtifactExport {
+	export, err := a.artifactService.Export(stockCode, id)
+	if err != nil {
+		return models.ArtifactExport{Error: err.Error()}
+	}
+	return export
+}
+
+// ========== Stock Documents API ==========
+
+// GetStockDocuments 获取个股已收录的公告/研报文档列表
+func (a *App) GetStockDocuments(stockCode string) []models.StockDocument {
+	return a.documentService.List(stockCode)
+}
+
+// IngestStockDocument 手动收录一篇 PDF 到个股文档库（同步下载与解析）
+func (a *App) IngestStockDocument(stockCode, title, url string) string {
+	if stockCode == "" || url == "" {
+		return "股票代码和链接不能为空"
+	}
+	if err := a.documentService.IngestPDF(stockCode, title, url, "manual", time.Now().Format("2006-01-02")); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// DeleteStockDocument 删除个股文档
+func (a *App) DeleteStockDocument(stockCode, docID string) string {
+	if err := a.documentService.Delete(stockCode, docID); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// ========== Research Notes API ==========
+
+// GetResearchNotes 获取个股研究笔记
+func (a *App) GetResearchNotes(stockCode string) models.ResearchNotes {
+	return a.notesService.GetNotes(stockCode)
+}
+
+// SaveResearchNotes 保存个股研究笔记（整体覆盖）
+func (a *App) SaveResearchNotes(notes models.ResearchNotes) string {
+	if err := a.notesService.SaveNotes(notes); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// EditResearchNoteSection 编辑研究笔记章节（append/replace/delete）
+func (a *App) EditResearchNoteSection(stockCode, stockName, title, content, mode string) string {
+	if err := a.notesService.EditSection(stockCode, stockName, title, content, mode, "user"); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// ========== Memory Transfer API ==========
+
+// ExportMemory 导出股票记忆到 JSON 文件（摘要、关键事实、长期记忆），stockCodes 为空时导出全部
+// includeEmbeddings 为 false 时不附带向量，导入方按原文重新计算
+func (a *App) ExportMemory(path string, stockCodes []string, includeEmbeddings bool) models.MemoryTransferResult {
+	result := models.MemoryTransferResult{Path: path}
+	if a.memoryManager == nil {
+		result.Error = "记忆管理未启用"
+		return result
+	}
+	codes, err := a.memoryManager.ExportMemoryFile(path, stockCodes, includeEmbeddings)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Stocks = codes
+	return result
+}
+
+// ImportMemory 从记忆导出文件导入股票记忆，已存在的股票在 overwrite 为 false 时跳过
+func (a *App) ImportMemory(path string, overwrite bool) models.MemoryTransferResult {
+	result := models.MemoryTransferResult{Path: path}
+	if a.memoryManager == nil {
+		result.Error = "记忆管理未启用"
+		return result
+	}
+	imported, err := a.memoryManager.ImportMemoryFile(path, overwrite)
+	result.Stocks, result.Skipped = imported.Imported, imported.Skipped
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// ========== User Memory API ==========
+
+// GetUserMemories 获取用户级全局记忆（所有股票会话共享）
+func (a *App) GetUserMemories() []models.UserMemoryEntry {
+	return a.userMemoryService.List()
+}
+
+// AddUserMemory 添加全局记忆，category: style/risk/position/preference
+func (a *App) AddUserMemory(category, content string) string {
+	if _, err := a.userMemoryService.Add(models.UserMemoryCategory(category), content, "user"); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// UpdateUserMemory 编辑全局记忆
+func (a *App) UpdateUserMemory(id, category, content string) string {
+	if err := a.userMemoryService.Update(id, models.UserMemoryCategory(category), content); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
+
+// DeleteUserMemory 删除全局记忆
+func (a *App) DeleteUserMemory(id string) string {
+	if err := a.userMemoryService.Delete(id); err != nil {
+		return err.Error()
+	}
+	return "success"
+}
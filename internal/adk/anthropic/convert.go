@@ -3,8 +3,12 @@ package anthropic
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/run-bigpig/jcp/internal/adk/media"
+	"github.com/run-bigpig/jcp/internal/adk/schemasanitize"
+	"github.com/run-bigpig/jcp/internal/adk/structured"
 	"github.com/run-bigpig/jcp/internal/logger"
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
@@ -44,7 +48,7 @@ func extractTextFromContent(content *genai.Content) string {
 func toAnthropicRequest(req *model.LLMRequest, modelName string, noSystemRole bool) (*MessagesRequest, error) {
 	ar := &MessagesRequest{
 		Model:     modelName,
-		MaxTokens: 4096, // Anthropic 要求必须设置
+		MaxTokens: DefaultMaxTokens(modelName), // Anthropic 要求必须设置
 	}
 
 	// 提取系统指令文本
@@ -86,6 +90,14 @@ func toAnthropicRequest(req *model.LLMRequest, modelName string, noSystemRole bo
 			return nil, err
 		}
 		ar.Tools = tools
+		applyToolChoice(ar, req.Config)
+	}
+
+	// 结构化输出：Anthropic 无原生 JSON Schema 输出，通过强制调用专用工具获得符合 schema 的参数
+	if req.Config != nil {
+		if err := applyStructuredOutput(ar, req.Config); err != nil {
+			return nil, err
+		}
 	}
 
 	// 应用配置
@@ -109,6 +121,100 @@ func toAnthropicRequest(req *model.LLMRequest, modelName string, noSystemRole bo
 	return ar, nil
 }
 
+// applyCacheControl 自动设置提示缓存断点：系统提示词、最后一个工具定义、最后一条消息的最后一个内容块
+// 多轮分析中系统提示词、工具与已有历史保持不变，后续请求可命中缓存（Anthropic 最多允许 4 个断点）
+func applyCacheControl(ar *MessagesRequest) {
+	ephemeral := &CacheControl{Type: "ephemeral"}
+	if ar.System != "" {
+		ar.SystemCacheControl = ephemeral
+	}
+	if n := len(ar.Tools); n > 0 {
+		ar.Tools[n-1].CacheControl = ephemeral
+	}
+	if n := len(ar.Messages); n > 0 {
+		blocks := ar.Messages[n-1].Content
+		// thinking 块不能直接设置断点
+		for i := len(blocks) - 1; i >= 0; i-- {
+			if blocks[i].Type != "thinking" {
+				blocks[i].CacheControl = ephemeral
+				break
+			}
+		}
+	}
+}
+
+// applyStructuredOutput 请求要求 JSON Schema 输出时追加专用工具并强制调用
+// 同时存在其他工具时使用 any，允许模型先调用数据工具，最终以专用工具输出结果
+func applyStructuredOutput(ar *MessagesRequest, cfg *genai.GenerateContentConfig) error {
+	schema := structured.Schema(cfg)
+	if schema == nil {
+		return nil
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("marshal response schema: %w", err)
+	}
+	if schemaJSON, err = schemasanitize.SanitizeJSON(schemaJSON, schemasanitize.Anthropic); err != nil {
+		convertLog.Warn("清洗 response schema 失败: %v", err)
+	}
+	// 禁止调用工具时只保留专用工具
+	if ar.ToolChoice != nil && ar.ToolChoice.Type == "none" {
+		ar.Tools = nil
+	}
+	ar.Tools = append(ar.Tools, Tool{
+		Name:        structured.ToolName,
+		Description: "以符合 schema 的 JSON 输出最终结果。完成分析后必须调用此工具给出答案。",
+		InputSchema: schemaJSON,
+	})
+	switch {
+	case ar.ToolChoice != nil && ar.ToolChoice.Type == "tool":
+		// 本轮已指定必须调用的函数，保持不变
+	case len(ar.Tools) == 1:
+		ar.ToolChoice = &ToolChoice{Type: "tool", Name: structured.ToolName}
+	default:
+		ar.ToolChoice = &ToolChoice{Type: "any"}
+	}
+	return nil
+}
+
+// applyToolChoice 将 genai 的函数调用配置映射为 tool_choice
+// ANY 且只允许一个函数时强制调用该函数；指定了允许列表时只发送列表中的工具
+func applyToolChoice(ar *MessagesRequest, cfg *genai.GenerateContentConfig) {
+	if cfg.ToolConfig == nil || cfg.ToolConfig.FunctionCallingConfig == nil || len(ar.Tools) == 0 {
+		return
+	}
+	fc := cfg.ToolConfig.FunctionCallingConfig
+	if len(fc.AllowedFunctionNames) > 0 && fc.Mode != genai.FunctionCallingConfigModeNone {
+		ar.Tools = slices.DeleteFunc(ar.Tools, func(t Tool) bool {
+			return !slices.Contains(fc.AllowedFunctionNames, t.Name)
+		})
+	}
+	switch fc.Mode {
+	case genai.FunctionCallingConfigModeNone:
+		ar.ToolChoice = &ToolChoice{Type: "none"}
+	case genai.FunctionCallingConfigModeAny:
+		if len(ar.Tools) == 1 {
+			ar.ToolChoice = &ToolChoice{Type: "tool", Name: ar.Tools[0].Name}
+		} else {
+			ar.ToolChoice = &ToolChoice{Type: "any"}
+		}
+	case genai.FunctionCallingConfigModeAuto, genai.FunctionCallingConfigModeValidated:
+		ar.ToolChoice = &ToolChoice{Type: "auto"}
+	}
+}
+
+// structuredOutputPart 将专用工具的调用参数还原为 JSON 文本输出
+func structuredOutputPart(name string, input []byte) (*genai.Part, bool) {
+	if name != structured.ToolName {
+		return nil, false
+	}
+	text := strings.TrimSpace(string(input))
+	if text == "" {
+		text = "{}"
+	}
+	return &genai.Part{Text: text}, true
+}
+
 // toAnthropicMessages 将 genai.Content 列表转换为 Anthropic messages
 func toAnthropicMessages(contents []*genai.Content) ([]Message, error) {
 	var msgs []Message
@@ -139,6 +245,24 @@ func toAnthropicMessages(contents []*genai.Content) ([]Message, error) {
 				})
 			}
 
+			// 图片 → image（内联数据 base64，远程地址 url）
+			img, err := media.ImageFromPart(part)
+			if err != nil {
+				return nil, err
+			}
+			if img != nil {
+				blocks = append(blocks, ContentBlock{Type: "image", Source: mediaSource(img)})
+			}
+
+			// PDF → document
+			doc, err := media.DocumentFromPart(part)
+			if err != nil {
+				return nil, err
+			}
+			if doc != nil {
+				blocks = append(blocks, ContentBlock{Type: "document", Source: mediaSource(doc), Title: doc.Name})
+			}
+
 			// 函数调用 → tool_use
 			if part.FunctionCall != nil {
 				inputJSON, err := json.Marshal(part.FunctionCall.Args)
@@ -182,6 +306,14 @@ func toAnthropicMessages(contents []*genai.Content) ([]Message, error) {
 	return msgs, nil
 }
 
+// mediaSource 转换为 Anthropic 图片/文档来源
+func mediaSource(f *media.File) *MediaSource {
+	if f.URL != "" {
+		return &MediaSource{Type: "url", URL: f.URL}
+	}
+	return &MediaSource{Type: "base64", MediaType: f.MIMEType, Data: f.Base64()}
+}
+
 // convertTools 将 genai.Tool 转换为 Anthropic Tool
 func convertTools(genaiTools []*genai.Tool) ([]Tool, error) {
 	var tools []Tool
@@ -202,7 +334,7 @@ func convertTools(genaiTools []*genai.Tool) ([]Tool, error) {
 				return nil, fmt.Errorf("marshal tool schema: %w", err)
 			}
 			// 清洗 MCP 透传的 JSON Schema，移除 Anthropic 不支持的关键字
-			schemaJSON, err = sanitizeSchemaForAnthropic(schemaJSON)
+			schemaJSON, err = schemasanitize.SanitizeJSON(schemaJSON, schemasanitize.Anthropic)
 			if err != nil {
 				convertLog.Warn("清洗 tool schema 失败 (%s): %v", fd.Name, err)
 			}
@@ -216,68 +348,6 @@ func convertTools(genaiTools []*genai.Tool) ([]Tool, error) {
 	return tools, nil
 }
 
-// sanitizeSchemaForAnthropic 清洗 JSON Schema，移除 Anthropic 不支持的关键字
-func sanitizeSchemaForAnthropic(raw json.RawMessage) (json.RawMessage, error) {
-	var schema map[string]any
-	if err := json.Unmarshal(raw, &schema); err != nil {
-		return raw, err
-	}
-	sanitizeSchemaNode(schema)
-	return json.Marshal(schema)
-}
-
-// sanitizeSchemaNode 递归清洗 schema 节点
-func sanitizeSchemaNode(node map[string]any) {
-	// const → enum 单值
-	if val, ok := node["const"]; ok {
-		node["enum"] = []any{val}
-		delete(node, "const")
-	}
-
-	// 移除 default: null
-	if v, ok := node["default"]; ok && v == nil {
-		delete(node, "default")
-	}
-
-	// anyOf 含 {"type":"null"} → 提取非 null 分支，标记非必填
-	if anyOf, ok := node["anyOf"].([]any); ok {
-		var nonNull []any
-		for _, item := range anyOf {
-			if m, ok := item.(map[string]any); ok {
-				if m["type"] == "null" {
-					continue
-				}
-				nonNull = append(nonNull, m)
-			}
-		}
-		if len(nonNull) == 1 {
-			// 单个非 null 分支，展平到当前节点
-			if m, ok := nonNull[0].(map[string]any); ok {
-				delete(node, "anyOf")
-				for k, v := range m {
-					node[k] = v
-				}
-			}
-		} else if len(nonNull) > 1 {
-			node["anyOf"] = nonNull
-		}
-	}
-
-	// 递归处理 properties
-	if props, ok := node["properties"].(map[string]any); ok {
-		for _, v := range props {
-			if m, ok := v.(map[string]any); ok {
-				sanitizeSchemaNode(m)
-			}
-		}
-	}
-
-	// 递归处理 items
-	if items, ok := node["items"].(map[string]any); ok {
-		sanitizeSchemaNode(items)
-	}
-}
-
 // convertAnthropicResponse 将 Anthropic 响应转换为 ADK LLMResponse
 func convertAnthropicResponse(resp *MessagesResponse) (*model.LLMResponse, error) {
 	content := &genai.Content{
@@ -296,6 +366,10 @@ func convertAnthropicResponse(resp *MessagesResponse) (*model.LLMResponse, error
 				content.Parts = append(content.Parts, &genai.Part{Text: block.Thinking, Thought: true})
 			}
 		case "tool_use":
+			if part, ok := structuredOutputPart(block.Name, block.Input); ok {
+				content.Parts = append(content.Parts, part)
+				continue
+			}
 			args := make(map[string]any)
 			if len(block.Input) > 0 {
 				if err := json.Unmarshal(block.Input, &args); err != nil {
@@ -325,22 +399,50 @@ func convertUsage(u *Usage) *genai.GenerateContentResponseUsageMetadata {
 	if u == nil {
 		return nil
 	}
+	// Anthropic 的 input_tokens 不含缓存部分，统一为总输入 token，缓存命中单独记录
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
 	return &genai.GenerateContentResponseUsageMetadata{
-		PromptTokenCount:     int32(u.InputTokens),
-		CandidatesTokenCount: int32(u.OutputTokens),
-		TotalTokenCount:      int32(u.InputTokens + u.OutputTokens),
+		PromptTokenCount:        int32(prompt),
+		CandidatesTokenCount:    int32(u.OutputTokens),
+		CachedContentTokenCount: int32(u.CacheReadInputTokens),
+		TotalTokenCount:         int32(prompt + u.OutputTokens),
+	}
+}
+
+// mergeUsage 合并流式用量：message_delta 通常只携带输出 token，非零字段覆盖 message_start 的值
+func mergeUsage(dst *Usage, src *Usage) *Usage {
+	if dst == nil {
+		u := *src
+		return &u
+	}
+	if src.InputTokens > 0 {
+		dst.InputTokens = src.InputTokens
+	}
+	if src.OutputTokens > 0 {
+		dst.OutputTokens = src.OutputTokens
+	}
+	if src.CacheCreationInputTokens > 0 {
+		dst.CacheCreationInputTokens = src.CacheCreationInputTokens
+	}
+	if src.CacheReadInputTokens > 0 {
+		dst.CacheReadInputTokens = src.CacheReadInputTokens
 	}
+	return dst
 }
 
-// convertStopReason 转换停止原因
+// convertStopReason 转换停止原因；原始原因另外写入响应元数据（pause_turn 需要上层继续请求）
 func convertStopReason(reason string) genai.FinishReason {
 	switch reason {
 	case "end_turn", "stop_sequence":
 		return genai.FinishReasonStop
-	case "max_tokens":
+	case "max_tokens", "model_context_window_exceeded":
 		return genai.FinishReasonMaxTokens
 	case "tool_use":
 		return genai.FinishReasonStop
+	case "refusal":
+		return genai.FinishReasonSafety
+	case "pause_turn":
+		return genai.FinishReasonOther
 	default:
 		return genai.FinishReasonUnspecified
 	}
@@ -0,0 +1,66 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/run-bigpig/jcp/internal/adk/providererr"
+	"google.golang.org/adk/model"
+)
+
+// countTokensRequest count_tokens 接口请求体（与 Messages 请求相同，但不含生成参数）
+type countTokensRequest struct {
+	Model      string      `json:"model"`
+	Messages   []Message   `json:"messages"`
+	System     string      `json:"system,omitempty"`
+	Tools      []Tool      `json:"tools,omitempty"`
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+}
+
+// CountTokens 调用 /v1/messages/count_tokens 统计请求的输入 token 数（按 Claude 分词器精确计算）
+func (m *AnthropicModel) CountTokens(ctx context.Context, req *model.LLMRequest) (int, error) {
+	ar, err := m.toRequest(req)
+	if err != nil {
+		return 0, err
+	}
+	body, err := json.Marshal(countTokensRequest{
+		Model:      ar.Model,
+		Messages:   ar.Messages,
+		System:     ar.System,
+		Tools:      ar.Tools,
+		ToolChoice: ar.ToolChoice,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("marshal request: %w", err)
+	}
+
+	var endpoint string
+	if m.vertex != nil {
+		// Vertex AI 的计数接口是独立的 count-tokens 模型，目标模型写在请求体中
+		endpoint = m.vertex.endpoint("count-tokens", "rawPredict")
+		if body, err = vertexBody(body, true); err != nil {
+			return 0, err
+		}
+	} else if endpoint, err = url.JoinPath(m.baseURL, "v1", "messages", "count_tokens"); err != nil {
+		return 0, fmt.Errorf("build endpoint: %w", err)
+	}
+
+	resp, err := m.post(ctx, endpoint, body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, providererr.FromResponse("Anthropic", resp)
+	}
+	var result struct {
+		InputTokens int `json:"input_tokens"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("decode response: %w", err)
+	}
+	return result.InputTokens, nil
+}
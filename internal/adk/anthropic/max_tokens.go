@@ -0,0 +1,35 @@
+package anthropic
+
+import "strings"
+
+// fallbackMaxTokens 未识别的模型（第三方兼容网关等）使用的输出上限
+const fallbackMaxTokens = 4096
+
+// modelMaxTokens 各 Claude 模型支持的最大输出 token，按顺序匹配模型名（先匹配更具体的名称）
+var modelMaxTokens = []struct {
+	match     string
+	maxTokens int
+}{
+	{"claude-opus-4", 32000},
+	{"claude-sonnet-4", 64000},
+	{"claude-haiku-4", 64000},
+	{"claude-3-7-sonnet", 64000},
+	{"claude-3-5-sonnet", 8192},
+	{"claude-3-5-haiku", 8192},
+	{"claude-3-", 4096},
+}
+
+// DefaultMaxTokens 请求与配置都未指定时的 max_tokens：已知模型使用其最大输出，未知模型为 4096
+// 模型名可带供应商前缀或版本后缀（如 anthropic/claude-sonnet-4-5、claude-sonnet-4@20250514）
+func DefaultMaxTokens(modelName string) int {
+	name := strings.ToLower(modelName)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, m := range modelMaxTokens {
+		if strings.HasPrefix(name, m.match) {
+			return m.maxTokens
+		}
+	}
+	return fallbackMaxTokens
+}
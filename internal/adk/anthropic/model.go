@@ -1,7 +1,6 @@
 package anthropic
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -14,7 +13,11 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/run-bigpig/jcp/internal/adk/providererr"
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+	"github.com/run-bigpig/jcp/internal/adk/structured"
 	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/pkg/sse"
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
 )
@@ -31,6 +34,13 @@ type AnthropicModel struct {
 	apiKey       string
 	modelName    string
 	noSystemRole bool
+	promptCache  bool
+
+	// MaxTokens 请求未指定输出上限时使用的 max_tokens，0 时按模型取默认值（见 DefaultMaxTokens）
+	MaxTokens int
+
+	// 非空时为 Vertex AI 上的 Claude：走 rawPredict 接口，鉴权由 httpClient 注入
+	vertex *vertexTarget
 }
 
 func normalizeBaseURL(baseURL string) string {
@@ -40,16 +50,29 @@ func normalizeBaseURL(baseURL string) string {
 }
 
 // NewAnthropicModel 创建 Anthropic 模型
-func NewAnthropicModel(modelName, apiKey, baseURL string, httpClient *http.Client, noSystemRole bool) *AnthropicModel {
+func NewAnthropicModel(modelName, apiKey, baseURL string, httpClient *http.Client, noSystemRole, promptCache bool) *AnthropicModel {
 	return &AnthropicModel{
 		httpClient:   httpClient,
 		baseURL:      normalizeBaseURL(baseURL),
 		apiKey:       apiKey,
 		modelName:    modelName,
 		noSystemRole: noSystemRole,
+		promptCache:  promptCache,
 	}
 }
 
+// toRequest 转换请求，请求未指定输出上限时使用配置的 MaxTokens
+func (m *AnthropicModel) toRequest(req *model.LLMRequest) (*MessagesRequest, error) {
+	ar, err := toAnthropicRequest(req, m.modelName, m.noSystemRole)
+	if err != nil {
+		return nil, err
+	}
+	if m.MaxTokens > 0 && (req.Config == nil || req.Config.MaxOutputTokens <= 0) {
+		ar.MaxTokens = m.MaxTokens
+	}
+	return ar, nil
+}
+
 // Name 返回模型名称
 func (m *AnthropicModel) Name() string {
 	return m.modelName
@@ -70,44 +93,62 @@ func (m *AnthropicModel) doRequest(ctx context.Context, ar *MessagesRequest) (*h
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	endpoint, err := url.JoinPath(m.baseURL, "v1", "messages")
-	if err != nil {
+	var endpoint string
+	if m.vertex != nil {
+		method := "rawPredict"
+		if ar.Stream {
+			method = "streamRawPredict"
+		}
+		endpoint = m.vertex.endpoint(m.modelName, method)
+		if jsonBody, err = vertexBody(jsonBody, false); err != nil {
+			return nil, err
+		}
+	} else if endpoint, err = url.JoinPath(m.baseURL, "v1", "messages"); err != nil {
 		return nil, fmt.Errorf("build endpoint: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", m.apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
-	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) CherryStudio/1.2.4 Chrome/126.0.6478.234 Electron/31.7.6 Safari/537.36")
-
-	resp, err := m.httpClient.Do(httpReq)
+	resp, err := m.post(ctx, endpoint, jsonBody)
 	if err != nil {
 		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		pe := providererr.FromResponse("Anthropic", resp)
 		resp.Body.Close()
-		modelLog.Error("API 响应异常: status=%d, body=%s", resp.StatusCode, string(body))
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		modelLog.Warn("%s 请求 %s 失败: %v，响应体: %s", m.modelName, providererr.Endpoint(endpoint), pe, pe.Body)
+		return nil, pe
 	}
 
 	return resp, nil
 }
 
+// post 发送 JSON 请求；Vertex AI 使用 Google 凭证鉴权，不附带 API Key 与版本头
+func (m *AnthropicModel) post(ctx context.Context, endpoint string, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if m.vertex == nil {
+		httpReq.Header.Set("x-api-key", m.apiKey)
+		httpReq.Header.Set("anthropic-version", "2023-06-01")
+	}
+	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) CherryStudio/1.2.4 Chrome/126.0.6478.234 Electron/31.7.6 Safari/537.36")
+	return m.httpClient.Do(httpReq)
+}
+
 // generate 非流式生成
 func (m *AnthropicModel) generate(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
-		ar, err := toAnthropicRequest(req, m.modelName, m.noSystemRole)
+		ar, err := m.toRequest(req)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
+		if m.promptCache {
+			applyCacheControl(ar)
+		}
 		ar.Stream = false
 
 		resp, err := m.doRequest(ctx, ar)
@@ -117,15 +158,10 @@ func (m *AnthropicModel) generate(ctx context.Context, req *model.LLMRequest) it
 		}
 		defer resp.Body.Close()
 
-		body, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
-		if err != nil {
-			yield(nil, fmt.Errorf("read response: %w", err))
-			return
-		}
-
+		// 流式解码，避免先整体读入内存
 		var msgResp MessagesResponse
-		if err := json.Unmarshal(body, &msgResp); err != nil {
-			yield(nil, fmt.Errorf("unmarshal response: %w", err))
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 10*1024*1024)).Decode(&msgResp); err != nil {
+			yield(nil, fmt.Errorf("decode response: %w", err))
 			return
 		}
 
@@ -134,6 +170,12 @@ func (m *AnthropicModel) generate(ctx context.Context, req *model.LLMRequest) it
 			yield(nil, err)
 			return
 		}
+		llmResp.CustomMetadata = respmeta.Meta{
+			ResponseID:   msgResp.ID,
+			RequestID:    respmeta.RequestIDFromHeader(resp.Header),
+			ModelVersion: msgResp.Model,
+			StopReason:   msgResp.StopReason,
+		}.Merge(llmResp.CustomMetadata)
 
 		yield(llmResp, nil)
 	}
@@ -142,11 +184,14 @@ func (m *AnthropicModel) generate(ctx context.Context, req *model.LLMRequest) it
 // generateStream 流式生成
 func (m *AnthropicModel) generateStream(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
-		ar, err := toAnthropicRequest(req, m.modelName, m.noSystemRole)
+		ar, err := m.toRequest(req)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
+		if m.promptCache {
+			applyCacheControl(ar)
+		}
 		ar.Stream = true
 
 		resp, err := m.doRequest(ctx, ar)
@@ -156,7 +201,7 @@ func (m *AnthropicModel) generateStream(ctx context.Context, req *model.LLMReque
 		}
 		defer resp.Body.Close()
 
-		m.processStream(resp.Body, yield)
+		m.processStream(resp.Body, resp.Header, yield)
 	}
 }
 
@@ -170,10 +215,22 @@ type blockState struct {
 	toolArgs  string
 }
 
+// partialResponse 工具调用进度事件（Partial），供界面在参数生成过程中展示正在调用的工具
+// 不携带 FunctionCall part，避免被 ADK 提前执行；结构化输出工具不属于工具调用，不发送进度
+func (bs *blockState) partialResponse() *model.LLMResponse {
+	if bs.toolName == structured.ToolName {
+		return nil
+	}
+	return &model.LLMResponse{
+		Content:        &genai.Content{Role: "model"},
+		CustomMetadata: respmeta.ToolCallDelta{ID: bs.toolID, Name: bs.toolName, Arguments: bs.toolArgs}.Map(),
+		Partial:        true,
+	}
+}
+
 // processStream 处理 SSE 事件流
-func (m *AnthropicModel) processStream(body io.Reader, yield func(*model.LLMResponse, error) bool) {
-	scanner := bufio.NewScanner(body)
-	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024) // 1MB buffer
+func (m *AnthropicModel) processStream(body io.Reader, header http.Header, yield func(*model.LLMResponse, error) bool) {
+	reader := sse.NewReader(body, 0)
 
 	aggregated := &genai.Content{
 		Role:  "model",
@@ -181,25 +238,22 @@ func (m *AnthropicModel) processStream(body io.Reader, yield func(*model.LLMResp
 	}
 	var stopReason string
 	var usage *Usage
+	meta := respmeta.Meta{RequestID: respmeta.RequestIDFromHeader(header)}
 	blocks := make(map[int]*blockState)
-	var eventType string
-
-	for scanner.Scan() {
-		line := scanner.Text()
 
-		// SSE 事件类型行
-		if ev, ok := strings.CutPrefix(line, "event: "); ok {
-			eventType = ev
-			continue
+	for {
+		ev, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
 		}
-
-		// SSE 数据行
-		data, ok := strings.CutPrefix(line, "data: ")
-		if !ok {
-			continue
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				yield(nil, &respmeta.StreamError{Err: fmt.Errorf("SSE 读取错误: %w", err), Meta: meta})
+			}
+			return
 		}
 
-		if err := m.handleSSEEvent(eventType, []byte(data), blocks, &stopReason, &usage, yield); err != nil {
+		if err := m.handleSSEEvent(ev.Type(), []byte(ev.Data), blocks, &stopReason, &usage, &meta, yield); err != nil {
 			if errors.Is(err, errStopIteration) {
 				return
 			}
@@ -208,15 +262,8 @@ func (m *AnthropicModel) processStream(body io.Reader, yield func(*model.LLMResp
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		if !errors.Is(err, context.Canceled) {
-			yield(nil, fmt.Errorf("SSE 读取错误: %w", err))
-		}
-		return
-	}
-
 	// 发送最终聚合响应
-	m.emitFinalResponse(aggregated, blocks, stopReason, usage, yield)
+	m.emitFinalResponse(aggregated, blocks, stopReason, usage, meta, yield)
 }
 
 var errStopIteration = errors.New("stop iteration")
@@ -225,7 +272,7 @@ var errStopIteration = errors.New("stop iteration")
 func (m *AnthropicModel) handleSSEEvent(
 	eventType string, data []byte,
 	blocks map[int]*blockState,
-	stopReason *string, usage **Usage,
+	stopReason *string, usage **Usage, meta *respmeta.Meta,
 	yield func(*model.LLMResponse, error) bool,
 ) error {
 	switch eventType {
@@ -236,6 +283,8 @@ func (m *AnthropicModel) handleSSEEvent(
 		}
 		u := ev.Message.Usage
 		*usage = &u
+		meta.ResponseID = ev.Message.ID
+		meta.ModelVersion = ev.Message.Model
 
 	case "content_block_start":
 		var ev SSEContentBlockStart
@@ -243,11 +292,14 @@ func (m *AnthropicModel) handleSSEEvent(
 			return nil
 		}
 		bs := &blockState{blockType: ev.ContentBlock.Type}
+		blocks[ev.Index] = bs
 		if ev.ContentBlock.Type == "tool_use" {
 			bs.toolID = ev.ContentBlock.ID
 			bs.toolName = ev.ContentBlock.Name
+			if resp := bs.partialResponse(); resp != nil && !yield(resp, nil) {
+				return errStopIteration
+			}
 		}
-		blocks[ev.Index] = bs
 
 	case "content_block_delta":
 		return m.handleDelta(data, blocks, yield)
@@ -262,7 +314,7 @@ func (m *AnthropicModel) handleSSEEvent(
 		}
 		*stopReason = ev.Delta.StopReason
 		if ev.Usage != nil {
-			*usage = ev.Usage
+			*usage = mergeUsage(*usage, ev.Usage)
 		}
 
 	case "message_stop":
@@ -273,7 +325,7 @@ func (m *AnthropicModel) handleSSEEvent(
 		if err := json.Unmarshal(data, &ev); err != nil {
 			return fmt.Errorf("SSE error: %s", string(data))
 		}
-		return fmt.Errorf("Anthropic API error: %s - %s", ev.Error.Type, ev.Error.Message)
+		return providererr.New("Anthropic", 0, ev.Error.Type, ev.Error.Message)
 
 	case "ping":
 		// 忽略
@@ -324,6 +376,9 @@ func (m *AnthropicModel) handleDelta(
 
 	case "input_json_delta":
 		bs.toolArgs += ev.Delta.PartialJSON
+		if resp := bs.partialResponse(); resp != nil && !yield(resp, nil) {
+			return errStopIteration
+		}
 	}
 
 	return nil
@@ -333,7 +388,7 @@ func (m *AnthropicModel) handleDelta(
 func (m *AnthropicModel) emitFinalResponse(
 	aggregated *genai.Content,
 	blocks map[int]*blockState,
-	stopReason string, usage *Usage,
+	stopReason string, usage *Usage, meta respmeta.Meta,
 	yield func(*model.LLMResponse, error) bool,
 ) {
 	// 按 index 顺序聚合所有块，避免 map 非连续索引导致内容丢失。
@@ -359,6 +414,10 @@ func (m *AnthropicModel) emitFinalResponse(
 				})
 			}
 		case "tool_use":
+			if part, ok := structuredOutputPart(bs.toolName, []byte(bs.toolArgs)); ok {
+				aggregated.Parts = append(aggregated.Parts, part)
+				continue
+			}
 			args := make(map[string]any)
 			if bs.toolArgs != "" {
 				if err := json.Unmarshal([]byte(bs.toolArgs), &args); err != nil {
@@ -375,12 +434,14 @@ func (m *AnthropicModel) emitFinalResponse(
 		}
 	}
 
+	meta.StopReason = stopReason
 	finalResp := &model.LLMResponse{
-		Content:       aggregated,
-		UsageMetadata: convertUsage(usage),
-		FinishReason:  convertStopReason(stopReason),
-		Partial:       false,
-		TurnComplete:  true,
+		Content:        aggregated,
+		UsageMetadata:  convertUsage(usage),
+		FinishReason:   convertStopReason(stopReason),
+		CustomMetadata: meta.Map(),
+		Partial:        false,
+		TurnComplete:   true,
 	}
 	yield(finalResp, nil)
 }
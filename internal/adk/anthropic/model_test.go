@@ -3,11 +3,15 @@ package anthropic
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
 )
@@ -75,7 +79,7 @@ func TestNormalizeBaseURL(t *testing.T) {
 }
 
 func TestNewAnthropicModel_NormalizesBaseURL(t *testing.T) {
-	m := NewAnthropicModel("claude-sonnet-4", "key", "https://api.anthropic.com/v1/", http.DefaultClient, false)
+	m := NewAnthropicModel("claude-sonnet-4", "key", "https://api.anthropic.com/v1/", http.DefaultClient, false, true)
 	if strings.Contains(m.baseURL, "/v1") {
 		t.Fatalf("expected normalized baseURL without /v1, got %q", m.baseURL)
 	}
@@ -250,6 +254,90 @@ func TestToAnthropicMessages_MergeConsecutiveRoles(t *testing.T) {
 }
 
 // 集成测试：需要设置环境变量 ANTHROPIC_TEST_URL 和 ANTHROPIC_TEST_KEY
+func TestCountTokens(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages/count_tokens" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := body["max_tokens"]; ok || body["system"] != "你是分析师" {
+			t.Errorf("body = %v", body)
+		}
+		_, _ = w.Write([]byte(`{"input_tokens": 1234}`))
+	}))
+	defer srv.Close()
+
+	m := NewAnthropicModel("claude-sonnet-4-5", "key", srv.URL, srv.Client(), false, true)
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("分析一下", genai.RoleUser)},
+		Config:   &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText("你是分析师", genai.RoleUser)},
+	}
+	n, err := m.CountTokens(context.Background(), req)
+	if err != nil || n != 1234 {
+		t.Fatalf("n=%d err=%v", n, err)
+	}
+}
+
+// rewriteTransport 把请求转发到测试服务器，并记录原始地址
+type rewriteTransport struct {
+	target string
+	urls   []string
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.urls = append(t.urls, req.URL.String())
+	u := *req.URL
+	u.Scheme, u.Host = "http", strings.TrimPrefix(t.target, "http://")
+	req.URL = &u
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestVertexAnthropicModel_RawPredict(t *testing.T) {
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(data, &body)
+		bodies = append(bodies, body)
+		if r.Header.Get("x-api-key") != "" {
+			t.Error("vertex request should not carry x-api-key")
+		}
+		if strings.Contains(r.URL.Path, "count-tokens") {
+			_, _ = w.Write([]byte(`{"input_tokens": 42}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"msg_1","model":"claude-sonnet-4@20250514","stop_reason":"end_turn","content":[{"type":"text","text":"PONG"}]}`))
+	}))
+	defer srv.Close()
+
+	rt := &rewriteTransport{target: srv.URL}
+	m := NewVertexAnthropicModel("claude-sonnet-4@20250514", "my-proj", "us-east5", &http.Client{Transport: rt}, false)
+	req := &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("ping", genai.RoleUser)}}
+	for resp, err := range m.GenerateContent(context.Background(), req, false) {
+		if err != nil || resp.Content.Parts[0].Text != "PONG" {
+			t.Fatalf("resp=%+v err=%v", resp, err)
+		}
+	}
+	if n, err := m.CountTokens(context.Background(), req); err != nil || n != 42 {
+		t.Fatalf("count=%d err=%v", n, err)
+	}
+
+	wantURLs := []string{
+		"https://us-east5-aiplatform.googleapis.com/v1/projects/my-proj/locations/us-east5/publishers/anthropic/models/claude-sonnet-4@20250514:rawPredict",
+		"https://us-east5-aiplatform.googleapis.com/v1/projects/my-proj/locations/us-east5/publishers/anthropic/models/count-tokens:rawPredict",
+	}
+	if len(rt.urls) != 2 || rt.urls[0] != wantURLs[0] || rt.urls[1] != wantURLs[1] {
+		t.Fatalf("urls = %v", rt.urls)
+	}
+	if _, ok := bodies[0]["model"]; ok || bodies[0]["anthropic_version"] != vertexAnthropicVersion {
+		t.Fatalf("generate body = %v", bodies[0])
+	}
+	if bodies[1]["model"] != "claude-sonnet-4@20250514" {
+		t.Fatalf("count body = %v", bodies[1])
+	}
+}
+
 func TestIntegration_NonStreaming(t *testing.T) {
 	baseURL := os.Getenv("ANTHROPIC_TEST_URL")
 	apiKey := os.Getenv("ANTHROPIC_TEST_KEY")
@@ -257,7 +345,7 @@ func TestIntegration_NonStreaming(t *testing.T) {
 		t.Skip("跳过集成测试：未设置 ANTHROPIC_TEST_URL / ANTHROPIC_TEST_KEY")
 	}
 
-	m := NewAnthropicModel("claude-opus-4-6", apiKey, baseURL, http.DefaultClient, false)
+	m := NewAnthropicModel("claude-opus-4-6", apiKey, baseURL, http.DefaultClient, false, true)
 	req := &model.LLMRequest{
 		Contents: []*genai.Content{
 			{Role: "user", Parts: []*genai.Part{{Text: "Reply with exactly: PONG"}}},
@@ -288,7 +376,7 @@ func TestIntegration_Streaming(t *testing.T) {
 		t.Skip("跳过集成测试：未设置 ANTHROPIC_TEST_URL / ANTHROPIC_TEST_KEY")
 	}
 
-	m := NewAnthropicModel("claude-opus-4-6", apiKey, baseURL, http.DefaultClient, false)
+	m := NewAnthropicModel("claude-opus-4-6", apiKey, baseURL, http.DefaultClient, false, true)
 	req := &model.LLMRequest{
 		Contents: []*genai.Content{
 			{Role: "user", Parts: []*genai.Part{{Text: "Reply with exactly: PONG"}}},
@@ -313,3 +401,254 @@ func TestIntegration_Streaming(t *testing.T) {
 		t.Error("never received TurnComplete response")
 	}
 }
+
+func TestStructuredOutput_ForcedTool(t *testing.T) {
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{{Role: "user", Parts: []*genai.Part{{Text: "评分"}}}},
+		Config: &genai.GenerateContentConfig{
+			ResponseMIMEType:   "application/json",
+			ResponseJsonSchema: map[string]any{"type": "object", "properties": map[string]any{"score": map[string]any{"type": "integer"}}},
+		},
+	}
+	ar, err := toAnthropicRequest(req, "claude", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ar.Tools) != 1 || ar.Tools[0].Name != "structured_output" ||
+		ar.ToolChoice == nil || ar.ToolChoice.Type != "tool" || ar.ToolChoice.Name != "structured_output" {
+		t.Fatalf("tools = %+v, tool_choice = %+v", ar.Tools, ar.ToolChoice)
+	}
+
+	resp, err := convertAnthropicResponse(&MessagesResponse{
+		StopReason: "tool_use",
+		Content:    []ContentBlock{{Type: "tool_use", ID: "t1", Name: "structured_output", Input: json.RawMessage(`{"score":8}`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts := resp.Content.Parts; len(parts) != 1 || parts[0].FunctionCall != nil || parts[0].Text != `{"score":8}` {
+		t.Fatalf("parts = %+v", parts)
+	}
+}
+
+func TestToAnthropicRequest_ToolChoice(t *testing.T) {
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+		{Name: "get_kline", Parameters: &genai.Schema{Type: genai.TypeObject}},
+		{Name: "get_news", Parameters: &genai.Schema{Type: genai.TypeObject}},
+	}}}
+	tests := []struct {
+		mode    genai.FunctionCallingConfigMode
+		allowed []string
+		want    ToolChoice
+		tools   int
+	}{
+		{genai.FunctionCallingConfigModeAuto, nil, ToolChoice{Type: "auto"}, 2},
+		{genai.FunctionCallingConfigModeNone, nil, ToolChoice{Type: "none"}, 2},
+		{genai.FunctionCallingConfigModeAny, nil, ToolChoice{Type: "any"}, 2},
+		{genai.FunctionCallingConfigModeAny, []string{"get_news"}, ToolChoice{Type: "tool", Name: "get_news"}, 1},
+	}
+	for _, tt := range tests {
+		req := &model.LLMRequest{
+			Contents: []*genai.Content{{Role: "user", Parts: []*genai.Part{{Text: "hi"}}}},
+			Config: &genai.GenerateContentConfig{
+				Tools: tools,
+				ToolConfig: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{
+					Mode: tt.mode, AllowedFunctionNames: tt.allowed,
+				}},
+			},
+		}
+		ar, err := toAnthropicRequest(req, "claude", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ar.ToolChoice == nil || *ar.ToolChoice != tt.want || len(ar.Tools) != tt.tools {
+			t.Errorf("mode %s: tool_choice = %+v, tools = %d", tt.mode, ar.ToolChoice, len(ar.Tools))
+		}
+	}
+}
+
+func TestToAnthropicMessages_ImageAndDocument(t *testing.T) {
+	contents := []*genai.Content{{Role: "user", Parts: []*genai.Part{
+		genai.NewPartFromBytes([]byte("\xff\xd8\xff"), "image/jpg"),
+		genai.NewPartFromURI("https://example.com/chart.png", "image/png"),
+		{InlineData: &genai.Blob{Data: []byte("%PDF"), MIMEType: "application/pdf", DisplayName: "研报.pdf"}},
+		{Text: "分析走势"},
+	}}}
+
+	msgs, err := toAnthropicMessages(contents)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ := json.Marshal(msgs[0].Content)
+	want := `[{"type":"image","source":{"type":"base64","media_type":"image/jpeg","data":"/9j/"}},` +
+		`{"type":"image","source":{"type":"url","url":"https://example.com/chart.png"}},` +
+		`{"type":"document","source":{"type":"base64","media_type":"application/pdf","data":"JVBERg=="},"title":"研报.pdf"},` +
+		`{"type":"text","text":"分析走势"}]`
+	if string(data) != want {
+		t.Errorf("got %s\nwant %s", data, want)
+	}
+}
+
+func TestProcessStream_CacheUsage(t *testing.T) {
+	// message_delta 只带输出 token，不能覆盖 message_start 中的输入与缓存用量
+	stream := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","model":"claude","usage":{"input_tokens":10,"output_tokens":1,"cache_creation_input_tokens":20,"cache_read_input_tokens":300}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"ok"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":5}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	m := &AnthropicModel{}
+	var final *model.LLMResponse
+	m.processStream(strings.NewReader(stream), http.Header{}, func(resp *model.LLMResponse, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Partial {
+			final = resp
+		}
+		return true
+	})
+
+	if final == nil || final.UsageMetadata == nil {
+		t.Fatalf("final response = %#v", final)
+	}
+	u := final.UsageMetadata
+	if u.PromptTokenCount != 330 || u.CachedContentTokenCount != 300 || u.CandidatesTokenCount != 5 || u.TotalTokenCount != 335 {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+func TestProcessStream_ToolCallDelta(t *testing.T) {
+	stream := `event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_quote","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"code\":"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"600519\"}"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":5}}
+
+`
+	m := &AnthropicModel{}
+	var deltas []respmeta.ToolCallDelta
+	var final *model.LLMResponse
+	m.processStream(strings.NewReader(stream), http.Header{}, func(resp *model.LLMResponse, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Partial {
+			final = resp
+			return true
+		}
+		// 进度事件不能携带 FunctionCall，否则会被提前执行
+		if len(resp.Content.Parts) != 0 {
+			t.Fatalf("partial response has parts: %#v", resp.Content.Parts)
+		}
+		if d, ok := respmeta.ToolCallDeltaFromCustomMetadata(resp.CustomMetadata); ok {
+			deltas = append(deltas, d)
+		}
+		return true
+	})
+
+	if len(deltas) != 3 || deltas[0].Name != "get_quote" || deltas[2].Arguments != `{"code":"600519"}` {
+		t.Fatalf("deltas = %+v", deltas)
+	}
+	if final == nil || len(final.Content.Parts) != 1 || final.Content.Parts[0].FunctionCall.Args["code"] != "600519" {
+		t.Fatalf("final response = %#v", final)
+	}
+}
+
+func TestApplyCacheControl(t *testing.T) {
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			{Role: "user", Parts: []*genai.Part{{Text: "分析茅台"}}},
+			{Role: "model", Parts: []*genai.Part{{Text: "思考", Thought: true}, {Text: "好的"}}},
+		},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: &genai.Content{Parts: []*genai.Part{{Text: "你是分析师"}}},
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+				{Name: "get_quote"}, {Name: "get_kline"},
+			}}},
+		},
+	}
+	ar, err := toAnthropicRequest(req, "claude", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyCacheControl(ar)
+
+	data, err := json.Marshal(ar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		System   []map[string]any `json:"system"`
+		Tools    []map[string]any `json:"tools"`
+		Messages []struct {
+			Content []map[string]any `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("system 应为文本块数组: %v\n%s", err, data)
+	}
+	if len(raw.System) != 1 || raw.System[0]["text"] != "你是分析师" || raw.System[0]["cache_control"] == nil {
+		t.Errorf("system = %v", raw.System)
+	}
+	if raw.Tools[0]["cache_control"] != nil || raw.Tools[1]["cache_control"] == nil {
+		t.Errorf("只有最后一个工具应设置断点: %v", raw.Tools)
+	}
+	last := raw.Messages[len(raw.Messages)-1].Content
+	if last[len(last)-1]["cache_control"] == nil || last[len(last)-1]["text"] != "好的" {
+		t.Errorf("最后一个内容块应设置断点: %v", last)
+	}
+
+	// 未设置断点时 system 保持字符串
+	plain, _ := toAnthropicRequest(req, "claude", false)
+	data, _ = json.Marshal(plain)
+	if !strings.Contains(string(data), `"system":"你是分析师"`) || strings.Contains(string(data), "cache_control") {
+		t.Errorf("plain request = %s", data)
+	}
+}
+
+func TestMaxTokensDefaults(t *testing.T) {
+	cases := map[string]int{
+		"claude-sonnet-4-5-20250929":                           64000,
+		"anthropic/claude-opus-4-1":                            32000,
+		"claude-3-5-haiku-latest":                              8192,
+		"claude-3-haiku-20240307":                              4096,
+		"some-gateway-model":                                   fallbackMaxTokens,
+		"publishers/anthropic/models/claude-sonnet-4@20250514": 64000,
+	}
+	for name, want := range cases {
+		if got := DefaultMaxTokens(name); got != want {
+			t.Errorf("DefaultMaxTokens(%q) = %d, want %d", name, got, want)
+		}
+	}
+
+	m := NewAnthropicModel("claude-sonnet-4-5", "k", "", http.DefaultClient, false, false)
+	req := &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("hi", "user")}}
+	if ar, _ := m.toRequest(req); ar.MaxTokens != 64000 {
+		t.Fatalf("model default = %d", ar.MaxTokens)
+	}
+	m.MaxTokens = 16000
+	if ar, _ := m.toRequest(req); ar.MaxTokens != 16000 {
+		t.Fatalf("config override = %d", ar.MaxTokens)
+	}
+	req.Config = &genai.GenerateContentConfig{MaxOutputTokens: 2000}
+	if ar, _ := m.toRequest(req); ar.MaxTokens != 2000 {
+		t.Fatalf("request override = %d", ar.MaxTokens)
+	}
+}
@@ -13,6 +13,33 @@ type MessagesRequest struct {
 	Stream      bool      `json:"stream,omitempty"`
 	Tools       []Tool    `json:"tools,omitempty"`
 	StopSequences []string `json:"stop_sequences,omitempty"`
+	ToolChoice  *ToolChoice `json:"tool_choice,omitempty"`
+
+	// 非空时 system 以文本块数组发送并附带缓存断点
+	SystemCacheControl *CacheControl `json:"-"`
+}
+
+// MarshalJSON system 设置缓存断点时改用文本块数组（字符串形式无法携带 cache_control）
+func (r MessagesRequest) MarshalJSON() ([]byte, error) {
+	type Alias MessagesRequest
+	if r.SystemCacheControl == nil || r.System == "" {
+		return json.Marshal(Alias(r))
+	}
+	return json.Marshal(struct {
+		Alias
+		System []ContentBlock `json:"system"`
+	}{Alias(r), []ContentBlock{{Type: "text", Text: r.System, CacheControl: r.SystemCacheControl}}})
+}
+
+// CacheControl 提示缓存断点，断点之前的前缀会被缓存
+type CacheControl struct {
+	Type string `json:"type"` // ephemeral
+}
+
+// ToolChoice 工具选择策略：auto / any / tool（指定 Name）
+type ToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
 }
 
 // Message 消息
@@ -24,7 +51,7 @@ type Message struct {
 // ContentBlock 内容块（多态）
 // 使用自定义 MarshalJSON 按 Type 输出不同字段，避免序列化冲突
 type ContentBlock struct {
-	Type string `json:"type"` // text / image / tool_use / tool_result / thinking
+	Type string `json:"type"` // text / image / document / tool_use / tool_result / thinking
 
 	// text
 	Text string `json:"text,omitempty"`
@@ -32,6 +59,10 @@ type ContentBlock struct {
 	// thinking
 	Thinking string `json:"thinking,omitempty"`
 
+	// image / document
+	Source *MediaSource `json:"source,omitempty"`
+	Title  string       `json:"title,omitempty"` // document
+
 	// tool_use
 	ID    string          `json:"id,omitempty"`
 	Name  string          `json:"name,omitempty"`
@@ -41,10 +72,28 @@ type ContentBlock struct {
 	ToolUseID  string          `json:"tool_use_id,omitempty"`
 	RawContent json.RawMessage `json:"-"` // 自定义序列化，不走默认 tag
 	IsError    bool            `json:"is_error,omitempty"`
+
+	CacheControl *CacheControl `json:"-"` // 由 MarshalJSON 追加
 }
 
 // MarshalJSON 按 Type 输出对应字段，避免多余字段导致 Anthropic 拒绝
 func (b ContentBlock) MarshalJSON() ([]byte, error) {
+	data, err := b.marshalFields()
+	if err != nil || b.CacheControl == nil {
+		return data, err
+	}
+	cc, err := json.Marshal(b.CacheControl)
+	if err != nil {
+		return nil, err
+	}
+	// 在对象末尾追加 cache_control 字段
+	out := append(data[:len(data)-1:len(data)-1], `,"cache_control":`...)
+	out = append(out, cc...)
+	return append(out, '}'), nil
+}
+
+// marshalFields 序列化 Type 对应的字段
+func (b ContentBlock) marshalFields() ([]byte, error) {
 	switch b.Type {
 	case "text":
 		return json.Marshal(struct {
@@ -56,6 +105,17 @@ func (b ContentBlock) MarshalJSON() ([]byte, error) {
 			Type     string `json:"type"`
 			Thinking string `json:"thinking"`
 		}{b.Type, b.Thinking})
+	case "image":
+		return json.Marshal(struct {
+			Type   string       `json:"type"`
+			Source *MediaSource `json:"source"`
+		}{b.Type, b.Source})
+	case "document":
+		return json.Marshal(struct {
+			Type   string       `json:"type"`
+			Source *MediaSource `json:"source"`
+			Title  string       `json:"title,omitempty"`
+		}{b.Type, b.Source, b.Title})
 	case "tool_use":
 		return json.Marshal(struct {
 			Type  string          `json:"type"`
@@ -77,11 +137,20 @@ func (b ContentBlock) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// MediaSource 图片/文档来源：base64 内联数据或远程 URL
+type MediaSource struct {
+	Type      string `json:"type"`                 // base64 / url
+	MediaType string `json:"media_type,omitempty"` // base64 时必填，如 image/png、application/pdf
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
 // Tool 工具定义
 type Tool struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description,omitempty"`
-	InputSchema json.RawMessage `json:"input_schema"`
+	Name         string          `json:"name"`
+	Description  string          `json:"description,omitempty"`
+	InputSchema  json.RawMessage `json:"input_schema"`
+	CacheControl *CacheControl   `json:"cache_control,omitempty"`
 }
 
 // ---- 响应类型 ----
@@ -100,8 +169,10 @@ type MessagesResponse struct {
 
 // Usage token 用量
 type Usage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"` // 未命中缓存的输入 token
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"` // 写入提示缓存的输入 token
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`     // 命中提示缓存的输入 token
 }
 
 // ---- SSE 事件类型 ----
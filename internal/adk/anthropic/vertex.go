@@ -0,0 +1,54 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// vertexAnthropicVersion Vertex AI 要求在请求体中声明的 API 版本
+const vertexAnthropicVersion = "vertex-2023-10-16"
+
+// vertexTarget Vertex AI 项目与区域
+type vertexTarget struct {
+	project  string
+	location string
+}
+
+// NewVertexAnthropicModel 创建 Vertex AI 上的 Claude 模型（publishers/anthropic），
+// modelName 形如 claude-sonnet-4@20250514，httpClient 需已注入 Google 凭证；location 为空时使用 global
+func NewVertexAnthropicModel(modelName, project, location string, httpClient *http.Client, promptCache bool) *AnthropicModel {
+	if location == "" {
+		location = "global"
+	}
+	return &AnthropicModel{
+		httpClient:  httpClient,
+		modelName:   modelName,
+		promptCache: promptCache,
+		vertex:      &vertexTarget{project: project, location: location},
+	}
+}
+
+// endpoint 返回 publishers/anthropic/models/{model}:{method} 接口地址，global 区域不带区域前缀
+func (v *vertexTarget) endpoint(modelName, method string) string {
+	host := "aiplatform.googleapis.com"
+	if v.location != "global" {
+		host = v.location + "-" + host
+	}
+	return fmt.Sprintf("https://%s/v1/projects/%s/locations/%s/publishers/anthropic/models/%s:%s",
+		host, url.PathEscape(v.project), url.PathEscape(v.location), url.PathEscape(modelName), method)
+}
+
+// vertexBody 转换为 Vertex AI 请求体：附带 anthropic_version；模型由 URL 指定时移除 model 字段
+func vertexBody(body []byte, keepModel bool) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	if !keepModel {
+		delete(fields, "model")
+	}
+	fields["anthropic_version"], _ = json.Marshal(vertexAnthropicVersion)
+	return json.Marshal(fields)
+}
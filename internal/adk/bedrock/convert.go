@@ -0,0 +1,238 @@
+package bedrock
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+var convertLog = logger.New("bedrock:convert")
+
+// defaultMaxTokens 未指定时的最大输出 token
+const defaultMaxTokens = 4096
+
+// toConverseRequest 将 ADK LLMRequest 转换为 Converse 请求
+func toConverseRequest(req *model.LLMRequest, noSystemRole bool) (*ConverseRequest, error) {
+	cr := &ConverseRequest{
+		InferenceConfig: &InferenceConfig{MaxTokens: defaultMaxTokens},
+	}
+
+	msgs, err := toConverseMessages(req.Contents)
+	if err != nil {
+		return nil, err
+	}
+
+	// 系统指令：部分模型（如旧版 Llama 网关）不支持 system，降级为首条 user 消息
+	var systemText string
+	if req.Config != nil && req.Config.SystemInstruction != nil {
+		systemText = extractText(req.Config.SystemInstruction)
+	}
+	if systemText != "" {
+		if !noSystemRole {
+			cr.System = []SystemBlock{{Text: systemText}}
+		} else if len(msgs) > 0 && msgs[0].Role == "user" {
+			msgs[0].Content = append([]ContentBlock{{Text: systemText}}, msgs[0].Content...)
+		} else {
+			msgs = append([]Message{{Role: "user", Content: []ContentBlock{{Text: systemText}}}}, msgs...)
+		}
+	}
+	cr.Messages = msgs
+
+	if req.Config == nil {
+		return cr, nil
+	}
+
+	if len(req.Config.Tools) > 0 {
+		tools, err := convertTools(req.Config.Tools)
+		if err != nil {
+			return nil, err
+		}
+		if len(tools) > 0 {
+			cr.ToolConfig = &ToolConfig{Tools: tools}
+		}
+	}
+
+	ic := cr.InferenceConfig
+	if req.Config.MaxOutputTokens > 0 {
+		ic.MaxTokens = int(req.Config.MaxOutputTokens)
+	}
+	if req.Config.Temperature != nil {
+		t := float64(*req.Config.Temperature)
+		ic.Temperature = &t
+	}
+	if req.Config.TopP != nil {
+		p := float64(*req.Config.TopP)
+		ic.TopP = &p
+	}
+	if len(req.Config.StopSequences) > 0 {
+		ic.StopSequences = req.Config.StopSequences
+	}
+	return cr, nil
+}
+
+// extractText 提取 genai.Content 中的纯文本
+func extractText(content *genai.Content) string {
+	var texts []string
+	for _, part := range content.Parts {
+		if part.Text != "" && !part.Thought {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// toConverseMessages 转换消息列表，Converse 要求 user/assistant 严格交替
+func toConverseMessages(contents []*genai.Content) ([]Message, error) {
+	var msgs []Message
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		role := "user"
+		if content.Role == genai.RoleModel {
+			role = "assistant"
+		}
+
+		var blocks []ContentBlock
+		for _, part := range content.Parts {
+			if part.Thought {
+				continue
+			}
+			if part.Text != "" {
+				blocks = append(blocks, ContentBlock{Text: part.Text})
+			}
+			if fc := part.FunctionCall; fc != nil {
+				args := fc.Args
+				if args == nil {
+					args = map[string]any{}
+				}
+				input, err := json.Marshal(args)
+				if err != nil {
+					return nil, fmt.Errorf("marshal function call args: %w", err)
+				}
+				blocks = append(blocks, ContentBlock{ToolUse: &ToolUseBlock{
+					ToolUseID: fc.ID,
+					Name:      fc.Name,
+					Input:     input,
+				}})
+			}
+			if fr := part.FunctionResponse; fr != nil {
+				blocks = append(blocks, ContentBlock{ToolResult: &ToolResultBlock{
+					ToolUseID: fr.ID,
+					Content:   []ToolResultContent{{JSON: fr.Response}},
+					Status:    "success",
+				}})
+			}
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		if len(msgs) > 0 && msgs[len(msgs)-1].Role == role {
+			msgs[len(msgs)-1].Content = append(msgs[len(msgs)-1].Content, blocks...)
+		} else {
+			msgs = append(msgs, Message{Role: role, Content: blocks})
+		}
+	}
+	return msgs, nil
+}
+
+// convertTools 将 genai.Tool 转换为 Converse 工具定义
+func convertTools(genaiTools []*genai.Tool) ([]Tool, error) {
+	var tools []Tool
+	for _, gt := range genaiTools {
+		if gt == nil {
+			continue
+		}
+		for _, fd := range gt.FunctionDeclarations {
+			var schema any = fd.ParametersJsonSchema
+			if fd.ParametersJsonSchema == nil {
+				schema = fd.Parameters
+			}
+			if fd.ParametersJsonSchema == nil && fd.Parameters == nil {
+				schema = map[string]any{"type": "object", "properties": map[string]any{}}
+			}
+			schemaJSON, err := json.Marshal(schema)
+			if err != nil {
+				return nil, fmt.Errorf("marshal tool schema: %w", err)
+			}
+			tools = append(tools, Tool{ToolSpec: ToolSpec{
+				Name:        fd.Name,
+				Description: fd.Description,
+				InputSchema: InputSchema{JSON: schemaJSON},
+			}})
+		}
+	}
+	return tools, nil
+}
+
+// convertConverseResponse 将 Converse 响应转换为 ADK LLMResponse
+func convertConverseResponse(resp *ConverseResponse) *model.LLMResponse {
+	content := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{}}
+	for _, block := range resp.Output.Message.Content {
+		switch {
+		case block.ReasoningContent != nil && block.ReasoningContent.ReasoningText != nil:
+			content.Parts = append(content.Parts, &genai.Part{Text: block.ReasoningContent.ReasoningText.Text, Thought: true})
+		case block.ToolUse != nil:
+			content.Parts = append(content.Parts, &genai.Part{FunctionCall: &genai.FunctionCall{
+				ID:   block.ToolUse.ToolUseID,
+				Name: block.ToolUse.Name,
+				Args: parseArgs(block.ToolUse.Input),
+			}})
+		case block.Text != "":
+			content.Parts = append(content.Parts, &genai.Part{Text: block.Text})
+		}
+	}
+	return &model.LLMResponse{
+		Content:       content,
+		UsageMetadata: convertUsage(&resp.Usage),
+		FinishReason:  convertStopReason(resp.StopReason),
+		TurnComplete:  true,
+	}
+}
+
+// parseArgs 解析工具参数 JSON
+func parseArgs(raw []byte) map[string]any {
+	args := make(map[string]any)
+	if len(raw) == 0 {
+		return args
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		convertLog.Warn("解析 toolUse input 失败: %v", err)
+	}
+	return args
+}
+
+// convertUsage 转换 token 用量
+func convertUsage(u *Usage) *genai.GenerateContentResponseUsageMetadata {
+	if u == nil {
+		return nil
+	}
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.InputTokens + u.OutputTokens
+	}
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     int32(u.InputTokens),
+		CandidatesTokenCount: int32(u.OutputTokens),
+		TotalTokenCount:      int32(total),
+	}
+}
+
+// convertStopReason 转换停止原因
+func convertStopReason(reason string) genai.FinishReason {
+	switch reason {
+	case "end_turn", "stop_sequence", "tool_use":
+		return genai.FinishReasonStop
+	case "max_tokens":
+		return genai.FinishReasonMaxTokens
+	case "guardrail_intervened", "content_filtered":
+		return genai.FinishReasonSafety
+	default:
+		return genai.FinishReasonUnspecified
+	}
+}
@@ -0,0 +1,131 @@
+package bedrock
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// maxEventFrameSize 单个事件帧上限，防止异常长度导致内存暴涨
+const maxEventFrameSize = 16 * 1024 * 1024
+
+// streamEvent AWS event-stream 帧
+type streamEvent struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// eventType 事件类型（:event-type 头）
+func (e *streamEvent) eventType() string {
+	return e.Headers[":event-type"]
+}
+
+// isException 是否为异常帧
+func (e *streamEvent) isException() bool {
+	t := e.Headers[":message-type"]
+	return t == "exception" || t == "error"
+}
+
+// exceptionType 异常类型
+func (e *streamEvent) exceptionType() string {
+	if t := e.Headers[":exception-type"]; t != "" {
+		return t
+	}
+	return e.Headers[":error-code"]
+}
+
+// eventStreamReader 解析 application/vnd.amazon.eventstream 二进制帧
+// 帧结构：总长度(4) + 头长度(4) + 前导CRC(4) + 头 + 负载 + 消息CRC(4)
+type eventStreamReader struct {
+	r io.Reader
+}
+
+func newEventStreamReader(r io.Reader) *eventStreamReader {
+	return &eventStreamReader{r: r}
+}
+
+// next 读取下一帧，流结束时返回 io.EOF
+func (er *eventStreamReader) next() (*streamEvent, error) {
+	prelude := make([]byte, 12)
+	if _, err := io.ReadFull(er.r, prelude); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("事件帧不完整: %w", err)
+		}
+		return nil, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, fmt.Errorf("事件帧前导校验失败")
+	}
+	if totalLen < 16 || totalLen > maxEventFrameSize || headersLen > totalLen-16 {
+		return nil, fmt.Errorf("事件帧长度异常: total=%d headers=%d", totalLen, headersLen)
+	}
+
+	rest := make([]byte, totalLen-12)
+	if _, err := io.ReadFull(er.r, rest); err != nil {
+		return nil, fmt.Errorf("读取事件帧失败: %w", err)
+	}
+	msgCRC := binary.BigEndian.Uint32(rest[len(rest)-4:])
+	crc := crc32.NewIEEE()
+	crc.Write(prelude)
+	crc.Write(rest[:len(rest)-4])
+	if crc.Sum32() != msgCRC {
+		return nil, fmt.Errorf("事件帧校验失败")
+	}
+
+	headers, err := parseEventHeaders(rest[:headersLen])
+	if err != nil {
+		return nil, err
+	}
+	return &streamEvent{
+		Headers: headers,
+		Payload: rest[headersLen : len(rest)-4],
+	}, nil
+}
+
+// parseEventHeaders 解析帧头，仅保留字符串类型的值
+func parseEventHeaders(b []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, fmt.Errorf("事件头格式错误")
+		}
+		name := string(b[1 : 1+nameLen])
+		valueType := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case 0, 1: // bool true / false
+			size = 0
+		case 2: // byte
+			size = 1
+		case 3: // int16
+			size = 2
+		case 4: // int32
+			size = 4
+		case 5, 8: // int64 / timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // bytes / string，2 字节长度前缀
+			if len(b) < 2 {
+				return nil, fmt.Errorf("事件头格式错误")
+			}
+			size = 2 + int(binary.BigEndian.Uint16(b[:2]))
+		default:
+			return nil, fmt.Errorf("未知事件头类型: %d", valueType)
+		}
+		if len(b) < size {
+			return nil, fmt.Errorf("事件头格式错误")
+		}
+		if valueType == 7 {
+			headers[name] = string(b[2:size])
+		}
+		b = b[size:]
+	}
+	return headers, nil
+}
@@ -0,0 +1,305 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/adk/providererr"
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+	"github.com/run-bigpig/jcp/internal/logger"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// 确保实现 model.LLM 接口
+var _ model.LLM = &BedrockModel{}
+
+var modelLog = logger.New("bedrock:model")
+
+// BedrockModel 通过 Converse API 调用 AWS Bedrock 模型（Claude、Llama 等）
+type BedrockModel struct {
+	httpClient   *http.Client
+	region       string
+	modelID      string
+	creds        Credentials
+	endpoint     string
+	noSystemRole bool
+}
+
+// NewBedrockModel 创建 Bedrock 模型
+// endpoint 为空时使用 https://bedrock-runtime.{region}.amazonaws.com
+func NewBedrockModel(modelID, region, endpoint string, creds Credentials, httpClient *http.Client, noSystemRole bool) (*BedrockModel, error) {
+	if region == "" {
+		return nil, fmt.Errorf("未配置 AWS Region")
+	}
+	resolved, err := resolveCredentials(creds)
+	if err != nil {
+		return nil, err
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", region)
+	}
+	return &BedrockModel{
+		httpClient:   httpClient,
+		region:       region,
+		modelID:      modelID,
+		creds:        resolved,
+		endpoint:     strings.TrimRight(endpoint, "/"),
+		noSystemRole: noSystemRole,
+	}, nil
+}
+
+// Name 返回模型名称
+func (m *BedrockModel) Name() string {
+	return m.modelID
+}
+
+// GenerateContent 实现 model.LLM 接口
+func (m *BedrockModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	if stream {
+		return m.generateStream(ctx, req)
+	}
+	return m.generate(ctx, req)
+}
+
+// doRequest 签名并发送请求，action 为 converse 或 converse-stream
+func (m *BedrockModel) doRequest(ctx context.Context, cr *ConverseRequest, action string) (*http.Response, error) {
+	body, err := json.Marshal(cr)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	// 模型 ID / 推理配置 ARN 中的 ':' 和 '/' 需编码
+	escapedID := strings.ReplaceAll(url.PathEscape(m.modelID), ":", "%3A")
+	u, err := url.Parse(m.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	basePath := u.EscapedPath()
+	u.Path += "/model/" + m.modelID + "/" + action
+	u.RawPath = basePath + "/model/" + escapedID + "/" + action
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if action == "converse-stream" {
+		httpReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+	if err := signRequest(httpReq, m.creds, m.region, time.Now()); err != nil {
+		return nil, err
+	}
+
+	resp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		message := string(respBody)
+		var errResp ErrorResponse
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Message != "" {
+			message = errResp.Message
+		}
+		pe := providererr.New("Bedrock", resp.StatusCode, strings.SplitN(resp.Header.Get("X-Amzn-Errortype"), ":", 2)[0], message).WithHeader(resp.Header).WithBody(respBody)
+		modelLog.Warn("%s 请求 %s 失败: %v，响应体: %s", m.modelID, providererr.Endpoint(httpReq.URL.String()), pe, pe.Body)
+		return nil, pe
+	}
+	return resp, nil
+}
+
+// generate 非流式生成
+func (m *BedrockModel) generate(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		cr, err := toConverseRequest(req, m.noSystemRole)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		resp, err := m.doRequest(ctx, cr, "converse")
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		var cresp ConverseResponse
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 10*1024*1024)).Decode(&cresp); err != nil {
+			yield(nil, fmt.Errorf("unmarshal response: %w", err))
+			return
+		}
+		llmResp := convertConverseResponse(&cresp)
+		llmResp.CustomMetadata = respmeta.Meta{
+			RequestID:    respmeta.RequestIDFromHeader(resp.Header),
+			ModelVersion: m.modelID,
+		}.Map()
+		yield(llmResp, nil)
+	}
+}
+
+// generateStream 流式生成（ConverseStream）
+func (m *BedrockModel) generateStream(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		cr, err := toConverseRequest(req, m.noSystemRole)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		resp, err := m.doRequest(ctx, cr, "converse-stream")
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		meta := respmeta.Meta{
+			RequestID:    respmeta.RequestIDFromHeader(resp.Header),
+			ModelVersion: m.modelID,
+		}
+		m.processStream(resp.Body, meta, yield)
+	}
+}
+
+// streamBlock 流式内容块状态
+type streamBlock struct {
+	text      string
+	reasoning string
+	toolID    string
+	toolName  string
+	toolInput string
+}
+
+// processStream 处理 event-stream 事件
+func (m *BedrockModel) processStream(body io.Reader, meta respmeta.Meta, yield func(*model.LLMResponse, error) bool) {
+	reader := newEventStreamReader(body)
+	blocks := make(map[int]*streamBlock)
+	var stopReason string
+	var usage *Usage
+
+	block := func(idx int) *streamBlock {
+		b, ok := blocks[idx]
+		if !ok {
+			b = &streamBlock{}
+			blocks[idx] = b
+		}
+		return b
+	}
+	emit := func(part *genai.Part) bool {
+		return yield(&model.LLMResponse{
+			Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{part}},
+			Partial: true,
+		}, nil)
+	}
+
+	for {
+		ev, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				yield(nil, &respmeta.StreamError{Err: fmt.Errorf("event-stream 读取错误: %w", err), Meta: meta})
+			}
+			return
+		}
+		if ev.isException() {
+			yield(nil, fmt.Errorf("Bedrock stream error (%s): %s", ev.exceptionType(), string(ev.Payload)))
+			return
+		}
+
+		switch ev.eventType() {
+		case "contentBlockStart":
+			var start StreamContentBlockStart
+			if err := json.Unmarshal(ev.Payload, &start); err != nil {
+				continue
+			}
+			if start.Start.ToolUse != nil {
+				b := block(start.ContentBlockIndex)
+				b.toolID = start.Start.ToolUse.ToolUseID
+				b.toolName = start.Start.ToolUse.Name
+			}
+
+		case "contentBlockDelta":
+			var delta StreamContentBlockDelta
+			if err := json.Unmarshal(ev.Payload, &delta); err != nil {
+				continue
+			}
+			b := block(delta.ContentBlockIndex)
+			switch {
+			case delta.Delta.Text != "":
+				b.text += delta.Delta.Text
+				if !emit(&genai.Part{Text: delta.Delta.Text}) {
+					return
+				}
+			case delta.Delta.ReasoningContent != nil && delta.Delta.ReasoningContent.Text != "":
+				b.reasoning += delta.Delta.ReasoningContent.Text
+				if !emit(&genai.Part{Text: delta.Delta.ReasoningContent.Text, Thought: true}) {
+					return
+				}
+			case delta.Delta.ToolUse != nil:
+				b.toolInput += delta.Delta.ToolUse.Input
+			}
+
+		case "messageStop":
+			var stop StreamMessageStop
+			if err := json.Unmarshal(ev.Payload, &stop); err == nil {
+				stopReason = stop.StopReason
+			}
+
+		case "metadata":
+			var md StreamMetadata
+			if err := json.Unmarshal(ev.Payload, &md); err == nil && md.Usage != nil {
+				usage = md.Usage
+			}
+		}
+	}
+
+	// 按块序号聚合最终响应
+	indices := make([]int, 0, len(blocks))
+	for idx := range blocks {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	content := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{}}
+	for _, idx := range indices {
+		b := blocks[idx]
+		if b.reasoning != "" {
+			content.Parts = append(content.Parts, &genai.Part{Text: b.reasoning, Thought: true})
+		}
+		if b.text != "" {
+			content.Parts = append(content.Parts, &genai.Part{Text: b.text})
+		}
+		if b.toolName != "" {
+			content.Parts = append(content.Parts, &genai.Part{FunctionCall: &genai.FunctionCall{
+				ID:   b.toolID,
+				Name: b.toolName,
+				Args: parseArgs([]byte(b.toolInput)),
+			}})
+		}
+	}
+
+	yield(&model.LLMResponse{
+		Content:        content,
+		UsageMetadata:  convertUsage(usage),
+		FinishReason:   convertStopReason(stopReason),
+		CustomMetadata: meta.Map(),
+		TurnComplete:   true,
+	}, nil)
+}
@@ -0,0 +1,79 @@
+package bedrock
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// encodeEvent 按 event-stream 格式编码一帧（仅字符串头）
+func encodeEvent(eventType, payload string) []byte {
+	var headers bytes.Buffer
+	for _, h := range [][2]string{{":message-type", "event"}, {":event-type", eventType}} {
+		headers.WriteByte(byte(len(h[0])))
+		headers.WriteString(h[0])
+		headers.WriteByte(7)
+		binary.Write(&headers, binary.BigEndian, uint16(len(h[1])))
+		headers.WriteString(h[1])
+	}
+
+	total := 12 + headers.Len() + len(payload) + 4
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(total))
+	binary.Write(&buf, binary.BigEndian, uint32(headers.Len()))
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	buf.Write(headers.Bytes())
+	buf.WriteString(payload)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+func TestProcessStream_TextAndToolUse(t *testing.T) {
+	var body bytes.Buffer
+	body.Write(encodeEvent("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"你好"}}`))
+	body.Write(encodeEvent("contentBlockStart", `{"contentBlockIndex":1,"start":{"toolUse":{"toolUseId":"t1","name":"get_quote"}}}`))
+	body.Write(encodeEvent("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"{\"code\":"}}}`))
+	body.Write(encodeEvent("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"toolUse":{"input":"\"sh600519\"}"}}}`))
+	body.Write(encodeEvent("messageStop", `{"stopReason":"tool_use"}`))
+	body.Write(encodeEvent("metadata", `{"usage":{"inputTokens":10,"outputTokens":5}}`))
+
+	m := &BedrockModel{modelID: "anthropic.claude-3-5-sonnet"}
+	var responses []*model.LLMResponse
+	m.processStream(&body, respmeta.Meta{RequestID: "req-1"}, func(r *model.LLMResponse, err error) bool {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		responses = append(responses, r)
+		return true
+	})
+
+	if len(responses) != 2 {
+		t.Fatalf("responses = %d, want 2", len(responses))
+	}
+	if !responses[0].Partial || responses[0].Content.Parts[0].Text != "你好" {
+		t.Errorf("partial response unexpected: %+v", responses[0])
+	}
+
+	final := responses[1]
+	if final.FinishReason != genai.FinishReasonStop {
+		t.Errorf("finish reason = %v, want STOP", final.FinishReason)
+	}
+	if final.UsageMetadata == nil || final.UsageMetadata.TotalTokenCount != 15 {
+		t.Errorf("usage unexpected: %+v", final.UsageMetadata)
+	}
+	if len(final.Content.Parts) != 2 {
+		t.Fatalf("parts = %d, want 2", len(final.Content.Parts))
+	}
+	fc := final.Content.Parts[1].FunctionCall
+	if fc == nil || fc.ID != "t1" || fc.Name != "get_quote" || fc.Args["code"] != "sh600519" {
+		t.Errorf("function call unexpected: %+v", fc)
+	}
+	if final.CustomMetadata[respmeta.KeyRequestID] != "req-1" {
+		t.Errorf("metadata unexpected: %+v", final.CustomMetadata)
+	}
+}
@@ -0,0 +1,153 @@
+package bedrock
+
+import "encoding/json"
+
+// ===== Converse API 请求 =====
+
+// ConverseRequest Converse / ConverseStream 请求体
+type ConverseRequest struct {
+	Messages        []Message        `json:"messages"`
+	System          []SystemBlock    `json:"system,omitempty"`
+	InferenceConfig *InferenceConfig `json:"inferenceConfig,omitempty"`
+	ToolConfig      *ToolConfig      `json:"toolConfig,omitempty"`
+}
+
+// Message 对话消息
+type Message struct {
+	Role    string         `json:"role"` // user / assistant
+	Content []ContentBlock `json:"content"`
+}
+
+// SystemBlock 系统指令
+type SystemBlock struct {
+	Text string `json:"text"`
+}
+
+// ContentBlock 内容块（各字段互斥）
+type ContentBlock struct {
+	Text             string            `json:"text,omitempty"`
+	ToolUse          *ToolUseBlock     `json:"toolUse,omitempty"`
+	ToolResult       *ToolResultBlock  `json:"toolResult,omitempty"`
+	ReasoningContent *ReasoningContent `json:"reasoningContent,omitempty"`
+}
+
+// ToolUseBlock 工具调用
+type ToolUseBlock struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+// ToolResultBlock 工具结果
+type ToolResultBlock struct {
+	ToolUseID string              `json:"toolUseId"`
+	Content   []ToolResultContent `json:"content"`
+	Status    string              `json:"status,omitempty"` // success / error
+}
+
+// ToolResultContent 工具结果内容
+type ToolResultContent struct {
+	JSON any    `json:"json,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+// ReasoningContent 推理内容（Claude extended thinking 等）
+type ReasoningContent struct {
+	ReasoningText *ReasoningText `json:"reasoningText,omitempty"`
+}
+
+// ReasoningText 推理文本
+type ReasoningText struct {
+	Text      string `json:"text"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// InferenceConfig 推理参数
+type InferenceConfig struct {
+	MaxTokens     int      `json:"maxTokens,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+// ToolConfig 工具配置
+type ToolConfig struct {
+	Tools []Tool `json:"tools"`
+}
+
+// Tool 工具定义
+type Tool struct {
+	ToolSpec ToolSpec `json:"toolSpec"`
+}
+
+// ToolSpec 工具规格
+type ToolSpec struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema InputSchema `json:"inputSchema"`
+}
+
+// InputSchema 工具参数 Schema
+type InputSchema struct {
+	JSON json.RawMessage `json:"json"`
+}
+
+// ===== Converse API 响应 =====
+
+// ConverseResponse Converse 响应
+type ConverseResponse struct {
+	Output struct {
+		Message Message `json:"message"`
+	} `json:"output"`
+	StopReason string `json:"stopReason"`
+	Usage      Usage  `json:"usage"`
+}
+
+// Usage token 用量
+type Usage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+}
+
+// ===== ConverseStream 事件 =====
+
+// StreamContentBlockStart contentBlockStart 事件
+type StreamContentBlockStart struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+	Start             struct {
+		ToolUse *struct {
+			ToolUseID string `json:"toolUseId"`
+			Name      string `json:"name"`
+		} `json:"toolUse,omitempty"`
+	} `json:"start"`
+}
+
+// StreamContentBlockDelta contentBlockDelta 事件
+type StreamContentBlockDelta struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+	Delta             struct {
+		Text    string `json:"text,omitempty"`
+		ToolUse *struct {
+			Input string `json:"input"`
+		} `json:"toolUse,omitempty"`
+		ReasoningContent *struct {
+			Text string `json:"text,omitempty"`
+		} `json:"reasoningContent,omitempty"`
+	} `json:"delta"`
+}
+
+// StreamMessageStop messageStop 事件
+type StreamMessageStop struct {
+	StopReason string `json:"stopReason"`
+}
+
+// StreamMetadata metadata 事件
+type StreamMetadata struct {
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// ErrorResponse 错误响应
+type ErrorResponse struct {
+	Message string `json:"message"`
+}
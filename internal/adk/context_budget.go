@@ -0,0 +1,297 @@
+package adk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/run-bigpig/jcp/internal/adk/anthropic"
+	"github.com/run-bigpig/jcp/internal/models"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+const (
+	// defaultOutputReserve 未设置 maxTokens 时为输出预留的 token 数
+	defaultOutputReserve = 4096
+	// preciseCountRatio 本地估算低于可用窗口的该比例时直接放行，不调用远端计数
+	preciseCountRatio = 0.8
+	// maxHistoryPartTokens 较早历史中单段文本或工具结果的 token 上限，超出时截断
+	maxHistoryPartTokens = 2000
+	// keepRecentContents 最近的几条消息保持原样，不截断
+	keepRecentContents = 2
+)
+
+// contextWindows 常见模型的上下文窗口，按顺序匹配模型名（不区分大小写，先匹配更具体的名称）
+var contextWindows = []struct {
+	match  string
+	window int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-5", 400000},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"o1-mini", 128000},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"gpt-4", 8192},
+	{"gpt-3.5", 16385},
+	{"claude", 200000},
+	{"gemini-1.5-pro", 2097152},
+	{"gemini", 1048576},
+	{"deepseek", 128000},
+	{"mistral-large", 128000},
+	{"qwen", 131072},
+	{"glm-4", 128000},
+	{"moonshot-v1-8k", 8192},
+	{"moonshot-v1-32k", 32768},
+	{"moonshot-v1-128k", 131072},
+}
+
+// ContextWindowFor 返回配置对应的上下文窗口：优先使用配置值，0 时按模型名识别，未知模型或 -1 返回 0（不检查）
+func ContextWindowFor(config *models.AIConfig) int {
+	if config == nil || config.ContextWindow < 0 {
+		return 0
+	}
+	if config.ContextWindow > 0 {
+		return config.ContextWindow
+	}
+	name := strings.ToLower(config.ModelName)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, w := range contextWindows {
+		if strings.HasPrefix(name, w.match) {
+			return w.window
+		}
+	}
+	return 0
+}
+
+var (
+	tokenCounters   = map[string]TokenCounter{} // key: 配置ID + 模型名 + Key
+	tokenCountersMu sync.Mutex
+)
+
+// sharedTokenCounter 按配置复用 token 计数器（Gemini 客户端只创建一次）
+func sharedTokenCounter(config *models.AIConfig) TokenCounter {
+	key := config.ID + "|" + config.ModelName + "|" + config.APIKey
+	tokenCountersMu.Lock()
+	defer tokenCountersMu.Unlock()
+	if c, ok := tokenCounters[key]; ok {
+		return c
+	}
+	c := NewModelFactory().CreateTokenCounter(config)
+	tokenCounters[key] = c
+	return c
+}
+
+// TokenCounterFor 返回 AI 配置共享的 token 计数器（与上下文预算检查共用校准结果）
+func TokenCounterFor(config *models.AIConfig) TokenCounter {
+	return sharedTokenCounter(config)
+}
+
+// contextBudgetCallback 请求发出前检查输入 token 是否超出上下文窗口（扣除输出预留）
+// 超出时先截断较早历史中过长的文本与工具结果，仍超出再按轮次移除最早的对话，并在系统指令中说明，而不是让请求在服务端失败
+// reserve 为请求未指定 maxTokens 时的输出预留；exact 为 true 时计数器按供应商分词器精确计数（Anthropic count_tokens），裁剪后会复核并继续收紧
+func contextBudgetCallback(counter TokenCounter, window, reserve int, exact bool) llmagent.BeforeModelCallback {
+	return func(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+		if window <= 0 || req == nil || len(req.Contents) == 0 {
+			return nil, nil
+		}
+		limit := window - outputReserve(req, reserve)
+		if limit <= 0 {
+			return nil, nil
+		}
+		// 本地估算按该计数器以往的计数结果校准（Claude 与 tiktoken 的分词差异较大）
+		estimate := EstimateRequestTokens(req)
+		if float64(estimate)*tokenRatio(counter) < float64(limit)*preciseCountRatio {
+			return nil, nil
+		}
+		count, _ := counter.CountTokens(ctx, req)
+		observeTokenRatio(counter, estimate, count)
+		if count <= limit || estimate <= 0 {
+			return nil, nil
+		}
+		truncated, dropped, final := packContext(ctx, counter, req, limit, estimate, count, exact)
+		log.Warn("agent %s 请求约 %d tokens，超出上下文窗口 %d（预留输出后 %d），截断 %d 段内容、省略 %d 条较早消息，裁剪后约 %d tokens",
+			ctx.AgentName(), count, window, limit, truncated, dropped, final)
+		if truncated > 0 || dropped > 0 {
+			if req.Config == nil {
+				req.Config = &genai.GenerateContentConfig{}
+			}
+			appendSystemNotice(req, fmt.Sprintf("【上下文提示】对话历史超出模型上下文窗口，已省略较早的 %d 条消息并截断 %d 段过长内容，如需其中的数据请重新获取。", dropped, truncated))
+		}
+		return nil, nil
+	}
+}
+
+// maxPackRounds 精确计数时裁剪与复核的最多轮数
+const maxPackRounds = 3
+
+// packContext 把请求裁剪到 limit 以内，返回截断段数、移除消息数与裁剪后的 token 数
+// 裁剪按本地估算进行，目标按计数与估算的比例换算；精确计数时每轮裁剪后重新计数，仍超出则按新的比例继续收紧
+func packContext(ctx context.Context, counter TokenCounter, req *model.LLMRequest, limit, estimate, count int, exact bool) (truncated, dropped, final int) {
+	for round := 0; ; round++ {
+		ratio := float64(count) / float64(estimate)
+		t, d := fitContextWindow(req, int(float64(limit)/ratio))
+		truncated += t
+		dropped += d
+		estimate = EstimateRequestTokens(req)
+		if !exact || t+d == 0 || estimate <= 0 {
+			return truncated, dropped, int(float64(estimate) * ratio)
+		}
+		count, _ = counter.CountTokens(ctx, req)
+		observeTokenRatio(counter, estimate, count)
+		if count <= limit || round+1 >= maxPackRounds {
+			return truncated, dropped, count
+		}
+	}
+}
+
+// tokenRatios 各计数器最近一次计数与本地估算之比
+var tokenRatios sync.Map // TokenCounter -> float64
+
+// tokenRatio 返回计数器的校准比例，未计数过时为 1
+func tokenRatio(counter TokenCounter) float64 {
+	if v, ok := tokenRatios.Load(counter); ok {
+		return v.(float64)
+	}
+	return 1
+}
+
+// observeTokenRatio 记录一次计数结果
+func observeTokenRatio(counter TokenCounter, estimate, count int) {
+	if estimate > 0 && count > 0 {
+		tokenRatios.Store(counter, float64(count)/float64(estimate))
+	}
+}
+
+// outputReserve 为输出预留的 token 数
+func outputReserve(req *model.LLMRequest, fallback int) int {
+	if req.Config != nil && req.Config.MaxOutputTokens > 0 {
+		return int(req.Config.MaxOutputTokens)
+	}
+	if fallback > 0 {
+		return fallback
+	}
+	return defaultOutputReserve
+}
+
+// defaultOutputTokens 请求未指定 maxTokens 时供应商实际使用的输出上限：
+// 配置的 maxTokens；Claude 按模型的最大输出（服务端要求输入与 max_tokens 之和不超过上下文窗口）；其余为 defaultOutputReserve
+func defaultOutputTokens(config *models.AIConfig) int {
+	switch {
+	case config == nil:
+		return defaultOutputReserve
+	case config.MaxTokens > 0:
+		return config.MaxTokens
+	case isClaudeConfig(config):
+		return anthropic.DefaultMaxTokens(config.ModelName)
+	}
+	return defaultOutputReserve
+}
+
+// fitContextWindow 把请求裁剪到 target（本地估算）以内，返回截断的内容段数与移除的消息数
+// 不修改原有 Content 对象（它们属于会话历史）；最后一条消息始终保留，移除只发生在用户轮次的边界，避免留下孤立的工具结果
+func fitContextWindow(req *model.LLMRequest, target int) (truncated, dropped int) {
+	total := EstimateRequestTokens(req)
+	contents := append([]*genai.Content(nil), req.Contents...)
+	for i := 0; i < len(contents)-keepRecentContents && total > target; i++ {
+		c, saved, n := compactContent(contents[i])
+		if n > 0 {
+			contents[i] = c
+			total -= saved
+			truncated += n
+		}
+	}
+
+	if total > target {
+		// 选择满足预算的最早轮次起点；都不满足时移除到最后一个轮次起点
+		removed := 0
+		for k := 1; k < len(contents); k++ {
+			removed += contentTokens(contents[k-1])
+			if !isTurnStart(contents[k]) {
+				continue
+			}
+			dropped = k
+			if total-removed <= target {
+				break
+			}
+		}
+		contents = contents[dropped:]
+	}
+	req.Contents = contents
+	return truncated, dropped
+}
+
+// isTurnStart 判断消息是否为新一轮用户输入（而不是工具结果）
+func isTurnStart(c *genai.Content) bool {
+	if c == nil || c.Role != genai.RoleUser {
+		return false
+	}
+	for _, p := range c.Parts {
+		if p != nil && p.FunctionResponse != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// compactContent 截断消息中过长的文本与工具结果，返回副本、节省的 token 数与截断的段数
+func compactContent(c *genai.Content) (*genai.Content, int, int) {
+	if c == nil {
+		return c, 0, 0
+	}
+	var (
+		parts []*genai.Part
+		saved int
+		n     int
+	)
+	for i, p := range c.Parts {
+		before := partTokens(p)
+		if before <= maxHistoryPartTokens {
+			continue
+		}
+		var replaced *genai.Part
+		switch {
+		case p.Text != "":
+			clone := *p
+			clone.Text = truncateTokens(p.Text, before, maxHistoryPartTokens)
+			replaced = &clone
+		case p.FunctionResponse != nil:
+			data, _ := json.Marshal(p.FunctionResponse.Response)
+			fr := *p.FunctionResponse
+			fr.Response = map[string]any{"output": truncateTokens(string(data), before, maxHistoryPartTokens)}
+			replaced = &genai.Part{FunctionResponse: &fr}
+		default:
+			continue
+		}
+		if parts == nil {
+			parts = append([]*genai.Part(nil), c.Parts...)
+		}
+		parts[i] = replaced
+		saved += before - partTokens(replaced)
+		n++
+	}
+	if n == 0 {
+		return c, 0, 0
+	}
+	return &genai.Content{Role: c.Role, Parts: parts}, saved, n
+}
+
+// truncateTokens 按 token 比例截取文本开头
+func truncateTokens(s string, tokens, limit int) string {
+	runes := []rune(s)
+	keep := len(runes) * limit / tokens
+	if keep >= len(runes) {
+		return s
+	}
+	return string(runes[:keep]) + "…（内容过长，已截断）"
+}
@@ -0,0 +1,99 @@
+package adk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestFitContextWindow_TrimsAtTurnBoundary(t *testing.T) {
+	long := strings.Repeat("历史分析内容", 1000)
+	history := []*genai.Content{
+		genai.NewContentFromText("第一轮问题", genai.RoleUser),
+		{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{Name: "get_quote"}}}},
+		{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{Name: "get_quote", Response: map[string]any{"data": long}}}}},
+		genai.NewContentFromText(long, genai.RoleModel),
+		genai.NewContentFromText("第二轮问题", genai.RoleUser),
+		genai.NewContentFromText("第二轮回答", genai.RoleModel),
+		genai.NewContentFromText("第三轮问题", genai.RoleUser),
+	}
+	req := &model.LLMRequest{
+		Contents: append([]*genai.Content(nil), history...),
+		Config:   &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText("你是分析师", genai.RoleUser)},
+	}
+
+	// 截断过长内容后仍超出，需要移除第一轮
+	truncated, dropped := fitContextWindow(req, 300)
+	if truncated != 2 || dropped != 4 {
+		t.Fatalf("truncated=%d dropped=%d", truncated, dropped)
+	}
+	if len(req.Contents) != 3 || req.Contents[0].Parts[0].Text != "第二轮问题" {
+		t.Fatalf("contents = %+v", req.Contents)
+	}
+	// 会话历史中的原对象不被修改
+	if history[3].Parts[0].Text != long {
+		t.Fatal("original content modified")
+	}
+
+	// 预算足够时只截断不移除
+	req.Contents = append([]*genai.Content(nil), history...)
+	if truncated, dropped := fitContextWindow(req, 6000); truncated != 2 || dropped != 0 {
+		t.Fatalf("truncated=%d dropped=%d", truncated, dropped)
+	}
+	if !strings.HasSuffix(req.Contents[3].Parts[0].Text, "已截断）") {
+		t.Fatal("long text not truncated")
+	}
+}
+
+func TestContextWindowFor(t *testing.T) {
+	cases := []struct {
+		cfg  models.AIConfig
+		want int
+	}{
+		{models.AIConfig{ModelName: "claude-sonnet-4-5"}, 200000},
+		{models.AIConfig{ModelName: "openai/gpt-4o-mini"}, 128000},
+		{models.AIConfig{ModelName: "gemini-2.5-pro"}, 1048576},
+		{models.AIConfig{ModelName: "my-local-model"}, 0},
+		{models.AIConfig{ModelName: "gpt-4o", ContextWindow: 32000}, 32000},
+		{models.AIConfig{ModelName: "gpt-4o", ContextWindow: -1}, 0},
+	}
+	for _, c := range cases {
+		if got := ContextWindowFor(&c.cfg); got != c.want {
+			t.Errorf("%s: got %d, want %d", c.cfg.ModelName, got, c.want)
+		}
+	}
+}
+
+// perMessageCounter 模拟与本地估算差异不成比例的分词器：每条消息额外 200 tokens
+type perMessageCounter struct{ calls int }
+
+func (c *perMessageCounter) CountTokens(_ context.Context, req *model.LLMRequest) (int, error) {
+	c.calls++
+	return EstimateRequestTokens(req) + 200*len(req.Contents), nil
+}
+
+func TestPackContext_ExactCountTightensUntilFits(t *testing.T) {
+	var history []*genai.Content
+	for i := 0; i < 20; i++ {
+		history = append(history,
+			genai.NewContentFromText(strings.Repeat("问题", 20), genai.RoleUser),
+			genai.NewContentFromText(strings.Repeat("回答", 40), genai.RoleModel))
+	}
+	const limit = 2000
+	counter := &perMessageCounter{}
+	req := &model.LLMRequest{Contents: append([]*genai.Content(nil), history...)}
+	estimate := EstimateRequestTokens(req)
+	count, _ := counter.CountTokens(context.Background(), req)
+	_, dropped, final := packContext(context.Background(), counter, req, limit, estimate, count, true)
+	if exact, _ := counter.CountTokens(context.Background(), req); final != exact || exact > limit {
+		t.Fatalf("final=%d exact=%d, want <= %d", final, exact, limit)
+	}
+	if dropped == 0 || !isTurnStart(req.Contents[0]) {
+		t.Fatalf("dropped=%d first=%+v", dropped, req.Contents[0])
+	}
+
+}
@@ -0,0 +1,307 @@
+package adk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+const (
+	// captureBodyLimit 单个请求 / 响应体最多记录的字节数
+	captureBodyLimit = 1 << 20
+	// captureDefaultSession 未关联会话的请求（意图分析、记忆摘要等）写入的目录
+	captureDefaultSession = "default"
+)
+
+// captureSensitiveHeader 需要打码的请求 / 响应头（按小写名称包含匹配）
+var captureSensitiveHeader = []string{"authorization", "key", "token", "secret", "cookie", "signature", "credential"}
+
+// captureSensitiveQuery 需要打码的查询参数
+var captureSensitiveQuery = map[string]bool{"key": true, "api_key": true, "apikey": true, "access_token": true, "token": true, "sig": true, "signature": true}
+
+// captureKeyPattern 请求 / 响应体中形似 API Key 的字符串
+var captureKeyPattern = regexp.MustCompile(`\b(sk-[A-Za-z0-9_\-]{16,}|AIza[0-9A-Za-z_\-]{30,}|ya29\.[0-9A-Za-z_\-]{20,})`)
+
+// debugCapture 全局抓包设置：开启后所有供应商请求与原始响应按会话写入 JSONL
+var debugCapture struct {
+	enabled atomic.Bool
+	mu      sync.Mutex
+	dir     string
+}
+
+// SetDebugCapture 设置抓包目录与开关，切换即时生效（已创建的模型也会受影响）
+func SetDebugCapture(dir string, enabled bool) {
+	debugCapture.mu.Lock()
+	debugCapture.dir = dir
+	debugCapture.mu.Unlock()
+	debugCapture.enabled.Store(enabled && dir != "")
+}
+
+// DebugCaptureDir 返回抓包目录
+func DebugCaptureDir() string {
+	debugCapture.mu.Lock()
+	defer debugCapture.mu.Unlock()
+	return debugCapture.dir
+}
+
+// ClearDebugCaptures 删除抓包目录下的全部记录
+func ClearDebugCaptures() error {
+	debugCapture.mu.Lock()
+	defer debugCapture.mu.Unlock()
+	if debugCapture.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(debugCapture.dir)
+}
+
+type captureSessionKey struct{}
+
+// captureScope 请求所属的会话与专家
+type captureScope struct {
+	session string
+	agent   string
+}
+
+// WithCaptureSession 在 context 中标记请求所属的会话（通常为股票代码）与专家，抓包按会话分目录
+func WithCaptureSession(ctx context.Context, session, agent string) context.Context {
+	return context.WithValue(ctx, captureSessionKey{}, captureScope{session: session, agent: agent})
+}
+
+// captureRecord 一次 HTTP 往返的抓包记录（JSONL 中的一行）
+type captureRecord struct {
+	Time            string              `json:"time"`
+	Provider        string              `json:"provider"`
+	Model           string              `json:"model"`
+	Agent           string              `json:"agent,omitempty"`
+	Method          string              `json:"method"`
+	URL             string              `json:"url"`
+	RequestHeaders  map[string][]string `json:"requestHeaders"`
+	RequestBody     string              `json:"requestBody,omitempty"`
+	Status          int                 `json:"status,omitempty"`
+	ResponseHeaders map[string][]string `json:"responseHeaders,omitempty"`
+	ResponseBody    string              `json:"responseBody,omitempty"`
+	Truncated       bool                `json:"truncated,omitempty"`
+	DurationMs      int64               `json:"durationMs"`
+	Error           string              `json:"error,omitempty"`
+}
+
+// captureTransport 位于 Transport 链最内层，记录实际发出的请求（含签名与轮换后的 Key）和未经解析的原始响应
+type captureTransport struct {
+	base     http.RoundTripper
+	provider string
+	model    string
+	secrets  []string // 配置中的 API Key，出现在请求体或响应体中时打码
+}
+
+func newCaptureTransport(base http.RoundTripper, config *models.AIConfig) *captureTransport {
+	t := &captureTransport{base: base}
+	if config == nil {
+		return t
+	}
+	t.provider, t.model = string(config.Provider), config.ModelName
+	keys := append([]string{config.APIKey, config.SecretAccessKey, config.SessionToken}, config.APIKeys...)
+	if config.Signing != nil {
+		keys = append(keys, config.Signing.Secret)
+	}
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); len(key) >= 8 {
+			t.secrets = append(t.secrets, key)
+		}
+	}
+	return t
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !debugCapture.enabled.Load() {
+		return t.base.RoundTrip(req)
+	}
+	scope, _ := req.Context().Value(captureSessionKey{}).(captureScope)
+	rec := &captureRecord{
+		Time:           time.Now().Format(time.RFC3339Nano),
+		Provider:       t.provider,
+		Model:          t.model,
+		Agent:          scope.agent,
+		Method:         req.Method,
+		URL:            maskURL(req.URL),
+		RequestHeaders: t.maskHeaders(req.Header),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		rec.RequestBody, rec.Truncated = t.maskBody(data)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		rec.DurationMs = time.Since(start).Milliseconds()
+		rec.Error = err.Error()
+		writeCapture(scope.session, rec)
+		return resp, err
+	}
+	rec.Status = resp.StatusCode
+	rec.ResponseHeaders = t.maskHeaders(resp.Header)
+	resp.Body = &captureBody{ReadCloser: resp.Body, transport: t, rec: rec, session: scope.session, start: start}
+	return resp, nil
+}
+
+// maskHeaders 复制请求头并对认证相关的值打码
+func (t *captureTransport) maskHeaders(h http.Header) map[string][]string {
+	out := make(map[string][]string, len(h))
+	for name, values := range h {
+		lower := strings.ToLower(name)
+		sensitive := false
+		for _, s := range captureSensitiveHeader {
+			if strings.Contains(lower, s) {
+				sensitive = true
+				break
+			}
+		}
+		masked := make([]string, len(values))
+		for i, v := range values {
+			if sensitive {
+				masked[i] = maskSecret(v)
+			} else {
+				masked[i] = v
+			}
+		}
+		out[name] = masked
+	}
+	return out
+}
+
+// maskBody 截断并打码请求 / 响应体中的 API Key
+func (t *captureTransport) maskBody(data []byte) (string, bool) {
+	truncated := len(data) > captureBodyLimit
+	if truncated {
+		data = data[:captureBodyLimit]
+	}
+	s := string(data)
+	for _, key := range t.secrets {
+		s = strings.ReplaceAll(s, key, maskSecret(key))
+	}
+	return captureKeyPattern.ReplaceAllStringFunc(s, maskSecret), truncated
+}
+
+// maskSecret 只保留前后各 4 个字符，较短的值整体打码；"Bearer xxx" 等保留认证方案
+func maskSecret(v string) string {
+	if scheme, token, ok := strings.Cut(v, " "); ok && token != "" {
+		return scheme + " " + maskSecret(token)
+	}
+	if len(v) <= 12 {
+		return "****"
+	}
+	return v[:4] + "****" + v[len(v)-4:]
+}
+
+// maskURL 对查询参数中的 Key 打码
+func maskURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	q := u.Query()
+	changed := false
+	for name, values := range q {
+		if !captureSensitiveQuery[strings.ToLower(name)] {
+			continue
+		}
+		for i, v := range values {
+			values[i] = maskSecret(v)
+		}
+		changed = true
+	}
+	if !changed {
+		return u.String()
+	}
+	out := *u
+	out.RawQuery = q.Encode()
+	return out.String()
+}
+
+// captureBody 读取响应的同时缓存原始内容，关闭时写入抓包记录（流式响应在读取结束后才完整）
+type captureBody struct {
+	io.ReadCloser
+	transport *captureTransport
+	rec       *captureRecord
+	session   string
+	start     time.Time
+	buf       bytes.Buffer
+	over      bool
+	once      sync.Once
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if room := captureBodyLimit + 1 - b.buf.Len(); room > 0 {
+			b.buf.Write(p[:min(n, room)])
+		} else {
+			b.over = true
+		}
+	}
+	if err != nil && err != io.EOF {
+		b.rec.Error = err.Error()
+	}
+	return n, err
+}
+
+func (b *captureBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.rec.DurationMs = time.Since(b.start).Milliseconds()
+		body, truncated := b.transport.maskBody(b.buf.Bytes())
+		b.rec.ResponseBody = body
+		b.rec.Truncated = b.rec.Truncated || truncated || b.over
+		writeCapture(b.session, b.rec)
+	})
+	return err
+}
+
+// captureUnsafe 会话目录名中不允许的字符
+var captureUnsafe = regexp.MustCompile(`[^0-9A-Za-z._\-]+`)
+
+// writeCapture 追加一行记录到 <dir>/<session>/capture-<日期>.jsonl
+func writeCapture(session string, rec *captureRecord) {
+	session = strings.Trim(captureUnsafe.ReplaceAllString(session, "_"), "._")
+	if session == "" {
+		session = captureDefaultSession
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+
+	debugCapture.mu.Lock()
+	defer debugCapture.mu.Unlock()
+	if debugCapture.dir == "" {
+		return
+	}
+	dir := filepath.Join(debugCapture.dir, session)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Warn("创建抓包目录失败: %v", err)
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "capture-"+time.Now().Format("20060102")+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Warn("写入抓包记录失败: %v", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
@@ -0,0 +1,61 @@
+package adk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestCaptureTransport_WritesMaskedJSONL(t *testing.T) {
+	const key = "sk-test-0123456789abcdefghij"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"echo\":\""+key+"\"}\n\n")
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	SetDebugCapture(dir, true)
+	defer SetDebugCapture("", false)
+
+	rt := newCaptureTransport(http.DefaultTransport, &models.AIConfig{Provider: models.AIProviderOpenAI, ModelName: "gpt-4o", APIKey: key})
+	ctx := WithCaptureSession(context.Background(), "600519", "analyst")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/v1/chat?key="+key, strings.NewReader(`{"api_key":"`+key+`"}`))
+	req.Header.Set("Authorization", "Bearer "+key)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), key) {
+		t.Fatal("抓包不应修改调用方读到的响应")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "600519", "capture-*.jsonl"))
+	if len(files) != 1 {
+		t.Fatalf("capture files = %v", files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if strings.Contains(string(data), key) {
+		t.Fatalf("抓包记录中出现了未打码的 Key: %s", data)
+	}
+	var rec captureRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Agent != "analyst" || rec.Status != http.StatusOK || !strings.Contains(rec.ResponseBody, "echo") {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if got := rec.RequestHeaders["Authorization"][0]; got != "Bearer sk-t****ghij" {
+		t.Fatalf("Authorization = %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package adk
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// dryRunReply 试运行时代替模型回答的占位文本
+const dryRunReply = "【试运行】请求已组装完成，未调用模型。"
+
+// DryRunEstimate 试运行拦截到的请求与 token 估算
+type DryRunEstimate struct {
+	Request      json.RawMessage // 将要发送的请求快照（RequestSnapshot 格式）
+	InputTokens  int             // 输入 token 估算（按计数器以往的计数结果校准）
+	OutputTokens int             // 输出上限：请求的 maxTokens 或默认预留
+	ToolCount    int             // 可用工具（函数声明）数量
+}
+
+// DryRun 试运行记录器：挂载后请求在发往模型前被拦截，只记录经过工具筛选与上下文裁剪后的完整请求
+type DryRun struct {
+	mu       sync.Mutex
+	estimate DryRunEstimate
+	recorded bool
+}
+
+type dryRunKey struct{}
+
+// WithDryRun 在 context 中挂载试运行记录器
+func WithDryRun(ctx context.Context, d *DryRun) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, d)
+}
+
+// Estimate 返回拦截到的请求，尚未拦截时 ok 为 false
+func (d *DryRun) Estimate() (DryRunEstimate, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.estimate, d.recorded
+}
+
+// dryRunCallback 试运行时拦截请求并返回占位回答，需放在其他 BeforeModelCallback 之后
+// counter 用于校准本地估算（不发起远端计数），reserve 为请求未指定 maxTokens 时的输出上限
+func dryRunCallback(counter TokenCounter, reserve int) llmagent.BeforeModelCallback {
+	return func(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+		d, ok := ctx.Value(dryRunKey{}).(*DryRun)
+		if !ok || req == nil {
+			return nil, nil
+		}
+		data, err := json.Marshal(RequestSnapshot{Contents: req.Contents, Config: req.Config})
+		if err != nil {
+			return nil, err
+		}
+		estimate := DryRunEstimate{
+			Request:      data,
+			InputTokens:  EstimateRequestTokens(req),
+			OutputTokens: outputReserve(req, reserve),
+		}
+		if counter != nil {
+			estimate.InputTokens = int(float64(estimate.InputTokens) * tokenRatio(counter))
+		}
+		if req.Config != nil {
+			for _, t := range req.Config.Tools {
+				if t != nil {
+					estimate.ToolCount += len(t.FunctionDeclarations)
+				}
+			}
+		}
+		d.mu.Lock()
+		d.estimate, d.recorded = estimate, true
+		d.mu.Unlock()
+		return &model.LLMResponse{Content: genai.NewContentFromText(dryRunReply, genai.RoleModel), TurnComplete: true}, nil
+	}
+}
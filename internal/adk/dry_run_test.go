@@ -0,0 +1,66 @@
+package adk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"iter"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// unreachableLLM 试运行时不应被调用的模型
+type unreachableLLM struct{ called bool }
+
+func (m *unreachableLLM) Name() string { return "unreachable" }
+
+func (m *unreachableLLM) GenerateContent(context.Context, *model.LLMRequest, bool) iter.Seq2[*model.LLMResponse, error] {
+	m.called = true
+	return func(yield func(*model.LLMResponse, error) bool) {
+		yield(nil, errors.New("provider called during dry run"))
+	}
+}
+
+func TestDryRun_InterceptsRequest(t *testing.T) {
+	llm := &unreachableLLM{}
+	cfg := &models.AIConfig{ID: "dry", Provider: models.AIProviderOpenAI, ModelName: "gpt-4o", MaxTokens: 1000}
+	agentInstance, err := NewExpertAgentBuilder(llm, cfg).BuildAgentWithContext(
+		&models.AgentConfig{ID: "analyst", Name: "分析师", Role: "技术分析"}, &models.Stock{Symbol: "sh600519", Name: "贵州茅台"}, "走势如何", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "jcp", Agent: agentInstance, SessionService: sessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := sessions.Create(ctx, &session.CreateRequest{AppName: "jcp", UserID: "user", SessionID: "s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dryRun := &DryRun{}
+	ctx = WithDryRun(ctx, dryRun)
+	for _, err := range r.Run(ctx, "user", "s", genai.NewContentFromText("走势如何", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if llm.called {
+		t.Fatal("model called during dry run")
+	}
+	estimate, ok := dryRun.Estimate()
+	if !ok || estimate.InputTokens <= 0 || estimate.OutputTokens != 1000 {
+		t.Fatalf("estimate = %+v", estimate)
+	}
+	var snapshot RequestSnapshot
+	if err := json.Unmarshal(estimate.Request, &snapshot); err != nil || snapshot.Config == nil || snapshot.Config.SystemInstruction == nil {
+		t.Fatalf("request snapshot = %s (%v)", estimate.Request, err)
+	}
+}
@@ -0,0 +1,201 @@
+package adk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// Embedder 文本向量化接口
+type Embedder interface {
+	// Embed 批量计算文本向量，返回的向量已归一化
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// ID 标识向量空间（模型不同的向量不可比较，用于缓存隔离）
+	ID() string
+}
+
+// CreateEmbedder 根据 AI 配置创建向量化器
+// 配置了 EmbeddingModel 的 OpenAI 兼容接口使用远端 /embeddings，否则使用本地 n-gram 哈希向量
+func (f *ModelFactory) CreateEmbedder(config *models.AIConfig) Embedder {
+	if config == nil || strings.TrimSpace(config.EmbeddingModel) == "" {
+		return LocalEmbedder{}
+	}
+	var baseURL string
+	switch config.Provider {
+	case models.AIProviderOpenAI:
+		baseURL = normalizeOpenAIBaseURL(config.BaseURL)
+	case models.AIProviderOpenRouter:
+		baseURL = strings.TrimRight(strings.TrimSpace(config.BaseURL), "/")
+		if baseURL == "" {
+			baseURL = "https://openrouter.ai/api/v1"
+		}
+	default:
+		return LocalEmbedder{}
+	}
+	client, err := f.newHTTPClient(config)
+	if err != nil {
+		log.Warn("create embedding client failed, fallback to local: %v", err)
+		return LocalEmbedder{}
+	}
+	return &openAIEmbedder{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  config.APIKey,
+		model:   config.EmbeddingModel,
+	}
+}
+
+// openAIEmbedder OpenAI 兼容的 /embeddings 接口
+type openAIEmbedder struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+// ID 实现 Embedder
+func (e *openAIEmbedder) ID() string {
+	return "openai:" + e.baseURL + ":" + e.model
+}
+
+// Embed 实现 Embedder
+func (e *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, _ := json.Marshal(map[string]any{"model": e.model, "input": texts})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embeddings HTTP %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析 embeddings 响应失败: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embeddings 返回 %d 条，期望 %d 条", len(result.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings 返回无效索引 %d", d.Index)
+		}
+		vectors[d.Index] = normalizeVector(d.Embedding)
+	}
+	return vectors, nil
+}
+
+// localEmbeddingDims 本地哈希向量维度
+const localEmbeddingDims = 512
+
+// LocalEmbedder 本地 n-gram 哈希向量（无需网络，适合中文短文本的粗粒度相似度）
+// 中文按单字与二元组、英文按单词切分后哈希到固定维度
+type LocalEmbedder struct{}
+
+// ID 实现 Embedder
+func (LocalEmbedder) ID() string {
+	return "local-ngram"
+}
+
+// Embed 实现 Embedder
+func (LocalEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, localEmbeddingDims)
+		for _, term := range embeddingTerms(text) {
+			h := fnv.New32a()
+			h.Write([]byte(term))
+			sum := h.Sum32()
+			// 符号位减少哈希碰撞带来的偏差
+			if sum&1 == 0 {
+				vec[sum%localEmbeddingDims]++
+			} else {
+				vec[sum%localEmbeddingDims]--
+			}
+		}
+		vectors[i] = normalizeVector(vec)
+	}
+	return vectors, nil
+}
+
+// embeddingTerms 切分文本：英文/数字按词（下划线视为分隔），中文输出单字与二元组
+func embeddingTerms(text string) []string {
+	var terms []string
+	var word []rune
+	var prevHan rune
+	flush := func() {
+		if len(word) > 0 {
+			terms = append(terms, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			terms = append(terms, string(r))
+			if prevHan != 0 {
+				terms = append(terms, string([]rune{prevHan, r}))
+			}
+			prevHan = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+		prevHan = 0
+	}
+	flush()
+	return terms
+}
+
+// normalizeVector L2 归一化
+func normalizeVector(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vec
+	}
+	n := float32(1 / math.Sqrt(sum))
+	out := make([]float32, len(vec))
+	for i, v := range vec {
+		out[i] = v * n
+	}
+	return out
+}
+
+// cosine 归一化向量的余弦相似度
+func cosine(a, b []float32) float32 {
+	var dot float32
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += a[i] * b[i]
+	}
+	return dot
+}
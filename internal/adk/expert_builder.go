@@ -1,11 +1,13 @@
 package adk
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/run-bigpig/jcp/internal/adk/mcp"
+	"github.com/run-bigpig/jcp/internal/adk/prompts"
 	"github.com/run-bigpig/jcp/internal/adk/tools"
 	"github.com/run-bigpig/jcp/internal/models"
 
@@ -22,6 +24,9 @@ type ExpertAgentBuilder struct {
 	aiConfig     *models.AIConfig // AI 配置（包含 temperature、maxTokens）
 	toolRegistry *tools.Registry
 	mcpManager   *mcp.Manager
+
+	promptVersion string              // 提示词版本，为空时使用默认版本
+	riskProfile   *models.RiskProfile // 用户风险画像，为空时不注入
 }
 
 // NewExpertAgentBuilder 创建专家 Agent 构建器
@@ -39,9 +44,36 @@ func NewExpertAgentBuilderFull(llm model.LLM, aiConfig *models.AIConfig, registr
 	return &ExpertAgentBuilder{llm: llm, aiConfig: aiConfig, toolRegistry: registry, mcpManager: mcpMgr}
 }
 
+// AIConfig 返回构建器使用的 AI 配置
+func (b *ExpertAgentBuilder) AIConfig() *models.AIConfig {
+	return b.aiConfig
+}
+
+// WithPromptVersion 返回使用指定提示词版本的构建器副本
+func (b *ExpertAgentBuilder) WithPromptVersion(version string) *ExpertAgentBuilder {
+	clone := *b
+	clone.promptVersion = version
+	return &clone
+}
+
+// WithRiskProfile 返回注入指定风险画像的构建器副本
+func (b *ExpertAgentBuilder) WithRiskProfile(profile *models.RiskProfile) *ExpertAgentBuilder {
+	clone := *b
+	clone.riskProfile = profile
+	return &clone
+}
+
+// PromptVersion 返回构建器实际使用的提示词版本
+func (b *ExpertAgentBuilder) PromptVersion() string {
+	return prompts.Resolve(b.promptVersion)
+}
+
 // BuildAgentWithContext 根据配置构建 LLM Agent（支持引用上下文）
 func (b *ExpertAgentBuilder) BuildAgentWithContext(config *models.AgentConfig, stock *models.Stock, query string, replyContent string, position *models.StockPosition) (agent.Agent, error) {
-	instruction := b.buildInstructionWithContext(config, stock, query, replyContent, position)
+	instruction, err := b.buildInstructionWithContext(config, stock, query, replyContent, position)
+	if err != nil {
+		return nil, err
+	}
 
 	// 获取 Agent 配置的工具
 	var agentTools []tool.Tool
@@ -61,9 +93,11 @@ func (b *ExpertAgentBuilder) BuildAgentWithContext(config *models.AgentConfig, s
 		}
 	}
 
-	// 构建生成配置（应用 temperature 和 maxTokens）
+	// 构建生成配置（应用 temperature、maxTokens 与采样参数）
 	var generateConfig *genai.GenerateContentConfig
+	toolBudget := 0
 	if b.aiConfig != nil {
+		toolBudget = b.aiConfig.ToolSchemaBudget
 		temp := float32(b.aiConfig.Temperature)
 		generateConfig = &genai.GenerateContentConfig{
 			Temperature: &temp,
@@ -71,6 +105,14 @@ func (b *ExpertAgentBuilder) BuildAgentWithContext(config *models.AgentConfig, s
 		if b.aiConfig.MaxTokens > 0 {
 			generateConfig.MaxOutputTokens = int32(b.aiConfig.MaxTokens)
 		}
+		applySamplingParams(generateConfig, b.aiConfig)
+	}
+	if schema := responseSchema(config); schema != nil {
+		if generateConfig == nil {
+			generateConfig = &genai.GenerateContentConfig{}
+		}
+		generateConfig.ResponseMIMEType = "application/json"
+		generateConfig.ResponseJsonSchema = schema
 	}
 
 	return llmagent.New(llmagent.Config{
@@ -81,11 +123,44 @@ func (b *ExpertAgentBuilder) BuildAgentWithContext(config *models.AgentConfig, s
 		Tools:                 agentTools,
 		Toolsets:              toolsets,
 		GenerateContentConfig: generateConfig,
+		BeforeModelCallbacks:  b.toolCallbacks(toolBudget),
 	})
 }
 
+// responseSchema 解析专家配置的输出 JSON Schema，未配置或无效时返回 nil
+func responseSchema(config *models.AgentConfig) map[string]any {
+	if strings.TrimSpace(config.ResponseSchema) == "" {
+		return nil
+	}
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(config.ResponseSchema), &schema); err != nil {
+		log.Warn("Agent %s 的 responseSchema 不是有效 JSON，忽略: %v", config.ID, err)
+		return nil
+	}
+	return schema
+}
+
+// toolCallbacks 请求前的工具筛选：先按语义相关度保留前 N 个，再按 token 预算裁剪，
+// 然后检查上下文窗口并裁剪历史，最后记录请求快照（试运行时在此拦截，不调用模型）
+func (b *ExpertAgentBuilder) toolCallbacks(toolBudget int) []llmagent.BeforeModelCallback {
+	var callbacks []llmagent.BeforeModelCallback
+	if b.aiConfig != nil && b.aiConfig.ToolSelectionTopN > 0 {
+		embedder := NewModelFactory().CreateEmbedder(b.aiConfig)
+		callbacks = append(callbacks, toolSelectionCallback(embedder, b.aiConfig.ToolSelectionTopN))
+	}
+	callbacks = append(callbacks, toolBudgetCallback(toolBudget))
+	if window := ContextWindowFor(b.aiConfig); window > 0 {
+		callbacks = append(callbacks, contextBudgetCallback(sharedTokenCounter(b.aiConfig), window, defaultOutputTokens(b.aiConfig), hasExactTokenCount(b.aiConfig)))
+	}
+	var counter TokenCounter
+	if b.aiConfig != nil {
+		counter = sharedTokenCounter(b.aiConfig)
+	}
+	return append(callbacks, requestCaptureCallback(), dryRunCallback(counter, defaultOutputTokens(b.aiConfig)))
+}
+
 // buildInstructionWithContext 构建 Agent 指令（支持引用上下文）
-func (b *ExpertAgentBuilder) buildInstructionWithContext(config *models.AgentConfig, stock *models.Stock, query string, replyContent string, position *models.StockPosition) string {
+func (b *ExpertAgentBuilder) buildInstructionWithContext(config *models.AgentConfig, stock *models.Stock, query string, replyContent string, position *models.StockPosition) (string, error) {
 	baseInstruction := config.Instruction
 	if baseInstruction == "" {
 		baseInstruction = fmt.Sprintf("你是一位%s，名字是%s。", config.Role, config.Name)
@@ -117,26 +192,16 @@ func (b *ExpertAgentBuilder) buildInstructionWithContext(config *models.AgentCon
 		marketStatus = "午间休市"
 	}
 
-	prompt := fmt.Sprintf(`%s
-%s
-当前时间: %s
-市场状态: %s
-
-## 工具调用规范
-当你需要调用工具时，必须通过系统提供的标准 function call 机制进行调用。
-**重要：需要调用工具时，不要在工具调用前输出任何思考过程或分析文字，直接发起工具调用。工具返回结果后，再基于结果组织你的回答。**
-禁止在回复文本中输出任何自定义的工具调用标签，包括但不限于：
-- <tool_call>、</tool_call>
-- <tool_call_begin>、</tool_call_end>
-- <invoke>、</invoke>
-- <tool>、</tool>
-- 任何类似 <xxx:tool_call> 格式的标签
-直接使用 API 提供的 tool_calls 功能，不要在文本中模拟工具调用。
-
-股票: %s (%s)
-当前价格: %.2f
-涨跌幅: %.2f%%
-`, baseInstruction, toolsDescription, timeStr, marketStatus, stock.Symbol, stock.Name, stock.Price, stock.ChangePercent)
+	prompt, err := prompts.Render(b.promptVersion, prompts.ExpertSystem, map[string]any{
+		"Instruction":      baseInstruction,
+		"ToolsDescription": toolsDescription,
+		"Time":             timeStr,
+		"MarketStatus":     marketStatus,
+		"Stock":            stock,
+	})
+	if err != nil {
+		return "", err
+	}
 
 	// 如果有持仓信息，加入上下文
 	if position != nil && position.Shares > 0 {
@@ -147,28 +212,46 @@ func (b *ExpertAgentBuilder) buildInstructionWithContext(config *models.AgentCon
 		if costAmount > 0 {
 			profitPercent = (profitLoss / costAmount) * 100
 		}
-		prompt += fmt.Sprintf(`
-用户持仓: %d股，成本价 %.2f
-持仓市值: %.2f，盈亏: %.2f (%.2f%%)
-`, position.Shares, position.CostPrice, marketValue, profitLoss, profitPercent)
+		section, err := prompts.Render(b.promptVersion, prompts.ExpertPosition, map[string]any{
+			"Shares":        position.Shares,
+			"CostPrice":     position.CostPrice,
+			"MarketValue":   marketValue,
+			"ProfitLoss":    profitLoss,
+			"ProfitPercent": profitPercent,
+		})
+		if err != nil {
+			return "", err
+		}
+		prompt += section
+	}
+
+	// 用户填写了风险画像时，要求建议与画像匹配
+	if p := b.riskProfile; p != nil && !p.IsEmpty() {
+		section, err := prompts.Render(b.promptVersion, prompts.ExpertRiskProfile, map[string]any{
+			"Horizon":     p.HorizonLabel(),
+			"MaxDrawdown": p.MaxDrawdown,
+			"Experience":  p.ExperienceLabel(),
+			"Notes":       p.Notes,
+		})
+		if err != nil {
+			return "", err
+		}
+		prompt += section
 	}
 
 	// 如果有引用内容，加入上下文
+	name := prompts.ExpertTask
 	if replyContent != "" {
-		prompt += fmt.Sprintf(`--- 引用的观点 ---
-%s
----
-
-你的分析任务: %s
-
-请结合以上引用的观点，发表你的专业看法。可以赞同、补充或反驳。回复控制在150字以内。`, replyContent, query)
-	} else {
-		prompt += fmt.Sprintf(`你的分析任务: %s
-
-请用简洁专业的语言回答，控制在150字以内。`, query)
+		name = prompts.ExpertReplyTask
 	}
-
-	return prompt
+	task, err := prompts.Render(b.promptVersion, name, map[string]any{
+		"ReplyContent": replyContent,
+		"Query":        query,
+	})
+	if err != nil {
+		return "", err
+	}
+	return prompt + task, nil
 }
 
 // buildToolsDescription 构建可用工具说明
@@ -283,3 +366,21 @@ func (b *ExpertAgentBuilder) formatToolsInstruction(searchTools, dataTools, othe
 
 	return result.String()
 }
+
+// applySamplingParams 把 AI 配置中的采样参数写入生成配置（未设置的保持服务端默认值）
+func applySamplingParams(gc *genai.GenerateContentConfig, cfg *models.AIConfig) {
+	f32 := func(v *float64) *float32 {
+		if v == nil {
+			return nil
+		}
+		f := float32(*v)
+		return &f
+	}
+	gc.TopP = f32(cfg.TopP)
+	gc.FrequencyPenalty = f32(cfg.FrequencyPenalty)
+	gc.PresencePenalty = f32(cfg.PresencePenalty)
+	if cfg.Seed != nil {
+		seed := int32(*cfg.Seed)
+		gc.Seed = &seed
+	}
+}
@@ -0,0 +1,36 @@
+package adk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestBuildInstructionWithRiskProfile(t *testing.T) {
+	cfg := &models.AgentConfig{ID: "a", Name: "老张", Role: "技术分析师"}
+	stock := &models.Stock{Symbol: "sh600519", Name: "贵州茅台", Price: 1500}
+	b := NewExpertAgentBuilder(nil, nil)
+
+	plain, err := b.buildInstructionWithContext(cfg, stock, "能买吗", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(plain, "风险画像") {
+		t.Fatal("empty profile should not be injected")
+	}
+
+	profile := &models.RiskProfile{Horizon: models.HorizonLong, MaxDrawdown: 20, Experience: models.ExperienceNovice}
+	got, err := b.WithRiskProfile(profile).buildInstructionWithContext(cfg, stock, "能买吗", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"用户风险画像", "长线（一年以上）", "可承受最大回撤: 20%", "新手（1 年以内）"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("instruction missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "补充说明") {
+		t.Fatal("empty notes should be omitted")
+	}
+}
@@ -0,0 +1,259 @@
+package adk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"iter"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Gemini 上下文缓存参数
+const (
+	// geminiCacheMinTokens 系统提示词与工具定义低于该 token 数时不缓存（服务端有最小缓存长度要求）
+	geminiCacheMinTokens = 2048
+	// geminiCacheTTL 缓存内容的有效期
+	geminiCacheTTL = time.Hour
+	// geminiCacheMargin 距过期不足该时间时重新创建，避免请求时缓存恰好失效
+	geminiCacheMargin = 5 * time.Minute
+	// geminiCacheRetry 创建失败后多久再尝试
+	geminiCacheRetry = 30 * time.Minute
+)
+
+// cacheClient 创建与删除缓存内容
+type cacheClient interface {
+	Create(ctx context.Context, modelName string, config *genai.CreateCachedContentConfig) (*genai.CachedContent, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// genaiCacheClient 基于 genai Caches API 的实现
+type genaiCacheClient struct {
+	client *genai.Client
+}
+
+func (c *genaiCacheClient) Create(ctx context.Context, modelName string, config *genai.CreateCachedContentConfig) (*genai.CachedContent, error) {
+	return c.client.Caches.Create(ctx, modelName, config)
+}
+
+func (c *genaiCacheClient) Delete(ctx context.Context, name string) error {
+	_, err := c.client.Caches.Delete(ctx, name, nil)
+	return err
+}
+
+// cacheEntry 一份缓存内容，mu 保证同一前缀只创建一次
+type cacheEntry struct {
+	mu        sync.Mutex
+	configID  string
+	model     string
+	name      string
+	tokens    int
+	hits      int
+	expiresAt time.Time
+	retryAt   time.Time // 创建失败后的冷却截止时间
+	client    cacheClient
+}
+
+// CacheService 管理 Gemini 上下文缓存，按 AI 配置、模型与提示词前缀（系统指令 + 工具定义）的摘要复用
+type CacheService struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// NewCacheService 创建上下文缓存服务
+func NewCacheService() *CacheService {
+	return &CacheService{entries: make(map[string]*cacheEntry)}
+}
+
+var contextCaches = NewCacheService()
+
+// ContextCaches 返回全局上下文缓存服务
+func ContextCaches() *CacheService {
+	return contextCaches
+}
+
+// Acquire 返回可引用的缓存名称：已有未过期缓存时直接复用，否则创建；前缀过短或创建失败时返回空字符串
+func (s *CacheService) Acquire(ctx context.Context, client cacheClient, configID, modelName string, cfg *genai.GenerateContentConfig) string {
+	if cfg == nil || cfg.CachedContent != "" || cfg.SystemInstruction == nil {
+		return ""
+	}
+	if contentTokens(cfg.SystemInstruction)+toolsTokens(cfg.Tools) < geminiCacheMinTokens {
+		return ""
+	}
+	key := cachePrefixKey(configID, modelName, cfg)
+
+	s.mu.Lock()
+	e, ok := s.entries[key]
+	if !ok {
+		e = &cacheEntry{configID: configID, model: modelName, client: client}
+		s.entries[key] = e
+	}
+	s.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	if e.name != "" && now.Add(geminiCacheMargin).Before(e.expiresAt) {
+		e.hits++
+		return e.name
+	}
+	if now.Before(e.retryAt) {
+		return ""
+	}
+	cached, err := client.Create(ctx, modelName, &genai.CreateCachedContentConfig{
+		TTL:               geminiCacheTTL,
+		DisplayName:       "jcp-" + key[len(key)-12:],
+		SystemInstruction: cfg.SystemInstruction,
+		Tools:             cfg.Tools,
+		ToolConfig:        cfg.ToolConfig,
+	})
+	if err != nil {
+		log.Warn("创建 Gemini 上下文缓存失败，%v 内不再尝试: %v", geminiCacheRetry, err)
+		e.name, e.retryAt = "", now.Add(geminiCacheRetry)
+		return ""
+	}
+	e.name, e.hits, e.client = cached.Name, 0, client
+	e.expiresAt = now.Add(geminiCacheTTL)
+	if !cached.ExpireTime.IsZero() {
+		e.expiresAt = cached.ExpireTime
+	}
+	if cached.UsageMetadata != nil {
+		e.tokens = int(cached.UsageMetadata.TotalTokenCount)
+	}
+	log.Info("已创建 Gemini 上下文缓存 %s（%s，%d tokens）", e.name, modelName, e.tokens)
+	return e.name
+}
+
+// Invalidate 丢弃指定名称的缓存记录（服务端已删除或过期时）
+func (s *CacheService) Invalidate(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, e := range s.entries {
+		if e.name == name {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// List 列出当前有效的缓存
+func (s *CacheService) List() []models.ContextCacheInfo {
+	s.mu.Lock()
+	entries := make([]*cacheEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	s.mu.Unlock()
+
+	now := time.Now()
+	result := make([]models.ContextCacheInfo, 0, len(entries))
+	for _, e := range entries {
+		e.mu.Lock()
+		if e.name != "" && now.Before(e.expiresAt) {
+			result = append(result, models.ContextCacheInfo{
+				ConfigID:  e.configID,
+				Model:     e.model,
+				Name:      e.name,
+				Tokens:    e.tokens,
+				Hits:      e.hits,
+				ExpiresAt: e.expiresAt.UnixMilli(),
+			})
+		}
+		e.mu.Unlock()
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ExpiresAt > result[j].ExpiresAt })
+	return result
+}
+
+// Clear 删除全部缓存（服务端按存储时长计费，退出时主动删除），返回删除失败的数量
+func (s *CacheService) Clear(ctx context.Context) int {
+	s.mu.Lock()
+	entries := s.entries
+	s.entries = make(map[string]*cacheEntry)
+	s.mu.Unlock()
+
+	failed := 0
+	for _, e := range entries {
+		e.mu.Lock()
+		if e.name != "" && time.Now().Before(e.expiresAt) {
+			if err := e.client.Delete(ctx, e.name); err != nil {
+				log.Warn("删除 Gemini 上下文缓存 %s 失败: %v", e.name, err)
+				failed++
+			}
+		}
+		e.mu.Unlock()
+	}
+	return failed
+}
+
+// cachePrefixKey 缓存键：配置 ID、模型与系统指令、工具定义、工具配置的摘要
+func cachePrefixKey(configID, modelName string, cfg *genai.GenerateContentConfig) string {
+	data, _ := json.Marshal([]any{cfg.SystemInstruction, cfg.Tools, cfg.ToolConfig})
+	sum := sha256.Sum256(data)
+	return configID + "|" + modelName + "|" + hex.EncodeToString(sum[:])
+}
+
+// geminiCacheModel 把系统指令与工具定义放入上下文缓存，请求改为引用缓存名称
+type geminiCacheModel struct {
+	model.LLM
+	client   cacheClient
+	configID string
+	cache    *CacheService
+}
+
+// wrapGeminiCache 包装 Gemini / Vertex AI 模型，创建客户端失败时返回原模型
+func wrapGeminiCache(ctx context.Context, llm model.LLM, clientConfig *genai.ClientConfig, configID string) model.LLM {
+	client, err := genai.NewClient(ctx, clientConfig)
+	if err != nil {
+		log.Warn("create gemini cache client failed: %v", err)
+		return llm
+	}
+	return &geminiCacheModel{LLM: llm, client: &genaiCacheClient{client: client}, configID: configID, cache: contextCaches}
+}
+
+// GenerateContent 实现 model.LLM 接口；引用缓存的请求在尚未输出任何内容前失败时，丢弃缓存并按原请求重试一次
+func (m *geminiCacheModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	name := ""
+	if req != nil {
+		name = m.cache.Acquire(ctx, m.client, m.configID, m.LLM.Name(), req.Config)
+	}
+	if name == "" {
+		return m.LLM.GenerateContent(ctx, req, stream)
+	}
+	return func(yield func(*model.LLMResponse, error) bool) {
+		emitted := false
+		for resp, err := range m.LLM.GenerateContent(ctx, withCachedContent(req, name), stream) {
+			if err != nil && !emitted {
+				log.Warn("引用 Gemini 上下文缓存 %s 失败，改用完整请求: %v", name, err)
+				m.cache.Invalidate(name)
+				for resp, err := range m.LLM.GenerateContent(ctx, req, stream) {
+					if !yield(resp, err) {
+						return
+					}
+				}
+				return
+			}
+			emitted = true
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// withCachedContent 返回引用缓存的请求副本：系统指令、工具与工具配置已在缓存中，不能重复发送
+func withCachedContent(req *model.LLMRequest, name string) *model.LLMRequest {
+	cfg := *req.Config
+	cfg.CachedContent = name
+	cfg.SystemInstruction = nil
+	cfg.Tools = nil
+	cfg.ToolConfig = nil
+	out := *req
+	out.Config = &cfg
+	return &out
+}
@@ -0,0 +1,66 @@
+package adk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+type fakeCacheClient struct {
+	creates, deletes int
+}
+
+func (c *fakeCacheClient) Create(_ context.Context, _ string, cfg *genai.CreateCachedContentConfig) (*genai.CachedContent, error) {
+	c.creates++
+	return &genai.CachedContent{Name: "cachedContents/abc", UsageMetadata: &genai.CachedContentUsageMetadata{TotalTokenCount: 3000}}, nil
+}
+
+func (c *fakeCacheClient) Delete(_ context.Context, _ string) error {
+	c.deletes++
+	return nil
+}
+
+func TestGeminiCacheModel_ReusesCachedPrefix(t *testing.T) {
+	inner := &captureLLM{}
+	client := &fakeCacheClient{}
+	m := &geminiCacheModel{LLM: inner, client: client, configID: "gemini-1", cache: NewCacheService()}
+
+	long := genai.NewContentFromText(strings.Repeat("你是资深分析师，", 500), genai.RoleUser)
+	tools := []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{Name: "get_quote"}}}}
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("分析茅台", genai.RoleUser)},
+		Config:   &genai.GenerateContentConfig{SystemInstruction: long, Tools: tools},
+	}
+	for range 2 {
+		for range m.GenerateContent(context.Background(), req, false) {
+		}
+	}
+	if client.creates != 1 {
+		t.Fatalf("creates = %d", client.creates)
+	}
+	sent := inner.req.Config
+	if sent.CachedContent != "cachedContents/abc" || sent.SystemInstruction != nil || sent.Tools != nil {
+		t.Fatalf("request not rewritten: %+v", sent)
+	}
+	if req.Config.SystemInstruction == nil || req.Config.CachedContent != "" {
+		t.Fatal("original request must not be modified")
+	}
+	if list := m.cache.List(); len(list) != 1 || list[0].Hits != 1 || list[0].Tokens != 3000 {
+		t.Fatalf("list = %+v", list)
+	}
+
+	// 短提示词不缓存
+	short := &model.LLMRequest{Config: &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText("简短", genai.RoleUser)}}
+	for range m.GenerateContent(context.Background(), short, false) {
+	}
+	if inner.req.Config.CachedContent != "" || client.creates != 1 {
+		t.Fatal("short prompt should not be cached")
+	}
+
+	if failed := m.cache.Clear(context.Background()); failed != 0 || client.deletes != 1 {
+		t.Fatalf("clear failed=%d deletes=%d", failed, client.deletes)
+	}
+}
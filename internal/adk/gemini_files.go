@@ -0,0 +1,173 @@
+package adk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Gemini Files API 参数
+const (
+	// geminiInlineLimit 单个内联数据超过该大小时改为上传（请求体整体上限约 20MB）
+	geminiInlineLimit = 4 << 20
+	// geminiFileTTL 已上传文件的复用期限（服务端保留 48 小时，留出余量）
+	geminiFileTTL = 46 * time.Hour
+	// geminiFileWait 等待文件处理完成的最长时间
+	geminiFileWait = 2 * time.Minute
+)
+
+// fileUploader 上传文件并返回可在请求中引用的 URI
+type fileUploader interface {
+	Upload(ctx context.Context, data []byte, mimeType, displayName string) (string, error)
+}
+
+// uploadedFile 已上传文件的缓存记录
+type uploadedFile struct {
+	uri       string
+	expiresAt time.Time
+}
+
+var (
+	geminiUploads   = map[string]uploadedFile{} // key: 配置标识 + 内容摘要
+	geminiUploadsMu sync.Mutex
+)
+
+// geminiFileModel 将请求中的大块内联数据（PDF、图片等）上传到 Files API，改为按 URI 引用
+type geminiFileModel struct {
+	model.LLM
+	uploader fileUploader
+	cacheKey string // 区分不同账号的上传缓存
+	limit    int
+}
+
+// wrapGeminiFiles 包装 Gemini API 模型，失败时返回原模型
+func wrapGeminiFiles(ctx context.Context, llm model.LLM, clientConfig *genai.ClientConfig, cacheKey string) model.LLM {
+	client, err := genai.NewClient(ctx, clientConfig)
+	if err != nil {
+		log.Warn("create gemini files client failed: %v", err)
+		return llm
+	}
+	return &geminiFileModel{
+		LLM:      llm,
+		uploader: &genaiUploader{client: client},
+		cacheKey: cacheKey,
+		limit:    geminiInlineLimit,
+	}
+}
+
+// GenerateContent 实现 model.LLM 接口
+func (m *geminiFileModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return m.LLM.GenerateContent(ctx, m.offloadInlineData(ctx, req), stream)
+}
+
+// offloadInlineData 返回替换了大块内联数据的请求副本，上传失败时保留内联
+func (m *geminiFileModel) offloadInlineData(ctx context.Context, req *model.LLMRequest) *model.LLMRequest {
+	if req == nil {
+		return req
+	}
+	var contents []*genai.Content
+	for i, c := range req.Contents {
+		if c == nil {
+			continue
+		}
+		var parts []*genai.Part
+		for j, p := range c.Parts {
+			if p == nil || p.InlineData == nil || len(p.InlineData.Data) <= m.limit {
+				continue
+			}
+			uri, err := m.upload(ctx, p.InlineData)
+			if err != nil {
+				log.Warn("gemini file upload failed, keep inline (%d bytes): %v", len(p.InlineData.Data), err)
+				continue
+			}
+			if parts == nil {
+				parts = append([]*genai.Part(nil), c.Parts...)
+			}
+			parts[j] = &genai.Part{FileData: &genai.FileData{
+				FileURI:     uri,
+				MIMEType:    p.InlineData.MIMEType,
+				DisplayName: p.InlineData.DisplayName,
+			}}
+		}
+		if parts == nil {
+			continue
+		}
+		// 写时复制，避免修改会话历史中的原始内容
+		if contents == nil {
+			contents = append([]*genai.Content(nil), req.Contents...)
+		}
+		contents[i] = &genai.Content{Role: c.Role, Parts: parts}
+	}
+	if contents == nil {
+		return req
+	}
+	clone := *req
+	clone.Contents = contents
+	return &clone
+}
+
+// upload 上传内联数据，相同内容在有效期内复用已上传的文件
+func (m *geminiFileModel) upload(ctx context.Context, blob *genai.Blob) (string, error) {
+	sum := sha256.Sum256(blob.Data)
+	key := m.cacheKey + "|" + hex.EncodeToString(sum[:])
+
+	geminiUploadsMu.Lock()
+	cached, ok := geminiUploads[key]
+	geminiUploadsMu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.uri, nil
+	}
+
+	uri, err := m.uploader.Upload(ctx, blob.Data, blob.MIMEType, blob.DisplayName)
+	if err != nil {
+		return "", err
+	}
+	geminiUploadsMu.Lock()
+	geminiUploads[key] = uploadedFile{uri: uri, expiresAt: time.Now().Add(geminiFileTTL)}
+	geminiUploadsMu.Unlock()
+	log.Info("uploaded %d bytes to gemini files: %s", len(blob.Data), uri)
+	return uri, nil
+}
+
+// genaiUploader 基于 genai Files API 的上传实现
+type genaiUploader struct {
+	client *genai.Client
+}
+
+// Upload 上传并等待文件处理完成
+func (u *genaiUploader) Upload(ctx context.Context, data []byte, mimeType, displayName string) (string, error) {
+	file, err := u.client.Files.Upload(ctx, bytes.NewReader(data), &genai.UploadFileConfig{
+		MIMEType:    mimeType,
+		DisplayName: displayName,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(geminiFileWait)
+	for file.State == genai.FileStateProcessing {
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("file %s still processing after %v", file.Name, geminiFileWait)
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+		if file, err = u.client.Files.Get(ctx, file.Name, nil); err != nil {
+			return "", err
+		}
+	}
+	if file.State == genai.FileStateFailed {
+		return "", fmt.Errorf("file %s processing failed", file.Name)
+	}
+	return file.URI, nil
+}
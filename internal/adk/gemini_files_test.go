@@ -0,0 +1,56 @@
+package adk
+
+import (
+	"context"
+	"iter"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+type captureLLM struct {
+	req *model.LLMRequest
+}
+
+func (c *captureLLM) Name() string { return "capture" }
+
+func (c *captureLLM) GenerateContent(_ context.Context, req *model.LLMRequest, _ bool) iter.Seq2[*model.LLMResponse, error] {
+	c.req = req
+	return func(yield func(*model.LLMResponse, error) bool) {}
+}
+
+type countingUploader struct{ calls int }
+
+func (u *countingUploader) Upload(_ context.Context, _ []byte, _, _ string) (string, error) {
+	u.calls++
+	return "https://files.example/abc", nil
+}
+
+func TestGeminiFileModel_OffloadsLargeInlineData(t *testing.T) {
+	inner := &captureLLM{}
+	uploader := &countingUploader{}
+	m := &geminiFileModel{LLM: inner, uploader: uploader, cacheKey: t.Name(), limit: 8}
+
+	big := &genai.Part{InlineData: &genai.Blob{Data: []byte("0123456789"), MIMEType: "application/pdf"}}
+	small := &genai.Part{InlineData: &genai.Blob{Data: []byte("tiny"), MIMEType: "image/png"}}
+	req := &model.LLMRequest{Contents: []*genai.Content{{Role: "user", Parts: []*genai.Part{genai.NewPartFromText("看公告"), big, small}}}}
+
+	for range 2 {
+		for range m.GenerateContent(context.Background(), req, false) {
+		}
+	}
+	parts := inner.req.Contents[0].Parts
+	if parts[1].FileData == nil || parts[1].FileData.FileURI != "https://files.example/abc" || parts[1].FileData.MIMEType != "application/pdf" {
+		t.Fatalf("large part not offloaded: %+v", parts[1])
+	}
+	if parts[2].InlineData == nil {
+		t.Fatal("small part should stay inline")
+	}
+	if req.Contents[0].Parts[1].InlineData == nil {
+		t.Fatal("original request must not be modified")
+	}
+	if uploader.calls != 1 {
+		t.Fatalf("uploads = %d, want cached after first", uploader.calls)
+	}
+}
@@ -0,0 +1,71 @@
+package adk
+
+import (
+	"context"
+	"iter"
+
+	"github.com/run-bigpig/jcp/internal/adk/schemasanitize"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// geminiSchemaModel 发送前按 Gemini 规则清洗工具参数与结构化输出的 JSON Schema
+// MCP 工具的 schema 含 $ref、oneOf、const 等关键字时 Gemini 会直接拒绝整个请求
+type geminiSchemaModel struct {
+	model.LLM
+}
+
+// wrapGeminiSchema 包装 Gemini / Vertex AI 模型
+func wrapGeminiSchema(llm model.LLM) model.LLM {
+	return &geminiSchemaModel{LLM: llm}
+}
+
+// GenerateContent 实现 model.LLM 接口
+func (m *geminiSchemaModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return m.LLM.GenerateContent(ctx, sanitizeGeminiRequest(req), stream)
+}
+
+// sanitizeGeminiRequest 返回清洗了 JSON Schema 的请求副本，无需清洗时返回原请求
+// genai.Schema 形式的参数本身就是 Gemini 支持的子集，保持不变
+func sanitizeGeminiRequest(req *model.LLMRequest) *model.LLMRequest {
+	if req == nil || req.Config == nil {
+		return req
+	}
+	cfg := *req.Config
+	changed := false
+	if cfg.ResponseJsonSchema != nil {
+		if s := schemasanitize.Sanitize(cfg.ResponseJsonSchema, schemasanitize.Gemini); s != nil {
+			cfg.ResponseJsonSchema = s
+			changed = true
+		}
+	}
+	tools := make([]*genai.Tool, len(cfg.Tools))
+	for i, t := range cfg.Tools {
+		tools[i] = t
+		if t == nil || len(t.FunctionDeclarations) == 0 {
+			continue
+		}
+		cp := *t
+		cp.FunctionDeclarations = make([]*genai.FunctionDeclaration, len(t.FunctionDeclarations))
+		for j, fd := range t.FunctionDeclarations {
+			cp.FunctionDeclarations[j] = fd
+			if fd == nil || fd.ParametersJsonSchema == nil {
+				continue
+			}
+			if s := schemasanitize.Sanitize(fd.ParametersJsonSchema, schemasanitize.Gemini); s != nil {
+				fdc := *fd
+				fdc.ParametersJsonSchema = s
+				cp.FunctionDeclarations[j] = &fdc
+				changed = true
+			}
+		}
+		tools[i] = &cp
+	}
+	if !changed {
+		return req
+	}
+	cfg.Tools = tools
+	out := *req
+	out.Config = &cfg
+	return &out
+}
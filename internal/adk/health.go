@@ -0,0 +1,165 @@
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/adk/providererr"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// probeError 探测请求返回的非 200 响应
+type probeError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *probeError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// CheckConnection 检查 AI 配置连通性，返回耗时、模型可用性与归一化错误
+// 供设置界面在保存前校验配置
+func (f *ModelFactory) CheckConnection(ctx context.Context, config *models.AIConfig) models.ConnectionCheckResult {
+	start := time.Now()
+	err := f.TestConnection(ctx, config)
+	result := models.ConnectionCheckResult{LatencyMs: time.Since(start).Milliseconds()}
+	if err == nil {
+		result.OK = true
+		result.ModelAvailable = boolPtr(true)
+		return result
+	}
+
+	result.Detail = err.Error()
+	result.StatusCode, result.ErrorCode = classifyConnectionError(err)
+	result.Error = connectionErrorMessages[result.ErrorCode]
+	switch result.ErrorCode {
+	case models.ConnErrModelNotFound:
+		result.ModelAvailable = boolPtr(false)
+	case models.ConnErrAuth, models.ConnErrNetwork, models.ConnErrTimeout, models.ConnErrConfig:
+		// 无法到达模型，不做判断
+	default:
+		// 请求被拒但接口可达时，通过模型列表确认模型是否存在
+		if config.Provider == models.AIProviderOpenAI {
+			if ids, err := f.listOpenAIModels(ctx, config); err == nil {
+				result.ModelAvailable = boolPtr(slices.Contains(ids, config.ModelName))
+			}
+		}
+	}
+	return result
+}
+
+// connectionErrorMessages 归一化错误的提示文案
+var connectionErrorMessages = map[string]string{
+	models.ConnErrConfig:        "配置无效，请检查供应商、Base URL 与凭证格式",
+	models.ConnErrAuth:          "鉴权失败，请检查 API Key 或凭证是否正确、是否有权限",
+	models.ConnErrModelNotFound: "模型不存在或当前账号无权使用，请检查模型名称",
+	models.ConnErrRateLimited:   "请求被限流或额度不足，请稍后重试或检查账户余额",
+	models.ConnErrBadRequest:    "请求被拒绝，接口可能不兼容当前参数",
+	models.ConnErrServer:        "服务端错误，请稍后重试",
+	models.ConnErrTimeout:       "请求超时，请检查网络、代理或超时设置",
+	models.ConnErrNetwork:       "无法连接到服务，请检查 Base URL、网络与代理设置",
+	models.ConnErrUnknown:       "连接失败",
+}
+
+// statusInTextRe 从供应商 SDK 的错误文本中提取 HTTP 状态码
+var statusInTextRe = regexp.MustCompile(`(?i)(?:HTTP|status(?:\s*code)?|Error)\s*:?\s*(\d{3})\b`)
+
+// classifyConnectionError 将各供应商的错误归一化为状态码与错误类型
+func classifyConnectionError(err error) (int, string) {
+	var pe *probeError
+	if errors.As(err, &pe) {
+		return pe.StatusCode, classifyStatus(pe.StatusCode, pe.Body)
+	}
+	if pe, ok := providererr.As(err); ok && pe.StatusCode > 0 {
+		return pe.StatusCode, classifyStatus(pe.StatusCode, pe.Code+" "+pe.Message)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return 0, models.ConnErrTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return 0, models.ConnErrTimeout
+		}
+		return 0, models.ConnErrNetwork
+	}
+
+	msg := err.Error()
+	if m := statusInTextRe.FindStringSubmatch(msg); m != nil {
+		if code, _ := strconv.Atoi(m[1]); code >= 400 && code < 600 {
+			return code, classifyStatus(code, msg)
+		}
+	}
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "不支持的 provider"), strings.Contains(lower, "客户端创建失败"):
+		return 0, models.ConnErrConfig
+	case strings.Contains(lower, "api key not valid"), strings.Contains(lower, "permission_denied"),
+		strings.Contains(lower, "unauthenticated"), strings.Contains(lower, "invalid_api_key"):
+		return 0, models.ConnErrAuth
+	case strings.Contains(lower, "resource_exhausted"):
+		return 0, models.ConnErrRateLimited
+	case strings.Contains(lower, "timeout"), strings.Contains(lower, "deadline"):
+		return 0, models.ConnErrTimeout
+	case strings.Contains(lower, "no such host"), strings.Contains(lower, "connection refused"),
+		strings.Contains(lower, "tls"), strings.Contains(lower, "proxyconnect"), strings.Contains(lower, "连接失败"):
+		return 0, models.ConnErrNetwork
+	}
+	return 0, models.ConnErrUnknown
+}
+
+// classifyStatus 按 HTTP 状态码与响应内容分类
+func classifyStatus(code int, body string) string {
+	lower := strings.ToLower(body)
+	switch {
+	case code == http.StatusUnauthorized:
+		return models.ConnErrAuth
+	case code == http.StatusForbidden:
+		if strings.Contains(lower, "model") {
+			return models.ConnErrModelNotFound
+		}
+		return models.ConnErrAuth
+	case code == http.StatusNotFound:
+		return models.ConnErrModelNotFound
+	case code == http.StatusTooManyRequests || code == http.StatusPaymentRequired:
+		return models.ConnErrRateLimited
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+		if strings.Contains(lower, "model_not_found") || strings.Contains(lower, "does not exist") ||
+			strings.Contains(lower, "invalid model") || strings.Contains(lower, "unknown model") {
+			return models.ConnErrModelNotFound
+		}
+		return models.ConnErrBadRequest
+	case code >= 500:
+		return models.ConnErrServer
+	}
+	return models.ConnErrUnknown
+}
+
+// listOpenAIModels 通过 GET /models 获取 OpenAI 兼容接口的模型 ID 列表
+func (f *ModelFactory) listOpenAIModels(ctx context.Context, config *models.AIConfig) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	entries, err := f.fetchOpenAIModels(ctx, config, normalizeOpenAIBaseURL(config.BaseURL))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(entries))
+	for _, m := range entries {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
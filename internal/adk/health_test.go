@@ -0,0 +1,38 @@
+package adk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestCheckConnection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Authorization") != "Bearer good":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v1/models":
+			w.Write([]byte(`{"data":[{"id":"gpt-a"}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"bad"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	f := NewModelFactory()
+	cfg := &models.AIConfig{Provider: models.AIProviderOpenAI, BaseURL: srv.URL, APIKey: "bad", ModelName: "gpt-b", MaxAttempts: 1}
+	res := f.CheckConnection(context.Background(), cfg)
+	if res.OK || res.ErrorCode != models.ConnErrAuth || res.StatusCode != 401 || res.ModelAvailable != nil {
+		t.Fatalf("auth result = %+v", res)
+	}
+
+	cfg.APIKey = "good"
+	res = f.CheckConnection(context.Background(), cfg)
+	if res.ErrorCode != models.ConnErrBadRequest || res.ModelAvailable == nil || *res.ModelAvailable {
+		t.Fatalf("model result = %+v", res)
+	}
+}
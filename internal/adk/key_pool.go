@@ -0,0 +1,137 @@
+package adk
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"github.com/run-bigpig/jcp/internal/pkg/httpclient"
+)
+
+// defaultKeyCooldown 429 未携带 Retry-After 时的冷却时长
+const defaultKeyCooldown = time.Minute
+
+// keyPool 同一配置下多个 API Key 的轮换池
+type keyPool struct {
+	keys     []string
+	cooldown map[string]time.Time // key -> 冷却结束时间
+	next     int
+	mu       sync.Mutex
+}
+
+var (
+	keyPools   = map[string]*keyPool{}
+	keyPoolsMu sync.Mutex
+)
+
+// apiKeys 返回配置中去重后的全部 API Key（主 Key 在前）
+func apiKeys(config *models.AIConfig) []string {
+	if config == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var keys []string
+	for _, k := range append([]string{config.APIKey}, config.APIKeys...) {
+		k = strings.TrimSpace(k)
+		if k != "" && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// sharedKeyPool 返回按配置共享的轮换池，Key 列表变化时重建
+// 未填写主 Key 或少于两个 Key 时返回 nil（请求中需要有主 Key 才能替换）
+func sharedKeyPool(config *models.AIConfig) *keyPool {
+	keys := apiKeys(config)
+	if len(keys) < 2 || strings.TrimSpace(config.APIKey) == "" {
+		return nil
+	}
+	id := config.ID
+	if id == "" {
+		id = keys[0]
+	}
+	keyPoolsMu.Lock()
+	defer keyPoolsMu.Unlock()
+	if p, ok := keyPools[id]; ok && strings.Join(p.keys, "\n") == strings.Join(keys, "\n") {
+		return p
+	}
+	p := &keyPool{keys: keys, cooldown: make(map[string]time.Time)}
+	keyPools[id] = p
+	return p
+}
+
+// pick 轮询选择未在冷却中的 Key；全部冷却时选最早恢复的
+func (p *keyPool) pick(now time.Time) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	best := ""
+	var bestUntil time.Time
+	for i := range p.keys {
+		k := p.keys[(p.next+i)%len(p.keys)]
+		until, cooling := p.cooldown[k]
+		if !cooling || !now.Before(until) {
+			p.next = (p.next + i + 1) % len(p.keys)
+			return k
+		}
+		if best == "" || until.Before(bestUntil) {
+			best, bestUntil = k, until
+		}
+	}
+	return best
+}
+
+// cool 将 Key 标记为冷却
+func (p *keyPool) cool(key string, d time.Duration, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cooldown[key] = now.Add(d)
+}
+
+// keyPoolTransport 将请求中的主 Key 替换为轮换池选出的 Key
+// 适用于 Authorization / x-api-key / x-goog-api-key 等各种鉴权头以及 URL 中的 key 参数
+type keyPoolTransport struct {
+	base    http.RoundTripper
+	primary string
+	pool    *keyPool
+}
+
+func (t *keyPoolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := t.pool.pick(time.Now())
+	if key != t.primary {
+		req = req.Clone(req.Context())
+		for name, values := range req.Header {
+			for i, v := range values {
+				if strings.Contains(v, t.primary) {
+					req.Header[name][i] = strings.ReplaceAll(v, t.primary, key)
+				}
+			}
+		}
+		if q := req.URL.Query(); q.Get("key") == t.primary {
+			q.Set("key", key)
+			req.URL.RawQuery = q.Encode()
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		d, ok := httpclient.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		if !ok || d <= 0 {
+			d = defaultKeyCooldown
+		}
+		t.pool.cool(key, d, time.Now())
+		log.Warn("API key ...%s rate limited, cooling down for %v", keySuffix(key), d)
+	}
+	return resp, err
+}
+
+// keySuffix 日志中只显示 Key 末 4 位
+func keySuffix(key string) string {
+	if len(key) <= 4 {
+		return key
+	}
+	return key[len(key)-4:]
+}
@@ -0,0 +1,48 @@
+package adk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestKeyPoolTransport_RotatesAndCoolsDown(t *testing.T) {
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Authorization")
+		seen = append(seen, key)
+		if key == "Bearer k2" {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	config := &models.AIConfig{ID: t.Name(), APIKey: "k1", APIKeys: []string{"k2", "k1", " "}}
+	pool := sharedKeyPool(config)
+	if pool == nil || len(pool.keys) != 2 {
+		t.Fatalf("pool = %+v", pool)
+	}
+	client := &http.Client{Transport: &keyPoolTransport{base: http.DefaultTransport, primary: "k1", pool: pool}}
+	for range 4 {
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		req.Header.Set("Authorization", "Bearer k1")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	want := []string{"Bearer k1", "Bearer k2", "Bearer k1", "Bearer k1"}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("keys used = %v, want %v", seen, want)
+		}
+	}
+
+	if sharedKeyPool(&models.AIConfig{APIKey: "only"}) != nil {
+		t.Fatal("single key should not create a pool")
+	}
+}
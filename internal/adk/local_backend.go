@@ -0,0 +1,115 @@
+package adk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// WarmUp 预热本地推理后端（Ollama / llama.cpp）的模型，避免首次对话等待加载权重
+// Ollama 通过 /api/generate 空请求加载模型并设置驻留时长，其他 OpenAI 兼容后端发送最小补全请求
+func (f *ModelFactory) WarmUp(ctx context.Context, config *models.AIConfig) error {
+	if config.Provider != models.AIProviderOpenAI {
+		return fmt.Errorf("模型预热仅支持 OpenAI 兼容的本地推理后端")
+	}
+	if !isOllamaBaseURL(config.BaseURL) {
+		return f.testOpenAIConnection(ctx, config)
+	}
+	transport, err := f.newTransport(config)
+	if err != nil {
+		return err
+	}
+	return ollamaLoad(ctx, &http.Client{Transport: transport}, ollamaBaseURL(config.BaseURL), config.ModelName, config.KeepAlive)
+}
+
+// ollamaBaseURL 去掉 OpenAI 兼容路径 /v1，得到 Ollama 原生接口地址
+func ollamaBaseURL(baseURL string) string {
+	return strings.TrimSuffix(strings.TrimRight(strings.TrimSpace(baseURL), "/"), "/v1")
+}
+
+// ollamaLoad 加载模型并设置驻留时长（空 prompt 只加载不生成）
+func ollamaLoad(ctx context.Context, client *http.Client, base, modelName, keepAlive string) error {
+	body := map[string]any{"model": modelName}
+	if v := keepAliveValue(keepAlive); v != nil {
+		body["keep_alive"] = v
+	}
+	data, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/api/generate", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &probeError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return nil
+}
+
+// keepAliveValue 转换驻留时长：纯数字按秒（-1 常驻、0 立即卸载），其余按时长字符串（如 30m）原样传递
+func keepAliveValue(s string) any {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	return s
+}
+
+// keepAliveTransport Ollama 的 OpenAI 兼容接口不接受 keep_alive，
+// 每次对话结束后模型驻留时长会被重置为服务端默认值，因此在响应读取完毕后重新设置
+type keepAliveTransport struct {
+	base      http.RoundTripper
+	ollamaURL string
+	model     string
+	keepAlive string
+}
+
+func (t *keepAliveTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode >= 300 || strings.Contains(req.URL.Path, "/api/") {
+		return resp, err
+	}
+	resp.Body = &onCloseBody{ReadCloser: resp.Body, fn: func() { go t.refresh() }}
+	return resp, nil
+}
+
+// refresh 重新设置模型驻留时长
+func (t *keepAliveTransport) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := ollamaLoad(ctx, &http.Client{Transport: t.base}, t.ollamaURL, t.model, t.keepAlive); err != nil {
+		log.Debug("刷新 Ollama keep_alive 失败: %v", err)
+	}
+}
+
+// onCloseBody 响应体关闭时执行一次回调
+type onCloseBody struct {
+	io.ReadCloser
+	once sync.Once
+	fn   func()
+}
+
+func (b *onCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.fn)
+	return err
+}
@@ -0,0 +1,47 @@
+package adk
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKeepAliveTransport_RefreshAfterChat(t *testing.T) {
+	refreshed := make(chan map[string]any, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/generate" {
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			refreshed <- body
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &keepAliveTransport{
+		base: http.DefaultTransport, ollamaURL: srv.URL, model: "qwen3", keepAlive: "-1",
+	}}
+	resp, err := client.Post(srv.URL+"/v1/chat/completions", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	select {
+	case <-refreshed:
+		t.Fatal("refresh should wait until the body is closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	resp.Body.Close()
+
+	select {
+	case body := <-refreshed:
+		if body["model"] != "qwen3" || body["keep_alive"] != float64(-1) {
+			t.Fatalf("refresh body = %v", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("keep_alive was not refreshed")
+	}
+}
@@ -4,7 +4,6 @@ package mcp
 
 import (
 	"context"
-	"os/exec"
 	"sync"
 	"time"
 
@@ -114,7 +113,7 @@ func createTransport(cfg *models.MCPServerConfig) mcp.Transport {
 		return &mcp.SSEClientTransport{Endpoint: cfg.Endpoint}
 	case models.MCPTransportCommand:
 		log.Info("创建 Command 传输 [%s]: %s %v", cfg.Name, cfg.Command, cfg.Args)
-		return &mcp.CommandTransport{Command: exec.Command(cfg.Command, cfg.Args...)}
+		return &mcp.CommandTransport{Command: buildCommand(cfg)}
 	default:
 		log.Info("创建 StreamableHTTP 传输 [%s]: %s", cfg.Name, cfg.Endpoint)
 		return &mcp.StreamableClientTransport{
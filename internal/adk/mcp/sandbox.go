@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// baseEnvAllowlist 沙箱内默认保留的环境变量（进程启动和 npx/uvx 查找依赖所需）
+var baseEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "LANG", "LC_ALL", "TMPDIR", "TEMP", "TMP",
+	"USERPROFILE", "APPDATA", "LOCALAPPDATA", "SYSTEMROOT", "COMSPEC", "PATHEXT",
+}
+
+// blackholeProxy 禁网时指向的不可达代理，node/python 等运行时会遵循代理变量
+const blackholeProxy = "http://127.0.0.1:9"
+
+// buildCommand 根据配置构建命令，启用沙箱时限制环境变量、网络和资源
+func buildCommand(cfg *models.MCPServerConfig) *exec.Cmd {
+	sb := cfg.Sandbox
+	if sb == nil || !sb.Enabled {
+		return exec.Command(cfg.Command, cfg.Args...)
+	}
+
+	name, args := cfg.Command, cfg.Args
+	if sb.MaxMemoryMB > 0 || sb.MaxCPUSeconds > 0 {
+		name, args = wrapResourceLimits(sb, name, args)
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Env = sandboxEnv(sb, os.Environ())
+	cmd.Dir = sb.WorkDir
+	if sb.NoNetwork {
+		isolateNetwork(cmd)
+	}
+	log.Info("MCP 沙箱已启用 [%s]: noNetwork=%v, memory=%dMB, cpu=%ds",
+		cfg.Name, sb.NoNetwork, sb.MaxMemoryMB, sb.MaxCPUSeconds)
+	return cmd
+}
+
+// sandboxEnv 过滤父进程环境变量，仅保留白名单并追加显式注入的变量
+func sandboxEnv(sb *models.MCPSandboxConfig, environ []string) []string {
+	allowed := make(map[string]bool)
+	for _, k := range baseEnvAllowlist {
+		allowed[strings.ToUpper(k)] = true
+	}
+	for _, k := range sb.AllowEnv {
+		allowed[strings.ToUpper(k)] = true
+	}
+
+	var env []string
+	for _, kv := range environ {
+		k, _, ok := strings.Cut(kv, "=")
+		if ok && allowed[strings.ToUpper(k)] {
+			env = append(env, kv)
+		}
+	}
+	for k, v := range sb.Env {
+		env = append(env, k+"="+v)
+	}
+	if sb.NoNetwork {
+		for _, k := range []string{"HTTP_PROXY", "HTTPS_PROXY", "ALL_PROXY", "http_proxy", "https_proxy", "all_proxy"} {
+			env = append(env, k+"="+blackholeProxy)
+		}
+		env = append(env, "NO_PROXY=", "no_proxy=", "npm_config_offline=true")
+	}
+	return env
+}
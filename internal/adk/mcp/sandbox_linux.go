@@ -0,0 +1,19 @@
+//go:build linux
+
+package mcp
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// isolateNetwork 在新的 user + network namespace 中启动进程，只有回环网卡可用
+// 系统禁用非特权 user namespace 时进程启动会失败，不会静默放行
+func isolateNetwork(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package mcp
+
+import "os/exec"
+
+// isolateNetwork 非 Linux 平台无法隔离网络命名空间，仅依赖环境变量中的不可达代理
+func isolateNetwork(cmd *exec.Cmd) {
+	log.Warn("当前平台不支持网络隔离，仅通过代理变量阻断网络访问")
+}
@@ -0,0 +1,31 @@
+package mcp
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestSandboxEnv(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "HOME=/home/u", "OPENAI_API_KEY=sk-secret", "GITHUB_TOKEN=ghp", "NODE_OPTIONS=x"}
+	sb := &models.MCPSandboxConfig{
+		Enabled:   true,
+		AllowEnv:  []string{"node_options"},
+		Env:       map[string]string{"API_TOKEN": "t"},
+		NoNetwork: true,
+	}
+
+	env := sandboxEnv(sb, environ)
+
+	for _, want := range []string{"PATH=/usr/bin", "HOME=/home/u", "NODE_OPTIONS=x", "API_TOKEN=t", "HTTPS_PROXY=" + blackholeProxy} {
+		if !slices.Contains(env, want) {
+			t.Errorf("env missing %q: %v", want, env)
+		}
+	}
+	for _, leaked := range []string{"OPENAI_API_KEY=sk-secret", "GITHUB_TOKEN=ghp"} {
+		if slices.Contains(env, leaked) {
+			t.Errorf("env leaked %q", leaked)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package mcp
+
+import (
+	"fmt"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// wrapResourceLimits 通过 sh 的 ulimit 为子进程设置内存和 CPU 上限
+func wrapResourceLimits(sb *models.MCPSandboxConfig, name string, args []string) (string, []string) {
+	script := ""
+	if sb.MaxMemoryMB > 0 {
+		// macOS 不支持 -v，忽略失败
+		script += fmt.Sprintf("ulimit -v %d 2>/dev/null; ", sb.MaxMemoryMB*1024)
+	}
+	if sb.MaxCPUSeconds > 0 {
+		script += fmt.Sprintf("ulimit -t %d; ", sb.MaxCPUSeconds)
+	}
+	script += `exec "$@"`
+	return "sh", append([]string{"-c", script, "sh", name}, args...)
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package mcp
+
+import "github.com/run-bigpig/jcp/internal/models"
+
+// wrapResourceLimits Windows 暂不支持资源限制（需 Job Object），保持原命令
+func wrapResourceLimits(sb *models.MCPSandboxConfig, name string, args []string) (string, []string) {
+	log.Warn("Windows 暂不支持 MCP 进程资源限制，已忽略")
+	return name, args
+}
@@ -0,0 +1,152 @@
+// Package media 将 genai.Part 中的内联数据（InlineData）与文件引用（FileData）
+// 转换为各供应商适配层可直接使用的图片/文档描述，统一 MIME 识别与大小校验
+package media
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// 内联数据大小上限（取各供应商中最严格的限制）
+const (
+	MaxImageSize    = 5 << 20  // 单张图片（Anthropic 5MB）
+	MaxDocumentSize = 32 << 20 // 单个 PDF（Anthropic 与 OpenAI 均为 32MB）
+)
+
+// PDFMIMEType PDF 文档的 MIME 类型
+const PDFMIMEType = "application/pdf"
+
+// supportedImageTypes 各供应商普遍支持的图片格式
+var supportedImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// File 待发送的图片或文档，Data 与 URL 二选一
+type File struct {
+	MIMEType string
+	Name     string // 显示名称（文档需要文件名）
+	Data     []byte // 内联数据
+	URL      string // http(s) 远程地址
+}
+
+// Base64 返回内联数据的 base64 编码
+func (f *File) Base64() string {
+	return base64.StdEncoding.EncodeToString(f.Data)
+}
+
+// DataURL 返回 data URL（内联数据）或远程地址
+func (f *File) DataURL() string {
+	if f.URL != "" {
+		return f.URL
+	}
+	return "data:" + f.MIMEType + ";base64," + f.Base64()
+}
+
+// Filename 返回文件名，缺省时按类型生成
+func (f *File) Filename() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	if f.MIMEType == PDFMIMEType {
+		return "document.pdf"
+	}
+	return "file"
+}
+
+// IsImage 判断 MIME 类型是否为图片
+func IsImage(mimeType string) bool {
+	return strings.HasPrefix(normalizeMIME(mimeType), "image/")
+}
+
+// ImageFromPart 从 Part 中提取图片
+// 不含图片时返回 (nil, nil)；图片格式不支持、超出大小或引用无法访问时返回错误，避免静默丢弃
+func ImageFromPart(part *genai.Part) (*File, error) {
+	switch {
+	case part.InlineData != nil:
+		mimeType := detectMIME(part.InlineData.MIMEType, part.InlineData.Data)
+		if !IsImage(mimeType) {
+			return nil, nil
+		}
+		if !supportedImageTypes[mimeType] {
+			return nil, fmt.Errorf("不支持的图片格式: %s", mimeType)
+		}
+		if len(part.InlineData.Data) > MaxImageSize {
+			return nil, fmt.Errorf("图片大小 %d 字节超过上限 %d 字节", len(part.InlineData.Data), MaxImageSize)
+		}
+		return &File{MIMEType: mimeType, Name: part.InlineData.DisplayName, Data: part.InlineData.Data}, nil
+	case part.FileData != nil:
+		if !IsImage(part.FileData.MIMEType) {
+			return nil, nil
+		}
+		return remoteFile(part.FileData, normalizeMIME(part.FileData.MIMEType), "图片")
+	}
+	return nil, nil
+}
+
+// DocumentFromPart 从 Part 中提取 PDF 文档
+// 不含文档时返回 (nil, nil)；超出大小或引用无法访问时返回错误
+func DocumentFromPart(part *genai.Part) (*File, error) {
+	switch {
+	case part.InlineData != nil:
+		if detectMIME(part.InlineData.MIMEType, part.InlineData.Data) != PDFMIMEType {
+			return nil, nil
+		}
+		if len(part.InlineData.Data) > MaxDocumentSize {
+			return nil, fmt.Errorf("文档大小 %d 字节超过上限 %d 字节", len(part.InlineData.Data), MaxDocumentSize)
+		}
+		return &File{MIMEType: PDFMIMEType, Name: part.InlineData.DisplayName, Data: part.InlineData.Data}, nil
+	case part.FileData != nil:
+		if !isPDF(part.FileData.MIMEType, part.FileData.FileURI) {
+			return nil, nil
+		}
+		return remoteFile(part.FileData, PDFMIMEType, "文档")
+	}
+	return nil, nil
+}
+
+// remoteFile 文件引用只支持 http(s) 地址（gs:// 或 Gemini Files URI 其他供应商无法访问）
+func remoteFile(fd *genai.FileData, mimeType, kind string) (*File, error) {
+	uri := fd.FileURI
+	if !strings.HasPrefix(uri, "https://") && !strings.HasPrefix(uri, "http://") {
+		return nil, fmt.Errorf("%s引用 %q 不是 http(s) 地址，当前供应商无法访问", kind, uri)
+	}
+	return &File{MIMEType: mimeType, Name: fd.DisplayName, URL: uri}, nil
+}
+
+// isPDF 文件引用是否为 PDF：优先看 MIME 类型，缺失时看扩展名
+func isPDF(mimeType, uri string) bool {
+	if m := normalizeMIME(mimeType); m != "" {
+		return m == PDFMIMEType
+	}
+	if i := strings.IndexAny(uri, "?#"); i >= 0 {
+		uri = uri[:i]
+	}
+	return strings.HasSuffix(strings.ToLower(uri), ".pdf")
+}
+
+// detectMIME 优先使用声明的 MIME 类型，缺失或为通用二进制类型时按内容嗅探
+func detectMIME(declared string, data []byte) string {
+	if m := normalizeMIME(declared); m != "" && m != "application/octet-stream" {
+		return m
+	}
+	return normalizeMIME(http.DetectContentType(data))
+}
+
+// normalizeMIME 去掉参数并转小写，image/jpg 统一为 image/jpeg
+func normalizeMIME(mimeType string) string {
+	m := strings.ToLower(strings.TrimSpace(mimeType))
+	if i := strings.IndexByte(m, ';'); i >= 0 {
+		m = strings.TrimSpace(m[:i])
+	}
+	if m == "image/jpg" {
+		return "image/jpeg"
+	}
+	return m
+}
@@ -0,0 +1,35 @@
+package media
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestDocumentFromPart(t *testing.T) {
+	// 未声明 MIME 时按内容识别 PDF
+	doc, err := DocumentFromPart(&genai.Part{InlineData: &genai.Blob{Data: []byte("%PDF-1.7\n"), MIMEType: "application/octet-stream"}})
+	if err != nil || doc == nil || doc.MIMEType != PDFMIMEType || doc.Filename() != "document.pdf" {
+		t.Fatalf("doc = %#v, err = %v", doc, err)
+	}
+	if !strings.HasPrefix(doc.DataURL(), "data:application/pdf;base64,") {
+		t.Errorf("data url = %q", doc.DataURL())
+	}
+
+	// 文件引用按扩展名识别
+	doc, err = DocumentFromPart(genai.NewPartFromURI("https://example.com/report.pdf?v=1", ""))
+	if err != nil || doc == nil || doc.URL == "" {
+		t.Fatalf("remote doc = %#v, err = %v", doc, err)
+	}
+
+	// 图片不是文档
+	if doc, _ := DocumentFromPart(genai.NewPartFromBytes([]byte("\x89PNG\r\n\x1a\n"), "image/png")); doc != nil {
+		t.Errorf("image treated as document: %#v", doc)
+	}
+
+	big := make([]byte, MaxDocumentSize+1)
+	if _, err := DocumentFromPart(genai.NewPartFromBytes(big, PDFMIMEType)); err == nil {
+		t.Error("expected size error")
+	}
+}
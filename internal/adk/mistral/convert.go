@@ -0,0 +1,265 @@
+package mistral
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+var convertLog = logger.New("mistral:convert")
+
+// toolCallIDLen Mistral 要求工具调用 ID 为 9 位字母数字
+const toolCallIDLen = 9
+
+const idAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// toChatRequest 将 ADK LLMRequest 转换为 Mistral 请求
+func toChatRequest(req *model.LLMRequest, modelName string, safePrompt, noSystemRole bool) (*ChatRequest, error) {
+	cr := &ChatRequest{Model: modelName, SafePrompt: safePrompt}
+
+	msgs, err := toMessages(req.Contents)
+	if err != nil {
+		return nil, err
+	}
+
+	var systemText string
+	if req.Config != nil && req.Config.SystemInstruction != nil {
+		systemText = extractText(req.Config.SystemInstruction)
+	}
+	if systemText != "" {
+		if !noSystemRole {
+			msgs = append([]Message{{Role: "system", Content: systemText}}, msgs...)
+		} else if len(msgs) > 0 && msgs[0].Role == "user" {
+			msgs[0].Content = systemText + "\n\n" + msgs[0].Content
+		} else {
+			msgs = append([]Message{{Role: "user", Content: systemText}}, msgs...)
+		}
+	}
+	cr.Messages = msgs
+
+	if req.Config == nil {
+		return cr, nil
+	}
+
+	if len(req.Config.Tools) > 0 {
+		tools, err := convertTools(req.Config.Tools)
+		if err != nil {
+			return nil, err
+		}
+		if len(tools) > 0 {
+			cr.Tools = tools
+			cr.ToolChoice = "auto"
+		}
+	}
+
+	if req.Config.MaxOutputTokens > 0 {
+		cr.MaxTokens = int(req.Config.MaxOutputTokens)
+	}
+	if req.Config.Temperature != nil {
+		t := float64(*req.Config.Temperature)
+		cr.Temperature = &t
+	}
+	if req.Config.TopP != nil {
+		p := float64(*req.Config.TopP)
+		cr.TopP = &p
+	}
+	if len(req.Config.StopSequences) > 0 {
+		cr.Stop = req.Config.StopSequences
+	}
+	return cr, nil
+}
+
+// extractText 提取 genai.Content 中的纯文本
+func extractText(content *genai.Content) string {
+	var texts []string
+	for _, part := range content.Parts {
+		if part.Text != "" && !part.Thought {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// toMessages 转换消息列表
+// 工具调用挂在 assistant 消息上，工具结果各自作为一条 tool 消息
+func toMessages(contents []*genai.Content) ([]Message, error) {
+	var msgs []Message
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+		role := "user"
+		if content.Role == genai.RoleModel {
+			role = "assistant"
+		}
+
+		var texts []string
+		var toolCalls []ToolCall
+		var toolResults []Message
+		for _, part := range content.Parts {
+			if part.Thought {
+				continue
+			}
+			if part.Text != "" {
+				texts = append(texts, part.Text)
+			}
+			if fc := part.FunctionCall; fc != nil {
+				args := fc.Args
+				if args == nil {
+					args = map[string]any{}
+				}
+				argsJSON, err := json.Marshal(args)
+				if err != nil {
+					return nil, fmt.Errorf("marshal function call args: %w", err)
+				}
+				toolCalls = append(toolCalls, ToolCall{
+					ID:       toToolCallID(fc.ID, fc.Name),
+					Type:     "function",
+					Function: FunctionCall{Name: fc.Name, Arguments: string(argsJSON)},
+				})
+			}
+			if fr := part.FunctionResponse; fr != nil {
+				result, err := json.Marshal(fr.Response)
+				if err != nil {
+					return nil, fmt.Errorf("marshal function response: %w", err)
+				}
+				toolResults = append(toolResults, Message{
+					Role:       "tool",
+					Name:       fr.Name,
+					Content:    string(result),
+					ToolCallID: toToolCallID(fr.ID, fr.Name),
+				})
+			}
+		}
+
+		msgs = append(msgs, toolResults...)
+		if len(texts) > 0 || len(toolCalls) > 0 {
+			msgs = append(msgs, Message{
+				Role:      role,
+				Content:   strings.Join(texts, "\n"),
+				ToolCalls: toolCalls,
+			})
+		}
+	}
+	return msgs, nil
+}
+
+// toToolCallID 将任意 ID 映射为 Mistral 接受的 9 位字母数字 ID
+// Mistral 自身返回的 ID 原样保留；ADK 生成的 ID 按哈希映射，保证调用与结果一致
+func toToolCallID(id, name string) string {
+	if len(id) == toolCallIDLen && strings.Trim(id, idAlphabet) == "" {
+		return id
+	}
+	if id == "" {
+		id = name
+	}
+	sum := sha256.Sum256([]byte(id))
+	b := make([]byte, toolCallIDLen)
+	for i := range b {
+		b[i] = idAlphabet[int(sum[i])%len(idAlphabet)]
+	}
+	return string(b)
+}
+
+// convertTools 将 genai.Tool 转换为 Mistral 工具定义
+func convertTools(genaiTools []*genai.Tool) ([]Tool, error) {
+	var tools []Tool
+	for _, gt := range genaiTools {
+		if gt == nil {
+			continue
+		}
+		for _, fd := range gt.FunctionDeclarations {
+			var schema any = fd.ParametersJsonSchema
+			if fd.ParametersJsonSchema == nil {
+				schema = fd.Parameters
+			}
+			if fd.ParametersJsonSchema == nil && fd.Parameters == nil {
+				schema = map[string]any{"type": "object", "properties": map[string]any{}}
+			}
+			schemaJSON, err := json.Marshal(schema)
+			if err != nil {
+				return nil, fmt.Errorf("marshal tool schema: %w", err)
+			}
+			tools = append(tools, Tool{
+				Type: "function",
+				Function: FunctionSpec{
+					Name:        fd.Name,
+					Description: fd.Description,
+					Parameters:  schemaJSON,
+				},
+			})
+		}
+	}
+	return tools, nil
+}
+
+// convertResponse 将非流式响应转换为 ADK LLMResponse
+func convertResponse(resp *ChatResponse) *model.LLMResponse {
+	content := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{}}
+	var finishReason string
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		finishReason = choice.FinishReason
+		if choice.Message.Content.Thinking != "" {
+			content.Parts = append(content.Parts, &genai.Part{Text: choice.Message.Content.Thinking, Thought: true})
+		}
+		if choice.Message.Content.Text != "" {
+			content.Parts = append(content.Parts, &genai.Part{Text: choice.Message.Content.Text})
+		}
+		for _, tc := range choice.Message.ToolCalls {
+			content.Parts = append(content.Parts, toFunctionCallPart(tc.ID, tc.Function.Name, tc.Function.Arguments))
+		}
+	}
+	return &model.LLMResponse{
+		Content:       content,
+		UsageMetadata: convertUsage(resp.Usage),
+		FinishReason:  convertFinishReason(finishReason),
+		TurnComplete:  true,
+	}
+}
+
+// toFunctionCallPart 构造函数调用 Part
+func toFunctionCallPart(id, name, arguments string) *genai.Part {
+	args := make(map[string]any)
+	if arguments != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			convertLog.Warn("解析 tool_call arguments 失败: %v", err)
+		}
+	}
+	return &genai.Part{FunctionCall: &genai.FunctionCall{ID: id, Name: name, Args: args}}
+}
+
+// convertUsage 转换 token 用量
+func convertUsage(u *Usage) *genai.GenerateContentResponseUsageMetadata {
+	if u == nil {
+		return nil
+	}
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.PromptTokens + u.CompletionTokens
+	}
+	return &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     int32(u.PromptTokens),
+		CandidatesTokenCount: int32(u.CompletionTokens),
+		TotalTokenCount:      int32(total),
+	}
+}
+
+// convertFinishReason 转换结束原因
+func convertFinishReason(reason string) genai.FinishReason {
+	switch reason {
+	case "stop", "tool_calls":
+		return genai.FinishReasonStop
+	case "length", "model_length":
+		return genai.FinishReasonMaxTokens
+	case "error":
+		return genai.FinishReasonOther
+	default:
+		return genai.FinishReasonUnspecified
+	}
+}
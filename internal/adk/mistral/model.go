@@ -0,0 +1,289 @@
+package mistral
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/adk/providererr"
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/pkg/sse"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// 确保实现 model.LLM 接口
+var _ model.LLM = &MistralModel{}
+
+// DefaultBaseURL Mistral La Plateforme 默认地址
+const DefaultBaseURL = "https://api.mistral.ai/v1"
+
+var modelLog = logger.New("mistral:model")
+
+// MistralModel 通过 Chat Completions 接口调用 Mistral 模型
+type MistralModel struct {
+	httpClient   *http.Client
+	apiKey       string
+	baseURL      string
+	modelName    string
+	safePrompt   bool
+	noSystemRole bool
+}
+
+// NewMistralModel 创建 Mistral 模型
+// safePrompt 开启后由服务端在对话前注入官方安全提示词
+func NewMistralModel(modelName, apiKey, baseURL string, httpClient *http.Client, safePrompt, noSystemRole bool) *MistralModel {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &MistralModel{
+		httpClient:   httpClient,
+		apiKey:       apiKey,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		modelName:    modelName,
+		safePrompt:   safePrompt,
+		noSystemRole: noSystemRole,
+	}
+}
+
+// Name 返回模型名称
+func (m *MistralModel) Name() string {
+	return m.modelName
+}
+
+// GenerateContent 实现 model.LLM 接口
+func (m *MistralModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	if stream {
+		return m.generateStream(ctx, req)
+	}
+	return m.generate(ctx, req)
+}
+
+// doRequest 发送请求
+func (m *MistralModel) doRequest(ctx context.Context, cr *ChatRequest) (*http.Response, error) {
+	body, err := json.Marshal(cr)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+m.apiKey)
+	if cr.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	} else {
+		httpReq.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		pe := providererr.New("Mistral", resp.StatusCode, "", errorMessage(respBody)).WithHeader(resp.Header).WithBody(respBody)
+		modelLog.Warn("%s 请求 %s 失败: %v，响应体: %s", m.modelName, httpReq.URL.Path, pe, pe.Body)
+		return nil, pe
+	}
+	return resp, nil
+}
+
+// errorMessage 提取错误信息，无法解析时返回原始响应体
+func errorMessage(body []byte) string {
+	var errResp ErrorResponse
+	if json.Unmarshal(body, &errResp) != nil {
+		return string(body)
+	}
+	var msg string
+	if json.Unmarshal(errResp.Message, &msg) == nil && msg != "" {
+		return msg
+	}
+	if len(errResp.Message) > 0 {
+		return string(errResp.Message)
+	}
+	if len(errResp.Detail) > 0 {
+		return string(errResp.Detail)
+	}
+	return string(body)
+}
+
+// generate 非流式生成
+func (m *MistralModel) generate(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		cr, err := toChatRequest(req, m.modelName, m.safePrompt, m.noSystemRole)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		resp, err := m.doRequest(ctx, cr)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		var cresp ChatResponse
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 10*1024*1024)).Decode(&cresp); err != nil {
+			yield(nil, fmt.Errorf("unmarshal response: %w", err))
+			return
+		}
+		llmResp := convertResponse(&cresp)
+		llmResp.CustomMetadata = respmeta.Meta{
+			ResponseID:   cresp.ID,
+			RequestID:    respmeta.RequestIDFromHeader(resp.Header),
+			ModelVersion: cresp.Model,
+		}.Map()
+		yield(llmResp, nil)
+	}
+}
+
+// generateStream 流式生成
+func (m *MistralModel) generateStream(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		cr, err := toChatRequest(req, m.modelName, m.safePrompt, m.noSystemRole)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		cr.Stream = true
+
+		resp, err := m.doRequest(ctx, cr)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		m.processStream(resp.Body, resp.Header, yield)
+	}
+}
+
+// streamToolCall 流式工具调用累积状态
+type streamToolCall struct {
+	id        string
+	name      string
+	arguments string
+}
+
+// processStream 处理 SSE 事件流
+func (m *MistralModel) processStream(body io.Reader, header http.Header, yield func(*model.LLMResponse, error) bool) {
+	reader := sse.NewReader(body, 0)
+
+	meta := respmeta.Meta{RequestID: respmeta.RequestIDFromHeader(header)}
+	var text, thinking strings.Builder
+	toolCalls := make(map[int]*streamToolCall)
+	var finishReason string
+	var usage *Usage
+
+	emit := func(part *genai.Part) bool {
+		return yield(&model.LLMResponse{
+			Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{part}},
+			Partial: true,
+		}, nil)
+	}
+
+	for {
+		ev, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				yield(nil, &respmeta.StreamError{Err: fmt.Errorf("SSE 读取错误: %w", err), Meta: meta})
+			}
+			return
+		}
+		if ev.Done() {
+			break
+		}
+
+		var chunk StreamChunk
+		if err := json.Unmarshal([]byte(ev.Data), &chunk); err != nil {
+			continue
+		}
+		if chunk.ID != "" {
+			meta.ResponseID = chunk.ID
+		}
+		if chunk.Model != "" {
+			meta.ModelVersion = chunk.Model
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		choice := chunk.Choices[0]
+		if choice.FinishReason != "" {
+			finishReason = choice.FinishReason
+		}
+		if t := choice.Delta.Content.Thinking; t != "" {
+			thinking.WriteString(t)
+			if !emit(&genai.Part{Text: t, Thought: true}) {
+				return
+			}
+		}
+		if t := choice.Delta.Content.Text; t != "" {
+			text.WriteString(t)
+			if !emit(&genai.Part{Text: t}) {
+				return
+			}
+		}
+		for _, tc := range choice.Delta.ToolCalls {
+			acc, ok := toolCalls[tc.Index]
+			if !ok {
+				acc = &streamToolCall{}
+				toolCalls[tc.Index] = acc
+			}
+			if tc.ID != "" {
+				acc.id = tc.ID
+			}
+			if tc.Function.Name != "" {
+				acc.name = tc.Function.Name
+			}
+			acc.arguments += tc.Function.Arguments
+		}
+	}
+
+	// 发送最终聚合响应
+	content := &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{}}
+	if thinking.Len() > 0 {
+		content.Parts = append(content.Parts, &genai.Part{Text: thinking.String(), Thought: true})
+	}
+	if text.Len() > 0 {
+		content.Parts = append(content.Parts, &genai.Part{Text: text.String()})
+	}
+	indices := make([]int, 0, len(toolCalls))
+	for idx := range toolCalls {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+	for _, idx := range indices {
+		tc := toolCalls[idx]
+		content.Parts = append(content.Parts, toFunctionCallPart(tc.id, tc.name, tc.arguments))
+	}
+
+	yield(&model.LLMResponse{
+		Content:        content,
+		UsageMetadata:  convertUsage(usage),
+		FinishReason:   convertFinishReason(finishReason),
+		CustomMetadata: meta.Map(),
+		TurnComplete:   true,
+	}, nil)
+}
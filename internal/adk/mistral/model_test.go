@@ -0,0 +1,72 @@
+package mistral
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestProcessStream_TextAndToolCalls(t *testing.T) {
+	body := strings.Join([]string{
+		`data: {"id":"cmpl-1","model":"mistral-large-2411","choices":[{"index":0,"delta":{"role":"assistant","content":"查询"}}]}`,
+		``,
+		`data: {"id":"cmpl-1","choices":[{"index":0,"delta":{"content":"","tool_calls":[{"id":"Ab3dE6gH9","index":0,"function":{"name":"get_quote","arguments":"{\"code\":\"sh600519\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":12,"completion_tokens":8,"total_tokens":20}}`,
+		``,
+		`data: [DONE]`,
+		``,
+	}, "\n")
+
+	header := http.Header{}
+	header.Set("X-Request-Id", "req-1")
+	m := &MistralModel{modelName: "mistral-large-latest"}
+	var responses []*model.LLMResponse
+	m.processStream(strings.NewReader(body), header, func(r *model.LLMResponse, err error) bool {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		responses = append(responses, r)
+		return true
+	})
+
+	if len(responses) != 2 {
+		t.Fatalf("responses = %d, want 2", len(responses))
+	}
+	final := responses[1]
+	if final.FinishReason != genai.FinishReasonStop || final.UsageMetadata.TotalTokenCount != 20 {
+		t.Errorf("final response unexpected: %+v", final)
+	}
+	fc := final.Content.Parts[1].FunctionCall
+	if fc == nil || fc.ID != "Ab3dE6gH9" || fc.Args["code"] != "sh600519" {
+		t.Errorf("function call unexpected: %+v", fc)
+	}
+	md := respmeta.FromCustomMetadata(final.CustomMetadata)
+	if md.ResponseID != "cmpl-1" || md.RequestID != "req-1" || md.ModelVersion != "mistral-large-2411" {
+		t.Errorf("metadata unexpected: %+v", md)
+	}
+}
+
+func TestToMessages_ToolCallIDs(t *testing.T) {
+	contents := []*genai.Content{
+		{Role: genai.RoleUser, Parts: []*genai.Part{{Text: "茅台现价"}}},
+		{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{ID: "adk-7f1c2d", Name: "get_quote"}}}},
+		{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{ID: "adk-7f1c2d", Name: "get_quote", Response: map[string]any{"price": 1500}}}}},
+	}
+	msgs, err := toMessages(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 3 || msgs[2].Role != "tool" {
+		t.Fatalf("messages unexpected: %+v", msgs)
+	}
+	callID := msgs[1].ToolCalls[0].ID
+	if len(callID) != toolCallIDLen || callID != msgs[2].ToolCallID {
+		t.Errorf("tool call id mismatch: call=%q result=%q", callID, msgs[2].ToolCallID)
+	}
+	if got := toToolCallID("Ab3dE6gH9", "x"); got != "Ab3dE6gH9" {
+		t.Errorf("valid id rewritten: %q", got)
+	}
+}
@@ -0,0 +1,160 @@
+package mistral
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ===== Chat Completions 请求 =====
+
+// ChatRequest Mistral Chat Completions 请求体
+type ChatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	TopP        *float64  `json:"top_p,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
+	Tools       []Tool    `json:"tools,omitempty"`
+	ToolChoice  string    `json:"tool_choice,omitempty"` // auto / any / none
+	SafePrompt  bool      `json:"safe_prompt,omitempty"` // 注入官方安全提示词
+}
+
+// Message 对话消息
+type Message struct {
+	Role       string     `json:"role"` // system / user / assistant / tool
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+}
+
+// Tool 工具定义
+type Tool struct {
+	Type     string       `json:"type"` // function
+	Function FunctionSpec `json:"function"`
+}
+
+// FunctionSpec 函数定义
+type FunctionSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// ToolCall 工具调用
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type,omitempty"`
+	Index    int          `json:"index,omitempty"` // 仅流式
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall 函数调用（arguments 为 JSON 字符串）
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ===== 响应 =====
+
+// ChatResponse 非流式响应
+type ChatResponse struct {
+	ID      string   `json:"id"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage,omitempty"`
+}
+
+// Choice 候选结果
+type Choice struct {
+	Index        int             `json:"index"`
+	Message      ResponseMessage `json:"message"`
+	FinishReason string          `json:"finish_reason"`
+}
+
+// ResponseMessage 响应消息
+type ResponseMessage struct {
+	Role      string     `json:"role"`
+	Content   Content    `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// StreamChunk 流式响应片段
+type StreamChunk struct {
+	ID      string         `json:"id"`
+	Model   string         `json:"model"`
+	Choices []StreamChoice `json:"choices"`
+	Usage   *Usage         `json:"usage,omitempty"`
+}
+
+// StreamChoice 流式候选
+type StreamChoice struct {
+	Index        int         `json:"index"`
+	Delta        StreamDelta `json:"delta"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// StreamDelta 流式增量
+type StreamDelta struct {
+	Role      string     `json:"role,omitempty"`
+	Content   Content    `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// Usage token 用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ErrorResponse 错误响应，message 可能是字符串或校验详情对象
+type ErrorResponse struct {
+	Message json.RawMessage `json:"message"`
+	Type    string          `json:"type"`
+	Detail  json.RawMessage `json:"detail"`
+}
+
+// Content 响应内容
+// 普通模型返回字符串；Magistral 推理模型返回 thinking/text 块数组
+type Content struct {
+	Text     string
+	Thinking string
+}
+
+// contentChunk 内容块
+type contentChunk struct {
+	Type     string         `json:"type"` // text / thinking
+	Text     string         `json:"text"`
+	Thinking []contentChunk `json:"thinking"`
+}
+
+// UnmarshalJSON 兼容字符串与块数组两种格式
+func (c *Content) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	if data[0] == '"' {
+		return json.Unmarshal(data, &c.Text)
+	}
+	var chunks []contentChunk
+	if err := json.Unmarshal(data, &chunks); err != nil {
+		return err
+	}
+	var text, thinking strings.Builder
+	for _, chunk := range chunks {
+		switch chunk.Type {
+		case "thinking":
+			for _, t := range chunk.Thinking {
+				thinking.WriteString(t.Text)
+			}
+		case "text":
+			text.WriteString(chunk.Text)
+		}
+	}
+	c.Text = text.String()
+	c.Thinking = thinking.String()
+	return nil
+}
@@ -0,0 +1,360 @@
+package adk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/genai"
+)
+
+// modelCatalogTTL 模型列表缓存时长
+const modelCatalogTTL = 10 * time.Minute
+
+// ModelCatalogService 查询供应商的可用模型列表，供配置界面下拉选择 ModelName
+type ModelCatalogService struct {
+	mu    sync.Mutex
+	cache map[string]catalogEntry
+}
+
+type catalogEntry struct {
+	models    []models.ModelInfo
+	fetchedAt time.Time
+}
+
+// NewModelCatalogService 创建模型列表服务
+func NewModelCatalogService() *ModelCatalogService {
+	return &ModelCatalogService{cache: make(map[string]catalogEntry)}
+}
+
+// ListModels 列出配置对应供应商的可用模型，结果按 ID 排序并缓存一段时间
+func (s *ModelCatalogService) ListModels(ctx context.Context, config *models.AIConfig) ([]models.ModelInfo, error) {
+	p, err := lookupProvider(config.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的 provider: %s", config.Provider)
+	}
+	if p.List == nil {
+		return nil, fmt.Errorf("%s 暂不支持获取模型列表", p.Name)
+	}
+
+	key := string(config.Provider) + "|" + config.BaseURL + "|" + config.APIKey
+	s.mu.Lock()
+	if e, ok := s.cache[key]; ok && time.Since(e.fetchedAt) < modelCatalogTTL {
+		s.mu.Unlock()
+		return e.models, nil
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+	list, err := p.List(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	s.mu.Lock()
+	s.cache[key] = catalogEntry{models: list, fetchedAt: time.Now()}
+	s.mu.Unlock()
+	return list, nil
+}
+
+// openAIModelEntry OpenAI 兼容 /models 返回的模型条目
+// 兼容 OpenRouter（context_length、architecture）、Mistral（max_context_length、capabilities）等扩展字段
+type openAIModelEntry struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	ContextLength    int    `json:"context_length"`
+	MaxContextLength int    `json:"max_context_length"`
+	ContextWindow    int    `json:"context_window"`
+	TopProvider      struct {
+		MaxCompletionTokens int `json:"max_completion_tokens"`
+	} `json:"top_provider"`
+	Architecture struct {
+		InputModalities []string `json:"input_modalities"`
+	} `json:"architecture"`
+	SupportedParameters []string        `json:"supported_parameters"`
+	Capabilities        json.RawMessage `json:"capabilities"`
+}
+
+// toModelInfo 转换为统一的模型信息
+func (e openAIModelEntry) toModelInfo() models.ModelInfo {
+	info := models.ModelInfo{
+		ID:              e.ID,
+		Name:            e.Name,
+		Description:     e.Description,
+		ContextWindow:   max(e.ContextLength, e.MaxContextLength, e.ContextWindow),
+		MaxOutputTokens: e.TopProvider.MaxCompletionTokens,
+	}
+	caps := map[string]bool{}
+	for _, m := range e.Architecture.InputModalities {
+		if m == "image" {
+			caps[models.ModelCapVision] = true
+		}
+	}
+	for _, p := range e.SupportedParameters {
+		switch p {
+		case "tools":
+			caps[models.ModelCapTools] = true
+		case "reasoning", "include_reasoning":
+			caps[models.ModelCapThinking] = true
+		}
+	}
+	// capabilities 可能是布尔字段对象（Mistral）或字符串数组
+	var flags map[string]any
+	var names []string
+	if json.Unmarshal(e.Capabilities, &flags) == nil {
+		for k, v := range flags {
+			if b, _ := v.(bool); b {
+				names = append(names, k)
+			}
+		}
+	} else {
+		_ = json.Unmarshal(e.Capabilities, &names)
+	}
+	for _, name := range names {
+		if c := normalizeCapability(name); c != "" {
+			caps[c] = true
+		}
+	}
+	if strings.Contains(strings.ToLower(e.ID), "embed") {
+		caps[models.ModelCapEmbedding] = true
+	}
+	info.Capabilities = sortedCapabilities(caps)
+	return info
+}
+
+// normalizeCapability 将供应商的能力名称映射为统一标签
+func normalizeCapability(name string) string {
+	switch strings.ToLower(name) {
+	case "vision", "image", "images":
+		return models.ModelCapVision
+	case "tools", "function_calling", "tool_use":
+		return models.ModelCapTools
+	case "thinking", "reasoning":
+		return models.ModelCapThinking
+	case "embedding", "embeddings":
+		return models.ModelCapEmbedding
+	}
+	return ""
+}
+
+func sortedCapabilities(caps map[string]bool) []string {
+	if len(caps) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(caps))
+	for c := range caps {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// fetchOpenAIModels 请求 OpenAI 兼容接口的 GET {baseURL}/models
+func (f *ModelFactory) fetchOpenAIModels(ctx context.Context, config *models.AIConfig, baseURL string) ([]openAIModelEntry, error) {
+	var list struct {
+		Data []openAIModelEntry `json:"data"`
+	}
+	header := http.Header{"Authorization": {"Bearer " + config.APIKey}}
+	if err := f.getJSON(ctx, config, strings.TrimSuffix(baseURL, "/")+"/models", header, &list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
+
+// listOpenAICompatibleModels OpenAI 兼容接口的模型列表，指向 Ollama 时改用 /api/tags
+func (f *ModelFactory) listOpenAICompatibleModels(ctx context.Context, config *models.AIConfig) ([]models.ModelInfo, error) {
+	if isOllamaBaseURL(config.BaseURL) {
+		return f.listOllamaModels(ctx, config)
+	}
+	var baseURL string
+	switch config.Provider {
+	case models.AIProviderOpenRouter:
+		baseURL = strings.TrimRight(strings.TrimSpace(config.BaseURL), "/")
+		if baseURL == "" {
+			baseURL = "https://openrouter.ai/api/v1"
+		}
+	case models.AIProviderMistral:
+		baseURL = strings.TrimRight(strings.TrimSpace(config.BaseURL), "/")
+		if baseURL == "" {
+			baseURL = "https://api.mistral.ai/v1"
+		} else if !strings.HasSuffix(baseURL, "/v1") {
+			baseURL += "/v1"
+		}
+	default:
+		baseURL = normalizeOpenAIBaseURL(config.BaseURL)
+	}
+	entries, err := f.fetchOpenAIModels(ctx, config, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.ModelInfo, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.toModelInfo())
+	}
+	return result, nil
+}
+
+// isOllamaBaseURL 判断 BaseURL 是否指向 Ollama（默认端口 11434 或主机名包含 ollama）
+func isOllamaBaseURL(baseURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Port() == "11434" || strings.Contains(strings.ToLower(u.Hostname()), "ollama")
+}
+
+// listOllamaModels 通过 Ollama 原生接口 GET /api/tags 获取本地模型
+func (f *ModelFactory) listOllamaModels(ctx context.Context, config *models.AIConfig) ([]models.ModelInfo, error) {
+	base := ollamaBaseURL(config.BaseURL)
+	var tags struct {
+		Models []struct {
+			Name    string `json:"name"`
+			Model   string `json:"model"`
+			Details struct {
+				Family            string `json:"family"`
+				ParameterSize     string `json:"parameter_size"`
+				QuantizationLevel string `json:"quantization_level"`
+			} `json:"details"`
+		} `json:"models"`
+	}
+	if err := f.getJSON(ctx, config, base+"/api/tags", nil, &tags); err != nil {
+		return nil, err
+	}
+	result := make([]models.ModelInfo, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		id := m.Model
+		if id == "" {
+			id = m.Name
+		}
+		var desc []string
+		for _, s := range []string{m.Details.Family, m.Details.ParameterSize, m.Details.QuantizationLevel} {
+			if s != "" {
+				desc = append(desc, s)
+			}
+		}
+		info := models.ModelInfo{ID: id, Name: m.Name, Description: strings.Join(desc, " ")}
+		if strings.Contains(strings.ToLower(id), "embed") {
+			info.Capabilities = []string{models.ModelCapEmbedding}
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// listAnthropicModels 通过 GET /v1/models 获取 Anthropic 模型列表
+func (f *ModelFactory) listAnthropicModels(ctx context.Context, config *models.AIConfig) ([]models.ModelInfo, error) {
+	var list struct {
+		Data []struct {
+			ID          string `json:"id"`
+			DisplayName string `json:"display_name"`
+		} `json:"data"`
+	}
+	header := http.Header{
+		"X-Api-Key":         {config.APIKey},
+		"Anthropic-Version": {"2023-06-01"},
+	}
+	if err := f.getJSON(ctx, config, normalizeAnthropicBaseURL(config.BaseURL)+"/v1/models?limit=1000", header, &list); err != nil {
+		return nil, err
+	}
+	result := make([]models.ModelInfo, 0, len(list.Data))
+	for _, m := range list.Data {
+		// Claude 系列模型均支持图片输入与工具调用
+		result = append(result, models.ModelInfo{
+			ID:           m.ID,
+			Name:         m.DisplayName,
+			Capabilities: []string{models.ModelCapTools, models.ModelCapVision},
+		})
+	}
+	return result, nil
+}
+
+// listGeminiModels 通过 models.list 获取 Gemini 模型列表，只保留可生成内容或向量化的模型
+func (f *ModelFactory) listGeminiModels(ctx context.Context, config *models.AIConfig) ([]models.ModelInfo, error) {
+	httpClient, err := f.newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:     config.APIKey,
+		Backend:    genai.BackendGeminiAPI,
+		HTTPClient: httpClient,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("客户端创建失败: %w", err)
+	}
+
+	var result []models.ModelInfo
+	for m, err := range client.Models.All(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		caps := map[string]bool{}
+		usable := false
+		for _, action := range m.SupportedActions {
+			switch action {
+			case "generateContent":
+				usable = true
+				caps[models.ModelCapTools] = true
+				caps[models.ModelCapVision] = true
+			case "embedContent":
+				usable = true
+				caps[models.ModelCapEmbedding] = true
+			}
+		}
+		if !usable {
+			continue
+		}
+		if m.Thinking {
+			caps[models.ModelCapThinking] = true
+		}
+		result = append(result, models.ModelInfo{
+			ID:              strings.TrimPrefix(m.Name, "models/"),
+			Name:            m.DisplayName,
+			Description:     m.Description,
+			ContextWindow:   int(m.InputTokenLimit),
+			MaxOutputTokens: int(m.OutputTokenLimit),
+			Capabilities:    sortedCapabilities(caps),
+		})
+	}
+	return result, nil
+}
+
+// getJSON 通过配置的传输链发送 GET 请求并解析 JSON 响应
+func (f *ModelFactory) getJSON(ctx context.Context, config *models.AIConfig, endpoint string, header http.Header, out any) error {
+	transport, err := f.newTransport(config)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &probeError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(out); err != nil {
+		return fmt.Errorf("解析模型列表失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package adk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestModelCatalog_OpenAICompatible(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer k" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		w.Write([]byte(`{"data":[
+			{"id":"z-model","context_length":128000,"top_provider":{"max_completion_tokens":8192},
+			 "architecture":{"input_modalities":["text","image"]},"supported_parameters":["tools","reasoning"]},
+			{"id":"a-model","max_context_length":32000,"capabilities":{"function_calling":true,"vision":false}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	svc := NewModelCatalogService()
+	cfg := &models.AIConfig{Provider: models.AIProviderOpenAI, BaseURL: srv.URL, APIKey: "k"}
+	for range 2 {
+		list, err := svc.ListModels(context.Background(), cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 2 || list[0].ID != "a-model" || list[0].ContextWindow != 32000 ||
+			!slices.Equal(list[0].Capabilities, []string{models.ModelCapTools}) {
+			t.Fatalf("list[0] = %+v", list[0])
+		}
+		z := list[1]
+		if z.ContextWindow != 128000 || z.MaxOutputTokens != 8192 ||
+			!slices.Equal(z.Capabilities, []string{models.ModelCapThinking, models.ModelCapTools, models.ModelCapVision}) {
+			t.Fatalf("list[1] = %+v", z)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want cached result", calls)
+	}
+}
+
+func TestIsOllamaBaseURL(t *testing.T) {
+	for url, want := range map[string]bool{
+		"http://localhost:11434/v1": true,
+		"http://ollama.lan:8080":    true,
+		"https://api.openai.com/v1": false,
+		"":                          false,
+	} {
+		if got := isOllamaBaseURL(url); got != want {
+			t.Errorf("isOllamaBaseURL(%q) = %v", url, got)
+		}
+	}
+}
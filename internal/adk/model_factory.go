@@ -15,9 +15,10 @@ import (
 	"cloud.google.com/go/auth/credentials"
 	"cloud.google.com/go/auth/httptransport"
 	"github.com/run-bigpig/jcp/internal/adk/anthropic"
+	"github.com/run-bigpig/jcp/internal/adk/bedrock"
+	"github.com/run-bigpig/jcp/internal/adk/mistral"
 	"github.com/run-bigpig/jcp/internal/adk/openai"
 	"github.com/run-bigpig/jcp/internal/models"
-	"github.com/run-bigpig/jcp/internal/pkg/proxy"
 
 	"github.com/run-bigpig/jcp/internal/logger"
 	go_openai "github.com/sashabaranov/go-openai"
@@ -49,46 +50,103 @@ func NewModelFactory() *ModelFactory {
 }
 
 // CreateModel 根据 AI 配置创建对应的模型
+// 启用敏感信息脱敏时，发往云端的模型会被包装
+// 流式输出的文本片段按 StreamCoalesceMs / StreamCoalesceRunes 合并
 func (f *ModelFactory) CreateModel(ctx context.Context, config *models.AIConfig) (model.LLM, error) {
-	switch config.Provider {
-	case models.AIProviderGemini:
-		return f.createGeminiModel(ctx, config)
-	case models.AIProviderVertexAI:
-		return f.createVertexAIModel(ctx, config)
-	case models.AIProviderOpenAI:
-		if config.UseResponses {
-			return f.createOpenAIResponsesModel(config)
-		}
-		return f.createOpenAIModel(config)
-	case models.AIProviderAnthropic:
-		return f.createAnthropicModel(config)
-	default:
-		return nil, fmt.Errorf("unsupported provider: %s", config.Provider)
+	llm, err := f.createModel(ctx, config)
+	if err != nil {
+		return nil, err
 	}
+	return wrapCoalescing(config, wrapRedaction(config, llm)), nil
+}
+
+// createModel 按供应商创建模型
+func (f *ModelFactory) createModel(ctx context.Context, config *models.AIConfig) (model.LLM, error) {
+	p, err := lookupProvider(config.Provider)
+	if err != nil {
+		return nil, err
+	}
+	return p.Create(ctx, config)
 }
 
 // createGeminiModel 创建 Gemini 模型
 func (f *ModelFactory) createGeminiModel(ctx context.Context, config *models.AIConfig) (model.LLM, error) {
+	httpClient, err := f.newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
 	clientConfig := &genai.ClientConfig{
 		APIKey:  config.APIKey,
 		Backend: genai.BackendGeminiAPI,
-		// 注入代理 Transport
-		HTTPClient: &http.Client{
-			Transport: &uaTransport{base: proxy.GetManager().GetTransport()},
-		},
+		// 注入代理、超时与 TLS 选项
+		HTTPClient: httpClient,
 	}
 
-	return gemini.NewModel(ctx, config.ModelName, clientConfig)
+	llm, err := gemini.NewModel(ctx, config.ModelName, clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	// 大文件走 Files API，避免请求体超限
+	// 文件与缓存内容归属创建时的 Key，多 Key 轮换时无法跨 Key 引用，保持内联
+	if len(apiKeys(config)) > 1 {
+		return wrapGeminiSchema(llm), nil
+	}
+	llm = wrapGeminiFiles(ctx, llm, clientConfig, config.ID+"|"+config.APIKey)
+	if config.ContextCache {
+		llm = wrapGeminiCache(ctx, llm, clientConfig, config.ID)
+	}
+	return wrapGeminiSchema(llm), nil
 }
 
 // createVertexAIModel 创建 Vertex AI 模型
+// Claude 模型（publishers/anthropic）走 rawPredict 接口，复用 Anthropic 的请求与响应转换
 func (f *ModelFactory) createVertexAIModel(ctx context.Context, config *models.AIConfig) (model.LLM, error) {
+	clientConfig, err := f.vertexClientConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if isVertexClaude(config.ModelName) {
+		llm := anthropic.NewVertexAnthropicModel(config.ModelName, config.Project, config.Location, clientConfig.HTTPClient, !config.DisablePromptCache)
+		llm.MaxTokens = config.MaxTokens
+		return llm, nil
+	}
+
+	llm, err := gemini.NewModel(ctx, config.ModelName, clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	if config.ContextCache {
+		llm = wrapGeminiCache(ctx, llm, clientConfig, config.ID)
+	}
+	return wrapGeminiSchema(llm), nil
+}
+
+// isVertexClaude 判断 Vertex AI 模型是否为 Anthropic Claude（如 claude-sonnet-4@20250514）
+func isVertexClaude(modelName string) bool {
+	name := strings.ToLower(strings.TrimSpace(modelName))
+	name = strings.TrimPrefix(name, "publishers/anthropic/models/")
+	return strings.HasPrefix(name, "claude")
+}
+
+// isClaudeConfig 判断配置是否走 Anthropic Messages 接口（Anthropic 或 Vertex AI 上的 Claude）
+func isClaudeConfig(config *models.AIConfig) bool {
+	if config == nil {
+		return false
+	}
+	return config.Provider == models.AIProviderAnthropic ||
+		(config.Provider == models.AIProviderVertexAI && isVertexClaude(config.ModelName))
+}
+
+// vertexClientConfig 获取凭证并构建 Vertex AI 客户端配置
+func (f *ModelFactory) vertexClientConfig(config *models.AIConfig) (*genai.ClientConfig, error) {
 	// 获取代理 Transport
-	uaRT := &uaTransport{base: proxy.GetManager().GetTransport()}
+	uaRT, err := f.newTransport(config)
+	if err != nil {
+		return nil, err
+	}
 
 	// 获取凭证
 	var creds *auth.Credentials
-	var err error
 
 	detectOpts := &credentials.DetectOptions{
 		Scopes: []string{"https://www.googleapis.com/auth/cloud-platform"},
@@ -110,15 +168,13 @@ func (f *ModelFactory) createVertexAIModel(ctx context.Context, config *models.A
 		return nil, fmt.Errorf("failed to create authenticated HTTP client: %w", err)
 	}
 
-	clientConfig := &genai.ClientConfig{
+	return &genai.ClientConfig{
 		Backend:     genai.BackendVertexAI,
 		Project:     config.Project,
 		Location:    config.Location,
 		Credentials: creds,
 		HTTPClient:  httpClient,
-	}
-
-	return gemini.NewModel(ctx, config.ModelName, clientConfig)
+	}, nil
 }
 
 // normalizeOpenAIBaseURL 规范化 OpenAI BaseURL
@@ -138,12 +194,28 @@ func normalizeOpenAIBaseURL(baseURL string) string {
 func (f *ModelFactory) createOpenAIModel(config *models.AIConfig) (model.LLM, error) {
 	openaiCfg := go_openai.DefaultConfig(config.APIKey)
 	openaiCfg.BaseURL = normalizeOpenAIBaseURL(config.BaseURL)
-	// 注入代理 Transport
-	openaiCfg.HTTPClient = &http.Client{
-		Transport: &uaTransport{base: proxy.GetManager().GetTransport()},
+	// 注入代理、超时与 TLS 选项
+	httpClient, err := f.newHTTPClient(config)
+	if err != nil {
+		return nil, err
 	}
+	openaiCfg.HTTPClient = httpClient
 
-	return openai.NewOpenAIModel(config.ModelName, openaiCfg, config.NoSystemRole), nil
+	m := openai.NewOpenAIModel(config.ModelName, openaiCfg, config.NoSystemRole)
+	m.LogitBias = config.LogitBias
+	return m, nil
+}
+
+// CreateBatchClient 创建 OpenAI Batch API 客户端（仅支持 OpenAI 提供商）
+func (f *ModelFactory) CreateBatchClient(config *models.AIConfig) (*openai.BatchClient, error) {
+	if config == nil || config.Provider != models.AIProviderOpenAI {
+		return nil, fmt.Errorf("批量模式仅支持 OpenAI 提供商")
+	}
+	httpClient, err := f.newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return openai.NewBatchClient(config.ModelName, config.APIKey, normalizeOpenAIBaseURL(config.BaseURL), httpClient), nil
 }
 
 // normalizeAnthropicBaseURL 规范化 Anthropic BaseURL
@@ -159,21 +231,85 @@ func normalizeAnthropicBaseURL(baseURL string) string {
 // createAnthropicModel 创建 Anthropic 模型
 func (f *ModelFactory) createAnthropicModel(config *models.AIConfig) (model.LLM, error) {
 	baseURL := normalizeAnthropicBaseURL(config.BaseURL)
-	httpClient := &http.Client{
-		Transport: &uaTransport{base: proxy.GetManager().GetTransport()},
+	httpClient, err := f.newHTTPClient(config)
+	if err != nil {
+		return nil, err
 	}
-	return anthropic.NewAnthropicModel(config.ModelName, config.APIKey, baseURL, httpClient, config.NoSystemRole), nil
+	llm := anthropic.NewAnthropicModel(config.ModelName, config.APIKey, baseURL, httpClient, config.NoSystemRole, !config.DisablePromptCache)
+	llm.MaxTokens = config.MaxTokens
+	return llm, nil
 }
 
 // createOpenAIResponsesModel 创建使用 Responses API 的 OpenAI 模型
 func (f *ModelFactory) createOpenAIResponsesModel(config *models.AIConfig) (model.LLM, error) {
 	baseURL := normalizeOpenAIBaseURL(config.BaseURL)
 
-	// 使用代理管理器的 HTTP Client
-	httpClient := &http.Client{
-		Transport: &uaTransport{base: proxy.GetManager().GetTransport()},
+	// 使用共享的 HTTP Client（代理、超时与 TLS 选项）
+	httpClient, err := f.newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	m := openai.NewResponsesModel(config.ModelName, config.APIKey, baseURL, httpClient, config.NoSystemRole)
+	m.UseConversations = config.UseConversations
+	m.UsePreviousResponseID = config.UsePreviousResponseID
+	m.HostedTools = config.HostedTools
+	m.ReasoningSummary = config.ReasoningSummary
+	return m, nil
+}
+
+// createBedrockModel 创建 AWS Bedrock 模型（Converse API）
+// BaseURL 非空时作为自定义 endpoint（如 VPC 终端节点）
+func (f *ModelFactory) createBedrockModel(config *models.AIConfig) (model.LLM, error) {
+	httpClient, err := f.newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	creds := bedrock.Credentials{
+		AccessKeyID:     config.AccessKeyID,
+		SecretAccessKey: config.SecretAccessKey,
+		SessionToken:    config.SessionToken,
 	}
-	return openai.NewResponsesModel(config.ModelName, config.APIKey, baseURL, httpClient, config.NoSystemRole), nil
+	return bedrock.NewBedrockModel(config.ModelName, config.Region, strings.TrimSpace(config.BaseURL), creds, httpClient, config.NoSystemRole)
+}
+
+// openRouterReferer / openRouterTitle OpenRouter 应用归属信息
+const (
+	openRouterReferer = "https://github.com/run-bigpig/jcp"
+	openRouterTitle   = "JCP"
+)
+
+// createOpenRouterModel 创建 OpenRouter 模型
+func (f *ModelFactory) createOpenRouterModel(config *models.AIConfig) (model.LLM, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(config.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = "https://openrouter.ai/api/v1"
+	}
+	httpClient, err := f.newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	opts := openai.OpenRouterOptions{
+		Referer:        openRouterReferer,
+		Title:          openRouterTitle,
+		ProviderOrder:  config.ProviderOrder,
+		AllowFallbacks: config.AllowFallbacks,
+	}
+	m := openai.NewOpenRouterModel(config.ModelName, config.APIKey, baseURL, httpClient, opts, config.NoSystemRole)
+	m.LogitBias = config.LogitBias
+	return m, nil
+}
+
+// createMistralModel 创建 Mistral 模型
+func (f *ModelFactory) createMistralModel(config *models.AIConfig) (model.LLM, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(config.BaseURL), "/")
+	if baseURL != "" && !strings.HasSuffix(baseURL, "/v1") {
+		baseURL += "/v1"
+	}
+	httpClient, err := f.newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return mistral.NewMistralModel(config.ModelName, config.APIKey, baseURL, httpClient, config.SafePrompt, config.NoSystemRole), nil
 }
 
 // TestConnection 测试 AI 配置的连通性
@@ -182,18 +318,19 @@ func (f *ModelFactory) TestConnection(ctx context.Context, config *models.AIConf
 	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
 	defer cancel()
 
-	switch config.Provider {
-	case models.AIProviderOpenAI:
-		return f.testOpenAIConnection(ctx, config)
-	case models.AIProviderGemini:
-		return f.testGeminiConnection(ctx, config)
-	case models.AIProviderVertexAI:
-		return f.testVertexAIConnection(ctx, config)
-	case models.AIProviderAnthropic:
-		return f.testAnthropicConnection(ctx, config)
-	default:
+	p, err := lookupProvider(config.Provider)
+	if err != nil {
 		return fmt.Errorf("不支持的 provider: %s", config.Provider)
 	}
+	if p.Test != nil {
+		return p.Test(ctx, config)
+	}
+
+	llm, err := p.Create(ctx, config)
+	if err != nil {
+		return fmt.Errorf("客户端创建失败: %w", err)
+	}
+	return f.testViaGenerate(ctx, llm)
 }
 
 // systemRoleProbeKeyword 探测暗号，不可能在正常对话中自然出现
@@ -219,7 +356,10 @@ func (f *ModelFactory) detectOpenAISystemRole(ctx context.Context, config *model
 	defer cancel()
 
 	baseURL := normalizeOpenAIBaseURL(config.BaseURL)
-	transport := proxy.GetManager().GetTransport()
+	transport, err := f.newTransport(config)
+	if err != nil {
+		return false
+	}
 
 	systemPrompt := fmt.Sprintf(
 		"You must reply with exactly: %s. Do not add anything else.",
@@ -278,7 +418,10 @@ func (f *ModelFactory) detectAnthropicSystemRole(ctx context.Context, config *mo
 	defer cancel()
 
 	baseURL := normalizeAnthropicBaseURL(config.BaseURL)
-	transport := proxy.GetManager().GetTransport()
+	transport, err := f.newTransport(config)
+	if err != nil {
+		return false
+	}
 
 	body := map[string]any{
 		"model":      config.ModelName,
@@ -350,7 +493,10 @@ func (f *ModelFactory) extractAnthropicReplyText(respBody []byte) string {
 // 根据 UseResponses 配置决定使用 Responses API 或 Chat Completions API
 func (f *ModelFactory) testOpenAIConnection(ctx context.Context, config *models.AIConfig) error {
 	baseURL := normalizeOpenAIBaseURL(config.BaseURL)
-	transport := proxy.GetManager().GetTransport()
+	transport, err := f.newTransport(config)
+	if err != nil {
+		return err
+	}
 
 	var body map[string]interface{}
 	var endpoint string
@@ -398,33 +544,16 @@ func (f *ModelFactory) testOpenAIConnection(ctx context.Context, config *models.
 	}
 
 	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
-	return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
-}
-
-// testGeminiConnection 测试 Gemini 连通性
-func (f *ModelFactory) testGeminiConnection(ctx context.Context, config *models.AIConfig) error {
-	llm, err := f.createGeminiModel(ctx, config)
-	if err != nil {
-		return fmt.Errorf("客户端创建失败: %w", err)
-	}
-
-	return f.testViaGenerate(ctx, llm)
-}
-
-// testVertexAIConnection 测试 Vertex AI 连通性
-func (f *ModelFactory) testVertexAIConnection(ctx context.Context, config *models.AIConfig) error {
-	llm, err := f.createVertexAIModel(ctx, config)
-	if err != nil {
-		return fmt.Errorf("客户端创建失败: %w", err)
-	}
-
-	return f.testViaGenerate(ctx, llm)
+	return &probeError{StatusCode: resp.StatusCode, Body: string(respBody)}
 }
 
 // testAnthropicConnection 测试 Anthropic 连通性
 func (f *ModelFactory) testAnthropicConnection(ctx context.Context, config *models.AIConfig) error {
 	baseURL := normalizeAnthropicBaseURL(config.BaseURL)
-	transport := proxy.GetManager().GetTransport()
+	transport, err := f.newTransport(config)
+	if err != nil {
+		return err
+	}
 
 	body := map[string]any{
 		"model":      config.ModelName,
@@ -462,7 +591,7 @@ func (f *ModelFactory) testAnthropicConnection(ctx context.Context, config *mode
 	}
 
 	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
-	return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(respBody))
+	return &probeError{StatusCode: resp.StatusCode, Body: string(respBody)}
 }
 
 // testViaGenerate 通过 GenerateContent 发送最小请求测试连通性
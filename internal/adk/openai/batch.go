@@ -0,0 +1,340 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/adk/providererr"
+)
+
+// Batch API 任务状态（终态为 completed/failed/expired/cancelled）
+const (
+	BatchStatusValidating = "validating"
+	BatchStatusInProgress = "in_progress"
+	BatchStatusFinalizing = "finalizing"
+	BatchStatusCompleted  = "completed"
+	BatchStatusFailed     = "failed"
+	BatchStatusExpired    = "expired"
+	BatchStatusCancelling = "cancelling"
+	BatchStatusCancelled  = "cancelled"
+)
+
+// BatchTerminal 判断任务是否已结束
+func BatchTerminal(status string) bool {
+	switch status {
+	case BatchStatusCompleted, BatchStatusFailed, BatchStatusExpired, BatchStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// batchEndpoint 批量请求使用的接口
+const batchEndpoint = "/v1/chat/completions"
+
+// BatchClient OpenAI Batch API 客户端：上传 JSONL 请求文件、创建任务、轮询状态并下载结果
+type BatchClient struct {
+	httpClient HTTPDoer
+	baseURL    string
+	apiKey     string
+	modelName  string
+}
+
+// NewBatchClient 创建 Batch API 客户端，baseURL 需以 /v1 结尾
+func NewBatchClient(modelName, apiKey, baseURL string, httpClient HTTPDoer) *BatchClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &BatchClient{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		modelName:  modelName,
+	}
+}
+
+// BatchPrompt 一条批量请求：CustomID 用于把结果对应回股票
+type BatchPrompt struct {
+	CustomID string
+	System   string
+	User     string
+}
+
+// Batch 批量任务
+type Batch struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	InputFileID   string `json:"input_file_id"`
+	OutputFileID  string `json:"output_file_id"`
+	ErrorFileID   string `json:"error_file_id"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+	Errors *struct {
+		Data []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"data"`
+	} `json:"errors,omitempty"`
+}
+
+// ErrorMessage 汇总任务级错误
+func (b *Batch) ErrorMessage() string {
+	if b.Errors == nil {
+		return ""
+	}
+	msgs := make([]string, 0, len(b.Errors.Data))
+	for _, e := range b.Errors.Data {
+		msgs = append(msgs, e.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// BatchResult 单条请求的结果
+type BatchResult struct {
+	CustomID         string
+	Content          string
+	Error            string
+	PromptTokens     int
+	CompletionTokens int
+	CachedTokens     int
+}
+
+// Submit 生成请求文件并创建批量任务
+func (c *BatchClient) Submit(ctx context.Context, prompts []BatchPrompt, metadata map[string]string) (*Batch, error) {
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf("批量请求为空")
+	}
+	fileID, err := c.uploadFile(ctx, c.buildInput(prompts))
+	if err != nil {
+		return nil, err
+	}
+	body, _ := json.Marshal(map[string]any{
+		"input_file_id":     fileID,
+		"endpoint":          batchEndpoint,
+		"completion_window": "24h",
+		"metadata":          metadata,
+	})
+	var batch Batch
+	if err := c.doJSON(ctx, http.MethodPost, "/batches", body, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// Get 查询任务状态
+func (c *BatchClient) Get(ctx context.Context, batchID string) (*Batch, error) {
+	var batch Batch
+	if err := c.doJSON(ctx, http.MethodGet, "/batches/"+batchID, nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// Cancel 取消任务
+func (c *BatchClient) Cancel(ctx context.Context, batchID string) (*Batch, error) {
+	var batch Batch
+	if err := c.doJSON(ctx, http.MethodPost, "/batches/"+batchID+"/cancel", nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// Results 下载并解析已结束任务的结果（成功输出与错误文件）
+func (c *BatchClient) Results(ctx context.Context, batch *Batch) ([]BatchResult, error) {
+	var results []BatchResult
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		data, err := c.fileContent(ctx, fileID)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, ParseBatchOutput(data)...)
+	}
+	return results, nil
+}
+
+// buildInput 生成 JSONL 请求文件内容
+func (c *BatchClient) buildInput(prompts []BatchPrompt) []byte {
+	var buf bytes.Buffer
+	for _, p := range prompts {
+		messages := make([]map[string]string, 0, 2)
+		if p.System != "" {
+			messages = append(messages, map[string]string{"role": "system", "content": p.System})
+		}
+		messages = append(messages, map[string]string{"role": "user", "content": p.User})
+		line, _ := json.Marshal(map[string]any{
+			"custom_id": p.CustomID,
+			"method":    http.MethodPost,
+			"url":       batchEndpoint,
+			"body": map[string]any{
+				"model":    c.modelName,
+				"messages": messages,
+			},
+		})
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// uploadFile 上传请求文件（purpose=batch），返回文件 ID
+func (c *BatchClient) uploadFile(ctx context.Context, content []byte) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("purpose", "batch")
+	part, err := w.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(content); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/files", &body)
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(req, &file); err != nil {
+		return "", err
+	}
+	if file.ID == "" {
+		return "", fmt.Errorf("Files API 未返回 id")
+	}
+	return file.ID, nil
+}
+
+// fileContent 下载文件内容
+func (c *BatchClient) fileContent(ctx context.Context, fileID string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/files/"+fileID+"/content", nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, providererr.FromResponse("OpenAI Files", resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// doJSON 发送 JSON 请求并解析响应
+func (c *BatchClient) doJSON(ctx context.Context, method, path string, body []byte, out any) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	return c.do(req, out)
+}
+
+func (c *BatchClient) do(req *http.Request, out any) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return providererr.FromResponse("OpenAI Batch", resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
+
+// batchOutputLine 结果文件中的一行
+type batchOutputLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int `json:"status_code"`
+		Body       struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens        int `json:"prompt_tokens"`
+				CompletionTokens    int `json:"completion_tokens"`
+				PromptTokensDetails *struct {
+					CachedTokens int `json:"cached_tokens"`
+				} `json:"prompt_tokens_details"`
+			} `json:"usage"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ParseBatchOutput 解析结果或错误文件（JSONL），跳过无法解析的行
+func ParseBatchOutput(data []byte) []BatchResult {
+	var results []BatchResult
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 8<<20)
+	for scanner.Scan() {
+		var line batchOutputLine
+		if json.Unmarshal(scanner.Bytes(), &line) != nil || line.CustomID == "" {
+			continue
+		}
+		r := BatchResult{CustomID: line.CustomID}
+		switch {
+		case line.Error != nil:
+			r.Error = line.Error.Message
+		case line.Response == nil:
+			r.Error = "无响应"
+		case line.Response.StatusCode >= 400:
+			r.Error = fmt.Sprintf("HTTP %d", line.Response.StatusCode)
+			if e := line.Response.Body.Error; e != nil {
+				r.Error += ": " + e.Message
+			}
+		default:
+			if len(line.Response.Body.Choices) > 0 {
+				r.Content = line.Response.Body.Choices[0].Message.Content
+			}
+			if u := line.Response.Body.Usage; u != nil {
+				r.PromptTokens = u.PromptTokens
+				r.CompletionTokens = u.CompletionTokens
+				if u.PromptTokensDetails != nil {
+					r.CachedTokens = u.PromptTokensDetails.CachedTokens
+				}
+			}
+		}
+		results = append(results, r)
+	}
+	return results
+}
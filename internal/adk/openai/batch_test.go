@@ -0,0 +1,81 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBatchClient_SubmitAndResults(t *testing.T) {
+	var inputLines []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files":
+			if r.FormValue("purpose") != "batch" {
+				t.Errorf("unexpected purpose: %q", r.FormValue("purpose"))
+			}
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				t.Fatal(err)
+			}
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				var line map[string]any
+				json.Unmarshal(scanner.Bytes(), &line)
+				inputLines = append(inputLines, line)
+			}
+			w.Write([]byte(`{"id":"file_in"}`))
+		case "/batches":
+			var req map[string]any
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["input_file_id"] != "file_in" || req["endpoint"] != "/v1/chat/completions" {
+				t.Errorf("unexpected batch request: %v", req)
+			}
+			w.Write([]byte(`{"id":"batch_1","status":"validating"}`))
+		case "/batches/batch_1":
+			w.Write([]byte(`{"id":"batch_1","status":"completed","output_file_id":"file_out","error_file_id":"file_err","request_counts":{"total":2,"completed":1,"failed":1}}`))
+		case "/files/file_out/content":
+			w.Write([]byte(`{"custom_id":"sh600519","response":{"status_code":200,"body":{"choices":[{"message":{"content":"复盘"}}],"usage":{"prompt_tokens":100,"completion_tokens":20,"prompt_tokens_details":{"cached_tokens":40}}}}}` + "\n"))
+		case "/files/file_err/content":
+			w.Write([]byte(`{"custom_id":"sz000001","response":{"status_code":400,"body":{"error":{"message":"bad"}}}}` + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewBatchClient("gpt-4o-mini", "k", srv.URL, srv.Client())
+	ctx := context.Background()
+	batch, err := c.Submit(ctx, []BatchPrompt{
+		{CustomID: "sh600519", System: "sys", User: "u1"},
+		{CustomID: "sz000001", User: "u2"},
+	}, nil)
+	if err != nil || batch.ID != "batch_1" {
+		t.Fatalf("submit: %v %+v", err, batch)
+	}
+	if len(inputLines) != 2 || inputLines[0]["custom_id"] != "sh600519" {
+		t.Fatalf("unexpected input lines: %v", inputLines)
+	}
+	body := inputLines[0]["body"].(map[string]any)
+	if body["model"] != "gpt-4o-mini" || len(body["messages"].([]any)) != 2 {
+		t.Fatalf("unexpected request body: %v", body)
+	}
+
+	batch, err = c.Get(ctx, "batch_1")
+	if err != nil || !BatchTerminal(batch.Status) {
+		t.Fatalf("get: %v %+v", err, batch)
+	}
+	results, err := c.Results(ctx, batch)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("results: %v %+v", err, results)
+	}
+	if r := results[0]; r.Content != "复盘" || r.PromptTokens != 100 || r.CachedTokens != 40 || r.Error != "" {
+		t.Fatalf("unexpected success result: %+v", r)
+	}
+	if r := results[1]; r.CustomID != "sz000001" || r.Error != "HTTP 400: bad" {
+		t.Fatalf("unexpected error result: %+v", r)
+	}
+}
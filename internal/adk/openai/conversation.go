@@ -0,0 +1,140 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/run-bigpig/jcp/internal/adk/providererr"
+	"google.golang.org/genai"
+)
+
+// ConversationStore 本地会话与服务端 conversation 的映射存储
+type ConversationStore interface {
+	GetConversation(key string) string
+	SetConversation(key, conversationID string) error
+}
+
+type conversationStoreKey struct{}
+type conversationKeyKey struct{}
+
+// WithConversationStore 在 context 中附加 conversation 映射存储（通常对应一个股票会话）
+func WithConversationStore(ctx context.Context, store ConversationStore) context.Context {
+	return context.WithValue(ctx, conversationStoreKey{}, store)
+}
+
+// WithConversationKey 指定当前调用在存储中的 key（如 专家ID@AI配置ID）
+func WithConversationKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, conversationKeyKey{}, key)
+}
+
+// conversationFromContext 取出存储与 key，任一缺失时返回 nil
+func conversationFromContext(ctx context.Context) (ConversationStore, string) {
+	store, _ := ctx.Value(conversationStoreKey{}).(ConversationStore)
+	key, _ := ctx.Value(conversationKeyKey{}).(string)
+	if store == nil || key == "" {
+		return nil, ""
+	}
+	return store, key
+}
+
+// conversationResponse Conversations API 创建响应
+type conversationResponse struct {
+	ID string `json:"id"`
+}
+
+// resolveConversation 返回当前调用对应的 conversation ID，不存在时创建
+// 未启用或创建失败时返回空字符串，调用方回退为发送完整历史
+func (r *ResponsesModel) resolveConversation(ctx context.Context) string {
+	if !r.UseConversations {
+		return ""
+	}
+	store, key := conversationFromContext(ctx)
+	if store == nil {
+		return ""
+	}
+	if id := store.GetConversation(key); id != "" {
+		return id
+	}
+	id, err := r.createConversation(ctx, key)
+	if err != nil {
+		respLog.Warn("创建 conversation 失败，回退为完整历史: %v", err)
+		return ""
+	}
+	if err := store.SetConversation(key, id); err != nil {
+		respLog.Warn("保存 conversation 映射失败: %v", err)
+	}
+	return id
+}
+
+// createConversation 调用 POST /conversations 创建服务端会话
+func (r *ResponsesModel) createConversation(ctx context.Context, key string) (string, error) {
+	body, _ := json.Marshal(map[string]any{
+		"metadata": map[string]string{"source": "jcp", "key": key},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+"/conversations", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return "", providererr.FromResponse("OpenAI Conversations", resp)
+	}
+	var conv conversationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&conv); err != nil {
+		return "", fmt.Errorf("解析响应失败: %w", err)
+	}
+	if conv.ID == "" {
+		return "", fmt.Errorf("Conversations API 未返回 id")
+	}
+	return conv.ID, nil
+}
+
+// newConversationItems 返回最后一条模型输出之后的内容
+// 之前的输入与输出已保存在服务端 conversation 中，无需重复发送
+func newConversationItems(contents []*genai.Content) []*genai.Content {
+	for i := len(contents) - 1; i >= 0; i-- {
+		if contents[i] != nil && contents[i].Role == genai.RoleModel {
+			return contents[i+1:]
+		}
+	}
+	return contents
+}
+
+// previousResponseKeyPrefix 上一次响应ID在映射存储中的 key 前缀，与 conversation 映射共用存储
+const previousResponseKeyPrefix = "prev:"
+
+// previousResponseID 返回当前会话记录的上一次响应ID，未启用或无记录时返回空字符串
+func (r *ResponsesModel) previousResponseID(ctx context.Context) string {
+	if !r.UsePreviousResponseID {
+		return ""
+	}
+	store, key := conversationFromContext(ctx)
+	if store == nil {
+		return ""
+	}
+	return store.GetConversation(previousResponseKeyPrefix + key)
+}
+
+// rememberResponse 记录本次响应ID，供下一次请求通过 previous_response_id 关联；传空字符串清除记录
+func (r *ResponsesModel) rememberResponse(ctx context.Context, responseID string) {
+	if !r.UsePreviousResponseID {
+		return
+	}
+	store, key := conversationFromContext(ctx)
+	if store == nil {
+		return
+	}
+	if err := store.SetConversation(previousResponseKeyPrefix+key, responseID); err != nil {
+		respLog.Warn("保存上一次响应ID失败: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+type mapConversationStore map[string]string
+
+func (m mapConversationStore) GetConversation(key string) string { return m[key] }
+
+func (m mapConversationStore) SetConversation(key, id string) error {
+	m[key] = id
+	return nil
+}
+
+func TestResponsesModel_Conversation(t *testing.T) {
+	var created int
+	var sent []CreateResponseRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/conversations":
+			created++
+			w.Write([]byte(`{"id":"conv_1"}`))
+		case "/responses":
+			var req CreateResponseRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			sent = append(sent, req)
+			w.Write([]byte(`{"id":"resp_1","status":"completed","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"ok"}]}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	m := NewResponsesModel("gpt", "k", srv.URL, srv.Client(), false)
+	m.UseConversations = true
+	store := mapConversationStore{}
+	ctx := WithConversationKey(WithConversationStore(context.Background(), store), "agent@cfg")
+
+	req := &model.LLMRequest{Contents: []*genai.Content{
+		genai.NewContentFromText("旧问题", genai.RoleUser),
+		genai.NewContentFromText("旧回答", genai.RoleModel),
+		genai.NewContentFromText("新问题", genai.RoleUser),
+	}}
+	for range 2 {
+		for _, err := range m.GenerateContent(ctx, req, false) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if created != 1 || store["agent@cfg"] != "conv_1" {
+		t.Fatalf("created=%d store=%v", created, store)
+	}
+	for _, req := range sent {
+		items, _ := req.Input.([]any)
+		if req.Conversation != "conv_1" || len(items) != 1 {
+			t.Fatalf("request = %+v, want conversation with only new input", req)
+		}
+	}
+
+	// 未提供映射存储时发送完整历史
+	sent = nil
+	for range m.GenerateContent(context.Background(), req, false) {
+	}
+	if items, _ := sent[0].Input.([]any); sent[0].Conversation != "" || len(items) != 3 {
+		t.Fatalf("request without store = %+v", sent[0])
+	}
+}
+
+func TestResponsesModel_PreviousResponseID(t *testing.T) {
+	var sent []CreateResponseRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req CreateResponseRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req)
+		if req.PreviousResponseID == "resp_expired" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":"previous_response_not_found","message":"not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"resp_` + string(rune('0'+len(sent))) + `","status":"completed","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"ok"}]}]}`))
+	}))
+	defer srv.Close()
+
+	m := NewResponsesModel("gpt", "k", srv.URL, srv.Client(), false)
+	m.UsePreviousResponseID = true
+	store := mapConversationStore{}
+	ctx := WithConversationKey(WithConversationStore(context.Background(), store), "agent@cfg")
+
+	req := &model.LLMRequest{Contents: []*genai.Content{
+		genai.NewContentFromText("旧问题", genai.RoleUser),
+		genai.NewContentFromText("旧回答", genai.RoleModel),
+		genai.NewContentFromText("新问题", genai.RoleUser),
+	}}
+	run := func() {
+		for _, err := range m.GenerateContent(ctx, req, false) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// 首次请求无记录，发送完整历史
+	run()
+	if items, _ := sent[0].Input.([]any); sent[0].PreviousResponseID != "" || len(items) != 3 {
+		t.Fatalf("first request = %+v", sent[0])
+	}
+	if store["prev:agent@cfg"] != "resp_1" {
+		t.Fatalf("store = %v", store)
+	}
+
+	// 第二次只发送新增内容并关联上一次响应
+	run()
+	if items, _ := sent[1].Input.([]any); sent[1].PreviousResponseID != "resp_1" || len(items) != 1 {
+		t.Fatalf("second request = %+v", sent[1])
+	}
+
+	// 响应已失效时回退为完整历史
+	store["prev:agent@cfg"] = "resp_expired"
+	sent = sent[:2]
+	run()
+	if len(sent) != 4 || sent[3].PreviousResponseID != "" {
+		t.Fatalf("fallback requests = %+v", sent[2:])
+	}
+	if items, _ := sent[3].Input.([]any); len(items) != 3 || store["prev:agent@cfg"] != "resp_4" {
+		t.Fatalf("fallback request = %+v store = %v", sent[3], store)
+	}
+}
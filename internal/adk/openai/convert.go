@@ -10,6 +10,9 @@ import (
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
 
+	"github.com/run-bigpig/jcp/internal/adk/media"
+	"github.com/run-bigpig/jcp/internal/adk/schemasanitize"
+	"github.com/run-bigpig/jcp/internal/adk/structured"
 	"github.com/run-bigpig/jcp/internal/logger"
 )
 
@@ -180,6 +183,7 @@ func toOpenAIChatCompletionRequest(req *model.LLMRequest, modelName string, noSy
 		}
 		openaiMessages = append(openaiMessages, msgs...)
 	}
+	dropStaleReasoning(openaiMessages)
 
 	openaiReq := openai.ChatCompletionRequest{
 		Model:    modelName,
@@ -205,6 +209,7 @@ func toOpenAIChatCompletionRequest(req *model.LLMRequest, modelName string, noSy
 			return openai.ChatCompletionRequest{}, err
 		}
 		openaiReq.Tools = tools
+		applyChatToolChoice(&openaiReq, req.Config)
 	}
 
 	// 应用配置
@@ -218,6 +223,16 @@ func toOpenAIChatCompletionRequest(req *model.LLMRequest, modelName string, noSy
 		if req.Config.TopP != nil {
 			openaiReq.TopP = *req.Config.TopP
 		}
+		if req.Config.FrequencyPenalty != nil {
+			openaiReq.FrequencyPenalty = *req.Config.FrequencyPenalty
+		}
+		if req.Config.PresencePenalty != nil {
+			openaiReq.PresencePenalty = *req.Config.PresencePenalty
+		}
+		if req.Config.Seed != nil {
+			seed := int(*req.Config.Seed)
+			openaiReq.Seed = &seed
+		}
 		if len(req.Config.StopSequences) > 0 {
 			openaiReq.Stop = req.Config.StopSequences
 		}
@@ -253,8 +268,20 @@ func toOpenAIChatCompletionRequest(req *model.LLMRequest, modelName string, noSy
 			openaiReq.Messages = openaiMessages
 		}
 
-		// 处理 JSON 模式
-		if req.Config.ResponseMIMEType == "application/json" {
+		// 处理 JSON 模式：提供 schema 时使用 json_schema，否则使用 json_object
+		if schema := structured.Schema(req.Config); schema != nil {
+			schemaJSON, err := json.Marshal(schemasanitize.Sanitize(schema, schemasanitize.OpenAI))
+			if err != nil {
+				return openaiReq, fmt.Errorf("marshal response schema: %w", err)
+			}
+			openaiReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+				JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+					Name:   structured.SchemaName(schema),
+					Schema: json.RawMessage(schemaJSON),
+				},
+			}
+		} else if structured.JSONRequested(req.Config) {
 			openaiReq.ResponseFormat = &openai.ChatCompletionResponseFormat{
 				Type: openai.ChatCompletionResponseFormatTypeJSONObject,
 			}
@@ -264,6 +291,23 @@ func toOpenAIChatCompletionRequest(req *model.LLMRequest, modelName string, noSy
 	return openaiReq, nil
 }
 
+// dropStaleReasoning 清除最后一条 user 消息之前的 assistant reasoning_content
+// DeepSeek 要求只在当前轮的工具调用循环中回传思维链，携带历史轮次的思维链会返回 400，
+// 其他 thinking 模型同样不需要历史思维链，去掉可节省输入 token
+func dropStaleReasoning(msgs []openai.ChatCompletionMessage) {
+	lastUser := -1
+	for i, msg := range msgs {
+		if msg.Role == openai.ChatMessageRoleUser {
+			lastUser = i
+		}
+	}
+	for i := 0; i < lastUser; i++ {
+		if msgs[i].Role == openai.ChatMessageRoleAssistant {
+			msgs[i].ReasoningContent = ""
+		}
+	}
+}
+
 // toOpenAIChatCompletionMessage 将 genai.Content 转换为 OpenAI 消息
 // 关键：处理 thinking 模型的 reasoning_content
 func toOpenAIChatCompletionMessage(content *genai.Content) ([]openai.ChatCompletionMessage, error) {
@@ -300,6 +344,8 @@ func toOpenAIChatCompletionMessage(content *genai.Content) ([]openai.ChatComplet
 	var textContent string
 	var reasoningContent string
 	var toolCalls []openai.ToolCall
+	var multiContent []openai.ChatMessagePart
+	hasImage := false
 
 	for _, part := range parts {
 		// 处理 thinking/reasoning 内容
@@ -311,6 +357,20 @@ func toOpenAIChatCompletionMessage(content *genai.Content) ([]openai.ChatComplet
 		// 处理普通文本
 		if part.Text != "" {
 			textContent += part.Text
+			multiContent = append(multiContent, openai.ChatMessagePart{Type: openai.ChatMessagePartTypeText, Text: part.Text})
+		}
+
+		// 处理图片（内联数据转为 data URL）
+		img, err := media.ImageFromPart(part)
+		if err != nil {
+			return nil, err
+		}
+		if img != nil {
+			hasImage = true
+			multiContent = append(multiContent, openai.ChatMessagePart{
+				Type:     openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{URL: img.DataURL(), Detail: openai.ImageURLDetailAuto},
+			})
 		}
 
 		// 处理函数调用
@@ -331,8 +391,10 @@ func toOpenAIChatCompletionMessage(content *genai.Content) ([]openai.ChatComplet
 		}
 	}
 
-	// 设置消息内容
-	if textContent != "" {
+	// 设置消息内容（含图片时使用多模态内容数组，与 Content 互斥）
+	if hasImage {
+		openaiMsg.MultiContent = multiContent
+	} else if textContent != "" {
 		openaiMsg.Content = textContent
 	}
 
@@ -398,10 +460,13 @@ func convertTools(genaiTools []*genai.Tool) ([]openai.Tool, error) {
 				Function: &openai.FunctionDefinition{
 					Name:        funcDecl.Name,
 					Description: funcDecl.Description,
-					Parameters:  funcDecl.ParametersJsonSchema,
 				},
 			}
-			if openaiTool.Function.Parameters == nil {
+			// MCP 透传的 JSON Schema 需清洗 $ref、oneOf 等关键字；genai.Schema 为受限子集，无需处理
+			if params := schemasanitize.Sanitize(funcDecl.ParametersJsonSchema, schemasanitize.OpenAI); params != nil {
+				openaiTool.Function.Parameters = params
+			}
+			if openaiTool.Function.Parameters == nil && funcDecl.Parameters != nil {
 				openaiTool.Function.Parameters = funcDecl.Parameters
 			}
 			if openaiTool.Function.Parameters == nil {
@@ -472,11 +537,7 @@ func convertChatCompletionResponse(resp *openai.ChatCompletionResponse) (*model.
 	// 处理 usage
 	var usageMetadata *genai.GenerateContentResponseUsageMetadata
 	if resp.Usage.TotalTokens > 0 {
-		usageMetadata = &genai.GenerateContentResponseUsageMetadata{
-			PromptTokenCount:     int32(resp.Usage.PromptTokens),
-			CandidatesTokenCount: int32(resp.Usage.CompletionTokens),
-			TotalTokenCount:      int32(resp.Usage.TotalTokens),
-		}
+		usageMetadata = convertUsage(&resp.Usage)
 	}
 
 	return &model.LLMResponse{
@@ -515,3 +576,19 @@ func parseJSONArgs(argsJSON string) map[string]any {
 	}
 	return args
 }
+
+// convertUsage 转换 Chat Completions 用量，缓存命中与推理 token 分别写入对应字段
+func convertUsage(u *openai.Usage) *genai.GenerateContentResponseUsageMetadata {
+	usage := &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     int32(u.PromptTokens),
+		CandidatesTokenCount: int32(u.CompletionTokens),
+		TotalTokenCount:      int32(u.TotalTokens),
+	}
+	if d := u.PromptTokensDetails; d != nil {
+		usage.CachedContentTokenCount = int32(d.CachedTokens)
+	}
+	if d := u.CompletionTokensDetails; d != nil {
+		usage.ThoughtsTokenCount = int32(d.ReasoningTokens)
+	}
+	return usage
+}
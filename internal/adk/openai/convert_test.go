@@ -0,0 +1,217 @@
+package openai
+
+import (
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestConvertChatCompletionResponse_ReasoningContent(t *testing.T) {
+	resp := &openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{
+			Message: openai.ChatCompletionMessage{
+				Role:             openai.ChatMessageRoleAssistant,
+				ReasoningContent: "先看估值",
+				Content:          "结论：低估",
+			},
+			FinishReason: openai.FinishReasonStop,
+		}},
+	}
+
+	llmResp, err := convertChatCompletionResponse(resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parts := llmResp.Content.Parts
+	if len(parts) != 2 {
+		t.Fatalf("parts = %d, want 2", len(parts))
+	}
+	if !parts[0].Thought || parts[0].Text != "先看估值" {
+		t.Errorf("thought part unexpected: %+v", parts[0])
+	}
+	if parts[1].Thought || parts[1].Text != "结论：低估" {
+		t.Errorf("text part unexpected: %+v", parts[1])
+	}
+}
+
+func TestToOpenAIChatCompletionRequest_DropsStaleReasoning(t *testing.T) {
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			{Role: "user", Parts: []*genai.Part{{Text: "第一问"}}},
+			{Role: "model", Parts: []*genai.Part{{Text: "旧思考", Thought: true}, {Text: "旧回答"}}},
+			{Role: "user", Parts: []*genai.Part{{Text: "第二问"}}},
+			{Role: "model", Parts: []*genai.Part{
+				{Text: "新思考", Thought: true},
+				{FunctionCall: &genai.FunctionCall{ID: "c1", Name: "get_news", Args: map[string]any{}}},
+			}},
+			{Role: "user", Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{ID: "c1", Name: "get_news", Response: map[string]any{"ok": true}}}}},
+		},
+	}
+
+	openaiReq, err := toOpenAIChatCompletionRequest(req, "deepseek-reasoner", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	msgs := openaiReq.Messages
+	if len(msgs) != 5 {
+		t.Fatalf("messages = %d, want 5", len(msgs))
+	}
+	if msgs[1].ReasoningContent != "" {
+		t.Errorf("历史轮次的 reasoning_content 应被清除: %q", msgs[1].ReasoningContent)
+	}
+	if msgs[3].ReasoningContent != "新思考" {
+		t.Errorf("当前轮的 reasoning_content 应保留: %q", msgs[3].ReasoningContent)
+	}
+}
+
+func TestToOpenAIChatCompletionRequest_ResponseSchema(t *testing.T) {
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			ResponseMIMEType: "application/json",
+			ResponseSchema:   &genai.Schema{Type: genai.TypeObject, Title: "verdict"},
+		},
+	}
+	got, err := toOpenAIChatCompletionRequest(req, "gpt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf := got.ResponseFormat
+	if rf == nil || rf.Type != openai.ChatCompletionResponseFormatTypeJSONSchema || rf.JSONSchema.Name != "verdict" {
+		t.Fatalf("response_format = %+v", rf)
+	}
+
+	apiReq, err := toResponsesRequest(req, "gpt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiReq.Text == nil || apiReq.Text.Format.Type != "json_schema" || apiReq.Text.Format.Schema["type"] != "object" {
+		t.Fatalf("text = %+v", apiReq.Text)
+	}
+}
+
+func TestToolChoice_ChatAndResponses(t *testing.T) {
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+				{Name: "get_kline", Parameters: &genai.Schema{Type: genai.TypeObject}},
+				{Name: "get_news", Parameters: &genai.Schema{Type: genai.TypeObject}},
+			}}},
+			ToolConfig: &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{
+				Mode: genai.FunctionCallingConfigModeAny, AllowedFunctionNames: []string{"get_news"},
+			}},
+		},
+	}
+	chat, err := toOpenAIChatCompletionRequest(req, "gpt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc, ok := chat.ToolChoice.(openai.ToolChoice); !ok || tc.Function.Name != "get_news" || len(chat.Tools) != 1 {
+		t.Fatalf("chat tool_choice = %#v, tools = %d", chat.ToolChoice, len(chat.Tools))
+	}
+
+	resp, err := toResponsesRequest(req, "gpt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc, ok := resp.ToolChoice.(map[string]string); !ok || tc["name"] != "get_news" {
+		t.Fatalf("responses tool_choice = %#v", resp.ToolChoice)
+	}
+
+	req.Config.ToolConfig.FunctionCallingConfig = &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeNone}
+	chat, _ = toOpenAIChatCompletionRequest(req, "gpt", false)
+	if chat.ToolChoice != "none" || len(chat.Tools) != 2 {
+		t.Fatalf("none: tool_choice = %#v, tools = %d", chat.ToolChoice, len(chat.Tools))
+	}
+}
+
+func TestImageInput_ChatAndResponses(t *testing.T) {
+	content := &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+		{Text: "看看这张K线图"},
+		genai.NewPartFromBytes([]byte("\x89PNG\r\n\x1a\n"), "image/png"),
+	}}
+
+	msgs, err := toOpenAIChatCompletionMessage(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0].Content != "" || len(msgs[0].MultiContent) != 2 {
+		t.Fatalf("chat message = %#v", msgs)
+	}
+	if url := msgs[0].MultiContent[1].ImageURL.URL; url != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Errorf("image url = %q", url)
+	}
+
+	items, err := toResponsesInputItem(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, ok := items[0].Content.([]ResponsesInputContent)
+	if !ok || len(parts) != 2 || parts[1].Type != "input_image" || parts[1].ImageURL == "" {
+		t.Fatalf("responses content = %#v", items[0].Content)
+	}
+
+	// 非 http(s) 的文件引用无法被 OpenAI 访问，应报错而不是静默丢弃
+	content.Parts = []*genai.Part{genai.NewPartFromURI("gs://bucket/chart.png", "image/png")}
+	if _, err := toOpenAIChatCompletionMessage(content); err == nil {
+		t.Error("expected error for gs:// image")
+	}
+}
+
+func TestSamplingParams_Chat(t *testing.T) {
+	topP, freq, pres, seed := float32(0.9), float32(0.5), float32(-0.2), int32(42)
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			TopP: &topP, FrequencyPenalty: &freq, PresencePenalty: &pres, Seed: &seed,
+		},
+	}
+	m := &OpenAIModel{ModelName: "gpt", LogitBias: map[string]int{"1234": -100}}
+	chat, err := m.chatRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chat.TopP != 0.9 || chat.FrequencyPenalty != 0.5 || chat.PresencePenalty != -0.2 {
+		t.Fatalf("unexpected sampling params: %+v", chat)
+	}
+	if chat.Seed == nil || *chat.Seed != 42 || chat.LogitBias["1234"] != -100 {
+		t.Fatalf("unexpected seed/logit_bias: %v %v", chat.Seed, chat.LogitBias)
+	}
+}
+
+func TestResponsesRequest_InstructionHierarchy(t *testing.T) {
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			{Role: "developer", Parts: []*genai.Part{{Text: "只输出结论"}}},
+			genai.NewContentFromText("分析一下", genai.RoleUser),
+		},
+		Config: &genai.GenerateContentConfig{SystemInstruction: &genai.Content{Parts: []*genai.Part{
+			{Text: "不得提供确定性收益承诺"},
+			{Text: "当前股票：600519"},
+		}}},
+	}
+
+	apiReq, err := toResponsesRequest(req, "gpt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiReq.Instructions != "不得提供确定性收益承诺" {
+		t.Errorf("instructions = %q", apiReq.Instructions)
+	}
+	input := apiReq.Input.([]ResponsesInputItem)
+	if len(input) != 3 || input[0].Role != "developer" || input[0].Content != "当前股票：600519" || input[1].Role != "developer" || input[2].Role != "user" {
+		t.Fatalf("input = %+v", input)
+	}
+
+	flat, err := toResponsesRequest(req, "gpt", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flatInput := flat.Input.([]ResponsesInputItem)
+	if flat.Instructions != "" || flatInput[1].Content != "不得提供确定性收益承诺\n当前股票：600519\n\n分析一下" {
+		t.Fatalf("noSystemRole input = %+v", flatInput)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
 
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
 	"github.com/run-bigpig/jcp/internal/logger"
 )
 
@@ -28,6 +29,10 @@ type OpenAIModel struct {
 	Client       *openai.Client
 	ModelName    string
 	NoSystemRole bool // 不支持 system role 时需要降级处理
+	// LogitBias token ID -> 偏置（-100~100），genai 配置没有对应字段，随模型配置下发
+	LogitBias map[string]int
+
+	openRouter bool // 解析 OpenRouter 扩展的 usage/cost 字段
 }
 
 // NewOpenAIModel 创建 OpenAI 模型
@@ -53,15 +58,31 @@ func (o *OpenAIModel) GenerateContent(ctx context.Context, req *model.LLMRequest
 	return o.generate(ctx, req)
 }
 
+// chatRequest 转换为 Chat Completions 请求并附加模型级参数
+func (o *OpenAIModel) chatRequest(req *model.LLMRequest) (openai.ChatCompletionRequest, error) {
+	openaiReq, err := toOpenAIChatCompletionRequest(req, o.ModelName, o.NoSystemRole)
+	if err != nil {
+		return openaiReq, err
+	}
+	if len(o.LogitBias) > 0 {
+		openaiReq.LogitBias = o.LogitBias
+	}
+	return openaiReq, nil
+}
+
 // generate 非流式生成
 func (o *OpenAIModel) generate(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
-		openaiReq, err := toOpenAIChatCompletionRequest(req, o.ModelName, o.NoSystemRole)
+		openaiReq, err := o.chatRequest(req)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
 
+		var extras *openRouterExtras
+		if o.openRouter {
+			ctx, extras = withOpenRouterExtras(ctx)
+		}
 		resp, err := o.Client.CreateChatCompletion(ctx, openaiReq)
 		if err != nil {
 			yield(nil, err)
@@ -73,6 +94,14 @@ func (o *OpenAIModel) generate(ctx context.Context, req *model.LLMRequest) iter.
 			yield(nil, err)
 			return
 		}
+		llmResp.CustomMetadata = respmeta.Meta{
+			ResponseID:   resp.ID,
+			RequestID:    respmeta.RequestIDFromHeader(resp.Header()),
+			ModelVersion: resp.Model,
+		}.Merge(llmResp.CustomMetadata)
+		if extras != nil {
+			extras.apply(llmResp)
+		}
 
 		yield(llmResp, nil)
 	}
@@ -81,13 +110,17 @@ func (o *OpenAIModel) generate(ctx context.Context, req *model.LLMRequest) iter.
 // generateStream 流式生成
 func (o *OpenAIModel) generateStream(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
-		openaiReq, err := toOpenAIChatCompletionRequest(req, o.ModelName, o.NoSystemRole)
+		openaiReq, err := o.chatRequest(req)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
 		openaiReq.Stream = true
 
+		var extras *openRouterExtras
+		if o.openRouter {
+			ctx, extras = withOpenRouterExtras(ctx)
+		}
 		stream, err := o.Client.CreateChatCompletionStream(ctx, openaiReq)
 		if err != nil {
 			yield(nil, err)
@@ -95,18 +128,20 @@ func (o *OpenAIModel) generateStream(ctx context.Context, req *model.LLMRequest)
 		}
 		defer stream.Close()
 
-		o.processStream(stream, yield)
+		o.processStream(stream, extras, yield)
 	}
 }
 
 // processStream 处理流式响应
-func (o *OpenAIModel) processStream(stream *openai.ChatCompletionStream, yield func(*model.LLMResponse, error) bool) {
+// extras 非空时（OpenRouter）在最终响应中补充扩展用量信息
+func (o *OpenAIModel) processStream(stream *openai.ChatCompletionStream, extras *openRouterExtras, yield func(*model.LLMResponse, error) bool) {
 	aggregatedContent := &genai.Content{
 		Role:  "model",
 		Parts: []*genai.Part{},
 	}
 	var finishReason genai.FinishReason
 	var usageMetadata *genai.GenerateContentResponseUsageMetadata
+	meta := respmeta.Meta{RequestID: respmeta.RequestIDFromHeader(stream.Header())}
 	toolCallsMap := make(map[int]*toolCallBuilder)
 	var textContent string
 	var thoughtContent string
@@ -145,6 +180,13 @@ func (o *OpenAIModel) processStream(stream *openai.ChatCompletionStream, yield f
 			break
 		}
 
+		if meta.ResponseID == "" {
+			meta.ResponseID = chunk.ID
+		}
+		if meta.ModelVersion == "" {
+			meta.ModelVersion = chunk.Model
+		}
+
 		if len(chunk.Choices) == 0 {
 			continue
 		}
@@ -194,11 +236,7 @@ func (o *OpenAIModel) processStream(stream *openai.ChatCompletionStream, yield f
 		}
 
 		if chunk.Usage != nil {
-			usageMetadata = &genai.GenerateContentResponseUsageMetadata{
-				PromptTokenCount:     int32(chunk.Usage.PromptTokens),
-				CandidatesTokenCount: int32(chunk.Usage.CompletionTokens),
-				TotalTokenCount:      int32(chunk.Usage.TotalTokens),
-			}
+			usageMetadata = convertUsage(chunk.Usage)
 		}
 	}
 
@@ -247,16 +285,20 @@ func (o *OpenAIModel) processStream(stream *openai.ChatCompletionStream, yield f
 	}
 
 	if streamErr != nil {
-		yield(nil, streamErr)
+		yield(nil, &respmeta.StreamError{Err: streamErr, Meta: meta})
 		return
 	}
 
 	finalResp := &model.LLMResponse{
-		Content:       aggregatedContent,
-		UsageMetadata: usageMetadata,
-		FinishReason:  finishReason,
-		Partial:       false,
-		TurnComplete:  true,
+		Content:        aggregatedContent,
+		UsageMetadata:  usageMetadata,
+		FinishReason:   finishReason,
+		CustomMetadata: meta.Map(),
+		Partial:        false,
+		TurnComplete:   true,
+	}
+	if extras != nil {
+		extras.apply(finalResp)
 	}
 	yield(finalResp, nil)
 }
@@ -0,0 +1,239 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// OpenRouter 扩展元数据键
+const (
+	MetaKeyOpenRouterCost     = "openrouter_cost"     // 本次请求花费（credits，约等于美元）
+	MetaKeyOpenRouterProvider = "openrouter_provider" // 实际承接请求的上游供应商
+)
+
+// OpenRouterOptions OpenRouter 专用选项
+type OpenRouterOptions struct {
+	Referer        string   // HTTP-Referer，用于 OpenRouter 应用归属统计
+	Title          string   // X-Title
+	ProviderOrder  []string // 上游供应商优先顺序
+	AllowFallbacks *bool    // 首选供应商不可用时是否允许回退，nil 使用 OpenRouter 默认
+}
+
+// NewOpenRouterModel 创建 OpenRouter 模型
+// 复用 Chat Completions 实现，请求时注入路由偏好并解析扩展的 usage/cost 字段
+func NewOpenRouterModel(modelName, apiKey, baseURL string, httpClient *http.Client, opts OpenRouterOptions, noSystemRole bool) *OpenAIModel {
+	cfg := openai.DefaultConfig(apiKey)
+	cfg.BaseURL = baseURL
+	cfg.HTTPClient = &openRouterDoer{client: httpClient, opts: opts}
+	m := NewOpenAIModel(modelName, cfg, noSystemRole)
+	m.openRouter = true
+	return m
+}
+
+// openRouterUsage OpenRouter 扩展的用量字段
+type openRouterUsage struct {
+	Cost                float64 `json:"cost"`
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails *struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+// openRouterExtras 从原始响应体中收集的扩展信息
+type openRouterExtras struct {
+	mu       sync.Mutex
+	usage    *openRouterUsage
+	provider string
+}
+
+type openRouterExtrasKey struct{}
+
+// withOpenRouterExtras 在 context 中挂载收集器，由 openRouterDoer 填充
+func withOpenRouterExtras(ctx context.Context) (context.Context, *openRouterExtras) {
+	extras := &openRouterExtras{}
+	return context.WithValue(ctx, openRouterExtrasKey{}, extras), extras
+}
+
+// parse 解析一段 JSON（完整响应或单个 SSE chunk）
+func (e *openRouterExtras) parse(data []byte) {
+	var payload struct {
+		Provider string           `json:"provider"`
+		Usage    *openRouterUsage `json:"usage"`
+	}
+	if json.Unmarshal(data, &payload) != nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if payload.Provider != "" {
+		e.provider = payload.Provider
+	}
+	if payload.Usage != nil {
+		e.usage = payload.Usage
+	}
+}
+
+// apply 将扩展信息写入 LLMResponse
+func (e *openRouterExtras) apply(resp *model.LLMResponse) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.usage != nil {
+		if resp.UsageMetadata == nil {
+			resp.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{}
+		}
+		if d := e.usage.PromptTokensDetails; d != nil {
+			resp.UsageMetadata.CachedContentTokenCount = int32(d.CachedTokens)
+		}
+		if d := e.usage.CompletionTokensDetails; d != nil {
+			resp.UsageMetadata.ThoughtsTokenCount = int32(d.ReasoningTokens)
+		}
+	}
+	if e.usage == nil && e.provider == "" {
+		return
+	}
+	if resp.CustomMetadata == nil {
+		resp.CustomMetadata = make(map[string]any)
+	}
+	if e.usage != nil {
+		resp.CustomMetadata[MetaKeyOpenRouterCost] = e.usage.Cost
+	}
+	if e.provider != "" {
+		resp.CustomMetadata[MetaKeyOpenRouterProvider] = e.provider
+	}
+}
+
+// openRouterDoer 实现 go-openai 的 HTTPDoer
+// 注入归属请求头和 provider 路由参数，并旁路解析响应中的扩展字段
+type openRouterDoer struct {
+	client *http.Client
+	opts   OpenRouterOptions
+}
+
+func (d *openRouterDoer) Do(req *http.Request) (*http.Response, error) {
+	if d.opts.Referer != "" {
+		req.Header.Set("HTTP-Referer", d.opts.Referer)
+	}
+	if d.opts.Title != "" {
+		req.Header.Set("X-Title", d.opts.Title)
+	}
+	if req.Method == http.MethodPost && req.Body != nil {
+		if err := d.rewriteBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	if extras, ok := req.Context().Value(openRouterExtrasKey{}).(*openRouterExtras); ok {
+		resp.Body = &openRouterCaptureBody{
+			ReadCloser: resp.Body,
+			extras:     extras,
+			sse:        strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"),
+		}
+	}
+	return resp, nil
+}
+
+// rewriteBody 在请求体中追加 provider 路由偏好和 usage.include
+func (d *openRouterDoer) rewriteBody(req *http.Request) error {
+	raw, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	var body map[string]any
+	if json.Unmarshal(raw, &body) == nil {
+		provider := map[string]any{}
+		if len(d.opts.ProviderOrder) > 0 {
+			provider["order"] = d.opts.ProviderOrder
+		}
+		if d.opts.AllowFallbacks != nil {
+			provider["allow_fallbacks"] = *d.opts.AllowFallbacks
+		}
+		if len(provider) > 0 {
+			body["provider"] = provider
+		}
+		body["usage"] = map[string]any{"include": true}
+		if rewritten, err := json.Marshal(body); err == nil {
+			raw = rewritten
+		}
+	}
+	req.Body = io.NopCloser(bytes.NewReader(raw))
+	req.ContentLength = int64(len(raw))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(raw)), nil
+	}
+	return nil
+}
+
+// openRouterCaptureBody 透传响应体，同时按行（SSE）或整体（JSON）解析扩展字段
+type openRouterCaptureBody struct {
+	io.ReadCloser
+	extras *openRouterExtras
+	sse    bool
+	buf    []byte
+	done   bool
+}
+
+func (b *openRouterCaptureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.buf = append(b.buf, p[:n]...)
+		if b.sse {
+			b.scanLines()
+		}
+	}
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *openRouterCaptureBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+// scanLines 处理已完整接收的 SSE 行，只解析带 usage/provider 的数据行
+func (b *openRouterCaptureBody) scanLines() {
+	for {
+		idx := bytes.IndexByte(b.buf, '\n')
+		if idx < 0 {
+			return
+		}
+		line := bytes.TrimSpace(b.buf[:idx])
+		b.buf = b.buf[idx+1:]
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			continue
+		}
+		if bytes.Contains(data, []byte(`"usage"`)) || bytes.Contains(data, []byte(`"provider"`)) {
+			b.extras.parse(bytes.TrimSpace(data))
+		}
+	}
+}
+
+func (b *openRouterCaptureBody) finish() {
+	if b.done {
+		return
+	}
+	b.done = true
+	if !b.sse {
+		b.extras.parse(bytes.TrimSpace(b.buf))
+	}
+	b.buf = nil
+}
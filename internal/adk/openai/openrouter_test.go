@@ -0,0 +1,148 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestOpenRouterModel_HeadersRoutingAndUsage(t *testing.T) {
+	const usage = `"usage":{"prompt_tokens":12,"completion_tokens":8,"total_tokens":20,"cost":0.0042,"prompt_tokens_details":{"cached_tokens":5},"completion_tokens_details":{"reasoning_tokens":3}}`
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("HTTP-Referer"); got != "https://jcp.example" {
+			t.Errorf("HTTP-Referer = %q", got)
+		}
+		if got := r.Header.Get("X-Title"); got != "JCP" {
+			t.Errorf("X-Title = %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer or-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		raw, _ := io.ReadAll(r.Body)
+		if int64(len(raw)) != r.ContentLength {
+			t.Errorf("content length = %d, body = %d bytes", r.ContentLength, len(raw))
+		}
+		var body map[string]any
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, body)
+
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"id\":\"gen-2\",\"model\":\"m\",\"provider\":\"DeepInfra\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"流式\"}}]}\n\n")
+			fmt.Fprintf(w, "data: {\"id\":\"gen-2\",\"model\":\"m\",\"provider\":\"DeepInfra\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}],%s}\n\n", usage)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"gen-1","model":"m","provider":"Together","choices":[{"index":0,"message":{"role":"assistant","content":"你好"},"finish_reason":"stop"}],%s}`, usage)
+	}))
+	defer srv.Close()
+
+	allow := false
+	m := NewOpenRouterModel("m", "or-key", srv.URL, srv.Client(), OpenRouterOptions{
+		Referer:        "https://jcp.example",
+		Title:          "JCP",
+		ProviderOrder:  []string{"Together", "DeepInfra"},
+		AllowFallbacks: &allow,
+	}, false)
+	req := &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}
+
+	// 非流式：整体解析响应中的 provider 与扩展 usage
+	var resp *model.LLMResponse
+	for r, err := range m.GenerateContent(context.Background(), req, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp = r
+	}
+	checkOpenRouterResponse(t, resp, "Together")
+
+	// 流式：逐行解析，最终响应带上扩展用量
+	var last *model.LLMResponse
+	var text strings.Builder
+	for r, err := range m.GenerateContent(context.Background(), req, true) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Content != nil && r.Partial {
+			for _, p := range r.Content.Parts {
+				text.WriteString(p.Text)
+			}
+		}
+		last = r
+	}
+	if text.String() != "流式" {
+		t.Errorf("stream text = %q", text.String())
+	}
+	checkOpenRouterResponse(t, last, "DeepInfra")
+
+	if len(bodies) != 2 {
+		t.Fatalf("requests = %d", len(bodies))
+	}
+	for _, body := range bodies {
+		provider, _ := body["provider"].(map[string]any)
+		order, _ := provider["order"].([]any)
+		if len(order) != 2 || order[0] != "Together" || provider["allow_fallbacks"] != false {
+			t.Errorf("provider routing = %v", body["provider"])
+		}
+		if u, _ := body["usage"].(map[string]any); u["include"] != true {
+			t.Errorf("usage.include = %v", body["usage"])
+		}
+	}
+}
+
+func checkOpenRouterResponse(t *testing.T, resp *model.LLMResponse, provider string) {
+	t.Helper()
+	if resp == nil {
+		t.Fatal("no response")
+	}
+	if got := resp.CustomMetadata[MetaKeyOpenRouterProvider]; got != provider {
+		t.Errorf("provider = %v, want %s", got, provider)
+	}
+	if got := resp.CustomMetadata[MetaKeyOpenRouterCost]; got != 0.0042 {
+		t.Errorf("cost = %v", got)
+	}
+	if u := resp.UsageMetadata; u == nil || u.CachedContentTokenCount != 5 || u.ThoughtsTokenCount != 3 {
+		t.Errorf("usage = %+v", resp.UsageMetadata)
+	}
+}
+
+func TestOpenRouterModel_NoRoutingPreferences(t *testing.T) {
+	var body map[string]any
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"gen-3","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	m := NewOpenRouterModel("m", "or-key", srv.URL, srv.Client(), OpenRouterOptions{}, false)
+	req := &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)}}
+	for _, err := range m.GenerateContent(context.Background(), req, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := body["provider"]; ok {
+		t.Errorf("provider should be omitted without preferences: %v", body["provider"])
+	}
+	if headers.Get("HTTP-Referer") != "" || headers.Get("X-Title") != "" {
+		t.Errorf("attribution headers should be omitted: %v", headers)
+	}
+}
@@ -3,7 +3,12 @@ package openai
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/run-bigpig/jcp/internal/adk/media"
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+	"github.com/run-bigpig/jcp/internal/adk/schemasanitize"
+	"github.com/run-bigpig/jcp/internal/adk/structured"
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
 )
@@ -28,7 +33,20 @@ func toResponsesRequest(req *model.LLMRequest, modelName string, noSystemRole bo
 	// 处理系统指令
 	if req.Config.SystemInstruction != nil {
 		systemText := extractTextFromContent(req.Config.SystemInstruction)
-		if noSystemRole {
+		if !noSystemRole {
+			// 第一段（全局约束）作为 instructions，其余段落（个股指令、运行时提示等）作为独立的 developer 消息，保留指令层级
+			parts := instructionTexts(req.Config.SystemInstruction)
+			if len(parts) > 0 {
+				apiReq.Instructions = parts[0]
+			}
+			if len(parts) > 1 {
+				developer := make([]ResponsesInputItem, 0, len(parts)-1+len(inputItems))
+				for _, text := range parts[1:] {
+					developer = append(developer, ResponsesInputItem{Role: "developer", Content: text})
+				}
+				apiReq.Input = append(developer, inputItems...)
+			}
+		} else {
 			// 不支持 instructions 字段，将系统指令注入到第一条 user input 前面
 			injected := false
 			for i, item := range inputItems {
@@ -49,8 +67,6 @@ func toResponsesRequest(req *model.LLMRequest, modelName string, noSystemRole bo
 				}}, inputItems...)
 			}
 			apiReq.Input = inputItems
-		} else {
-			apiReq.Instructions = systemText
 		}
 	}
 
@@ -65,12 +81,16 @@ func toResponsesRequest(req *model.LLMRequest, modelName string, noSystemRole bo
 		default:
 			reasoning.Effort = "medium"
 		}
+		if req.Config.ThinkingConfig.IncludeThoughts {
+			reasoning.Summary = "auto"
+		}
 		apiReq.Reasoning = reasoning
 	}
 
 	// 转换工具定义
 	if len(req.Config.Tools) > 0 {
 		apiReq.Tools = convertResponsesTools(req.Config.Tools)
+		applyResponsesToolChoice(&apiReq, req.Config)
 	}
 
 	// 应用生成参数
@@ -85,10 +105,22 @@ func toResponsesRequest(req *model.LLMRequest, modelName string, noSystemRole bo
 		p := float32(*req.Config.TopP)
 		apiReq.TopP = &p
 	}
+	// Responses API 不支持 frequency/presence penalty、seed 与 logit_bias，这些参数只对 Chat Completions 生效
 	if len(req.Config.StopSequences) > 0 {
 		apiReq.Stop = req.Config.StopSequences
 	}
 
+	// 结构化输出：映射为 text.format
+	if schema := structured.Schema(req.Config); schema != nil {
+		apiReq.Text = &ResponsesText{Format: ResponsesTextFormat{
+			Type:   "json_schema",
+			Name:   structured.SchemaName(schema),
+			Schema: schemasanitize.Sanitize(schema, schemasanitize.OpenAI),
+		}}
+	} else if structured.JSONRequested(req.Config) {
+		apiReq.Text = &ResponsesText{Format: ResponsesTextFormat{Type: "json_object"}}
+	}
+
 	return apiReq, nil
 }
 
@@ -126,8 +158,10 @@ func toResponsesInputItem(content *genai.Content) ([]ResponsesInputItem, error)
 		}
 	}
 
-	// 收集文本、reasoning、函数调用
+	// 收集文本、图片、文件、函数调用
 	var textContent string
+	var contentParts []ResponsesInputContent
+	hasMedia := false
 	var toolCallItems []ResponsesInputItem
 
 	for _, part := range content.Parts {
@@ -136,6 +170,23 @@ func toResponsesInputItem(content *genai.Content) ([]ResponsesInputItem, error)
 		}
 		if part.Text != "" && !part.Thought {
 			textContent += part.Text
+			contentParts = append(contentParts, ResponsesInputContent{Type: "input_text", Text: part.Text})
+		}
+		img, err := media.ImageFromPart(part)
+		if err != nil {
+			return nil, err
+		}
+		if img != nil {
+			hasMedia = true
+			contentParts = append(contentParts, ResponsesInputContent{Type: "input_image", ImageURL: img.DataURL(), Detail: "auto"})
+		}
+		doc, err := media.DocumentFromPart(part)
+		if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			hasMedia = true
+			contentParts = append(contentParts, inputFile(doc))
 		}
 		if part.FunctionCall != nil {
 			argsJSON, err := json.Marshal(part.FunctionCall.Args)
@@ -154,7 +205,12 @@ func toResponsesInputItem(content *genai.Content) ([]ResponsesInputItem, error)
 
 	// 构建普通消息
 	role := convertRoleForResponses(content.Role)
-	if textContent != "" {
+	if hasMedia {
+		items = append(items, ResponsesInputItem{
+			Role:    role,
+			Content: contentParts,
+		})
+	} else if textContent != "" {
 		items = append(items, ResponsesInputItem{
 			Role:    role,
 			Content: textContent,
@@ -167,6 +223,14 @@ func toResponsesInputItem(content *genai.Content) ([]ResponsesInputItem, error)
 	return items, nil
 }
 
+// inputFile 构建 input_file 内容：远程地址用 file_url，内联数据用 data URL
+func inputFile(f *media.File) ResponsesInputContent {
+	if f.URL != "" {
+		return ResponsesInputContent{Type: "input_file", FileURL: f.URL}
+	}
+	return ResponsesInputContent{Type: "input_file", Filename: f.Filename(), FileData: f.DataURL()}
+}
+
 // convertRoleForResponses 转换角色为 Responses API 格式
 func convertRoleForResponses(role string) string {
 	switch role {
@@ -176,11 +240,24 @@ func convertRoleForResponses(role string) string {
 		return "assistant"
 	case "system":
 		return "system"
+	case "developer":
+		return "developer"
 	default:
 		return "user"
 	}
 }
 
+// instructionTexts 按 part 拆分系统指令，忽略空白段落
+func instructionTexts(content *genai.Content) []string {
+	var texts []string
+	for _, part := range content.Parts {
+		if part != nil && strings.TrimSpace(part.Text) != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return texts
+}
+
 // convertResponsesTools 转换工具定义为 Responses API 扁平化格式
 func convertResponsesTools(genaiTools []*genai.Tool) []ResponsesTool {
 	var tools []ResponsesTool
@@ -189,8 +266,10 @@ func convertResponsesTools(genaiTools []*genai.Tool) []ResponsesTool {
 			continue
 		}
 		for _, funcDecl := range genaiTool.FunctionDeclarations {
-			params := funcDecl.ParametersJsonSchema
-			if params == nil {
+			var params any
+			if m := schemasanitize.Sanitize(funcDecl.ParametersJsonSchema, schemasanitize.OpenAI); m != nil {
+				params = m
+			} else if funcDecl.Parameters != nil {
 				params = funcDecl.Parameters
 			}
 			tools = append(tools, ResponsesTool{
@@ -206,6 +285,9 @@ func convertResponsesTools(genaiTools []*genai.Tool) []ResponsesTool {
 
 // convertResponsesResponse 将 Responses API 响应转换为 ADK LLMResponse
 func convertResponsesResponse(resp *CreateResponseResponse) (*model.LLMResponse, error) {
+	if resp.Status == "failed" {
+		return nil, fmt.Errorf("Responses API 响应失败: %s", responsesErrorMessage(resp.Error))
+	}
 	if len(resp.Output) == 0 {
 		return nil, ErrNoChoicesInResponse
 	}
@@ -215,12 +297,20 @@ func convertResponsesResponse(resp *CreateResponseResponse) (*model.LLMResponse,
 		Parts: []*genai.Part{},
 	}
 
+	var grounding groundingBuilder
+	toolCalls, refused := false, false
 	for _, item := range resp.Output {
 		switch item.Type {
 		case "message":
 			for _, part := range item.Content {
 				switch part.Type {
+				case "refusal":
+					refused = true
+					if part.Refusal != "" {
+						content.Parts = append(content.Parts, &genai.Part{Text: part.Refusal})
+					}
 				case "output_text":
+					grounding.addCitations(part.Text, part.Annotations, len(content.Parts), 0)
 					// 解析第三方特殊工具调用标记
 					vendorCalls, cleanedText := parseVendorToolCalls(part.Text)
 					for _, seg := range splitThinkTaggedText(cleanedText) {
@@ -237,6 +327,7 @@ func convertResponsesResponse(resp *CreateResponseResponse) (*model.LLMResponse,
 								Args: vc.Args,
 							},
 						})
+						toolCalls = true
 					}
 				case "reasoning":
 					content.Parts = append(content.Parts, &genai.Part{
@@ -245,7 +336,12 @@ func convertResponsesResponse(resp *CreateResponseResponse) (*model.LLMResponse,
 					})
 				}
 			}
+		case "reasoning":
+			if text := reasoningSummaryText(item.Summary); text != "" {
+				content.Parts = append(content.Parts, &genai.Part{Text: text, Thought: true})
+			}
 		case "function_call":
+			toolCalls = true
 			content.Parts = append(content.Parts, &genai.Part{
 				FunctionCall: &genai.FunctionCall{
 					ID:   item.CallID,
@@ -253,23 +349,129 @@ func convertResponsesResponse(resp *CreateResponseResponse) (*model.LLMResponse,
 					Args: parseJSONArgs(item.Arguments),
 				},
 			})
+		case "web_search_call":
+			grounding.addSearchCall(item)
+		case "code_interpreter_call":
+			content.Parts = append(content.Parts, codeInterpreterParts(item)...)
+		}
+	}
+
+	finish, stopReason := responsesFinishReason(resp, toolCalls, refused)
+	llmResp := &model.LLMResponse{
+		Content:           content,
+		UsageMetadata:     convertResponsesUsage(resp.Usage),
+		GroundingMetadata: grounding.metadata(),
+		FinishReason:      finish,
+		CustomMetadata:    respmeta.Meta{StopReason: stopReason}.Map(),
+		TurnComplete:      true,
+	}
+	applyIncomplete(llmResp, resp)
+	return llmResp, nil
+}
+
+// responsesFinishReason 按响应状态与输出内容确定结束原因，返回 genai 结束原因与原始原因（写入响应元数据）
+// resp 为 nil（流式响应缺少完成事件）时按正常结束处理
+func responsesFinishReason(resp *CreateResponseResponse, toolCalls, refused bool) (genai.FinishReason, string) {
+	status := ""
+	if resp != nil {
+		status = resp.Status
+	}
+	switch {
+	case status == "incomplete":
+		reason := ""
+		if resp.IncompleteDetails != nil {
+			reason = resp.IncompleteDetails.Reason
+		}
+		switch reason {
+		case "max_output_tokens":
+			return genai.FinishReasonMaxTokens, reason
+		case "content_filter":
+			return genai.FinishReasonSafety, reason
+		}
+		return genai.FinishReasonOther, reason
+	case refused:
+		return genai.FinishReasonSafety, "refusal"
+	case toolCalls:
+		return genai.FinishReasonStop, "tool_calls"
+	case status == "", status == "completed":
+		return genai.FinishReasonStop, ""
+	}
+	// cancelled / queued / in_progress 等非终态
+	return genai.FinishReasonOther, status
+}
+
+// applyIncomplete 响应未完成（status=incomplete）时设置结束原因与错误信息，保留已生成的内容
+func applyIncomplete(llmResp *model.LLMResponse, resp *CreateResponseResponse) {
+	if resp.Status != "incomplete" {
+		return
+	}
+	finish, reason := responsesFinishReason(resp, false, false)
+	llmResp.FinishReason = finish
+	if reason == "" {
+		reason = "unknown"
+	}
+	llmResp.ErrorCode = "incomplete"
+	llmResp.ErrorMessage = "响应未完成: " + reason
+}
+
+// responsesErrorMessage 提取响应中的错误信息（对象或字符串）
+func responsesErrorMessage(v any) string {
+	switch e := v.(type) {
+	case nil:
+		return "未知错误"
+	case string:
+		return e
+	case map[string]any:
+		msg, _ := e["message"].(string)
+		if code, _ := e["code"].(string); code != "" {
+			return code + ": " + msg
+		}
+		if msg != "" {
+			return msg
 		}
 	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// convertResponsesUsage 转换 Responses API 用量，缓存命中与推理 token 分别写入对应字段
+func convertResponsesUsage(u *ResponsesUsage) *genai.GenerateContentResponseUsageMetadata {
+	if u == nil {
+		return nil
+	}
+	usage := &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     int32(u.InputTokens),
+		CandidatesTokenCount: int32(u.OutputTokens),
+		TotalTokenCount:      int32(u.TotalTokens),
+	}
+	if d := u.InputTokensDetails; d != nil {
+		usage.CachedContentTokenCount = int32(d.CachedTokens)
+	}
+	if d := u.OutputTokensDetails; d != nil {
+		usage.ThoughtsTokenCount = int32(d.ReasoningTokens)
+	}
+	return usage
+}
 
-	// 处理 usage
-	var usageMetadata *genai.GenerateContentResponseUsageMetadata
-	if resp.Usage != nil {
-		usageMetadata = &genai.GenerateContentResponseUsageMetadata{
-			PromptTokenCount:     int32(resp.Usage.InputTokens),
-			CandidatesTokenCount: int32(resp.Usage.OutputTokens),
-			TotalTokenCount:      int32(resp.Usage.TotalTokens),
+// reasoningSummaryText 合并 reasoning 输出项中的推理摘要，段落之间空一行
+func reasoningSummaryText(summary []ResponsesReasoningSummary) string {
+	texts := make([]string, 0, len(summary))
+	for _, s := range summary {
+		if s.Text != "" {
+			texts = append(texts, s.Text)
 		}
 	}
+	return strings.Join(texts, "\n\n")
+}
 
-	return &model.LLMResponse{
-		Content:       content,
-		UsageMetadata: usageMetadata,
-		FinishReason:  genai.FinishReasonStop,
-		TurnComplete:  true,
-	}, nil
+// applyReasoningSummary 请求返回推理摘要（未配置推理强度时使用服务端默认强度）
+func applyReasoningSummary(apiReq *CreateResponseRequest, summary string) {
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return
+	}
+	if apiReq.Reasoning == nil {
+		apiReq.Reasoning = &ResponsesReasoning{}
+	}
+	apiReq.Reasoning.Summary = summary
 }
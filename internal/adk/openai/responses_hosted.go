@@ -0,0 +1,152 @@
+package openai
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/genai"
+)
+
+// Responses API 内置工具（由 OpenAI 服务端执行）
+const (
+	HostedToolWebSearch       = "web_search"
+	HostedToolCodeInterpreter = "code_interpreter"
+)
+
+// applyHostedTools 把启用的内置工具追加到请求工具列表，并请求返回搜索来源与代码输出
+func applyHostedTools(apiReq *CreateResponseRequest, hosted []string) {
+	seen := make(map[string]bool, len(hosted))
+	for _, name := range hosted {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		switch name {
+		case HostedToolWebSearch:
+			apiReq.Tools = append(apiReq.Tools, ResponsesTool{Type: HostedToolWebSearch})
+			apiReq.Include = append(apiReq.Include, "web_search_call.action.sources")
+		case HostedToolCodeInterpreter:
+			apiReq.Tools = append(apiReq.Tools, ResponsesTool{Type: HostedToolCodeInterpreter, Container: map[string]string{"type": "auto"}})
+			apiReq.Include = append(apiReq.Include, "code_interpreter_call.outputs")
+		default:
+			if name != "" {
+				respLog.Warn("忽略不支持的内置工具: %s", name)
+			}
+		}
+	}
+}
+
+// codeInterpreterParts 把 code_interpreter_call 输出项转换为可执行代码与执行结果 part
+func codeInterpreterParts(item ResponsesOutputItem) []*genai.Part {
+	var parts []*genai.Part
+	if item.Code != "" {
+		parts = append(parts, &genai.Part{ExecutableCode: &genai.ExecutableCode{Code: item.Code, Language: genai.LanguagePython}})
+	}
+	var logs []string
+	for _, out := range item.Outputs {
+		switch out.Type {
+		case "logs":
+			logs = append(logs, out.Logs)
+		case "image":
+			if out.URL != "" {
+				parts = append(parts, &genai.Part{FileData: &genai.FileData{FileURI: out.URL, MIMEType: "image/png"}})
+			}
+		}
+	}
+	if len(logs) > 0 || item.Status == "failed" {
+		outcome := genai.OutcomeOK
+		if item.Status == "failed" {
+			outcome = genai.OutcomeFailed
+		}
+		parts = append(parts, &genai.Part{CodeExecutionResult: &genai.CodeExecutionResult{Outcome: outcome, Output: strings.Join(logs, "\n")}})
+	}
+	return parts
+}
+
+// groundingBuilder 汇总网页搜索查询与 url_citation 引用，生成 GroundingMetadata
+type groundingBuilder struct {
+	queries  []string
+	chunks   []*genai.GroundingChunk
+	supports []*genai.GroundingSupport
+	chunkIdx map[string]int32
+}
+
+// addSearchCall 记录 web_search_call 的查询与来源
+func (g *groundingBuilder) addSearchCall(item ResponsesOutputItem) {
+	if item.Action == nil {
+		return
+	}
+	if item.Action.Query != "" {
+		g.queries = append(g.queries, item.Action.Query)
+	}
+	for _, src := range item.Action.Sources {
+		g.chunk(src.URL, "")
+	}
+}
+
+// addCitations 记录文本中的 url_citation 标注，partIndex 为文本所在 part 的下标，base 为文本在该 part 中的字节偏移
+// Responses API 的索引按字符计，Segment 按字节计，这里做一次换算
+func (g *groundingBuilder) addCitations(text string, annotations []ResponsesAnnotation, partIndex, base int) {
+	for _, a := range annotations {
+		if a.Type != "url_citation" || a.URL == "" {
+			continue
+		}
+		idx := g.chunk(a.URL, a.Title)
+		start, end := runeOffset(text, a.StartIndex), runeOffset(text, a.EndIndex)
+		seg := &genai.Segment{PartIndex: int32(partIndex), StartIndex: int32(base + start), EndIndex: int32(base + end)}
+		if start < end {
+			seg.Text = text[start:end]
+		}
+		g.supports = append(g.supports, &genai.GroundingSupport{GroundingChunkIndices: []int32{idx}, Segment: seg})
+	}
+}
+
+// shiftParts 在引用文本之前插入了 n 个 part 时修正下标
+func (g *groundingBuilder) shiftParts(n int) {
+	for _, s := range g.supports {
+		s.Segment.PartIndex += int32(n)
+	}
+}
+
+// chunk 按 URL 去重添加引用来源，返回其下标
+func (g *groundingBuilder) chunk(url, title string) int32 {
+	if g.chunkIdx == nil {
+		g.chunkIdx = make(map[string]int32)
+	}
+	if idx, ok := g.chunkIdx[url]; ok {
+		if title != "" && g.chunks[idx].Web.Title == "" {
+			g.chunks[idx].Web.Title = title
+		}
+		return idx
+	}
+	idx := int32(len(g.chunks))
+	g.chunks = append(g.chunks, &genai.GroundingChunk{Web: &genai.GroundingChunkWeb{URI: url, Title: title}})
+	g.chunkIdx[url] = idx
+	return idx
+}
+
+// metadata 返回汇总结果，没有任何搜索与引用时返回 nil
+func (g *groundingBuilder) metadata() *genai.GroundingMetadata {
+	if len(g.queries) == 0 && len(g.chunks) == 0 {
+		return nil
+	}
+	return &genai.GroundingMetadata{
+		WebSearchQueries:  g.queries,
+		GroundingChunks:   g.chunks,
+		GroundingSupports: g.supports,
+	}
+}
+
+// runeOffset 把字符下标换算为字节偏移，超出范围时取文本末尾
+func runeOffset(s string, runes int) int {
+	if runes <= 0 {
+		return 0
+	}
+	i := 0
+	for n := 0; n < runes && i < len(s); n++ {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return i
+}
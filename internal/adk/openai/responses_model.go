@@ -1,27 +1,28 @@
 package openai
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"iter"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"google.golang.org/adk/model"
 	"google.golang.org/genai"
 
+	"github.com/run-bigpig/jcp/internal/adk/providererr"
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
 	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/pkg/sse"
 )
 
 var respLog = logger.New("openai:responses")
 
-// sseMaxBufferSize SSE 扫描器最大缓冲区（1MB），防止超长工具参数被截断
-const sseMaxBufferSize = 1024 * 1024
-
 var _ model.LLM = &ResponsesModel{}
 
 // HTTPDoer HTTP 客户端接口
@@ -36,6 +37,14 @@ type ResponsesModel struct {
 	apiKey       string
 	modelName    string
 	NoSystemRole bool // 不支持 system role 时需要降级处理
+	// UseConversations 使用 Conversations API 保存历史（需在 context 中提供映射存储）
+	UseConversations bool
+	// UsePreviousResponseID 记录每个会话最后一次响应ID，后续请求通过 previous_response_id 只发送新增内容
+	UsePreviousResponseID bool
+	// HostedTools 启用的内置工具（web_search / code_interpreter），由服务端执行，结果以引用与代码 part 返回
+	HostedTools []string
+	// ReasoningSummary 推理模型返回推理摘要（auto / concise / detailed），摘要以思考 part 输出
+	ReasoningSummary string
 }
 
 // NewResponsesModel 创建 Responses API 模型
@@ -87,35 +96,92 @@ func (r *ResponsesModel) doRequest(ctx context.Context, body []byte, stream bool
 	return r.httpClient.Do(req)
 }
 
-// generate 非流式生成
-func (r *ResponsesModel) generate(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
-	return func(yield func(*model.LLMResponse, error) bool) {
-		apiReq, err := toResponsesRequest(req, r.modelName, r.NoSystemRole)
-		if err != nil {
-			yield(nil, err)
-			return
-		}
-		apiReq.Stream = false
+// buildRequest 转换请求；关联了服务端 conversation 或上一次响应时只发送新增内容
+func (r *ResponsesModel) buildRequest(ctx context.Context, req *model.LLMRequest) (CreateResponseRequest, error) {
+	convID := r.resolveConversation(ctx)
+	var prevID string
+	if convID == "" {
+		prevID = r.previousResponseID(ctx)
+	}
+	if convID == "" && prevID == "" {
+		return toResponsesRequest(req, r.modelName, r.NoSystemRole)
+	}
+	trimmed := *req
+	trimmed.Contents = newConversationItems(req.Contents)
+	apiReq, err := toResponsesRequest(&trimmed, r.modelName, r.NoSystemRole)
+	if err != nil {
+		return apiReq, err
+	}
+	// 服务端会保留 input 中的 developer 消息，而 instructions 每次请求重新生效，关联会话时并回 instructions 避免重复累积
+	foldDeveloperInstructions(&apiReq)
+	apiReq.Conversation = convID
+	apiReq.PreviousResponseID = prevID
+	return apiReq, nil
+}
 
-		body, err := json.Marshal(apiReq)
-		if err != nil {
-			yield(nil, fmt.Errorf("序列化请求失败: %w", err))
-			return
+// foldDeveloperInstructions 把开头由系统指令拆出的 developer 消息合并回 instructions
+func foldDeveloperInstructions(apiReq *CreateResponseRequest) {
+	items, ok := apiReq.Input.([]ResponsesInputItem)
+	if !ok || apiReq.Instructions == "" {
+		return
+	}
+	n := 0
+	for n < len(items) && items[n].Role == "developer" {
+		text, ok := items[n].Content.(string)
+		if !ok {
+			break
 		}
+		apiReq.Instructions += "\n" + text
+		n++
+	}
+	apiReq.Input = items[n:]
+}
+
+// send 构建并发送请求，返回状态码正常的响应
+// previous_response_id 失效（过期或已删除）时清除记录，改为发送完整历史重试一次
+func (r *ResponsesModel) send(ctx context.Context, req *model.LLMRequest, stream bool) (*http.Response, error) {
+	apiReq, err := r.buildRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	apiReq.Stream = stream
+	applyHostedTools(&apiReq, r.HostedTools)
+	applyReasoningSummary(&apiReq, r.ReasoningSummary)
+
+	body, err := json.Marshal(apiReq)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	resp, err := r.doRequest(ctx, body, stream)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+		return resp, nil
+	}
+	pe := providererr.FromResponse("OpenAI Responses", resp)
+	resp.Body.Close()
+	respLog.Warn("%s 请求 %s 失败: %v，响应体: %s", r.modelName, providererr.Endpoint(r.responsesEndpoint()), pe, pe.Body)
+	if apiReq.PreviousResponseID != "" && resp.StatusCode < 500 &&
+		(pe.Code == "previous_response_not_found" || strings.Contains(pe.Message, "previous_response")) {
+		respLog.Warn("previous_response_id 已失效，改为发送完整历史: %v", pe)
+		r.rememberResponse(ctx, "")
+		return r.send(ctx, req, stream)
+	}
+	return nil, pe
+}
 
-		resp, err := r.doRequest(ctx, body, false)
+// generate 非流式生成
+func (r *ResponsesModel) generate(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		resp, err := r.send(ctx, req, false)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
 		defer resp.Body.Close()
 
-		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-			respBody, _ := io.ReadAll(resp.Body)
-			yield(nil, fmt.Errorf("Responses API 错误 (HTTP %d): %s", resp.StatusCode, string(respBody)))
-			return
-		}
-
 		var apiResp CreateResponseResponse
 		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
 			yield(nil, fmt.Errorf("解析响应失败: %w", err))
@@ -127,47 +193,78 @@ func (r *ResponsesModel) generate(ctx context.Context, req *model.LLMRequest) it
 			yield(nil, err)
 			return
 		}
+		llmResp.CustomMetadata = respmeta.Meta{
+			ResponseID:   apiResp.ID,
+			RequestID:    respmeta.RequestIDFromHeader(resp.Header),
+			ModelVersion: apiResp.Model,
+		}.Merge(llmResp.CustomMetadata)
+		r.rememberResponse(ctx, apiResp.ID)
 		yield(llmResp, nil)
 	}
 }
 
+// RetrieveResponse 按响应ID取回已存储的响应（用于流式中断后恢复）
+// 仅当响应已完成时返回结果，其余状态返回错误
+func (r *ResponsesModel) RetrieveResponse(ctx context.Context, responseID string) (*model.LLMResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.responsesEndpoint()+"/"+url.PathEscape(responseID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("取回响应失败: %w", providererr.FromResponse("OpenAI Responses", resp))
+	}
+
+	var apiResp CreateResponseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if apiResp.Status != "completed" {
+		return nil, fmt.Errorf("响应未完成: %s", apiResp.Status)
+	}
+
+	llmResp, err := convertResponsesResponse(&apiResp)
+	if err != nil {
+		return nil, err
+	}
+	llmResp.CustomMetadata = respmeta.Meta{
+		ResponseID:   apiResp.ID,
+		RequestID:    respmeta.RequestIDFromHeader(resp.Header),
+		ModelVersion: apiResp.Model,
+	}.Merge(llmResp.CustomMetadata)
+	return llmResp, nil
+}
+
 // generateStream 流式生成
 func (r *ResponsesModel) generateStream(ctx context.Context, req *model.LLMRequest) iter.Seq2[*model.LLMResponse, error] {
 	return func(yield func(*model.LLMResponse, error) bool) {
-		apiReq, err := toResponsesRequest(req, r.modelName, r.NoSystemRole)
-		if err != nil {
-			yield(nil, err)
-			return
-		}
-		apiReq.Stream = true
-
-		body, err := json.Marshal(apiReq)
-		if err != nil {
-			yield(nil, fmt.Errorf("序列化请求失败: %w", err))
-			return
-		}
-
-		resp, err := r.doRequest(ctx, body, true)
+		resp, err := r.send(ctx, req, true)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
 		defer resp.Body.Close()
 
-		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-			respBody, _ := io.ReadAll(resp.Body)
-			yield(nil, fmt.Errorf("Responses API 流式错误 (HTTP %d): %s", resp.StatusCode, string(respBody)))
-			return
-		}
-
-		r.processResponsesStream(resp.Body, yield)
+		r.processResponsesStream(ctx, resp.Body, resp.Header, func(llmResp *model.LLMResponse, err error) bool {
+			if err == nil && llmResp != nil && !llmResp.Partial {
+				r.rememberResponse(ctx, respmeta.FromCustomMetadata(llmResp.CustomMetadata).ResponseID)
+			}
+			return yield(llmResp, err)
+		})
 	}
 }
 
 // processResponsesStream 处理 Responses API 的 SSE 流
-func (r *ResponsesModel) processResponsesStream(body io.Reader, yield func(*model.LLMResponse, error) bool) {
-	scanner := bufio.NewScanner(body)
-	scanner.Buffer(make([]byte, 0, 64*1024), sseMaxBufferSize)
+// 每个事件前检查 ctx，取消后不再继续解析（代理持续发送事件时读取本身不会被中断）
+func (r *ResponsesModel) processResponsesStream(ctx context.Context, body io.Reader, header http.Header, yield func(*model.LLMResponse, error) bool) {
+	reader := sse.NewReader(body, 0)
 
 	aggregatedContent := &genai.Content{Role: "model", Parts: []*genai.Part{}}
 	var textContent string
@@ -175,43 +272,89 @@ func (r *ResponsesModel) processResponsesStream(body io.Reader, yield func(*mode
 	toolCallsMap := make(map[string]*responsesToolCallBuilder)
 	var toolCallOrder []string
 	var usageMetadata *genai.GenerateContentResponseUsageMetadata
-	var currentEventType string
+	meta := respmeta.Meta{RequestID: respmeta.RequestIDFromHeader(header)}
 	thinkParser := newThinkTagStreamParser()
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if eventType, ok := strings.CutPrefix(line, "event: "); ok {
-			currentEventType = eventType
-			continue
+	var completed, incomplete *CreateResponseResponse
+	refused := false
+	var grounding groundingBuilder
+	var codeParts []*genai.Part
+	summary := reasoningSummaryState{streamed: make(map[string]bool)}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			yield(nil, &respmeta.StreamError{Err: fmt.Errorf("SSE 流已取消: %w", err), Meta: meta})
+			return
 		}
-		data, ok := strings.CutPrefix(line, "data: ")
-		if !ok || data == "" {
-			continue
+		ev, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			respLog.Warn("SSE 流读取错误: %v", err)
+			yield(nil, &respmeta.StreamError{Err: fmt.Errorf("SSE 流读取错误: %w", err), Meta: meta})
+			return
 		}
+		if ev.Done() {
+			break
+		}
+		data := ev.Data
 
-		switch currentEventType {
+		switch ev.Type() {
 		case "response.output_text.delta":
 			if !r.handleTextDelta(data, thinkParser, &textContent, &thoughtContent, yield) {
 				return
 			}
+		case "response.refusal.delta":
+			// 拒绝说明按正文输出，结束原因标记为 refusal
+			refused = true
+			if !r.handleTextDelta(data, thinkParser, &textContent, &thoughtContent, yield) {
+				return
+			}
 		case "response.function_call_arguments.delta":
-			r.handleFuncArgsDelta(data, toolCallsMap)
+			if b := r.handleFuncArgsDelta(data, toolCallsMap); b != nil && !yield(b.partialResponse(), nil) {
+				return
+			}
 		case "response.output_item.added":
-			r.handleOutputItemAdded(data, toolCallsMap, &toolCallOrder)
+			if b := r.handleOutputItemAdded(data, toolCallsMap, &toolCallOrder); b != nil && !yield(b.partialResponse(), nil) {
+				return
+			}
+		case "response.reasoning_summary_text.delta":
+			if !r.handleReasoningSummaryDelta(data, &summary, &textContent, &thoughtContent, yield) {
+				return
+			}
 		case "response.output_item.done":
-			r.handleOutputItemDone(data, toolCallsMap, &toolCallOrder)
+			item := r.handleOutputItemDone(data, toolCallsMap, &toolCallOrder, textContent, &grounding, &codeParts)
+			// 未以增量推送摘要的 reasoning 项，在完成时一次输出
+			if item != nil && item.Type == "reasoning" && !summary.streamed[item.ID] {
+				if text := reasoningSummaryText(item.Summary); text != "" {
+					if thoughtContent != "" {
+						text = "\n\n" + text
+					}
+					if !r.emitTextSegments([]thinkSegment{{Text: text, Thought: true}}, &textContent, &thoughtContent, yield) {
+						return
+					}
+				}
+			}
+		case "response.created":
+			r.handleCreated(data, &meta)
 		case "response.completed":
-			r.handleCompleted(data, &usageMetadata)
+			completed = r.handleCompleted(data, &usageMetadata, &meta)
+		case "response.incomplete":
+			incomplete = r.handleCompleted(data, &usageMetadata, &meta)
+		case "response.failed":
+			failed := r.handleCompleted(data, &usageMetadata, &meta)
+			msg := "未知错误"
+			if failed != nil {
+				msg = responsesErrorMessage(failed.Error)
+			}
+			yield(nil, fmt.Errorf("Responses API 响应失败: %s", msg))
+			return
+		case "error":
+			var streamErr ResponsesStreamError
+			_ = json.Unmarshal([]byte(data), &streamErr)
+			yield(nil, &respmeta.StreamError{Err: providererr.New("OpenAI Responses", 0, streamErr.Code, streamErr.Message), Meta: meta})
+			return
 		}
-
-		currentEventType = ""
-	}
-
-	if err := scanner.Err(); err != nil {
-		respLog.Warn("SSE 流读取错误: %v", err)
-		yield(nil, fmt.Errorf("SSE 流读取错误: %w", err))
-		return
 	}
 
 	// 刷新剩余分片（处理标签跨 chunk）
@@ -219,9 +362,14 @@ func (r *ResponsesModel) processResponsesStream(body io.Reader, yield func(*mode
 		return
 	}
 
+	// 代码解释器的代码与输出排在文本之前
+	aggregatedContent.Parts = append(aggregatedContent.Parts, codeParts...)
+
 	// 组装最终文本，并解析第三方工具调用标记
+	toolCalls := len(toolCallOrder) > 0
 	if textContent != "" {
 		vendorCalls, cleanedText := parseVendorToolCalls(textContent)
+		toolCalls = toolCalls || len(vendorCalls) > 0
 		if cleanedText != "" {
 			aggregatedContent.Parts = append(aggregatedContent.Parts, &genai.Part{Text: cleanedText})
 		}
@@ -251,16 +399,30 @@ func (r *ResponsesModel) processResponsesStream(body io.Reader, yield func(*mode
 		})
 	}
 
+	// 引用标注指向最终文本 part
+	grounding.shiftParts(len(codeParts))
 	if thoughtContent != "" {
 		aggregatedContent.Parts = append([]*genai.Part{{Text: thoughtContent, Thought: true}}, aggregatedContent.Parts...)
+		grounding.shiftParts(1)
 	}
 
+	final := completed
+	if incomplete != nil {
+		final = incomplete
+	}
+	finish, stopReason := responsesFinishReason(final, toolCalls, refused)
+	meta.StopReason = stopReason
 	finalResp := &model.LLMResponse{
-		Content:       aggregatedContent,
-		UsageMetadata: usageMetadata,
-		FinishReason:  genai.FinishReasonStop,
-		Partial:       false,
-		TurnComplete:  true,
+		Content:           aggregatedContent,
+		UsageMetadata:     usageMetadata,
+		GroundingMetadata: grounding.metadata(),
+		FinishReason:      finish,
+		CustomMetadata:    meta.Map(),
+		Partial:           false,
+		TurnComplete:      true,
+	}
+	if incomplete != nil {
+		applyIncomplete(finalResp, incomplete)
 	}
 	yield(finalResp, nil)
 }
@@ -273,6 +435,16 @@ type responsesToolCallBuilder struct {
 	args   string
 }
 
+// partialResponse 工具调用进度事件（Partial），供界面在参数生成过程中展示正在调用的工具
+// 不携带 FunctionCall part，避免被提前执行；完整调用仍只出现在最终聚合响应中
+func (b *responsesToolCallBuilder) partialResponse() *model.LLMResponse {
+	return &model.LLMResponse{
+		Content:        &genai.Content{Role: "model"},
+		Partial:        true,
+		CustomMetadata: respmeta.ToolCallDelta{ID: b.callID, Name: b.name, Arguments: b.args}.Map(),
+	}
+}
+
 // handleTextDelta 处理文本增量事件
 func (r *ResponsesModel) handleTextDelta(
 	data string,
@@ -317,41 +489,100 @@ func (r *ResponsesModel) emitTextSegments(
 	return true
 }
 
-// handleFuncArgsDelta 处理函数调用参数增量事件
-func (r *ResponsesModel) handleFuncArgsDelta(data string, toolCallsMap map[string]*responsesToolCallBuilder) {
+// handleFuncArgsDelta 处理函数调用参数增量事件，返回更新的工具调用
+func (r *ResponsesModel) handleFuncArgsDelta(data string, toolCallsMap map[string]*responsesToolCallBuilder) *responsesToolCallBuilder {
 	var delta ResponsesFuncCallArgsDelta
 	if err := json.Unmarshal([]byte(data), &delta); err != nil {
 		respLog.Warn("解析函数参数增量失败: %v", err)
-		return
+		return nil
 	}
-	if builder, exists := toolCallsMap[delta.ItemID]; exists {
-		builder.args += delta.Delta
+	builder, exists := toolCallsMap[delta.ItemID]
+	if !exists {
+		return nil
 	}
+	builder.args += delta.Delta
+	return builder
 }
 
-// handleOutputItemAdded 处理 output item added 事件
-func (r *ResponsesModel) handleOutputItemAdded(data string, toolCallsMap map[string]*responsesToolCallBuilder, toolCallOrder *[]string) {
+// handleOutputItemAdded 处理 output item added 事件，新增工具调用时返回该调用
+func (r *ResponsesModel) handleOutputItemAdded(data string, toolCallsMap map[string]*responsesToolCallBuilder, toolCallOrder *[]string) *responsesToolCallBuilder {
 	var added ResponsesOutputItemAdded
 	if err := json.Unmarshal([]byte(data), &added); err != nil {
 		respLog.Warn("解析输出项添加事件失败: %v", err)
-		return
+		return nil
 	}
-	if added.Item.Type == "function_call" {
-		toolCallsMap[added.Item.ID] = &responsesToolCallBuilder{
-			itemID: added.Item.ID,
-			callID: added.Item.CallID,
-			name:   added.Item.Name,
-		}
-		*toolCallOrder = append(*toolCallOrder, added.Item.ID)
+	if added.Item.Type != "function_call" {
+		return nil
+	}
+	builder := &responsesToolCallBuilder{
+		itemID: added.Item.ID,
+		callID: added.Item.CallID,
+		name:   added.Item.Name,
+	}
+	toolCallsMap[added.Item.ID] = builder
+	*toolCallOrder = append(*toolCallOrder, added.Item.ID)
+	return builder
+}
+
+// reasoningSummaryState 流式推理摘要的聚合状态
+type reasoningSummaryState struct {
+	item     string // 最近一段摘要所属的输出项与序号，切换时插入段落分隔
+	index    int
+	streamed map[string]bool // 已收到增量的 reasoning 输出项
+}
+
+// handleReasoningSummaryDelta 处理推理摘要增量事件，摘要作为思考内容输出
+func (r *ResponsesModel) handleReasoningSummaryDelta(
+	data string,
+	state *reasoningSummaryState,
+	textContent *string,
+	thoughtContent *string,
+	yield func(*model.LLMResponse, error) bool,
+) bool {
+	var delta ResponsesReasoningSummaryDelta
+	if err := json.Unmarshal([]byte(data), &delta); err != nil {
+		respLog.Warn("解析推理摘要增量失败: %v", err)
+		return true
 	}
+	text := delta.Delta
+	if *thoughtContent != "" && (delta.ItemID != state.item || delta.SummaryIndex != state.index) {
+		text = "\n\n" + text
+	}
+	state.item, state.index = delta.ItemID, delta.SummaryIndex
+	state.streamed[delta.ItemID] = true
+	return r.emitTextSegments([]thinkSegment{{Text: text, Thought: true}}, textContent, thoughtContent, yield)
 }
 
-// handleOutputItemDone 处理 output item done 事件
-func (r *ResponsesModel) handleOutputItemDone(data string, toolCallsMap map[string]*responsesToolCallBuilder, toolCallOrder *[]string) {
+// handleOutputItemDone 处理 output item done 事件，返回解析出的输出项
+// 消息项携带完整文本与引用标注，textContent 为目前已聚合的文本，用于定位引用在最终文本中的偏移
+func (r *ResponsesModel) handleOutputItemDone(
+	data string,
+	toolCallsMap map[string]*responsesToolCallBuilder,
+	toolCallOrder *[]string,
+	textContent string,
+	grounding *groundingBuilder,
+	codeParts *[]*genai.Part,
+) *ResponsesOutputItem {
 	var done ResponsesOutputItemDone
 	if err := json.Unmarshal([]byte(data), &done); err != nil {
 		respLog.Warn("解析输出项完成事件失败: %v", err)
-		return
+		return nil
+	}
+	switch done.Item.Type {
+	case "message":
+		end := len(textContent)
+		for i := len(done.Item.Content) - 1; i >= 0; i-- {
+			part := done.Item.Content[i]
+			if part.Type != "output_text" {
+				continue
+			}
+			end -= len(part.Text)
+			grounding.addCitations(part.Text, part.Annotations, 0, max(end, 0))
+		}
+	case "web_search_call":
+		grounding.addSearchCall(done.Item)
+	case "code_interpreter_call":
+		*codeParts = append(*codeParts, codeInterpreterParts(done.Item)...)
 	}
 	if done.Item.Type == "function_call" {
 		if builder, exists := toolCallsMap[done.Item.ID]; exists {
@@ -370,20 +601,30 @@ func (r *ResponsesModel) handleOutputItemDone(data string, toolCallsMap map[stri
 			*toolCallOrder = append(*toolCallOrder, done.Item.ID)
 		}
 	}
+	return &done.Item
+}
+
+// handleCreated 处理 response.created 事件，记录响应ID用于中断后取回
+func (r *ResponsesModel) handleCreated(data string, meta *respmeta.Meta) {
+	var created ResponsesCompleted
+	if err := json.Unmarshal([]byte(data), &created); err != nil {
+		return
+	}
+	meta.ResponseID = created.Response.ID
+	meta.ModelVersion = created.Response.Model
 }
 
-// handleCompleted 处理 response.completed 事件
-func (r *ResponsesModel) handleCompleted(data string, usageMetadata **genai.GenerateContentResponseUsageMetadata) {
+// handleCompleted 处理 response.completed/incomplete/failed 事件，返回事件中的响应对象
+func (r *ResponsesModel) handleCompleted(data string, usageMetadata **genai.GenerateContentResponseUsageMetadata, meta *respmeta.Meta) *CreateResponseResponse {
 	var completed ResponsesCompleted
 	if err := json.Unmarshal([]byte(data), &completed); err != nil {
 		respLog.Warn("解析完成事件失败: %v", err)
-		return
+		return nil
 	}
+	meta.ResponseID = completed.Response.ID
+	meta.ModelVersion = completed.Response.Model
 	if completed.Response.Usage != nil {
-		*usageMetadata = &genai.GenerateContentResponseUsageMetadata{
-			PromptTokenCount:     int32(completed.Response.Usage.InputTokens),
-			CandidatesTokenCount: int32(completed.Response.Usage.OutputTokens),
-			TotalTokenCount:      int32(completed.Response.Usage.TotalTokens),
-		}
+		*usageMetadata = convertResponsesUsage(completed.Response.Usage)
 	}
+	return &completed.Response
 }
@@ -0,0 +1,340 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+)
+
+func TestProcessResponsesStream_DataOnly(t *testing.T) {
+	// 网关省略 event 行，只发送 data，按 JSON 的 type 字段分发
+	stream := `data: {"type":"response.created","response":{"id":"resp_1","model":"gpt"}}
+
+data: {"type":"response.output_text.delta","delta":"你好"}
+
+data: {"type":"response.output_text.delta","delta":"，世界"}
+
+data: {"type":"response.completed","response":{"id":"resp_1","usage":{"input_tokens":3,"output_tokens":4,"total_tokens":7}}}
+
+data: [DONE]
+
+`
+	r := &ResponsesModel{}
+	var final *model.LLMResponse
+	r.processResponsesStream(context.Background(), strings.NewReader(stream), http.Header{}, func(resp *model.LLMResponse, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Partial {
+			final = resp
+		}
+		return true
+	})
+
+	if final == nil || final.Content == nil || len(final.Content.Parts) == 0 {
+		t.Fatalf("final response = %#v", final)
+	}
+	if got := final.Content.Parts[0].Text; got != "你好，世界" {
+		t.Errorf("text = %q", got)
+	}
+	if final.UsageMetadata == nil || final.UsageMetadata.TotalTokenCount != 7 {
+		t.Errorf("usage = %#v", final.UsageMetadata)
+	}
+}
+
+func TestProcessResponsesStream_PartialFunctionCall(t *testing.T) {
+	stream := `event: response.output_item.added
+data: {"type":"response.output_item.added","item":{"type":"function_call","id":"fc_1","call_id":"call_1","name":"get_kline"}}
+
+event: response.function_call_arguments.delta
+data: {"type":"response.function_call_arguments.delta","item_id":"fc_1","delta":"{\"code\":"}
+
+event: response.function_call_arguments.delta
+data: {"type":"response.function_call_arguments.delta","item_id":"fc_1","delta":"\"600519\"}"}
+
+event: response.output_item.done
+data: {"type":"response.output_item.done","item":{"type":"function_call","id":"fc_1","call_id":"call_1","name":"get_kline","arguments":"{\"code\":\"600519\"}"}}
+
+`
+	r := &ResponsesModel{}
+	var deltas []respmeta.ToolCallDelta
+	var final *model.LLMResponse
+	r.processResponsesStream(context.Background(), strings.NewReader(stream), http.Header{}, func(resp *model.LLMResponse, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Partial {
+			final = resp
+			return true
+		}
+		// 进度事件不能带 FunctionCall part，否则会被 ADK 提前执行
+		if resp.Content != nil && len(resp.Content.Parts) > 0 {
+			t.Fatalf("partial response carries parts: %#v", resp.Content.Parts)
+		}
+		if d, ok := respmeta.ToolCallDeltaFromCustomMetadata(resp.CustomMetadata); ok {
+			deltas = append(deltas, d)
+		}
+		return true
+	})
+
+	if len(deltas) != 3 || deltas[0].Name != "get_kline" || deltas[2].Arguments != `{"code":"600519"}` {
+		t.Fatalf("deltas = %#v", deltas)
+	}
+	if final == nil || len(final.Content.Parts) != 1 || final.Content.Parts[0].FunctionCall == nil {
+		t.Fatalf("final = %#v", final)
+	}
+}
+
+func TestProcessResponsesStream_LongMultiLineCRLF(t *testing.T) {
+	// 超过 bufio.Scanner 默认 64KB 的增量，且 JSON 被拆成多行 data、使用 CRLF 分隔
+	long := strings.Repeat("涨", 40*1024)
+	stream := "event: response.output_text.delta\r\n" +
+		"data: {\"type\":\"response.output_text.delta\",\r\n" +
+		"data: \"delta\":\"" + long + "\"}\r\n\r\n"
+
+	r := &ResponsesModel{}
+	var final *model.LLMResponse
+	r.processResponsesStream(context.Background(), strings.NewReader(stream), http.Header{}, func(resp *model.LLMResponse, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Partial {
+			final = resp
+		}
+		return true
+	})
+
+	if final == nil || len(final.Content.Parts) != 1 || final.Content.Parts[0].Text != long {
+		t.Fatalf("final text length mismatch: %#v", final)
+	}
+}
+
+func TestProcessResponsesStream_FailedAndIncomplete(t *testing.T) {
+	collect := func(stream string) (*model.LLMResponse, error) {
+		var final *model.LLMResponse
+		var streamErr error
+		(&ResponsesModel{}).processResponsesStream(context.Background(), strings.NewReader(stream), http.Header{}, func(resp *model.LLMResponse, err error) bool {
+			if err != nil {
+				streamErr = err
+				return false
+			}
+			if !resp.Partial {
+				final = resp
+			}
+			return true
+		})
+		return final, streamErr
+	}
+
+	_, err := collect("event: response.failed\ndata: {\"type\":\"response.failed\",\"response\":{\"id\":\"resp_1\",\"status\":\"failed\",\"error\":{\"code\":\"server_error\",\"message\":\"boom\"}}}\n\n")
+	if err == nil || !strings.Contains(err.Error(), "server_error: boom") {
+		t.Errorf("failed: err = %v", err)
+	}
+
+	_, err = collect("event: error\ndata: {\"type\":\"error\",\"code\":\"rate_limit_exceeded\",\"message\":\"slow down\"}\n\n")
+	if err == nil || !strings.Contains(err.Error(), "rate_limit_exceeded") {
+		t.Errorf("error event: err = %v", err)
+	}
+
+	final, err := collect("event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"delta\":\"半截\"}\n\n" +
+		"event: response.incomplete\ndata: {\"type\":\"response.incomplete\",\"response\":{\"id\":\"resp_2\",\"status\":\"incomplete\",\"incomplete_details\":{\"reason\":\"max_output_tokens\"}}}\n\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final.FinishReason != genai.FinishReasonMaxTokens || final.ErrorCode != "incomplete" || final.Content.Parts[0].Text != "半截" {
+		t.Errorf("incomplete: %#v", final)
+	}
+}
+
+func TestConvertResponsesUsage_CachedAndReasoning(t *testing.T) {
+	resp := &CreateResponseResponse{}
+	if err := json.Unmarshal([]byte(`{"id":"resp_1","status":"completed","usage":{"input_tokens":1200,"output_tokens":800,"total_tokens":2000,
+		"input_tokens_details":{"cached_tokens":1024},"output_tokens_details":{"reasoning_tokens":640}}}`), resp); err != nil {
+		t.Fatal(err)
+	}
+	u := convertResponsesUsage(resp.Usage)
+	if u.PromptTokenCount != 1200 || u.CachedContentTokenCount != 1024 || u.ThoughtsTokenCount != 640 || u.TotalTokenCount != 2000 {
+		t.Errorf("usage = %+v", u)
+	}
+}
+
+func TestHostedTools_RequestAndCitations(t *testing.T) {
+	apiReq := CreateResponseRequest{Tools: []ResponsesTool{{Type: "function", Name: "get_kline"}}}
+	applyHostedTools(&apiReq, []string{HostedToolWebSearch, HostedToolCodeInterpreter, HostedToolWebSearch, "file_search"})
+	body, _ := json.Marshal(apiReq)
+	for _, want := range []string{`{"type":"web_search"}`, `{"type":"code_interpreter","container":{"type":"auto"}}`, `"include":["web_search_call.action.sources","code_interpreter_call.outputs"]`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("request missing %s: %s", want, body)
+		}
+	}
+
+	item := `{"type":"message","role":"assistant","content":[{"type":"output_text","text":"茅台公告分红。","annotations":[{"type":"url_citation","url":"https://example.com/a","title":"公告","start_index":2,"end_index":6}]}]}`
+	search := `{"type":"web_search_call","id":"ws_1","status":"completed","action":{"type":"search","query":"贵州茅台 分红"}}`
+
+	var resp CreateResponseResponse
+	if err := json.Unmarshal([]byte(`{"status":"completed","output":[`+search+`,`+item+`]}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+	llmResp, err := convertResponsesResponse(&resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(name string, r *model.LLMResponse, partIndex int32) {
+		g := r.GroundingMetadata
+		if g == nil || len(g.WebSearchQueries) != 1 || g.WebSearchQueries[0] != "贵州茅台 分红" {
+			t.Fatalf("%s: grounding = %#v", name, g)
+		}
+		if len(g.GroundingChunks) != 1 || g.GroundingChunks[0].Web.URI != "https://example.com/a" || g.GroundingChunks[0].Web.Title != "公告" {
+			t.Errorf("%s: chunks = %#v", name, g.GroundingChunks)
+		}
+		seg := g.GroundingSupports[0].Segment
+		text := r.Content.Parts[seg.PartIndex].Text
+		if seg.PartIndex != partIndex || seg.Text != "公告分红" || text[seg.StartIndex:seg.EndIndex] != "公告分红" {
+			t.Errorf("%s: segment = %#v", name, seg)
+		}
+	}
+	check("non-stream", llmResp, 0)
+
+	stream := "data: {\"type\":\"response.output_item.done\",\"item\":" + search + "}\n\n" +
+		"data: {\"type\":\"response.output_text.delta\",\"delta\":\"<think>查询</think>前言。\"}\n\n" +
+		"data: {\"type\":\"response.output_text.delta\",\"delta\":\"茅台公告分红。\"}\n\n" +
+		"data: {\"type\":\"response.output_item.done\",\"item\":" + item + "}\n\n"
+	var final *model.LLMResponse
+	(&ResponsesModel{}).processResponsesStream(context.Background(), strings.NewReader(stream), http.Header{}, func(r *model.LLMResponse, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Partial {
+			final = r
+		}
+		return true
+	})
+	check("stream", final, 1)
+}
+
+func TestProcessResponsesStream_ReasoningSummary(t *testing.T) {
+	stream := `data: {"type":"response.output_item.added","item":{"type":"reasoning","id":"rs_1"}}
+
+data: {"type":"response.reasoning_summary_text.delta","item_id":"rs_1","summary_index":0,"delta":"先看估值"}
+
+data: {"type":"response.reasoning_summary_text.delta","item_id":"rs_1","summary_index":1,"delta":"再看资金"}
+
+data: {"type":"response.output_item.done","item":{"type":"reasoning","id":"rs_1","summary":[{"type":"summary_text","text":"先看估值"},{"type":"summary_text","text":"再看资金"}]}}
+
+data: {"type":"response.output_item.done","item":{"type":"reasoning","id":"rs_2","summary":[{"type":"summary_text","text":"结论偏多"}]}}
+
+data: {"type":"response.output_text.delta","delta":"结论：持有"}
+
+data: {"type":"response.completed","response":{"id":"resp_1"}}
+
+`
+	r := &ResponsesModel{}
+	var partialThoughts []string
+	var final *model.LLMResponse
+	r.processResponsesStream(context.Background(), strings.NewReader(stream), http.Header{}, func(resp *model.LLMResponse, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Partial {
+			final = resp
+			return true
+		}
+		for _, p := range resp.Content.Parts {
+			if p.Thought {
+				partialThoughts = append(partialThoughts, p.Text)
+			}
+		}
+		return true
+	})
+
+	if len(partialThoughts) != 3 || partialThoughts[1] != "\n\n再看资金" {
+		t.Errorf("partial thoughts = %q", partialThoughts)
+	}
+	parts := final.Content.Parts
+	if len(parts) != 2 || !parts[0].Thought || parts[0].Text != "先看估值\n\n再看资金\n\n结论偏多" || parts[1].Text != "结论：持有" {
+		t.Fatalf("final parts = %+v", parts)
+	}
+
+	resp, err := convertResponsesResponse(&CreateResponseResponse{Output: []ResponsesOutputItem{
+		{Type: "reasoning", Summary: []ResponsesReasoningSummary{{Type: "summary_text", Text: "先看估值"}}},
+		{Type: "message", Content: []ResponsesContentPart{{Type: "output_text", Text: "持有"}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := resp.Content.Parts; len(p) != 2 || !p[0].Thought || p[0].Text != "先看估值" {
+		t.Fatalf("non-stream parts = %+v", p)
+	}
+
+	apiReq := CreateResponseRequest{}
+	applyReasoningSummary(&apiReq, "auto")
+	if apiReq.Reasoning == nil || apiReq.Reasoning.Summary != "auto" {
+		t.Errorf("reasoning = %+v", apiReq.Reasoning)
+	}
+}
+
+func TestResponsesFinishReason(t *testing.T) {
+	resp, err := convertResponsesResponse(&CreateResponseResponse{Status: "completed", Output: []ResponsesOutputItem{
+		{Type: "message", Content: []ResponsesContentPart{{Type: "refusal", Refusal: "无法提供该建议"}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.FinishReason != genai.FinishReasonSafety || respmeta.FromCustomMetadata(resp.CustomMetadata).StopReason != "refusal" || resp.Content.Parts[0].Text != "无法提供该建议" {
+		t.Errorf("refusal: finish = %s, meta = %v, parts = %+v", resp.FinishReason, resp.CustomMetadata, resp.Content.Parts)
+	}
+
+	resp, err = convertResponsesResponse(&CreateResponseResponse{Status: "completed", Output: []ResponsesOutputItem{
+		{Type: "function_call", CallID: "c1", Name: "get_kline", Arguments: "{}"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.FinishReason != genai.FinishReasonStop || respmeta.FromCustomMetadata(resp.CustomMetadata).StopReason != "tool_calls" {
+		t.Errorf("tool call: finish = %s, meta = %v", resp.FinishReason, resp.CustomMetadata)
+	}
+
+	stream := `data: {"type":"response.output_text.delta","delta":"部分"}
+
+data: {"type":"response.incomplete","response":{"id":"resp_1","status":"incomplete","incomplete_details":{"reason":"max_output_tokens"}}}
+
+`
+	var final *model.LLMResponse
+	(&ResponsesModel{}).processResponsesStream(context.Background(), strings.NewReader(stream), http.Header{}, func(resp *model.LLMResponse, err error) bool {
+		if err == nil && !resp.Partial {
+			final = resp
+		}
+		return true
+	})
+	if final == nil || final.FinishReason != genai.FinishReasonMaxTokens || respmeta.FromCustomMetadata(final.CustomMetadata).StopReason != "max_output_tokens" {
+		t.Fatalf("stream final = %+v", final)
+	}
+}
+
+func TestProcessResponsesStream_ContextCanceled(t *testing.T) {
+	stream := `data: {"type":"response.output_text.delta","delta":"你好"}
+
+`
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var gotErr error
+	(&ResponsesModel{}).processResponsesStream(ctx, strings.NewReader(stream), http.Header{}, func(resp *model.LLMResponse, err error) bool {
+		if err != nil {
+			gotErr = err
+		} else if resp != nil {
+			t.Errorf("取消后不应继续输出: %+v", resp)
+		}
+		return true
+	})
+	if !errors.Is(gotErr, context.Canceled) {
+		t.Errorf("err = %v, 期望 context.Canceled", gotErr)
+	}
+}
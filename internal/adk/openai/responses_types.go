@@ -8,6 +8,7 @@ type CreateResponseRequest struct {
 	Input              any                 `json:"input"`                         // string 或 []ResponsesInputItem
 	Instructions       string              `json:"instructions,omitempty"`
 	Tools              []ResponsesTool     `json:"tools,omitempty"`
+	ToolChoice         any                 `json:"tool_choice,omitempty"`         // "auto" / "required" / "none" 或指定函数
 	Stream             bool                `json:"stream,omitempty"`
 	MaxOutputTokens    int                 `json:"max_output_tokens,omitempty"`
 	Temperature        *float32            `json:"temperature,omitempty"`
@@ -15,6 +16,22 @@ type CreateResponseRequest struct {
 	Stop               []string            `json:"stop,omitempty"`
 	Reasoning          *ResponsesReasoning `json:"reasoning,omitempty"`
 	PreviousResponseID string              `json:"previous_response_id,omitempty"` // 多轮对话关联
+	Conversation       string              `json:"conversation,omitempty"`         // 服务端会话 ID，历史由服务端保存
+	Text               *ResponsesText      `json:"text,omitempty"`                 // 输出格式（结构化输出）
+	Include            []string            `json:"include,omitempty"`              // 额外返回的字段（如内置工具的来源与输出）
+}
+
+// ResponsesText 文本输出配置
+type ResponsesText struct {
+	Format ResponsesTextFormat `json:"format"`
+}
+
+// ResponsesTextFormat 输出格式：text / json_object / json_schema
+type ResponsesTextFormat struct {
+	Type   string         `json:"type"`
+	Name   string         `json:"name,omitempty"`
+	Schema map[string]any `json:"schema,omitempty"`
+	Strict bool           `json:"strict,omitempty"`
 }
 
 // ResponsesInputItem input 数组中的一条消息
@@ -31,18 +48,32 @@ type ResponsesInputItem struct {
 	Arguments string `json:"arguments,omitempty"`
 }
 
+// ResponsesInputContent 多模态 input 内容片段（消息含图片或文件时 Content 使用该类型的数组）
+type ResponsesInputContent struct {
+	Type     string `json:"type"`                // "input_text", "input_image", "input_file"
+	Text     string `json:"text,omitempty"`      // input_text
+	ImageURL string `json:"image_url,omitempty"` // input_image: data URL 或 http(s) 地址
+	Detail   string `json:"detail,omitempty"`    // input_image: "auto", "low", "high"
+	Filename string `json:"filename,omitempty"`  // input_file: 内联文件名
+	FileData string `json:"file_data,omitempty"` // input_file: data URL
+	FileURL  string `json:"file_url,omitempty"`  // input_file: http(s) 地址
+}
+
 // ResponsesTool Responses API 工具定义（扁平化，name 在顶层）
+// 内置工具（web_search、code_interpreter）只有 type 与各自的配置字段
 type ResponsesTool struct {
-	Type        string `json:"type"`                  // "function"
-	Name        string `json:"name"`
+	Type        string `json:"type"`                  // "function", "web_search", "code_interpreter"
+	Name        string `json:"name,omitempty"`
 	Description string `json:"description,omitempty"`
-	Parameters  any    `json:"parameters"`
+	Parameters  any    `json:"parameters,omitempty"`
 	Strict      bool   `json:"strict,omitempty"`
+	Container   any    `json:"container,omitempty"` // code_interpreter 专用：{"type":"auto"} 或容器 ID
 }
 
 // ResponsesReasoning 推理/思考配置
 type ResponsesReasoning struct {
-	Effort string `json:"effort,omitempty"` // "low", "medium", "high"
+	Effort  string `json:"effort,omitempty"`  // "low", "medium", "high"
+	Summary string `json:"summary,omitempty"` // "auto", "concise", "detailed"，设置后返回推理摘要
 }
 
 // ===== Responses API 响应类型 =====
@@ -58,11 +89,25 @@ type CreateResponseResponse struct {
 	Output     []ResponsesOutputItem `json:"output"`
 	OutputText string                `json:"output_text"`
 	Usage      *ResponsesUsage       `json:"usage,omitempty"`
+	// status 为 incomplete 时的原因
+	IncompleteDetails *ResponsesIncompleteDetails `json:"incomplete_details,omitempty"`
+}
+
+// ResponsesIncompleteDetails 响应未完成的原因
+type ResponsesIncompleteDetails struct {
+	Reason string `json:"reason"` // "max_output_tokens", "content_filter"
+}
+
+// ResponsesStreamError 流式 error 事件 (error)
+type ResponsesStreamError struct {
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
 // ResponsesOutputItem output 数组中的一项
 type ResponsesOutputItem struct {
-	Type   string `json:"type"`   // "message", "function_call"
+	Type   string `json:"type"`   // "message", "function_call", "reasoning", "web_search_call", "code_interpreter_call"
 	ID     string `json:"id"`
 	Status string `json:"status"`
 	// message 类型字段
@@ -72,19 +117,76 @@ type ResponsesOutputItem struct {
 	Name      string `json:"name,omitempty"`
 	CallID    string `json:"call_id,omitempty"`
 	Arguments string `json:"arguments,omitempty"`
+	// web_search_call 类型字段
+	Action *ResponsesWebSearchAction `json:"action,omitempty"`
+	// code_interpreter_call 类型字段
+	Code    string                      `json:"code,omitempty"`
+	Outputs []ResponsesCodeInterpOutput `json:"outputs,omitempty"`
+	// reasoning 类型字段
+	Summary []ResponsesReasoningSummary `json:"summary,omitempty"`
+}
+
+// ResponsesWebSearchAction 网页搜索动作（search / open_page / find）
+type ResponsesWebSearchAction struct {
+	Type    string                     `json:"type"`
+	Query   string                     `json:"query,omitempty"`
+	URL     string                     `json:"url,omitempty"`
+	Sources []ResponsesWebSearchSource `json:"sources,omitempty"` // 需 include web_search_call.action.sources
+}
+
+// ResponsesWebSearchSource 搜索到的来源
+type ResponsesWebSearchSource struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// ResponsesCodeInterpOutput 代码解释器输出（需 include code_interpreter_call.outputs）
+type ResponsesCodeInterpOutput struct {
+	Type string `json:"type"` // "logs", "image"
+	Logs string `json:"logs,omitempty"`
+	URL  string `json:"url,omitempty"`
 }
 
 // ResponsesContentPart content 中的一个部分
 type ResponsesContentPart struct {
-	Type string `json:"type"`           // "output_text", "refusal", "reasoning"
-	Text string `json:"text,omitempty"`
+	Type        string                `json:"type"`           // "output_text", "refusal", "reasoning"
+	Text        string                `json:"text,omitempty"`
+	Refusal     string                `json:"refusal,omitempty"` // refusal 类型：模型拒绝回答的说明
+	Annotations []ResponsesAnnotation `json:"annotations,omitempty"`
+}
+
+// ResponsesReasoningSummary reasoning 输出项中的一段推理摘要
+type ResponsesReasoningSummary struct {
+	Type string `json:"type"` // "summary_text"
+	Text string `json:"text"`
+}
+
+// ResponsesAnnotation 文本标注（url_citation 为网页搜索引用，索引按字符计）
+type ResponsesAnnotation struct {
+	Type       string `json:"type"`
+	URL        string `json:"url,omitempty"`
+	Title      string `json:"title,omitempty"`
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
 }
 
 // ResponsesUsage 用量信息
 type ResponsesUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
-	TotalTokens  int `json:"total_tokens"`
+	InputTokens         int                           `json:"input_tokens"`
+	OutputTokens        int                           `json:"output_tokens"`
+	TotalTokens         int                           `json:"total_tokens"`
+	InputTokensDetails  *ResponsesInputTokensDetails  `json:"input_tokens_details,omitempty"`
+	OutputTokensDetails *ResponsesOutputTokensDetails `json:"output_tokens_details,omitempty"`
+}
+
+// ResponsesInputTokensDetails 输入 token 明细
+type ResponsesInputTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"` // 命中提示缓存的 token 数（包含在 InputTokens 中）
+}
+
+// ResponsesOutputTokensDetails 输出 token 明细
+type ResponsesOutputTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"` // 推理 token 数（包含在 OutputTokens 中）
 }
 
 // ===== 流式 SSE 事件类型 =====
@@ -98,6 +200,15 @@ type ResponsesTextDelta struct {
 	Delta        string `json:"delta"`
 }
 
+// ResponsesReasoningSummaryDelta 推理摘要增量事件 (response.reasoning_summary_text.delta)
+type ResponsesReasoningSummaryDelta struct {
+	Type         string `json:"type"`
+	ItemID       string `json:"item_id"`
+	OutputIndex  int    `json:"output_index"`
+	SummaryIndex int    `json:"summary_index"`
+	Delta        string `json:"delta"`
+}
+
 // ResponsesFuncCallArgsDelta 函数调用参数增量 (response.function_call_arguments.delta)
 type ResponsesFuncCallArgsDelta struct {
 	Type        string `json:"type"`
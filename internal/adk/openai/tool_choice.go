@@ -0,0 +1,71 @@
+package openai
+
+import (
+	"slices"
+
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+// functionCalling 读取 genai 的函数调用配置，未配置时 mode 为空
+func functionCalling(cfg *genai.GenerateContentConfig) (genai.FunctionCallingConfigMode, []string) {
+	if cfg == nil || cfg.ToolConfig == nil || cfg.ToolConfig.FunctionCallingConfig == nil {
+		return "", nil
+	}
+	fc := cfg.ToolConfig.FunctionCallingConfig
+	return fc.Mode, fc.AllowedFunctionNames
+}
+
+// applyChatToolChoice 将函数调用配置映射为 Chat Completions 的 tool_choice
+// ANY 且只允许一个函数时强制调用该函数；指定了允许列表时只发送列表中的工具
+func applyChatToolChoice(req *openai.ChatCompletionRequest, cfg *genai.GenerateContentConfig) {
+	mode, allowed := functionCalling(cfg)
+	if mode == "" || len(req.Tools) == 0 {
+		return
+	}
+	if len(allowed) > 0 && mode != genai.FunctionCallingConfigModeNone {
+		req.Tools = slices.DeleteFunc(req.Tools, func(t openai.Tool) bool {
+			return t.Function == nil || !slices.Contains(allowed, t.Function.Name)
+		})
+	}
+	switch mode {
+	case genai.FunctionCallingConfigModeNone:
+		req.ToolChoice = "none"
+	case genai.FunctionCallingConfigModeAny:
+		if len(req.Tools) == 1 {
+			req.ToolChoice = openai.ToolChoice{
+				Type:     openai.ToolTypeFunction,
+				Function: openai.ToolFunction{Name: req.Tools[0].Function.Name},
+			}
+		} else {
+			req.ToolChoice = "required"
+		}
+	case genai.FunctionCallingConfigModeAuto, genai.FunctionCallingConfigModeValidated:
+		req.ToolChoice = "auto"
+	}
+}
+
+// applyResponsesToolChoice 将函数调用配置映射为 Responses API 的 tool_choice
+func applyResponsesToolChoice(req *CreateResponseRequest, cfg *genai.GenerateContentConfig) {
+	mode, allowed := functionCalling(cfg)
+	if mode == "" || len(req.Tools) == 0 {
+		return
+	}
+	if len(allowed) > 0 && mode != genai.FunctionCallingConfigModeNone {
+		req.Tools = slices.DeleteFunc(req.Tools, func(t ResponsesTool) bool {
+			return t.Type == "function" && !slices.Contains(allowed, t.Name)
+		})
+	}
+	switch mode {
+	case genai.FunctionCallingConfigModeNone:
+		req.ToolChoice = "none"
+	case genai.FunctionCallingConfigModeAny:
+		if len(req.Tools) == 1 && req.Tools[0].Type == "function" {
+			req.ToolChoice = map[string]string{"type": "function", "name": req.Tools[0].Name}
+		} else {
+			req.ToolChoice = "required"
+		}
+	case genai.FunctionCallingConfigModeAuto, genai.FunctionCallingConfigModeValidated:
+		req.ToolChoice = "auto"
+	}
+}
@@ -0,0 +1,132 @@
+// Package prompts 管理内置系统提示词与用户模板的版本
+// 新版本只需覆盖有变化的模板，未覆盖的模板沿用上一版本
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// 模板名称
+const (
+	ExpertSystem       = "expert_system"       // 专家系统指令（角色、工具规范、行情）
+	ExpertPosition     = "expert_position"     // 专家指令中的持仓段落
+	ExpertRiskProfile  = "expert_risk_profile" // 专家指令中的用户风险画像段落
+	ExpertTask         = "expert_task"         // 专家分析任务
+	ExpertReplyTask    = "expert_reply_task"   // 引用观点时的专家分析任务
+	ModeratorAnalyze   = "moderator_analyze"   // 小韭菜意图分析
+	ModeratorSummarize = "moderator_summarize" // 小韭菜总结
+)
+
+// Version 一组提示词模板的版本
+type Version struct {
+	ID        string
+	Date      string            // 发布日期
+	Note      string            // 变更说明
+	Templates map[string]string // 模板名称 -> text/template 文本
+}
+
+// VersionInfo 版本描述（供界面列出可选版本）
+type VersionInfo struct {
+	ID     string `json:"id"`
+	Date   string `json:"date"`
+	Note   string `json:"note"`
+	Latest bool   `json:"latest"`
+}
+
+// versions 按发布顺序排列，最后一个为默认版本
+var versions = []*Version{v1, v2}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]*template.Template)
+)
+
+// Latest 返回当前默认版本 ID
+func Latest() string {
+	return versions[len(versions)-1].ID
+}
+
+// Exists 判断版本是否存在
+func Exists(id string) bool {
+	return indexOf(id) >= 0
+}
+
+// Resolve 返回可用的版本 ID，为空或不存在时使用默认版本
+func Resolve(id string) string {
+	if Exists(id) {
+		return id
+	}
+	return Latest()
+}
+
+// Versions 按发布顺序列出所有版本
+func Versions() []VersionInfo {
+	latest := Latest()
+	result := make([]VersionInfo, 0, len(versions))
+	for _, v := range versions {
+		result = append(result, VersionInfo{ID: v.ID, Date: v.Date, Note: v.Note, Latest: v.ID == latest})
+	}
+	return result
+}
+
+// Render 使用指定版本渲染模板，版本为空或不存在时使用默认版本
+func Render(version, name string, data any) (string, error) {
+	tmpl, err := lookup(Resolve(version), name)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("渲染提示词 %s 失败: %w", name, err)
+	}
+	return sb.String(), nil
+}
+
+// lookup 查找并缓存已解析的模板，当前版本未覆盖时向前回溯
+func lookup(version, name string) (*template.Template, error) {
+	key := version + "/" + name
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if tmpl, ok := cache[key]; ok {
+		return tmpl, nil
+	}
+	for i := indexOf(version); i >= 0; i-- {
+		text, ok := versions[i].Templates[name]
+		if !ok {
+			continue
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("解析提示词 %s@%s 失败: %w", name, versions[i].ID, err)
+		}
+		cache[key] = tmpl
+		return tmpl, nil
+	}
+	return nil, fmt.Errorf("提示词模板不存在: %s@%s", name, version)
+}
+
+func indexOf(id string) int {
+	for i, v := range versions {
+		if v.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+type versionKey struct{}
+
+// WithVersion 在 context 中指定本次会话使用的提示词版本
+func WithVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, versionKey{}, version)
+}
+
+// FromContext 读取 context 中的提示词版本，未指定时返回默认版本
+func FromContext(ctx context.Context) string {
+	v, _ := ctx.Value(versionKey{}).(string)
+	return Resolve(v)
+}
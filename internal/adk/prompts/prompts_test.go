@@ -0,0 +1,38 @@
+package prompts
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRender_InheritsFromPreviousVersion(t *testing.T) {
+	saved := versions
+	defer func() { versions = saved }()
+	versions = append(versions[:len(versions):len(versions)], &Version{
+		ID:        "test-next",
+		Templates: map[string]string{ExpertTask: "任务: {{.Query}}"},
+	})
+
+	if got := Latest(); got != "test-next" {
+		t.Fatalf("Latest() = %q", got)
+	}
+	got, err := Render("", ExpertTask, map[string]any{"Query": "q"})
+	if err != nil || got != "任务: q" {
+		t.Fatalf("render latest = %q, %v", got, err)
+	}
+	// 未覆盖的模板沿用 v1
+	got, err = Render("test-next", ExpertReplyTask, map[string]any{"Query": "q", "ReplyContent": "r"})
+	if err != nil || !strings.HasPrefix(got, "--- 引用的观点 ---\nr\n") {
+		t.Fatalf("render inherited = %q, %v", got, err)
+	}
+	// 固定到旧版本
+	ctx := WithVersion(context.Background(), "v1")
+	got, err = Render(FromContext(ctx), ExpertTask, map[string]any{"Query": "q"})
+	if err != nil || !strings.HasPrefix(got, "你的分析任务: q") {
+		t.Fatalf("render pinned = %q, %v", got, err)
+	}
+	if FromContext(context.Background()) != "test-next" || Resolve("missing") != "test-next" {
+		t.Fatal("unknown version should resolve to latest")
+	}
+}
@@ -0,0 +1,138 @@
+package adk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/adk/model"
+)
+
+// ProviderConstructor 供应商模型构造函数
+type ProviderConstructor func(ctx context.Context, config *models.AIConfig) (model.LLM, error)
+
+// ProviderTester 供应商连通性测试函数
+type ProviderTester func(ctx context.Context, config *models.AIConfig) error
+
+// ProviderModelLister 供应商模型列表查询函数
+type ProviderModelLister func(ctx context.Context, config *models.AIConfig) ([]models.ModelInfo, error)
+
+// Provider 已注册的模型供应商
+type Provider struct {
+	ID     models.AIProvider
+	Name   string              // 展示名称
+	Create ProviderConstructor // 必填
+	Test   ProviderTester      // 可选，为空时创建模型并发送最小请求
+	List   ProviderModelLister // 可选，为空时不支持获取模型列表
+}
+
+// ProviderInfo 供应商描述（供配置界面列出可选项）
+type ProviderInfo struct {
+	ID   models.AIProvider `json:"id"`
+	Name string            `json:"name"`
+}
+
+var (
+	providersMu   sync.RWMutex
+	providers     = make(map[models.AIProvider]Provider)
+	providerOrder []models.AIProvider
+)
+
+// RegisterProvider 注册模型供应商，重复注册时覆盖已有实现
+// 第三方供应商可在自己包的 init 中调用
+func RegisterProvider(p Provider) {
+	if p.ID == "" || p.Create == nil {
+		panic("adk: RegisterProvider 需要 ID 和 Create")
+	}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	if _, exists := providers[p.ID]; !exists {
+		providerOrder = append(providerOrder, p.ID)
+	}
+	if p.Name == "" {
+		p.Name = string(p.ID)
+	}
+	providers[p.ID] = p
+}
+
+// lookupProvider 查找供应商
+func lookupProvider(id models.AIProvider) (Provider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[id]
+	if !ok {
+		return Provider{}, fmt.Errorf("unsupported provider: %s", id)
+	}
+	return p, nil
+}
+
+// SupportedProviders 按注册顺序列出支持的供应商
+func SupportedProviders() []ProviderInfo {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	result := make([]ProviderInfo, 0, len(providerOrder))
+	for _, id := range providerOrder {
+		result = append(result, ProviderInfo{ID: id, Name: providers[id].Name})
+	}
+	return result
+}
+
+// 内置供应商
+func init() {
+	f := &ModelFactory{}
+	RegisterProvider(Provider{
+		ID:   models.AIProviderOpenAI,
+		Name: "OpenAI 兼容",
+		Create: func(_ context.Context, config *models.AIConfig) (model.LLM, error) {
+			if config.UseResponses {
+				return f.createOpenAIResponsesModel(config)
+			}
+			return f.createOpenAIModel(config)
+		},
+		Test: f.testOpenAIConnection,
+		List: f.listOpenAICompatibleModels,
+	})
+	RegisterProvider(Provider{
+		ID:     models.AIProviderGemini,
+		Name:   "Google Gemini",
+		Create: f.createGeminiModel,
+		List:   f.listGeminiModels,
+	})
+	RegisterProvider(Provider{
+		ID:     models.AIProviderVertexAI,
+		Name:   "Vertex AI",
+		Create: f.createVertexAIModel,
+	})
+	RegisterProvider(Provider{
+		ID:     models.AIProviderAnthropic,
+		Name:   "Anthropic",
+		Create: withoutContext(f.createAnthropicModel),
+		Test:   f.testAnthropicConnection,
+		List:   f.listAnthropicModels,
+	})
+	RegisterProvider(Provider{
+		ID:     models.AIProviderBedrock,
+		Name:   "AWS Bedrock",
+		Create: withoutContext(f.createBedrockModel),
+	})
+	RegisterProvider(Provider{
+		ID:     models.AIProviderOpenRouter,
+		Name:   "OpenRouter",
+		Create: withoutContext(f.createOpenRouterModel),
+		List:   f.listOpenAICompatibleModels,
+	})
+	RegisterProvider(Provider{
+		ID:     models.AIProviderMistral,
+		Name:   "Mistral",
+		Create: withoutContext(f.createMistralModel),
+		List:   f.listOpenAICompatibleModels,
+	})
+}
+
+// withoutContext 适配不需要 context 的构造函数
+func withoutContext(fn func(*models.AIConfig) (model.LLM, error)) ProviderConstructor {
+	return func(_ context.Context, config *models.AIConfig) (model.LLM, error) {
+		return fn(config)
+	}
+}
@@ -0,0 +1,39 @@
+package adk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/adk/model"
+)
+
+func TestRegisterProvider_CustomProvider(t *testing.T) {
+	const id models.AIProvider = "test-custom"
+	var created bool
+	RegisterProvider(Provider{
+		ID: id,
+		Create: func(ctx context.Context, config *models.AIConfig) (model.LLM, error) {
+			created = true
+			return nil, nil
+		},
+	})
+	t.Cleanup(func() {
+		providersMu.Lock()
+		delete(providers, id)
+		providerOrder = providerOrder[:len(providerOrder)-1]
+		providersMu.Unlock()
+	})
+
+	if _, err := NewModelFactory().CreateModel(context.Background(), &models.AIConfig{Provider: id}); err != nil || !created {
+		t.Fatalf("custom provider not used: created=%v err=%v", created, err)
+	}
+
+	list := SupportedProviders()
+	if list[0].ID != models.AIProviderOpenAI || list[len(list)-1].ID != id {
+		t.Errorf("unexpected provider order: %+v", list)
+	}
+	if _, err := NewModelFactory().CreateModel(context.Background(), &models.AIConfig{Provider: "nope"}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
@@ -0,0 +1,296 @@
+// Package providererr 各供应商适配器共用的结构化错误：
+// 状态码、供应商错误码、是否可重试、限流恢复时间，供上层重试策略与界面提示使用
+package providererr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+	"github.com/run-bigpig/jcp/internal/pkg/httpclient"
+)
+
+// Error 供应商 API 错误
+type Error struct {
+	Provider   string        // 供应商名称，如 OpenAI / Anthropic / Gemini
+	StatusCode int           // HTTP 状态码，流式错误事件中为 0
+	Code       string        // 供应商错误码或错误类型，如 rate_limit_error
+	Message    string        // 供应商返回的错误信息
+	RequestID  string        // 供应商请求ID
+	Body       string        // 原始响应体开头（最多 BodySnippetSize 字节），用于排查网关返回的非标准错误
+	Retryable  bool          // 是否值得重试
+	RetryAfter time.Duration // 限流恢复时间（来自响应头），未知时为 0
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	sb.WriteString(e.Provider)
+	sb.WriteString(" API 错误")
+	switch {
+	case e.StatusCode > 0 && e.RequestID != "":
+		fmt.Fprintf(&sb, " (HTTP %d, request-id %s)", e.StatusCode, e.RequestID)
+	case e.StatusCode > 0:
+		fmt.Fprintf(&sb, " (HTTP %d)", e.StatusCode)
+	case e.RequestID != "":
+		fmt.Fprintf(&sb, " (request-id %s)", e.RequestID)
+	}
+	sb.WriteString(": ")
+	if e.Code != "" {
+		sb.WriteString("[" + e.Code + "] ")
+	}
+	sb.WriteString(e.Message)
+	return sb.String()
+}
+
+// New 按状态码与错误码构造错误并判定是否可重试
+func New(provider string, statusCode int, code, message string) *Error {
+	return &Error{
+		Provider:   provider,
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+		Retryable:  isRetryable(statusCode, code),
+	}
+}
+
+// BodySnippetSize 错误中保留的原始响应体字节数
+const BodySnippetSize = 512
+
+// FromResponse 从非 2xx 响应构造错误（读取响应体，不负责关闭）
+func FromResponse(provider string, resp *http.Response) *Error {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, httpclient.ErrorSnippetSize))
+	code, message := parseBody(httpclient.ReadErrorBody(bytes.NewReader(raw)))
+	return New(provider, resp.StatusCode, code, message).WithHeader(resp.Header).WithBody(raw)
+}
+
+// WithBody 记录原始响应体开头（按 UTF-8 边界截断）
+func (e *Error) WithBody(body []byte) *Error {
+	if len(body) > BodySnippetSize {
+		body = body[:BodySnippetSize]
+		for len(body) > 0 && !utf8.Valid(body) {
+			body = body[:len(body)-1]
+		}
+	}
+	e.Body = strings.TrimSpace(string(body))
+	return e
+}
+
+// Endpoint 去掉查询参数（可能包含 API Key）后的请求地址，用于日志
+func Endpoint(rawURL string) string {
+	if i := strings.IndexByte(rawURL, '?'); i >= 0 {
+		return rawURL[:i]
+	}
+	return rawURL
+}
+
+// WithHeader 从响应头补充请求ID与限流恢复时间
+func (e *Error) WithHeader(h http.Header) *Error {
+	e.RequestID = respmeta.RequestIDFromHeader(h)
+	e.RetryAfter = retryAfterFromHeader(h, time.Now())
+	return e
+}
+
+// errorBody 常见供应商错误响应体：
+// OpenAI {"error":{"message","type","code"}}、Anthropic {"type":"error","error":{"type","message"}}、
+// Google {"error":{"code":429,"message","status"}}
+type errorBody struct {
+	Error *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    any    `json:"code"`
+		Status  string `json:"status"`
+	} `json:"error"`
+	Message string `json:"message"`
+}
+
+// parseBody 提取错误码与错误信息，无法解析时原样返回响应体
+func parseBody(body string) (code, message string) {
+	var eb errorBody
+	if err := json.Unmarshal([]byte(body), &eb); err != nil {
+		return "", body
+	}
+	if eb.Error == nil {
+		if eb.Message != "" {
+			return "", eb.Message
+		}
+		return "", body
+	}
+	if s, ok := eb.Error.Code.(string); ok && s != "" {
+		code = s
+	} else if eb.Error.Status != "" {
+		code = eb.Error.Status
+	} else {
+		code = eb.Error.Type
+	}
+	message = eb.Error.Message
+	if message == "" {
+		message = body
+	}
+	return code, message
+}
+
+// nonRetryableCodes 状态码可重试但实际重试无意义的错误码（额度耗尽）
+var nonRetryableCodes = map[string]bool{
+	"insufficient_quota":         true,
+	"billing_hard_limit_reached": true,
+	"billing_not_active":         true,
+}
+
+// retryableCodes 与状态码无关、总是可重试的错误码（流式错误事件没有状态码）
+var retryableCodes = map[string]bool{
+	"overloaded_error":    true,
+	"rate_limit_error":    true,
+	"api_error":           true,
+	"server_error":        true,
+	"rate_limit_exceeded": true,
+	"RESOURCE_EXHAUSTED":  true,
+	"UNAVAILABLE":         true,
+	"INTERNAL":            true,
+}
+
+// isRetryable 判断错误是否可重试：限流、过载与服务端错误可重试，额度耗尽不重试
+func isRetryable(statusCode int, code string) bool {
+	if nonRetryableCodes[code] {
+		return false
+	}
+	if retryableCodes[code] {
+		return true
+	}
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	}
+	return statusCode >= 500
+}
+
+// retryAfterFromHeader 从响应头读取限流恢复时间：
+// Retry-After（秒数或 HTTP 日期）、OpenAI x-ratelimit-reset-*（如 "6m0s"）、Anthropic anthropic-ratelimit-*-reset（RFC3339）
+func retryAfterFromHeader(h http.Header, now time.Time) time.Duration {
+	if d, ok := httpclient.ParseRetryAfter(h.Get("Retry-After")); ok {
+		return d
+	}
+	var longest time.Duration
+	for _, key := range []string{"X-Ratelimit-Reset-Requests", "X-Ratelimit-Reset-Tokens"} {
+		if d, err := time.ParseDuration(h.Get(key)); err == nil && d > longest {
+			longest = d
+		}
+	}
+	for _, key := range []string{"Anthropic-Ratelimit-Requests-Reset", "Anthropic-Ratelimit-Tokens-Reset"} {
+		if at, err := time.Parse(time.RFC3339, h.Get(key)); err == nil && at.Sub(now) > longest {
+			longest = at.Sub(now)
+		}
+	}
+	return longest
+}
+
+// As 将错误转换为 *Error：识别本包错误以及 go-openai、genai SDK 的错误类型
+func As(err error) (*Error, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var pe *Error
+	if errors.As(err, &pe) {
+		return pe, true
+	}
+	var oe *openai.APIError
+	if errors.As(err, &oe) {
+		code, _ := oe.Code.(string)
+		if code == "" {
+			code = oe.Type
+		}
+		return New("OpenAI", oe.HTTPStatusCode, code, oe.Message), true
+	}
+	var re *openai.RequestError
+	if errors.As(err, &re) {
+		code, message := parseBody(string(re.Body))
+		if message == "" && re.Err != nil {
+			message = re.Err.Error()
+		}
+		return New("OpenAI", re.HTTPStatusCode, code, message), true
+	}
+	var ge genai.APIError
+	if errors.As(err, &ge) {
+		return New("Gemini", ge.Code, ge.Status, ge.Message), true
+	}
+	return nil, false
+}
+
+// Retryable 判断错误是否可重试；known 为 false 表示不是供应商错误，由调用方自行判断
+func Retryable(err error) (retryable, known bool) {
+	pe, ok := As(err)
+	if !ok {
+		return false, false
+	}
+	return pe.Retryable, true
+}
+
+// RetryAfter 返回供应商错误携带的限流恢复时间
+func RetryAfter(err error) time.Duration {
+	if pe, ok := As(err); ok {
+		return pe.RetryAfter
+	}
+	return 0
+}
+
+// UserMessage 面向用户的错误提示：供应商错误按类型给出中文说明并附带原始信息，其他错误原样返回
+func UserMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	pe, ok := As(err)
+	if !ok {
+		return err.Error()
+	}
+	hint := pe.hint()
+	if hint == "" {
+		return err.Error()
+	}
+	detail := pe.Message
+	if r := []rune(detail); len(r) > 200 {
+		detail = string(r[:200]) + "…"
+	}
+	if detail == "" {
+		return hint
+	}
+	return hint + "（" + detail + "）"
+}
+
+// hint 按状态码与错误码给出中文说明
+func (e *Error) hint() string {
+	lower := strings.ToLower(e.Code)
+	switch {
+	case lower == "insufficient_quota" || strings.HasPrefix(lower, "billing_"):
+		return "账户额度不足，请检查余额或套餐"
+	case lower == "context_length_exceeded" || strings.Contains(strings.ToLower(e.Message), "context length"):
+		return "对话内容超出模型上下文长度，请清理历史后重试"
+	case strings.Contains(lower, "content_filter") || strings.Contains(lower, "content_policy"):
+		return "内容触发了供应商的安全策略"
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden ||
+		lower == "authentication_error" || lower == "permission_error" || lower == "unauthenticated" || lower == "permission_denied":
+		return "鉴权失败，请检查 API Key 或账号权限"
+	case e.StatusCode == http.StatusNotFound || lower == "not_found_error" || lower == "model_not_found":
+		return "模型或接口不存在，请检查模型名称与 Base URL"
+	case e.StatusCode == http.StatusTooManyRequests || lower == "rate_limit_error" || lower == "rate_limit_exceeded" || e.Code == "RESOURCE_EXHAUSTED":
+		if e.RetryAfter > 0 {
+			return fmt.Sprintf("请求过于频繁，已被限流，约 %d 秒后恢复", int(e.RetryAfter.Seconds()+0.5))
+		}
+		return "请求过于频繁，已被限流，请稍后重试"
+	case e.StatusCode == 529 || e.StatusCode == http.StatusServiceUnavailable || lower == "overloaded_error" || e.Code == "UNAVAILABLE":
+		return "模型服务繁忙，请稍后重试"
+	case e.StatusCode >= 500 || lower == "api_error" || lower == "server_error":
+		return "模型服务端错误，请稍后重试"
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity || lower == "invalid_request_error":
+		return "请求被模型服务拒绝"
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package providererr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+func response(status int, body string, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestFromResponse(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    int
+		body      string
+		code      string
+		message   string
+		retryable bool
+	}{
+		{"openai quota", 429, `{"error":{"message":"You exceeded your quota","type":"insufficient_quota","code":"insufficient_quota"}}`, "insufficient_quota", "You exceeded your quota", false},
+		{"openai rate limit", 429, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`, "rate_limit_exceeded", "Rate limit reached", true},
+		{"anthropic overloaded", 529, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, "overloaded_error", "Overloaded", true},
+		{"anthropic auth", 401, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, "authentication_error", "invalid x-api-key", false},
+		{"google", 429, `{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED"}}`, "RESOURCE_EXHAUSTED", "Resource has been exhausted", true},
+		{"plain text", 502, `Bad Gateway`, "", "Bad Gateway", true},
+	}
+	for _, c := range cases {
+		e := FromResponse("P", response(c.status, c.body, nil))
+		if e.Code != c.code || e.Message != c.message || e.Retryable != c.retryable {
+			t.Errorf("%s: got code=%q message=%q retryable=%v", c.name, e.Code, e.Message, e.Retryable)
+		}
+	}
+}
+
+func TestFromResponse_RequestIDAndBodySnippet(t *testing.T) {
+	body := "<html><title>502 Bad Gateway</title>" + strings.Repeat("网关", 400) + "</html>"
+	e := FromResponse("Anthropic", response(502, body, http.Header{"Request-Id": {"req_123"}}))
+	if e.RequestID != "req_123" || !strings.Contains(e.Error(), "request-id req_123") {
+		t.Fatalf("Error() = %q", e.Error())
+	}
+	if len(e.Body) > BodySnippetSize || !strings.HasPrefix(e.Body, "<html><title>502") || !utf8.ValidString(e.Body) {
+		t.Fatalf("Body = %q", e.Body)
+	}
+	if got := Endpoint("https://example.com/v1beta/models/x:generate?key=secret"); got != "https://example.com/v1beta/models/x:generate" {
+		t.Fatalf("Endpoint = %q", got)
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := http.Header{}
+	h.Set("X-Ratelimit-Reset-Requests", "1s")
+	h.Set("X-Ratelimit-Reset-Tokens", "6m0s")
+	if d := retryAfterFromHeader(h, now); d != 6*time.Minute {
+		t.Errorf("openai reset = %v", d)
+	}
+	h = http.Header{}
+	h.Set("Anthropic-Ratelimit-Requests-Reset", now.Add(30*time.Second).Format(time.RFC3339))
+	if d := retryAfterFromHeader(h, now); d != 30*time.Second {
+		t.Errorf("anthropic reset = %v", d)
+	}
+	h.Set("Retry-After", "7")
+	if d := retryAfterFromHeader(h, now); d != 7*time.Second {
+		t.Errorf("retry-after = %v", d)
+	}
+}
+
+func TestAs(t *testing.T) {
+	wrapped := fmt.Errorf("agent failed: %w", &openai.APIError{HTTPStatusCode: 429, Code: "insufficient_quota", Message: "quota"})
+	if retryable, known := Retryable(wrapped); !known || retryable {
+		t.Errorf("openai quota: retryable=%v known=%v", retryable, known)
+	}
+	if retryable, known := Retryable(genai.APIError{Code: 503, Status: "UNAVAILABLE", Message: "busy"}); !known || !retryable {
+		t.Errorf("genai unavailable: retryable=%v known=%v", retryable, known)
+	}
+	if _, known := Retryable(errors.New("boom")); known {
+		t.Error("plain error should be unknown")
+	}
+
+	if msg := UserMessage(wrapped); msg != "账户额度不足，请检查余额或套餐（quota）" {
+		t.Errorf("UserMessage = %q", msg)
+	}
+	if msg := UserMessage(errors.New("boom")); msg != "boom" {
+		t.Errorf("UserMessage(plain) = %q", msg)
+	}
+}
@@ -0,0 +1,193 @@
+package adk
+
+import (
+	"context"
+	"iter"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"github.com/run-bigpig/jcp/internal/pkg/redact"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// responseRetriever 支持按响应ID取回结果的模型，包装后需保留该能力
+type responseRetriever interface {
+	RetrieveResponse(ctx context.Context, responseID string) (*model.LLMResponse, error)
+}
+
+// wrapRedaction 启用脱敏时包装模型：请求内容脱敏，响应中的占位符还原
+// 本地部署的模型（回环/内网地址）不做处理
+func wrapRedaction(config *models.AIConfig, llm model.LLM) model.LLM {
+	mgr := redact.GetManager()
+	if !mgr.Enabled() || isLocalEndpoint(config.BaseURL) {
+		return llm
+	}
+	rm := &redactingModel{LLM: llm, redactor: mgr.NewRedactor()}
+	if retriever, ok := llm.(responseRetriever); ok {
+		return &redactingRetrieverModel{redactingModel: rm, retriever: retriever}
+	}
+	return rm
+}
+
+// isLocalEndpoint 判断 BaseURL 是否指向本机或内网
+func isLocalEndpoint(baseURL string) bool {
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		return false
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate())
+}
+
+// redactingModel 脱敏包装
+type redactingModel struct {
+	model.LLM
+	redactor *redact.Redactor
+}
+
+// GenerateContent 实现 model.LLM 接口
+func (m *redactingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		text := m.redactor.NewStreamRestorer()
+		thought := m.redactor.NewStreamRestorer()
+
+		for resp, err := range m.LLM.GenerateContent(ctx, m.redactRequest(req), stream) {
+			if err != nil || resp == nil {
+				if !yield(resp, err) {
+					return
+				}
+				continue
+			}
+			if !resp.Partial {
+				// 聚合响应前补发暂缓的片段
+				if flushed := flushPartial(text.Flush(), thought.Flush()); flushed != nil {
+					if !yield(flushed, nil) {
+						return
+					}
+				}
+			}
+			if !yield(m.restoreResponse(resp, text, thought), nil) {
+				return
+			}
+		}
+	}
+}
+
+// redactRequest 复制请求并脱敏系统指令、消息文本、工具参数与工具结果
+func (m *redactingModel) redactRequest(req *model.LLMRequest) *model.LLMRequest {
+	out := *req
+	out.Contents = make([]*genai.Content, len(req.Contents))
+	for i, content := range req.Contents {
+		out.Contents[i] = m.redactContent(content)
+	}
+	if req.Config != nil && req.Config.SystemInstruction != nil {
+		cfg := *req.Config
+		cfg.SystemInstruction = m.redactContent(req.Config.SystemInstruction)
+		out.Config = &cfg
+	}
+	return &out
+}
+
+func (m *redactingModel) redactContent(content *genai.Content) *genai.Content {
+	if content == nil {
+		return nil
+	}
+	out := &genai.Content{Role: content.Role, Parts: make([]*genai.Part, len(content.Parts))}
+	for i, part := range content.Parts {
+		if part == nil {
+			continue
+		}
+		p := *part
+		p.Text = m.redactor.Redact(part.Text)
+		if fc := part.FunctionCall; fc != nil {
+			cp := *fc
+			cp.Args, _ = m.redactor.RedactValue(fc.Args).(map[string]any)
+			p.FunctionCall = &cp
+		}
+		if fr := part.FunctionResponse; fr != nil {
+			cp := *fr
+			cp.Response, _ = m.redactor.RedactToolResult(fr.Response).(map[string]any)
+			p.FunctionResponse = &cp
+		}
+		out.Parts[i] = &p
+	}
+	return out
+}
+
+// restoreResponse 还原响应中的占位符；流式片段经 StreamRestorer 处理跨片段的占位符
+func (m *redactingModel) restoreResponse(resp *model.LLMResponse, text, thought *redact.StreamRestorer) *model.LLMResponse {
+	if resp.Content == nil {
+		return resp
+	}
+	out := *resp
+	out.Content = &genai.Content{Role: resp.Content.Role, Parts: make([]*genai.Part, 0, len(resp.Content.Parts))}
+	for _, part := range resp.Content.Parts {
+		if part == nil {
+			continue
+		}
+		p := *part
+		switch {
+		case resp.Partial && part.Thought:
+			p.Text = thought.Write(part.Text)
+		case resp.Partial:
+			p.Text = text.Write(part.Text)
+		default:
+			p.Text = m.redactor.Restore(part.Text)
+		}
+		if fc := part.FunctionCall; fc != nil {
+			cp := *fc
+			cp.Args, _ = m.redactor.RestoreValue(fc.Args).(map[string]any)
+			p.FunctionCall = &cp
+		}
+		// 整段被暂缓的纯文本片段不输出
+		if part.Text != "" && p.Text == "" && p.FunctionCall == nil {
+			continue
+		}
+		out.Content.Parts = append(out.Content.Parts, &p)
+	}
+	return &out
+}
+
+// flushPartial 构造补发暂缓内容的流式片段，无内容时返回 nil
+func flushPartial(text, thought string) *model.LLMResponse {
+	var parts []*genai.Part
+	if thought != "" {
+		parts = append(parts, &genai.Part{Text: thought, Thought: true})
+	}
+	if text != "" {
+		parts = append(parts, &genai.Part{Text: text})
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return &model.LLMResponse{
+		Content: &genai.Content{Role: genai.RoleModel, Parts: parts},
+		Partial: true,
+	}
+}
+
+// redactingRetrieverModel 保留 RetrieveResponse 能力的脱敏包装
+type redactingRetrieverModel struct {
+	*redactingModel
+	retriever responseRetriever
+}
+
+// RetrieveResponse 取回响应并还原占位符
+func (m *redactingRetrieverModel) RetrieveResponse(ctx context.Context, responseID string) (*model.LLMResponse, error) {
+	resp, err := m.retriever.RetrieveResponse(ctx, responseID)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	return m.restoreResponse(resp, nil, nil), nil
+}
@@ -0,0 +1,65 @@
+package adk
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// RequestSnapshot 发往模型的请求快照（工具筛选之后、发送之前），用于事后重放与提示词调试
+type RequestSnapshot struct {
+	Contents []*genai.Content             `json:"contents"`
+	Config   *genai.GenerateContentConfig `json:"config,omitempty"`
+}
+
+// Request 还原为 LLMRequest
+func (s *RequestSnapshot) Request(modelName string) *model.LLMRequest {
+	return &model.LLMRequest{Model: modelName, Contents: s.Contents, Config: s.Config}
+}
+
+// RequestCapture 记录一次专家发言中最后一次模型请求（多轮工具调用时为产生最终回答的那次）
+type RequestCapture struct {
+	mu   sync.Mutex
+	last json.RawMessage
+}
+
+type requestCaptureKey struct{}
+
+// WithRequestCapture 在 context 中挂载请求记录器
+func WithRequestCapture(ctx context.Context, c *RequestCapture) context.Context {
+	return context.WithValue(ctx, requestCaptureKey{}, c)
+}
+
+// Last 返回最后一次请求的 JSON 快照，未记录时为 nil
+func (c *RequestCapture) Last() json.RawMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+// record 序列化请求（立即序列化，避免后续回调修改请求对象）
+func (c *RequestCapture) record(req *model.LLMRequest) {
+	data, err := json.Marshal(RequestSnapshot{Contents: req.Contents, Config: req.Config})
+	if err != nil {
+		log.Warn("记录请求快照失败: %v", err)
+		return
+	}
+	c.mu.Lock()
+	c.last = data
+	c.mu.Unlock()
+}
+
+// requestCaptureCallback 请求发出前记录快照，需放在其他 BeforeModelCallback 之后
+func requestCaptureCallback() llmagent.BeforeModelCallback {
+	return func(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+		if c, ok := ctx.Value(requestCaptureKey{}).(*RequestCapture); ok && req != nil {
+			c.record(req)
+		}
+		return nil, nil
+	}
+}
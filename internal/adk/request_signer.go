@@ -0,0 +1,234 @@
+package adk
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"github.com/run-bigpig/jcp/internal/pkg/httpclient"
+)
+
+// RequestMutator 请求发出前的修改钩子（签名、附加头等）
+type RequestMutator interface {
+	Mutate(req *http.Request) error
+}
+
+// RequestMutatorFunc 函数形式的 RequestMutator
+type RequestMutatorFunc func(req *http.Request) error
+
+// Mutate 实现 RequestMutator
+func (f RequestMutatorFunc) Mutate(req *http.Request) error {
+	return f(req)
+}
+
+// RequestMutatorFactory 根据签名配置创建 RequestMutator
+type RequestMutatorFactory func(cfg *models.RequestSigningConfig) (RequestMutator, error)
+
+var (
+	mutatorFactories   = map[string]RequestMutatorFactory{}
+	mutatorFactoriesMu sync.RWMutex
+)
+
+// 签名方式
+const (
+	SigningHMACSHA256 = "hmac-sha256"
+	SigningTimestamp  = "timestamp"
+)
+
+func init() {
+	RegisterRequestMutator(SigningHMACSHA256, newHMACSigner)
+	RegisterRequestMutator(SigningTimestamp, newTimestampSigner)
+}
+
+// RegisterRequestMutator 注册自定义签名方式
+func RegisterRequestMutator(name string, factory RequestMutatorFactory) {
+	mutatorFactoriesMu.Lock()
+	defer mutatorFactoriesMu.Unlock()
+	mutatorFactories[name] = factory
+}
+
+// buildRequestMutator 根据 AI 配置创建签名钩子，未配置时返回 nil
+func buildRequestMutator(config *models.AIConfig) (RequestMutator, error) {
+	if config == nil || config.Signing == nil || config.Signing.Type == "" {
+		return nil, nil
+	}
+	mutatorFactoriesMu.RLock()
+	factory, ok := mutatorFactories[config.Signing.Type]
+	mutatorFactoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing type: %s", config.Signing.Type)
+	}
+	return factory(config.Signing)
+}
+
+// signingTransport 在发送前执行 RequestMutator
+type signingTransport struct {
+	base    http.RoundTripper
+	mutator RequestMutator
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper 不应修改原始请求
+	req = req.Clone(req.Context())
+	if err := t.mutator.Mutate(req); err != nil {
+		return nil, fmt.Errorf("request signing failed: %w", err)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// rateLimiter 返回 AI 配置的共享限流器，未配置限额时返回 nil
+func rateLimiter(config *models.AIConfig) *httpclient.Limiter {
+	if config == nil {
+		return nil
+	}
+	key := config.ID
+	if key == "" {
+		key = string(config.Provider) + "|" + config.BaseURL + "|" + config.ModelName
+	}
+	return httpclient.SharedLimiter(key, config.RequestsPerMinute, config.TokensPerMinute)
+}
+
+// newTransport 创建带抓包、UA、请求签名、多 Key 轮换、限流、失败重试和流式空闲超时的 Transport
+func (f *ModelFactory) newTransport(config *models.AIConfig) (http.RoundTripper, error) {
+	base, err := httpclient.NewTransport(httpOptions(config))
+	if err != nil {
+		return nil, err
+	}
+	// 限制非流式响应体大小，防止异常网关返回超大页面
+	var rt http.RoundTripper = &httpclient.BodyLimitTransport{Base: base}
+	// 抓包在最内层，记录签名与 Key 轮换后实际发出的请求
+	rt = newCaptureTransport(rt, config)
+	rt = &uaTransport{base: rt}
+	if config != nil && config.Provider == models.AIProviderOpenAI && config.KeepAlive != "" && isOllamaBaseURL(config.BaseURL) {
+		rt = &keepAliveTransport{base: rt, ollamaURL: ollamaBaseURL(config.BaseURL), model: config.ModelName, keepAlive: config.KeepAlive}
+	}
+	mutator, err := buildRequestMutator(config)
+	if err != nil {
+		return nil, err
+	}
+	if mutator != nil {
+		rt = &signingTransport{base: rt, mutator: mutator}
+	}
+	// 多 Key 轮换在重试之内，429 后的重试会换用其他 Key
+	if pool := sharedKeyPool(config); pool != nil {
+		rt = &keyPoolTransport{base: rt, primary: strings.TrimSpace(config.APIKey), pool: pool}
+	}
+	// 限流在重试之内，每次重试都消耗配额
+	if limiter := rateLimiter(config); limiter != nil {
+		rt = &httpclient.RateLimitTransport{Base: rt, Limiter: limiter}
+	}
+	// 重试在签名之外，每次重试都会重新签名
+	if config != nil {
+		rt = httpclient.NewRetryTransport(rt, config.MaxAttempts)
+	}
+	// 空闲超时未配置时仍然挂载，以便请求截止时给出“流式响应停滞”的说明
+	if config != nil {
+		rt = &idleTimeoutTransport{base: rt, timeout: time.Duration(config.StreamIdleTimeout) * time.Second}
+	}
+	return rt, nil
+}
+
+// newHTTPClient 创建供应商共用的 HTTP Client
+func (f *ModelFactory) newHTTPClient(config *models.AIConfig) (*http.Client, error) {
+	rt, err := f.newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: rt}, nil
+}
+
+// httpOptions 将 AI 配置中的代理、超时与 TLS 选项转换为 httpclient 选项
+func httpOptions(config *models.AIConfig) httpclient.Options {
+	if config == nil {
+		return httpclient.Options{}
+	}
+	opts := httpclient.Options{
+		InsecureSkipVerify: config.TLSInsecureSkipVerify,
+		CACertPEM:          strings.TrimSpace(config.TLSCACert),
+	}
+	if config.HttpProxyEnabled {
+		opts.ProxyURL = strings.TrimSpace(config.HttpProxy)
+	}
+	if config.Timeout > 0 {
+		timeout := time.Duration(config.Timeout) * time.Second
+		opts.ReadTimeout = timeout
+		opts.DialTimeout = min(timeout, maxDialTimeout)
+	}
+	return opts
+}
+
+// maxDialTimeout 建立连接的超时上限
+const maxDialTimeout = 30 * time.Second
+
+// headerOrDefault 返回配置的请求头名称或默认值
+func headerOrDefault(name, def string) string {
+	if name != "" {
+		return name
+	}
+	return def
+}
+
+// signingNow 签名时间戳的时钟，测试中替换为固定时间
+var signingNow = time.Now
+
+// applyStaticHeaders 写入时间戳、密钥标识和附加头，返回时间戳
+func applyStaticHeaders(req *http.Request, cfg *models.RequestSigningConfig) string {
+	ts := strconv.FormatInt(signingNow().Unix(), 10)
+	req.Header.Set(headerOrDefault(cfg.TimestampHeader, "X-Timestamp"), ts)
+	if cfg.KeyID != "" {
+		req.Header.Set(headerOrDefault(cfg.KeyIDHeader, "X-Key-Id"), cfg.KeyID)
+	}
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	return ts
+}
+
+// newTimestampSigner 仅附加时间戳和固定头
+func newTimestampSigner(cfg *models.RequestSigningConfig) (RequestMutator, error) {
+	return RequestMutatorFunc(func(req *http.Request) error {
+		applyStaticHeaders(req, cfg)
+		return nil
+	}), nil
+}
+
+// newHMACSigner HMAC-SHA256 签名
+// 签名串: METHOD\nPATH\nTIMESTAMP\nhex(sha256(body))
+func newHMACSigner(cfg *models.RequestSigningConfig) (RequestMutator, error) {
+	if cfg.Secret == "" {
+		return nil, fmt.Errorf("hmac signing requires secret")
+	}
+	return RequestMutatorFunc(func(req *http.Request) error {
+		ts := applyStaticHeaders(req, cfg)
+
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+		bodyHash := sha256.Sum256(body)
+
+		payload := req.Method + "\n" + req.URL.EscapedPath() + "\n" + ts + "\n" + hex.EncodeToString(bodyHash[:])
+		mac := hmac.New(sha256.New, []byte(cfg.Secret))
+		mac.Write([]byte(payload))
+		req.Header.Set(headerOrDefault(cfg.SignatureHeader, "X-Signature"), hex.EncodeToString(mac.Sum(nil)))
+		return nil
+	}), nil
+}
@@ -0,0 +1,178 @@
+package adk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// fixedSigningClock 将签名时钟固定为 start，每次调用前进 1 秒
+func fixedSigningClock(t *testing.T, start int64) {
+	t.Helper()
+	var calls atomic.Int64
+	prev := signingNow
+	signingNow = func() time.Time { return time.Unix(start+calls.Add(1)-1, 0) }
+	t.Cleanup(func() { signingNow = prev })
+}
+
+func TestRequestSigners(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     models.RequestSigningConfig
+		method  string
+		url     string
+		body    string
+		headers map[string]string
+	}{
+		{
+			name:   "hmac post",
+			cfg:    models.RequestSigningConfig{Type: SigningHMACSHA256, Secret: "s3cret", KeyID: "k-1"},
+			method: "POST",
+			url:    "https://api.example.com/v1/chat/completions",
+			body:   `{"model":"m"}`,
+			headers: map[string]string{
+				"X-Timestamp": "1700000000",
+				"X-Key-Id":    "k-1",
+				"X-Signature": "6b27e30355f9b984ff97bf52bb6c82c1b3e2a2662d26aff3216bc849219a300f",
+			},
+		},
+		{
+			name:   "hmac empty body",
+			cfg:    models.RequestSigningConfig{Type: SigningHMACSHA256, Secret: "s3cret"},
+			method: "GET",
+			url:    "https://api.example.com/v1/models",
+			headers: map[string]string{
+				"X-Timestamp": "1700000000",
+				"X-Key-Id":    "",
+				"X-Signature": "f02242f17bda9a1bf4865a7ae639f3710036ae4ae4c22abba4cc3926ca2d7c75",
+			},
+		},
+		{
+			name: "hmac custom headers and escaped path",
+			cfg: models.RequestSigningConfig{
+				Type: SigningHMACSHA256, Secret: "s3cret",
+				SignatureHeader: "X-Sign", TimestampHeader: "X-Ts",
+				Headers: map[string]string{"X-App": "jcp"},
+			},
+			method: "POST",
+			url:    "https://api.example.com/v1/a%20b",
+			body:   "x",
+			headers: map[string]string{
+				"X-Ts":        "1700000000",
+				"X-App":       "jcp",
+				"X-Sign":      "797309048a4d039c5a789c1cd5be418573f7a9cf6b2e2c9bea399148e63c577f",
+				"X-Signature": "",
+			},
+		},
+		{
+			name:   "timestamp only",
+			cfg:    models.RequestSigningConfig{Type: SigningTimestamp, KeyID: "k-2", KeyIDHeader: "X-Client"},
+			method: "POST",
+			url:    "https://api.example.com/v1/chat/completions",
+			body:   `{"model":"m"}`,
+			headers: map[string]string{
+				"X-Timestamp": "1700000000",
+				"X-Client":    "k-2",
+				"X-Signature": "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixedSigningClock(t, 1700000000)
+			mutator, err := buildRequestMutator(&models.AIConfig{Signing: &tt.cfg})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req, _ := http.NewRequest(tt.method, tt.url, body)
+			if err := mutator.Mutate(req); err != nil {
+				t.Fatal(err)
+			}
+			for k, want := range tt.headers {
+				if got := req.Header.Get(k); got != want {
+					t.Errorf("%s = %q, want %q", k, got, want)
+				}
+			}
+			if tt.body != "" {
+				got, _ := io.ReadAll(req.Body)
+				if string(got) != tt.body {
+					t.Errorf("body after signing = %q, want %q", got, tt.body)
+				}
+			}
+		})
+	}
+
+	if _, err := buildRequestMutator(&models.AIConfig{Signing: &models.RequestSigningConfig{Type: SigningHMACSHA256}}); err == nil {
+		t.Error("hmac without secret should fail")
+	}
+	if _, err := buildRequestMutator(&models.AIConfig{Signing: &models.RequestSigningConfig{Type: "unknown"}}); err == nil {
+		t.Error("unknown signing type should fail")
+	}
+}
+
+func TestNewTransport_RetryResignsWithFreshTimestamp(t *testing.T) {
+	fixedSigningClock(t, 1700000000)
+	const secret = "s3cret"
+	type attempt struct{ ts, sig, body string }
+	var attempts []attempt
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		attempts = append(attempts, attempt{r.Header.Get("X-Timestamp"), r.Header.Get("X-Signature"), string(body)})
+		if len(attempts) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	config := &models.AIConfig{
+		ID:          t.Name(),
+		Provider:    models.AIProviderOpenAI,
+		MaxAttempts: 3,
+		Signing:     &models.RequestSigningConfig{Type: SigningHMACSHA256, Secret: secret},
+	}
+	rt, err := NewModelFactory().newTransport(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", srv.URL+"/v1/chat/completions", strings.NewReader(`{"model":"m"}`))
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	if len(attempts) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(attempts))
+	}
+	if attempts[0].ts == attempts[1].ts {
+		t.Errorf("retry reused timestamp %s, signer must sit inside the retry transport", attempts[0].ts)
+	}
+	for i, a := range attempts {
+		sum := sha256.Sum256([]byte(a.body))
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("POST\n/v1/chat/completions\n" + a.ts + "\n" + hex.EncodeToString(sum[:])))
+		if want := hex.EncodeToString(mac.Sum(nil)); a.sig != want {
+			t.Errorf("attempt %d signature = %s, want %s", i+1, a.sig, want)
+		}
+		if a.body != `{"model":"m"}` {
+			t.Errorf("attempt %d body = %q", i+1, a.body)
+		}
+	}
+}
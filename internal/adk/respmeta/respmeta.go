@@ -0,0 +1,140 @@
+// Package respmeta 在 LLMResponse.CustomMetadata 中传递供应商响应元数据
+// （响应ID、请求ID、实际模型版本、原始结束原因），便于向供应商提交工单时定位具体请求。
+package respmeta
+
+import "net/http"
+
+// CustomMetadata 中使用的键
+const (
+	KeyResponseID   = "response_id"
+	KeyRequestID    = "request_id"
+	KeyModelVersion = "model_version"
+	KeyStopReason   = "stop_reason"
+)
+
+// StopReasonPauseTurn 服务端工具执行过久时回合被暂停（Anthropic），需把回答发回让模型继续
+const StopReasonPauseTurn = "pause_turn"
+
+// requestIDHeaders 常见供应商的请求ID响应头（按优先级）
+var requestIDHeaders = []string{
+	"X-Request-Id",
+	"Request-Id",
+	"X-Amzn-Requestid",
+	"X-Goog-Request-Id",
+	"Cf-Ray",
+}
+
+// Meta 响应元数据
+type Meta struct {
+	ResponseID   string
+	RequestID    string
+	ModelVersion string
+	StopReason   string // 供应商原始的结束原因（genai.FinishReason 无法区分时使用，如 tool_use、pause_turn、refusal）
+}
+
+// RequestIDFromHeader 从响应头提取请求ID
+func RequestIDFromHeader(h http.Header) string {
+	if h == nil {
+		return ""
+	}
+	for _, key := range requestIDHeaders {
+		if v := h.Get(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// IsZero 判断是否没有任何元数据
+func (m Meta) IsZero() bool {
+	return m.ResponseID == "" && m.RequestID == "" && m.ModelVersion == "" && m.StopReason == ""
+}
+
+// Map 转换为 CustomMetadata，无数据时返回 nil
+func (m Meta) Map() map[string]any {
+	if m.IsZero() {
+		return nil
+	}
+	result := make(map[string]any, 4)
+	if m.ResponseID != "" {
+		result[KeyResponseID] = m.ResponseID
+	}
+	if m.RequestID != "" {
+		result[KeyRequestID] = m.RequestID
+	}
+	if m.ModelVersion != "" {
+		result[KeyModelVersion] = m.ModelVersion
+	}
+	if m.StopReason != "" {
+		result[KeyStopReason] = m.StopReason
+	}
+	return result
+}
+
+// Merge 将元数据写入已有的 CustomMetadata（不覆盖已有键）
+func (m Meta) Merge(dst map[string]any) map[string]any {
+	src := m.Map()
+	if src == nil {
+		return dst
+	}
+	if dst == nil {
+		return src
+	}
+	for k, v := range src {
+		if _, ok := dst[k]; !ok {
+			dst[k] = v
+		}
+	}
+	return dst
+}
+
+// FromCustomMetadata 从 CustomMetadata 读取元数据
+func FromCustomMetadata(md map[string]any) Meta {
+	str := func(key string) string {
+		s, _ := md[key].(string)
+		return s
+	}
+	return Meta{
+		ResponseID:   str(KeyResponseID),
+		RequestID:    str(KeyRequestID),
+		ModelVersion: str(KeyModelVersion),
+		StopReason:   str(KeyStopReason),
+	}
+}
+
+// KeyToolCallDelta 流式工具调用进度在 CustomMetadata 中的键
+const KeyToolCallDelta = "tool_call_delta"
+
+// ToolCallDelta 流式生成中的工具调用（参数尚未完整）
+// ADK 会执行响应中的所有 FunctionCall（包括 Partial 响应），因此进度只能通过元数据传递
+type ToolCallDelta struct {
+	ID        string
+	Name      string
+	Arguments string // 截至目前已生成的参数 JSON 片段
+}
+
+// Map 转换为 CustomMetadata
+func (d ToolCallDelta) Map() map[string]any {
+	return map[string]any{KeyToolCallDelta: d}
+}
+
+// ToolCallDeltaFromCustomMetadata 从 CustomMetadata 读取工具调用进度
+func ToolCallDeltaFromCustomMetadata(md map[string]any) (ToolCallDelta, bool) {
+	d, ok := md[KeyToolCallDelta].(ToolCallDelta)
+	return d, ok
+}
+
+// StreamError 流式响应中途中断的错误，携带中断前已获得的响应元数据，
+// 上层可据此按响应ID取回结果或发起续写
+type StreamError struct {
+	Err  error
+	Meta Meta
+}
+
+func (e *StreamError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
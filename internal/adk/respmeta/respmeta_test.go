@@ -0,0 +1,25 @@
+package respmeta
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMetaRoundTrip(t *testing.T) {
+	h := http.Header{}
+	h.Set("Request-Id", "req_anthropic")
+	h.Set("X-Request-Id", "req_openai")
+
+	m := Meta{ResponseID: "msg_1", RequestID: RequestIDFromHeader(h), ModelVersion: "claude-x-20250101"}
+	if m.RequestID != "req_openai" {
+		t.Errorf("RequestID = %q, 期望优先使用 x-request-id", m.RequestID)
+	}
+
+	got := FromCustomMetadata(m.Merge(map[string]any{"other": 1}))
+	if got != m {
+		t.Errorf("FromCustomMetadata = %+v, 期望 %+v", got, m)
+	}
+	if (Meta{}).Map() != nil {
+		t.Error("空元数据应返回 nil")
+	}
+}
@@ -0,0 +1,369 @@
+// Package schemasanitize 按供应商清洗工具参数与结构化输出的 JSON Schema
+// MCP 服务透传的 schema 常含 $ref、oneOf、const、format 等关键字，各供应商支持的子集不同，
+// 由 Profile 描述每个供应商需要的转换，各适配层按自己的 Profile 调用
+package schemasanitize
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Profile 供应商的 schema 兼容规则
+type Profile struct {
+	Name string
+	// InlineRefs 展开本地 $ref（#/$defs/、#/definitions/），并移除定义表
+	InlineRefs bool
+	// MergeAllOf 把 allOf 各分支合并到当前节点
+	MergeAllOf bool
+	// OneOfToAnyOf oneOf 改为 anyOf
+	OneOfToAnyOf bool
+	// ConstToEnum const 改为单值 enum
+	ConstToEnum bool
+	// DropNullDefault 移除 default: null
+	DropNullDefault bool
+	// FlattenNullable 去掉 anyOf 中的 {"type":"null"} 分支与 type 数组中的 "null"，只剩一个分支时展平
+	FlattenNullable bool
+	// Formats 允许保留的 format，nil 表示全部保留
+	Formats []string
+	// DropKeywords 直接移除的关键字
+	DropKeywords []string
+}
+
+// 内置的供应商规则
+var (
+	// Anthropic input_schema 不接受 const 与 default: null，nullable 的 anyOf 会导致参数被忽略
+	Anthropic = Profile{
+		Name:            "anthropic",
+		ConstToEnum:     true,
+		DropNullDefault: true,
+		FlattenNullable: true,
+	}
+	// OpenAI 严格模式及多数兼容网关不支持 $ref、oneOf、allOf 与大部分 format
+	OpenAI = Profile{
+		Name:            "openai",
+		InlineRefs:      true,
+		MergeAllOf:      true,
+		OneOfToAnyOf:    true,
+		DropNullDefault: true,
+		Formats:         []string{"date-time", "time", "date", "duration", "email", "hostname", "ipv4", "ipv6", "uuid"},
+		DropKeywords:    []string{"$schema", "$id"},
+	}
+	// Gemini 只接受 JSON Schema 的一个子集，遇到未知关键字会直接拒绝请求
+	Gemini = Profile{
+		Name:            "gemini",
+		InlineRefs:      true,
+		MergeAllOf:      true,
+		OneOfToAnyOf:    true,
+		ConstToEnum:     true,
+		DropNullDefault: true,
+		FlattenNullable: true,
+		Formats:         []string{"date-time", "date", "time", "enum"},
+		DropKeywords: []string{
+			"$schema", "$id", "$comment", "default", "examples", "patternProperties",
+			"exclusiveMinimum", "exclusiveMaximum", "multipleOf", "not", "if", "then", "else",
+			"dependentRequired", "dependentSchemas", "unevaluatedProperties", "contentEncoding", "contentMediaType",
+		},
+	}
+)
+
+// maxRefDepth $ref 展开的最大嵌套深度，超过（或遇到循环引用）时替换为通用对象
+const maxRefDepth = 8
+
+// Sanitize 按规则清洗 schema（map、结构体或 JSON 均可），返回新的 map；schema 不是对象时返回 nil
+func Sanitize(schema any, p Profile) map[string]any {
+	var node map[string]any
+	switch v := schema.(type) {
+	case nil:
+		return nil
+	case json.RawMessage:
+		if json.Unmarshal(v, &node) != nil {
+			return nil
+		}
+	default:
+		data, err := json.Marshal(v)
+		if err != nil || json.Unmarshal(data, &node) != nil {
+			return nil
+		}
+	}
+	if node == nil {
+		return nil
+	}
+	s := &sanitizer{p: p, root: node, formats: toSet(p.Formats), drop: toSet(p.DropKeywords)}
+	s.walk(node, nil)
+	if p.InlineRefs {
+		delete(node, "$defs")
+		delete(node, "definitions")
+	}
+	return node
+}
+
+// SanitizeJSON 清洗 JSON 形式的 schema，解析失败时原样返回错误
+func SanitizeJSON(raw json.RawMessage, p Profile) (json.RawMessage, error) {
+	var probe map[string]any
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return raw, err
+	}
+	return json.Marshal(Sanitize(raw, p))
+}
+
+type sanitizer struct {
+	p       Profile
+	root    map[string]any
+	formats map[string]bool
+	drop    map[string]bool
+}
+
+// walk 清洗一个节点，refs 为当前路径上正在展开的引用（用于检测循环）
+func (s *sanitizer) walk(node map[string]any, refs []string) {
+	// 展平 nullable 分支后节点可能带入新的 $ref、const 等，重新处理直到稳定
+	for {
+		if s.p.InlineRefs {
+			refs = s.inlineRef(node, refs)
+		}
+		if s.p.MergeAllOf {
+			// allOf 分支先清洗（展开引用）再合并
+			for _, child := range schemaList(node["allOf"]) {
+				s.walk(child, refs)
+			}
+			mergeAllOf(node)
+		}
+		if s.p.OneOfToAnyOf {
+			if oneOf, ok := node["oneOf"]; ok {
+				if _, exists := node["anyOf"]; !exists {
+					node["anyOf"] = oneOf
+				}
+				delete(node, "oneOf")
+			}
+		}
+		if s.p.ConstToEnum {
+			if val, ok := node["const"]; ok {
+				node["enum"] = []any{val}
+				delete(node, "const")
+			}
+		}
+		if s.p.DropNullDefault {
+			if v, ok := node["default"]; ok && v == nil {
+				delete(node, "default")
+			}
+		}
+		if !s.p.FlattenNullable || !flattenNullable(node) {
+			break
+		}
+	}
+	if f, ok := node["format"].(string); ok && s.formats != nil && !s.formats[f] {
+		delete(node, "format")
+	}
+	for k := range s.drop {
+		delete(node, k)
+	}
+
+	// 递归处理子 schema
+	for _, key := range []string{"properties", "patternProperties", "$defs", "definitions"} {
+		if props, ok := node[key].(map[string]any); ok {
+			for _, v := range props {
+				if m, ok := v.(map[string]any); ok {
+					s.walk(m, refs)
+				}
+			}
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if m, ok := node[key].(map[string]any); ok {
+			s.walk(m, refs)
+		}
+	}
+	for _, key := range []string{"items", "prefixItems", "anyOf", "oneOf", "allOf"} {
+		for _, child := range schemaList(node[key]) {
+			s.walk(child, refs)
+		}
+	}
+}
+
+// inlineRef 把节点中的 $ref 替换为引用的定义（同级关键字优先），返回更新后的引用路径
+func (s *sanitizer) inlineRef(node map[string]any, refs []string) []string {
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return refs
+		}
+		delete(node, "$ref")
+		target := s.resolve(ref)
+		if target == nil || len(refs) >= maxRefDepth || contains(refs, ref) {
+			// 无法解析或循环引用：退化为不限制结构的对象
+			if _, ok := node["type"]; !ok {
+				node["type"] = "object"
+			}
+			return refs
+		}
+		for k, v := range deepCopy(target).(map[string]any) {
+			if _, exists := node[k]; !exists {
+				node[k] = v
+			}
+		}
+		refs = append(refs[:len(refs):len(refs)], ref)
+	}
+}
+
+// resolve 查找本地引用（#/$defs/Name、#/definitions/Name 或 JSON Pointer 路径）
+func (s *sanitizer) resolve(ref string) map[string]any {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var cur any = s.root
+	for _, seg := range strings.Split(ref[2:], "/") {
+		seg = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[seg]
+	}
+	m, _ := cur.(map[string]any)
+	return m
+}
+
+// mergeAllOf 合并 allOf 分支：properties、required 取并集，其余关键字当前节点优先
+func mergeAllOf(node map[string]any) {
+	branches := schemaList(node["allOf"])
+	if branches == nil {
+		return
+	}
+	delete(node, "allOf")
+	for _, b := range branches {
+		for k, v := range b {
+			switch k {
+			case "properties":
+				props, _ := node["properties"].(map[string]any)
+				if props == nil {
+					props = make(map[string]any)
+				}
+				if bp, ok := v.(map[string]any); ok {
+					for name, schema := range bp {
+						if _, exists := props[name]; !exists {
+							props[name] = schema
+						}
+					}
+				}
+				node["properties"] = props
+			case "required":
+				node["required"] = unionStrings(node["required"], v)
+			default:
+				if _, exists := node[k]; !exists {
+					node[k] = v
+				}
+			}
+		}
+	}
+}
+
+// flattenNullable 去掉 null 分支；只剩一个分支时展平到当前节点，发生展平时返回 true
+func flattenNullable(node map[string]any) bool {
+	if types, ok := node["type"].([]any); ok {
+		var nonNull []any
+		for _, t := range types {
+			if t != "null" {
+				nonNull = append(nonNull, t)
+			}
+		}
+		switch {
+		case len(nonNull) == 1:
+			node["type"] = nonNull[0]
+		case len(nonNull) > 1:
+			node["type"] = nonNull
+		}
+	}
+	anyOf, ok := node["anyOf"].([]any)
+	if !ok {
+		return false
+	}
+	var nonNull []any
+	for _, item := range anyOf {
+		if m, ok := item.(map[string]any); ok {
+			if m["type"] == "null" {
+				continue
+			}
+			nonNull = append(nonNull, m)
+		}
+	}
+	if len(nonNull) == 1 {
+		delete(node, "anyOf")
+		for k, v := range nonNull[0].(map[string]any) {
+			if _, exists := node[k]; !exists || k == "type" {
+				node[k] = v
+			}
+		}
+		return true
+	}
+	if len(nonNull) > 1 {
+		node["anyOf"] = nonNull
+	}
+	return false
+}
+
+// schemaList 取出 schema 数组中的对象
+func schemaList(v any) []map[string]any {
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	result := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		if m, ok := item.(map[string]any); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func unionStrings(a, b any) []any {
+	var result []any
+	seen := make(map[any]bool)
+	for _, list := range []any{a, b} {
+		items, _ := list.([]any)
+		for _, item := range items {
+			if !seen[item] {
+				seen[item] = true
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+func deepCopy(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(t))
+		for k, val := range t {
+			m[k] = deepCopy(val)
+		}
+		return m
+	case []any:
+		s := make([]any, len(t))
+		for i, val := range t {
+			s[i] = deepCopy(val)
+		}
+		return s
+	}
+	return v
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func toSet(list []string) map[string]bool {
+	if list == nil {
+		return nil
+	}
+	set := make(map[string]bool, len(list))
+	for _, s := range list {
+		set[s] = true
+	}
+	return set
+}
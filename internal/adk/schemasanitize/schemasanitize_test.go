@@ -0,0 +1,153 @@
+package schemasanitize
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// 真实 MCP 服务输出的参数 schema
+var mcpSchemas = map[string]string{
+	// Python FastMCP（pydantic）：可选参数为 anyOf + null、default null，嵌套模型放在 $defs
+	"fastmcp": `{"properties":{"query":{"title":"Query","type":"string"},
+		"limit":{"anyOf":[{"type":"integer"},{"type":"null"}],"default":null,"title":"Limit"},
+		"filter":{"anyOf":[{"$ref":"#/$defs/Filter"},{"type":"null"}],"default":null}},
+		"required":["query"],"title":"searchArguments","type":"object",
+		"$defs":{"Filter":{"properties":{"market":{"const":"A","type":"string"},"since":{"format":"date","type":"string"},
+			"site":{"format":"uri","type":"string"}},"type":"object"}}}`,
+	// TypeScript SDK（zod-to-json-schema）：带 $schema 与 additionalProperties
+	"zod": `{"type":"object","properties":{"path":{"type":"string"},
+		"edits":{"type":"array","items":{"type":"object","properties":{"oldText":{"type":"string"},"newText":{"type":"string"}},
+			"required":["oldText","newText"],"additionalProperties":false}},
+		"dryRun":{"type":"boolean","default":false}},
+		"required":["path","edits"],"additionalProperties":false,"$schema":"http://json-schema.org/draft-07/schema#"}`,
+	// Go SDK（jsonschema-go）：oneOf 区分目标类型，带 exclusiveMinimum 与 uri format
+	"oneof": `{"type":"object","properties":{"state":{"type":"string","enum":["open","closed"]},
+		"target":{"oneOf":[{"type":"object","properties":{"issue":{"type":"integer","exclusiveMinimum":0}},"required":["issue"]},
+			{"type":"object","properties":{"url":{"type":"string","format":"uri"}},"required":["url"]}]}}}`,
+	// 递归结构与 allOf 继承，使用 draft-07 的 definitions
+	"recursive": `{"type":"object","properties":{"root":{"$ref":"#/definitions/Node"},
+		"meta":{"allOf":[{"$ref":"#/definitions/Base"},{"properties":{"tag":{"type":["string","null"]}},"required":["tag"]}]}},
+		"definitions":{"Node":{"type":"object","properties":{"name":{"type":"string"},"children":{"type":"array","items":{"$ref":"#/definitions/Node"}}}},
+			"Base":{"type":"object","properties":{"id":{"type":"string","format":"uuid"}},"required":["id"]}}}`,
+}
+
+// forbidden 各供应商清洗后不应出现的关键字
+var forbidden = map[string][]string{
+	"anthropic": {"const"},
+	"openai":    {"$ref", "$defs", "definitions", "oneOf", "allOf", "$schema"},
+	"gemini":    {"$ref", "$defs", "definitions", "oneOf", "allOf", "const", "default", "$schema", "exclusiveMinimum"},
+}
+
+func TestSanitize_MCPSchemas(t *testing.T) {
+	for _, p := range []Profile{Anthropic, OpenAI, Gemini} {
+		for name, raw := range mcpSchemas {
+			out := Sanitize(json.RawMessage(raw), p)
+			if out == nil {
+				t.Fatalf("%s/%s: nil result", p.Name, name)
+			}
+			visit(out, func(node map[string]any) {
+				for _, k := range forbidden[p.Name] {
+					if _, ok := node[k]; ok {
+						t.Errorf("%s/%s: keyword %s remains in %v", p.Name, name, k, node)
+					}
+				}
+				if v, ok := node["default"]; ok && v == nil {
+					t.Errorf("%s/%s: default null remains", p.Name, name)
+				}
+				if f, ok := node["format"].(string); ok && p.Formats != nil && !slices.Contains(p.Formats, f) {
+					t.Errorf("%s/%s: format %s remains", p.Name, name, f)
+				}
+				if p.FlattenNullable && hasNullType(node) {
+					t.Errorf("%s/%s: null type remains in %v", p.Name, name, node)
+				}
+			})
+			// 清洗结果再次清洗应保持不变
+			if again := Sanitize(out, p); !reflect.DeepEqual(again, out) {
+				t.Errorf("%s/%s: not idempotent", p.Name, name)
+			}
+		}
+	}
+}
+
+func TestSanitize_Details(t *testing.T) {
+	get := func(m map[string]any, path ...string) any {
+		var cur any = m
+		for _, k := range path {
+			cur = cur.(map[string]any)[k]
+		}
+		return cur
+	}
+
+	g := Sanitize(json.RawMessage(mcpSchemas["fastmcp"]), Gemini)
+	if get(g, "properties", "limit", "type") != "integer" {
+		t.Errorf("nullable anyOf not flattened: %v", get(g, "properties", "limit"))
+	}
+	if !reflect.DeepEqual(get(g, "properties", "filter", "properties", "market", "enum"), []any{"A"}) {
+		t.Errorf("$ref not inlined or const not converted: %v", get(g, "properties", "filter"))
+	}
+	if get(g, "properties", "filter", "properties", "since", "format") != "date" {
+		t.Error("supported format should be kept")
+	}
+
+	// OpenAI 不展平 nullable，只去掉 default null
+	o := Sanitize(json.RawMessage(mcpSchemas["fastmcp"]), OpenAI)
+	if _, ok := get(o, "properties", "limit").(map[string]any)["anyOf"]; !ok {
+		t.Error("openai should keep nullable anyOf")
+	}
+
+	// Anthropic 保留 $ref 与 $defs，但清洗定义内部
+	a := Sanitize(json.RawMessage(mcpSchemas["fastmcp"]), Anthropic)
+	if get(a, "properties", "filter", "$ref") != "#/$defs/Filter" || get(a, "$defs", "Filter", "properties", "market", "enum") == nil {
+		t.Errorf("anthropic refs: %v", a)
+	}
+
+	r := Sanitize(json.RawMessage(mcpSchemas["recursive"]), Gemini)
+	if !reflect.DeepEqual(get(r, "properties", "meta", "required"), []any{"id", "tag"}) &&
+		!reflect.DeepEqual(get(r, "properties", "meta", "required"), []any{"tag", "id"}) {
+		t.Errorf("allOf required not merged: %v", get(r, "properties", "meta"))
+	}
+	if get(r, "properties", "meta", "properties", "tag", "type") != "string" {
+		t.Errorf("type array not flattened: %v", get(r, "properties", "meta"))
+	}
+	if get(r, "properties", "root", "properties", "children", "items", "type") != "object" {
+		t.Errorf("recursive ref not expanded: %v", get(r, "properties", "root"))
+	}
+
+	if got := Sanitize(json.RawMessage(mcpSchemas["oneof"]), OpenAI); len(schemaList(get(got, "properties", "target").(map[string]any)["anyOf"])) != 2 {
+		t.Errorf("oneOf not converted: %v", got)
+	}
+
+	// 输入不被修改
+	src := map[string]any{"type": "object", "properties": map[string]any{"x": map[string]any{"const": 1}}}
+	Sanitize(src, Gemini)
+	if _, ok := src["properties"].(map[string]any)["x"].(map[string]any)["const"]; !ok {
+		t.Error("input schema was modified")
+	}
+}
+
+// visit 遍历所有 schema 节点
+func visit(v any, fn func(map[string]any)) {
+	switch t := v.(type) {
+	case map[string]any:
+		fn(t)
+		for _, child := range t {
+			visit(child, fn)
+		}
+	case []any:
+		for _, child := range t {
+			visit(child, fn)
+		}
+	}
+}
+
+func hasNullType(node map[string]any) bool {
+	switch t := node["type"].(type) {
+	case string:
+		return t == "null" && len(node) == 1
+	case []any:
+		return slices.Contains(t, any("null"))
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package adk
+
+import (
+	"context"
+	"iter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+const (
+	// defaultCoalesceInterval 流式文本片段的默认合并窗口
+	defaultCoalesceInterval = 100 * time.Millisecond
+	// defaultCoalesceRunes 缓冲文本达到该字符数时立即输出
+	defaultCoalesceRunes = 256
+)
+
+// coalesceSettings 返回流式片段合并参数，interval 为 0 表示不合并
+func coalesceSettings(config *models.AIConfig) (time.Duration, int) {
+	if config == nil || config.StreamCoalesceMs < 0 {
+		return 0, 0
+	}
+	interval := defaultCoalesceInterval
+	if config.StreamCoalesceMs > 0 {
+		interval = time.Duration(config.StreamCoalesceMs) * time.Millisecond
+	}
+	runes := defaultCoalesceRunes
+	if config.StreamCoalesceRunes > 0 {
+		runes = config.StreamCoalesceRunes
+	}
+	return interval, runes
+}
+
+// wrapCoalescing 合并过于零碎的流式文本片段，降低前端事件数量
+func wrapCoalescing(config *models.AIConfig, llm model.LLM) model.LLM {
+	interval, runes := coalesceSettings(config)
+	if interval <= 0 {
+		return llm
+	}
+	cm := &coalescingModel{LLM: llm, interval: interval, maxRunes: runes}
+	if retriever, ok := llm.(responseRetriever); ok {
+		return &coalescingRetrieverModel{coalescingModel: cm, responseRetriever: retriever}
+	}
+	return cm
+}
+
+// coalescingModel 自适应合并流式片段：
+//   - 距上次输出已超过合并窗口的片段立即输出，输出较慢的模型不增加延迟
+//   - 窗口内到达的片段先缓冲，窗口结束或缓冲达到字符上限时合并输出
+//   - 非纯文本片段（工具调用增量等）、聚合响应与错误到达前先输出缓冲内容，保持顺序
+type coalescingModel struct {
+	model.LLM
+	interval time.Duration
+	maxRunes int
+}
+
+// coalesceItem 上游产生的一个响应
+type coalesceItem struct {
+	resp *model.LLMResponse
+	err  error
+}
+
+// GenerateContent 实现 model.LLM 接口
+func (m *coalescingModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	if !stream {
+		return m.LLM.GenerateContent(ctx, req, stream)
+	}
+	return func(yield func(*model.LLMResponse, error) bool) {
+		// 上游在独立的 goroutine 中读取，窗口到期时即使没有新片段也能输出缓冲内容
+		items := make(chan coalesceItem)
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			defer close(items)
+			for resp, err := range m.LLM.GenerateContent(ctx, req, stream) {
+				select {
+				case items <- coalesceItem{resp: resp, err: err}:
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		var (
+			buf       partialBuffer
+			lastFlush time.Time
+			timer     = time.NewTimer(m.interval)
+			timerC    <-chan time.Time
+		)
+		timer.Stop()
+		defer timer.Stop()
+		flush := func() bool {
+			timer.Stop()
+			timerC = nil
+			resp := buf.take()
+			if resp == nil {
+				return true
+			}
+			lastFlush = time.Now()
+			return yield(resp, nil)
+		}
+
+		for {
+			select {
+			case it, ok := <-items:
+				if !ok {
+					flush()
+					return
+				}
+				if it.err != nil || !isCoalescible(it.resp) {
+					if !flush() || !yield(it.resp, it.err) {
+						return
+					}
+					continue
+				}
+				buf.add(it.resp)
+				if wait := m.interval - time.Since(lastFlush); wait <= 0 || buf.runes >= m.maxRunes {
+					if !flush() {
+						return
+					}
+				} else if timerC == nil {
+					timer.Reset(wait)
+					timerC = timer.C
+				}
+			case <-timerC:
+				if !flush() {
+					return
+				}
+			}
+		}
+	}
+}
+
+// isCoalescible 判断是否为可合并的纯文本流式片段
+func isCoalescible(resp *model.LLMResponse) bool {
+	if resp == nil || !resp.Partial || resp.Content == nil || len(resp.Content.Parts) == 0 {
+		return false
+	}
+	if resp.CustomMetadata != nil || resp.UsageMetadata != nil || resp.GroundingMetadata != nil ||
+		resp.CitationMetadata != nil || resp.FinishReason != "" || resp.ErrorCode != "" || resp.TurnComplete {
+		return false
+	}
+	for _, p := range resp.Content.Parts {
+		if p == nil || p.Text == "" || p.FunctionCall != nil || p.FunctionResponse != nil ||
+			p.InlineData != nil || p.FileData != nil || len(p.ThoughtSignature) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// partialBuffer 缓冲的流式文本，相邻且类型相同（正文 / 思考）的片段合并为一个 part
+type partialBuffer struct {
+	base  *model.LLMResponse
+	parts []*genai.Part
+	runes int
+}
+
+func (b *partialBuffer) add(resp *model.LLMResponse) {
+	if b.base == nil {
+		b.base = resp
+	}
+	for _, p := range resp.Content.Parts {
+		b.runes += utf8.RuneCountInString(p.Text)
+		if n := len(b.parts); n > 0 && b.parts[n-1].Thought == p.Thought {
+			b.parts[n-1].Text += p.Text
+			continue
+		}
+		b.parts = append(b.parts, &genai.Part{Text: p.Text, Thought: p.Thought})
+	}
+}
+
+// take 取出合并后的片段并清空缓冲，无内容时返回 nil
+func (b *partialBuffer) take() *model.LLMResponse {
+	if b.base == nil {
+		return nil
+	}
+	out := *b.base
+	out.Content = &genai.Content{Role: b.base.Content.Role, Parts: b.parts}
+	if out.Content.Role == "" {
+		out.Content.Role = genai.RoleModel
+	}
+	*b = partialBuffer{}
+	return &out
+}
+
+// coalescingRetrieverModel 保留 RetrieveResponse 能力的合并包装
+type coalescingRetrieverModel struct {
+	*coalescingModel
+	responseRetriever
+}
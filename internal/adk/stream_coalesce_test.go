@@ -0,0 +1,84 @@
+package adk
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// chattyLLM 逐字输出流式片段，最后给出工具调用增量与聚合响应
+type chattyLLM struct {
+	text string
+}
+
+func (c *chattyLLM) Name() string { return "chatty" }
+
+func (c *chattyLLM) GenerateContent(_ context.Context, _ *model.LLMRequest, _ bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for _, r := range c.text {
+			resp := &model.LLMResponse{Content: genai.NewContentFromText(string(r), genai.RoleModel), Partial: true}
+			if !yield(resp, nil) {
+				return
+			}
+		}
+		if !yield(&model.LLMResponse{Partial: true, CustomMetadata: map[string]any{"tool_call_delta": true}}, nil) {
+			return
+		}
+		yield(&model.LLMResponse{Content: genai.NewContentFromText(c.text, genai.RoleModel), TurnComplete: true}, nil)
+	}
+}
+
+func TestCoalescingModel_MergesChattyDeltas(t *testing.T) {
+	text := strings.Repeat("行情数据", 100)
+	m := &coalescingModel{LLM: &chattyLLM{text: text}, interval: time.Hour, maxRunes: 64}
+
+	var (
+		partials int
+		got      strings.Builder
+		last     *model.LLMResponse
+		metadata bool
+	)
+	for resp, err := range m.GenerateContent(context.Background(), &model.LLMRequest{}, true) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = resp
+		if resp.CustomMetadata != nil {
+			metadata = true
+			continue
+		}
+		if resp.Partial {
+			if metadata {
+				t.Fatal("工具调用增量之后不应再有缓冲的文本片段")
+			}
+			partials++
+			got.WriteString(resp.Content.Parts[0].Text)
+		}
+	}
+	if got.String() != text {
+		t.Fatalf("合并后的文本不一致: %d runes", len([]rune(got.String())))
+	}
+	// 首个片段立即输出，其余每 64 字合并一次
+	if want := 1 + (len([]rune(text))-1+63)/64; partials != want {
+		t.Fatalf("partials = %d, want %d", partials, want)
+	}
+	if !metadata || last == nil || last.Partial || !last.TurnComplete {
+		t.Fatal("工具调用增量与聚合响应应原样透传")
+	}
+}
+
+func TestCoalesceSettings(t *testing.T) {
+	if d, _ := coalesceSettings(&models.AIConfig{StreamCoalesceMs: -1}); d != 0 {
+		t.Fatalf("-1 应关闭合并，got %v", d)
+	}
+	d, n := coalesceSettings(&models.AIConfig{})
+	if d != defaultCoalesceInterval || n != defaultCoalesceRunes {
+		t.Fatalf("默认值 = %v/%d", d, n)
+	}
+}
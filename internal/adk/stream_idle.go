@@ -0,0 +1,122 @@
+package adk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStreamIdle 流式响应在空闲窗口内没有收到任何事件（连接停滞）
+// 单纯的空闲超时可被会议层重试（中断并重新发起请求）；请求截止时间到达时同时包装 context 错误，不再重试
+var ErrStreamIdle = errors.New("流式响应停滞")
+
+// stallReportThreshold 请求截止或取消时，距上一行数据超过该时长才按“停滞”报告，否则保留原始 context 错误
+const stallReportThreshold = 15 * time.Second
+
+// idleTimeoutTransport 为 SSE 响应增加读空闲超时
+// 部分代理会缓冲 SSE，导致连接长时间无数据却不断开；timeout 为 0 时不限制空闲时长，只在请求截止时报告停滞
+type idleTimeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *idleTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !isEventStream(resp) {
+		return resp, err
+	}
+	body := newIdleTimeoutBody(resp.Body, t.timeout)
+	body.ctx = req.Context()
+	resp.Body = body
+	return resp, nil
+}
+
+// isEventStream 判断是否为 SSE 响应
+func isEventStream(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// idleTimeoutBody 按行读取 SSE 响应体：
+//   - 每收到一行（包括 ": ping" 心跳）重置空闲计时
+//   - 过滤注释行，避免解析器把心跳当成空消息
+//   - 超时后关闭底层连接，读取返回 ErrStreamIdle
+//   - 请求截止时间到达（或被取消）时若已停滞较久，返回同时包装 ErrStreamIdle 与 context 错误的说明
+type idleTimeoutBody struct {
+	ctx      context.Context
+	body     io.ReadCloser
+	reader   *bufio.Reader
+	pending  []byte
+	timeout  time.Duration
+	timer    *time.Timer
+	idle     atomic.Bool
+	lastLine time.Time
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration) *idleTimeoutBody {
+	b := &idleTimeoutBody{
+		ctx:      context.Background(),
+		body:     body,
+		reader:   bufio.NewReader(body),
+		timeout:  timeout,
+		lastLine: time.Now(),
+	}
+	if timeout > 0 {
+		b.timer = time.AfterFunc(timeout, func() {
+			b.idle.Store(true)
+			b.body.Close()
+		})
+	}
+	return b
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	for len(b.pending) == 0 {
+		line, err := b.reader.ReadBytes('\n')
+		if len(line) > 0 {
+			b.lastLine = time.Now()
+			if b.timer != nil {
+				b.timer.Reset(b.timeout)
+			}
+			if !isSSEComment(line) {
+				b.pending = line
+			}
+		}
+		if err != nil {
+			if len(b.pending) > 0 {
+				break
+			}
+			if b.idle.Load() {
+				return 0, fmt.Errorf("%w：%v 内未收到事件，连接可能被代理或模型挂起", ErrStreamIdle, b.timeout)
+			}
+			if ctxErr := b.ctx.Err(); ctxErr != nil {
+				if stalled := time.Since(b.lastLine); stalled >= stallReportThreshold {
+					return 0, fmt.Errorf("%w：中断前 %v 内未收到事件: %w", ErrStreamIdle, stalled.Round(time.Second), ctxErr)
+				}
+			}
+			return 0, err
+		}
+	}
+	n := copy(p, b.pending)
+	b.pending = b.pending[n:]
+	return n, nil
+}
+
+func (b *idleTimeoutBody) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	return b.body.Close()
+}
+
+// isSSEComment 判断是否为 SSE 注释行（如 ": ping"）
+func isSSEComment(line []byte) bool {
+	return bytes.HasPrefix(line, []byte(":"))
+}
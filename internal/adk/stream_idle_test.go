@@ -0,0 +1,60 @@
+package adk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdleTimeoutBody_FiltersComments(t *testing.T) {
+	src := ": ping\n\nevent: message_start\ndata: {}\n\n: ping\n"
+	body := newIdleTimeoutBody(io.NopCloser(strings.NewReader(src)), time.Second)
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("读取失败: %v", err)
+	}
+	if got, want := string(data), "\nevent: message_start\ndata: {}\n\n"; got != want {
+		t.Errorf("读取内容 = %q, 期望 %q", got, want)
+	}
+}
+
+func TestIdleTimeoutBody_Stalled(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	body := newIdleTimeoutBody(pr, 50*time.Millisecond)
+	defer body.Close()
+
+	go pw.Write([]byte("data: first\n"))
+	buf := make([]byte, 64)
+	if _, err := body.Read(buf); err != nil {
+		t.Fatalf("首次读取失败: %v", err)
+	}
+	if _, err := body.Read(buf); !errors.Is(err, ErrStreamIdle) {
+		t.Errorf("err = %v, 期望 ErrStreamIdle", err)
+	}
+}
+
+func TestIdleTimeoutBody_StalledAtDeadline(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	body := newIdleTimeoutBody(pr, 0)
+	body.ctx = ctx
+	body.lastLine = time.Now().Add(-time.Minute)
+	defer body.Close()
+
+	go func() {
+		<-ctx.Done()
+		pr.CloseWithError(ctx.Err())
+	}()
+	_, err := body.Read(make([]byte, 64))
+	if !errors.Is(err, ErrStreamIdle) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, 期望同时包装 ErrStreamIdle 与 DeadlineExceeded", err)
+	}
+}
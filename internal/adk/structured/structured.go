@@ -0,0 +1,114 @@
+// Package structured 将 genai 的结构化输出配置（ResponseMIMEType/ResponseSchema/ResponseJsonSchema）
+// 转换为标准 JSON Schema，供各供应商适配层映射到自己的结构化输出参数
+package structured
+
+import (
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// ToolName 不支持原生结构化输出的供应商（Anthropic）用于强制 JSON 输出的工具名
+const ToolName = "structured_output"
+
+// JSONRequested 请求是否要求 JSON 输出
+func JSONRequested(cfg *genai.GenerateContentConfig) bool {
+	return cfg != nil && cfg.ResponseMIMEType == "application/json"
+}
+
+// Schema 返回请求要求的 JSON Schema，优先使用 ResponseJsonSchema，其次转换 ResponseSchema
+// 未要求 JSON 输出或未提供 schema 时返回 nil
+func Schema(cfg *genai.GenerateContentConfig) map[string]any {
+	if !JSONRequested(cfg) {
+		return nil
+	}
+	if cfg.ResponseJsonSchema != nil {
+		return toMap(cfg.ResponseJsonSchema)
+	}
+	if cfg.ResponseSchema != nil {
+		schema := toMap(cfg.ResponseSchema)
+		if schema != nil {
+			convertGenaiSchema(schema)
+		}
+		return schema
+	}
+	return nil
+}
+
+// SchemaName 结构化输出的名称：取 schema 的 title，缺省为 ToolName
+func SchemaName(schema map[string]any) string {
+	if title, ok := schema["title"].(string); ok {
+		var sb strings.Builder
+		for _, r := range title {
+			if r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+				sb.WriteRune(r)
+			}
+		}
+		if sb.Len() > 0 {
+			return sb.String()
+		}
+	}
+	return ToolName
+}
+
+// toMap 将任意 schema 值（map、结构体、json.RawMessage 或 JSON 字符串）转为 map
+func toMap(v any) map[string]any {
+	var data []byte
+	switch s := v.(type) {
+	case map[string]any:
+		return s
+	case string:
+		data = []byte(s)
+	case json.RawMessage:
+		data = s
+	case []byte:
+		data = s
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil
+		}
+	}
+	var m map[string]any
+	if json.Unmarshal(data, &m) != nil {
+		return nil
+	}
+	return m
+}
+
+// convertGenaiSchema 将 genai.Schema 的序列化结果改写为标准 JSON Schema：
+// 类型转小写、nullable 转为 type 数组，移除 genai 专有字段
+func convertGenaiSchema(node map[string]any) {
+	if t, ok := node["type"].(string); ok {
+		t = strings.ToLower(t)
+		if t == "type_unspecified" {
+			delete(node, "type")
+		} else if nullable, _ := node["nullable"].(bool); nullable {
+			node["type"] = []any{t, "null"}
+		} else {
+			node["type"] = t
+		}
+	}
+	delete(node, "nullable")
+	delete(node, "propertyOrdering")
+	delete(node, "example")
+
+	if props, ok := node["properties"].(map[string]any); ok {
+		for _, v := range props {
+			if m, ok := v.(map[string]any); ok {
+				convertGenaiSchema(m)
+			}
+		}
+	}
+	if items, ok := node["items"].(map[string]any); ok {
+		convertGenaiSchema(items)
+	}
+	if anyOf, ok := node["anyOf"].([]any); ok {
+		for _, v := range anyOf {
+			if m, ok := v.(map[string]any); ok {
+				convertGenaiSchema(m)
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package structured
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestSchema_ConvertsGenaiSchema(t *testing.T) {
+	cfg := &genai.GenerateContentConfig{
+		ResponseMIMEType: "application/json",
+		ResponseSchema: &genai.Schema{
+			Type:             genai.TypeObject,
+			Title:            "stock verdict",
+			PropertyOrdering: []string{"score"},
+			Properties: map[string]*genai.Schema{
+				"score": {Type: genai.TypeInteger},
+				"note":  {Type: genai.TypeString, Nullable: genai.Ptr(true)},
+			},
+			Required: []string{"score"},
+		},
+	}
+	schema := Schema(cfg)
+	want := map[string]any{
+		"type":  "object",
+		"title": "stock verdict",
+		"properties": map[string]any{
+			"score": map[string]any{"type": "integer"},
+			"note":  map[string]any{"type": []any{"string", "null"}},
+		},
+		"required": []any{"score"},
+	}
+	if !reflect.DeepEqual(schema, want) {
+		t.Fatalf("Schema() = %#v", schema)
+	}
+	if name := SchemaName(schema); name != "stockverdict" {
+		t.Fatalf("SchemaName() = %q", name)
+	}
+
+	cfg.ResponseMIMEType = ""
+	if Schema(cfg) != nil {
+		t.Fatal("want nil schema without JSON mime type")
+	}
+}
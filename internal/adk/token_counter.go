@@ -0,0 +1,259 @@
+package adk
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"unicode"
+
+	"github.com/run-bigpig/jcp/internal/adk/anthropic"
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// TokenCounter 统计请求的输入 token 数
+type TokenCounter interface {
+	CountTokens(ctx context.Context, req *model.LLMRequest) (int, error)
+}
+
+const (
+	// messageOverheadTokens 每条消息的角色与分隔符开销（参考 OpenAI 的计数方式）
+	messageOverheadTokens = 4
+	// mediaPartTokens 图片等二进制内容按固定值估算
+	mediaPartTokens = 258
+)
+
+// CreateTokenCounter 按供应商创建 token 计数器：Anthropic（含 Vertex AI 上的 Claude）使用 count_tokens 接口，Gemini / Vertex AI 使用 countTokens，
+// 其余供应商使用本地 BPE 估算；远端计数失败时回退到本地估算
+func (f *ModelFactory) CreateTokenCounter(config *models.AIConfig) TokenCounter {
+	var remote TokenCounter
+	switch config.Provider {
+	case models.AIProviderAnthropic:
+		if httpClient, err := f.newHTTPClient(config); err == nil {
+			remote = anthropic.NewAnthropicModel(config.ModelName, config.APIKey, normalizeAnthropicBaseURL(config.BaseURL), httpClient, config.NoSystemRole, false)
+		}
+	case models.AIProviderGemini:
+		remote = &geminiCounter{modelName: config.ModelName, clientConfig: func(ctx context.Context) (*genai.ClientConfig, error) {
+			httpClient, err := f.newHTTPClient(config)
+			if err != nil {
+				return nil, err
+			}
+			return &genai.ClientConfig{APIKey: config.APIKey, Backend: genai.BackendGeminiAPI, HTTPClient: httpClient}, nil
+		}}
+	case models.AIProviderVertexAI:
+		if isVertexClaude(config.ModelName) {
+			if clientConfig, err := f.vertexClientConfig(config); err == nil {
+				remote = anthropic.NewVertexAnthropicModel(config.ModelName, config.Project, config.Location, clientConfig.HTTPClient, false)
+			}
+			break
+		}
+		remote = &geminiCounter{modelName: config.ModelName, vertex: true, clientConfig: func(ctx context.Context) (*genai.ClientConfig, error) {
+			return f.vertexClientConfig(config)
+		}}
+	}
+	if remote == nil {
+		return estimateCounter{}
+	}
+	return &fallbackCounter{remote: remote, provider: string(config.Provider)}
+}
+
+// hasExactTokenCount 供应商是否按模型自身的分词器精确计数（Anthropic count_tokens，含 Vertex AI 上的 Claude）
+func hasExactTokenCount(config *models.AIConfig) bool {
+	return isClaudeConfig(config)
+}
+
+// estimateCounter 本地估算，不发起网络请求
+type estimateCounter struct{}
+
+func (estimateCounter) CountTokens(_ context.Context, req *model.LLMRequest) (int, error) {
+	return EstimateRequestTokens(req), nil
+}
+
+// fallbackCounter 远端计数失败时回退到本地估算
+type fallbackCounter struct {
+	remote   TokenCounter
+	provider string
+}
+
+func (c *fallbackCounter) CountTokens(ctx context.Context, req *model.LLMRequest) (int, error) {
+	n, err := c.remote.CountTokens(ctx, req)
+	if err != nil {
+		log.Warn("%s token 计数失败，使用本地估算: %v", c.provider, err)
+		return EstimateRequestTokens(req), nil
+	}
+	return n, nil
+}
+
+// geminiCounter 调用 Gemini countTokens，客户端在首次使用时创建
+// Gemini API 的 countTokens 不接受系统指令与工具，这部分按本地估算累加
+type geminiCounter struct {
+	modelName    string
+	vertex       bool
+	clientConfig func(ctx context.Context) (*genai.ClientConfig, error)
+
+	once    sync.Once
+	client  *genai.Client
+	initErr error
+}
+
+func (c *geminiCounter) CountTokens(ctx context.Context, req *model.LLMRequest) (int, error) {
+	c.once.Do(func() {
+		cfg, err := c.clientConfig(ctx)
+		if err != nil {
+			c.initErr = err
+			return
+		}
+		c.client, c.initErr = genai.NewClient(ctx, cfg)
+	})
+	if c.initErr != nil {
+		return 0, c.initErr
+	}
+
+	var (
+		cfg   *genai.CountTokensConfig
+		extra int
+	)
+	if req.Config != nil {
+		if c.vertex {
+			cfg = &genai.CountTokensConfig{SystemInstruction: req.Config.SystemInstruction, Tools: req.Config.Tools}
+		} else {
+			extra = contentTokens(req.Config.SystemInstruction) + toolsTokens(req.Config.Tools)
+		}
+	}
+	resp, err := c.client.Models.CountTokens(ctx, c.modelName, req.Contents, cfg)
+	if err != nil {
+		return 0, err
+	}
+	return int(resp.TotalTokens) + extra, nil
+}
+
+// EstimateRequestTokens 本地估算请求的输入 token 数（系统指令、历史消息与工具定义）
+func EstimateRequestTokens(req *model.LLMRequest) int {
+	if req == nil {
+		return 0
+	}
+	total := 0
+	for _, c := range req.Contents {
+		total += contentTokens(c)
+	}
+	if req.Config != nil {
+		total += contentTokens(req.Config.SystemInstruction) + toolsTokens(req.Config.Tools)
+	}
+	return total
+}
+
+// contentTokens 估算单条消息的 token 数
+func contentTokens(c *genai.Content) int {
+	if c == nil {
+		return 0
+	}
+	total := messageOverheadTokens
+	for _, p := range c.Parts {
+		total += partTokens(p)
+	}
+	return total
+}
+
+// partTokens 估算单个 part 的 token 数
+func partTokens(p *genai.Part) int {
+	if p == nil {
+		return 0
+	}
+	switch {
+	case p.Text != "":
+		return CountBPETokens(p.Text)
+	case p.FunctionCall != nil:
+		return jsonTokens(p.FunctionCall)
+	case p.FunctionResponse != nil:
+		return jsonTokens(p.FunctionResponse)
+	case p.InlineData != nil, p.FileData != nil:
+		return mediaPartTokens
+	case p.ExecutableCode != nil:
+		return CountBPETokens(p.ExecutableCode.Code)
+	case p.CodeExecutionResult != nil:
+		return CountBPETokens(p.CodeExecutionResult.Output)
+	}
+	return 0
+}
+
+// toolsTokens 估算工具定义的 token 数
+func toolsTokens(tools []*genai.Tool) int {
+	total := 0
+	for _, t := range tools {
+		if t != nil {
+			total += jsonTokens(t)
+		}
+	}
+	return total
+}
+
+func jsonTokens(v any) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return CountBPETokens(string(data))
+}
+
+// CountBPETokens 按 tiktoken（cl100k / o200k）的预分词规则切分文本并估算 token 数
+// 英文单词通常为 1 个 token，长单词约每 5 个字母 1 个 token；数字每 3 位 1 个 token；
+// 中日韩字符约 1 个字符 1 个 token；标点按连续串计；空白并入后一个词
+func CountBPETokens(s string) int {
+	total := 0
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			// 单个空格并入后一个词，连续空白（缩进、换行）单独成 token
+			j := i
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+			if j-i > 1 || j == len(runes) || !isWordRune(runes[j]) {
+				total++
+			}
+			i = j
+		case isCJK(r):
+			total++
+			i++
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsMark(runes[j])) && !isCJK(runes[j]) {
+				j++
+			}
+			n := j - i
+			if n <= 6 {
+				total++
+			} else {
+				total += (n + 4) / 5
+			}
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			total += (j - i + 2) / 3
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !unicode.IsLetter(runes[j]) && !unicode.IsDigit(runes[j]) {
+				j++
+			}
+			total += (j - i + 1) / 2
+			i = j
+		}
+	}
+	return total
+}
+
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r)
+}
+
+// isCJK 判断是否为中日韩文字（分词器中通常单字成 token）
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
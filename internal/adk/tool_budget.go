@@ -0,0 +1,200 @@
+package adk
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// defaultToolSchemaBudget 每轮请求中工具定义的默认 token 预算
+const defaultToolSchemaBudget = 8000
+
+var (
+	toolLastUsed   = map[string]time.Time{} // 工具名 -> 最近一次被模型调用的时间
+	toolLastUsedMu sync.Mutex
+)
+
+// markToolsUsed 记录工具的最近使用时间
+func markToolsUsed(names []string, now time.Time) {
+	if len(names) == 0 {
+		return
+	}
+	toolLastUsedMu.Lock()
+	defer toolLastUsedMu.Unlock()
+	for _, name := range names {
+		toolLastUsed[name] = now
+	}
+}
+
+// EstimateTokens 粗略估算 token 数：ASCII 约 4 字符/token，其余字符约 1 字符/token
+func EstimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < 0x80 {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return ascii/4 + other
+}
+
+// toolSchemaTokens 估算单个工具定义占用的 token 数
+func toolSchemaTokens(decl *genai.FunctionDeclaration) int {
+	data, err := json.Marshal(decl)
+	if err != nil {
+		return 0
+	}
+	return EstimateTokens(string(data))
+}
+
+// toolBudgetCallback 在请求发出前检查工具定义的 token 开销
+// 超出预算时按最近最少使用的顺序移除工具，并在系统指令中说明，而不是让请求失败
+// budget < 0 表示不限制，0 使用默认预算
+func toolBudgetCallback(budget int) llmagent.BeforeModelCallback {
+	if budget == 0 {
+		budget = defaultToolSchemaBudget
+	}
+	return func(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+		if budget < 0 || req == nil || req.Config == nil {
+			return nil, nil
+		}
+		called := calledToolNames(req.Contents)
+		markToolsUsed(called, time.Now())
+		if excluded := applyToolBudget(req, budget, called); len(excluded) > 0 {
+			log.Warn("agent %s 工具定义超出预算 %d tokens，本轮移除: %v", ctx.AgentName(), budget, excluded)
+		}
+		return nil, nil
+	}
+}
+
+// calledToolNames 收集当前对话中已经调用过的工具，这些工具不会被移除
+func calledToolNames(contents []*genai.Content) []string {
+	var names []string
+	for _, c := range contents {
+		if c == nil {
+			continue
+		}
+		for _, p := range c.Parts {
+			if p != nil && p.FunctionCall != nil {
+				names = append(names, p.FunctionCall.Name)
+			}
+		}
+	}
+	return names
+}
+
+// applyToolBudget 按预算裁剪请求中的工具定义，返回被移除的工具名
+func applyToolBudget(req *model.LLMRequest, budget int, keep []string) []string {
+	type candidate struct {
+		name     string
+		tokens   int
+		lastUsed time.Time
+		order    int
+	}
+	var (
+		cands []candidate
+		total int
+	)
+	for _, t := range req.Config.Tools {
+		if t == nil {
+			continue
+		}
+		for _, decl := range t.FunctionDeclarations {
+			if decl == nil {
+				continue
+			}
+			tokens := toolSchemaTokens(decl)
+			total += tokens
+			cands = append(cands, candidate{name: decl.Name, tokens: tokens, order: len(cands)})
+		}
+	}
+	if total <= budget {
+		return nil
+	}
+
+	protected := make(map[string]bool, len(keep))
+	for _, name := range keep {
+		protected[name] = true
+	}
+	toolLastUsedMu.Lock()
+	for i := range cands {
+		cands[i].lastUsed = toolLastUsed[cands[i].name]
+	}
+	toolLastUsedMu.Unlock()
+	// 最久未使用的优先移除；从未使用的工具中靠后注册的优先移除
+	sort.SliceStable(cands, func(i, j int) bool {
+		if !cands[i].lastUsed.Equal(cands[j].lastUsed) {
+			return cands[i].lastUsed.Before(cands[j].lastUsed)
+		}
+		return cands[i].order > cands[j].order
+	})
+
+	drop := make(map[string]bool)
+	var excluded []string
+	for _, c := range cands {
+		if total <= budget {
+			break
+		}
+		if protected[c.name] {
+			continue
+		}
+		drop[c.name] = true
+		excluded = append(excluded, c.name)
+		total -= c.tokens
+	}
+	if len(excluded) == 0 {
+		return nil
+	}
+
+	removeFunctionDeclarations(req, drop)
+
+	notice := "【工具提示】启用的工具过多，以下工具本轮不可用，请使用其余工具完成任务: " + strings.Join(excluded, ", ")
+	appendSystemNotice(req, notice)
+	return excluded
+}
+
+// removeFunctionDeclarations 从请求中移除指定的工具定义（不修改原有 Tool 对象）
+func removeFunctionDeclarations(req *model.LLMRequest, drop map[string]bool) {
+	tools := make([]*genai.Tool, 0, len(req.Config.Tools))
+	for _, t := range req.Config.Tools {
+		if t == nil || len(t.FunctionDeclarations) == 0 {
+			tools = append(tools, t)
+			continue
+		}
+		var decls []*genai.FunctionDeclaration
+		for _, decl := range t.FunctionDeclarations {
+			if decl == nil || !drop[decl.Name] {
+				decls = append(decls, decl)
+			}
+		}
+		if len(decls) == 0 && t.GoogleSearch == nil && t.CodeExecution == nil && t.Retrieval == nil {
+			continue
+		}
+		clone := *t
+		clone.FunctionDeclarations = decls
+		tools = append(tools, &clone)
+	}
+	req.Config.Tools = tools
+	for name := range drop {
+		delete(req.Tools, name)
+	}
+}
+
+// appendSystemNotice 在系统指令末尾追加说明（复制 Content，不修改原对象）
+func appendSystemNotice(req *model.LLMRequest, notice string) {
+	instruction := &genai.Content{Role: "user"}
+	if si := req.Config.SystemInstruction; si != nil {
+		instruction.Role = si.Role
+		instruction.Parts = append(instruction.Parts, si.Parts...)
+	}
+	instruction.Parts = append(instruction.Parts, genai.NewPartFromText(notice))
+	req.Config.SystemInstruction = instruction
+}
@@ -0,0 +1,46 @@
+package adk
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestApplyToolBudget_DropsLeastRecentlyUsed(t *testing.T) {
+	decl := func(name string) *genai.FunctionDeclaration {
+		return &genai.FunctionDeclaration{Name: name, Description: strings.Repeat("描述", 50)}
+	}
+	req := &model.LLMRequest{
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("你是分析师", genai.RoleUser),
+			Tools: []*genai.Tool{
+				{FunctionDeclarations: []*genai.FunctionDeclaration{decl("budget_a"), decl("budget_b")}},
+				{FunctionDeclarations: []*genai.FunctionDeclaration{decl("budget_c")}},
+			},
+		},
+		Tools: map[string]any{"budget_a": 1, "budget_b": 2, "budget_c": 3},
+	}
+	markToolsUsed([]string{"budget_a"}, time.Now())
+	markToolsUsed([]string{"budget_c"}, time.Now().Add(-time.Hour))
+
+	one := toolSchemaTokens(decl("budget_a"))
+	excluded := applyToolBudget(req, one*2, []string{"budget_b"})
+
+	// budget_b 本轮已调用受保护，budget_c 比 budget_a 更久未使用
+	if len(excluded) != 1 || excluded[0] != "budget_c" {
+		t.Fatalf("excluded = %v", excluded)
+	}
+	if len(req.Config.Tools) != 1 || len(req.Config.Tools[0].FunctionDeclarations) != 2 {
+		t.Fatalf("tools = %+v", req.Config.Tools)
+	}
+	if _, ok := req.Tools["budget_c"]; ok {
+		t.Fatal("excluded tool still callable")
+	}
+	parts := req.Config.SystemInstruction.Parts
+	if len(parts) != 2 || !strings.Contains(parts[1].Text, "budget_c") {
+		t.Fatalf("notice missing: %+v", parts)
+	}
+}
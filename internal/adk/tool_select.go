@@ -0,0 +1,155 @@
+package adk
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// maxEmbeddingCache 向量缓存条目上限
+const maxEmbeddingCache = 4096
+
+var (
+	embeddingCache   = map[string][]float32{} // key: 向量空间ID + 文本
+	embeddingCacheMu sync.Mutex
+)
+
+// toolSelectionCallback 在请求发出前按与用户问题的语义相关度筛选工具，只附带前 topN 个
+// 本轮已调用过的工具始终保留；向量计算失败时不做筛选
+func toolSelectionCallback(embedder Embedder, topN int) llmagent.BeforeModelCallback {
+	return func(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+		if topN <= 0 || req == nil || req.Config == nil {
+			return nil, nil
+		}
+		query := contentText(ctx.UserContent())
+		if query == "" {
+			return nil, nil
+		}
+		embedCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		if dropped := selectTools(embedCtx, embedder, req, query, topN, calledToolNames(req.Contents)); len(dropped) > 0 {
+			log.Debug("agent %s 按相关度保留 %d 个工具，省略 %d 个", ctx.AgentName(), topN, len(dropped))
+		}
+		return nil, nil
+	}
+}
+
+// selectTools 计算工具与问题的相似度并移除排名 topN 之后的工具，返回被移除的工具名
+func selectTools(ctx context.Context, embedder Embedder, req *model.LLMRequest, query string, topN int, keep []string) []string {
+	var decls []*genai.FunctionDeclaration
+	for _, t := range req.Config.Tools {
+		if t == nil {
+			continue
+		}
+		for _, decl := range t.FunctionDeclarations {
+			if decl != nil {
+				decls = append(decls, decl)
+			}
+		}
+	}
+	if len(decls) <= topN {
+		return nil
+	}
+
+	texts := make([]string, 0, len(decls)+1)
+	texts = append(texts, query)
+	for _, decl := range decls {
+		texts = append(texts, toolEmbeddingText(decl))
+	}
+	vectors, err := cachedEmbed(ctx, embedder, texts)
+	if err != nil {
+		log.Warn("工具相关度计算失败，附带全部工具: %v", err)
+		return nil
+	}
+
+	type scored struct {
+		name  string
+		score float32
+	}
+	ranked := make([]scored, len(decls))
+	for i, decl := range decls {
+		ranked[i] = scored{name: decl.Name, score: cosine(vectors[0], vectors[i+1])}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	protected := make(map[string]bool, len(keep))
+	for _, name := range keep {
+		protected[name] = true
+	}
+	drop := make(map[string]bool)
+	var dropped []string
+	for i, r := range ranked {
+		if i >= topN && !protected[r.name] {
+			drop[r.name] = true
+			dropped = append(dropped, r.name)
+		}
+	}
+	if len(dropped) > 0 {
+		removeFunctionDeclarations(req, drop)
+		appendSystemNotice(req, "【工具提示】本轮只提供与问题相关的工具，未提供的工具不可调用")
+	}
+	return dropped
+}
+
+// toolEmbeddingText 用于计算相似度的工具文本
+func toolEmbeddingText(decl *genai.FunctionDeclaration) string {
+	return strings.ReplaceAll(decl.Name, "_", " ") + ": " + decl.Description
+}
+
+// contentText 提取内容中的文本
+func contentText(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, p := range c.Parts {
+		if p != nil && p.Text != "" && !p.Thought {
+			sb.WriteString(p.Text)
+		}
+	}
+	return sb.String()
+}
+
+// cachedEmbed 带缓存的批量向量化，只对未缓存的文本发起请求
+func cachedEmbed(ctx context.Context, embedder Embedder, texts []string) ([][]float32, error) {
+	prefix := embedder.ID() + "|"
+	vectors := make([][]float32, len(texts))
+	var missing []string
+	var missingIdx []int
+
+	embeddingCacheMu.Lock()
+	for i, text := range texts {
+		if v, ok := embeddingCache[prefix+text]; ok {
+			vectors[i] = v
+		} else {
+			missing = append(missing, text)
+			missingIdx = append(missingIdx, i)
+		}
+	}
+	embeddingCacheMu.Unlock()
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	computed, err := embedder.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	embeddingCacheMu.Lock()
+	if len(embeddingCache)+len(missing) > maxEmbeddingCache {
+		embeddingCache = map[string][]float32{}
+	}
+	for j, i := range missingIdx {
+		vectors[i] = computed[j]
+		embeddingCache[prefix+missing[j]] = computed[j]
+	}
+	embeddingCacheMu.Unlock()
+	return vectors, nil
+}
@@ -0,0 +1,34 @@
+package adk
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestSelectTools_KeepsMostRelevant(t *testing.T) {
+	req := &model.LLMRequest{
+		Config: &genai.GenerateContentConfig{Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{
+			{Name: "get_kline_data", Description: "获取股票K线数据，支持日线、周线、月线"},
+			{Name: "get_longhubang", Description: "获取A股龙虎榜数据，包括营业部买卖金额、上榜原因"},
+			{Name: "get_hottrend", Description: "获取全网舆情热点，微博知乎热搜榜单"},
+			{Name: "get_news", Description: "获取最新财经快讯"},
+		}}}},
+		Tools: map[string]any{"get_kline_data": 1, "get_longhubang": 2, "get_hottrend": 3, "get_news": 4},
+	}
+
+	dropped := selectTools(context.Background(), LocalEmbedder{}, req, "最近龙虎榜上哪些营业部在买？", 1, []string{"get_news"})
+	if len(dropped) != 2 {
+		t.Fatalf("dropped = %v", dropped)
+	}
+	var kept []string
+	for _, d := range req.Config.Tools[0].FunctionDeclarations {
+		kept = append(kept, d.Name)
+	}
+	// get_news 已被调用过，始终保留
+	if len(kept) != 2 || kept[0] != "get_longhubang" || kept[1] != "get_news" {
+		t.Fatalf("kept = %v", kept)
+	}
+}
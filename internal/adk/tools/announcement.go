@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/services"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// maxAutoIngest 单次调用最多自动收录的公告 PDF 数
+const maxAutoIngest = 3
+
+// GetAnnouncementsInput 公告查询输入参数
+type GetAnnouncementsInput struct {
+	Code  string `json:"code" jsonschema:"股票代码，如 sz000001 或 000001"`
+	Limit int    `json:"limit,omitzero" jsonschema:"返回条数，默认10，最多30"`
+}
+
+// GetAnnouncementsOutput 公告查询输出
+type GetAnnouncementsOutput struct {
+	Data  string `json:"data" jsonschema:"公告列表"`
+	Count int    `json:"count" jsonschema:"公告数量"`
+	AsOf  string `json:"asOf" jsonschema:"数据获取时间"`
+	Stale bool   `json:"stale" jsonschema:"为 true 表示实时获取失败，返回的是过期缓存"`
+}
+
+// createAnnouncementsTool 创建公告查询工具，并在后台收录最新几篇公告 PDF
+func (r *Registry) createAnnouncementsTool() (tool.Tool, error) {
+	handler := func(ctx tool.Context, input GetAnnouncementsInput) (GetAnnouncementsOutput, error) {
+		fmt.Printf("[Tool:get_announcements] 调用开始, code=%s, limit=%d\n", input.Code, input.Limit)
+
+		if input.Code == "" {
+			return GetAnnouncementsOutput{Data: "请提供股票代码"}, nil
+		}
+		limit := input.Limit
+		if limit <= 0 {
+			limit = 10
+		}
+		limit = min(limit, 30)
+
+		key := fmt.Sprintf("announcements:%s:%d", input.Code, limit)
+		list, fresh, err := cachedFetch(r.cache, key, announcementTTL, func() ([]services.Announcement, error) {
+			return r.announcementService.GetAnnouncements(input.Code, limit)
+		})
+		if err != nil {
+			fmt.Printf("[Tool:get_announcements] 错误: %v\n", err)
+			return GetAnnouncementsOutput{}, err
+		}
+
+		var sb strings.Builder
+		for i, a := range list {
+			fmt.Fprintf(&sb, "%d. [%s] %s", i+1, a.NoticeDate, a.Title)
+			if a.Category != "" {
+				fmt.Fprintf(&sb, "（%s）", a.Category)
+			}
+			if a.PDFUrl != "" {
+				fmt.Fprintf(&sb, "\n   PDF: %s", a.PDFUrl)
+			}
+			sb.WriteString("\n")
+			if r.documentService != nil && i < maxAutoIngest && a.PDFUrl != "" {
+				r.documentService.IngestAsync(input.Code, a.Title, a.PDFUrl, "announcement", a.NoticeDate)
+			}
+		}
+		if len(list) == 0 {
+			sb.WriteString("暂无公告\n")
+		}
+
+		fmt.Printf("[Tool:get_announcements] 调用完成, 返回%d条公告\n", len(list))
+		return GetAnnouncementsOutput{
+			Data:  fresh.Header() + sb.String(),
+			Count: len(list),
+			AsOf:  fresh.AsOfString(),
+			Stale: fresh.Stale,
+		}, nil
+	}
+
+	return functiontool.New(functiontool.Config{
+		Name:        "get_announcements",
+		Description: "获取个股最新公告列表（含PDF链接），最新几篇公告正文会在后台收录到个股文档库，下一次分析时自动作为引用资料提供",
+	}, handler)
+}
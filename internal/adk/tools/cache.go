@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 各类数据的缓存有效期
+const (
+	newsTTL          = time.Minute
+	reportListTTL    = 30 * time.Minute
+	reportContentTTL = 24 * time.Hour
+	longHuBangTTL    = 10 * time.Minute
+	announcementTTL  = 10 * time.Minute
+)
+
+// maxCacheEntries 缓存条目上限，超出时清理过期条目
+const maxCacheEntries = 256
+
+// Freshness 工具数据的时效信息
+type Freshness struct {
+	AsOf   time.Time // 数据获取时间
+	Cached bool      // 来自缓存
+	Stale  bool      // 上游获取失败，返回的是已过期的缓存
+}
+
+// Header 生成注入到工具结果开头的时效说明
+func (f Freshness) Header() string {
+	asOf := f.AsOf.Format("2006-01-02 15:04:05")
+	if f.Stale {
+		return fmt.Sprintf("[数据时间: %s，实时获取失败，以下为过期缓存，引用时必须注明数据时间]\n", asOf)
+	}
+	return fmt.Sprintf("[数据时间: %s]\n", asOf)
+}
+
+// AsOfString 返回 RFC3339 格式的数据时间
+func (f Freshness) AsOfString() string {
+	return f.AsOf.Format(time.RFC3339)
+}
+
+type cacheEntry struct {
+	value     any
+	fetchedAt time.Time
+	ttl       time.Duration
+}
+
+// dataCache 工具数据读穿缓存：有效期内直接返回，过期后重新获取，获取失败时降级返回过期数据
+type dataCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+func newDataCache() *dataCache {
+	return &dataCache{entries: make(map[string]cacheEntry), now: time.Now}
+}
+
+// cachedFetch 按 key 读取缓存，未命中或过期时调用 fetch
+func cachedFetch[T any](c *dataCache, key string, ttl time.Duration, fetch func() (T, error)) (T, Freshness, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	now := c.now()
+	c.mu.Unlock()
+
+	if ok && now.Sub(entry.fetchedAt) < entry.ttl {
+		return entry.value.(T), Freshness{AsOf: entry.fetchedAt, Cached: true}, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		if ok {
+			return entry.value.(T), Freshness{AsOf: entry.fetchedAt, Cached: true, Stale: true}, nil
+		}
+		var zero T
+		return zero, Freshness{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCacheEntries {
+		c.evictExpired(now)
+	}
+	c.entries[key] = cacheEntry{value: value, fetchedAt: now, ttl: ttl}
+	return value, Freshness{AsOf: now}, nil
+}
+
+// evictExpired 清理过期条目，仍超出上限时清空（调用方需持有锁）
+func (c *dataCache) evictExpired(now time.Time) {
+	for k, e := range c.entries {
+		if now.Sub(e.fetchedAt) >= e.ttl {
+			delete(c.entries, k)
+		}
+	}
+	if len(c.entries) >= maxCacheEntries {
+		c.entries = make(map[string]cacheEntry)
+	}
+}
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCachedFetch_StaleFallback(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	c := newDataCache()
+	c.now = func() time.Time { return now }
+
+	calls := 0
+	fetch := func() (string, error) {
+		calls++
+		if calls > 1 {
+			return "", errors.New("upstream down")
+		}
+		return "v1", nil
+	}
+
+	v, fresh, err := cachedFetch(c, "k", time.Minute, fetch)
+	if err != nil || v != "v1" || fresh.Cached || fresh.Stale {
+		t.Fatalf("first fetch = %q %+v %v", v, fresh, err)
+	}
+	v, fresh, _ = cachedFetch(c, "k", time.Minute, fetch)
+	if calls != 1 || !fresh.Cached || fresh.Stale {
+		t.Fatalf("within ttl should hit cache, calls=%d %+v", calls, fresh)
+	}
+
+	now = now.Add(2 * time.Minute)
+	v, fresh, err = cachedFetch(c, "k", time.Minute, fetch)
+	if err != nil || v != "v1" || !fresh.Stale || !fresh.AsOf.Equal(now.Add(-2*time.Minute)) {
+		t.Fatalf("expired + upstream error should return stale cache, got %q %+v %v", v, fresh, err)
+	}
+}
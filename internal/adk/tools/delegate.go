@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// DelegateToolName 子代理委派工具名（子代理自身不可再委派）
+const DelegateToolName = "delegate"
+
+// DelegateRequest 子代理委派请求
+type DelegateRequest struct {
+	Task    string
+	Context string
+	Tools   []string
+}
+
+// DelegateFunc 执行子代理并返回总结
+// 由会议服务注入，避免工具包反向依赖 Agent 构建逻辑
+type DelegateFunc func(ctx context.Context, req DelegateRequest) (string, error)
+
+// SetDelegateFunc 设置子代理执行函数
+func (r *Registry) SetDelegateFunc(fn DelegateFunc) {
+	r.delegate = fn
+}
+
+// DelegateInput 委派工具输入
+type DelegateInput struct {
+	Task    string   `json:"task" jsonschema:"交给子代理完成的子任务，描述要具体，如：整理最近三年财报要点"`
+	Context string   `json:"context,omitzero" jsonschema:"子任务需要的背景信息（可选），如股票代码、关注重点"`
+	Tools   []string `json:"tools,omitzero" jsonschema:"子代理可使用的工具名（可选），只能从允许的工具中选择"`
+}
+
+// DelegateOutput 委派工具输出
+type DelegateOutput struct {
+	Summary string `json:"summary" jsonschema:"子代理的总结"`
+}
+
+// createDelegateTool 创建子代理委派工具
+func (r *Registry) createDelegateTool() (tool.Tool, error) {
+	handler := func(ctx tool.Context, input DelegateInput) (DelegateOutput, error) {
+		fmt.Printf("[Tool:delegate] 调用开始, task=%s, tools=%v\n", input.Task, input.Tools)
+		if r.delegate == nil {
+			return DelegateOutput{}, fmt.Errorf("子代理服务未初始化")
+		}
+		if input.Task == "" {
+			return DelegateOutput{}, fmt.Errorf("task 不能为空")
+		}
+		summary, err := r.delegate(ctx, DelegateRequest{
+			Task:    input.Task,
+			Context: input.Context,
+			Tools:   input.Tools,
+		})
+		if err != nil {
+			fmt.Printf("[Tool:delegate] 错误: %v\n", err)
+			return DelegateOutput{}, err
+		}
+		fmt.Printf("[Tool:delegate] 调用完成, 总结长度=%d\n", len(summary))
+		return DelegateOutput{Summary: summary}, nil
+	}
+
+	return functiontool.New(functiontool.Config{
+		Name:        DelegateToolName,
+		Description: "把耗时的资料整理类子任务委派给独立子代理完成，返回子代理的总结",
+	}, handler)
+}
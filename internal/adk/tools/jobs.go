@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/adk/tool"
+)
+
+// asyncJobTimeout 后台工具任务最长执行时间
+const asyncJobTimeout = 5 * time.Minute
+
+// 后台任务状态
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// JobFunc 后台任务执行函数，progress 用于上报中间进度
+type JobFunc func(ctx context.Context, progress func(msg string)) (map[string]any, error)
+
+// Job 长耗时工具的后台任务
+// 工具调用立即返回任务句柄，任务完成后由会议层以同一 FunctionCallID 的
+// function response 补发最终结果
+type Job struct {
+	ID        string
+	Tool      string
+	CallID    string // 触发任务的 function call ID
+	SessionID string
+
+	mu       sync.Mutex
+	status   string
+	progress []string
+	result   map[string]any
+	err      error
+	updates  chan string
+	cancel   context.CancelFunc
+}
+
+// Wait 等待任务结束，期间通过 onProgress 转发进度；ctx 取消时同时取消任务
+func (j *Job) Wait(ctx context.Context, onProgress func(msg string)) error {
+	for {
+		select {
+		case msg, ok := <-j.updates:
+			if !ok {
+				return nil
+			}
+			if onProgress != nil {
+				onProgress(msg)
+			}
+		case <-ctx.Done():
+			j.cancel()
+			return ctx.Err()
+		}
+	}
+}
+
+// Handle 工具调用立即返回的任务句柄
+func (j *Job) Handle() map[string]any {
+	return map[string]any{
+		"jobId":   j.ID,
+		"status":  JobRunning,
+		"message": "任务已在后台执行，完成后会自动返回结果，请等待",
+	}
+}
+
+// Response 任务结束后补发给模型的最终结果（含中间进度）
+func (j *Job) Response() map[string]any {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	resp := map[string]any{
+		"jobId":  j.ID,
+		"status": j.status,
+	}
+	if len(j.progress) > 0 {
+		resp["progress"] = append([]string(nil), j.progress...)
+	}
+	if j.err != nil {
+		resp["error"] = j.err.Error()
+	}
+	for k, v := range j.result {
+		resp[k] = v
+	}
+	return resp
+}
+
+func (j *Job) report(msg string) {
+	j.mu.Lock()
+	j.progress = append(j.progress, msg)
+	j.mu.Unlock()
+	select {
+	case j.updates <- msg:
+	default:
+	}
+}
+
+func (j *Job) finish(result map[string]any, err error) {
+	j.mu.Lock()
+	j.result = result
+	j.err = err
+	j.status = JobDone
+	if err != nil {
+		j.status = JobFailed
+	}
+	j.mu.Unlock()
+	close(j.updates)
+}
+
+// JobManager 后台工具任务管理
+type JobManager struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewJobManager 创建后台任务管理器
+func NewJobManager() *JobManager {
+	return &JobManager{jobs: make(map[string]*Job)}
+}
+
+// Start 启动后台任务，立即返回任务句柄
+// 任务上下文独立于本次工具调用，由 Release 统一取消
+func (m *JobManager) Start(ctx tool.Context, toolName string, fn JobFunc) *Job {
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), asyncJobTimeout)
+	job := &Job{
+		ID:        uuid.New().String(),
+		Tool:      toolName,
+		CallID:    ctx.FunctionCallID(),
+		SessionID: ctx.SessionID(),
+		status:    JobRunning,
+		updates:   make(chan string, 16),
+		cancel:    cancel,
+	}
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		result, err := fn(jobCtx, job.report)
+		job.finish(result, err)
+	}()
+	return job
+}
+
+// Take 取出会话中所有后台任务（取出后由调用方负责等待并补发结果）
+func (m *JobManager) Take(sessionID string) []*Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*Job
+	for id, job := range m.jobs {
+		if job.SessionID == sessionID {
+			result = append(result, job)
+			delete(m.jobs, id)
+		}
+	}
+	return result
+}
+
+// Release 取消并移除会话中所有未取出的后台任务
+func (m *JobManager) Release(sessionID string) {
+	for _, job := range m.Take(sessionID) {
+		job.cancel()
+	}
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/tool"
+)
+
+// fakeToolContext 仅实现后台任务用到的方法
+type fakeToolContext struct {
+	tool.Context
+	ctx       context.Context
+	callID    string
+	sessionID string
+}
+
+func (f fakeToolContext) FunctionCallID() string      { return f.callID }
+func (f fakeToolContext) SessionID() string           { return f.sessionID }
+func (f fakeToolContext) Deadline() (time.Time, bool) { return f.ctx.Deadline() }
+func (f fakeToolContext) Done() <-chan struct{}       { return f.ctx.Done() }
+func (f fakeToolContext) Err() error                  { return f.ctx.Err() }
+func (f fakeToolContext) Value(key any) any           { return f.ctx.Value(key) }
+
+func TestJobManager_TwoJobs(t *testing.T) {
+	m := NewJobManager()
+	release := make(chan struct{})
+	ok := m.Start(fakeToolContext{ctx: context.Background(), callID: "c1", sessionID: "s1"}, "report", func(ctx context.Context, progress func(string)) (map[string]any, error) {
+		progress("下载中")
+		<-release
+		return map[string]any{"pages": 3}, nil
+	})
+	failed := m.Start(fakeToolContext{ctx: context.Background(), callID: "c2", sessionID: "s1"}, "report", func(ctx context.Context, progress func(string)) (map[string]any, error) {
+		return nil, errors.New("超时")
+	})
+	other := m.Start(fakeToolContext{ctx: context.Background(), callID: "c3", sessionID: "s2"}, "report", func(ctx context.Context, progress func(string)) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if h := ok.Handle(); h["status"] != JobRunning || h["jobId"] != ok.ID {
+		t.Fatalf("handle = %v", h)
+	}
+
+	taken := m.Take("s1")
+	if len(taken) != 2 || len(m.Take("s1")) != 0 {
+		t.Fatalf("take = %d jobs", len(taken))
+	}
+
+	var progress []string
+	close(release)
+	if err := ok.Wait(context.Background(), func(msg string) { progress = append(progress, msg) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := failed.Wait(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if r := ok.Response(); r["status"] != JobDone || r["pages"] != 3 || len(progress) != 1 || ok.CallID != "c1" {
+		t.Errorf("ok response = %v, progress = %v", r, progress)
+	}
+	if r := failed.Response(); r["status"] != JobFailed || r["error"] != "超时" {
+		t.Errorf("failed response = %v", r)
+	}
+
+	// Release 取消其他会话中未取出的任务
+	m.Release("s2")
+	if err := other.Wait(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if r := other.Response(); r["status"] != JobFailed {
+		t.Errorf("released job = %v", r)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+	"github.com/run-bigpig/jcp/internal/services"
 
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
@@ -20,7 +22,9 @@ type GetLongHuBangInput struct {
 
 // GetLongHuBangOutput 龙虎榜输出
 type GetLongHuBangOutput struct {
-	Data string `json:"data" jsonschema:"龙虎榜数据列表"`
+	Data  string `json:"data" jsonschema:"龙虎榜数据列表"`
+	AsOf  string `json:"asOf" jsonschema:"数据获取时间"`
+	Stale bool   `json:"stale" jsonschema:"为 true 表示实时获取失败，返回的是过期缓存"`
 }
 
 // createLongHuBangTool 创建龙虎榜工具
@@ -40,13 +44,16 @@ func (r *Registry) createLongHuBangTool() (tool.Tool, error) {
 			pageNumber = 1
 		}
 
-		listResult, err := r.longHuBangService.GetLongHuBangList(pageSize, pageNumber, input.TradeDate)
+		key := fmt.Sprintf("longhubang:%d:%d:%s", pageSize, pageNumber, input.TradeDate)
+		listResult, fresh, err := cachedFetch(r.cache, key, longHuBangTTL, func() (*services.LongHuBangListResult, error) {
+			return r.longHuBangService.GetLongHuBangList(pageSize, pageNumber, input.TradeDate)
+		})
 		if err != nil {
 			lhbLog.Error("获取龙虎榜失败: %v", err)
 			return GetLongHuBangOutput{}, err
 		}
 
-		var result string
+		result := fresh.Header()
 		for i, item := range listResult.Items {
 			// 格式化金额为万元
 			netBuyWan := item.NetBuyAmt / 10000
@@ -66,7 +73,7 @@ func (r *Registry) createLongHuBangTool() (tool.Tool, error) {
 		}
 
 		lhbLog.Debug("调用完成, 返回%d条数据", len(listResult.Items))
-		return GetLongHuBangOutput{Data: result}, nil
+		return GetLongHuBangOutput{Data: result, AsOf: fresh.AsOfString(), Stale: fresh.Stale}, nil
 	}
 
 	return functiontool.New(functiontool.Config{
@@ -83,7 +90,9 @@ type GetLongHuBangDetailInput struct {
 
 // GetLongHuBangDetailOutput 龙虎榜营业部明细输出
 type GetLongHuBangDetailOutput struct {
-	Data string `json:"data" jsonschema:"营业部买卖明细"`
+	Data  string `json:"data" jsonschema:"营业部买卖明细"`
+	AsOf  string `json:"asOf" jsonschema:"数据获取时间"`
+	Stale bool   `json:"stale" jsonschema:"为 true 表示实时获取失败，返回的是过期缓存"`
 }
 
 // createLongHuBangDetailTool 创建龙虎榜营业部明细工具
@@ -95,7 +104,9 @@ func (r *Registry) createLongHuBangDetailTool() (tool.Tool, error) {
 			return GetLongHuBangDetailOutput{}, fmt.Errorf("股票代码和交易日期不能为空")
 		}
 
-		details, err := r.longHuBangService.GetStockDetail(input.Code, input.TradeDate)
+		details, fresh, err := cachedFetch(r.cache, "longhubang_detail:"+input.Code+":"+input.TradeDate, longHuBangTTL, func() ([]models.LongHuBangDetail, error) {
+			return r.longHuBangService.GetStockDetail(input.Code, input.TradeDate)
+		})
 		if err != nil {
 			lhbLog.Error("获取营业部明细失败: %v", err)
 			return GetLongHuBangDetailOutput{}, err
@@ -105,7 +116,7 @@ func (r *Registry) createLongHuBangDetailTool() (tool.Tool, error) {
 			return GetLongHuBangDetailOutput{Data: "未找到该股票的龙虎榜营业部数据"}, nil
 		}
 
-		var result string
+		result := fresh.Header()
 		result += fmt.Sprintf("=== %s 龙虎榜营业部明细 ===\n\n", input.Code)
 
 		// 分别输出买入和卖出
@@ -130,7 +141,7 @@ func (r *Registry) createLongHuBangDetailTool() (tool.Tool, error) {
 		}
 
 		lhbLog.Debug("调用完成")
-		return GetLongHuBangDetailOutput{Data: result}, nil
+		return GetLongHuBangDetailOutput{Data: result, AsOf: fresh.AsOfString(), Stale: fresh.Stale}, nil
 	}
 
 	return functiontool.New(functiontool.Config{
@@ -14,7 +14,9 @@ type GetNewsInput struct {
 
 // GetNewsOutput 快讯输出
 type GetNewsOutput struct {
-	Data string `json:"data" jsonschema:"财经快讯列表"`
+	Data  string `json:"data" jsonschema:"财经快讯列表"`
+	AsOf  string `json:"asOf" jsonschema:"数据获取时间"`
+	Stale bool   `json:"stale" jsonschema:"为 true 表示实时获取失败，返回的是过期缓存"`
 }
 
 // createNewsTool 创建快讯工具
@@ -22,7 +24,7 @@ func (r *Registry) createNewsTool() (tool.Tool, error) {
 	handler := func(ctx tool.Context, input GetNewsInput) (GetNewsOutput, error) {
 		fmt.Printf("[Tool:get_news] 调用开始, limit=%d\n", input.Limit)
 
-		news, err := r.newsService.GetTelegraphList()
+		news, fresh, err := cachedFetch(r.cache, "news", newsTTL, r.newsService.GetTelegraphList)
 		if err != nil {
 			fmt.Printf("[Tool:get_news] 错误: %v\n", err)
 			return GetNewsOutput{}, err
@@ -36,14 +38,14 @@ func (r *Registry) createNewsTool() (tool.Tool, error) {
 			limit = len(news)
 		}
 
-		var result string
+		result := fresh.Header()
 		for i := 0; i < limit; i++ {
 			n := news[i]
 			result += fmt.Sprintf("[%s] %s\n", n.Time, n.Content)
 		}
 
 		fmt.Printf("[Tool:get_news] 调用完成, 返回%d条快讯\n", limit)
-		return GetNewsOutput{Data: result}, nil
+		return GetNewsOutput{Data: result, AsOf: fresh.AsOfString(), Stale: fresh.Stale}, nil
 	}
 
 	return functiontool.New(functiontool.Config{
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/run-bigpig/jcp/internal/services"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+type sessionStockKey struct{}
+
+// WithSessionStock 在 context 中绑定当前会话讨论的股票，研究笔记工具只读写该股票的笔记
+func WithSessionStock(ctx context.Context, stockCode string) context.Context {
+	return context.WithValue(ctx, sessionStockKey{}, stockCode)
+}
+
+// sessionStock 读取当前会话绑定的股票代码
+func sessionStock(ctx context.Context) (string, error) {
+	code, _ := ctx.Value(sessionStockKey{}).(string)
+	if code == "" {
+		return "", fmt.Errorf("当前会话未关联股票，无法访问研究笔记")
+	}
+	return code, nil
+}
+
+// GetResearchNotesInput 研究笔记查询输入（股票固定为当前会话讨论的股票）
+type GetResearchNotesInput struct{}
+
+// GetResearchNotesOutput 研究笔记查询输出
+type GetResearchNotesOutput struct {
+	Data string `json:"data" jsonschema:"研究笔记内容"`
+}
+
+// createGetResearchNotesTool 创建研究笔记查询工具
+func (r *Registry) createGetResearchNotesTool() (tool.Tool, error) {
+	handler := func(ctx tool.Context, input GetResearchNotesInput) (GetResearchNotesOutput, error) {
+		return r.getResearchNotes(ctx)
+	}
+
+	return functiontool.New(functiontool.Config{
+		Name:        "get_research_notes",
+		Description: "读取当前讨论股票的研究笔记（长期投资逻辑、关键假设、跟踪要点）",
+	}, handler)
+}
+
+func (r *Registry) getResearchNotes(ctx context.Context) (GetResearchNotesOutput, error) {
+	stockCode, err := sessionStock(ctx)
+	if err != nil {
+		return GetResearchNotesOutput{}, err
+	}
+	fmt.Printf("[Tool:get_research_notes] 调用开始, stockCode=%s\n", stockCode)
+	if r.notesService == nil {
+		return GetResearchNotesOutput{}, fmt.Errorf("研究笔记服务未初始化")
+	}
+	notes := r.notesService.FormatForPrompt(stockCode)
+	if notes == "" {
+		notes = "暂无研究笔记"
+	}
+	return GetResearchNotesOutput{Data: notes}, nil
+}
+
+// EditResearchNotesInput 研究笔记编辑输入（股票固定为当前会话讨论的股票）
+type EditResearchNotesInput struct {
+	Section string `json:"section" jsonschema:"章节标题，如 投资逻辑、风险点、跟踪指标"`
+	Content string `json:"content,omitzero" jsonschema:"章节内容，delete模式可为空"`
+	Mode    string `json:"mode,omitzero" jsonschema:"编辑模式：append(追加，默认)/replace(覆盖)/delete(删除章节)"`
+}
+
+// EditResearchNotesOutput 研究笔记编辑输出
+type EditResearchNotesOutput struct {
+	Result string `json:"result" jsonschema:"编辑结果"`
+}
+
+// createEditResearchNotesTool 创建研究笔记编辑工具
+func (r *Registry) createEditResearchNotesTool() (tool.Tool, error) {
+	handler := func(ctx tool.Context, input EditResearchNotesInput) (EditResearchNotesOutput, error) {
+		return r.editResearchNotes(ctx, ctx.AgentName(), input)
+	}
+
+	return functiontool.New(functiontool.Config{
+		Name:        "edit_research_notes",
+		Description: "编辑当前讨论股票的研究笔记章节，用于沉淀长期有效的投资逻辑和跟踪要点（不会被聊天压缩丢失）",
+	}, handler)
+}
+
+func (r *Registry) editResearchNotes(ctx context.Context, author string, input EditResearchNotesInput) (EditResearchNotesOutput, error) {
+	stockCode, err := sessionStock(ctx)
+	if err != nil {
+		return EditResearchNotesOutput{}, err
+	}
+	fmt.Printf("[Tool:edit_research_notes] 调用开始, stockCode=%s, section=%s, mode=%s\n", stockCode, input.Section, input.Mode)
+	if r.notesService == nil {
+		return EditResearchNotesOutput{}, fmt.Errorf("研究笔记服务未初始化")
+	}
+	mode := input.Mode
+	if mode == "" {
+		mode = services.NoteModeAppend
+	}
+	if err := r.notesService.EditSection(stockCode, "", input.Section, input.Content, mode, author); err != nil {
+		fmt.Printf("[Tool:edit_research_notes] 错误: %v\n", err)
+		return EditResearchNotesOutput{}, err
+	}
+	return EditResearchNotesOutput{Result: fmt.Sprintf("章节「%s」已%s", input.Section, mode)}, nil
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/services"
+)
+
+func TestEditResearchNotes_BoundToSessionStock(t *testing.T) {
+	dir := t.TempDir()
+	r := &Registry{notesService: services.NewNotesService(dir)}
+
+	if _, err := r.editResearchNotes(context.Background(), "专家", EditResearchNotesInput{Section: "投资逻辑", Content: "x"}); err == nil {
+		t.Fatal("未绑定股票时应拒绝编辑")
+	}
+
+	ctx := WithSessionStock(context.Background(), "sh600519")
+	if _, err := r.editResearchNotes(ctx, "专家", EditResearchNotesInput{Section: "投资逻辑", Content: "高端白酒龙头"}); err != nil {
+		t.Fatalf("编辑失败: %v", err)
+	}
+	if notes := r.notesService.GetNotes("sh600519"); len(notes.Sections) != 1 || notes.Sections[0].UpdatedBy != "专家" {
+		t.Errorf("notes = %+v", notes)
+	}
+	out, err := r.getResearchNotes(ctx)
+	if err != nil || out.Data == "暂无研究笔记" {
+		t.Errorf("读取 = %+v, %v", out, err)
+	}
+
+	// 会话绑定的股票代码含路径时拒绝，不会写到 notes 目录之外
+	evil := WithSessionStock(context.Background(), "../config")
+	if _, err := r.editResearchNotes(evil, "专家", EditResearchNotesInput{Section: "x", Content: "x"}); err == nil {
+		t.Error("路径穿越的股票代码应被拒绝")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "config.json")); !os.IsNotExist(err) {
+		t.Errorf("不应写入 config.json: %v", err)
+	}
+}
@@ -21,6 +21,12 @@ type Registry struct {
 	researchReportService *services.ResearchReportService
 	hotTrendService       *hottrend.HotTrendService
 	longHuBangService     *services.LongHuBangService
+	notesService          *services.NotesService
+	announcementService   *services.AnnouncementService
+	documentService       *services.DocumentService
+	jobs                  *JobManager
+	cache                 *dataCache // 资讯/研报类工具的读穿缓存
+	delegate              DelegateFunc
 	tools                 map[string]tool.Tool
 	toolInfos             map[string]ToolInfo // 工具信息映射
 }
@@ -33,6 +39,9 @@ func NewRegistry(
 	researchReportService *services.ResearchReportService,
 	hotTrendService *hottrend.HotTrendService,
 	longHuBangService *services.LongHuBangService,
+	notesService *services.NotesService,
+	announcementService *services.AnnouncementService,
+	documentService *services.DocumentService,
 ) *Registry {
 	r := &Registry{
 		marketService:         marketService,
@@ -41,6 +50,11 @@ func NewRegistry(
 		researchReportService: researchReportService,
 		hotTrendService:       hotTrendService,
 		longHuBangService:     longHuBangService,
+		notesService:          notesService,
+		announcementService:   announcementService,
+		documentService:       documentService,
+		jobs:                  NewJobManager(),
+		cache:                 newDataCache(),
 		tools:                 make(map[string]tool.Tool),
 		toolInfos:             make(map[string]ToolInfo),
 	}
@@ -71,6 +85,9 @@ func (r *Registry) registerAllTools() {
 	// 注册研报内容查询工具
 	r.registerTool("get_report_content", "获取研报正文内容，需要先通过 get_research_report 获取 infoCode", r.createReportContentTool)
 
+	// 注册批量研报正文采集工具（后台任务）
+	r.registerTool("collect_report_contents", "批量采集个股最近几篇研报正文（耗时较长，后台执行，结果稍后自动返回）", r.createCollectReportsTool)
+
 	// 注册舆情热点工具
 	r.registerTool("get_hottrend", "获取全网舆情热点，支持微博、知乎、B站、百度、抖音、头条等平台的实时热搜榜单", r.createHotTrendTool)
 
@@ -79,6 +96,16 @@ func (r *Registry) registerAllTools() {
 
 	// 注册龙虎榜营业部明细工具
 	r.registerTool("get_longhubang_detail", "获取个股龙虎榜营业部买卖明细，需要提供股票代码和交易日期", r.createLongHuBangDetailTool)
+
+	// 注册公告工具
+	r.registerTool("get_announcements", "获取个股最新公告列表（含PDF链接），公告正文会自动收录到个股文档库供后续分析引用", r.createAnnouncementsTool)
+
+	// 注册研究笔记工具
+	r.registerTool("get_research_notes", "读取个股研究笔记（长期投资逻辑、关键假设、跟踪要点）", r.createGetResearchNotesTool)
+	r.registerTool("edit_research_notes", "编辑个股研究笔记章节（追加/覆盖/删除），沉淀不受聊天压缩影响的长期结论", r.createEditResearchNotesTool)
+
+	// 注册子代理委派工具
+	r.registerTool(DelegateToolName, "把耗时的资料整理类子任务委派给独立子代理完成，返回子代理的总结", r.createDelegateTool)
 }
 
 // registerTool 注册单个工具并保存信息
@@ -89,6 +116,11 @@ func (r *Registry) registerTool(name, description string, creator func() (tool.T
 	}
 }
 
+// Jobs 返回后台工具任务管理器
+func (r *Registry) Jobs() *JobManager {
+	return r.jobs
+}
+
 // GetTool 获取指定工具
 func (r *Registry) GetTool(name string) (tool.Tool, bool) {
 	t, ok := r.tools[name]
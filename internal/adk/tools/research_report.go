@@ -1,7 +1,11 @@
 package tools
 
 import (
+	"context"
 	"fmt"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/services"
 
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
@@ -18,6 +22,8 @@ type GetResearchReportInput struct {
 type GetResearchReportOutput struct {
 	Data       string `json:"data" jsonschema:"研报数据"`
 	TotalCount int    `json:"totalCount" jsonschema:"总数量"`
+	AsOf       string `json:"asOf" jsonschema:"数据获取时间"`
+	Stale      bool   `json:"stale" jsonschema:"为 true 表示实时获取失败，返回的是过期缓存"`
 }
 
 // createResearchReportTool 创建研报查询工具
@@ -40,7 +46,10 @@ func (r *Registry) createResearchReportTool() (tool.Tool, error) {
 			pageNo = 1
 		}
 
-		result, err := r.researchReportService.GetResearchReports(input.Code, pageSize, pageNo)
+		key := fmt.Sprintf("report_list:%s:%d:%d", input.Code, pageSize, pageNo)
+		result, fresh, err := cachedFetch(r.cache, key, reportListTTL, func() (*services.ResearchReportResponse, error) {
+			return r.researchReportService.GetResearchReports(input.Code, pageSize, pageNo)
+		})
 		if err != nil {
 			fmt.Printf("[Tool:get_research_report] 错误: %v\n", err)
 			return GetResearchReportOutput{}, err
@@ -50,8 +59,10 @@ func (r *Registry) createResearchReportTool() (tool.Tool, error) {
 		fmt.Printf("[Tool:get_research_report] 调用完成, 返回%d条研报\n", len(result.Data))
 
 		return GetResearchReportOutput{
-			Data:       text,
+			Data:       fresh.Header() + text,
 			TotalCount: result.TotalCount,
+			AsOf:       fresh.AsOfString(),
+			Stale:      fresh.Stale,
 		}, nil
 	}
 
@@ -70,6 +81,8 @@ type GetReportContentInput struct {
 type GetReportContentOutput struct {
 	Content string `json:"content" jsonschema:"研报正文内容"`
 	PDFUrl  string `json:"pdfUrl" jsonschema:"PDF下载链接"`
+	AsOf    string `json:"asOf" jsonschema:"数据获取时间"`
+	Stale   bool   `json:"stale" jsonschema:"为 true 表示实时获取失败，返回的是过期缓存"`
 }
 
 // createReportContentTool 创建研报内容查询工具
@@ -82,7 +95,9 @@ func (r *Registry) createReportContentTool() (tool.Tool, error) {
 			return GetReportContentOutput{Content: "请提供研报的 infoCode"}, nil
 		}
 
-		result, err := r.researchReportService.GetReportContent(input.InfoCode)
+		result, fresh, err := cachedFetch(r.cache, "report_content:"+input.InfoCode, reportContentTTL, func() (*services.ReportContentResponse, error) {
+			return r.researchReportService.GetReportContent(input.InfoCode)
+		})
 		if err != nil {
 			fmt.Printf("[Tool:get_report_content] 错误: %v\n", err)
 			return GetReportContentOutput{}, err
@@ -91,8 +106,10 @@ func (r *Registry) createReportContentTool() (tool.Tool, error) {
 		fmt.Printf("[Tool:get_report_content] 调用完成, 内容长度=%d\n", len(result.Content))
 
 		return GetReportContentOutput{
-			Content: result.Content,
+			Content: fresh.Header() + result.Content,
 			PDFUrl:  result.PDFUrl,
+			AsOf:    fresh.AsOfString(),
+			Stale:   fresh.Stale,
 		}, nil
 	}
 
@@ -101,3 +118,60 @@ func (r *Registry) createReportContentTool() (tool.Tool, error) {
 		Description: "获取研报正文内容，需要先通过 get_research_report 获取研报列表中的 infoCode",
 	}, handler)
 }
+
+// CollectReportsInput 批量研报正文采集输入参数
+type CollectReportsInput struct {
+	Code  string `json:"code" jsonschema:"股票代码，如 sz000001 或 000001"`
+	Count int    `json:"count,omitzero" jsonschema:"采集最近几篇研报正文，默认3，最多5"`
+}
+
+// maxCollectReports 单次最多采集的研报篇数
+const maxCollectReports = 5
+
+// createCollectReportsTool 创建批量研报正文采集工具（后台异步执行）
+func (r *Registry) createCollectReportsTool() (tool.Tool, error) {
+	handler := func(ctx tool.Context, input CollectReportsInput) (map[string]any, error) {
+		fmt.Printf("[Tool:collect_report_contents] 调用开始, code=%s, count=%d\n", input.Code, input.Count)
+
+		if input.Code == "" {
+			return map[string]any{"error": "请提供股票代码"}, nil
+		}
+		count := input.Count
+		if count <= 0 {
+			count = 3
+		}
+		count = min(count, maxCollectReports)
+
+		job := r.jobs.Start(ctx, "collect_report_contents", func(jobCtx context.Context, progress func(string)) (map[string]any, error) {
+			list, err := r.researchReportService.GetResearchReports(input.Code, count, 1)
+			if err != nil {
+				return nil, err
+			}
+			reports := list.Data[:min(count, len(list.Data))]
+			progress(fmt.Sprintf("找到 %d 篇研报，开始采集正文", len(reports)))
+
+			var sb strings.Builder
+			for i, rep := range reports {
+				if jobCtx.Err() != nil {
+					return nil, jobCtx.Err()
+				}
+				content, err := r.researchReportService.GetReportContent(rep.InfoCode)
+				if err != nil {
+					progress(fmt.Sprintf("《%s》采集失败: %v", rep.Title, err))
+					continue
+				}
+				fmt.Fprintf(&sb, "## %s（%s %s，%s）\n%s\n\n", rep.Title, rep.OrgSName, rep.PublishDate, rep.EmRatingName, content.Content)
+				progress(fmt.Sprintf("已采集 %d/%d 篇", i+1, len(reports)))
+			}
+			fmt.Printf("[Tool:collect_report_contents] 后台任务完成, 内容长度=%d\n", sb.Len())
+			return map[string]any{"data": sb.String()}, nil
+		})
+		return job.Handle(), nil
+	}
+
+	return functiontool.New(functiontool.Config{
+		Name:          "collect_report_contents",
+		Description:   "批量采集个股最近几篇研报正文（耗时较长，后台执行，结果稍后自动返回）",
+		IsLongRunning: true,
+	}, handler)
+}
@@ -0,0 +1,182 @@
+package meeting
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/adk/tools"
+	"github.com/run-bigpig/jcp/internal/models"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// defaultDelegateTokenBudget 单次委派默认 token 上限
+const defaultDelegateTokenBudget = 20000
+
+// defaultDelegateTools 未配置时子代理可用的只读数据工具
+var defaultDelegateTools = []string{
+	"get_stock_realtime", "get_kline_data", "get_news",
+	"get_research_report", "get_report_content", "get_longhubang",
+}
+
+const delegateInstruction = `你是一名研究助理，负责完成主分析师委派的子任务。
+- 需要数据时调用工具获取，不要编造
+- 只输出与任务相关的要点总结，条理清晰，不超过 400 字
+- 不要给出投资建议，结论交由主分析师判断`
+
+// SetDelegateConfig 设置子代理委派配置
+func (s *Service) SetDelegateConfig(cfg models.DelegateConfig) {
+	s.delegateMu.Lock()
+	defer s.delegateMu.Unlock()
+	s.delegateCfg = cfg
+}
+
+// delegate 执行 delegate 工具委派的子任务，返回子代理的总结
+// 子代理使用独立模型配置和受限工具集，累计 token 超出预算时提前结束
+func (s *Service) delegate(ctx context.Context, req tools.DelegateRequest) (string, error) {
+	s.delegateMu.RLock()
+	cfg := s.delegateCfg
+	s.delegateMu.RUnlock()
+
+	if s.aiConfigResolver == nil {
+		return "", ErrNoAIConfig
+	}
+	aiConfig := s.aiConfigResolver(cfg.AIConfigID)
+	if aiConfig == nil {
+		return "", ErrNoAIConfig
+	}
+	budget := cfg.TokenBudget
+	if budget <= 0 {
+		budget = defaultDelegateTokenBudget
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, AgentTimeout)
+	defer cancel()
+
+	llm, err := s.modelFactory.CreateModel(ctx, aiConfig)
+	if err != nil {
+		return "", fmt.Errorf("create model error: %w", err)
+	}
+
+	genCfg := &genai.GenerateContentConfig{MaxOutputTokens: int32(min(budget, 4096))}
+	if aiConfig.MaxTokens > 0 && int32(aiConfig.MaxTokens) < genCfg.MaxOutputTokens {
+		genCfg.MaxOutputTokens = int32(aiConfig.MaxTokens)
+	}
+	subAgent, err := llmagent.New(llmagent.Config{
+		Name:                  "delegate",
+		Model:                 llm,
+		Description:           "子任务研究助理",
+		Instruction:           delegateInstruction,
+		Tools:                 s.delegateTools(cfg, req.Tools),
+		GenerateContentConfig: genCfg,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sessionService := session.InMemoryService()
+	r, err := runner.New(runner.Config{
+		AppName:        "jcp",
+		Agent:          subAgent,
+		SessionService: sessionService,
+	})
+	if err != nil {
+		return "", err
+	}
+	sessionID := fmt.Sprintf("delegate-%d", time.Now().UnixNano())
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{
+		AppName: "jcp", UserID: "user", SessionID: sessionID,
+	}); err != nil {
+		return "", fmt.Errorf("create session error: %w", err)
+	}
+
+	prompt := req.Task
+	if req.Context != "" {
+		prompt = fmt.Sprintf("背景信息：\n%s\n\n子任务：%s", req.Context, req.Task)
+	}
+	msg := &genai.Content{Role: "user", Parts: []*genai.Part{genai.NewPartFromText(prompt)}}
+
+	var summary string
+	used := 0
+	for event, err := range r.Run(ctx, "user", sessionID, msg, agent.RunConfig{}) {
+		if err != nil {
+			if summary != "" {
+				break
+			}
+			return "", err
+		}
+		if event == nil {
+			continue
+		}
+		if u := event.LLMResponse.UsageMetadata; u != nil {
+			used += int(u.TotalTokenCount)
+		}
+		if text := eventText(event.LLMResponse.Content); text != "" {
+			summary = text
+		}
+		if used > budget {
+			log.Warn("delegate 超出 token 预算: %d > %d", used, budget)
+			if summary == "" {
+				return "", fmt.Errorf("子代理超出 token 预算（%d）且未产出结果", budget)
+			}
+			summary += "\n\n（已达到 token 预算，结果可能不完整）"
+			break
+		}
+	}
+	if summary == "" {
+		return "", fmt.Errorf("子代理未返回结果")
+	}
+	return summary, nil
+}
+
+// delegateTools 计算子代理可用工具：请求的工具与允许列表取交集
+// 始终排除 delegate 自身和后台任务类工具
+func (s *Service) delegateTools(cfg models.DelegateConfig, requested []string) []tool.Tool {
+	if s.toolRegistry == nil {
+		return nil
+	}
+	allowed := cfg.AllowedTools
+	if len(allowed) == 0 {
+		allowed = defaultDelegateTools
+	}
+	names := allowed
+	if len(requested) > 0 {
+		names = nil
+		for _, name := range requested {
+			if slices.Contains(allowed, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	var result []tool.Tool
+	for _, t := range s.toolRegistry.GetTools(names) {
+		if t.Name() == tools.DelegateToolName || t.IsLongRunning() {
+			continue
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// eventText 提取事件中的正文文本（忽略思考过程）
+func eventText(content *genai.Content) string {
+	if content == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, part := range content.Parts {
+		if part.Text != "" && !part.Thought {
+			sb.WriteString(part.Text)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,52 @@
+package meeting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/run-bigpig/jcp/internal/adk"
+	"github.com/run-bigpig/jcp/internal/adk/prompts"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// DryRunAgent 试运行单个专家发言：按正常流程组装请求（提示词、工具筛选、上下文裁剪与生成参数），
+// 在发往模型前拦截并返回请求与 token 估算，不调用供应商接口；费用由调用方按价格表计算
+func (s *Service) DryRunAgent(
+	ctx context.Context,
+	aiConfig *models.AIConfig,
+	agentCfg *models.AgentConfig,
+	stock *models.Stock,
+	query string,
+	position *models.StockPosition,
+) (models.DryRunResult, error) {
+	result := models.DryRunResult{AgentID: agentCfg.ID, AgentName: agentCfg.Name}
+	agentAIConfig := s.resolveAgentAIConfig(agentCfg, aiConfig)
+	if agentAIConfig == nil {
+		return result, fmt.Errorf("未配置 AI 模型")
+	}
+	result.Model = agentAIConfig.ModelName
+	result.ContextWindow = adk.ContextWindowFor(agentAIConfig)
+
+	agentLLM, err := s.modelFactory.CreateModel(ctx, agentAIConfig)
+	if err != nil {
+		return result, fmt.Errorf("create model error: %w", err)
+	}
+	builder := s.createBuilder(agentLLM, agentAIConfig)
+	result.PromptVersion = builder.WithPromptVersion(prompts.FromContext(ctx)).PromptVersion()
+
+	dryRun := &adk.DryRun{}
+	agentCtx, cancel := context.WithTimeout(adk.WithDryRun(ctx, dryRun), AgentTimeout)
+	defer cancel()
+	if _, err := s.runSingleAgent(agentCtx, builder, agentCfg, stock, query, "", nil, position); err != nil {
+		return result, err
+	}
+	estimate, ok := dryRun.Estimate()
+	if !ok {
+		return result, fmt.Errorf("未能组装模型请求")
+	}
+	result.Request = estimate.Request
+	result.InputTokens = estimate.InputTokens
+	result.OutputTokens = estimate.OutputTokens
+	result.ToolCount = estimate.ToolCount
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package meeting
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/adk"
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+	"google.golang.org/adk/model"
+)
+
+// thinkingHeartbeatInterval 静默多久后开始发送思考心跳（之后每隔同样时长发送一次）
+const thinkingHeartbeatInterval = 10 * time.Second
+
+// thinkingHeartbeat 推理模型长时间无可见输出时定期发送“思考中”状态，避免界面看起来卡住
+// 可见正文、工具调用都会重置计时；工具执行期间暂停（由工具进度事件负责展示）
+type thinkingHeartbeat struct {
+	mu           sync.Mutex
+	silentSince  time.Time
+	paused       bool
+	thoughtText  string // 本轮静默期间收到的推理文本（用于估算 token）
+	thoughtUsage int    // 响应元数据中的推理 token 数（优先使用）
+}
+
+func newThinkingHeartbeat() *thinkingHeartbeat {
+	return &thinkingHeartbeat{silentSince: time.Now()}
+}
+
+// observe 根据模型响应更新静默状态
+func (h *thinkingHeartbeat) observe(resp *model.LLMResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if u := resp.UsageMetadata; u != nil && u.ThoughtsTokenCount > 0 {
+		h.thoughtUsage = int(u.ThoughtsTokenCount)
+	}
+	if _, ok := respmeta.ToolCallDeltaFromCustomMetadata(resp.CustomMetadata); ok {
+		h.paused = true
+	}
+	if resp.Content == nil {
+		return
+	}
+	for _, part := range resp.Content.Parts {
+		switch {
+		case part.FunctionCall != nil:
+			h.paused = true
+		case part.FunctionResponse != nil:
+			h.resetLocked()
+		case part.Thought:
+			h.thoughtText += part.Text
+		case part.Text != "":
+			h.resetLocked()
+		}
+	}
+}
+
+// restart 开始新一轮模型调用（如补发后台工具结果）时重新计时
+func (h *thinkingHeartbeat) restart() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.resetLocked()
+}
+
+// resetLocked 有可见输出或工具返回后重新开始计时
+func (h *thinkingHeartbeat) resetLocked() {
+	h.silentSince = time.Now()
+	h.paused = false
+	h.thoughtText = ""
+	h.thoughtUsage = 0
+}
+
+// status 返回当前静默时长与已推理 token 数，未达到心跳条件时返回 false
+func (h *thinkingHeartbeat) status(now time.Time) (time.Duration, int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	elapsed := now.Sub(h.silentSince)
+	if h.paused || elapsed < thinkingHeartbeatInterval {
+		return 0, 0, false
+	}
+	tokens := h.thoughtUsage
+	if tokens == 0 {
+		tokens = adk.EstimateTokens(h.thoughtText)
+	}
+	return elapsed, tokens, true
+}
+
+// start 在后台定期检查静默状态并发送心跳
+// 返回的 stop 会等待后台协程退出，保证专家发言结束后不再有心跳事件
+func (h *thinkingHeartbeat) start(ctx context.Context, emit func(content string)) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(thinkingHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if elapsed, tokens, ok := h.status(now); ok {
+					emit(thinkingMessage(elapsed, tokens))
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// thinkingMessage 心跳文案
+func thinkingMessage(elapsed time.Duration, tokens int) string {
+	secs := int(elapsed.Seconds())
+	if tokens > 0 {
+		return fmt.Sprintf("模型思考中（%ds，已推理约 %d tokens）", secs, tokens)
+	}
+	return fmt.Sprintf("模型思考中（%ds）", secs)
+}
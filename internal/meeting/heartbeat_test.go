@@ -0,0 +1,43 @@
+package meeting
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestThinkingHeartbeat(t *testing.T) {
+	h := newThinkingHeartbeat()
+	start := h.silentSince
+
+	if _, _, ok := h.status(start.Add(5 * time.Second)); ok {
+		t.Fatal("heartbeat before interval")
+	}
+
+	// 推理文本不算可见输出，继续计时并累计 token
+	h.observe(&model.LLMResponse{Content: &genai.Content{Parts: []*genai.Part{{Text: "先看均线", Thought: true}}}})
+	elapsed, tokens, ok := h.status(start.Add(12 * time.Second))
+	if !ok || elapsed != 12*time.Second || tokens == 0 {
+		t.Fatalf("status = %v, %d, %v", elapsed, tokens, ok)
+	}
+
+	// 元数据中的推理 token 优先
+	h.observe(&model.LLMResponse{UsageMetadata: &genai.GenerateContentResponseUsageMetadata{ThoughtsTokenCount: 321}})
+	if _, tokens, _ := h.status(start.Add(12 * time.Second)); tokens != 321 {
+		t.Errorf("tokens = %d, want 321", tokens)
+	}
+
+	// 工具执行期间暂停
+	h.observe(&model.LLMResponse{Content: &genai.Content{Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{Name: "get_kline"}}}}})
+	if _, _, ok := h.status(start.Add(time.Minute)); ok {
+		t.Error("heartbeat while tool running")
+	}
+
+	// 可见正文重置计时
+	h.observe(&model.LLMResponse{Content: &genai.Content{Parts: []*genai.Part{{Text: "结论"}}}})
+	if _, _, ok := h.status(time.Now().Add(5 * time.Second)); ok {
+		t.Error("heartbeat right after visible output")
+	}
+}
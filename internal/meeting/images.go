@@ -0,0 +1,52 @@
+package meeting
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/genai"
+)
+
+type imagesKey struct{}
+
+// WithImages 在 context 中附加用户消息的图片，专家发言时随提问一并发送给模型
+func WithImages(ctx context.Context, parts []*genai.Part) context.Context {
+	if len(parts) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, imagesKey{}, parts)
+}
+
+// imagesFromContext 取出用户消息附带的图片
+func imagesFromContext(ctx context.Context) []*genai.Part {
+	parts, _ := ctx.Value(imagesKey{}).([]*genai.Part)
+	return parts
+}
+
+// ImageParts 将前端上传的 base64 图片解码为 genai 内联数据
+func ImageParts(images []models.ChatImage) ([]*genai.Part, error) {
+	parts := make([]*genai.Part, 0, len(images))
+	for i, img := range images {
+		mimeType, data := img.MimeType, img.Data
+		// 兼容 data:image/png;base64,xxx 形式
+		if rest, ok := strings.CutPrefix(data, "data:"); ok {
+			header, payload, found := strings.Cut(rest, ",")
+			if !found {
+				return nil, fmt.Errorf("第 %d 张图片格式错误", i+1)
+			}
+			if mimeType == "" {
+				mimeType = strings.TrimSuffix(header, ";base64")
+			}
+			data = payload
+		}
+		raw, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 张图片解码失败: %w", i+1, err)
+		}
+		parts = append(parts, genai.NewPartFromBytes(raw, mimeType))
+	}
+	return parts, nil
+}
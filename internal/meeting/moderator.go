@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/run-bigpig/jcp/internal/adk/openai"
+	"github.com/run-bigpig/jcp/internal/adk/prompts"
 	"github.com/run-bigpig/jcp/internal/models"
 
 	"google.golang.org/adk/model"
@@ -43,7 +44,10 @@ type DiscussionEntry struct {
 
 // Analyze 分析用户意图并选择专家
 func (m *Moderator) Analyze(ctx context.Context, stock *models.Stock, query string, agents []models.AgentConfig) (*ModeratorDecision, error) {
-	prompt := m.buildAnalyzePrompt(stock, query, agents)
+	prompt, err := m.buildAnalyzePrompt(prompts.FromContext(ctx), stock, query, agents)
+	if err != nil {
+		return nil, err
+	}
 	content, err := m.generate(ctx, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("moderator analyze error: %w", err)
@@ -53,7 +57,10 @@ func (m *Moderator) Analyze(ctx context.Context, stock *models.Stock, query stri
 
 // Summarize 总结讨论并给出结论
 func (m *Moderator) Summarize(ctx context.Context, stock *models.Stock, query string, history []DiscussionEntry) (string, error) {
-	prompt := m.buildSummarizePrompt(stock, query, history)
+	prompt, err := m.buildSummarizePrompt(prompts.FromContext(ctx), stock, query, history)
+	if err != nil {
+		return "", err
+	}
 	return m.generate(ctx, prompt)
 }
 
@@ -86,44 +93,21 @@ func (m *Moderator) generate(ctx context.Context, prompt string) (string, error)
 }
 
 // buildAnalyzePrompt 构建意图分析 Prompt
-func (m *Moderator) buildAnalyzePrompt(stock *models.Stock, query string, agents []models.AgentConfig) string {
-	var sb strings.Builder
-	sb.WriteString("你是「财经会议室」的小韭菜，负责组织专家讨论。\n\n")
-	sb.WriteString("## 当前股票\n")
-	fmt.Fprintf(&sb, "%s (%s)，现价 %.2f，涨跌幅 %.2f%%\n\n", stock.Name, stock.Symbol, stock.Price, stock.ChangePercent)
-	sb.WriteString("## 老韭菜问题\n")
-	sb.WriteString(query + "\n\n")
-	sb.WriteString("## 可邀请的专家\n")
-	for _, a := range agents {
-		fmt.Fprintf(&sb, "- %s（ID: %s）：%s\n", a.Name, a.ID, a.Role)
-	}
-	sb.WriteString("\n## 你的任务\n")
-	sb.WriteString("1. 分析老韭菜问题的核心意图\n")
-	sb.WriteString(fmt.Sprintf("2. 除非用户特别约束专家数量,否则选择 1-%d 位最相关的专家\n", len(agents)))
-	sb.WriteString("3. 为每位选中的专家制定一个明确的、与其专业匹配的分析任务（不要照搬用户原话，要根据专家角色拆解）\n")
-	sb.WriteString("4. 生成讨论议题和开场白\n\n")
-	sb.WriteString("## 输出格式（仅输出JSON）\n")
-	sb.WriteString(`{"intent":"意图","selected":["id1","id2"],"tasks":{"id1":"该专家需要分析的具体问题","id2":"该专家需要分析的具体问题"},"topic":"议题","opening":"开场白"}`)
-	return sb.String()
+func (m *Moderator) buildAnalyzePrompt(version string, stock *models.Stock, query string, agents []models.AgentConfig) (string, error) {
+	return prompts.Render(version, prompts.ModeratorAnalyze, map[string]any{
+		"Stock":  stock,
+		"Query":  query,
+		"Agents": agents,
+	})
 }
 
 // buildSummarizePrompt 构建总结 Prompt
-func (m *Moderator) buildSummarizePrompt(stock *models.Stock, query string, history []DiscussionEntry) string {
-	var sb strings.Builder
-	sb.WriteString("你是会议小韭菜，请总结讨论并给老韭菜结论。\n\n")
-	fmt.Fprintf(&sb, "## 股票：%s (%s)\n\n", stock.Name, stock.Symbol)
-	sb.WriteString("## 老韭菜问题\n")
-	sb.WriteString(query + "\n\n")
-	sb.WriteString("## 讨论记录\n")
-	for _, e := range history {
-		fmt.Fprintf(&sb, "【%s（%s）】\n%s\n\n", e.AgentName, e.Role, e.Content)
-	}
-	sb.WriteString("## 输出要求\n")
-	sb.WriteString("1. 核心结论（直接回答老韭菜）\n")
-	sb.WriteString("2. 各方观点摘要\n")
-	sb.WriteString("3. 综合建议\n\n")
-	sb.WriteString("控制在 300 字以内。")
-	return sb.String()
+func (m *Moderator) buildSummarizePrompt(version string, stock *models.Stock, query string, history []DiscussionEntry) (string, error) {
+	return prompts.Render(version, prompts.ModeratorSummarize, map[string]any{
+		"Stock":   stock,
+		"Query":   query,
+		"History": history,
+	})
 }
 
 // parseDecision 解析小韭菜决策 JSON（增强健壮性）
@@ -151,6 +135,11 @@ func (m *Moderator) parseDecision(content string) (*ModeratorDecision, error) {
 
 // extractJSON 从文本中提取 JSON 对象
 func (m *Moderator) extractJSON(content string) string {
+	return extractJSONObject(content)
+}
+
+// extractJSONObject 从模型输出中提取 JSON 对象（兼容代码块和前后夹杂说明文字）
+func extractJSONObject(content string) string {
 	// 方法1: 尝试直接解析整个内容
 	content = strings.TrimSpace(content)
 	if strings.HasPrefix(content, "{") && strings.HasSuffix(content, "}") {
@@ -0,0 +1,192 @@
+package meeting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// MeetingModePipeline 声明式流水线模式
+const MeetingModePipeline = "pipeline"
+
+// pipelineTemplateData 步骤提示模板可用的变量
+// 例：{{.Stock.Name}}、{{.Query}}、{{.Prev}}、{{index .Steps "fundamental"}}
+type pipelineTemplateData struct {
+	Stock *models.Stock
+	Query string
+	Prev  string            // 上一步输出
+	Steps map[string]string // 已完成步骤的输出，按步骤 ID 索引
+}
+
+// RunPipeline 按顺序执行声明式流水线，每步完成后通过 respCallback 推送
+// 步骤失败且未设置 continueOnError 时中止，返回已完成的结果
+func (s *Service) RunPipeline(
+	ctx context.Context,
+	aiConfig *models.AIConfig,
+	pipeline *models.Pipeline,
+	stock *models.Stock,
+	query string,
+	respCallback ResponseCallback,
+	progressCallback ProgressCallback,
+	position *models.StockPosition,
+) ([]ChatResponse, error) {
+	if aiConfig == nil {
+		return nil, ErrNoAIConfig
+	}
+	if len(pipeline.Steps) == 0 {
+		return nil, fmt.Errorf("流水线 %s 没有步骤", pipeline.ID)
+	}
+
+	data := pipelineTemplateData{Stock: stock, Query: query, Steps: make(map[string]string)}
+	var responses []ChatResponse
+	for i := range pipeline.Steps {
+		step := &pipeline.Steps[i]
+		resp, err := s.runPipelineStep(ctx, aiConfig, step, &data, progressCallback, position)
+		resp.Round = i + 1
+		if i == len(pipeline.Steps)-1 {
+			resp.MsgType = "summary"
+		}
+		responses = append(responses, resp)
+		if respCallback != nil {
+			respCallback(resp)
+		}
+
+		if err != nil {
+			log.Warn("pipeline %s step %s failed: %v", pipeline.ID, step.ID, err)
+			if !step.ContinueOnError || ctx.Err() != nil {
+				return responses, err
+			}
+			continue
+		}
+		data.Prev = resp.Content
+		data.Steps[step.ID] = resp.Content
+	}
+	return responses, nil
+}
+
+// runPipelineStep 将步骤转换为专家配置并执行
+func (s *Service) runPipelineStep(
+	ctx context.Context,
+	aiConfig *models.AIConfig,
+	step *models.PipelineStep,
+	data *pipelineTemplateData,
+	progressCallback ProgressCallback,
+	position *models.StockPosition,
+) (ChatResponse, error) {
+	agentCfg := models.AgentConfig{
+		ID:          step.ID,
+		Name:        step.Name,
+		Role:        step.Role,
+		Instruction: step.Instruction,
+		Tools:       step.Tools,
+		MCPServers:  step.MCPServers,
+		Enabled:     true,
+		AIConfigID:  step.AIConfigID,
+	}
+	resp := ChatResponse{
+		AgentID:     step.ID,
+		AgentName:   step.Name,
+		Role:        step.Role,
+		MsgType:     "opinion",
+		MeetingMode: MeetingModePipeline,
+	}
+
+	prompt, err := renderStepPrompt(step, data)
+	if err != nil {
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	stepAIConfig := s.resolveAgentAIConfig(&agentCfg, aiConfig)
+	llm, err := s.modelFactory.CreateModel(ctx, stepAIConfig)
+	if err != nil {
+		resp.Error = err.Error()
+		return resp, fmt.Errorf("create model error: %w", err)
+	}
+	builder := s.createBuilder(llm, stepAIConfig)
+
+	emitProgress(progressCallback, ProgressEvent{
+		Type: "agent_start", AgentID: step.ID, AgentName: step.Name, Detail: step.Role,
+	})
+	out, err := retryRun(ctx, MaxAgentRetries, func() (agentOutput, error) {
+		stepCtx, cancel := context.WithTimeout(ctx, AgentTimeout)
+		defer cancel()
+		return s.runSingleAgent(stepCtx, builder, &agentCfg, data.Stock, prompt, "", progressCallback, position)
+	})
+	emitProgress(progressCallback, ProgressEvent{
+		Type: "agent_done", AgentID: step.ID, AgentName: step.Name,
+	})
+
+	resp.Content = out.Content
+	resp.Metadata = out.Metadata
+	resp.Trace = out.Trace
+	if err != nil {
+		resp.Error = err.Error()
+		resp.Partial = out.Content != ""
+		return resp, err
+	}
+	resp.Thinking = out.Thinking
+	resp.Sources = out.Sources
+
+	if step.OutputSchema != nil {
+		normalized, err := validateStepOutput(out.Content, step.OutputSchema)
+		if err != nil {
+			resp.Error = err.Error()
+			return resp, err
+		}
+		resp.Content = normalized
+	}
+	return resp, nil
+}
+
+// renderStepPrompt 渲染步骤提示模板，设置了输出 Schema 时追加 JSON 输出要求
+func renderStepPrompt(step *models.PipelineStep, data *pipelineTemplateData) (string, error) {
+	prompt := data.Query
+	if step.Prompt != "" {
+		tpl, err := template.New(step.ID).Option("missingkey=zero").Parse(step.Prompt)
+		if err != nil {
+			return "", fmt.Errorf("步骤 %s 提示模板解析失败: %w", step.ID, err)
+		}
+		var sb strings.Builder
+		if err := tpl.Execute(&sb, data); err != nil {
+			return "", fmt.Errorf("步骤 %s 提示模板渲染失败: %w", step.ID, err)
+		}
+		prompt = sb.String()
+	} else if data.Prev != "" {
+		prompt = fmt.Sprintf("%s\n\n【上一步结论】\n%s", data.Query, data.Prev)
+	}
+
+	if step.OutputSchema != nil {
+		schema, err := json.MarshalIndent(step.OutputSchema, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("步骤 %s 输出 Schema 无效: %w", step.ID, err)
+		}
+		prompt += "\n\n请只输出一个符合以下 JSON Schema 的 JSON 对象，不要输出其他内容：\n" + string(schema)
+	}
+	return prompt, nil
+}
+
+// validateStepOutput 提取并校验步骤输出的 JSON（仅检查顶层 required 字段）
+func validateStepOutput(content string, schema map[string]any) (string, error) {
+	jsonStr := extractJSONObject(content)
+	if jsonStr == "" {
+		return "", fmt.Errorf("输出不是 JSON: %s", truncateString(content, 200))
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(jsonStr), &obj); err != nil {
+		return "", fmt.Errorf("输出 JSON 解析失败: %w", err)
+	}
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			key, _ := r.(string)
+			if _, exists := obj[key]; key != "" && !exists {
+				return "", fmt.Errorf("输出缺少必需字段: %s", key)
+			}
+		}
+	}
+	return jsonStr, nil
+}
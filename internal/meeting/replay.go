@@ -0,0 +1,49 @@
+package meeting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/adk"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// Replay 用保存的请求快照重新调用模型（单次调用，不执行工具），返回模型回答
+// 快照中已包含渲染后的系统提示词、历史与工具结果，因此与原消息发出时的输入一致
+func (s *Service) Replay(ctx context.Context, aiConfig *models.AIConfig, request json.RawMessage) (string, error) {
+	if aiConfig == nil {
+		return "", fmt.Errorf("未配置 AI 模型")
+	}
+	var snapshot adk.RequestSnapshot
+	if err := json.Unmarshal(request, &snapshot); err != nil {
+		return "", fmt.Errorf("解析请求快照失败: %w", err)
+	}
+	llm, err := s.modelFactory.CreateModel(ctx, aiConfig)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for resp, err := range llm.GenerateContent(ctx, snapshot.Request(aiConfig.ModelName), false) {
+		if err != nil {
+			return sb.String(), err
+		}
+		if resp == nil || resp.Content == nil {
+			continue
+		}
+		for _, part := range resp.Content.Parts {
+			switch {
+			case part.Thought:
+			case part.FunctionCall != nil:
+				// 重放不执行工具，只记录模型想要调用的工具
+				args, _ := json.Marshal(part.FunctionCall.Args)
+				fmt.Fprintf(&sb, "[调用工具 %s %s]\n", part.FunctionCall.Name, args)
+			case part.Text != "":
+				sb.WriteString(part.Text)
+			}
+		}
+	}
+	return sb.String(), nil
+}
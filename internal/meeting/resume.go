@@ -0,0 +1,111 @@
+package meeting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/adk/openai"
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/adk/model"
+)
+
+// continuationPromptTpl 续写提示词模板：原始问题 + 已输出的部分内容
+const continuationPromptTpl = `%s
+
+【续写】你之前的回答因网络中断未完成，已输出的内容如下：
+"""
+%s
+"""
+请从中断处直接继续输出剩余内容，不要重复已输出的部分，也不要添加任何说明。`
+
+// responseRetriever 支持按响应ID取回结果的模型（Responses API）
+type responseRetriever interface {
+	RetrieveResponse(ctx context.Context, responseID string) (*model.LLMResponse, error)
+}
+
+// ResumeSingleAgent 恢复被中断的专家发言
+// Responses API 优先按响应ID取回完整结果；其余情况通过续写提示词从中断处继续
+func (s *Service) ResumeSingleAgent(
+	ctx context.Context,
+	aiConfig *models.AIConfig,
+	agentCfg *models.AgentConfig,
+	stock *models.Stock,
+	query string,
+	partial string,
+	responseID string,
+	progressCallback ProgressCallback,
+	position *models.StockPosition,
+) (ChatResponse, error) {
+	if responseID != "" {
+		if resp, ok := s.retrieveAgentResponse(ctx, aiConfig, agentCfg, responseID); ok {
+			emitProgress(progressCallback, ProgressEvent{
+				Type: "agent_done", AgentID: agentCfg.ID, AgentName: agentCfg.Name,
+			})
+			return resp, nil
+		}
+	}
+
+	resumeQuery := fmt.Sprintf(continuationPromptTpl, query, partial)
+	resp, err := s.RetrySingleAgent(ctx, aiConfig, agentCfg, stock, resumeQuery, progressCallback, position)
+	resp.Content = partial + resp.Content
+	if responseID != "" && resp.Trace != nil {
+		resp.Trace.Fallbacks = append(resp.Trace.Fallbacks, FallbackContinuation)
+	}
+	if err != nil {
+		resp.Partial = resp.Content != ""
+	}
+	return resp, err
+}
+
+// retrieveAgentResponse 按响应ID取回已完成的结果，不支持或未完成时返回 false
+func (s *Service) retrieveAgentResponse(ctx context.Context, aiConfig *models.AIConfig, agentCfg *models.AgentConfig, responseID string) (ChatResponse, bool) {
+	agentAIConfig := s.resolveAgentAIConfig(agentCfg, aiConfig)
+	llm, err := s.modelFactory.CreateModel(ctx, agentAIConfig)
+	if err != nil {
+		return ChatResponse{}, false
+	}
+	retriever, ok := llm.(responseRetriever)
+	if !ok {
+		return ChatResponse{}, false
+	}
+
+	llmResp, err := retriever.RetrieveResponse(ctx, responseID)
+	if err != nil {
+		log.Warn("retrieve response %s failed, fallback to continuation: %v", responseID, err)
+		return ChatResponse{}, false
+	}
+
+	if llmResp.Content == nil {
+		return ChatResponse{}, false
+	}
+	var sb, thinking strings.Builder
+	for _, part := range llmResp.Content.Parts {
+		// 最终输出仍包含工具调用说明回答未结束，只能续写
+		if part.FunctionCall != nil {
+			return ChatResponse{}, false
+		}
+		if part.Thought {
+			thinking.WriteString(part.Text)
+		} else {
+			sb.WriteString(part.Text)
+		}
+	}
+	content := openai.FilterVendorToolCallMarkers(sb.String())
+	if content == "" {
+		return ChatResponse{}, false
+	}
+
+	return ChatResponse{
+		AgentID:     agentCfg.ID,
+		AgentName:   agentCfg.Name,
+		Role:        agentCfg.Role,
+		Content:     content,
+		Thinking:    thinking.String(),
+		Metadata:    toResponseMeta(respmeta.FromCustomMetadata(llmResp.CustomMetadata)),
+		Round:       1,
+		MsgType:     "opinion",
+		MeetingMode: MeetingModeDirect,
+	}, true
+}
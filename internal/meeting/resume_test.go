@@ -0,0 +1,108 @@
+package meeting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// fakeChatServer 模拟 OpenAI 兼容的 Chat Completions 接口，记录请求中的用户消息
+func fakeChatServer(t *testing.T, reply string) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var prompts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Stream   bool `json:"stream"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content any    `json:"content"`
+			} `json:"messages"`
+		}
+		json.Unmarshal(body, &req)
+		mu.Lock()
+		for _, m := range req.Messages {
+			if m.Role == "user" {
+				prompts = append(prompts, fmt.Sprint(m.Content))
+			}
+		}
+		mu.Unlock()
+
+		content, _ := json.Marshal(reply)
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":%s},\"finish_reason\":null}]}\n\n", content)
+			fmt.Fprint(w, "data: {\"id\":\"c1\",\"object\":\"chat.completion.chunk\",\"model\":\"m\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5,\"total_tokens\":15}}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, "{\"id\":\"c1\",\"object\":\"chat.completion\",\"model\":\"m\",\"choices\":[{\"index\":0,\"message\":{\"role\":\"assistant\",\"content\":%s},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":5,\"total_tokens\":15}}", content)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), prompts...)
+	}
+}
+
+func TestResumeSingleAgent_ContinuesPartialOutput(t *testing.T) {
+	srv, prompts := fakeChatServer(t, "，短期关注 1700 支撑。")
+	s := NewServiceFull(nil, nil)
+	aiConfig := &models.AIConfig{ID: "ai", Provider: models.AIProviderOpenAI, BaseURL: srv.URL + "/v1", APIKey: "k", ModelName: "m", MaxAttempts: 1}
+	agent := &models.AgentConfig{ID: "tech", Name: "技术分析师", Role: "技术面", Instruction: "你是技术分析师"}
+	stock := &models.Stock{Symbol: "sh600519", Name: "贵州茅台"}
+
+	partial := "均线多头排列"
+	resp, err := s.ResumeSingleAgent(context.Background(), aiConfig, agent, stock, "茅台走势如何", partial, "", nil, nil)
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if resp.Content != "均线多头排列，短期关注 1700 支撑。" {
+		t.Errorf("content = %q", resp.Content)
+	}
+	if resp.Partial {
+		t.Error("completed resume should not be partial")
+	}
+
+	// 续写提示词携带原始问题与已输出的部分内容
+	var found bool
+	for _, p := range prompts() {
+		if strings.Contains(p, "茅台走势如何") && strings.Contains(p, "【续写】") && strings.Contains(p, partial) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("continuation prompt not sent, user prompts = %q", prompts())
+	}
+}
+
+func TestResumeSingleAgent_KeepsPartialOnFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"invalid request","type":"invalid_request_error"}}`)
+	}))
+	defer srv.Close()
+	s := NewServiceFull(nil, nil)
+	aiConfig := &models.AIConfig{ID: "ai", Provider: models.AIProviderOpenAI, BaseURL: srv.URL + "/v1", APIKey: "k", ModelName: "m", MaxAttempts: 1}
+	agent := &models.AgentConfig{ID: "tech", Name: "技术分析师", Role: "技术面"}
+
+	resp, err := s.ResumeSingleAgent(context.Background(), aiConfig, agent, &models.Stock{Symbol: "sh600519", Name: "贵州茅台"}, "茅台走势如何", "均线多头排列", "", nil, nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !resp.Partial || !strings.HasPrefix(resp.Content, "均线多头排列") {
+		t.Errorf("failed resume should keep the partial output: %+v", resp)
+	}
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/run-bigpig/jcp/internal/adk"
 	"github.com/run-bigpig/jcp/internal/adk/mcp"
 	"github.com/run-bigpig/jcp/internal/adk/openai"
+	"github.com/run-bigpig/jcp/internal/adk/prompts"
+	"github.com/run-bigpig/jcp/internal/adk/providererr"
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
 	"github.com/run-bigpig/jcp/internal/adk/tools"
 	"github.com/run-bigpig/jcp/internal/logger"
 	"github.com/run-bigpig/jcp/internal/memory"
@@ -39,8 +42,15 @@ const (
 	MaxAgentRetries = 2                // 单个专家最大重试次数
 	RetryBaseDelay  = 2 * time.Second  // 指数退避基础延迟
 	RetryMaxDelay   = 15 * time.Second // 指数退避最大延迟
+
+	RetryMaxRateLimitDelay = time.Minute // 按限流恢复时间等待的上限
+
+	maxPauseContinuations = 3 // 回合被供应商暂停（pause_turn）时自动继续的最多次数
 )
 
+// pauseContinuePrompt 回合被暂停后请求模型继续的提示
+const pauseContinuePrompt = "请继续完成上面未完成的回答。"
+
 // 错误定义
 var (
 	ErrMeetingTimeout   = errors.New("会议超时，已返回部分结果")
@@ -58,6 +68,14 @@ func isRetryableError(err error) bool {
 	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
 		return false
 	}
+	// 流式空闲超时：连接已被中断，重新发起请求
+	if errors.Is(err, adk.ErrStreamIdle) {
+		return true
+	}
+	// 供应商错误按状态码与错误码判断（限流、过载可重试；鉴权失败、额度耗尽不重试）
+	if retryable, known := providererr.Retryable(err); known {
+		return retryable
+	}
 	msg := err.Error()
 	// 配置类错误不重试
 	if strings.Contains(msg, "config") || strings.Contains(msg, "not found") {
@@ -67,25 +85,38 @@ func isRetryableError(err error) bool {
 }
 
 // retryRun 带指数退避的重试包装
-// 在父 ctx 未取消的前提下，最多重试 maxRetries 次
-func retryRun(ctx context.Context, maxRetries int, fn func() (string, error)) (string, error) {
-	result, err := fn()
+// 在父 ctx 未取消的前提下，最多重试 maxRetries 次；失败时返回最后一次的结果（可能含部分输出）
+func retryRun[T any](ctx context.Context, maxRetries int, fn func() (T, error)) (result T, err error) {
+	started := time.Now()
+	retries := 0
+	defer func() {
+		if rec, ok := any(&result).(retryRecorder); ok {
+			rec.recordRetries(retries, started)
+		}
+	}()
+
+	result, err = fn()
 	if err == nil || !isRetryableError(err) {
 		return result, err
 	}
 
 	var lastErr error = err
 	for i := 1; i <= maxRetries; i++ {
+		retries = i
 		// 指数退避：baseDelay * 2^(i-1)，上限 RetryMaxDelay
 		delay := RetryBaseDelay * time.Duration(1<<(i-1))
 		if delay > RetryMaxDelay {
 			delay = RetryMaxDelay
 		}
+		// 限流时等待供应商给出的恢复时间
+		if d := providererr.RetryAfter(lastErr); d > delay {
+			delay = min(d, RetryMaxRateLimitDelay)
+		}
 		log.Warn("retry %d/%d after %v, last error: %v", i, maxRetries, delay, lastErr)
 
 		select {
 		case <-ctx.Done():
-			return "", ctx.Err()
+			return result, ctx.Err()
 		case <-time.After(delay):
 		}
 
@@ -96,10 +127,15 @@ func retryRun(ctx context.Context, maxRetries int, fn func() (string, error)) (s
 		}
 		lastErr = err
 		if !isRetryableError(err) {
-			return "", err
+			return result, err
 		}
 	}
-	return "", fmt.Errorf("重试 %d 次后仍失败: %w", maxRetries, lastErr)
+	return result, fmt.Errorf("重试 %d 次后仍失败: %w", maxRetries, lastErr)
+}
+
+// retryRecorder 需要感知重试次数的结果类型（如执行轨迹）
+type retryRecorder interface {
+	recordRetries(n int, started time.Time)
 }
 
 // AIConfigResolver AI配置解析器函数类型
@@ -127,25 +163,37 @@ const MeetingStateTTL = 10 * time.Minute
 
 // Service 会议室服务，编排多专家并行分析
 type Service struct {
-	modelFactory      *adk.ModelFactory
-	toolRegistry      *tools.Registry
-	mcpManager        *mcp.Manager
-	memoryManager     *memory.Manager
-	memoryAIConfig    *models.AIConfig         // 记忆管理使用的 LLM 配置
-	moderatorAIConfig *models.AIConfig         // 意图分析(小韭菜)使用的 LLM 配置
-	aiConfigResolver  AIConfigResolver         // AI配置解析器
-	meetingStates     map[string]*MeetingState // 中断的会议状态缓存，key: stockCode
-	meetingStatesMu   sync.RWMutex
+	modelFactory       *adk.ModelFactory
+	toolRegistry       *tools.Registry
+	mcpManager         *mcp.Manager
+	memoryManager      *memory.Manager
+	memoryAIConfig     *models.AIConfig                     // 记忆管理使用的 LLM 配置
+	moderatorAIConfig  *models.AIConfig                     // 意图分析(小韭菜)使用的 LLM 配置
+	aiConfigResolver   AIConfigResolver                     // AI配置解析器
+	notesProvider      func(stockCode string) string        // 研究笔记上下文提供者
+	userMemoryProvider func() string                        // 用户级全局记忆上下文提供者
+	documentProvider   func(stockCode, query string) string // 已收录文档检索上下文提供者
+	signalProvider     func(stockCode string) string        // 规则信号上下文提供者
+	meetingStates      map[string]*MeetingState             // 中断的会议状态缓存，key: stockCode
+	meetingStatesMu    sync.RWMutex
+	delegateCfg        models.DelegateConfig // 子代理委派配置
+	delegateMu         sync.RWMutex
+	riskProfile        models.RiskProfile // 用户风险画像
+	riskMu             sync.RWMutex
 }
 
 // NewServiceFull 创建完整配置的会议室服务
 func NewServiceFull(registry *tools.Registry, mcpMgr *mcp.Manager) *Service {
-	return &Service{
+	s := &Service{
 		modelFactory:  adk.NewModelFactory(),
 		toolRegistry:  registry,
 		mcpManager:    mcpMgr,
 		meetingStates: make(map[string]*MeetingState),
 	}
+	if registry != nil {
+		registry.SetDelegateFunc(s.delegate)
+	}
+	return s
 }
 
 // SetMemoryManager 设置记忆管理器
@@ -158,11 +206,118 @@ func (s *Service) SetMemoryAIConfig(aiConfig *models.AIConfig) {
 	s.memoryAIConfig = aiConfig
 }
 
+// memoryEmbedder 长期向量记忆使用的向量化器：优先使用记忆 LLM 配置的向量模型，否则使用会议配置（均未配置时为本地向量）
+func (s *Service) memoryEmbedder(aiConfig *models.AIConfig) adk.Embedder {
+	if s.memoryAIConfig != nil && s.memoryAIConfig.EmbeddingModel != "" {
+		aiConfig = s.memoryAIConfig
+	}
+	return s.modelFactory.CreateEmbedder(aiConfig)
+}
+
 // SetModeratorAIConfig 设置意图分析(小韭菜)使用的 LLM 配置
 func (s *Service) SetModeratorAIConfig(aiConfig *models.AIConfig) {
 	s.moderatorAIConfig = aiConfig
 }
 
+// SetNotesProvider 设置研究笔记上下文提供者
+// 研究笔记独立于记忆压缩，每次会议都会完整注入
+func (s *Service) SetNotesProvider(provider func(stockCode string) string) {
+	s.notesProvider = provider
+}
+
+// withResearchNotes 将研究笔记拼接到记忆上下文之前
+func (s *Service) withResearchNotes(stockCode, memoryContext string) string {
+	if s.notesProvider == nil {
+		return memoryContext
+	}
+	notes := s.notesProvider(stockCode)
+	if notes == "" {
+		return memoryContext
+	}
+	if memoryContext == "" {
+		return notes
+	}
+	return notes + "\n" + memoryContext
+}
+
+// SetUserMemoryProvider 设置用户级全局记忆（投资风格、风险承受等）上下文提供者，所有股票的会议共享
+func (s *Service) SetUserMemoryProvider(provider func() string) {
+	s.userMemoryProvider = provider
+}
+
+// withUserMemory 将全局记忆拼接到记忆上下文之前
+func (s *Service) withUserMemory(memoryContext string) string {
+	if s.userMemoryProvider == nil {
+		return memoryContext
+	}
+	prefs := s.userMemoryProvider()
+	if prefs == "" {
+		return memoryContext
+	}
+	if memoryContext == "" {
+		return prefs
+	}
+	return prefs + "\n" + memoryContext
+}
+
+// SetSignalProvider 设置规则信号（金叉、新高、放量等）上下文提供者
+func (s *Service) SetSignalProvider(provider func(stockCode string) string) {
+	s.signalProvider = provider
+}
+
+// withSignals 将规则信号拼接到记忆上下文之前
+func (s *Service) withSignals(stockCode, memoryContext string) string {
+	if s.signalProvider == nil {
+		return memoryContext
+	}
+	signals := s.signalProvider(stockCode)
+	if signals == "" {
+		return memoryContext
+	}
+	if memoryContext == "" {
+		return signals
+	}
+	return signals + "\n" + memoryContext
+}
+
+// SetDocumentProvider 设置已收录文档（公告/研报 PDF）的检索上下文提供者
+func (s *Service) SetDocumentProvider(provider func(stockCode, query string) string) {
+	s.documentProvider = provider
+}
+
+// withDocuments 将与问题相关的文档摘录追加到记忆上下文之后，供专家引用
+func (s *Service) withDocuments(stockCode, query, memoryContext string) string {
+	if s.documentProvider == nil {
+		return memoryContext
+	}
+	docs := s.documentProvider(stockCode, query)
+	if docs == "" {
+		return memoryContext
+	}
+	if memoryContext == "" {
+		return docs
+	}
+	return memoryContext + "\n" + docs
+}
+
+// SetRiskProfile 设置注入专家提示词的用户风险画像
+func (s *Service) SetRiskProfile(profile models.RiskProfile) {
+	s.riskMu.Lock()
+	defer s.riskMu.Unlock()
+	s.riskProfile = profile
+}
+
+// currentRiskProfile 返回风险画像副本，未填写时为 nil
+func (s *Service) currentRiskProfile() *models.RiskProfile {
+	s.riskMu.RLock()
+	defer s.riskMu.RUnlock()
+	if s.riskProfile.IsEmpty() {
+		return nil
+	}
+	p := s.riskProfile
+	return &p
+}
+
 // SetAIConfigResolver 设置 AI 配置解析器
 func (s *Service) SetAIConfigResolver(resolver AIConfigResolver) {
 	s.aiConfigResolver = resolver
@@ -188,14 +343,19 @@ const (
 
 // ChatResponse 聊天响应
 type ChatResponse struct {
-	AgentID     string `json:"agentId"`
-	AgentName   string `json:"agentName"`
-	Role        string `json:"role"`
-	Content     string `json:"content"`
-	Round       int    `json:"round"`
-	MsgType     string `json:"msgType"`               // opening/opinion/summary
-	Error       string `json:"error,omitempty"`       // 失败时的错误信息，前端据此显示重试按钮
-	MeetingMode string `json:"meetingMode,omitempty"` // smart=串行, direct=独立
+	AgentID     string               `json:"agentId"`
+	AgentName   string               `json:"agentName"`
+	Role        string               `json:"role"`
+	Content     string               `json:"content"`
+	Round       int                  `json:"round"`
+	MsgType     string               `json:"msgType"`               // opening/opinion/summary
+	Error       string               `json:"error,omitempty"`       // 失败时的错误信息，前端据此显示重试按钮
+	MeetingMode string               `json:"meetingMode,omitempty"` // smart=串行, direct=独立
+	Thinking    string               `json:"thinking,omitempty"`    // 模型思考过程
+	Sources     []models.ToolSource  `json:"sources,omitempty"`     // 本次发言引用的工具数据
+	Metadata    *models.ResponseMeta `json:"metadata,omitempty"`    // 供应商响应元数据
+	Partial     bool                 `json:"partial,omitempty"`     // 流式中断，Content 为已生成的部分内容
+	Trace       *models.TurnTrace    `json:"-"`                     // 执行轨迹，由调用方持久化
 }
 
 // ResponseCallback 响应回调函数类型
@@ -204,7 +364,7 @@ type ResponseCallback func(resp ChatResponse)
 
 // ProgressEvent 进度事件（细粒度实时反馈）
 type ProgressEvent struct {
-	Type      string `json:"type"`      // thinking/tool_call/tool_result/streaming/agent_start/agent_done
+	Type      string `json:"type"`      // thinking/tool_call_delta/tool_call/tool_result/streaming/agent_start/agent_done
 	AgentID   string `json:"agentId"`   // 当前专家 ID
 	AgentName string `json:"agentName"` // 当前专家名称
 	Detail    string `json:"detail"`    // 工具名称或阶段描述
@@ -286,6 +446,8 @@ func (s *Service) RunSmartMeetingSync(ctx context.Context, aiConfig *models.AICo
 		} else {
 			s.memoryManager.SetLLM(llm)
 		}
+		s.memoryManager.SetEmbedder(s.memoryEmbedder(aiConfig))
+		s.memoryManager.SetTokenBudget(adk.TokenCounterFor(aiConfig), adk.ContextWindowFor(aiConfig))
 	}
 
 	// 加载股票记忆
@@ -293,8 +455,12 @@ func (s *Service) RunSmartMeetingSync(ctx context.Context, aiConfig *models.AICo
 	var memoryContext string
 	if s.memoryManager != nil {
 		stockMemory, _ = s.memoryManager.GetOrCreate(req.Stock.Symbol, req.Stock.Name)
-		memoryContext = s.memoryManager.BuildContext(stockMemory, req.Query)
+		memoryContext = s.memoryManager.BuildContext(meetingCtx, stockMemory, req.Query)
 	}
+	memoryContext = s.withResearchNotes(req.Stock.Symbol, memoryContext)
+	memoryContext = s.withUserMemory(memoryContext)
+	memoryContext = s.withSignals(req.Stock.Symbol, memoryContext)
+	memoryContext = s.withDocuments(req.Stock.Symbol, req.Query, memoryContext)
 
 	log.Info("[OpenClaw] stock: %s, query: %s, agents: %d", req.Stock.Symbol, req.Query, len(req.AllAgents))
 
@@ -346,11 +512,12 @@ func (s *Service) RunSmartMeetingSync(ctx context.Context, aiConfig *models.AICo
 			}
 		}
 
-		content, err := retryRun(meetingCtx, MaxAgentRetries, func() (string, error) {
+		out, err := retryRun(meetingCtx, MaxAgentRetries, func() (agentOutput, error) {
 			agentCtx, agentCancel := context.WithTimeout(meetingCtx, AgentTimeout)
 			defer agentCancel()
 			return s.runSingleAgent(agentCtx, builder, &agentCfg, &req.Stock, agentQuery, previousContext, nil, req.Position)
 		})
+		content := out.Content
 
 		if err != nil {
 			log.Error("[OpenClaw] agent %s failed, skip: %v", agentCfg.ID, err)
@@ -446,6 +613,8 @@ func (s *Service) RunSmartMeetingWithCallback(ctx context.Context, aiConfig *mod
 		} else {
 			s.memoryManager.SetLLM(llm)
 		}
+		s.memoryManager.SetEmbedder(s.memoryEmbedder(aiConfig))
+		s.memoryManager.SetTokenBudget(adk.TokenCounterFor(aiConfig), adk.ContextWindowFor(aiConfig))
 	}
 
 	// 加载股票记忆（如果启用了记忆管理）
@@ -453,11 +622,15 @@ func (s *Service) RunSmartMeetingWithCallback(ctx context.Context, aiConfig *mod
 	var memoryContext string
 	if s.memoryManager != nil {
 		stockMemory, _ = s.memoryManager.GetOrCreate(req.Stock.Symbol, req.Stock.Name)
-		memoryContext = s.memoryManager.BuildContext(stockMemory, req.Query)
+		memoryContext = s.memoryManager.BuildContext(meetingCtx, stockMemory, req.Query)
 		if memoryContext != "" {
 			log.Debug("loaded memory context for %s, len: %d", req.Stock.Symbol, len(memoryContext))
 		}
 	}
+	memoryContext = s.withResearchNotes(req.Stock.Symbol, memoryContext)
+	memoryContext = s.withUserMemory(memoryContext)
+	memoryContext = s.withSignals(req.Stock.Symbol, memoryContext)
+	memoryContext = s.withDocuments(req.Stock.Symbol, req.Query, memoryContext)
 
 	log.Info("stock: %s, query: %s, agents: %d", req.Stock.Symbol, req.Query, len(req.AllAgents))
 
@@ -553,11 +726,12 @@ func (s *Service) RunSmartMeetingWithCallback(ctx context.Context, aiConfig *mod
 		}
 
 		// 运行单个专家（带超时控制 + 指数退避重试）
-		content, err := retryRun(meetingCtx, MaxAgentRetries, func() (string, error) {
+		out, err := retryRun(meetingCtx, MaxAgentRetries, func() (agentOutput, error) {
 			agentCtx, agentCancel := context.WithTimeout(meetingCtx, AgentTimeout)
 			defer agentCancel()
 			return s.runSingleAgent(agentCtx, builder, &agentCfg, &req.Stock, agentQuery, previousContext, progressCallback, req.Position)
 		})
+		content := out.Content
 
 		if err != nil {
 			emitProgress(progressCallback, ProgressEvent{
@@ -573,11 +747,14 @@ func (s *Service) RunSmartMeetingWithCallback(ctx context.Context, aiConfig *mod
 				AgentID:     agentCfg.ID,
 				AgentName:   agentCfg.Name,
 				Role:        agentCfg.Role,
-				Content:     "",
+				Content:     content,
 				Round:       1,
 				MsgType:     "opinion",
-				Error:       err.Error(),
+				Error:       providererr.UserMessage(err),
 				MeetingMode: MeetingModeSmart,
+				Partial:     content != "",
+				Metadata:    out.Metadata,
+				Trace:       out.Trace,
 			}
 			responses = append(responses, failedResp)
 			if respCallback != nil {
@@ -629,6 +806,10 @@ func (s *Service) RunSmartMeetingWithCallback(ctx context.Context, aiConfig *mod
 			AgentName:   agentCfg.Name,
 			Role:        agentCfg.Role,
 			Content:     content,
+			Thinking:    out.Thinking,
+			Sources:     out.Sources,
+			Metadata:    out.Metadata,
+			Trace:       out.Trace,
 			Round:       1,
 			MsgType:     "opinion",
 			MeetingMode: MeetingModeSmart,
@@ -755,11 +936,12 @@ func (s *Service) runAgentsParallel(ctx context.Context, defaultLLM model.LLM, d
 			builder := s.createBuilder(agentLLM, agentAIConfig)
 
 			// 单个 Agent 带指数退避重试
-			content, err := retryRun(parallelCtx, MaxAgentRetries, func() (string, error) {
+			out, err := retryRun(parallelCtx, MaxAgentRetries, func() (agentOutput, error) {
 				agentCtx, agentCancel := context.WithTimeout(parallelCtx, AgentTimeout)
 				defer agentCancel()
 				return s.runSingleAgent(agentCtx, builder, &cfg, &req.Stock, req.Query, req.ReplyContent, nil, req.Position)
 			})
+			content := out.Content
 			if err != nil {
 				log.Error("agent %s failed after retries: %v", cfg.ID, err)
 				mu.Lock()
@@ -768,8 +950,9 @@ func (s *Service) runAgentsParallel(ctx context.Context, defaultLLM model.LLM, d
 					AgentName:   cfg.Name,
 					Role:        cfg.Role,
 					MsgType:     "opinion",
-					Error:       err.Error(),
+					Error:       providererr.UserMessage(err),
 					MeetingMode: MeetingModeDirect,
+					Trace:       out.Trace,
 				})
 				mu.Unlock()
 				return
@@ -781,6 +964,10 @@ func (s *Service) runAgentsParallel(ctx context.Context, defaultLLM model.LLM, d
 				AgentName:   cfg.Name,
 				Role:        cfg.Role,
 				Content:     content,
+				Thinking:    out.Thinking,
+				Sources:     out.Sources,
+				Metadata:    out.Metadata,
+				Trace:       out.Trace,
 				MeetingMode: MeetingModeDirect,
 			})
 			mu.Unlock()
@@ -804,10 +991,19 @@ func (s *Service) runSingleAgent(
 	replyContent string,
 	progressCallback ProgressCallback,
 	position *models.StockPosition,
-) (string, error) {
+) (agentOutput, error) {
+	builder = builder.WithPromptVersion(prompts.FromContext(ctx)).WithRiskProfile(s.currentRiskProfile())
+	if aiCfg := builder.AIConfig(); aiCfg != nil {
+		// 每个专家在每个 AI 配置下使用独立的服务端 conversation
+		ctx = openai.WithConversationKey(ctx, cfg.ID+"@"+aiCfg.ID)
+	}
+	if stock != nil {
+		ctx = adk.WithCaptureSession(ctx, stock.Symbol, cfg.ID)
+		ctx = tools.WithSessionStock(ctx, stock.Symbol)
+	}
 	agentInstance, err := builder.BuildAgentWithContext(cfg, stock, query, replyContent, position)
 	if err != nil {
-		return "", err
+		return agentOutput{}, err
 	}
 
 	sessionService := session.InMemoryService()
@@ -817,7 +1013,7 @@ func (s *Service) runSingleAgent(
 		SessionService: sessionService,
 	})
 	if err != nil {
-		return "", err
+		return agentOutput{}, err
 	}
 
 	sessionID := fmt.Sprintf("session-%s-%d", cfg.ID, time.Now().UnixNano())
@@ -826,12 +1022,12 @@ func (s *Service) runSingleAgent(
 		UserID:    "user",
 		SessionID: sessionID,
 	}); err != nil {
-		return "", fmt.Errorf("create session error: %w", err)
+		return agentOutput{}, fmt.Errorf("create session error: %w", err)
 	}
 
 	userMsg := &genai.Content{
 		Role:  "user",
-		Parts: []*genai.Part{genai.NewPartFromText(query)},
+		Parts: append([]*genai.Part{genai.NewPartFromText(query)}, imagesFromContext(ctx)...),
 	}
 
 	// 有 progressCallback 时启用 streaming，否则普通模式
@@ -840,48 +1036,185 @@ func (s *Service) runSingleAgent(
 		runCfg.StreamingMode = agent.StreamingModeSSE
 	}
 
-	var sb strings.Builder
-	for event, err := range r.Run(ctx, "user", sessionID, userMsg, runCfg) {
-		if err != nil {
-			return "", err
+	var sb, thinking strings.Builder
+	sources := newSourceCollector()
+	var meta respmeta.Meta
+	trace := newTraceRecorder(cfg, builder.AIConfig(), builder.PromptVersion())
+	capture := &adk.RequestCapture{}
+	ctx = adk.WithRequestCapture(ctx, capture)
+	if s.toolRegistry != nil {
+		trace.builtin = func(name string) bool {
+			_, ok := s.toolRegistry.GetTool(name)
+			return ok
 		}
-		if event == nil || event.LLMResponse.Content == nil {
-			continue
+	}
+	output := func(err error) agentOutput {
+		tr := trace.finish(err)
+		tr.Request = capture.Last()
+		s.reportQuota(tr, err)
+		return agentOutput{
+			Content:  openai.FilterVendorToolCallMarkers(sb.String()),
+			Thinking: thinking.String(),
+			Sources:  sources.list(),
+			Metadata: toResponseMeta(meta),
+			Trace:    tr,
+		}
+	}
+	// 流式模式下，推理模型长时间静默时定期推送思考心跳
+	var heartbeat *thinkingHeartbeat
+	if progressCallback != nil {
+		heartbeat = newThinkingHeartbeat()
+		stopHeartbeat := heartbeat.start(ctx, func(content string) {
+			progressCallback(ProgressEvent{
+				Type: "thinking", AgentID: cfg.ID, AgentName: cfg.Name,
+				Detail: "heartbeat", Content: content,
+			})
+		})
+		defer stopHeartbeat()
+	}
+	run := func(msg *genai.Content) error {
+		if heartbeat != nil {
+			heartbeat.restart()
 		}
-		for _, part := range event.LLMResponse.Content.Parts {
-			if part.Thought {
+		for event, err := range r.Run(ctx, "user", sessionID, msg, runCfg) {
+			if err != nil {
+				// 保留中断前已生成的内容，供续写使用
+				var streamErr *respmeta.StreamError
+				if errors.As(err, &streamErr) && !streamErr.Meta.IsZero() {
+					meta = streamErr.Meta
+				}
+				return err
+			}
+			if event == nil {
 				continue
 			}
-			if part.FunctionCall != nil && progressCallback != nil {
-				progressCallback(ProgressEvent{
-					Type: "tool_call", AgentID: cfg.ID, AgentName: cfg.Name,
-					Detail: part.FunctionCall.Name,
-				})
+			trace.observe(event)
+			if heartbeat != nil {
+				heartbeat.observe(&event.LLMResponse)
 			}
-			if part.FunctionResponse != nil && progressCallback != nil {
+			// 多轮工具调用时保留最后一次模型响应的元数据
+			if !event.LLMResponse.Partial {
+				if m := respmeta.FromCustomMetadata(event.LLMResponse.CustomMetadata); !m.IsZero() {
+					meta = m
+				}
+			}
+			// 工具参数生成中：推送正在调用的工具，参数完整后由 tool_call 事件接续
+			if d, ok := respmeta.ToolCallDeltaFromCustomMetadata(event.LLMResponse.CustomMetadata); ok && progressCallback != nil {
 				progressCallback(ProgressEvent{
-					Type: "tool_result", AgentID: cfg.ID, AgentName: cfg.Name,
-					Detail: part.FunctionResponse.Name,
+					Type: "tool_call_delta", AgentID: cfg.ID, AgentName: cfg.Name,
+					Detail: d.Name, Content: d.Arguments,
 				})
 			}
-			if part.Text != "" {
-				// streaming 模式下只累积 Partial 片段，避免重复
-				if progressCallback != nil {
-					if event.LLMResponse.Partial {
+			if event.LLMResponse.Content == nil {
+				continue
+			}
+			for _, part := range event.LLMResponse.Content.Parts {
+				if part.Thought {
+					// 与正文一致：streaming 模式下只累积 Partial 片段
+					if progressCallback == nil || event.LLMResponse.Partial {
+						thinking.WriteString(part.Text)
+					}
+					continue
+				}
+				if part.FunctionCall != nil {
+					sources.addCall(part.FunctionCall)
+				}
+				if part.FunctionResponse != nil {
+					sources.addResult(part.FunctionResponse)
+				}
+				if part.FunctionCall != nil && progressCallback != nil {
+					progressCallback(ProgressEvent{
+						Type: "tool_call", AgentID: cfg.ID, AgentName: cfg.Name,
+						Detail: part.FunctionCall.Name,
+					})
+				}
+				if part.FunctionResponse != nil && progressCallback != nil {
+					progressCallback(ProgressEvent{
+						Type: "tool_result", AgentID: cfg.ID, AgentName: cfg.Name,
+						Detail: part.FunctionResponse.Name,
+					})
+				}
+				if part.Text != "" {
+					// streaming 模式下只累积 Partial 片段，避免重复
+					if progressCallback != nil {
+						if event.LLMResponse.Partial {
+							sb.WriteString(part.Text)
+							progressCallback(ProgressEvent{
+								Type: "streaming", AgentID: cfg.ID, AgentName: cfg.Name,
+								Content: part.Text,
+							})
+						}
+					} else {
 						sb.WriteString(part.Text)
-						progressCallback(ProgressEvent{
-							Type: "streaming", AgentID: cfg.ID, AgentName: cfg.Name,
-							Content: part.Text,
-						})
 					}
-				} else {
-					sb.WriteString(part.Text)
 				}
 			}
 		}
+		return nil
+	}
+	if err := run(userMsg); err != nil {
+		return output(err), err
+	}
+	// 服务端工具执行过久时回合被暂停（pause_turn），自动请求模型继续，已输出的内容保留
+	for i := 0; i < maxPauseContinuations && meta.StopReason == respmeta.StopReasonPauseTurn; i++ {
+		meta.StopReason = ""
+		log.Info("agent %s turn paused by provider, continuing (%d/%d)", cfg.ID, i+1, maxPauseContinuations)
+		if err := run(genai.NewContentFromText(pauseContinuePrompt, genai.RoleUser)); err != nil {
+			return output(err), err
+		}
+	}
+
+	// 后台工具任务：等待完成后以同一 FunctionCallID 补发最终结果，专家据此继续作答
+	if s.toolRegistry != nil {
+		jobs := s.toolRegistry.Jobs()
+		defer jobs.Release(sessionID)
+		err := followUpBackgroundJobs(ctx, jobs, sessionID,
+			func(job *tools.Job, msg string) {
+				emitProgress(progressCallback, ProgressEvent{
+					Type: "tool_progress", AgentID: cfg.ID, AgentName: cfg.Name,
+					Detail: job.Tool, Content: msg,
+				})
+			},
+			sb.Reset,
+			func(job *tools.Job, followUp *genai.Content) error {
+				trace.finishTool(job.CallID, job.Tool, job.Response())
+				return run(followUp)
+			})
+		if err != nil {
+			return output(err), err
+		}
 	}
 
-	return openai.FilterVendorToolCallMarkers(sb.String()), nil
+	return output(nil), nil
+}
+
+// followUpBackgroundJobs 等待会话中的后台工具任务，逐个以同一 FunctionCallID 补发最终结果
+// 补发第一个结果前调用一次 discardInterim 丢弃等待期间的过渡性发言，之后每个结果引出的作答都保留
+func followUpBackgroundJobs(ctx context.Context, jobs *tools.JobManager, sessionID string,
+	onProgress func(job *tools.Job, msg string), discardInterim func(),
+	send func(job *tools.Job, followUp *genai.Content) error) error {
+	discarded := false
+	for pending := jobs.Take(sessionID); len(pending) > 0; pending = jobs.Take(sessionID) {
+		for _, job := range pending {
+			if err := job.Wait(ctx, func(msg string) { onProgress(job, msg) }); err != nil {
+				return err
+			}
+			if !discarded {
+				discardInterim()
+				discarded = true
+			}
+			followUp := &genai.Content{
+				Role: "user",
+				Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+					ID: job.CallID, Name: job.Tool, Response: job.Response(),
+				}}},
+			}
+			if err := send(job, followUp); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 // filterAgentsOrdered 按指定顺序筛选专家（保持小韭菜选择的顺序）
@@ -991,11 +1324,12 @@ func (s *Service) RetrySingleAgent(
 	})
 
 	// 带指数退避重试
-	content, err := retryRun(ctx, MaxAgentRetries, func() (string, error) {
+	out, err := retryRun(ctx, MaxAgentRetries, func() (agentOutput, error) {
 		agentCtx, cancel := context.WithTimeout(ctx, AgentTimeout)
 		defer cancel()
 		return s.runSingleAgent(agentCtx, builder, agentCfg, stock, query, "", progressCallback, position)
 	})
+	content := out.Content
 
 	emitProgress(progressCallback, ProgressEvent{
 		Type: "agent_done", AgentID: agentCfg.ID, AgentName: agentCfg.Name,
@@ -1006,9 +1340,13 @@ func (s *Service) RetrySingleAgent(
 			AgentID:     agentCfg.ID,
 			AgentName:   agentCfg.Name,
 			Role:        agentCfg.Role,
+			Content:     content,
 			MsgType:     "opinion",
-			Error:       err.Error(),
+			Error:       providererr.UserMessage(err),
 			MeetingMode: MeetingModeDirect,
+			Partial:     content != "",
+			Metadata:    out.Metadata,
+			Trace:       out.Trace,
 		}, err
 	}
 
@@ -1017,6 +1355,10 @@ func (s *Service) RetrySingleAgent(
 		AgentName:   agentCfg.Name,
 		Role:        agentCfg.Role,
 		Content:     content,
+		Thinking:    out.Thinking,
+		Sources:     out.Sources,
+		Metadata:    out.Metadata,
+		Trace:       out.Trace,
 		Round:       1,
 		MsgType:     "opinion",
 		MeetingMode: MeetingModeDirect,
@@ -1115,11 +1457,12 @@ func (s *Service) ContinueMeeting(
 			previousContext = state.MemoryContext + "\n" + previousContext
 		}
 
-		content, err := retryRun(meetingCtx, MaxAgentRetries, func() (string, error) {
+		out, err := retryRun(meetingCtx, MaxAgentRetries, func() (agentOutput, error) {
 			agentCtx, agentCancel := context.WithTimeout(meetingCtx, AgentTimeout)
 			defer agentCancel()
 			return s.runSingleAgent(agentCtx, builder, &agentCfg, &state.Stock, state.Query, previousContext, progressCallback, state.Position)
 		})
+		content := out.Content
 
 		if err != nil {
 			emitProgress(progressCallback, ProgressEvent{Type: "agent_error", AgentID: agentCfg.ID, AgentName: agentCfg.Name, Detail: err.Error()})
@@ -1127,8 +1470,9 @@ func (s *Service) ContinueMeeting(
 			log.Error("continue: agent %s failed: %v", agentCfg.ID, err)
 
 			failedResp := ChatResponse{
-				AgentID: agentCfg.ID, AgentName: agentCfg.Name, Role: agentCfg.Role,
-				Round: 1, MsgType: "opinion", Error: err.Error(), MeetingMode: MeetingModeSmart,
+				AgentID: agentCfg.ID, AgentName: agentCfg.Name, Role: agentCfg.Role, Content: content,
+				Round: 1, MsgType: "opinion", Error: providererr.UserMessage(err), MeetingMode: MeetingModeSmart,
+				Partial: content != "", Metadata: out.Metadata, Trace: out.Trace,
 			}
 			responses = append(responses, failedResp)
 			if respCallback != nil {
@@ -1167,6 +1511,10 @@ func (s *Service) ContinueMeeting(
 		resp := ChatResponse{
 			AgentID: agentCfg.ID, AgentName: agentCfg.Name, Role: agentCfg.Role,
 			Content: content, Round: 1, MsgType: "opinion", MeetingMode: MeetingModeSmart,
+			Thinking: out.Thinking,
+			Sources:  out.Sources,
+			Metadata: out.Metadata,
+			Trace:    out.Trace,
 		}
 		responses = append(responses, resp)
 		if respCallback != nil {
@@ -0,0 +1,65 @@
+package meeting
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/adk/tools"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// fakeToolContext 仅实现后台任务用到的方法
+type fakeToolContext struct {
+	tool.Context
+	callID string
+}
+
+func (f fakeToolContext) FunctionCallID() string      { return f.callID }
+func (f fakeToolContext) SessionID() string           { return "s1" }
+func (f fakeToolContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (f fakeToolContext) Done() <-chan struct{}       { return nil }
+func (f fakeToolContext) Err() error                  { return nil }
+func (f fakeToolContext) Value(key any) any           { return nil }
+
+func TestFollowUpBackgroundJobs_KeepsEveryReply(t *testing.T) {
+	jobs := tools.NewJobManager()
+	for _, id := range []string{"c1", "c2"} {
+		jobs.Start(fakeToolContext{callID: id}, "collect_report_contents", func(ctx context.Context, progress func(string)) (map[string]any, error) {
+			return map[string]any{"call": id}, nil
+		})
+	}
+
+	var sb strings.Builder
+	sb.WriteString("正在收集研报，请稍候。")
+	var replied []string
+	err := followUpBackgroundJobs(context.Background(), jobs, "s1",
+		func(*tools.Job, string) {},
+		sb.Reset,
+		func(job *tools.Job, followUp *genai.Content) error {
+			fr := followUp.Parts[0].FunctionResponse
+			if fr.ID != job.CallID || fr.Response["call"] != job.CallID {
+				t.Errorf("follow-up = %+v", fr)
+			}
+			replied = append(replied, job.CallID)
+			sb.WriteString("结论" + job.CallID + "。")
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replied) != 2 {
+		t.Fatalf("replied = %v", replied)
+	}
+	got := sb.String()
+	if strings.Contains(got, "请稍候") {
+		t.Errorf("interim text should be discarded: %q", got)
+	}
+	for _, id := range replied {
+		if !strings.Contains(got, "结论"+id) {
+			t.Errorf("reply to %s lost: %q", id, got)
+		}
+	}
+}
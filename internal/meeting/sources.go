@@ -0,0 +1,105 @@
+package meeting
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/adk/respmeta"
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/genai"
+)
+
+// maxSourceSummaryLen 工具结果摘要最大长度
+const maxSourceSummaryLen = 200
+
+// agentOutput 单个专家的运行结果
+type agentOutput struct {
+	Content  string
+	Thinking string
+	Sources  []models.ToolSource
+	Metadata *models.ResponseMeta
+	Trace    *models.TurnTrace
+}
+
+// sourceCollector 收集一次专家发言中调用的工具及其结果
+// streaming 模式下同一个 function call 可能出现多次，按 ID 去重
+type sourceCollector struct {
+	sources []models.ToolSource
+	byID    map[string]int
+}
+
+func newSourceCollector() *sourceCollector {
+	return &sourceCollector{byID: make(map[string]int)}
+}
+
+// addCall 记录工具调用
+func (c *sourceCollector) addCall(fc *genai.FunctionCall) {
+	if fc.ID != "" {
+		if _, ok := c.byID[fc.ID]; ok {
+			return
+		}
+		c.byID[fc.ID] = len(c.sources)
+	}
+	c.sources = append(c.sources, models.ToolSource{
+		Tool:      fc.Name,
+		Args:      fc.Args,
+		Timestamp: time.Now().UnixMilli(),
+	})
+}
+
+// addResult 记录工具结果，关联到对应的调用
+func (c *sourceCollector) addResult(fr *genai.FunctionResponse) {
+	idx, ok := c.byID[fr.ID]
+	if !ok {
+		// 无 ID 时按名称匹配最近一个尚无结果的调用
+		idx = -1
+		for i := len(c.sources) - 1; i >= 0; i-- {
+			if c.sources[i].Tool == fr.Name && c.sources[i].Summary == "" {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return
+		}
+	}
+	src := &c.sources[idx]
+	src.Timestamp = time.Now().UnixMilli()
+	src.Summary = summarizeToolResult(fr.Response)
+}
+
+// list 返回收集到的数据来源
+func (c *sourceCollector) list() []models.ToolSource {
+	return c.sources
+}
+
+// summarizeToolResult 生成工具结果摘要
+func summarizeToolResult(resp map[string]any) string {
+	if len(resp) == 0 {
+		return ""
+	}
+	var text string
+	if data, ok := resp["data"].(string); ok {
+		text = data
+	} else if b, err := json.Marshal(resp); err == nil {
+		text = string(b)
+	}
+	runes := []rune(text)
+	if len(runes) > maxSourceSummaryLen {
+		return string(runes[:maxSourceSummaryLen]) + "..."
+	}
+	return text
+}
+
+// toResponseMeta 转换为消息元数据，无数据时返回 nil
+func toResponseMeta(m respmeta.Meta) *models.ResponseMeta {
+	if m.IsZero() {
+		return nil
+	}
+	return &models.ResponseMeta{
+		ResponseID:   m.ResponseID,
+		RequestID:    m.RequestID,
+		ModelVersion: m.ModelVersion,
+		StopReason:   m.StopReason,
+	}
+}
@@ -0,0 +1,182 @@
+package meeting
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/adk"
+	"github.com/run-bigpig/jcp/internal/models"
+
+	"github.com/google/uuid"
+	"google.golang.org/adk/session"
+)
+
+// 降级路径标识
+const (
+	FallbackDefaultAIConfig = "ai_config:default"   // 专家自定义 AI 配置不可用，使用默认配置
+	FallbackContinuation    = "resume:continuation" // 按响应ID取回失败，改用续写提示词
+)
+
+// TraceID 返回响应对应的执行轨迹 ID
+func (r ChatResponse) TraceID() string {
+	if r.Trace == nil {
+		return ""
+	}
+	return r.Trace.ID
+}
+
+// Cost 返回响应的费用（轨迹记录时计算），未计算时为 nil
+func (r ChatResponse) Cost() *models.Cost {
+	if r.Trace == nil {
+		return nil
+	}
+	return r.Trace.Cost
+}
+
+// Attribution 返回生成该响应的供应商、模型与 AI 配置，无轨迹时为 nil
+func (r ChatResponse) Attribution() *models.MessageAttribution {
+	if r.Trace == nil || r.Trace.Model == "" {
+		return nil
+	}
+	a := &models.MessageAttribution{
+		Provider:   r.Trace.Provider,
+		Model:      r.Trace.Model,
+		AIConfigID: r.Trace.AIConfigID,
+		Fallback:   slices.Contains(r.Trace.Fallbacks, FallbackDefaultAIConfig),
+	}
+	if r.Metadata != nil && r.Metadata.ModelVersion != a.Model {
+		a.ModelVersion = r.Metadata.ModelVersion
+	}
+	return a
+}
+
+// instructionHash 计算专家指令摘要，用于区分用户自定义指令的变更
+func instructionHash(instruction string) string {
+	sum := sha256.Sum256([]byte(instruction))
+	return hex.EncodeToString(sum[:4])
+}
+
+// traceRecorder 在一次 runSingleAgent 中收集执行轨迹
+type traceRecorder struct {
+	trace   *models.TurnTrace
+	started time.Time
+	calls   map[string]time.Time   // FunctionCallID -> 调用开始时间
+	toolIdx map[string]int         // FunctionCallID -> trace.Tools 下标
+	builtin func(name string) bool // 判断是否为内置工具，为空时不标注来源
+}
+
+func newTraceRecorder(cfg *models.AgentConfig, aiConfig *models.AIConfig, promptVersion string) *traceRecorder {
+	now := time.Now()
+	trace := &models.TurnTrace{
+		ID:              uuid.New().String(),
+		AgentID:         cfg.ID,
+		AgentName:       cfg.Name,
+		PromptVersion:   promptVersion,
+		InstructionHash: instructionHash(cfg.Instruction),
+		StartedAt:       now.UnixMilli(),
+	}
+	if aiConfig != nil {
+		trace.AIConfigID = aiConfig.ID
+		trace.Provider = string(aiConfig.Provider)
+		trace.Model = aiConfig.ModelName
+		if cfg.AIConfigID != "" && aiConfig.ID != cfg.AIConfigID {
+			trace.Fallbacks = append(trace.Fallbacks, FallbackDefaultAIConfig)
+		}
+	}
+	return &traceRecorder{
+		trace:   trace,
+		started: now,
+		calls:   make(map[string]time.Time),
+		toolIdx: make(map[string]int),
+	}
+}
+
+// observe 记录一个 runner 事件：模型调用次数、token 用量与工具调用
+func (t *traceRecorder) observe(event *session.Event) {
+	if event.LLMResponse.Partial {
+		return
+	}
+	if u := event.LLMResponse.UsageMetadata; u != nil {
+		t.trace.LLMCalls++
+		t.trace.PromptTokens += int(u.PromptTokenCount)
+		t.trace.CompletionTokens += int(u.CandidatesTokenCount)
+		t.trace.ThoughtTokens += int(u.ThoughtsTokenCount)
+		t.trace.CachedTokens += int(u.CachedContentTokenCount)
+		t.trace.TotalTokens += int(u.TotalTokenCount)
+	}
+	if event.LLMResponse.Content == nil {
+		return
+	}
+	for _, part := range event.LLMResponse.Content.Parts {
+		if fc := part.FunctionCall; fc != nil {
+			if _, ok := t.calls[fc.ID]; !ok || fc.ID == "" {
+				t.calls[fc.ID] = time.Now()
+			}
+		}
+		if fr := part.FunctionResponse; fr != nil {
+			t.finishTool(fr.ID, fr.Name, fr.Response)
+		}
+	}
+}
+
+// finishTool 记录工具调用结束；后台任务补发结果时按同一 CallID 更新耗时
+func (t *traceRecorder) finishTool(callID, name string, response map[string]any) {
+	var duration int64
+	if start, ok := t.calls[callID]; ok {
+		duration = time.Since(start).Milliseconds()
+	}
+	_, hasErr := response["error"]
+	var resultTokens int
+	if data, err := json.Marshal(response); err == nil {
+		resultTokens = adk.EstimateTokens(string(data))
+	}
+	if i, ok := t.toolIdx[callID]; ok && callID != "" {
+		t.trace.Tools[i].DurationMs = duration
+		t.trace.Tools[i].Error = hasErr
+		t.trace.Tools[i].ResultTokens = resultTokens
+		return
+	}
+	var source string
+	if t.builtin != nil {
+		source = models.ToolSourceMCP
+		if t.builtin(name) {
+			source = models.ToolSourceBuiltin
+		}
+	}
+	t.toolIdx[callID] = len(t.trace.Tools)
+	t.trace.Tools = append(t.trace.Tools, models.ToolTrace{
+		Name: name, Source: source, CallID: callID, DurationMs: duration, Error: hasErr, ResultTokens: resultTokens,
+	})
+}
+
+// finish 结束记录并返回轨迹
+func (t *traceRecorder) finish(err error) *models.TurnTrace {
+	t.trace.DurationMs = time.Since(t.started).Milliseconds()
+	if err != nil {
+		t.trace.Error = err.Error()
+	}
+	return t.trace
+}
+
+// reportQuota 向记忆管理器上报本次发言的 token 用量与限流错误，用于记忆功能降级
+func (s *Service) reportQuota(trace *models.TurnTrace, err error) {
+	if s.memoryManager == nil {
+		return
+	}
+	quota := s.memoryManager.Quota()
+	quota.RecordUsage(trace.TotalTokens)
+	quota.RecordError(err)
+}
+
+// recordRetries 由 retryRun 回写重试次数，耗时扩展为包含全部重试
+func (o *agentOutput) recordRetries(n int, started time.Time) {
+	if o.Trace == nil {
+		return
+	}
+	o.Trace.Retries = n
+	o.Trace.StartedAt = started.UnixMilli()
+	o.Trace.DurationMs = time.Since(started).Milliseconds()
+}
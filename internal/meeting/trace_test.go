@@ -0,0 +1,24 @@
+package meeting
+
+import (
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestChatResponseAttribution(t *testing.T) {
+	if (ChatResponse{}).Attribution() != nil {
+		t.Fatal("attribution without trace")
+	}
+
+	cfg := &models.AgentConfig{ID: "analyst", AIConfigID: "custom"}
+	aiConfig := &models.AIConfig{ID: "default", Provider: models.AIProviderOpenAI, ModelName: "gpt-4o"}
+	resp := ChatResponse{
+		Trace:    newTraceRecorder(cfg, aiConfig, "v1").trace,
+		Metadata: &models.ResponseMeta{ModelVersion: "gpt-4o-2024-08-06"},
+	}
+	a := resp.Attribution()
+	if a == nil || a.Provider != "openai" || a.Model != "gpt-4o" || a.AIConfigID != "default" || !a.Fallback || a.ModelVersion != "gpt-4o-2024-08-06" {
+		t.Fatalf("attribution = %+v", a)
+	}
+}
@@ -0,0 +1,165 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	// ExportFormat 记忆导出包的格式标识
+	ExportFormat = "jcp-memory"
+	// ExportVersion 记忆导出包的结构版本
+	ExportVersion = 1
+)
+
+// ExportBundle 可移植的记忆导出包（摘要、关键事实、最近轮次，可选附带向量）
+type ExportBundle struct {
+	Format     string        `json:"format"`
+	Version    int           `json:"version"`
+	ExportedAt int64         `json:"exported_at"`
+	EmbedderID string        `json:"embedder_id,omitempty"` // 附带向量时的向量空间，导入方模型不同时按原文重新计算
+	Stocks     []StockExport `json:"stocks"`
+}
+
+// StockExport 单只股票的记忆与长期向量记忆条目（不附带向量时只保留原文）
+type StockExport struct {
+	Memory  *StockMemory  `json:"memory"`
+	Vectors []VectorEntry `json:"vectors,omitempty"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Imported []string // 已导入的股票代码
+	Skipped  []string // 已存在且未选择覆盖的股票代码
+}
+
+// ExportMemory 导出记忆，stockCodes 为空时导出全部股票；includeEmbeddings 为 false 时向量条目只保留原文
+func (m *Manager) ExportMemory(stockCodes []string, includeEmbeddings bool) ([]byte, error) {
+	bundle, err := m.buildExport(stockCodes, includeEmbeddings)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(bundle, "", "  ")
+}
+
+// ExportMemoryFile 导出记忆到文件，返回已导出的股票代码
+func (m *Manager) ExportMemoryFile(path string, stockCodes []string, includeEmbeddings bool) ([]string, error) {
+	bundle, err := m.buildExport(stockCodes, includeEmbeddings)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, len(bundle.Stocks))
+	for _, item := range bundle.Stocks {
+		codes = append(codes, item.Memory.StockCode)
+	}
+	return codes, nil
+}
+
+// ImportMemoryFile 从文件导入记忆
+func (m *Manager) ImportMemoryFile(path string, overwrite bool) (ImportResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	return m.ImportMemory(data, overwrite)
+}
+
+// buildExport 组装导出包
+func (m *Manager) buildExport(stockCodes []string, includeEmbeddings bool) (*ExportBundle, error) {
+	if len(stockCodes) == 0 {
+		codes, err := m.storage.List()
+		if err != nil {
+			return nil, err
+		}
+		stockCodes = codes
+	}
+	stockCodes = slices.Sorted(slices.Values(stockCodes))
+
+	bundle := ExportBundle{Format: ExportFormat, Version: ExportVersion, ExportedAt: time.Now().UnixMilli()}
+	for _, code := range stockCodes {
+		mem, err := m.storage.Load(code)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 的记忆失败: %w", code, err)
+		}
+		item := StockExport{Memory: mem}
+		if m.vectors != nil {
+			f, err := m.vectors.Snapshot(code)
+			if err != nil {
+				return nil, fmt.Errorf("读取 %s 的向量记忆失败: %w", code, err)
+			}
+			item.Vectors = f.Entries
+			if includeEmbeddings && len(f.Entries) > 0 {
+				if bundle.EmbedderID != "" && bundle.EmbedderID != f.EmbedderID {
+					return nil, fmt.Errorf("向量记忆来自不同的向量模型，请不附带向量导出")
+				}
+				bundle.EmbedderID = f.EmbedderID
+			}
+		}
+		if !includeEmbeddings {
+			for i := range item.Vectors {
+				item.Vectors[i].Vector = nil
+			}
+		}
+		bundle.Stocks = append(bundle.Stocks, item)
+	}
+	return &bundle, nil
+}
+
+// ImportMemory 导入记忆导出包；已存在的股票记忆在 overwrite 为 false 时跳过
+// 未附带向量（或向量模型不同）的条目在下次检索时按原文重新计算向量
+func (m *Manager) ImportMemory(data []byte, overwrite bool) (ImportResult, error) {
+	var result ImportResult
+	var bundle ExportBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return result, fmt.Errorf("记忆导出包解析失败: %w", err)
+	}
+	if bundle.Format != ExportFormat {
+		return result, fmt.Errorf("不是记忆导出包")
+	}
+	if bundle.Version > ExportVersion {
+		return result, fmt.Errorf("记忆导出包版本 %d 高于当前支持的版本 %d", bundle.Version, ExportVersion)
+	}
+	for _, item := range bundle.Stocks {
+		if item.Memory == nil || !validStockCode(item.Memory.StockCode) {
+			return result, fmt.Errorf("记忆导出包包含无效的股票记忆")
+		}
+	}
+
+	for _, item := range bundle.Stocks {
+		code := item.Memory.StockCode
+		if _, err := m.storage.Load(code); err == nil && !overwrite {
+			result.Skipped = append(result.Skipped, code)
+			continue
+		}
+		if err := m.Save(item.Memory); err != nil {
+			return result, fmt.Errorf("保存 %s 的记忆失败: %w", code, err)
+		}
+		if m.vectors != nil {
+			embedderID := bundle.EmbedderID
+			if slices.ContainsFunc(item.Vectors, func(e VectorEntry) bool { return len(e.Vector) == 0 }) {
+				embedderID = ""
+			}
+			if err := m.vectors.Replace(code, &vectorFile{EmbedderID: embedderID, Entries: item.Vectors}); err != nil {
+				return result, fmt.Errorf("保存 %s 的向量记忆失败: %w", code, err)
+			}
+		}
+		result.Imported = append(result.Imported, code)
+	}
+	return result, nil
+}
+
+// validStockCode 股票代码可安全用作文件名
+func validStockCode(code string) bool {
+	return code != "" && code != "." && code != ".." && filepath.Base(code) == code
+}
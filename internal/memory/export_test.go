@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"testing"
+)
+
+func TestManager_ExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	srcDir := t.TempDir()
+	src := &Manager{storage: NewFileStorage(srcDir), vectors: NewVectorStore(srcDir)}
+	mem := NewStockMemory("sh600000", "浦发银行")
+	mem.Summary = "维持高分红判断"
+	mem.KeyFacts = []MemoryEntry{{ID: "f1", Type: EntryTypeFact, Content: "股息率 6%"}}
+	if err := src.Save(mem); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.vectors.Add(ctx, keywordEmbedder{id: "v1"}, "sh600000", []VectorEntry{{Kind: VectorKindConclusion, Content: "分红结论"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := src.ExportMemory(nil, false)
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+
+	dstDir := t.TempDir()
+	dst := &Manager{storage: NewFileStorage(dstDir), vectors: NewVectorStore(dstDir)}
+	result, err := dst.ImportMemory(data, false)
+	if err != nil || len(result.Imported) != 1 {
+		t.Fatalf("导入失败: %+v, %v", result, err)
+	}
+	got, err := dst.storage.Load("sh600000")
+	if err != nil || got.Summary != mem.Summary || len(got.KeyFacts) != 1 {
+		t.Fatalf("导入的记忆 = %+v, %v", got, err)
+	}
+
+	// 未附带向量时按原文重新计算后仍可召回
+	hits, err := dst.vectors.Search(ctx, keywordEmbedder{id: "v1"}, "sh600000", "分红", 5)
+	if err != nil || len(hits) != 1 {
+		t.Errorf("召回 = %+v, %v", hits, err)
+	}
+
+	// 已存在时不覆盖则跳过
+	if result, _ := dst.ImportMemory(data, false); len(result.Skipped) != 1 {
+		t.Errorf("skipped = %v, 期望跳过已存在的股票", result.Skipped)
+	}
+	if _, err := dst.ImportMemory([]byte(`{"format":"other"}`), true); err == nil {
+		t.Error("非记忆导出包应被拒绝")
+	}
+}
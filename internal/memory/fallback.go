@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// maxRuleFacts 规则提取单次最多保留的事实数
+const maxRuleFacts = 3
+
+// factHints 规则提取时优先保留的句子关键词
+var factHints = []string{"营收", "净利", "利润", "同比", "环比", "毛利", "目标价", "评级", "估值", "市盈率", "支撑", "压力", "增持", "减持", "分红", "订单"}
+
+// ruleSummarizeRounds 不调用 LLM，按轮次拼接问题与截断后的结论
+func ruleSummarizeRounds(rounds []RoundMemory) string {
+	var sb strings.Builder
+	for _, r := range rounds {
+		date := time.UnixMilli(r.Timestamp).Format("01-02")
+		fmt.Fprintf(&sb, "[%s] %s → %s\n", date, truncateRunes(r.Query, 30), truncateRunes(r.Consensus, 60))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// ruleExtractFacts 不调用 LLM，从内容中挑选含数字或关键指标的句子作为事实
+func ruleExtractFacts(content, source string, tokenizer Tokenizer) []MemoryEntry {
+	var facts []MemoryEntry
+	now := time.Now().UnixMilli()
+	for _, sentence := range splitSentences(content) {
+		if len(facts) >= maxRuleFacts {
+			break
+		}
+		if !hasDigit(sentence) || !containsAny(sentence, factHints) {
+			continue
+		}
+		var keywords []string
+		if tokenizer != nil {
+			keywords = tokenizer.Extract(sentence, 5)
+		}
+		facts = append(facts, MemoryEntry{
+			ID:        uuid.New().String(),
+			Type:      EntryTypeFact,
+			Content:   truncateRunes(sentence, 100),
+			Source:    source,
+			Keywords:  keywords,
+			Timestamp: now,
+			Weight:    0.5,
+		})
+	}
+	return facts
+}
+
+// splitSentences 按中英文句末标点和换行切分
+func splitSentences(text string) []string {
+	parts := strings.FieldsFunc(text, func(r rune) bool {
+		return strings.ContainsRune("。！？；\n!?;", r)
+	})
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(strings.Trim(p, "-*# "))
+		if p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func hasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
+
+func containsAny(s string, keys []string) bool {
+	for _, k := range keys {
+		if strings.Contains(s, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
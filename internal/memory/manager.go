@@ -2,11 +2,15 @@ package memory
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"google.golang.org/adk/model"
+	"google.golang.org/genai"
 )
 
 // Manager 记忆管理器
@@ -16,9 +20,16 @@ type Manager struct {
 	tokenizer  Tokenizer
 	relevance  *Relevance
 	summarizer Summarizer
+	quota      *QuotaGuard  // 配额守卫，决定非必要 LLM 调用的降级
+	vectors    *VectorStore // 长期向量记忆，设置 Embedder 后启用索引与召回
+	embedder   Embedder
+	budget     tokenBudget                   // 按 token 触发压缩时使用的计数器与上下文窗口
+	prompts    *promptTemplates              // 用户自定义的摘要与事实提取提示词
+	positionOf func(stockCode string) string // 查询持仓描述，用于提示词变量
 	dataDir    string
-	saveCh     chan *StockMemory // 异步保存通道
-	closeCh    chan struct{}     // 关闭信号
+	saveCh     chan *StockMemory             // 异步保存通道
+	closeCh    chan struct{}                 // 关闭信号
+	industryOf func(stockCode string) string // 查询股票所属行业，用于同行业记忆
 }
 
 // NewManager 创建记忆管理器（无 LLM，摘要功能禁用）
@@ -29,23 +40,114 @@ func NewManager(dataDir string) *Manager {
 		storage:   NewFileStorage(dataDir),
 		tokenizer: tokenizer,
 		relevance: NewRelevance(tokenizer),
+		quota:     NewQuotaGuard(DegradePolicy{}),
+		vectors:   NewVectorStore(dataDir),
 		dataDir:   dataDir,
 		saveCh:    make(chan *StockMemory, 100), // 缓冲通道
 		closeCh:   make(chan struct{}),
 	}
+	m.quota.persistTo(filepath.Join(dataDir, "memory_quota.json"))
 	go m.asyncSaveLoop()
 	return m
 }
 
 // SetLLM 设置 LLM（启用摘要功能）
 func (m *Manager) SetLLM(llm model.LLM) {
-	m.summarizer = NewLLMSummarizer(llm, m.tokenizer)
+	summarizer := NewLLMSummarizer(llm, m.tokenizer)
+	summarizer.templates = m.prompts
+	// 摘要与事实提取的用量同样计入每日预算，限流时进入冷却
+	summarizer.onUsage = func(tokens int, err error) {
+		m.quota.RecordUsage(tokens)
+		m.quota.RecordError(err)
+	}
+	m.summarizer = summarizer
+}
+
+// SetPromptTemplates 设置自定义的摘要与事实提取提示词，模板无效时返回错误并保留原设置
+func (m *Manager) SetPromptTemplates(t PromptTemplates) error {
+	parsed, err := parsePromptTemplates(t)
+	if err != nil {
+		return err
+	}
+	m.prompts = parsed
+	if s, ok := m.summarizer.(*LLMSummarizer); ok {
+		s.templates = parsed
+	}
+	return nil
+}
+
+// SetPositionLookup 设置持仓描述查询函数（来自会话持仓）
+func (m *Manager) SetPositionLookup(fn func(stockCode string) string) {
+	m.positionOf = fn
+}
+
+// SetEmbedder 设置向量化器（启用长期向量记忆：压缩时索引事实与结论，构建上下文时按语义召回）
+func (m *Manager) SetEmbedder(embedder Embedder) {
+	m.embedder = embedder
+}
+
+// TokenCounter 统计请求的输入 token 数（与 adk.TokenCounter 方法一致，由调用方注入）
+type TokenCounter interface {
+	CountTokens(ctx context.Context, req *model.LLMRequest) (int, error)
+}
+
+// tokenBudget 会议模型的 token 计数器与上下文窗口
+type tokenBudget struct {
+	counter       TokenCounter
+	contextWindow int
+}
+
+// SetTokenBudget 设置会议模型的 token 计数器与上下文窗口，配合 CompressTokenRatio 按 token 触发压缩
+func (m *Manager) SetTokenBudget(counter TokenCounter, contextWindow int) {
+	m.budget = tokenBudget{counter: counter, contextWindow: contextWindow}
+}
+
+// overTokenBudget 记忆注入的历史（摘要、事实与最近轮次）估算 token 是否超过上下文窗口的 CompressTokenRatio
+// 不同模型的窗口相差很大，按比例触发比固定轮次更稳定
+func (m *Manager) overTokenBudget(ctx context.Context, mem *StockMemory) bool {
+	ratio := m.config.CompressTokenRatio
+	if ratio <= 0 || m.budget.counter == nil || m.budget.contextWindow <= 0 {
+		return false
+	}
+	var sb strings.Builder
+	sb.WriteString(mem.Summary)
+	sb.WriteString("\n")
+	for _, f := range mem.KeyFacts {
+		sb.WriteString(f.Content)
+		sb.WriteString("\n")
+	}
+	sb.WriteString(roundsContent(mem.RecentRounds))
+	req := &model.LLMRequest{Contents: []*genai.Content{genai.NewContentFromText(sb.String(), genai.RoleUser)}}
+	tokens, err := m.budget.counter.CountTokens(ctx, req)
+	if err != nil {
+		fmt.Printf("count memory tokens error: %v\n", err)
+		return false
+	}
+	return float64(tokens) > ratio*float64(m.budget.contextWindow)
+}
+
+// SetDegradePolicy 设置配额紧张时的降级策略
+func (m *Manager) SetDegradePolicy(policy DegradePolicy) {
+	m.quota.SetPolicy(policy)
+}
+
+// SetIndustryLookup 设置行业查询函数（来自证券主表）
+func (m *Manager) SetIndustryLookup(fn func(stockCode string) string) {
+	m.industryOf = fn
+}
+
+// Quota 返回配额守卫，供调用方上报用量与限流错误
+func (m *Manager) Quota() *QuotaGuard {
+	return m.quota
 }
 
 // NewManagerWithConfig 使用自定义配置创建记忆管理器
 func NewManagerWithConfig(dataDir string, config Config) *Manager {
 	m := NewManager(dataDir)
 	m.config = config
+	if err := m.SetPromptTemplates(config.Prompts); err != nil {
+		fmt.Printf("memory prompt templates invalid, using defaults: %v\n", err)
+	}
 	return m
 }
 
@@ -56,6 +158,9 @@ func (m *Manager) GetOrCreate(stockCode, stockName string) (*StockMemory, error)
 		// 不存在则创建新的
 		mem = NewStockMemory(stockCode, stockName)
 	}
+	if mem.Industry == "" && m.industryOf != nil {
+		mem.Industry = m.industryOf(stockCode)
+	}
 	return mem, nil
 }
 
@@ -99,7 +204,7 @@ func (m *Manager) asyncSaveLoop() {
 }
 
 // BuildContext 构建上下文（核心方法）
-func (m *Manager) BuildContext(mem *StockMemory, currentQuery string) string {
+func (m *Manager) BuildContext(ctx context.Context, mem *StockMemory, currentQuery string) string {
 	var sb strings.Builder
 
 	// 1. 历史摘要
@@ -120,7 +225,27 @@ func (m *Manager) BuildContext(mem *StockMemory, currentQuery string) string {
 		sb.WriteString("\n")
 	}
 
-	// 3. 最近几轮讨论的要点
+	// 3. 语义召回的长期记忆（已压缩的历史结论与事实），跳过上面已列出的事实
+	listed := make(map[string]bool, len(relevantFacts))
+	for _, fact := range relevantFacts {
+		listed[fact.Content] = true
+	}
+	var recalled []ScoredVector
+	for _, r := range m.Recall(ctx, mem.StockCode, currentQuery, defaultRecallLimit) {
+		if !listed[r.Entry.Content] {
+			recalled = append(recalled, r)
+		}
+	}
+	if len(recalled) > 0 {
+		sb.WriteString("【相关长期记忆】\n")
+		for _, r := range recalled {
+			timeStr := time.UnixMilli(r.Entry.Timestamp).Format("2006-01-02")
+			fmt.Fprintf(&sb, "- [%s] %s\n", timeStr, r.Entry.Content)
+		}
+		sb.WriteString("\n")
+	}
+
+	// 4. 最近几轮讨论的要点
 	if len(mem.RecentRounds) > 0 {
 		sb.WriteString("【近期讨论】\n")
 		for _, round := range mem.RecentRounds {
@@ -130,6 +255,92 @@ func (m *Manager) BuildContext(mem *StockMemory, currentQuery string) string {
 		}
 	}
 
+	// 5. 同行业其他股票的近期结论
+	sb.WriteString(m.sectorContext(mem))
+
+	return sb.String()
+}
+
+// Recall 按语义检索股票的长期向量记忆，未启用或检索失败时返回 nil
+func (m *Manager) Recall(ctx context.Context, stockCode, query string, limit int) []ScoredVector {
+	if m.vectors == nil || m.embedder == nil || query == "" {
+		return nil
+	}
+	results, err := m.vectors.Search(ctx, m.embedder, stockCode, query, limit)
+	if err != nil {
+		fmt.Printf("recall vector memory error: %v\n", err)
+		return nil
+	}
+	return results
+}
+
+// indexVectors 把被压缩轮次的结论与新提取的事实写入向量记忆
+func (m *Manager) indexVectors(ctx context.Context, mem *StockMemory, rounds []RoundMemory, facts []MemoryEntry) {
+	if m.vectors == nil || m.embedder == nil {
+		return
+	}
+	entries := make([]VectorEntry, 0, len(rounds)+len(facts))
+	for _, r := range rounds {
+		if r.Consensus == "" {
+			continue
+		}
+		entries = append(entries, VectorEntry{
+			Kind:      VectorKindConclusion,
+			Content:   fmt.Sprintf("问题: %s\n结论: %s", r.Query, r.Consensus),
+			Timestamp: r.Timestamp,
+		})
+	}
+	for _, f := range facts {
+		entries = append(entries, VectorEntry{Kind: VectorKindFact, Content: f.Content, Timestamp: f.Timestamp})
+	}
+	if err := m.vectors.Add(ctx, m.embedder, mem.StockCode, entries); err != nil {
+		fmt.Printf("index vector memory error: %v\n", err)
+	}
+}
+
+// maxSectorPeers 同行业记忆最多引用的股票数
+const maxSectorPeers = 3
+
+// sectorContext 同行业其他股票最近一轮讨论的结论
+func (m *Manager) sectorContext(mem *StockMemory) string {
+	if mem.Industry == "" {
+		return ""
+	}
+	codes, err := m.storage.List()
+	if err != nil {
+		return ""
+	}
+	var peers []*StockMemory
+	for _, code := range codes {
+		if code == mem.StockCode {
+			continue
+		}
+		other, err := m.storage.Load(code)
+		if err != nil || other.Industry != mem.Industry || len(other.RecentRounds) == 0 {
+			continue
+		}
+		peers = append(peers, other)
+	}
+	if len(peers) == 0 {
+		return ""
+	}
+	last := func(p *StockMemory) RoundMemory { return p.RecentRounds[len(p.RecentRounds)-1] }
+	sort.Slice(peers, func(i, j int) bool { return last(peers[i]).Timestamp > last(peers[j]).Timestamp })
+	if len(peers) > maxSectorPeers {
+		peers = peers[:maxSectorPeers]
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "【同行业（%s）近期讨论】\n", mem.Industry)
+	for _, p := range peers {
+		round := last(p)
+		consensus := []rune(round.Consensus)
+		if len(consensus) > 100 {
+			consensus = append(consensus[:100], '…')
+		}
+		fmt.Fprintf(&sb, "- [%s] %s: %s\n", time.UnixMilli(round.Timestamp).Format("2006-01-02"), p.StockName, string(consensus))
+	}
+	sb.WriteString("\n")
 	return sb.String()
 }
 
@@ -145,8 +356,12 @@ func (m *Manager) AddRound(ctx context.Context, mem *StockMemory, query, consens
 	}
 	mem.RecentRounds = append(mem.RecentRounds, round)
 
-	// 检查是否需要压缩
-	if len(mem.RecentRounds) >= m.config.CompressThreshold {
+	// 检查是否需要压缩（Soft 降级时延长压缩间隔）
+	threshold := m.config.CompressThreshold
+	if m.quota.Level() == DegradeSoft {
+		threshold *= m.quota.Policy().CompressIntervalFactor
+	}
+	if len(mem.RecentRounds) >= threshold || m.overTokenBudget(ctx, mem) {
 		if err := m.compress(ctx, mem); err != nil {
 			// 压缩失败不影响主流程，记录日志即可
 			fmt.Printf("compress memory error: %v\n", err)
@@ -167,6 +382,7 @@ func (m *Manager) compress(ctx context.Context, mem *StockMemory) error {
 
 	toCompress := mem.RecentRounds[:len(mem.RecentRounds)-keepCount]
 	toKeep := mem.RecentRounds[len(mem.RecentRounds)-keepCount:]
+	ctx = withPromptContext(ctx, m.promptContext(mem, toCompress))
 
 	// 如果没有 summarizer，只保留最近的轮次，不生成摘要
 	if m.summarizer == nil {
@@ -174,19 +390,98 @@ func (m *Manager) compress(ctx context.Context, mem *StockMemory) error {
 		return nil
 	}
 
-	// 生成新摘要
-	newSummary, err := m.summarizer.SummarizeRounds(ctx, toCompress)
-	if err != nil {
-		return err
+	// 生成新摘要，Hard 降级时改用规则摘要
+	if m.quota.Level() == DegradeHard {
+		mem.Summary = m.mergeSummaries(mem.Summary, ruleSummarizeRounds(toCompress))
+	} else {
+		summary, err := m.summarizer.SummarizeRounds(ctx, mem.Summary, toCompress, m.config.MaxSummaryLength)
+		if err != nil {
+			return err
+		}
+		mem.Summary = summary
 	}
 
-	// 合并摘要
-	mem.Summary = m.mergeSummaries(mem.Summary, newSummary)
+	// 被压缩轮次中的要点提取为关键事实，之后按问题相关性召回
+	facts, err := m.extractFacts(ctx, roundsContent(toCompress), "memory")
+	if err != nil {
+		// 事实提取失败不影响摘要，记录日志即可
+		fmt.Printf("extract memory facts error: %v\n", err)
+	}
+	m.AddFacts(mem, facts)
+	m.indexVectors(ctx, mem, toCompress, facts)
 	mem.RecentRounds = toKeep
 
 	return nil
 }
 
+// promptContext 提示词变量：股票、持仓与待压缩轮次的起止日期
+func (m *Manager) promptContext(mem *StockMemory, rounds []RoundMemory) PromptContext {
+	pc := PromptContext{StockCode: mem.StockCode, StockName: mem.StockName}
+	if m.positionOf != nil {
+		pc.Position = m.positionOf(mem.StockCode)
+	}
+	if len(rounds) > 0 {
+		pc.DateFrom = time.UnixMilli(rounds[0].Timestamp).Format("2006-01-02")
+		pc.DateTo = time.UnixMilli(rounds[len(rounds)-1].Timestamp).Format("2006-01-02")
+	}
+	return pc
+}
+
+// roundsContent 拼接轮次的问题、结论与要点，作为事实提取的输入
+func roundsContent(rounds []RoundMemory) string {
+	var sb strings.Builder
+	for _, r := range rounds {
+		fmt.Fprintf(&sb, "问题: %s\n结论: %s\n", r.Query, r.Consensus)
+		for _, p := range r.KeyPoints {
+			fmt.Fprintf(&sb, "- %s\n", p)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// CompressResult 单只股票的压缩结果
+type CompressResult struct {
+	StockCode string
+	StockName string
+	Rounds    int // 被压缩进摘要的轮次数
+	Err       error
+}
+
+// CompressAll 对所有股票记忆重新执行压缩
+// dryRun 为 true 时只统计待压缩的轮次，不调用 LLM 也不保存
+func (m *Manager) CompressAll(ctx context.Context, dryRun bool) ([]CompressResult, error) {
+	codes, err := m.storage.List()
+	if err != nil {
+		return nil, err
+	}
+	var results []CompressResult
+	for _, code := range codes {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		mem, err := m.storage.Load(code)
+		if err != nil {
+			results = append(results, CompressResult{StockCode: code, Err: err})
+			continue
+		}
+		pending := len(mem.RecentRounds) - m.config.MaxRecentRounds
+		if pending <= 0 {
+			continue
+		}
+		result := CompressResult{StockCode: code, StockName: mem.StockName, Rounds: pending}
+		if !dryRun {
+			if err := m.compress(ctx, mem); err != nil {
+				result.Err = err
+			} else {
+				result.Err = m.Save(mem)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
 // mergeSummaries 合并摘要
 func (m *Manager) mergeSummaries(old, new string) string {
 	if old == "" {
@@ -215,8 +510,9 @@ func (m *Manager) AddFacts(mem *StockMemory, facts []MemoryEntry) {
 }
 
 // ExtractAndAddFacts 从内容中提取并添加事实
+// 无 LLM 或配额紧张时使用规则提取
 func (m *Manager) ExtractAndAddFacts(ctx context.Context, mem *StockMemory, content, source string) error {
-	facts, err := m.summarizer.ExtractFacts(ctx, content, source)
+	facts, err := m.extractFacts(ctx, content, source)
 	if err != nil {
 		return err
 	}
@@ -224,10 +520,18 @@ func (m *Manager) ExtractAndAddFacts(ctx context.Context, mem *StockMemory, cont
 	return nil
 }
 
+// extractFacts 提取事实，无 LLM 或配额紧张时使用规则提取
+func (m *Manager) extractFacts(ctx context.Context, content, source string) ([]MemoryEntry, error) {
+	if m.summarizer == nil || m.quota.Level() >= DegradeSoft {
+		return ruleExtractFacts(content, source, m.tokenizer), nil
+	}
+	return m.summarizer.ExtractFacts(ctx, content, source)
+}
+
 // ExtractKeyPoints 智能提取讨论关键点
 func (m *Manager) ExtractKeyPoints(ctx context.Context, discussions []DiscussionInput) ([]string, error) {
-	if m.summarizer == nil {
-		// 无 LLM 时使用简单截取
+	if m.summarizer == nil || m.quota.Level() >= DegradeSoft {
+		// 无 LLM 或配额紧张时使用简单截取
 		return m.fallbackExtractKeyPoints(discussions), nil
 	}
 	return m.summarizer.ExtractKeyPoints(ctx, discussions)
@@ -247,8 +551,35 @@ func (m *Manager) fallbackExtractKeyPoints(discussions []DiscussionInput) []stri
 	return points
 }
 
+// Snapshot 导出股票记忆快照（事实 + 摘要 + 最近轮次），不存在时返回 nil
+func (m *Manager) Snapshot(stockCode string) (json.RawMessage, error) {
+	mem, err := m.storage.Load(stockCode)
+	if err != nil {
+		return nil, nil
+	}
+	return json.Marshal(mem)
+}
+
+// Restore 用快照覆盖股票记忆，快照为空时删除记忆
+func (m *Manager) Restore(stockCode string, snapshot json.RawMessage) error {
+	if len(snapshot) == 0 {
+		return m.storage.Delete(stockCode)
+	}
+	var mem StockMemory
+	if err := json.Unmarshal(snapshot, &mem); err != nil {
+		return fmt.Errorf("记忆快照解析失败: %w", err)
+	}
+	mem.StockCode = stockCode
+	return m.Save(&mem)
+}
+
 // DeleteMemory 删除指定股票的记忆
 func (m *Manager) DeleteMemory(stockCode string) error {
+	if m.vectors != nil {
+		if err := m.vectors.Delete(stockCode); err != nil {
+			return err
+		}
+	}
 	return m.storage.Delete(stockCode)
 }
 
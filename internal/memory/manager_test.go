@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/adk/model"
+)
+
+// stubSummarizer 记录摘要调用参数，返回固定结果
+type stubSummarizer struct {
+	previous  string
+	maxLength int
+}
+
+func (s *stubSummarizer) SummarizeRounds(_ context.Context, previous string, rounds []RoundMemory, maxLength int) (string, error) {
+	s.previous, s.maxLength = previous, maxLength
+	return "合并摘要", nil
+}
+
+func (s *stubSummarizer) ExtractFacts(_ context.Context, content, agentName string) ([]MemoryEntry, error) {
+	return []MemoryEntry{{ID: "f1", Type: EntryTypeFact, Content: "营收同比增长 20%", Source: agentName}}, nil
+}
+
+func (s *stubSummarizer) ExtractKeyPoints(context.Context, []DiscussionInput) ([]string, error) {
+	return nil, nil
+}
+
+func TestManager_CompressMergesSummaryAndExtractsFacts(t *testing.T) {
+	stub := &stubSummarizer{}
+	m := &Manager{
+		config:     Config{MaxRecentRounds: 1, MaxKeyFacts: 5, MaxSummaryLength: 120, CompressThreshold: 3},
+		quota:      NewQuotaGuard(DegradePolicy{}),
+		summarizer: stub,
+	}
+	mem := NewStockMemory("sh600000", "浦发银行")
+	mem.Summary = "旧摘要"
+	mem.RecentRounds = []RoundMemory{{Round: 1, Query: "q1"}, {Round: 2, Query: "q2"}, {Round: 3, Query: "q3"}}
+
+	if err := m.compress(context.Background(), mem); err != nil {
+		t.Fatalf("压缩失败: %v", err)
+	}
+	if mem.Summary != "合并摘要" || stub.previous != "旧摘要" || stub.maxLength != 120 {
+		t.Errorf("summary = %q, previous = %q, maxLength = %d", mem.Summary, stub.previous, stub.maxLength)
+	}
+	if len(mem.RecentRounds) != 1 || mem.RecentRounds[0].Round != 3 {
+		t.Errorf("recent rounds = %+v, 期望只保留第 3 轮", mem.RecentRounds)
+	}
+	if len(mem.KeyFacts) != 1 || mem.KeyFacts[0].Source != "memory" {
+		t.Errorf("key facts = %+v", mem.KeyFacts)
+	}
+}
+
+// fixedCounter 返回固定 token 数
+type fixedCounter int
+
+func (c fixedCounter) CountTokens(context.Context, *model.LLMRequest) (int, error) {
+	return int(c), nil
+}
+
+func TestManager_OverTokenBudget(t *testing.T) {
+	m := &Manager{config: Config{CompressTokenRatio: 0.1}}
+	mem := NewStockMemory("sh600000", "浦发银行")
+	mem.RecentRounds = []RoundMemory{{Round: 1, Query: "q1", Consensus: "c1"}}
+
+	if m.overTokenBudget(context.Background(), mem) {
+		t.Error("未设置计数器时不应按 token 触发")
+	}
+	m.SetTokenBudget(fixedCounter(900), 10000)
+	if m.overTokenBudget(context.Background(), mem) {
+		t.Error("900 < 10000*0.1，不应触发")
+	}
+	m.SetTokenBudget(fixedCounter(900), 4000)
+	if !m.overTokenBudget(context.Background(), mem) {
+		t.Error("900 > 4000*0.1，应触发压缩")
+	}
+}
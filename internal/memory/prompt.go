@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// PromptTemplates 用户自定义的记忆提示词（text/template 文本），为空时使用内置提示词
+//
+// 摘要模板可用变量：.StockCode .StockName .Position .DateFrom .DateTo .Previous .MaxLength
+// 以及 .Rounds（每轮含 .Round .Query .Consensus .KeyPoints）
+// 事实提取模板可用变量：.StockCode .StockName .Position .DateFrom .DateTo .Content .Source
+type PromptTemplates struct {
+	Summarize    string
+	ExtractFacts string
+}
+
+// PromptContext 渲染提示词时的股票上下文
+type PromptContext struct {
+	StockCode string
+	StockName string
+	Position  string // 持仓描述，未持仓时为空
+	DateFrom  string // 涉及讨论的起止日期（2006-01-02）
+	DateTo    string
+}
+
+// summarizePromptData 摘要模板数据
+type summarizePromptData struct {
+	PromptContext
+	Previous  string
+	Rounds    []RoundMemory
+	MaxLength int
+}
+
+// factsPromptData 事实提取模板数据
+type factsPromptData struct {
+	PromptContext
+	Content string
+	Source  string
+}
+
+// promptTemplates 已解析的自定义模板，nil 表示使用内置提示词
+type promptTemplates struct {
+	summarize    *template.Template
+	extractFacts *template.Template
+}
+
+// parsePromptTemplates 解析并用示例数据试渲染，变量名写错时直接报错
+func parsePromptTemplates(t PromptTemplates) (*promptTemplates, error) {
+	sample := PromptContext{StockCode: "sh600000", StockName: "浦发银行", DateFrom: "2026-01-01", DateTo: "2026-01-31"}
+	parsed := &promptTemplates{}
+	var err error
+	if parsed.summarize, err = parsePromptTemplate("summarize", t.Summarize, summarizePromptData{PromptContext: sample, Rounds: []RoundMemory{{Round: 1}}, MaxLength: 300}); err != nil {
+		return nil, err
+	}
+	if parsed.extractFacts, err = parsePromptTemplate("extract_facts", t.ExtractFacts, factsPromptData{PromptContext: sample}); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+func parsePromptTemplate(name, text string, sample any) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析记忆提示词 %s 失败: %w", name, err)
+	}
+	if err := tmpl.Execute(new(strings.Builder), sample); err != nil {
+		return nil, fmt.Errorf("记忆提示词 %s 变量无效: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// ValidatePromptTemplates 校验自定义记忆提示词
+func ValidatePromptTemplates(t PromptTemplates) error {
+	_, err := parsePromptTemplates(t)
+	return err
+}
+
+// renderPrompt 渲染自定义模板，失败时返回 false 由调用方回退到内置提示词
+func renderPrompt(tmpl *template.Template, data any) (string, bool) {
+	if tmpl == nil {
+		return "", false
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		fmt.Printf("render memory prompt %s error, fallback to default: %v\n", tmpl.Name(), err)
+		return "", false
+	}
+	return sb.String(), true
+}
+
+type promptContextKey struct{}
+
+// withPromptContext 在 context 中附带本次压缩的股票上下文
+func withPromptContext(ctx context.Context, pc PromptContext) context.Context {
+	return context.WithValue(ctx, promptContextKey{}, pc)
+}
+
+// promptContextFrom 读取股票上下文，未附带时日期取当天
+func promptContextFrom(ctx context.Context) PromptContext {
+	pc, ok := ctx.Value(promptContextKey{}).(PromptContext)
+	if !ok {
+		today := time.Now().Format("2006-01-02")
+		pc = PromptContext{DateFrom: today, DateTo: today}
+	}
+	return pc
+}
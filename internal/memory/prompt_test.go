@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPromptTemplates_RenderAndValidate(t *testing.T) {
+	if err := ValidatePromptTemplates(PromptTemplates{Summarize: "{{.Unknown}}"}); err == nil {
+		t.Error("未知变量应校验失败")
+	}
+	if err := ValidatePromptTemplates(PromptTemplates{ExtractFacts: "{{.Rounds}}"}); err == nil {
+		t.Error("事实提取模板不支持 .Rounds")
+	}
+
+	parsed, err := parsePromptTemplates(PromptTemplates{
+		Summarize: "Summarize {{.StockName}}({{.StockCode}}) {{.DateFrom}}~{{.DateTo}} {{.Position}} in {{.MaxLength}} words:{{range .Rounds}} {{.Query}}{{end}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := withPromptContext(context.Background(), PromptContext{
+		StockCode: "sh600000", StockName: "浦发银行", Position: "持有 1000 股", DateFrom: "2026-03-01", DateTo: "2026-03-05",
+	})
+	prompt, ok := renderPrompt(parsed.summarize, summarizePromptData{
+		PromptContext: promptContextFrom(ctx),
+		Rounds:        []RoundMemory{{Query: "分红"}},
+		MaxLength:     200,
+	})
+	want := "Summarize 浦发银行(sh600000) 2026-03-01~2026-03-05 持有 1000 股 in 200 words: 分红"
+	if !ok || prompt != want {
+		t.Errorf("prompt = %q, 期望 %q", prompt, want)
+	}
+	if parsed.extractFacts != nil {
+		t.Error("未自定义的模板应使用内置提示词")
+	}
+	if _, ok := renderPrompt(nil, nil); ok || !strings.Contains(new(LLMSummarizer).buildExtractPrompt("x"), "JSON") {
+		t.Error("内置提示词回退异常")
+	}
+}
@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DegradeLevel 记忆功能降级级别
+type DegradeLevel int
+
+const (
+	DegradeNone DegradeLevel = iota // 正常
+	DegradeSoft                     // 延长压缩间隔，关键点/事实改用规则提取
+	DegradeHard                     // 不再调用 LLM，压缩改为规则摘要
+)
+
+// DegradePolicy 降级策略
+type DegradePolicy struct {
+	Enabled                bool
+	DailyTokenBudget       int           // 每日 token 预算，0 表示不按预算降级
+	SoftRatio              float64       // 用量达到预算的比例时进入 Soft，默认 0.7
+	HardRatio              float64       // 用量达到预算的比例时进入 Hard，默认 0.9
+	RateLimitCooldown      time.Duration // 遇到限流后保持 Hard 的时长，默认 5 分钟
+	CompressIntervalFactor int           // Soft 级别下压缩阈值的放大倍数，默认 2
+}
+
+// withDefaults 填充默认值
+func (p DegradePolicy) withDefaults() DegradePolicy {
+	if p.SoftRatio <= 0 {
+		p.SoftRatio = 0.7
+	}
+	if p.HardRatio <= 0 {
+		p.HardRatio = 0.9
+	}
+	if p.RateLimitCooldown <= 0 {
+		p.RateLimitCooldown = 5 * time.Minute
+	}
+	if p.CompressIntervalFactor <= 1 {
+		p.CompressIntervalFactor = 2
+	}
+	return p
+}
+
+// QuotaGuard 跟踪当日 token 用量和限流情况，决定非必要 LLM 调用的降级级别
+// 设置存储路径后当日用量写入文件，应用重启后继续累计
+type QuotaGuard struct {
+	mu            sync.Mutex
+	policy        DegradePolicy
+	day           string
+	used          int
+	lastRateLimit time.Time
+	now           func() time.Time
+	path          string // 当日用量文件，为空时只在内存中统计
+}
+
+// quotaUsage 持久化的当日用量
+type quotaUsage struct {
+	Day  string `json:"day"`
+	Used int    `json:"used"`
+}
+
+// NewQuotaGuard 创建配额守卫
+func NewQuotaGuard(policy DegradePolicy) *QuotaGuard {
+	return &QuotaGuard{policy: policy.withDefaults(), now: time.Now}
+}
+
+// persistTo 设置当日用量文件并读取已记录的用量（跨天的记录在 rollDay 时清零）
+func (g *QuotaGuard) persistTo(path string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.path = path
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var u quotaUsage
+	if json.Unmarshal(data, &u) == nil {
+		g.day, g.used = u.Day, u.Used
+	}
+}
+
+// saveLocked 写入当日用量（调用方需持有锁），先写临时文件再重命名
+func (g *QuotaGuard) saveLocked() {
+	if g.path == "" {
+		return
+	}
+	data, err := json.Marshal(quotaUsage{Day: g.day, Used: g.used})
+	if err != nil {
+		return
+	}
+	tmp := g.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(g.path), 0755); err == nil {
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, g.path)
+		}
+		if err != nil {
+			fmt.Printf("save memory quota usage error: %v\n", err)
+		}
+	}
+}
+
+// SetPolicy 更新降级策略，保留已统计的用量
+func (g *QuotaGuard) SetPolicy(policy DegradePolicy) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.policy = policy.withDefaults()
+}
+
+// Policy 返回当前策略
+func (g *QuotaGuard) Policy() DegradePolicy {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.policy
+}
+
+// RecordUsage 累计 token 用量，跨天自动清零
+func (g *QuotaGuard) RecordUsage(tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rollDay()
+	g.used += tokens
+	g.saveLocked()
+}
+
+// RecordError 记录模型调用错误，识别为限流时进入冷却期
+func (g *QuotaGuard) RecordError(err error) {
+	if err == nil || !IsRateLimitError(err) {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.lastRateLimit = g.now()
+}
+
+// Level 返回当前降级级别
+func (g *QuotaGuard) Level() DegradeLevel {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.policy.Enabled {
+		return DegradeNone
+	}
+	if !g.lastRateLimit.IsZero() && g.now().Sub(g.lastRateLimit) < g.policy.RateLimitCooldown {
+		return DegradeHard
+	}
+	if g.policy.DailyTokenBudget <= 0 {
+		return DegradeNone
+	}
+	g.rollDay()
+	ratio := float64(g.used) / float64(g.policy.DailyTokenBudget)
+	switch {
+	case ratio >= g.policy.HardRatio:
+		return DegradeHard
+	case ratio >= g.policy.SoftRatio:
+		return DegradeSoft
+	default:
+		return DegradeNone
+	}
+}
+
+// Used 返回当日已用 token
+func (g *QuotaGuard) Used() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rollDay()
+	return g.used
+}
+
+// rollDay 跨天重置用量（调用方需持有锁）
+func (g *QuotaGuard) rollDay() {
+	day := g.now().Format("2006-01-02")
+	if day != g.day {
+		g.day = day
+		g.used = 0
+	}
+}
+
+// IsRateLimitError 判断错误是否为供应商限流/配额耗尽
+func IsRateLimitError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, key := range []string{"429", "rate limit", "rate_limit", "too many requests", "resource_exhausted", "quota"} {
+		if strings.Contains(msg, key) {
+			return true
+		}
+	}
+	return false
+}
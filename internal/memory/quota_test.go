@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestQuotaGuard_Level(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	g := NewQuotaGuard(DegradePolicy{Enabled: true, DailyTokenBudget: 1000})
+	g.now = func() time.Time { return now }
+
+	if lv := g.Level(); lv != DegradeNone {
+		t.Fatalf("level = %d, want none", lv)
+	}
+	g.RecordUsage(750)
+	if lv := g.Level(); lv != DegradeSoft {
+		t.Fatalf("level = %d, want soft", lv)
+	}
+	g.RecordUsage(200)
+	if lv := g.Level(); lv != DegradeHard {
+		t.Fatalf("level = %d, want hard", lv)
+	}
+
+	// 跨天清零
+	now = now.Add(24 * time.Hour)
+	if lv := g.Level(); lv != DegradeNone {
+		t.Fatalf("level after day roll = %d, want none", lv)
+	}
+
+	// 限流进入冷却期，期满恢复
+	g.RecordError(errors.New("OpenAI API error (HTTP 429): Too Many Requests"))
+	if lv := g.Level(); lv != DegradeHard {
+		t.Fatalf("level after 429 = %d, want hard", lv)
+	}
+	now = now.Add(6 * time.Minute)
+	if lv := g.Level(); lv != DegradeNone {
+		t.Fatalf("level after cooldown = %d, want none", lv)
+	}
+}
+
+func TestQuotaGuard_PersistsDailyUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory_quota.json")
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+
+	g := NewQuotaGuard(DegradePolicy{Enabled: true, DailyTokenBudget: 1000})
+	g.now = func() time.Time { return now }
+	g.persistTo(path)
+	g.RecordUsage(950)
+
+	// 重启后当日用量继续累计
+	restarted := NewQuotaGuard(DegradePolicy{Enabled: true, DailyTokenBudget: 1000})
+	restarted.now = func() time.Time { return now }
+	restarted.persistTo(path)
+	if used, lv := restarted.Used(), restarted.Level(); used != 950 || lv != DegradeHard {
+		t.Fatalf("after restart used = %d, level = %d", used, lv)
+	}
+
+	// 次日重启从零开始
+	tomorrow := NewQuotaGuard(DegradePolicy{Enabled: true, DailyTokenBudget: 1000})
+	tomorrow.now = func() time.Time { return now.Add(24 * time.Hour) }
+	tomorrow.persistTo(path)
+	if used := tomorrow.Used(); used != 0 {
+		t.Fatalf("next day used = %d", used)
+	}
+}
+
+// usageLLM 返回固定文本与用量的模型
+type usageLLM struct {
+	tokens int32
+	err    error
+}
+
+func (l usageLLM) Name() string { return "usage" }
+
+func (l usageLLM) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if l.err != nil {
+			yield(nil, l.err)
+			return
+		}
+		yield(&model.LLMResponse{
+			Content:       genai.NewContentFromText("- 要点", genai.RoleModel),
+			UsageMetadata: &genai.GenerateContentResponseUsageMetadata{TotalTokenCount: l.tokens},
+		}, nil)
+	}
+}
+
+func TestManager_RecordsSummarizerUsage(t *testing.T) {
+	m := NewManager(t.TempDir())
+	defer m.Close()
+	m.SetDegradePolicy(DegradePolicy{Enabled: true, DailyTokenBudget: 1000})
+
+	m.SetLLM(usageLLM{tokens: 800})
+	if _, err := m.summarizer.ExtractKeyPoints(context.Background(), []DiscussionInput{{AgentName: "专家", Content: "看多"}}); err != nil {
+		t.Fatal(err)
+	}
+	if used := m.Quota().Used(); used != 800 {
+		t.Fatalf("used = %d, want 800", used)
+	}
+
+	m.SetLLM(usageLLM{err: errors.New("HTTP 429 Too Many Requests")})
+	m.summarizer.ExtractKeyPoints(context.Background(), []DiscussionInput{{AgentName: "专家", Content: "看多"}})
+	if lv := m.Quota().Level(); lv != DegradeHard {
+		t.Fatalf("level after summarizer 429 = %d, want hard", lv)
+	}
+}
@@ -14,7 +14,8 @@ import (
 
 // Summarizer 摘要生成器接口
 type Summarizer interface {
-	SummarizeRounds(ctx context.Context, rounds []RoundMemory) (string, error)
+	// SummarizeRounds 把已有摘要与待压缩的轮次合并为一份新摘要，maxLength 为摘要字数上限
+	SummarizeRounds(ctx context.Context, previous string, rounds []RoundMemory, maxLength int) (string, error)
 	ExtractFacts(ctx context.Context, content, agentName string) ([]MemoryEntry, error)
 	ExtractKeyPoints(ctx context.Context, discussions []DiscussionInput) ([]string, error)
 }
@@ -30,6 +31,9 @@ type DiscussionInput struct {
 type LLMSummarizer struct {
 	llm       model.LLM
 	tokenizer Tokenizer
+	templates *promptTemplates // 用户自定义提示词，nil 时使用内置提示词
+	// 每次调用结束后上报 token 用量与错误（计入记忆配额），可为空
+	onUsage func(tokens int, err error)
 }
 
 // NewLLMSummarizer 创建 LLM 摘要生成器
@@ -52,10 +56,16 @@ func (s *LLMSummarizer) generate(ctx context.Context, prompt string) (string, er
 	}
 
 	var result string
+	tokens := 0
 	for resp, err := range s.llm.GenerateContent(ctx, req, false) {
 		if err != nil {
+			s.reportUsage(tokens, err)
 			return "", err
 		}
+		if resp != nil && resp.UsageMetadata != nil {
+			u := resp.UsageMetadata
+			tokens = max(tokens, int(u.TotalTokenCount), int(u.PromptTokenCount+u.CandidatesTokenCount+u.ThoughtsTokenCount))
+		}
 		if resp != nil && resp.Content != nil {
 			for _, part := range resp.Content.Parts {
 				if part.Thought {
@@ -67,27 +77,63 @@ func (s *LLMSummarizer) generate(ctx context.Context, prompt string) (string, er
 			}
 		}
 	}
+	s.reportUsage(tokens, nil)
 	return result, nil
 }
 
-// SummarizeRounds 压缩多轮讨论为摘要
-func (s *LLMSummarizer) SummarizeRounds(ctx context.Context, rounds []RoundMemory) (string, error) {
+// reportUsage 上报本次调用的 token 用量与错误
+func (s *LLMSummarizer) reportUsage(tokens int, err error) {
+	if s.onUsage != nil {
+		s.onUsage(tokens, err)
+	}
+}
+
+// SummarizeRounds 压缩多轮讨论为摘要（与已有摘要合并），超出字数上限时截断
+func (s *LLMSummarizer) SummarizeRounds(ctx context.Context, previous string, rounds []RoundMemory, maxLength int) (string, error) {
 	if len(rounds) == 0 {
-		return "", nil
+		return previous, nil
+	}
+	if maxLength <= 0 {
+		maxLength = DefaultConfig().MaxSummaryLength
 	}
 
-	prompt := s.buildSummarizePrompt(rounds)
-	return s.generate(ctx, prompt)
+	prompt, ok := "", false
+	if s.templates != nil {
+		prompt, ok = renderPrompt(s.templates.summarize, summarizePromptData{
+			PromptContext: promptContextFrom(ctx),
+			Previous:      previous,
+			Rounds:        rounds,
+			MaxLength:     maxLength,
+		})
+	}
+	if !ok {
+		prompt = s.buildSummarizePrompt(previous, rounds, maxLength)
+	}
+	summary, err := s.generate(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	summary = strings.TrimSpace(summary)
+	if runes := []rune(summary); len(runes) > maxLength {
+		summary = string(runes[:maxLength])
+	}
+	return summary, nil
 }
 
-func (s *LLMSummarizer) buildSummarizePrompt(rounds []RoundMemory) string {
+func (s *LLMSummarizer) buildSummarizePrompt(previous string, rounds []RoundMemory, maxLength int) string {
 	var sb strings.Builder
 	sb.WriteString("请将以下多轮股票讨论压缩为简洁摘要。\n\n")
 	sb.WriteString("要求：\n")
 	sb.WriteString("1. 保留关键结论和观点\n")
 	sb.WriteString("2. 去除重复信息\n")
-	sb.WriteString("3. 控制在150字以内\n\n")
-	sb.WriteString("讨论记录：\n")
+	fmt.Fprintf(&sb, "3. 控制在%d字以内\n", maxLength)
+	if previous != "" {
+		sb.WriteString("4. 与已有摘要合并为一份摘要，较新的结论优先\n\n")
+		sb.WriteString("已有摘要：\n")
+		sb.WriteString(previous)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n讨论记录：\n")
 
 	for _, r := range rounds {
 		sb.WriteString(fmt.Sprintf("【第%d轮】问题: %s\n", r.Round, r.Query))
@@ -100,7 +146,17 @@ func (s *LLMSummarizer) buildSummarizePrompt(rounds []RoundMemory) string {
 
 // ExtractFacts 从讨论内容中提取关键事实
 func (s *LLMSummarizer) ExtractFacts(ctx context.Context, content, agentName string) ([]MemoryEntry, error) {
-	prompt := s.buildExtractPrompt(content)
+	prompt, ok := "", false
+	if s.templates != nil {
+		prompt, ok = renderPrompt(s.templates.extractFacts, factsPromptData{
+			PromptContext: promptContextFrom(ctx),
+			Content:       content,
+			Source:        agentName,
+		})
+	}
+	if !ok {
+		prompt = s.buildExtractPrompt(content)
+	}
 	result, err := s.generate(ctx, prompt)
 	if err != nil {
 		return nil, err
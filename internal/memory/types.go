@@ -42,6 +42,7 @@ type StockMemory struct {
 	TotalRounds  int           `json:"total_rounds"`  // 总讨论轮次
 	CreatedAt    int64         `json:"created_at"`
 	UpdatedAt    int64         `json:"updated_at"`
+	Industry     string        `json:"industry,omitempty"` // 所属行业，用于关联同行业讨论
 }
 
 // NewStockMemory 创建新的股票记忆
@@ -63,6 +64,9 @@ type Config struct {
 	MaxKeyFacts       int // 最大关键事实数，默认 20
 	MaxSummaryLength  int // 摘要最大字数，默认 300
 	CompressThreshold int // 触发压缩的轮次数，默认 5
+	// CompressTokenRatio 历史 token 估算超过上下文窗口的该比例时也触发压缩（需设置 TokenBudget），0 只按轮次
+	CompressTokenRatio float64
+	Prompts            PromptTemplates // 自定义摘要与事实提取提示词，为空时使用内置提示词
 }
 
 // DefaultConfig 默认配置
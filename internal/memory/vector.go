@@ -0,0 +1,246 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxVectorEntries 单只股票保留的向量条目上限，超出时淘汰最旧的条目
+	maxVectorEntries = 500
+	// defaultRecallLimit 每次召回注入上下文的条目数
+	defaultRecallLimit = 5
+	// minRecallScore 召回的最低余弦相似度，低于此值视为不相关
+	minRecallScore = 0.2
+)
+
+// Embedder 文本向量化接口（与 adk.Embedder 方法一致，由调用方注入）
+type Embedder interface {
+	// Embed 批量计算文本向量，返回的向量已归一化
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// ID 标识向量空间，模型不同的向量不可比较
+	ID() string
+}
+
+// VectorKind 向量条目来源
+type VectorKind string
+
+const (
+	VectorKindFact       VectorKind = "fact"       // 压缩时提取的关键事实
+	VectorKindConclusion VectorKind = "conclusion" // 被压缩轮次的问题与结论
+)
+
+// VectorEntry 长期记忆的向量条目
+type VectorEntry struct {
+	ID        string     `json:"id"`
+	Kind      VectorKind `json:"kind"`
+	Content   string     `json:"content"`
+	Timestamp int64      `json:"timestamp"`
+	Vector    []float32  `json:"vector,omitempty"`
+}
+
+// ScoredVector 带相似度的向量条目
+type ScoredVector struct {
+	Entry VectorEntry
+	Score float64
+}
+
+// vectorFile 单只股票的向量文件，EmbedderID 变化时按原文重新计算向量
+type vectorFile struct {
+	EmbedderID string        `json:"embedder_id"`
+	Entries    []VectorEntry `json:"entries"`
+}
+
+// VectorStore 按股票隔离的向量记忆存储（memories/vectors/{code}.json）
+type VectorStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewVectorStore 创建向量记忆存储
+func NewVectorStore(dataDir string) *VectorStore {
+	dir := filepath.Join(dataDir, "memories", "vectors")
+	os.MkdirAll(dir, 0755)
+	return &VectorStore{dir: dir}
+}
+
+func (s *VectorStore) path(stockCode string) string {
+	return filepath.Join(s.dir, stockCode+".json")
+}
+
+func (s *VectorStore) load(stockCode string) (*vectorFile, error) {
+	data, err := os.ReadFile(s.path(stockCode))
+	if os.IsNotExist(err) {
+		return &vectorFile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f vectorFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (s *VectorStore) save(stockCode string, f *vectorFile) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(stockCode), data, 0644)
+}
+
+// reembed 向量空间变化（更换了向量模型）时按原文重新计算全部向量
+func (s *VectorStore) reembed(ctx context.Context, embedder Embedder, f *vectorFile) error {
+	if f.EmbedderID == embedder.ID() || len(f.Entries) == 0 {
+		f.EmbedderID = embedder.ID()
+		return nil
+	}
+	texts := make([]string, len(f.Entries))
+	for i, e := range f.Entries {
+		texts[i] = e.Content
+	}
+	vectors, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("重新计算记忆向量失败: %w", err)
+	}
+	for i := range f.Entries {
+		f.Entries[i].Vector = vectors[i]
+	}
+	f.EmbedderID = embedder.ID()
+	return nil
+}
+
+// Add 向量化并追加条目，内容重复的条目跳过
+func (s *VectorStore) Add(ctx context.Context, embedder Embedder, stockCode string, entries []VectorEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.load(stockCode)
+	if err != nil {
+		return err
+	}
+	if err := s.reembed(ctx, embedder, f); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(f.Entries))
+	for _, e := range f.Entries {
+		seen[e.Content] = true
+	}
+	var pending []VectorEntry
+	var texts []string
+	for _, e := range entries {
+		if e.Content == "" || seen[e.Content] {
+			continue
+		}
+		seen[e.Content] = true
+		if e.ID == "" {
+			e.ID = uuid.New().String()
+		}
+		if e.Timestamp == 0 {
+			e.Timestamp = time.Now().UnixMilli()
+		}
+		pending = append(pending, e)
+		texts = append(texts, e.Content)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	vectors, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("计算记忆向量失败: %w", err)
+	}
+	for i := range pending {
+		pending[i].Vector = vectors[i]
+	}
+	f.Entries = append(f.Entries, pending...)
+	if len(f.Entries) > maxVectorEntries {
+		f.Entries = f.Entries[len(f.Entries)-maxVectorEntries:]
+	}
+	return s.save(stockCode, f)
+}
+
+// Search 按余弦相似度检索与 query 最相关的条目，低于 minRecallScore 的不返回
+func (s *VectorStore) Search(ctx context.Context, embedder Embedder, stockCode, query string, limit int) ([]ScoredVector, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.load(stockCode)
+	if err != nil || len(f.Entries) == 0 {
+		return nil, err
+	}
+	if f.EmbedderID != embedder.ID() {
+		if err := s.reembed(ctx, embedder, f); err != nil {
+			return nil, err
+		}
+		if err := s.save(stockCode, f); err != nil {
+			return nil, err
+		}
+	}
+	vectors, err := embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("计算查询向量失败: %w", err)
+	}
+
+	var scored []ScoredVector
+	for _, e := range f.Entries {
+		if score := cosineSimilarity(vectors[0], e.Vector); score >= minRecallScore {
+			scored = append(scored, ScoredVector{Entry: e, Score: score})
+		}
+	}
+	sort.Slice(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored, nil
+}
+
+// Snapshot 读取股票的向量文件副本（不存在时为空）
+func (s *VectorStore) Snapshot(stockCode string) (*vectorFile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(stockCode)
+}
+
+// Replace 用导入的条目覆盖股票的向量文件
+func (s *VectorStore) Replace(stockCode string, f *vectorFile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(f.Entries) == 0 {
+		err := os.Remove(s.path(stockCode))
+		if err != nil && os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return s.save(stockCode, f)
+}
+
+// Delete 删除股票的向量记忆
+func (s *VectorStore) Delete(stockCode string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(stockCode))
+	if err != nil && os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// cosineSimilarity 归一化向量的余弦相似度（维度不同时按较短的计算）
+func cosineSimilarity(a, b []float32) float64 {
+	var dot float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// keywordEmbedder 按是否包含关键词生成向量，便于断言召回结果
+type keywordEmbedder struct{ id string }
+
+var testKeywords = []string{"分红", "订单", "减持"}
+
+func (e keywordEmbedder) ID() string { return e.id }
+
+func (e keywordEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, len(testKeywords))
+		for j, k := range testKeywords {
+			if strings.Contains(text, k) {
+				vec[j] = 1
+				break
+			}
+		}
+		vectors[i] = vec
+	}
+	return vectors, nil
+}
+
+func TestVectorStore_SearchRecallsRelevantMemory(t *testing.T) {
+	store := NewVectorStore(t.TempDir())
+	ctx := context.Background()
+	embedder := keywordEmbedder{id: "v1"}
+
+	entries := []VectorEntry{
+		{Kind: VectorKindConclusion, Content: "问题: 分红怎么看\n结论: 维持高分红，适合长期持有"},
+		{Kind: VectorKindFact, Content: "新签订单 30 亿元"},
+		{Kind: VectorKindFact, Content: "新签订单 30 亿元"},
+	}
+	if err := store.Add(ctx, embedder, "sh600000", entries); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+
+	results, err := store.Search(ctx, embedder, "sh600000", "上个月关于分红的结论是什么", 5)
+	if err != nil {
+		t.Fatalf("检索失败: %v", err)
+	}
+	if len(results) != 1 || results[0].Entry.Kind != VectorKindConclusion {
+		t.Fatalf("results = %+v, 期望只召回分红结论", results)
+	}
+
+	// 更换向量模型后按原文重新计算，重复内容只保留一条
+	f, _ := store.load("sh600000")
+	if len(f.Entries) != 2 {
+		t.Errorf("entries = %d, 期望去重后 2 条", len(f.Entries))
+	}
+	if _, err := store.Search(ctx, keywordEmbedder{id: "v2"}, "sh600000", "订单", 5); err != nil {
+		t.Fatalf("更换向量模型后检索失败: %v", err)
+	}
+	if f, _ := store.load("sh600000"); f.EmbedderID != "v2" {
+		t.Errorf("embedderID = %q, 期望 v2", f.EmbedderID)
+	}
+}
@@ -12,4 +12,6 @@ type AgentConfig struct {
 	MCPServers  []string `json:"mcpServers"`
 	Enabled     bool     `json:"enabled"`
 	AIConfigID  string   `json:"aiConfigId"` // 可选，空则用默认AI
+	// 可选，JSON Schema 文本；设置后要求专家按该结构输出 JSON
+	ResponseSchema string `json:"responseSchema,omitempty"`
 }
@@ -0,0 +1,45 @@
+package models
+
+import "encoding/json"
+
+// 分析产物类型
+const (
+	ArtifactKindTable = "table" // 表格
+	ArtifactKindChart = "chart" // 图表（前端渲染用的图表配置 JSON）
+	ArtifactKindJSON  = "json"  // 结构化数据（如 JSON 格式的操作建议）
+	ArtifactKindFile  = "file"  // 生成的文件
+)
+
+// Artifact 分析产物：从发言中提取或单独保存的结构化结果，与消息关联
+type Artifact struct {
+	ID        string          `json:"id"`
+	StockCode string          `json:"stockCode"`
+	MessageID string          `json:"messageId,omitempty"` // 关联的消息 ID
+	AgentID   string          `json:"agentId,omitempty"`
+	AgentName string          `json:"agentName,omitempty"`
+	Model     string          `json:"model,omitempty"` // 生成关联消息的模型
+	Kind      string          `json:"kind"`            // table / chart / json / file
+	Title     string          `json:"title"`
+	Table     *ArtifactTable  `json:"table,omitempty"`    // table
+	Data      json.RawMessage `json:"data,omitempty"`     // chart / json
+	FileName  string          `json:"fileName,omitempty"` // file
+	MimeType  string          `json:"mimeType,omitempty"` // file
+	Content   string          `json:"content,omitempty"`  // file：文本内容，Base64 为 true 时为 base64 编码
+	Base64    bool            `json:"base64,omitempty"`
+	CreatedAt int64           `json:"createdAt"`
+}
+
+// ArtifactTable 表格数据
+type ArtifactTable struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// ArtifactExport 导出结果：前端据此保存文件
+type ArtifactExport struct {
+	FileName string `json:"fileName"`
+	MimeType string `json:"mimeType"`
+	Content  string `json:"content"`
+	Base64   bool   `json:"base64,omitempty"` // Content 为 base64 编码的二进制内容
+	Error    string `json:"error,omitempty"`
+}
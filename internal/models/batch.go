@@ -0,0 +1,29 @@
+package models
+
+// BatchConfig 定时批量分析配置：交易日指定时间后把自选股分析通过 OpenAI Batch API 提交（费用约为实时调用的一半）
+type BatchConfig struct {
+	Enabled    bool   `json:"enabled"`
+	AIConfigID string `json:"aiConfigId"` // 使用的 AI 配置，需为 OpenAI 提供商，空则使用默认配置
+	Time       string `json:"time"`       // 提交时间 HH:MM（北京时间），空则为 15:30
+	Query      string `json:"query"`      // 分析要求，空则使用默认的盘后复盘要求
+}
+
+// BatchJob 批量分析任务
+type BatchJob struct {
+	ID          string   `json:"id"`
+	BatchID     string   `json:"batchId"` // OpenAI 端的任务 ID
+	AIConfigID  string   `json:"aiConfigId"`
+	Model       string   `json:"model"`
+	Query       string   `json:"query"`
+	StockCodes  []string `json:"stockCodes"`
+	Status      string   `json:"status"` // 与 OpenAI 任务状态一致，提交失败时为 failed
+	Total       int      `json:"total"`
+	Completed   int      `json:"completed"`
+	Failed      int      `json:"failed"`
+	Applied     bool     `json:"applied"` // 结果已写回会话
+	Error       string   `json:"error,omitempty"`
+	CreatedAt   int64    `json:"createdAt"`
+	UpdatedAt   int64    `json:"updatedAt"`
+	CompletedAt int64    `json:"completedAt,omitempty"`
+	Scheduled   bool     `json:"scheduled,omitempty"` // 由定时任务提交
+}
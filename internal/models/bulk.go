@@ -0,0 +1,27 @@
+package models
+
+// 会话批量操作类型
+const (
+	BulkDeleteInactiveSessions = "delete_inactive_sessions" // 删除无持仓且长期无活动的会话
+	BulkClearOldMessages       = "clear_old_messages"       // 清除指定日期之前的消息
+	BulkCompressMemories       = "compress_memories"        // 对所有记忆重新执行压缩
+)
+
+// BulkItem 批量操作中单个会话的处理结果
+type BulkItem struct {
+	StockCode string `json:"stockCode"`
+	StockName string `json:"stockName,omitempty"`
+	Count     int    `json:"count"`            // 受影响的消息数/轮次数
+	Detail    string `json:"detail,omitempty"` // 说明（如最后活动时间）
+	Error     string `json:"error,omitempty"`
+}
+
+// BulkReport 批量操作报告，DryRun 为 true 时只统计不修改
+type BulkReport struct {
+	Action  string     `json:"action"`
+	DryRun  bool       `json:"dryRun"`
+	Scanned int        `json:"scanned"` // 扫描的会话数
+	Items   []BulkItem `json:"items"`   // 命中的会话
+	Total   int        `json:"total"`   // 受影响的消息数/轮次数合计
+	Error   string     `json:"error,omitempty"`
+}
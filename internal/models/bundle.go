@@ -0,0 +1,29 @@
+package models
+
+// ResearchBundleVersion 研究快照格式版本
+const ResearchBundleVersion = 1
+
+// ResearchBundleManifest 研究快照清单（随数据一起加密）
+type ResearchBundleManifest struct {
+	Version   int                  `json:"version"`
+	CreatedAt int64                `json:"createdAt"`
+	Files     []ResearchBundleFile `json:"files"`
+}
+
+// ResearchBundleFile 快照中的单个文件
+type ResearchBundleFile struct {
+	Path   string `json:"path"` // 相对数据目录的路径（/ 分隔）
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ResearchBundleInfo 研究快照导出、校验或解包结果
+type ResearchBundleInfo struct {
+	Path      string         `json:"path"`
+	CreatedAt int64          `json:"createdAt"`
+	Files     int            `json:"files"`
+	Bytes     int64          `json:"bytes"`
+	Counts    map[string]int `json:"counts,omitempty"`    // 按类别统计的文件数: sessions/notes/artifacts/memories...
+	Extracted string         `json:"extracted,omitempty"` // 解包目录（仅解包时）
+	Error     string         `json:"error,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "encoding/json"
+
+// Checkpoint 会话检查点（消息 + 记忆快照），用于回滚
+type Checkpoint struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	StockCode string          `json:"stockCode"`
+	Messages  []ChatMessage   `json:"messages"`
+	Memory    json.RawMessage `json:"memory,omitempty"` // 记忆快照（事实 + 摘要），未启用记忆时为空
+	CreatedAt int64           `json:"createdAt"`
+}
+
+// CheckpointInfo 检查点概要（列表展示用，不含快照内容）
+type CheckpointInfo struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	MessageCount int    `json:"messageCount"`
+	HasMemory    bool   `json:"hasMemory"`
+	CreatedAt    int64  `json:"createdAt"`
+}
@@ -4,10 +4,13 @@ package models
 type AIProvider string
 
 const (
-	AIProviderOpenAI    AIProvider = "openai"
-	AIProviderGemini    AIProvider = "gemini"
-	AIProviderVertexAI  AIProvider = "vertexai"
-	AIProviderAnthropic AIProvider = "anthropic"
+	AIProviderOpenAI     AIProvider = "openai"
+	AIProviderGemini     AIProvider = "gemini"
+	AIProviderVertexAI   AIProvider = "vertexai"
+	AIProviderAnthropic  AIProvider = "anthropic"
+	AIProviderBedrock    AIProvider = "bedrock"
+	AIProviderOpenRouter AIProvider = "openrouter"
+	AIProviderMistral    AIProvider = "mistral"
 )
 
 // AIConfig AI服务配置
@@ -17,19 +20,96 @@ type AIConfig struct {
 	Provider    AIProvider `json:"provider"`
 	BaseURL     string     `json:"baseUrl"`
 	APIKey      string     `json:"apiKey"`
+	// 额外的 API Key，与 APIKey 一起按请求轮换，429 后单独冷却
+	APIKeys     []string   `json:"apiKeys,omitempty"`
 	ModelName   string     `json:"modelName"`
 	MaxTokens   int        `json:"maxTokens"`
 	Temperature float64    `json:"temperature"`
-	Timeout     int        `json:"timeout"`
+	Timeout     int        `json:"timeout"` // 连接与等待响应头的超时（秒），0 使用默认值
+	// 流式响应空闲超时（秒），超过该时间未收到事件则中断并重试，0 表示不限制
+	StreamIdleTimeout int `json:"streamIdleTimeout"`
+	// 流式文本片段合并窗口（毫秒），窗口内的片段合并后输出，0 使用默认值（100ms），-1 关闭合并
+	StreamCoalesceMs int `json:"streamCoalesceMs"`
+	// 合并缓冲达到该字符数时立即输出，0 使用默认值（256）
+	StreamCoalesceRunes int `json:"streamCoalesceRunes"`
+	// 采样参数，为空时使用服务端默认值
+	TopP             *float64 `json:"topP,omitempty"`
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`
+	// 固定随机种子，配合 temperature 0 获得可复现的输出（服务端尽力保证）
+	Seed *int `json:"seed,omitempty"`
+	// logit_bias：token ID -> 偏置（-100~100），仅 OpenAI Chat Completions 生效
+	LogitBias map[string]int `json:"logitBias,omitempty"`
+	// 单独的 HTTP 代理，启用后覆盖全局代理设置
+	HttpProxy        string `json:"httpProxy"`
+	HttpProxyEnabled bool   `json:"httpProxyEnabled"`
+	// TLS 选项（自签名证书的企业内网网关）
+	TLSInsecureSkipVerify bool   `json:"tlsInsecureSkipVerify"`
+	TLSCACert             string `json:"tlsCaCert"` // 额外信任的 CA 证书（PEM）
+	// 单次 HTTP 请求的最大尝试次数（含首次，遇到 429/5xx 时退避重试），0 使用默认值 3，1 表示不重试
+	MaxAttempts int `json:"maxAttempts"`
+	// 本地限流（按配置 ID 共享配额），0 表示不限制
+	RequestsPerMinute int `json:"requestsPerMinute"`
+	TokensPerMinute   int `json:"tokensPerMinute"` // 按请求体大小估算输入 token
+	// 每轮请求中工具定义的 token 预算，超出时移除最久未使用的工具；0 使用默认值 8000，-1 表示不限制
+	ToolSchemaBudget int `json:"toolSchemaBudget"`
+	// 按与问题的语义相关度只附带前 N 个工具，0 表示不启用
+	ToolSelectionTopN int `json:"toolSelectionTopN"`
+	// 模型上下文窗口（token），请求超出时先裁剪较早的历史；0 按模型名自动识别，-1 表示不检查
+	ContextWindow int `json:"contextWindow"`
+	// 向量模型（OpenAI 兼容接口的 /embeddings），为空时使用本地 n-gram 向量
+	EmbeddingModel string `json:"embeddingModel"`
+	// 本地推理后端（Ollama / llama.cpp）：启动时预热模型，避免首次对话等待加载权重
+	WarmUp bool `json:"warmUp"`
+	// Ollama 模型驻留时长（如 "30m"，"-1" 表示常驻），为空使用 Ollama 默认值
+	KeepAlive string `json:"keepAlive"`
 	IsDefault   bool       `json:"isDefault"`
 	// OpenAI Responses API 开关
 	UseResponses bool `json:"useResponses"`
+	// 使用 Conversations API 在服务端保存会话历史（仅 Responses API 生效）
+	UseConversations bool `json:"useConversations"`
+	// 使用 previous_response_id 关联上一次响应，只发送新增内容（仅 Responses API 生效，与 Conversations 同时开启时以 Conversations 为准）
+	UsePreviousResponseID bool `json:"usePreviousResponseId"`
+	// OpenAI 内置工具（web_search / code_interpreter），由服务端执行并返回引用来源（仅 Responses API 生效）
+	HostedTools []string `json:"hostedTools,omitempty"`
+	// 推理模型返回推理摘要（auto / concise / detailed，仅 Responses API 生效），摘要作为思考内容实时展示
+	ReasoningSummary string `json:"reasoningSummary,omitempty"`
 	// 不支持 system role（自动检测，用户不可见）
 	NoSystemRole bool `json:"noSystemRole"`
+	// 关闭 Anthropic 提示缓存（默认在系统提示词、工具定义和最新历史处设置 cache_control 断点）
+	DisablePromptCache bool `json:"disablePromptCache"`
+	// Gemini 上下文缓存：系统提示词与工具定义较长时创建缓存内容，后续相同前缀的请求直接引用（Gemini / Vertex AI 生效）
+	ContextCache bool `json:"contextCache"`
+	// 自定义模型单价（美元 / 百万 token），为空时按全局价格表匹配
+	Price *ModelPrice `json:"price,omitempty"`
 	// Vertex AI 专用字段
 	Project         string `json:"project"`
 	Location        string `json:"location"`
 	CredentialsJSON string `json:"credentialsJson"`
+	// AWS Bedrock 专用字段（凭证为空时读取 AWS_* 环境变量）
+	Region          string `json:"region"`
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	SessionToken    string `json:"sessionToken"`
+	// OpenRouter 专用字段：上游供应商路由偏好
+	ProviderOrder  []string `json:"providerOrder"`
+	AllowFallbacks *bool    `json:"allowFallbacks"` // nil 使用 OpenRouter 默认（允许回退）
+	// Mistral 专用字段：由服务端注入官方安全提示词
+	SafePrompt bool `json:"safePrompt"`
+	// 请求签名（企业网关自定义签名，可选）
+	Signing *RequestSigningConfig `json:"signing,omitempty"`
+}
+
+// RequestSigningConfig 请求签名配置
+// 在请求发出前执行，用于需要自定义签名的企业 LLM 网关
+type RequestSigningConfig struct {
+	Type            string            `json:"type"`            // 签名方式: hmac-sha256 / timestamp
+	KeyID           string            `json:"keyId"`           // 密钥标识（可选）
+	Secret          string            `json:"secret"`          // 签名密钥
+	SignatureHeader string            `json:"signatureHeader"` // 签名头，默认 X-Signature
+	TimestampHeader string            `json:"timestampHeader"` // 时间戳头，默认 X-Timestamp
+	KeyIDHeader     string            `json:"keyIdHeader"`     // 密钥标识头，默认 X-Key-Id
+	Headers         map[string]string `json:"headers"`         // 附加固定请求头
 }
 
 // MCPTransportType MCP传输类型
@@ -51,6 +131,20 @@ type MCPServerConfig struct {
 	Args          []string         `json:"args"`          // 命令行参数
 	ToolFilter    []string         `json:"toolFilter"`    // 工具过滤列表（空则全部）
 	Enabled       bool             `json:"enabled"`       // 是否启用
+	// 命令行传输的沙箱限制（可选）
+	Sandbox *MCPSandboxConfig `json:"sandbox,omitempty"`
+}
+
+// MCPSandboxConfig 命令行 MCP 进程沙箱配置
+// 用户常从网上直接粘贴 npx 命令，开启后限制子进程的环境变量、网络和资源
+type MCPSandboxConfig struct {
+	Enabled       bool              `json:"enabled"`       // 是否启用沙箱
+	AllowEnv      []string          `json:"allowEnv"`      // 额外透传的环境变量名（默认仅保留 PATH/HOME 等基础变量）
+	Env           map[string]string `json:"env"`           // 显式注入的环境变量
+	NoNetwork     bool              `json:"noNetwork"`     // 禁止网络访问
+	MaxMemoryMB   int               `json:"maxMemoryMb"`   // 虚拟内存上限（MB），0 不限制
+	MaxCPUSeconds int               `json:"maxCpuSeconds"` // CPU 时间上限（秒），0 不限制
+	WorkDir       string            `json:"workDir"`       // 工作目录（可选）
 }
 
 // AppConfig 应用配置
@@ -67,6 +161,63 @@ type AppConfig struct {
 	Layout          LayoutConfig      `json:"layout"`        // 界面布局配置
 	OpenClaw        OpenClawConfig    `json:"openClaw"`      // OpenClaw 服务配置
 	Indicators      IndicatorConfig   `json:"indicators"`    // 技术指标配置
+	Storage         StorageConfig     `json:"storage"`       // 存储与隐私配置
+	Delegate        DelegateConfig    `json:"delegate"`      // 子代理委派配置
+	Redaction       RedactionConfig   `json:"redaction"`     // 敏感信息脱敏配置
+	WarmCache       WarmCacheConfig   `json:"warmCache"`     // 盘前行情预热配置
+	PostProcess     PostProcessConfig `json:"postProcess"`   // 专家发言后处理配置
+	Cost            CostConfig        `json:"cost"`          // 费用计算配置
+	BaseCurrency    string            `json:"baseCurrency"`  // 持仓组合的本位币，为空时为 CNY
+	Batch           BatchConfig       `json:"batch"`         // 定时批量分析配置
+	RiskProfile     RiskProfile       `json:"riskProfile"`   // 用户风险画像
+	Digest          DigestConfig      `json:"digest"`        // 每周摘要配置
+	Notify          NotifyConfig      `json:"notify"`        // 通知渠道配置
+	DebugCapture    bool              `json:"debugCapture"`  // 记录模型请求与原始响应（脱敏后按会话写入 debug 目录），用于排查供应商兼容问题
+	SchemaVersion   int               `json:"schemaVersion"` // 写入配置的版本的结构版本，高于当前版本时说明由更新版本写入
+}
+
+// PostProcessConfig 专家发言保存前的后处理流水线配置
+type PostProcessConfig struct {
+	Enabled      bool          `json:"enabled"`
+	Stages       []string      `json:"stages"`       // 按顺序执行的阶段: markdown/tables/numbers/links，空则使用默认阶段
+	LinkRewrites []LinkRewrite `json:"linkRewrites"` // links 阶段的链接改写规则
+}
+
+// LinkRewrite 链接改写规则：以 From 开头的链接替换为 To 前缀
+type LinkRewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// WarmCacheConfig 盘前行情预热：交易日指定时间后为自选股拉取行情与K线写入本地快照
+type WarmCacheConfig struct {
+	Enabled bool   `json:"enabled"`
+	Time    string `json:"time"` // 预热时间 HH:MM（北京时间），空则为 09:00
+}
+
+// RedactionConfig 发往云端模型前的敏感信息脱敏配置
+type RedactionConfig struct {
+	Enabled  bool               `json:"enabled"`
+	Rules    []string           `json:"rules"`    // 启用的内置规则: id_card/bank_card/phone/email，空则全部启用
+	Patterns []RedactionPattern `json:"patterns"` // 自定义正则规则
+}
+
+// RedactionPattern 自定义脱敏规则
+type RedactionPattern struct {
+	Name    string `json:"name"`    // 规则名，用于占位符，如 ACCOUNT
+	Pattern string `json:"pattern"` // Go 正则表达式
+}
+
+// DelegateConfig 子代理委派（delegate 工具）配置
+type DelegateConfig struct {
+	AIConfigID   string   `json:"aiConfigId"`   // 子代理使用的AI，空则用默认AI
+	AllowedTools []string `json:"allowedTools"` // 子代理可用工具，空则使用内置只读数据工具
+	TokenBudget  int      `json:"tokenBudget"`  // 单次委派 token 上限，0 使用默认值
+}
+
+// StorageConfig 存储与隐私配置
+type StorageConfig struct {
+	StripThinking bool `json:"stripThinking"` // 持久化前移除模型思考过程，仅保留最终回答
 }
 
 // ProxyMode 代理模式
@@ -92,6 +243,28 @@ type MemoryConfig struct {
 	MaxKeyFacts       int    `json:"maxKeyFacts"`       // 最大关键事实数
 	MaxSummaryLength  int    `json:"maxSummaryLength"`  // 摘要最大字数
 	CompressThreshold int    `json:"compressThreshold"` // 触发压缩的轮次数
+	CompressTokenRatio float64 `json:"compressTokenRatio"` // 历史 token 估算超过会议模型上下文窗口的该比例时也触发压缩，0 只按轮次
+	Degrade MemoryDegradeConfig `json:"degrade"` // 配额紧张时的降级策略
+	Prompts MemoryPromptConfig  `json:"prompts"` // 自定义记忆提示词
+}
+
+// MemoryPromptConfig 自定义记忆提示词（Go text/template 语法），为空时使用内置提示词
+// 可用变量：.StockCode .StockName .Position .DateFrom .DateTo；
+// 摘要另有 .Previous .Rounds .MaxLength，事实提取另有 .Content .Source
+type MemoryPromptConfig struct {
+	Summarize    string `json:"summarize"`    // 多轮讨论压缩摘要
+	ExtractFacts string `json:"extractFacts"` // 关键事实提取（需输出 JSON 数组）
+}
+
+// MemoryDegradeConfig 记忆功能降级策略
+// 当日 token 用量接近预算或遇到限流时，减少记忆管理的非必要 LLM 调用
+type MemoryDegradeConfig struct {
+	Enabled                bool    `json:"enabled"`
+	DailyTokenBudget       int     `json:"dailyTokenBudget"`       // 每日 token 预算，0 只按限流降级
+	SoftRatio              float64 `json:"softRatio"`              // 达到预算比例后延长压缩间隔、改用规则提取，默认 0.7
+	HardRatio              float64 `json:"hardRatio"`              // 达到预算比例后停止记忆相关 LLM 调用，默认 0.9
+	RateLimitCooldown      int     `json:"rateLimitCooldown"`      // 限流后保持降级的秒数，默认 300
+	CompressIntervalFactor int     `json:"compressIntervalFactor"` // 降级时压缩阈值放大倍数，默认 2
 }
 
 // LayoutConfig 界面布局配置
@@ -105,9 +278,37 @@ type LayoutConfig struct {
 
 // OpenClawConfig OpenClaw 服务配置
 type OpenClawConfig struct {
-	Enabled bool   `json:"enabled"` // 是否启用
-	Port    int    `json:"port"`    // 监听端口
-	APIKey  string `json:"apiKey"`  // API 鉴权密钥（可选）
+	Enabled bool            `json:"enabled"`          // 是否启用
+	Port    int             `json:"port"`             // 监听端口
+	APIKey  string          `json:"apiKey"`           // API 鉴权密钥（可选，拥有全部权限）
+	Tokens  []OpenClawToken `json:"tokens,omitempty"` // 按权限范围划分的访问令牌
+}
+
+// OpenClaw 令牌权限范围，高级别包含低级别的全部权限
+const (
+	OpenClawScopeQuotes  = "quotes"  // 只读行情
+	OpenClawScopeSession = "session" // 发起分析、写入会话
+	OpenClawScopeAdmin   = "admin"   // 配置管理与审计日志
+)
+
+// OpenClawToken OpenClaw 访问令牌
+type OpenClawToken struct {
+	Name  string `json:"name"`  // 令牌名称，记录在审计日志中
+	Token string `json:"token"` // 令牌值
+	Scope string `json:"scope"` // 权限范围：quotes / session / admin
+}
+
+// OpenClawAuditEntry OpenClaw 外部调用审计记录
+type OpenClawAuditEntry struct {
+	Time       int64  `json:"time"`  // 调用时间（毫秒时间戳）
+	Token      string `json:"token"` // 令牌名称，鉴权失败时为空
+	Scope      string `json:"scope"` // 令牌权限范围
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Query      string `json:"query,omitempty"`
+	RemoteAddr string `json:"remoteAddr"`
+	Status     int    `json:"status"`     // HTTP 状态码
+	DurationMs int64  `json:"durationMs"` // 处理耗时
 }
 
 // IndicatorConfig 技术指标配置
@@ -0,0 +1,11 @@
+package models
+
+// ContextCacheInfo Gemini 上下文缓存条目（系统提示词与工具定义）
+type ContextCacheInfo struct {
+	ConfigID  string `json:"configId"`
+	Model     string `json:"model"`
+	Name      string `json:"name"`      // 服务端缓存名称 cachedContents/...
+	Tokens    int    `json:"tokens"`    // 缓存的 token 数
+	Hits      int    `json:"hits"`      // 复用次数
+	ExpiresAt int64  `json:"expiresAt"` // 过期时间（毫秒）
+}
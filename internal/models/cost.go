@@ -0,0 +1,40 @@
+package models
+
+// ModelPrice 模型单价（美元 / 百万 token）
+type ModelPrice struct {
+	Model       string  `json:"model"`                 // 模型名前缀，按最长前缀匹配（如 gpt-4o-mini）
+	Input       float64 `json:"input"`                 // 输入单价
+	CachedInput float64 `json:"cachedInput,omitempty"` // 缓存命中的输入单价，为 0 时按输入单价计
+	Output      float64 `json:"output"`                // 输出单价（含思考 token）
+}
+
+// CostConfig 费用计算配置
+type CostConfig struct {
+	Prices  []ModelPrice `json:"prices,omitempty"`  // 自定义价格表，优先于内置价格
+	CNYRate float64      `json:"cnyRate,omitempty"` // 美元兑人民币汇率，为 0 时使用默认汇率
+}
+
+// Cost 一次响应的费用
+type Cost struct {
+	USD float64 `json:"usd"`
+	CNY float64 `json:"cny"`
+}
+
+// CostTotals 累计费用与用量
+type CostTotals struct {
+	Responses        int     `json:"responses"` // 计费的响应数
+	PromptTokens     int     `json:"promptTokens"`
+	CachedTokens     int     `json:"cachedTokens"`
+	CompletionTokens int     `json:"completionTokens"` // 含思考 token
+	USD              float64 `json:"usd"`
+	CNY              float64 `json:"cny"`
+	Unpriced         int     `json:"unpriced,omitempty"` // 没有匹配价格、未计入费用的响应数
+	UpdatedAt        int64   `json:"updatedAt"`
+}
+
+// CostSummary 按会话与 AI 配置汇总的累计费用
+type CostSummary struct {
+	Total    CostTotals            `json:"total"`
+	Sessions map[string]CostTotals `json:"sessions"` // key: 股票代码
+	Configs  map[string]CostTotals `json:"configs"`  // key: AI 配置 ID
+}
@@ -0,0 +1,24 @@
+package models
+
+// DataDirInfo 数据目录信息
+type DataDirInfo struct {
+	Current string `json:"current"` // 当前进程使用的数据目录
+	Default string `json:"default"` // 默认数据目录
+	Custom  string `json:"custom"`  // 已记录的自定义目录（迁移后重启前与 Current 不同）
+	Files   int    `json:"files"`   // 当前目录文件数
+	Bytes   int64  `json:"bytes"`   // 当前目录总大小
+	Restart bool   `json:"restart"` // 记录的目录与当前不一致，需要重启生效
+
+	// 自定义目录不可用（外接盘未连接等）而回退到默认目录时的提示
+	Warning string `json:"warning,omitempty"`
+}
+
+// DataDirMigration 数据目录迁移结果
+type DataDirMigration struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Files      int    `json:"files"`
+	Bytes      int64  `json:"bytes"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// DigestConfig 每周摘要配置
+type DigestConfig struct {
+	Enabled bool   `json:"enabled"`
+	Weekday int    `json:"weekday"` // 生成日 0-6（周日为 0），默认周五
+	Time    string `json:"time"`    // 生成时间 HH:MM（北京时间），空则为 20:00
+}
+
+// Digest 一期周报
+type Digest struct {
+	ID          string      `json:"id"`
+	PeriodStart int64       `json:"periodStart"`
+	PeriodEnd   int64       `json:"periodEnd"`
+	Title       string      `json:"title"`
+	Content     string      `json:"content"` // Markdown 正文
+	Stats       DigestStats `json:"stats"`
+	Delivered   []string    `json:"delivered,omitempty"` // 推送成功的渠道
+	Error       string      `json:"error,omitempty"`     // 生成或推送失败信息
+	CreatedAt   int64       `json:"createdAt"`
+}
+
+// DigestStats 周报统计数据
+type DigestStats struct {
+	ActiveSessions  int     `json:"activeSessions"`  // 本周有讨论的股票数
+	Recommendations int     `json:"recommendations"` // 本周的总结性结论数
+	Alerts          int     `json:"alerts"`          // 本周的事件触发次数
+	Signals         int     `json:"signals"`         // 本周出现的规则信号数
+	PortfolioValue  float64 `json:"portfolioValue"`  // 组合市值（本位币）
+	PortfolioPnL    float64 `json:"portfolioPnl"`    // 组合浮动盈亏
+	PnLChange       float64 `json:"pnlChange"`       // 较上期的盈亏变化
+	HasPreviousPnL  bool    `json:"hasPreviousPnl"`  // 是否有上期数据可比较
+	BaseCurrency    string  `json:"baseCurrency"`
+}
@@ -0,0 +1,30 @@
+package models
+
+// DocumentChunk 文档切片（检索与引用的最小单位）
+type DocumentChunk struct {
+	Seq  int    `json:"seq"`
+	Text string `json:"text"`
+}
+
+// StockDocument 个股文档索引中的一篇文档（公告、研报 PDF 等）
+type StockDocument struct {
+	ID          string          `json:"id"`
+	StockCode   string          `json:"stockCode"`
+	Title       string          `json:"title"`
+	URL         string          `json:"url"`
+	Source      string          `json:"source"`      // announcement / research_report
+	PublishDate string          `json:"publishDate"` // 发布日期
+	IngestedAt  int64           `json:"ingestedAt"`
+	Chunks      []DocumentChunk `json:"chunks"`
+}
+
+// DocumentHit 文档检索命中
+type DocumentHit struct {
+	DocID       string  `json:"docId"`
+	Title       string  `json:"title"`
+	URL         string  `json:"url"`
+	PublishDate string  `json:"publishDate"`
+	Seq         int     `json:"seq"`
+	Text        string  `json:"text"`
+	Score       float64 `json:"score"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// 连接检查的归一化错误类型
+const (
+	ConnErrConfig        = "config"          // 配置错误（供应商、BaseURL、凭证格式等）
+	ConnErrAuth          = "auth"            // 鉴权失败（401/403）
+	ConnErrModelNotFound = "model_not_found" // 模型不存在或无权限
+	ConnErrRateLimited   = "rate_limited"    // 限流或额度不足（429）
+	ConnErrBadRequest    = "bad_request"     // 请求参数被拒绝（400/422）
+	ConnErrServer        = "server"          // 服务端错误（5xx）
+	ConnErrTimeout       = "timeout"         // 超时
+	ConnErrNetwork       = "network"         // 网络不可达（DNS、连接、TLS、代理）
+	ConnErrUnknown       = "unknown"
+)
+
+// ConnectionCheckResult AI 配置连通性检查结果
+type ConnectionCheckResult struct {
+	OK             bool   `json:"ok"`
+	LatencyMs      int64  `json:"latencyMs"`                // 最小请求耗时
+	ModelAvailable *bool  `json:"modelAvailable,omitempty"` // 模型是否可用，无法判断时为空
+	StatusCode     int    `json:"statusCode,omitempty"`     // 上游 HTTP 状态码
+	ErrorCode      string `json:"errorCode,omitempty"`      // 归一化错误类型
+	Error          string `json:"error,omitempty"`          // 面向用户的错误说明
+	Detail         string `json:"detail,omitempty"`         // 原始错误信息
+}
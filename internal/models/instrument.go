@@ -0,0 +1,36 @@
+package models
+
+// 证券上市状态
+const (
+	InstrumentListed   = "L" // 上市
+	InstrumentDelisted = "D" // 退市
+	InstrumentPaused   = "P" // 暂停上市
+)
+
+// Instrument 证券基础信息（本地缓存的证券主表）
+type Instrument struct {
+	Code       string `json:"code"`                 // 带市场前缀的代码，如 sh600519
+	Symbol     string `json:"symbol"`               // 纯数字代码
+	Name       string `json:"name"`                 // 证券简称
+	Industry   string `json:"industry,omitempty"`   // 所属行业
+	Area       string `json:"area,omitempty"`       // 地域
+	Market     string `json:"market,omitempty"`     // 板块：主板 / 创业板 / 科创板 / 北交所
+	ListDate   string `json:"listDate,omitempty"`   // 上市日期 YYYYMMDD
+	DelistDate string `json:"delistDate,omitempty"` // 退市日期 YYYYMMDD
+	Status     string `json:"status"`               // 上市状态：L / D / P
+}
+
+// Delisted 是否已退市
+func (i Instrument) Delisted() bool {
+	return i.Status == InstrumentDelisted
+}
+
+// InstrumentRefreshReport 证券主表刷新结果
+type InstrumentRefreshReport struct {
+	Total    int      `json:"total"`              // 刷新后的证券总数
+	Added    int      `json:"added"`              // 新上市
+	Renamed  int      `json:"renamed"`            // 名称变更
+	Delisted []string `json:"delisted,omitempty"` // 本次检测到退市的代码
+	Archived []string `json:"archived,omitempty"` // 因退市归档的会话
+	Error    string   `json:"error,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "encoding/json"
+
+// 后台任务状态
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// 后台任务类型
+const (
+	JobKindDocumentIngest = "document.ingest" // 下载并入库公告/研报 PDF
+	JobKindBatchSubmit    = "batch.submit"    // 提交定时批量分析
+	JobKindDigest         = "digest.generate" // 生成并推送周报
+)
+
+// Job 持久化的后台任务，应用重启后未完成的任务继续执行
+type Job struct {
+	ID          string          `json:"id"`
+	Kind        string          `json:"kind"`
+	Title       string          `json:"title"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	DedupeKey   string          `json:"dedupeKey,omitempty"` // 相同键的任务未结束时不重复入队
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	LastError   string          `json:"lastError,omitempty"`
+	NextRunAt   int64           `json:"nextRunAt"` // 下次执行时间（失败重试退避）
+	CreatedAt   int64           `json:"createdAt"`
+	UpdatedAt   int64           `json:"updatedAt"`
+	FinishedAt  int64           `json:"finishedAt,omitempty"`
+}
+
+// JobStats 各状态的任务数量
+type JobStats struct {
+	Pending   int `json:"pending"`
+	Running   int `json:"running"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// MemoryTransferResult 记忆导出/导入结果
+type MemoryTransferResult struct {
+	Path    string   `json:"path"`
+	Stocks  []string `json:"stocks,omitempty"`  // 已导出或已导入的股票代码
+	Skipped []string `json:"skipped,omitempty"` // 导入时已存在且未选择覆盖的股票代码
+	Error   string   `json:"error,omitempty"`
+}
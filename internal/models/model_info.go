@@ -0,0 +1,25 @@
+package models
+
+// 模型能力标签
+const (
+	ModelCapVision    = "vision"    // 支持图片输入
+	ModelCapTools     = "tools"     // 支持函数调用
+	ModelCapThinking  = "thinking"  // 支持思考/推理
+	ModelCapEmbedding = "embedding" // 向量化模型
+)
+
+// ModelInfo 供应商模型列表中的单个模型
+type ModelInfo struct {
+	ID              string   `json:"id"`                        // 填入 ModelName 的标识
+	Name            string   `json:"name,omitempty"`            // 展示名称
+	Description     string   `json:"description,omitempty"`     // 简要说明（如参数规模）
+	ContextWindow   int      `json:"contextWindow,omitempty"`   // 上下文窗口（token），未知为 0
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"` // 最大输出 token，未知为 0
+	Capabilities    []string `json:"capabilities,omitempty"`    // 能力标签
+}
+
+// ModelCatalogResult 模型列表查询结果
+type ModelCatalogResult struct {
+	Models []ModelInfo `json:"models"`
+	Error  string      `json:"error,omitempty"`
+}
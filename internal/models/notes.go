@@ -0,0 +1,17 @@
+package models
+
+// NoteSection 研究笔记章节
+type NoteSection struct {
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	UpdatedBy string `json:"updatedBy"` // user 或 专家名称
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+// ResearchNotes 个股研究笔记（独立于聊天记录，不受记忆压缩影响）
+type ResearchNotes struct {
+	StockCode string        `json:"stockCode"`
+	StockName string        `json:"stockName"`
+	Sections  []NoteSection `json:"sections"`
+	UpdatedAt int64         `json:"updatedAt"`
+}
@@ -0,0 +1,34 @@
+package models
+
+// 通知 Webhook 类型
+const (
+	WebhookGeneric  = "generic"  // 通用 JSON：{"title","content"}
+	WebhookWeCom    = "wecom"    // 企业微信群机器人
+	WebhookDingTalk = "dingtalk" // 钉钉群机器人
+	WebhookFeishu   = "feishu"   // 飞书群机器人
+)
+
+// NotifyConfig 通知渠道配置（周报等推送）
+type NotifyConfig struct {
+	Webhooks []WebhookSink `json:"webhooks"`
+	Email    EmailSink     `json:"email"`
+}
+
+// WebhookSink Webhook 通知渠道
+type WebhookSink struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"` // generic/wecom/dingtalk/feishu，空则为 generic
+	URL     string `json:"url"`
+	Enabled bool   `json:"enabled"`
+}
+
+// EmailSink 邮件通知渠道（SMTP）
+type EmailSink struct {
+	Enabled  bool     `json:"enabled"`
+	Host     string   `json:"host"`
+	Port     int      `json:"port"` // 465 使用 TLS 直连，其他端口支持 STARTTLS
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"` // 为空时使用 Username
+	To       []string `json:"to"`
+}
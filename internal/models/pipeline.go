@@ -0,0 +1,28 @@
+package models
+
+// Pipeline 声明式分析流水线（从 dataDir/pipelines 下的 YAML/JSON 文件加载）
+// 各步骤按顺序执行，后续步骤可通过模板引用前序步骤的输出
+type Pipeline struct {
+	ID          string         `json:"id" yaml:"id"`
+	Name        string         `json:"name" yaml:"name"`
+	Description string         `json:"description" yaml:"description"`
+	Steps       []PipelineStep `json:"steps" yaml:"steps"`
+
+	Source string `json:"source" yaml:"-"` // 来源文件名
+}
+
+// PipelineStep 流水线步骤
+type PipelineStep struct {
+	ID          string   `json:"id" yaml:"id"`
+	Name        string   `json:"name" yaml:"name"`
+	Role        string   `json:"role" yaml:"role"`
+	Instruction string   `json:"instruction" yaml:"instruction"` // 系统指令
+	Prompt      string   `json:"prompt" yaml:"prompt"`           // 用户提示模板（text/template），空则使用原始问题
+	AIConfigID  string   `json:"aiConfigId" yaml:"aiConfigId"`   // 可选，空则用默认AI
+	Tools       []string `json:"tools" yaml:"tools"`
+	MCPServers  []string `json:"mcpServers" yaml:"mcpServers"`
+	// OutputSchema 期望输出的 JSON Schema（可选），设置后要求模型输出 JSON 并校验
+	OutputSchema map[string]any `json:"outputSchema,omitempty" yaml:"outputSchema,omitempty"`
+	// ContinueOnError 步骤失败时是否继续执行后续步骤
+	ContinueOnError bool `json:"continueOnError" yaml:"continueOnError"`
+}
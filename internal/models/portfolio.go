@@ -0,0 +1,61 @@
+package models
+
+import "strings"
+
+// 支持的币种
+const (
+	CurrencyCNY = "CNY"
+	CurrencyHKD = "HKD"
+	CurrencyUSD = "USD"
+)
+
+// CurrencyForCode 按股票代码推断交易币种：hk 开头为港股，gb_/us 开头为美股，其余为 A 股
+func CurrencyForCode(code string) string {
+	c := strings.ToLower(code)
+	switch {
+	case strings.HasPrefix(c, "hk"):
+		return CurrencyHKD
+	case strings.HasPrefix(c, "gb_"), strings.HasPrefix(c, "us"):
+		return CurrencyUSD
+	}
+	return CurrencyCNY
+}
+
+// PortfolioPosition 组合中的一笔持仓
+type PortfolioPosition struct {
+	StockCode   string  `json:"stockCode"`
+	StockName   string  `json:"stockName"`
+	Currency    string  `json:"currency"`  // 交易币种
+	Shares      int64   `json:"shares"`    // 持仓数量
+	CostPrice   float64 `json:"costPrice"` // 成本价（交易币种）
+	Price       float64 `json:"price"`     // 现价（交易币种），无行情时为 0
+	Cost        float64 `json:"cost"`      // 持仓成本（交易币种）
+	MarketValue float64 `json:"marketValue"`
+	PnL         float64 `json:"pnl"`        // 浮动盈亏（交易币种）
+	PnLPercent  float64 `json:"pnlPercent"` // 盈亏比例 %
+	FXRate      float64 `json:"fxRate"`     // 1 单位交易币种折合的本位币
+
+	// 折算为本位币的金额
+	BaseCost        float64 `json:"baseCost"`
+	BaseMarketValue float64 `json:"baseMarketValue"`
+	BasePnL         float64 `json:"basePnl"`
+	Stale           bool    `json:"stale,omitempty"` // 没有获取到现价，市值按成本计
+}
+
+// Portfolio 按本位币汇总的持仓组合
+type Portfolio struct {
+	BaseCurrency     string              `json:"baseCurrency"`
+	Positions        []PortfolioPosition `json:"positions"`
+	TotalCost        float64             `json:"totalCost"`
+	TotalMarketValue float64             `json:"totalMarketValue"`
+	TotalPnL         float64             `json:"totalPnl"`
+	TotalPnLPercent  float64             `json:"totalPnlPercent"`
+	Rates            map[string]float64  `json:"rates"` // 各币种兑本位币汇率
+	RatesUpdatedAt   int64               `json:"ratesUpdatedAt"`
+}
+
+// FXRates 汇率快照：每单位外币折合的人民币
+type FXRates struct {
+	Rates     map[string]float64 `json:"rates"`
+	UpdatedAt int64              `json:"updatedAt"`
+}
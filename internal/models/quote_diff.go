@@ -0,0 +1,16 @@
+package models
+
+// QuoteDiff 自选股行情的字段级增量（market:stock:diff 事件）
+// Seq 连续递增，前端发现跳号时发送 market:stock:resync 请求全量；Full 为 true 时 Changes 包含全部股票的全部字段，前端应替换本地状态
+type QuoteDiff struct {
+	Seq     uint64       `json:"seq"`
+	Full    bool         `json:"full"`
+	Changes []QuotePatch `json:"changes"`
+	Removed []string     `json:"removed,omitempty"` // 已取消订阅的股票代码
+}
+
+// QuotePatch 单只股票变化的字段（键为 Stock 的 JSON 字段名）
+type QuotePatch struct {
+	Symbol string         `json:"symbol"`
+	Fields map[string]any `json:"fields"`
+}
@@ -0,0 +1,47 @@
+package models
+
+// RubricCriterion 评分维度
+type RubricCriterion struct {
+	Key         string  `json:"key"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Weight      float64 `json:"weight"`
+}
+
+// CriterionScore 单维度评分
+type CriterionScore struct {
+	Key    string  `json:"key"`
+	Score  float64 `json:"score"` // 0-10
+	Reason string  `json:"reason"`
+}
+
+// RankingRow 排名表中的单行
+type RankingRow struct {
+	Rank       int              `json:"rank"`
+	StockCode  string           `json:"stockCode"`
+	StockName  string           `json:"stockName"`
+	Price      float64          `json:"price"`
+	Change     float64          `json:"changePercent"`
+	Scores     []CriterionScore `json:"scores"`
+	TotalScore float64          `json:"totalScore"` // 加权总分（0-100）
+	Summary    string           `json:"summary"`
+	SessionID  string           `json:"sessionId"` // 关联会话，用于下钻
+	Error      string           `json:"error,omitempty"`
+}
+
+// RankingReport 自选股批量评分报告
+type RankingReport struct {
+	ID        string            `json:"id"`
+	Rubric    []RubricCriterion `json:"rubric"`
+	Rows      []RankingRow      `json:"rows"`
+	AIConfig  string            `json:"aiConfig"` // 使用的模型名称
+	CreatedAt int64             `json:"createdAt"`
+}
+
+// RankingReportSummary 报告列表项
+type RankingReportSummary struct {
+	ID         string `json:"id"`
+	StockCount int    `json:"stockCount"`
+	TopStock   string `json:"topStock"`
+	CreatedAt  int64  `json:"createdAt"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import "fmt"
+
+// 投资期限
+const (
+	HorizonShort  = "short"  // 短线（数日至数周）
+	HorizonMedium = "medium" // 中线（数月）
+	HorizonLong   = "long"   // 长线（一年以上）
+)
+
+// 投资经验
+const (
+	ExperienceNovice       = "novice"       // 新手（1 年以内）
+	ExperienceIntermediate = "intermediate" // 有一定经验（1-5 年）
+	ExperienceExperienced  = "experienced"  // 经验丰富（5 年以上）
+)
+
+// RiskProfile 用户风险画像，填写后注入专家分析提示词，使建议与用户的承受能力匹配
+type RiskProfile struct {
+	Horizon     string `json:"horizon"`     // 投资期限: short/medium/long
+	MaxDrawdown int    `json:"maxDrawdown"` // 可承受的最大回撤（%），0 表示未填写
+	Experience  string `json:"experience"`  // 投资经验: novice/intermediate/experienced
+	Notes       string `json:"notes"`       // 其他补充（如仓位偏好、不碰的行业）
+}
+
+// IsEmpty 是否未填写任何项
+func (p RiskProfile) IsEmpty() bool {
+	return p.Horizon == "" && p.MaxDrawdown <= 0 && p.Experience == "" && p.Notes == ""
+}
+
+// RiskOption 问卷选项
+type RiskOption struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// RiskQuestion 风险画像问卷题目
+type RiskQuestion struct {
+	Key     string       `json:"key"` // 对应 RiskProfile 字段的 JSON 名
+	Title   string       `json:"title"`
+	Options []RiskOption `json:"options"`
+}
+
+// RiskQuestionnaire 风险画像问卷
+var RiskQuestionnaire = []RiskQuestion{
+	{Key: "horizon", Title: "你通常的持股周期？", Options: []RiskOption{
+		{Value: HorizonShort, Label: "短线（数日至数周）"},
+		{Value: HorizonMedium, Label: "中线（数月）"},
+		{Value: HorizonLong, Label: "长线（一年以上）"},
+	}},
+	{Key: "maxDrawdown", Title: "账户最多能承受多大回撤而不影响判断？", Options: []RiskOption{
+		{Value: "10", Label: "10% 以内"},
+		{Value: "20", Label: "20% 左右"},
+		{Value: "30", Label: "30% 左右"},
+		{Value: "50", Label: "50% 以上也能接受"},
+	}},
+	{Key: "experience", Title: "你的股票投资经验？", Options: []RiskOption{
+		{Value: ExperienceNovice, Label: "新手（1 年以内）"},
+		{Value: ExperienceIntermediate, Label: "有一定经验（1-5 年）"},
+		{Value: ExperienceExperienced, Label: "经验丰富（5 年以上）"},
+	}},
+}
+
+// riskOptionLabel 返回问卷选项的文字，未知值原样返回
+func riskOptionLabel(key, value string) string {
+	for _, q := range RiskQuestionnaire {
+		if q.Key != key {
+			continue
+		}
+		for _, o := range q.Options {
+			if o.Value == value {
+				return o.Label
+			}
+		}
+	}
+	return value
+}
+
+// HorizonLabel 投资期限的文字描述
+func (p RiskProfile) HorizonLabel() string {
+	return riskOptionLabel("horizon", p.Horizon)
+}
+
+// ExperienceLabel 投资经验的文字描述
+func (p RiskProfile) ExperienceLabel() string {
+	return riskOptionLabel("experience", p.Experience)
+}
+
+// Validate 校验选项取值
+func (p RiskProfile) Validate() error {
+	switch p.Horizon {
+	case "", HorizonShort, HorizonMedium, HorizonLong:
+	default:
+		return fmt.Errorf("无效的投资期限: %s", p.Horizon)
+	}
+	switch p.Experience {
+	case "", ExperienceNovice, ExperienceIntermediate, ExperienceExperienced:
+	default:
+		return fmt.Errorf("无效的投资经验: %s", p.Experience)
+	}
+	if p.MaxDrawdown < 0 || p.MaxDrawdown > 100 {
+		return fmt.Errorf("最大回撤需在 0-100 之间")
+	}
+	return nil
+}
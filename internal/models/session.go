@@ -2,8 +2,9 @@ package models
 
 // StockPosition 股票持仓信息
 type StockPosition struct {
-	Shares    int64   `json:"shares"`    // 持仓数量
-	CostPrice float64 `json:"costPrice"` // 成本价
+	Shares    int64   `json:"shares"`             // 持仓数量
+	CostPrice float64 `json:"costPrice"`          // 成本价
+	Currency  string  `json:"currency,omitempty"` // 交易币种，为空时按股票代码推断
 }
 
 // StockSession 股票会话（每个自选股独立）
@@ -13,6 +14,9 @@ type StockSession struct {
 	StockName string         `json:"stockName"` // 股票名称
 	Messages  []ChatMessage  `json:"messages"`  // 讨论历史
 	Position  *StockPosition `json:"position"`  // 持仓信息
+	PromptVersion string     `json:"promptVersion,omitempty"` // 固定使用的提示词版本，为空时跟随默认版本
+	AIConfigID    string     `json:"aiConfigId,omitempty"`    // 固定使用的 AI 配置，为空时跟随默认配置
+	Conversations map[string]string `json:"conversations,omitempty"` // 服务端 conversation 映射，key: 专家ID@AI配置ID
 	CreatedAt int64          `json:"createdAt"`
 	UpdatedAt int64          `json:"updatedAt"`
 }
@@ -31,4 +35,44 @@ type ChatMessage struct {
 	MsgType   string   `json:"msgType,omitempty"`   // 消息类型: opening/opinion/summary
 	Error       string   `json:"error,omitempty"`       // 失败时的错误信息
 	MeetingMode string   `json:"meetingMode,omitempty"` // smart=串行, direct=独立
+	Thinking    string   `json:"thinking,omitempty"`    // 模型思考过程（可配置为不持久化）
+	Sources     []ToolSource `json:"sources,omitempty"` // 引用的工具数据来源
+	Metadata    *ResponseMeta `json:"metadata,omitempty"` // 供应商响应元数据
+	Partial     bool          `json:"partial,omitempty"`  // 流式中断，Content 为已生成的部分内容，可续写
+	TraceID     string        `json:"traceId,omitempty"`  // 对应的执行轨迹 ID
+	PromptVersion string      `json:"promptVersion,omitempty"` // 生成该消息的提示词版本
+	IdempotencyKey string     `json:"idempotencyKey,omitempty"` // 前端提交时生成的幂等键，用于识别重复提交
+	Cost        *Cost         `json:"cost,omitempty"`     // 生成该消息的费用
+	Attribution *MessageAttribution `json:"attribution,omitempty"` // 生成该消息的供应商、模型与 AI 配置
+}
+
+// MessageAttribution 专家消息的模型归属（导出、重放对比与建议追踪时区分不同模型的结论）
+type MessageAttribution struct {
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`                  // 请求的模型名
+	ModelVersion string `json:"modelVersion,omitempty"` // 供应商实际响应的模型版本
+	AIConfigID   string `json:"aiConfigId,omitempty"`
+	Fallback     bool   `json:"fallback,omitempty"` // 专家自定义的 AI 配置不可用，改用了默认配置
+}
+
+// ChatImage 用户消息附带的图片（如粘贴的 K 线截图）
+type ChatImage struct {
+	MimeType string `json:"mimeType"` // 如 image/png，为空时按内容识别
+	Data     string `json:"data"`     // base64 编码的图片数据（可带 data URL 前缀）
+}
+
+// ToolSource 工具数据来源（用于回溯分析中引用的数据）
+type ToolSource struct {
+	Tool      string         `json:"tool"`              // 工具名称
+	Args      map[string]any `json:"args,omitempty"`    // 调用参数
+	Timestamp int64          `json:"timestamp"`         // 数据返回时间（毫秒）
+	Summary   string         `json:"summary,omitempty"` // 返回结果摘要
+}
+
+// ResponseMeta 供应商响应元数据（向供应商提交工单时用于定位请求）
+type ResponseMeta struct {
+	ResponseID   string `json:"responseId,omitempty"`   // 供应商返回的响应ID
+	RequestID    string `json:"requestId,omitempty"`    // 响应头中的请求ID
+	ModelVersion string `json:"modelVersion,omitempty"` // 实际响应的模型版本
+	StopReason   string `json:"stopReason,omitempty"`   // 供应商原始的结束原因（如 max_tokens、refusal、tool_calls）
 }
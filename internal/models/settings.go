@@ -0,0 +1,14 @@
+package models
+
+// UISettings 界面设置，修改后即时生效并通知前端
+type UISettings struct {
+	Theme           string `json:"theme"`           // 主题色: military, ocean, purple, orange, dark
+	CandleColorMode string `json:"candleColorMode"` // 涨跌颜色模式: red-up / green-up
+	RefreshInterval int    `json:"refreshInterval"` // 交易时段行情刷新间隔（秒），1~60
+}
+
+// UISettingsProfiles 按配置档保存的界面设置（如"盯盘"、"复盘"各用一套）
+type UISettingsProfiles struct {
+	Active   string                `json:"active"`
+	Profiles map[string]UISettings `json:"profiles"`
+}
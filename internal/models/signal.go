@@ -0,0 +1,21 @@
+package models
+
+// SignalType 规则信号类型
+type SignalType string
+
+const (
+	SignalGoldenCross SignalType = "golden_cross" // 均线金叉（MA5 上穿 MA20）
+	SignalHigh52W     SignalType = "high_52w"     // 创 52 周新高
+	SignalVolumeSpike SignalType = "volume_spike" // 放量（成交量显著高于近期均量）
+)
+
+// Signal 基于 K 线规则计算出的确定性信号，不依赖大模型
+type Signal struct {
+	StockCode  string     `json:"stockCode"`
+	StockName  string     `json:"stockName,omitempty"`
+	Type       SignalType `json:"type"`
+	Title      string     `json:"title"`
+	Detail     string     `json:"detail"`
+	Date       string     `json:"date"` // 信号所在 K 线日期
+	DetectedAt int64      `json:"detectedAt"`
+}
@@ -95,3 +95,13 @@ type LongHuBangDetail struct {
 	NetAmt      float64 `json:"netAmt"`      // 净买入(元)
 	Direction   string  `json:"direction"`   // 方向: buy/sell
 }
+
+// WarmCacheReport 行情预热结果
+type WarmCacheReport struct {
+	Stocks     int      `json:"stocks"`           // 预热的股票数
+	Quotes     int      `json:"quotes"`           // 成功获取行情的股票数
+	KLines     int      `json:"klines"`           // 成功写入的K线快照数（股票 × 周期）
+	Errors     []string `json:"errors,omitempty"` // 失败项
+	StartedAt  int64    `json:"startedAt"`
+	FinishedAt int64    `json:"finishedAt"`
+}
@@ -12,6 +12,8 @@ type StrategyAgent struct {
 	MCPServers  []string `json:"mcpServers"`
 	Enabled     bool     `json:"enabled"`
 	AIConfigID  string   `json:"aiConfigId"` // 可选，空则用默认AI
+	// 可选，JSON Schema 文本；设置后要求专家按该结构输出 JSON
+	ResponseSchema string `json:"responseSchema,omitempty"`
 }
 
 // Strategy 策略配置
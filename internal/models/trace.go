@@ -0,0 +1,98 @@
+package models
+
+import "encoding/json"
+
+// TurnTrace 单次专家发言的结构化执行轨迹（用于调试与统计分析）
+type TurnTrace struct {
+	ID               string      `json:"id"`
+	StockCode        string      `json:"stockCode"`
+	AgentID          string      `json:"agentId"`
+	AgentName        string      `json:"agentName"`
+	AIConfigID       string      `json:"aiConfigId,omitempty"`
+	Provider         string      `json:"provider"`
+	Model            string      `json:"model"`
+	PromptVersion    string      `json:"promptVersion"`       // 内置提示词版本
+	InstructionHash  string      `json:"instructionHash"`     // 专家指令摘要，指令变更后随之变化
+	StartedAt        int64       `json:"startedAt"`           // 毫秒时间戳
+	DurationMs       int64       `json:"durationMs"`          // 含重试在内的总耗时
+	LLMCalls         int         `json:"llmCalls"`            // 模型调用次数（多轮工具调用时大于 1）
+	Retries          int         `json:"retries"`             // 整体重试次数
+	Fallbacks        []string    `json:"fallbacks,omitempty"` // 触发的降级路径
+	Tools            []ToolTrace `json:"tools,omitempty"`     // 工具调用明细
+	PromptTokens     int         `json:"promptTokens"`
+	CompletionTokens int         `json:"completionTokens"`
+	ThoughtTokens    int         `json:"thoughtTokens,omitempty"`
+	CachedTokens     int         `json:"cachedTokens,omitempty"`
+	TotalTokens      int         `json:"totalTokens"`
+	Cost             *Cost       `json:"cost,omitempty"` // 按价格表计算的费用，无匹配价格时为空
+	Error            string      `json:"error,omitempty"`
+
+	// 最后一次发往模型的请求快照，单独持久化（见 ReplayResult）
+	Request json.RawMessage `json:"-"`
+}
+
+// ReplayResult 历史消息重放结果：用当时的请求快照重新调用模型并与原回答对比
+type ReplayResult struct {
+	MessageID     string     `json:"messageId"`
+	TraceID       string     `json:"traceId"`
+	PromptVersion string     `json:"promptVersion"` // 原消息使用的提示词版本（快照中已是渲染后的提示词）
+	OriginalModel string     `json:"originalModel"`
+	Model         string     `json:"model"` // 重放使用的模型
+	Original      string     `json:"original"`
+	Replayed      string     `json:"replayed"`
+	Diff          []DiffLine `json:"diff"`
+	DurationMs    int64      `json:"durationMs"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// DryRunResult 专家发言试运行结果：组装完整请求并估算 token 与费用，不调用模型
+type DryRunResult struct {
+	AgentID       string          `json:"agentId"`
+	AgentName     string          `json:"agentName"`
+	Model         string          `json:"model"`
+	PromptVersion string          `json:"promptVersion"`
+	Request       json.RawMessage `json:"request,omitempty"` // 将要发送的请求（系统指令、历史、工具与生成参数）
+	InputTokens   int             `json:"inputTokens"`
+	OutputTokens  int             `json:"outputTokens"` // 输出上限，费用按上限估算
+	ContextWindow int             `json:"contextWindow"`
+	ToolCount     int             `json:"toolCount"`
+	Cost          *Cost           `json:"cost,omitempty"` // 无匹配价格时为空
+	Error         string          `json:"error,omitempty"`
+}
+
+// DiffLine 逐行差异：op 为 " "（相同）、"-"（仅原回答）、"+"（仅重放结果）
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// 工具来源
+const (
+	ToolSourceBuiltin = "builtin" // 内置工具
+	ToolSourceMCP     = "mcp"     // MCP 服务器提供的工具
+)
+
+// ToolTrace 单次工具调用记录
+type ToolTrace struct {
+	Name         string `json:"name"`
+	Source       string `json:"source,omitempty"` // 工具来源，见 ToolSource*
+	CallID       string `json:"callId,omitempty"`
+	DurationMs   int64  `json:"durationMs"`
+	Error        bool   `json:"error,omitempty"`        // 工具返回了错误
+	ResultTokens int    `json:"resultTokens,omitempty"` // 工具结果回填给模型的估算 token 数
+}
+
+// ToolStats 单个工具的调用统计（由执行轨迹汇总）
+type ToolStats struct {
+	Name            string  `json:"name"`
+	Source          string  `json:"source,omitempty"`
+	Calls           int     `json:"calls"`
+	Errors          int     `json:"errors"`
+	ErrorRate       float64 `json:"errorRate"` // 0-1
+	AvgLatencyMs    int64   `json:"avgLatencyMs"`
+	P95LatencyMs    int64   `json:"p95LatencyMs"`
+	MaxLatencyMs    int64   `json:"maxLatencyMs"`
+	ResultTokens    int     `json:"resultTokens"`    // 工具结果累计占用的估算 token
+	AvgResultTokens int     `json:"avgResultTokens"` // 单次调用平均占用的估算 token
+	LastUsedAt      int64   `json:"lastUsedAt"`      // 毫秒时间戳
+}
@@ -0,0 +1,50 @@
+package models
+
+// TriggerType 触发器类型
+type TriggerType string
+
+const (
+	TriggerAnnouncement TriggerType = "announcement" // 新公告
+	TriggerEarnings     TriggerType = "earnings"     // 业绩发布
+	TriggerPriceGap     TriggerType = "price_gap"    // 跳空缺口
+)
+
+// AnalysisTrigger 事件驱动的自动分析触发器
+type AnalysisTrigger struct {
+	ID            string      `json:"id"`
+	Name          string      `json:"name"`
+	Type          TriggerType `json:"type"`
+	StockCodes    []string    `json:"stockCodes"`    // 监控股票（空则监控全部自选股）
+	Threshold     float64     `json:"threshold"`     // 跳空阈值（百分比，默认 5）
+	QueryTemplate string      `json:"queryTemplate"` // 分析模板，支持 {{name}} {{code}} {{reason}}
+	Enabled       bool        `json:"enabled"`
+}
+
+// TriggerFiring 触发记录（用于去重和每日上限）
+type TriggerFiring struct {
+	TriggerID string `json:"triggerId"`
+	StockCode string `json:"stockCode"`
+	DedupeKey string `json:"dedupeKey"`
+	Date      string `json:"date"` // 2006-01-02
+	FiredAt   int64  `json:"firedAt"`
+	StockName string `json:"stockName,omitempty"`
+	Reason    string `json:"reason,omitempty"` // 触发原因，用于周报等回顾
+}
+
+// TriggerStore 触发器持久化结构
+type TriggerStore struct {
+	Triggers []AnalysisTrigger `json:"triggers"`
+	DailyCap int               `json:"dailyCap"` // 每日自动分析上限
+	Firings  []TriggerFiring   `json:"firings"`
+}
+
+// TriggeredAnalysis 已触发、待执行的分析任务
+type TriggeredAnalysis struct {
+	TriggerID   string `json:"triggerId"`
+	TriggerName string `json:"triggerName"`
+	StockCode   string `json:"stockCode"`
+	StockName   string `json:"stockName"`
+	Reason      string `json:"reason"`
+	Query       string `json:"query"`
+	FiredAt     int64  `json:"firedAt"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// UserMemoryCategory 全局记忆分类
+type UserMemoryCategory string
+
+const (
+	UserMemoryStyle      UserMemoryCategory = "style"      // 投资风格
+	UserMemoryRisk       UserMemoryCategory = "risk"       // 风险承受能力
+	UserMemoryPosition   UserMemoryCategory = "position"   // 仓位习惯
+	UserMemoryPreference UserMemoryCategory = "preference" // 其他长期偏好
+)
+
+// UserMemoryCategories 全部全局记忆分类（按注入提示词的顺序）
+var UserMemoryCategories = []UserMemoryCategory{UserMemoryStyle, UserMemoryRisk, UserMemoryPosition, UserMemoryPreference}
+
+// Label 分类的中文名称
+func (c UserMemoryCategory) Label() string {
+	switch c {
+	case UserMemoryStyle:
+		return "投资风格"
+	case UserMemoryRisk:
+		return "风险承受"
+	case UserMemoryPosition:
+		return "仓位习惯"
+	case UserMemoryPreference:
+		return "偏好"
+	}
+	return string(c)
+}
+
+// UserMemoryEntry 用户级全局记忆条目，所有股票会话共享
+type UserMemoryEntry struct {
+	ID        string             `json:"id"`
+	Category  UserMemoryCategory `json:"category"`
+	Content   string             `json:"content"`
+	Source    string             `json:"source"` // user 或 专家名称
+	CreatedAt int64              `json:"createdAt"`
+	UpdatedAt int64              `json:"updatedAt"`
+}
@@ -0,0 +1,73 @@
+package openclaw
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// maxRecentAudit 内存中保留的最近审计记录数
+const maxRecentAudit = 200
+
+// auditLog 外部调用审计日志：追加写入 JSONL 文件，并在内存中保留最近记录
+type auditLog struct {
+	mu     sync.Mutex
+	path   string
+	recent []models.OpenClawAuditEntry
+}
+
+func (a *auditLog) record(e models.OpenClawAuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.recent = append(a.recent, e)
+	if len(a.recent) > maxRecentAudit {
+		a.recent = a.recent[len(a.recent)-maxRecentAudit:]
+	}
+	log.Info("外部调用: %s %s token=%s scope=%s status=%d", e.Method, e.Path, e.Token, e.Scope, e.Status)
+	if a.path == "" {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Warn("写入审计日志失败: %v", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+// list 返回最近的审计记录（新的在前），limit <= 0 时返回全部
+func (a *auditLog) list(limit int) []models.OpenClawAuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	n := len(a.recent)
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	out := make([]models.OpenClawAuditEntry, 0, limit)
+	for i := n - 1; i >= n-limit; i-- {
+		out = append(out, a.recent[i])
+	}
+	return out
+}
+
+// EnableAuditLog 将审计日志持久化到 dataDir/openclaw_audit.jsonl
+func (s *Server) EnableAuditLog(dataDir string) {
+	s.audit.mu.Lock()
+	defer s.audit.mu.Unlock()
+	s.audit.path = filepath.Join(dataDir, "openclaw_audit.jsonl")
+}
+
+// GetAuditLog 获取最近的外部调用审计记录
+func (s *Server) GetAuditLog(limit int) []models.OpenClawAuditEntry {
+	return s.audit.list(limit)
+}
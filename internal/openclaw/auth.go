@@ -0,0 +1,103 @@
+package openclaw
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// scopeLevels 权限范围级别，高级别包含低级别的全部权限
+var scopeLevels = map[string]int{
+	models.OpenClawScopeQuotes:  1,
+	models.OpenClawScopeSession: 2,
+	models.OpenClawScopeAdmin:   3,
+}
+
+// scopeAllows 判断已授予的权限范围是否满足要求，未知范围按最低权限处理
+func scopeAllows(granted, required string) bool {
+	level := scopeLevels[granted]
+	if level == 0 {
+		level = scopeLevels[models.OpenClawScopeQuotes]
+	}
+	return level >= scopeLevels[required]
+}
+
+// SetTokens 设置访问令牌（热更新，无需重启服务）
+func (s *Server) SetTokens(tokens []models.OpenClawToken) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = append([]models.OpenClawToken(nil), tokens...)
+}
+
+// authenticate 校验请求令牌，返回令牌名称与权限范围
+// 未配置任何密钥与令牌时不鉴权，按管理员权限处理
+func (s *Server) authenticate(r *http.Request) (name, scope string, ok bool) {
+	s.mu.RLock()
+	apiKey, tokens := s.apiKey, s.tokens
+	s.mu.RUnlock()
+
+	if apiKey == "" && len(tokens) == 0 {
+		return "", models.OpenClawScopeAdmin, true
+	}
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return "", "", false
+	}
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if apiKey != "" && tokenEqual(token, apiKey) {
+		return "apiKey", models.OpenClawScopeAdmin, true
+	}
+	for _, t := range tokens {
+		if t.Token != "" && tokenEqual(token, t.Token) {
+			return t.Name, t.Scope, true
+		}
+	}
+	return "", "", false
+}
+
+// tokenEqual 常量时间比较，避免计时攻击
+func tokenEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// withScope 鉴权中间件：校验令牌与权限范围，并记录审计日志
+func (s *Server) withScope(required string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		name, scope, ok := s.authenticate(r)
+		switch {
+		case !ok:
+			writeJSON(rec, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+		case !scopeAllows(scope, required):
+			writeJSON(rec, http.StatusForbidden, map[string]any{"error": "insufficient scope", "required": required})
+		default:
+			next(rec, r)
+		}
+		s.audit.record(models.OpenClawAuditEntry{
+			Time:       start.UnixMilli(),
+			Token:      name,
+			Scope:      scope,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Query:      r.URL.RawQuery,
+			RemoteAddr: r.RemoteAddr,
+			Status:     rec.status,
+			DurationMs: time.Since(start).Milliseconds(),
+		})
+	}
+}
+
+// statusRecorder 记录响应状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
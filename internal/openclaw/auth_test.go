@@ -0,0 +1,55 @@
+package openclaw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestWithScope(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil)
+	s.apiKey = "admin-key"
+	s.SetTokens([]models.OpenClawToken{
+		{Name: "bot", Token: "quote-token", Scope: models.OpenClawScopeQuotes},
+		{Name: "agent", Token: "session-token", Scope: models.OpenClawScopeSession},
+	})
+	ok := func(w http.ResponseWriter, r *http.Request) { writeJSON(w, http.StatusOK, nil) }
+
+	cases := []struct {
+		token    string
+		required string
+		want     int
+	}{
+		{"", models.OpenClawScopeQuotes, http.StatusUnauthorized},
+		{"wrong", models.OpenClawScopeQuotes, http.StatusUnauthorized},
+		{"quote-token", models.OpenClawScopeQuotes, http.StatusOK},
+		{"quote-token", models.OpenClawScopeSession, http.StatusForbidden},
+		{"session-token", models.OpenClawScopeSession, http.StatusOK},
+		{"session-token", models.OpenClawScopeAdmin, http.StatusForbidden},
+		{"admin-key", models.OpenClawScopeAdmin, http.StatusOK},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/x", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		rec := httptest.NewRecorder()
+		s.withScope(c.required, ok)(rec, req)
+		if rec.Code != c.want {
+			t.Errorf("token=%q scope=%s: status = %d, want %d", c.token, c.required, rec.Code, c.want)
+		}
+	}
+
+	entries := s.GetAuditLog(0)
+	if len(entries) != len(cases) {
+		t.Fatalf("audit entries = %d, want %d", len(entries), len(cases))
+	}
+	if e := entries[0]; e.Token != "apiKey" || e.Status != http.StatusOK {
+		t.Errorf("latest audit entry = %+v", e)
+	}
+	if e := entries[3]; e.Token != "bot" || e.Status != http.StatusForbidden {
+		t.Errorf("forbidden audit entry = %+v", e)
+	}
+}
@@ -3,23 +3,10 @@ package openclaw
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
-// withAuth 鉴权中间件
-func (s *Server) withAuth(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if s.apiKey != "" {
-			auth := r.Header.Get("Authorization")
-			if !strings.HasPrefix(auth, "Bearer ") || strings.TrimPrefix(auth, "Bearer ") != s.apiKey {
-				writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
-				return
-			}
-		}
-		next(w, r)
-	}
-}
-
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
 }
@@ -33,6 +20,28 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleQuote 查询实时行情（只读）
+func (s *Server) handleQuote(w http.ResponseWriter, r *http.Request) {
+	code := strings.TrimSpace(r.URL.Query().Get("code"))
+	if code == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "code required"})
+		return
+	}
+	stock, err := s.stockResolver(code)
+	if err != nil || stock == nil {
+		log.Error("获取股票数据失败: %s, %v", code, err)
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": "failed to get stock data"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "stock": stock})
+}
+
+// handleAudit 查询最近的外部调用审计记录
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	writeJSON(w, http.StatusOK, map[string]any{"success": true, "entries": s.GetAuditLog(limit)})
+}
+
 func writeJSON(w http.ResponseWriter, code int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
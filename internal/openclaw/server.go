@@ -24,6 +24,8 @@ type Server struct {
 	server         *http.Server
 	port           int
 	apiKey         string
+	tokens         []models.OpenClawToken
+	audit          auditLog
 	meetingService *meeting.Service
 	agentContainer *agent.Container
 	aiResolver     func(string) *models.AIConfig
@@ -58,7 +60,9 @@ func (s *Server) Start(port int, apiKey string) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.HandleFunc("/status", s.handleStatus)
-	mux.HandleFunc("/analyze", s.withAuth(s.handleAnalyze))
+	mux.HandleFunc("/quote", s.withScope(models.OpenClawScopeQuotes, s.handleQuote))
+	mux.HandleFunc("/analyze", s.withScope(models.OpenClawScopeSession, s.handleAnalyze))
+	mux.HandleFunc("/audit", s.withScope(models.OpenClawScopeAdmin, s.handleAudit))
 
 	s.port = port
 	s.apiKey = apiKey
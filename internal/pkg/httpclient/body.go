@@ -0,0 +1,120 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// 响应体大小上限
+const (
+	DefaultMaxResponseBody = 32 << 20 // 非流式成功响应
+	DefaultMaxErrorBody    = 1 << 20  // 错误响应（4xx/5xx）
+	ErrorSnippetSize       = 4 << 10  // 错误信息中保留的响应体长度
+)
+
+// ErrBodyTooLarge 响应体超过上限
+var ErrBodyTooLarge = errors.New("响应体超过大小上限")
+
+// BodyLimitTransport 限制响应体大小，防止异常网关返回超大页面耗尽内存
+// 流式响应（SSE、AWS event stream）不限制总大小，由逐行/逐帧解析自行约束
+type BodyLimitTransport struct {
+	Base       http.RoundTripper
+	MaxBody    int64 // 0 使用 DefaultMaxResponseBody
+	MaxErrBody int64 // 0 使用 DefaultMaxErrorBody
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *BodyLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil || resp.Body == nil || isStreamingResponse(resp) {
+		return resp, err
+	}
+	limit := t.MaxBody
+	if limit <= 0 {
+		limit = DefaultMaxResponseBody
+	}
+	if resp.StatusCode >= 400 {
+		limit = t.MaxErrBody
+		if limit <= 0 {
+			limit = DefaultMaxErrorBody
+		}
+	}
+	resp.Body = LimitBody(resp.Body, limit)
+	return resp, nil
+}
+
+// isStreamingResponse 判断是否为流式响应
+func isStreamingResponse(resp *http.Response) bool {
+	ct := strings.ToLower(resp.Header.Get("Content-Type"))
+	return strings.HasPrefix(ct, "text/event-stream") ||
+		strings.HasPrefix(ct, "application/vnd.amazon.eventstream") ||
+		strings.HasPrefix(ct, "application/x-ndjson")
+}
+
+// LimitBody 限制读取的字节数，超出时返回 ErrBodyTooLarge（而不是静默截断）
+func LimitBody(rc io.ReadCloser, limit int64) io.ReadCloser {
+	return &limitedBody{rc: rc, remaining: limit, limit: limit}
+}
+
+type limitedBody struct {
+	rc        io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// 多读一个字节判断是否恰好读完
+		var one [1]byte
+		if n, _ := b.rc.Read(one[:]); n > 0 {
+			return 0, fmt.Errorf("%w (%d 字节)", ErrBodyTooLarge, b.limit)
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.rc.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.rc.Close()
+}
+
+var (
+	htmlTitleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlCodeRe  = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	htmlTagRe   = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaceRe     = regexp.MustCompile(`\s+`)
+)
+
+// ReadErrorBody 读取错误响应体的前 4KB 用于错误信息
+// HTML 页面（网关错误页）只保留标题与正文摘要，避免把整页标记写进日志和界面
+func ReadErrorBody(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, ErrorSnippetSize))
+	text := strings.TrimSpace(string(data))
+	lower := strings.ToLower(text)
+	if !strings.HasPrefix(lower, "<!doctype html") && !strings.HasPrefix(lower, "<html") {
+		return text
+	}
+	var title string
+	if m := htmlTitleRe.FindStringSubmatch(text); m != nil {
+		title = strings.TrimSpace(spaceRe.ReplaceAllString(m[1], " "))
+		text = strings.Replace(text, m[0], "", 1)
+	}
+	text = htmlCodeRe.ReplaceAllString(text, " ")
+	summary := strings.TrimSpace(spaceRe.ReplaceAllString(htmlTagRe.ReplaceAllString(text, " "), " "))
+	if r := []rune(summary); len(r) > 200 {
+		summary = string(r[:200]) + "…"
+	}
+	if title != "" {
+		return "HTML 页面: " + title + " " + summary
+	}
+	return "HTML 页面: " + summary
+}
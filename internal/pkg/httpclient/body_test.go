@@ -0,0 +1,54 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimitTransport(t *testing.T) {
+	payload := strings.Repeat("x", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+
+	read := func(limit int64, path string) (int, error) {
+		client := &http.Client{Transport: &BodyLimitTransport{Base: http.DefaultTransport, MaxBody: limit}}
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		return len(data), err
+	}
+
+	if n, err := read(100, "/"); err != nil || n != 100 {
+		t.Fatalf("exact limit: n=%d err=%v", n, err)
+	}
+	if _, err := read(50, "/"); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("over limit: err=%v, want ErrBodyTooLarge", err)
+	}
+	if n, err := read(50, "/stream"); err != nil || n != 100 {
+		t.Fatalf("streaming response should not be limited: n=%d err=%v", n, err)
+	}
+}
+
+func TestReadErrorBody_HTML(t *testing.T) {
+	page := "<!DOCTYPE html><html><head><title>502 Bad Gateway</title><style>body{}</style></head><body><h1>Bad Gateway</h1>" +
+		strings.Repeat("<p>padding</p>", 2000) + "</body></html>"
+	got := ReadErrorBody(strings.NewReader(page))
+	if !strings.HasPrefix(got, "HTML 页面: 502 Bad Gateway") || len(got) > 600 {
+		t.Fatalf("ReadErrorBody() = %q", got)
+	}
+	if got := ReadErrorBody(strings.NewReader(`{"error":"bad"}`)); got != `{"error":"bad"}` {
+		t.Fatalf("json body = %q", got)
+	}
+}
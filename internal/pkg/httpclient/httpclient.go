@@ -0,0 +1,69 @@
+// Package httpclient 构建供模型供应商共用的 HTTP Transport
+// 在全局代理设置的基础上叠加单个 AI 配置的代理、超时与 TLS 选项
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/pkg/proxy"
+)
+
+// Options HTTP Client 选项，零值表示沿用全局设置
+type Options struct {
+	ProxyURL           string        // 非空时覆盖全局代理，支持 http/https/socks5
+	DialTimeout        time.Duration // 建立连接超时
+	ReadTimeout        time.Duration // 等待响应头超时；不限制流式响应体的读取
+	InsecureSkipVerify bool          // 跳过证书校验（仅用于自签名的内网网关）
+	CACertPEM          string        // 额外信任的 CA 证书（PEM）
+}
+
+// NewTransport 基于全局代理 Transport 创建应用了选项的 Transport
+func NewTransport(opts Options) (*http.Transport, error) {
+	t := proxy.GetManager().GetTransport()
+
+	if opts.ProxyURL != "" {
+		proxyURL, err := url.Parse(strings.TrimSpace(opts.ProxyURL))
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("代理地址无效: %s", opts.ProxyURL)
+		}
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if opts.DialTimeout > 0 {
+		t.DialContext = (&net.Dialer{
+			Timeout:   opts.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+	if opts.ReadTimeout > 0 {
+		t.ResponseHeaderTimeout = opts.ReadTimeout
+	}
+
+	if opts.InsecureSkipVerify || opts.CACertPEM != "" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if t.TLSClientConfig != nil {
+			tlsConfig = t.TLSClientConfig.Clone()
+		}
+		tlsConfig.InsecureSkipVerify = opts.InsecureSkipVerify
+		if opts.CACertPEM != "" {
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM([]byte(opts.CACertPEM)) {
+				return nil, errors.New("CA 证书解析失败")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+	return t, nil
+}
@@ -0,0 +1,36 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewTransport_Options(t *testing.T) {
+	tr, err := NewTransport(Options{
+		ProxyURL:           "http://127.0.0.1:7890",
+		ReadTimeout:        20 * time.Second,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", "https://api.openai.com/v1/models", nil)
+	proxyURL, err := tr.Proxy(req)
+	if err != nil || proxyURL == nil || proxyURL.Host != "127.0.0.1:7890" {
+		t.Fatalf("proxy = %v, %v", proxyURL, err)
+	}
+	if tr.ResponseHeaderTimeout != 20*time.Second {
+		t.Fatalf("ResponseHeaderTimeout = %v", tr.ResponseHeaderTimeout)
+	}
+	if tr.TLSClientConfig == nil || !tr.TLSClientConfig.InsecureSkipVerify {
+		t.Fatal("InsecureSkipVerify not applied")
+	}
+
+	if _, err := NewTransport(Options{ProxyURL: "://bad"}); err == nil {
+		t.Fatal("expected error for invalid proxy url")
+	}
+	if _, err := NewTransport(Options{CACertPEM: "not a pem"}); err == nil {
+		t.Fatal("expected error for invalid CA cert")
+	}
+}
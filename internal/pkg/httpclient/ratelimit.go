@@ -0,0 +1,135 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// bucket 令牌桶：容量为每分钟配额，按秒平滑补充
+type bucket struct {
+	capacity float64
+	rate     float64 // 每秒补充的令牌数
+	tokens   float64
+	last     time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	return &bucket{
+		capacity: float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		tokens:   float64(perMinute),
+		last:     now,
+	}
+}
+
+// reserve 预扣 n 个令牌，返回需要等待的时长（允许透支，由等待补齐）
+// 超过容量的请求按容量计算，避免单个大请求永远无法发出
+func (b *bucket) reserve(n float64, now time.Time) time.Duration {
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= min(n, b.capacity)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Limiter 按 请求数/分钟 与 token 数/分钟 限流
+type Limiter struct {
+	rpm, tpm int
+	requests *bucket // nil 表示不限制
+	tokens   *bucket
+
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewLimiter 创建限流器，rpm/tpm 为 0 表示对应维度不限制
+func NewLimiter(rpm, tpm int) *Limiter {
+	l := &Limiter{rpm: rpm, tpm: tpm, now: time.Now}
+	now := l.now()
+	if rpm > 0 {
+		l.requests = newBucket(rpm, now)
+	}
+	if tpm > 0 {
+		l.tokens = newBucket(tpm, now)
+	}
+	return l
+}
+
+// Reserve 预扣一次请求及 tokens 个 token 的配额，返回需要等待的时长
+func (l *Limiter) Reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	var wait time.Duration
+	if l.requests != nil {
+		wait = l.requests.reserve(1, now)
+	}
+	if l.tokens != nil && tokens > 0 {
+		wait = max(wait, l.tokens.reserve(float64(tokens), now))
+	}
+	return wait
+}
+
+// Wait 预扣配额并等待，ctx 取消时返回错误
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	d := l.Reserve(tokens)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var (
+	limiters   = map[string]*Limiter{}
+	limitersMu sync.Mutex
+)
+
+// SharedLimiter 返回按 key（如 AI 配置 ID）共享的限流器
+// 同一配置创建的多个客户端共用配额；限额变化时重建，rpm 与 tpm 都为 0 时返回 nil
+func SharedLimiter(key string, rpm, tpm int) *Limiter {
+	if rpm <= 0 && tpm <= 0 {
+		return nil
+	}
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	if l, ok := limiters[key]; ok && l.rpm == rpm && l.tpm == tpm {
+		return l
+	}
+	l := NewLimiter(max(rpm, 0), max(tpm, 0))
+	limiters[key] = l
+	return l
+}
+
+// RateLimitTransport 在发送前按限流器等待配额
+// token 数按请求体字节数粗略估算（约 4 字节/token），仅用于平滑突发
+type RateLimitTransport struct {
+	Base    http.RoundTripper
+	Limiter *Limiter
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tokens := 0
+	if req.ContentLength > 0 {
+		tokens = int(req.ContentLength/4) + 1
+	}
+	start := time.Now()
+	if err := t.Limiter.Wait(req.Context(), tokens); err != nil {
+		return nil, err
+	}
+	if waited := time.Since(start); waited > time.Second {
+		log.Info("%s 触发本地限流，等待 %v", req.URL.Host, waited.Round(time.Millisecond))
+	}
+	return t.Base.RoundTrip(req)
+}
@@ -0,0 +1,43 @@
+package httpclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(2, 600)
+	l.now = func() time.Time { return now }
+	l.requests.last, l.tokens.last = now, now
+
+	if d := l.Reserve(100); d != 0 {
+		t.Fatalf("first reserve waited %v", d)
+	}
+	if d := l.Reserve(100); d != 0 {
+		t.Fatalf("second reserve waited %v", d)
+	}
+	// 请求配额耗尽：每分钟 2 次，补充一次需 30 秒
+	if d := l.Reserve(0); d != 30*time.Second {
+		t.Fatalf("rpm wait = %v, want 30s", d)
+	}
+
+	// token 维度：超过容量的请求按容量计，之后需等待补充
+	now = now.Add(90 * time.Second)
+	l2 := NewLimiter(0, 600)
+	l2.now = func() time.Time { return now }
+	l2.tokens.last = now
+	if d := l2.Reserve(1000); d != 0 {
+		t.Fatalf("oversized request should be clamped, waited %v", d)
+	}
+	if d := l2.Reserve(60); d != 6*time.Second {
+		t.Fatalf("tpm wait = %v, want 6s", d)
+	}
+
+	if SharedLimiter("x", 0, 0) != nil {
+		t.Fatal("no limits should return nil")
+	}
+	if a, b := SharedLimiter("x", 10, 0), SharedLimiter("x", 10, 0); a != b {
+		t.Fatal("same key and limits should share limiter")
+	}
+}
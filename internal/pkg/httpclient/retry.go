@@ -0,0 +1,156 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+)
+
+var log = logger.New("httpclient")
+
+// 默认重试参数
+const (
+	DefaultMaxAttempts = 3
+	defaultBaseDelay   = 500 * time.Millisecond
+	defaultMaxDelay    = 20 * time.Second
+)
+
+// RetryTransport 对限流和服务端临时错误进行指数退避重试
+//   - 429/500/502/503/504/529 以及连接类错误会重试
+//   - 优先使用 Retry-After 响应头，超过 MaxDelay 时直接返回原响应
+//   - 请求体不可重放（GetBody 为空）时不重试
+type RetryTransport struct {
+	Base        http.RoundTripper
+	MaxAttempts int           // 最大尝试次数（含首次），<=1 不重试
+	BaseDelay   time.Duration // 首次退避时长，按 2 的幂增长并加入随机抖动
+	MaxDelay    time.Duration // 单次等待上限
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryTransport 创建重试 Transport，maxAttempts 为 0 时使用默认值
+func NewRetryTransport(base http.RoundTripper, maxAttempts int) *RetryTransport {
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	return &RetryTransport{
+		Base:        base,
+		MaxAttempts: maxAttempts,
+		BaseDelay:   defaultBaseDelay,
+		MaxDelay:    defaultMaxDelay,
+	}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.MaxAttempts <= 1 || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return t.Base.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.Base.RoundTrip(req)
+		if attempt >= t.MaxAttempts || !retryable(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if after > t.MaxDelay {
+					return resp, nil
+				}
+				delay = after
+			}
+			// 丢弃响应体以便复用连接
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			log.Warn("%s %s 返回 %d，%v 后第 %d 次重试", req.Method, req.URL.Host, resp.StatusCode, delay, attempt)
+		} else {
+			log.Warn("%s %s 请求失败: %v，%v 后第 %d 次重试", req.Method, req.URL.Host, err, delay, attempt)
+		}
+
+		if err := t.wait(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// backoff 计算第 attempt 次失败后的等待时长（带 ±50% 抖动）
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	d := t.BaseDelay << (attempt - 1)
+	if d <= 0 || d > t.MaxDelay {
+		d = t.MaxDelay
+	}
+	jitter := time.Duration(rand.Int64N(int64(d)))
+	return d/2 + jitter
+}
+
+func (t *RetryTransport) wait(ctx context.Context, d time.Duration) error {
+	if t.sleep != nil {
+		return t.sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryable 判断响应或错误是否值得重试
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		529: // Anthropic overloaded
+		return true
+	}
+	return false
+}
+
+// ParseRetryAfter 解析 Retry-After 响应头
+func ParseRetryAfter(value string) (time.Duration, bool) {
+	return retryAfter(value, time.Now())
+}
+
+// retryAfter 解析 Retry-After（秒数或 HTTP 日期）
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var calls int
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if calls == 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	var delays []time.Duration
+	rt := NewRetryTransport(http.DefaultTransport, 0)
+	rt.sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	req, _ := http.NewRequest("POST", srv.URL, strings.NewReader(`{"a":1}`))
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls != 3 {
+		t.Fatalf("status=%d calls=%d", resp.StatusCode, calls)
+	}
+	for _, b := range bodies {
+		if b != `{"a":1}` {
+			t.Fatalf("body not replayed: %q", bodies)
+		}
+	}
+	if len(delays) != 2 || delays[0] != time.Second {
+		t.Fatalf("delays = %v, want Retry-After first", delays)
+	}
+
+	// 非临时错误不重试
+	calls = 10
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	req, _ = http.NewRequest("POST", srv.URL, strings.NewReader("{}"))
+	resp, err = (&http.Client{Transport: rt}).Do(req)
+	if err != nil || resp.StatusCode != http.StatusBadRequest || calls != 11 {
+		t.Fatalf("400 should not retry: calls=%d err=%v", calls, err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,77 @@
+// Package idempotency 按幂等键合并重复提交：执行中的重复调用等待首次调用完成，
+// 完成后的结果在有效期内直接复用，避免界面重试或 IPC 抖动导致重复生成
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// call 一次调用的状态
+type call[T any] struct {
+	done     chan struct{}
+	result   T
+	finished time.Time
+}
+
+// Cache 幂等调用缓存
+type Cache[T any] struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	calls map[string]*call[T]
+}
+
+// New 创建幂等调用缓存，ttl 为完成后结果的保留时长
+func New[T any](ttl time.Duration) *Cache[T] {
+	return &Cache[T]{ttl: ttl, calls: make(map[string]*call[T])}
+}
+
+// Do 执行 fn；同一 key 的重复调用不再执行，返回首次调用的结果，shared 为 true
+// key 为空时总是执行
+func (c *Cache[T]) Do(key string, fn func() T) (result T, shared bool) {
+	if key == "" {
+		return fn(), false
+	}
+
+	c.mu.Lock()
+	c.evictLocked(time.Now())
+	if existing, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-existing.done
+		return existing.result, true
+	}
+	cl := &call[T]{done: make(chan struct{})}
+	c.calls[key] = cl
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		cl.finished = time.Now()
+		c.mu.Unlock()
+		close(cl.done)
+	}()
+	cl.result = fn()
+	return cl.result, false
+}
+
+// evictLocked 清理过期的已完成调用
+func (c *Cache[T]) evictLocked(now time.Time) {
+	for key, cl := range c.calls {
+		if !cl.finished.IsZero() && now.Sub(cl.finished) > c.ttl {
+			delete(c.calls, key)
+		}
+	}
+}
+
+// ContentKey 由请求内容派生幂等键，调用方未提供幂等键时用于合并内容完全相同的重复提交
+func ContentKey(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		// 写入长度前缀，避免 ("ab","c") 与 ("a","bc") 得到相同的键
+		h.Write([]byte{byte(len(p) >> 24), byte(len(p) >> 16), byte(len(p) >> 8), byte(len(p))})
+		h.Write([]byte(p))
+	}
+	return "content:" + hex.EncodeToString(h.Sum(nil)[:16])
+}
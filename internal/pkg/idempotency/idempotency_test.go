@@ -0,0 +1,76 @@
+package idempotency
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	c := New[int](time.Minute)
+	var runs atomic.Int32
+	release := make(chan struct{})
+	fn := func() int {
+		<-release
+		return int(runs.Add(1))
+	}
+
+	// 并发的重复提交只执行一次，且都拿到同一结果
+	var wg sync.WaitGroup
+	results := make([]int, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = c.Do("k", fn)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	for _, r := range results {
+		if r != 1 {
+			t.Fatalf("results = %v, want all 1", results)
+		}
+	}
+
+	// 完成后的重试复用结果
+	if r, shared := c.Do("k", fn); r != 1 || !shared {
+		t.Fatalf("retry = %d shared=%v", r, shared)
+	}
+	// 不同 key 与空 key 正常执行
+	if r, shared := c.Do("other", fn); r != 2 || shared {
+		t.Fatalf("other key = %d shared=%v", r, shared)
+	}
+	if r, _ := c.Do("", fn); r != 3 {
+		t.Fatalf("empty key = %d", r)
+	}
+}
+
+func TestDoExpires(t *testing.T) {
+	c := New[int](time.Millisecond)
+	n := 0
+	c.Do("k", func() int { n++; return n })
+	time.Sleep(5 * time.Millisecond)
+	if r, shared := c.Do("k", func() int { n++; return n }); r != 2 || shared {
+		t.Fatalf("after ttl = %d shared=%v", r, shared)
+	}
+}
+
+func TestContentKey(t *testing.T) {
+	a := ContentKey("sh600519", "怎么看", "", "")
+	if a != ContentKey("sh600519", "怎么看", "", "") {
+		t.Fatal("same content should give the same key")
+	}
+	for _, other := range []string{
+		ContentKey("sh600000", "怎么看", "", ""),
+		ContentKey("sh600519", "怎么看？", "", ""),
+		ContentKey("sh600519", "怎么看", "msg-1", ""),
+		ContentKey("sh600519怎么看", "", "", ""),
+	} {
+		if other == a {
+			t.Fatalf("different content gave the same key %s", a)
+		}
+	}
+}
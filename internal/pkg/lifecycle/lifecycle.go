@@ -0,0 +1,196 @@
+// Package lifecycle 子系统的启动与停止编排：按顺序启动，逆序停止，每个钩子有独立的超时，
+// 单个子系统失败或超时只记录日志，不阻塞其余子系统
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+)
+
+var log = logger.New("lifecycle")
+
+// DefaultTimeout 钩子未设置超时时使用的默认值
+const DefaultTimeout = 10 * time.Second
+
+// 钩子状态
+const (
+	StatePending = "pending" // 尚未启动
+	StateRunning = "running" // 已启动
+	StateFailed  = "failed"  // 启动失败
+	StateStopped = "stopped" // 已停止
+)
+
+// Hook 子系统的启动与停止钩子
+//   - Start 收到的 ctx 在子系统停止时取消，后台协程应以它为生命周期
+//   - Stop 在 ctx 取消之后调用，用于关闭连接、等待协程退出与落盘，收到的 ctx 带超时
+type Hook struct {
+	Name    string
+	Order   int           // 启动按升序，停止按降序；相同顺序按注册先后
+	Timeout time.Duration // 单次 Start / Stop 的超时，0 使用 DefaultTimeout
+	Start   func(ctx context.Context) error
+	Stop    func(ctx context.Context) error
+}
+
+// Status 子系统当前状态
+type Status struct {
+	Name     string `json:"name"`
+	Order    int    `json:"order"`
+	State    string `json:"state"`
+	Error    string `json:"error,omitempty"`
+	StartMs  int64  `json:"startMs"` // 启动耗时（毫秒）
+	StopMs   int64  `json:"stopMs"`  // 停止耗时（毫秒）
+	TimedOut bool   `json:"timedOut,omitempty"`
+}
+
+// entry 已注册的钩子与运行状态
+type entry struct {
+	hook   Hook
+	cancel context.CancelFunc
+	status Status
+}
+
+// Manager 子系统生命周期管理
+type Manager struct {
+	mu      sync.Mutex
+	entries []*entry
+	started bool
+}
+
+// NewManager 创建生命周期管理器
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Register 注册子系统钩子，需在 Start 之前调用
+func (m *Manager) Register(h Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if h.Timeout <= 0 {
+		h.Timeout = DefaultTimeout
+	}
+	m.entries = append(m.entries, &entry{hook: h, status: Status{Name: h.Name, Order: h.Order, State: StatePending}})
+}
+
+// Start 按顺序启动全部子系统，返回启动失败的错误（已合并）
+// 超时的子系统视为已启动（它可能仍在初始化），停止时照常调用其 Stop
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	if m.started {
+		m.mu.Unlock()
+		return errors.New("生命周期管理器已启动")
+	}
+	m.started = true
+	sort.SliceStable(m.entries, func(i, j int) bool { return m.entries[i].hook.Order < m.entries[j].hook.Order })
+	entries := append([]*entry(nil), m.entries...)
+	m.mu.Unlock()
+
+	var errs []error
+	for _, e := range entries {
+		hookCtx, cancel := context.WithCancel(ctx)
+		start := time.Now()
+		var err error
+		timedOut := false
+		if e.hook.Start != nil {
+			timedOut, err = runWithTimeout(func() error { return e.hook.Start(hookCtx) }, e.hook.Timeout)
+		}
+
+		m.mu.Lock()
+		e.status.StartMs = time.Since(start).Milliseconds()
+		e.status.TimedOut = timedOut
+		if err != nil {
+			cancel()
+			e.status.State, e.status.Error = StateFailed, err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", e.hook.Name, err))
+			log.Warn("子系统 %s 启动失败: %v", e.hook.Name, err)
+		} else {
+			e.cancel = cancel
+			e.status.State = StateRunning
+			if timedOut {
+				log.Warn("子系统 %s 启动超过 %v，继续启动后续子系统", e.hook.Name, e.hook.Timeout)
+			}
+		}
+		m.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Stop 逆序停止已启动的子系统：先取消其 ctx，再调用 Stop；ctx 结束后剩余子系统只取消不等待
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	entries := append([]*entry(nil), m.entries...)
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		m.mu.Lock()
+		running := e.status.State == StateRunning
+		cancel := e.cancel
+		m.mu.Unlock()
+		if !running {
+			continue
+		}
+
+		start := time.Now()
+		cancel()
+		var err error
+		timedOut := false
+		if e.hook.Stop != nil && ctx.Err() == nil {
+			stopCtx, stopCancel := context.WithTimeout(ctx, e.hook.Timeout)
+			timedOut, err = runWithTimeout(func() error { return e.hook.Stop(stopCtx) }, e.hook.Timeout)
+			stopCancel()
+		}
+
+		m.mu.Lock()
+		e.status.State = StateStopped
+		e.status.StopMs = time.Since(start).Milliseconds()
+		e.status.TimedOut = e.status.TimedOut || timedOut
+		if err != nil {
+			e.status.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", e.hook.Name, err))
+			log.Warn("子系统 %s 停止失败: %v", e.hook.Name, err)
+		} else if timedOut {
+			log.Warn("子系统 %s 停止超过 %v，不再等待", e.hook.Name, e.hook.Timeout)
+		}
+		m.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Statuses 返回各子系统的状态（按启动顺序）
+func (m *Manager) Statuses() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Status, len(m.entries))
+	for i, e := range m.entries {
+		out[i] = e.status
+	}
+	return out
+}
+
+// runWithTimeout 执行 fn，超时后返回（fn 仍在后台运行）
+func runWithTimeout(fn func() error, timeout time.Duration) (timedOut bool, err error) {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- fn()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return false, err
+	case <-timer.C:
+		return true, nil
+	}
+}
@@ -0,0 +1,74 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManager_OrderFailureAndTimeout(t *testing.T) {
+	var events []string
+	m := NewManager()
+	hook := func(name string, order int, startErr error) Hook {
+		return Hook{
+			Name:  name,
+			Order: order,
+			Start: func(ctx context.Context) error {
+				events = append(events, "start:"+name)
+				return startErr
+			},
+			Stop: func(ctx context.Context) error {
+				events = append(events, "stop:"+name)
+				return nil
+			},
+		}
+	}
+	m.Register(hook("scheduler", 50, nil))
+	m.Register(hook("mcp", 10, nil))
+	m.Register(hook("broken", 20, errors.New("boom")))
+
+	// 后台协程以 Start 的 ctx 为生命周期，Stop 之前取消
+	loopDone := make(chan struct{})
+	m.Register(Hook{
+		Name:    "loop",
+		Order:   30,
+		Timeout: 50 * time.Millisecond,
+		Start: func(ctx context.Context) error {
+			go func() {
+				<-ctx.Done()
+				close(loopDone)
+			}()
+			time.Sleep(200 * time.Millisecond) // 超时后继续启动后续子系统
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			select {
+			case <-loopDone:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	if err := m.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "broken: boom") {
+		t.Fatalf("Start err = %v", err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop err = %v", err)
+	}
+
+	want := "start:mcp,start:broken,start:scheduler,stop:scheduler,stop:mcp"
+	if got := strings.Join(events, ","); got != want {
+		t.Fatalf("events = %s, want %s", got, want)
+	}
+	states := map[string]Status{}
+	for _, s := range m.Statuses() {
+		states[s.Name] = s
+	}
+	if states["broken"].State != StateFailed || states["loop"].State != StateStopped || !states["loop"].TimedOut {
+		t.Fatalf("statuses = %+v", states)
+	}
+}
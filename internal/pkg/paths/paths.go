@@ -1,12 +1,29 @@
 package paths
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
-// GetDataDir 获取应用数据目录
-func GetDataDir() string {
+// locationFile 默认数据目录下记录自定义数据目录的文件
+const locationFile = "datadir.json"
+
+// location 自定义数据目录记录
+type location struct {
+	Path string `json:"path"`
+}
+
+var (
+	dataDirOnce    sync.Once
+	dataDir        string
+	dataDirWarning string
+)
+
+// DefaultDataDir 默认数据目录（用户配置目录下的 jcp）
+func DefaultDataDir() string {
 	userConfigDir, err := os.UserConfigDir()
 	if err != nil || userConfigDir == "" {
 		return filepath.Join(".", "data")
@@ -14,6 +31,89 @@ func GetDataDir() string {
 	return filepath.Join(userConfigDir, "jcp")
 }
 
+// GetDataDir 获取应用数据目录：默认目录中记录了自定义目录时使用自定义目录
+// 结果在进程内缓存，迁移后需重启生效
+func GetDataDir() string {
+	dataDirOnce.Do(func() {
+		dataDir, dataDirWarning = resolveDataDir()
+	})
+	return dataDir
+}
+
+// DataDirWarning 自定义数据目录不可用（外接盘未连接、同步目录未挂载）时的提示，正常时为空
+func DataDirWarning() string {
+	GetDataDir()
+	return dataDirWarning
+}
+
+// resolveDataDir 确定数据目录；记录的自定义目录不存在时回退到默认目录并返回提示，
+// 避免在未挂载的路径下新建一套空数据
+func resolveDataDir() (string, string) {
+	def := DefaultDataDir()
+	custom := CustomDataDir()
+	if custom == "" {
+		return def, ""
+	}
+	if info, err := os.Stat(custom); err != nil || !info.IsDir() {
+		return def, fmt.Sprintf("自定义数据目录 %s 不可用，本次使用默认目录 %s；请确认外接磁盘或同步目录已连接后重启", custom, def)
+	}
+	return custom, ""
+}
+
+// CustomDataDir 读取记录的自定义数据目录，未设置时返回空
+func CustomDataDir() string {
+	data, err := os.ReadFile(filepath.Join(DefaultDataDir(), locationFile))
+	if err != nil {
+		return ""
+	}
+	var loc location
+	if json.Unmarshal(data, &loc) != nil {
+		return ""
+	}
+	return loc.Path
+}
+
+// SetCustomDataDir 原子写入自定义数据目录记录，dir 为空或等于默认目录时恢复默认
+func SetCustomDataDir(dir string) error {
+	base := DefaultDataDir()
+	path := filepath.Join(base, locationFile)
+	if dir == "" || filepath.Clean(dir) == filepath.Clean(base) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(location{Path: dir}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(base, locationFile+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// IsLocationFile 判断是否为自定义目录记录文件（迁移时不复制）
+func IsLocationFile(name string) bool {
+	return name == locationFile
+}
+
 // GetCacheDir 获取缓存目录
 func GetCacheDir() string {
 	return filepath.Join(GetDataDir(), "cache")
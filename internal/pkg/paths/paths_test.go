@@ -0,0 +1,42 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDataDir_FallsBackWhenCustomMissing(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	def := DefaultDataDir()
+
+	if dir, warning := resolveDataDir(); dir != def || warning != "" {
+		t.Fatalf("no custom dir: got %q, %q", dir, warning)
+	}
+
+	custom := filepath.Join(t.TempDir(), "external")
+	if err := os.MkdirAll(custom, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetCustomDataDir(custom); err != nil {
+		t.Fatal(err)
+	}
+	if dir, warning := resolveDataDir(); dir != custom || warning != "" {
+		t.Fatalf("available custom dir: got %q, %q", dir, warning)
+	}
+
+	// 模拟外接磁盘拔出：不应在原路径下新建空目录
+	if err := os.RemoveAll(custom); err != nil {
+		t.Fatal(err)
+	}
+	dir, warning := resolveDataDir()
+	if dir != def || warning == "" {
+		t.Fatalf("missing custom dir should fall back with a warning: got %q, %q", dir, warning)
+	}
+	if _, err := os.Stat(custom); !os.IsNotExist(err) {
+		t.Fatal("custom dir should not be recreated")
+	}
+	if CustomDataDir() != custom {
+		t.Fatal("recorded custom dir should be kept for the next start")
+	}
+}
@@ -0,0 +1,132 @@
+package pdftext
+
+import (
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+// cmap ToUnicode 映射：字符编码 -> Unicode 文本
+type cmap struct {
+	width int // 编码字节数（1 或 2）
+	m     map[uint32]string
+}
+
+var (
+	hexTokenRe  = regexp.MustCompile(`<([0-9A-Fa-f\s]*)>|\[|\]`)
+	cmapBlockRe = regexp.MustCompile(`(?s)begin(bfchar|bfrange|codespacerange)(.*?)end(?:bfchar|bfrange|codespacerange)`)
+)
+
+// parseCMap 解析 bfchar / bfrange 段
+func parseCMap(data []byte) *cmap {
+	c := &cmap{m: make(map[uint32]string)}
+	for _, block := range cmapBlockRe.FindAllStringSubmatch(string(data), -1) {
+		tokens := hexTokenRe.FindAllStringSubmatch(block[2], -1)
+		switch block[1] {
+		case "codespacerange":
+			if len(tokens) > 0 && c.width == 0 {
+				c.width = len(cleanHex(tokens[0][1])) / 2
+			}
+		case "bfchar":
+			for i := 0; i+1 < len(tokens); i += 2 {
+				src := cleanHex(tokens[i][1])
+				c.setWidth(src)
+				c.m[hexCode(src)] = utf16Hex(cleanHex(tokens[i+1][1]))
+			}
+		case "bfrange":
+			for i := 0; i+2 < len(tokens); {
+				lo, hi := cleanHex(tokens[i][1]), cleanHex(tokens[i+1][1])
+				c.setWidth(lo)
+				start, end := hexCode(lo), hexCode(hi)
+				if end < start || end-start > 0xFFFF {
+					i += 3
+					continue
+				}
+				if tokens[i+2][0] == "[" {
+					// <lo> <hi> [<dst1> <dst2> ...]
+					j := i + 3
+					for code := start; j < len(tokens) && tokens[j][0] != "]"; j++ {
+						c.m[code] = utf16Hex(cleanHex(tokens[j][1]))
+						code++
+					}
+					i = j + 1
+					continue
+				}
+				// <lo> <hi> <dst>：目标最后一个 UTF-16 码元按偏移递增
+				dst := utf16Units(cleanHex(tokens[i+2][1]))
+				for code := start; code <= end && len(dst) > 0; code++ {
+					units := append([]uint16(nil), dst...)
+					units[len(units)-1] += uint16(code - start)
+					c.m[code] = string(utf16.Decode(units))
+				}
+				i += 3
+			}
+		}
+	}
+	if c.width == 0 {
+		c.width = 2
+	}
+	return c
+}
+
+func (c *cmap) setWidth(src string) {
+	if c.width == 0 && len(src) >= 2 {
+		c.width = len(src) / 2
+	}
+}
+
+// decode 按编码宽度把字节串转换为文本
+func (c *cmap) decode(b []byte) string {
+	if c == nil {
+		return latin1(b)
+	}
+	var sb strings.Builder
+	for i := 0; i+c.width <= len(b); i += c.width {
+		var code uint32
+		for _, x := range b[i : i+c.width] {
+			code = code<<8 | uint32(x)
+		}
+		if s, ok := c.m[code]; ok {
+			sb.WriteString(s)
+		}
+	}
+	return sb.String()
+}
+
+// latin1 无映射时按单字节解码，丢弃控制字符
+func latin1(b []byte) string {
+	var sb strings.Builder
+	for _, x := range b {
+		if x >= 0x20 && x != 0x7F {
+			sb.WriteRune(rune(x))
+		}
+	}
+	return sb.String()
+}
+
+func cleanHex(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+func hexCode(s string) uint32 {
+	b, _ := hex.DecodeString(s)
+	var code uint32
+	for _, x := range b {
+		code = code<<8 | uint32(x)
+	}
+	return code
+}
+
+func utf16Units(s string) []uint16 {
+	b, _ := hex.DecodeString(s)
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return units
+}
+
+func utf16Hex(s string) string {
+	return string(utf16.Decode(utf16Units(s)))
+}
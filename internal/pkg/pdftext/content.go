@@ -0,0 +1,262 @@
+package pdftext
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// token 内容流中的词法单元
+type token struct {
+	kind  byte // 's' 字符串, 'n' 数字, '/' 名称, '[' 数组开始, ']' 数组结束, 'o' 操作符
+	str   []byte
+	num   float64
+	value string
+}
+
+// renderContent 执行内容流中的文本操作符，输出页面文本
+func renderContent(content []byte, fonts map[string]*cmap) string {
+	var (
+		sb       strings.Builder
+		operands []token
+		font     *cmap
+		inArray  bool
+		array    []token
+		lastY    float64
+	)
+	newline := func() {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteByte('\n')
+		}
+	}
+	show := func(b []byte) {
+		sb.WriteString(font.decode(b))
+	}
+
+	lex := lexer{data: content}
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		switch tok.kind {
+		case '[':
+			inArray, array = true, nil
+			continue
+		case ']':
+			inArray = false
+			operands = append(operands, token{kind: '['})
+			continue
+		}
+		if inArray {
+			array = append(array, tok)
+			continue
+		}
+		if tok.kind != 'o' {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch tok.value {
+		case "Tf":
+			if len(operands) >= 2 && operands[len(operands)-2].kind == '/' {
+				font = fonts[operands[len(operands)-2].value]
+			}
+		case "Tj", "'", "\"":
+			if tok.value != "Tj" {
+				newline()
+			}
+			if len(operands) > 0 && operands[len(operands)-1].kind == 's' {
+				show(operands[len(operands)-1].str)
+			}
+		case "TJ":
+			for _, t := range array {
+				switch {
+				case t.kind == 's':
+					show(t.str)
+				case t.kind == 'n' && t.num < -250 && font == nil:
+					// 单字节字体中较大的负间距通常代表单词间空格
+					sb.WriteByte(' ')
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 && operands[len(operands)-1].kind == 'n' && operands[len(operands)-1].num != 0 {
+				newline()
+			}
+		case "Tm":
+			if len(operands) >= 6 && operands[len(operands)-1].kind == 'n' {
+				if y := operands[len(operands)-1].num; y != lastY {
+					newline()
+					lastY = y
+				}
+			}
+		case "T*", "ET":
+			newline()
+		case "BI":
+			lex.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+	return sb.String()
+}
+
+// lexer 内容流词法分析器
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func (l *lexer) next() (token, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case c == '(':
+			return token{kind: 's', str: l.literalString()}, true
+		case c == '<':
+			if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+				l.pos += 2
+				return token{kind: 'o', value: "<<"}, true
+			}
+			return token{kind: 's', str: l.hexString()}, true
+		case c == '>':
+			l.pos++
+			if l.pos < len(l.data) && l.data[l.pos] == '>' {
+				l.pos++
+			}
+			return token{kind: 'o', value: ">>"}, true
+		case c == '[' || c == ']':
+			l.pos++
+			return token{kind: c}, true
+		case c == '/':
+			l.pos++
+			return token{kind: '/', value: l.word()}, true
+		default:
+			w := l.word()
+			if w == "" {
+				l.pos++
+				continue
+			}
+			if n, err := strconv.ParseFloat(w, 64); err == nil {
+				return token{kind: 'n', num: n}, true
+			}
+			return token{kind: 'o', value: w}, true
+		}
+	}
+	return token{}, false
+}
+
+func (l *lexer) word() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// literalString 读取 (...) 字符串，处理嵌套括号与转义
+func (l *lexer) literalString() []byte {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r', '\n':
+				// 续行
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for k := 0; k < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; k++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// hexString 读取 <...> 十六进制字符串，奇数位补 0
+func (l *lexer) hexString() []byte {
+	l.pos++ // <
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		end = len(l.data) - l.pos
+	}
+	s := cleanHex(string(l.data[l.pos : l.pos+end]))
+	l.pos += end + 1
+	if len(s)%2 == 1 {
+		s += "0"
+	}
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+// skipInlineImage 跳过 BI ... ID <二进制> EI
+func (l *lexer) skipInlineImage() {
+	idx := bytes.Index(l.data[l.pos:], []byte("ID"))
+	if idx < 0 {
+		l.pos = len(l.data)
+		return
+	}
+	l.pos += idx + 2
+	for l.pos < len(l.data) {
+		idx := bytes.Index(l.data[l.pos:], []byte("EI"))
+		if idx < 0 {
+			l.pos = len(l.data)
+			return
+		}
+		l.pos += idx + 2
+		if (l.pos >= len(l.data) || isSpace(l.data[l.pos])) && idx > 0 && isSpace(l.data[l.pos-3]) {
+			return
+		}
+	}
+}
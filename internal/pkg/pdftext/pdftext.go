@@ -0,0 +1,395 @@
+// Package pdftext 从 PDF 中提取纯文本（仅依赖标准库）
+// 支持 FlateDecode 内容流、对象流（ObjStm）与 ToUnicode CMap，
+// 足以处理交易所公告、研报等文字型 PDF；扫描件与加密文件不支持
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxPages 最多提取的页数
+	MaxPages = 200
+	// MaxStreamSize 单个流解压后的最大字节数，超出的流整体丢弃（防止压缩炸弹）
+	MaxStreamSize = 16 << 20
+	// MaxDecodedSize 整个文件解压后的最大字节数，超出后不再解压后续的流
+	MaxDecodedSize = 64 << 20
+)
+
+var (
+	// ErrEncrypted 加密的 PDF
+	ErrEncrypted = errors.New("不支持加密的 PDF")
+	// ErrNoText 未提取到文本（可能是扫描件）
+	ErrNoText = errors.New("PDF 中未提取到文本")
+)
+
+var (
+	objHeaderRe = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	refRe       = regexp.MustCompile(`(\d+)\s+\d+\s+R\b`)
+	namedRefRe  = regexp.MustCompile(`/([^\s/<>\[\]()]+)\s+(\d+)\s+\d+\s+R\b`)
+	leadRefRe   = regexp.MustCompile(`^\s*(\d+)\s+\d+\s+R`)
+	pageTypeRe  = regexp.MustCompile(`/Type\s*/Page\b`)
+)
+
+// object PDF 间接对象
+type object struct {
+	dict   string // 对象字典（或直接值）的原始文本
+	stream []byte // 解码后的流数据，无流时为 nil
+}
+
+// document 解析后的 PDF
+type document struct {
+	objects map[int]*object
+	order   []int // 对象出现顺序
+}
+
+// Extract 提取 PDF 全文，页与页之间以空行分隔
+func Extract(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\r\n\t "), []byte("%PDF")) {
+		return "", errors.New("不是有效的 PDF 文件")
+	}
+	doc := parse(data)
+	if len(doc.objects) == 0 {
+		return "", errors.New("PDF 解析失败")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return "", ErrEncrypted
+	}
+
+	var pages []string
+	for _, page := range doc.pages() {
+		if len(pages) >= MaxPages {
+			break
+		}
+		fonts := doc.pageFonts(page)
+		var content []byte
+		for _, ref := range doc.contentRefs(page) {
+			if obj := doc.objects[ref]; obj != nil && obj.stream != nil {
+				content = append(content, obj.stream...)
+				content = append(content, '\n')
+			}
+		}
+		if text := strings.TrimSpace(renderContent(content, fonts)); text != "" {
+			pages = append(pages, text)
+		}
+	}
+	if len(pages) == 0 {
+		return "", ErrNoText
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
+
+// parse 扫描文件中的所有间接对象，并展开对象流
+func parse(data []byte) *document {
+	doc := &document{objects: make(map[int]*object)}
+	budget := MaxDecodedSize
+	locs := objHeaderRe.FindAllSubmatchIndex(data, -1)
+	for i, loc := range locs {
+		num, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		start := loc[1]
+		end := len(data)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		body := data[start:end]
+		if idx := bytes.LastIndex(body, []byte("endobj")); idx >= 0 {
+			body = body[:idx]
+		}
+		obj := parseObject(body, &budget)
+		if _, exists := doc.objects[num]; !exists {
+			doc.order = append(doc.order, num)
+		}
+		doc.objects[num] = obj
+	}
+
+	// 展开对象流（PDF 1.5+ 常把字体、页面字典压缩在 ObjStm 中）
+	for _, num := range append([]int(nil), doc.order...) {
+		obj := doc.objects[num]
+		if obj.stream == nil || !strings.Contains(obj.dict, "/ObjStm") {
+			continue
+		}
+		n, _ := dictInt(obj.dict, "/N")
+		first, _ := dictInt(obj.dict, "/First")
+		if n <= 0 || first <= 0 || first > len(obj.stream) {
+			continue
+		}
+		header := strings.Fields(string(obj.stream[:first]))
+		type entry struct{ num, off int }
+		var entries []entry
+		for i := 0; i+1 < len(header) && len(entries) < n; i += 2 {
+			en, err1 := strconv.Atoi(header[i])
+			off, err2 := strconv.Atoi(header[i+1])
+			if err1 == nil && err2 == nil {
+				entries = append(entries, entry{en, off})
+			}
+		}
+		for i, e := range entries {
+			start := first + e.off
+			end := len(obj.stream)
+			if i+1 < len(entries) {
+				end = first + entries[i+1].off
+			}
+			if start < 0 || start > end || end > len(obj.stream) {
+				continue
+			}
+			if _, exists := doc.objects[e.num]; !exists {
+				doc.order = append(doc.order, e.num)
+				doc.objects[e.num] = &object{dict: string(obj.stream[start:end])}
+			}
+		}
+	}
+	return doc
+}
+
+// parseObject 拆分对象字典与流，并按 Filter 解码；budget 为剩余可解压字节数，解压成功后扣减
+func parseObject(body []byte, budget *int) *object {
+	idx := bytes.Index(body, []byte("stream"))
+	if idx < 0 {
+		return &object{dict: string(body)}
+	}
+	dict := string(body[:idx])
+	raw := body[idx+len("stream"):]
+	raw = bytes.TrimPrefix(raw, []byte("\r"))
+	raw = bytes.TrimPrefix(raw, []byte("\n"))
+	if length, ok := dictInt(dict, "/Length"); ok && length <= len(raw) && !strings.Contains(dict, "/Length "+strconv.Itoa(length)+" 0 R") {
+		raw = raw[:length]
+	} else if end := bytes.LastIndex(raw, []byte("endstream")); end >= 0 {
+		raw = bytes.TrimRight(raw[:end], "\r\n")
+	}
+
+	obj := &object{dict: dict}
+	switch {
+	case strings.Contains(dict, "/FlateDecode"):
+		limit := min(MaxStreamSize, *budget)
+		if limit <= 0 {
+			break
+		}
+		if r, err := zlib.NewReader(bytes.NewReader(raw)); err == nil {
+			// 容忍截断的流，尽量保留已解压部分；超出上限的流整体丢弃
+			decoded, _ := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+			if len(decoded) <= limit {
+				obj.stream = decoded
+				*budget -= len(decoded)
+			}
+		}
+	case !strings.Contains(dict, "/Filter"):
+		obj.stream = raw
+	}
+	return obj
+}
+
+// pages 按页面树顺序返回页面对象编号，页面树损坏时按对象顺序回退
+func (d *document) pages() []int {
+	var result []int
+	visited := make(map[int]bool)
+	var walk func(num int)
+	walk = func(num int) {
+		obj := d.objects[num]
+		if obj == nil || visited[num] || len(result) >= MaxPages {
+			return
+		}
+		visited[num] = true
+		if isPage(obj.dict) {
+			result = append(result, num)
+			return
+		}
+		if kids, ok := dictArray(obj.dict, "/Kids"); ok {
+			for _, m := range refRe.FindAllStringSubmatch(kids, -1) {
+				kid, _ := strconv.Atoi(m[1])
+				walk(kid)
+			}
+		}
+	}
+	for _, num := range d.order {
+		if strings.Contains(d.objects[num].dict, "/Catalog") {
+			if ref, ok := dictRef(d.objects[num].dict, "/Pages"); ok {
+				walk(ref)
+			}
+			break
+		}
+	}
+	if len(result) > 0 {
+		return result
+	}
+	for _, num := range d.order {
+		if isPage(d.objects[num].dict) {
+			result = append(result, num)
+		}
+	}
+	return result
+}
+
+func isPage(dict string) bool {
+	return pageTypeRe.MatchString(dict)
+}
+
+// contentRefs 返回页面的内容流对象编号
+func (d *document) contentRefs(page int) []int {
+	dict := d.objects[page].dict
+	var refs []int
+	if arr, ok := dictArray(dict, "/Contents"); ok {
+		for _, m := range refRe.FindAllStringSubmatch(arr, -1) {
+			n, _ := strconv.Atoi(m[1])
+			refs = append(refs, n)
+		}
+		return refs
+	}
+	if ref, ok := dictRef(dict, "/Contents"); ok {
+		// Contents 也可能间接引用一个数组
+		if obj := d.objects[ref]; obj != nil && obj.stream == nil {
+			for _, m := range refRe.FindAllStringSubmatch(obj.dict, -1) {
+				n, _ := strconv.Atoi(m[1])
+				refs = append(refs, n)
+			}
+			return refs
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// pageFonts 解析页面（含继承自父节点的）字体资源：资源名 -> ToUnicode 映射
+func (d *document) pageFonts(page int) map[string]*cmap {
+	fonts := make(map[string]*cmap)
+	num := page
+	for depth := 0; depth < 32; depth++ {
+		obj := d.objects[num]
+		if obj == nil {
+			break
+		}
+		if res := d.resolveDict(obj.dict, "/Resources"); res != "" {
+			if fontDict := d.resolveDict(res, "/Font"); fontDict != "" {
+				for _, m := range namedRefRe.FindAllStringSubmatch(fontDict, -1) {
+					if _, exists := fonts[m[1]]; exists {
+						continue
+					}
+					ref, _ := strconv.Atoi(m[2])
+					fonts[m[1]] = d.fontCMap(ref)
+				}
+				return fonts
+			}
+		}
+		parent, ok := dictRef(obj.dict, "/Parent")
+		if !ok {
+			break
+		}
+		num = parent
+	}
+	return fonts
+}
+
+// fontCMap 读取字体的 ToUnicode 映射
+func (d *document) fontCMap(ref int) *cmap {
+	font := d.objects[ref]
+	if font == nil {
+		return nil
+	}
+	if tu, ok := dictRef(font.dict, "/ToUnicode"); ok {
+		if obj := d.objects[tu]; obj != nil && obj.stream != nil {
+			return parseCMap(obj.stream)
+		}
+	}
+	// 无 ToUnicode 的 Identity-H 字体无法还原文本，按单字节处理
+	return nil
+}
+
+// resolveDict 取字典中 key 对应的子字典文本，支持内联与间接引用
+func (d *document) resolveDict(dict, key string) string {
+	idx := keyIndex(dict, key)
+	if idx < 0 {
+		return ""
+	}
+	rest := strings.TrimLeft(dict[idx+len(key):], " \r\n\t")
+	if strings.HasPrefix(rest, "<<") {
+		return balanced(rest, "<<", ">>")
+	}
+	if m := leadRefRe.FindStringSubmatch(rest); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if obj := d.objects[n]; obj != nil {
+			return obj.dict
+		}
+	}
+	return ""
+}
+
+// keyIndex 查找字典键（要求键名后不是名称字符，避免 /Font 匹配到 /FontDescriptor）
+func keyIndex(dict, key string) int {
+	from := 0
+	for {
+		i := strings.Index(dict[from:], key)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		end := i + len(key)
+		if end >= len(dict) || strings.ContainsRune(" \r\n\t<[/(", rune(dict[end])) {
+			return i
+		}
+		from = end
+	}
+}
+
+// balanced 截取从开头起与 open/close 配对的片段
+func balanced(s, open, close string) string {
+	depth := 0
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], open):
+			depth++
+			i += len(open)
+		case strings.HasPrefix(s[i:], close):
+			depth--
+			i += len(close)
+			if depth == 0 {
+				return s[:i]
+			}
+		default:
+			i++
+		}
+	}
+	return s
+}
+
+func dictInt(dict, key string) (int, bool) {
+	idx := keyIndex(dict, key)
+	if idx < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(dict[idx+len(key):])
+	if len(fields) == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(strings.TrimRight(fields[0], "/>]"))
+	return n, err == nil
+}
+
+func dictRef(dict, key string) (int, bool) {
+	idx := keyIndex(dict, key)
+	if idx < 0 {
+		return 0, false
+	}
+	m := leadRefRe.FindStringSubmatch(dict[idx+len(key):])
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n, true
+}
+
+func dictArray(dict, key string) (string, bool) {
+	idx := keyIndex(dict, key)
+	if idx < 0 {
+		return "", false
+	}
+	rest := strings.TrimLeft(dict[idx+len(key):], " \r\n\t")
+	if !strings.HasPrefix(rest, "[") {
+		return "", false
+	}
+	return balanced(rest, "[", "]"), true
+}
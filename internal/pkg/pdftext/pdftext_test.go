@@ -0,0 +1,102 @@
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func flate(s string) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	toUnicode := `/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar
+<0001> <516C>
+<0002> <544A>
+endbfchar
+1 beginbfrange
+<0003> <0004> <0031>
+endbfrange
+endcmap`
+	content := flate("BT /F1 12 Tf 72 720 Td <00010002> Tj 0 -20 Td [<0003> -100 <0004>] TJ ET\nBT /F2 10 Tf 1 0 0 1 72 600 Tm (Hello \\(PDF\\)) Tj ET")
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	obj := func(n int, body string, stream []byte) {
+		if stream == nil {
+			fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", n, body)
+			return
+		}
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nstream\n", n, body)
+		buf.Write(stream)
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+	obj(1, "<< /Type /Catalog /Pages 2 0 R >>", nil)
+	obj(2, "<< /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /Font << /F1 5 0 R /F2 7 0 R >> >> >>", nil)
+	obj(3, "<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>", nil)
+	obj(4, fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", len(content)), content)
+	obj(5, "<< /Type /Font /Subtype /Type0 /BaseFont /SimSun /ToUnicode 6 0 R >>", nil)
+	obj(6, fmt.Sprintf("<< /Length %d >>", len(toUnicode)), []byte(toUnicode))
+	obj(7, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>", nil)
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF")
+
+	text, err := Extract(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"公告", "12", "Hello (PDF)"}
+	lines := strings.Split(text, "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("text = %q", text)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("line %d = %q, want %q (text=%q)", i, lines[i], want[i], text)
+		}
+	}
+
+	if _, err := Extract([]byte("not a pdf")); err == nil {
+		t.Fatal("expected error for non-pdf input")
+	}
+}
+
+func TestParseObject_DecodeLimits(t *testing.T) {
+	stream := func(data []byte) []byte {
+		var body bytes.Buffer
+		fmt.Fprintf(&body, "<< /Length %d /Filter /FlateDecode >>\nstream\n", len(data))
+		body.Write(data)
+		body.WriteString("\nendstream\n")
+		return body.Bytes()
+	}
+
+	bomb := stream(flate(strings.Repeat("\x00", MaxStreamSize+1)))
+	budget := MaxDecodedSize
+	if obj := parseObject(bomb, &budget); obj.stream != nil {
+		t.Errorf("超过单流上限的流应丢弃，解压得到 %d 字节", len(obj.stream))
+	}
+	if budget != MaxDecodedSize {
+		t.Errorf("丢弃的流不应占用总额度，剩余 %d", budget)
+	}
+
+	small := stream(flate("BT (Hello) Tj ET"))
+	budget = 20
+	if obj := parseObject(small, &budget); string(obj.stream) != "BT (Hello) Tj ET" {
+		t.Fatalf("解压结果 = %q", obj.stream)
+	}
+	if budget != 4 {
+		t.Errorf("剩余额度 = %d，期望 4", budget)
+	}
+	if obj := parseObject(small, &budget); obj.stream != nil {
+		t.Errorf("总额度不足时应跳过后续流，解压得到 %q", obj.stream)
+	}
+}
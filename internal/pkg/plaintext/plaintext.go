@@ -0,0 +1,139 @@
+// Package plaintext 把 Markdown 报告转换为适合朗读（TTS）与纯文本通知渠道的干净文本
+// 表格按“列名：值”逐行展开，链接只保留文字，去除标题、列表、强调等标记
+package plaintext
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	codeFenceRe  = regexp.MustCompile("^\\s*(```|~~~)")
+	headingRe    = regexp.MustCompile(`^\s{0,3}#{1,6}\s*(.*?)\s*#*\s*$`)
+	ruleRe       = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	quoteRe      = regexp.MustCompile(`^\s*(>\s?)+`)
+	bulletRe     = regexp.MustCompile(`^\s*[-*+]\s+(\[[ xX]\]\s+)?`)
+	orderedRe    = regexp.MustCompile(`^\s*(\d+)[.)]\s+`)
+	tableSepRe   = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+	imageRe      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkRe       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	refLinkRe    = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	refDefRe     = regexp.MustCompile(`^\s*\[[^\]]+\]:\s+\S+`)
+	autoLinkRe   = regexp.MustCompile(`<https?://[^>]+>`)
+	bareURLRe    = regexp.MustCompile(`\(?https?://[^\s)\]>"'，。；]+\)?`)
+	htmlTagRe    = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	boldRe       = regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`)
+	italicRe     = regexp.MustCompile(`(^|[^\w*])[*_]([^*_\s][^*_]*?)[*_]($|[^\w*])`)
+	strikeRe     = regexp.MustCompile(`~~(.+?)~~`)
+	inlineCodeRe = regexp.MustCompile("`+([^`]*)`+")
+	spacesRe     = regexp.MustCompile(`[ \t]{2,}`)
+	punctSpaceRe = regexp.MustCompile(`[ \t]+([，。；：！？、）])`)
+	blankLinesRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// Render 把 Markdown 转换为纯文本
+func Render(markdown string) string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	inCode := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if codeFenceRe.MatchString(line) {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			// 代码块原样保留内容，只去掉围栏
+			out = append(out, line)
+			continue
+		}
+
+		// 表格：表头下一行为分隔行
+		if isTableRow(line) && i+1 < len(lines) && tableSepRe.MatchString(strings.TrimSpace(lines[i+1])) {
+			end := i + 2
+			for end < len(lines) && isTableRow(lines[end]) {
+				end++
+			}
+			out = append(out, flattenTable(lines[i], lines[i+2:end])...)
+			i = end - 1
+			continue
+		}
+
+		switch {
+		case ruleRe.MatchString(line), refDefRe.MatchString(line):
+			out = append(out, "")
+			continue
+		case headingRe.MatchString(line):
+			line = headingRe.ReplaceAllString(line, "$1")
+		default:
+			line = quoteRe.ReplaceAllString(line, "")
+			if m := orderedRe.FindStringSubmatch(line); m != nil {
+				line = m[1] + "、" + line[len(m[0]):]
+			} else {
+				line = bulletRe.ReplaceAllString(line, "")
+			}
+		}
+		out = append(out, Inline(line))
+	}
+	text := strings.Join(out, "\n")
+	return strings.TrimSpace(blankLinesRe.ReplaceAllString(text, "\n\n"))
+}
+
+// Inline 去除行内标记：图片、链接、网址、HTML 标签、强调与行内代码
+func Inline(s string) string {
+	s = imageRe.ReplaceAllString(s, "$1")
+	s = linkRe.ReplaceAllString(s, "$1")
+	s = refLinkRe.ReplaceAllString(s, "$1")
+	s = autoLinkRe.ReplaceAllString(s, "")
+	s = bareURLRe.ReplaceAllString(s, "")
+	s = htmlTagRe.ReplaceAllString(s, "")
+	s = inlineCodeRe.ReplaceAllString(s, "$1")
+	s = boldRe.ReplaceAllString(s, "$2")
+	s = strikeRe.ReplaceAllString(s, "$1")
+	s = italicRe.ReplaceAllString(s, "$1$2$3")
+	s = spacesRe.ReplaceAllString(s, " ")
+	// 去掉网址后残留在中文标点前的空格
+	s = punctSpaceRe.ReplaceAllString(s, "$1")
+	return strings.TrimRight(s, " \t")
+}
+
+// isTableRow 以 | 开头或包含至少两个 | 的行
+func isTableRow(line string) bool {
+	t := strings.TrimSpace(line)
+	return t != "" && (strings.HasPrefix(t, "|") || strings.Count(t, "|") >= 2)
+}
+
+// splitRow 拆分表格行的单元格
+func splitRow(line string) []string {
+	t := strings.TrimSpace(line)
+	t = strings.TrimSuffix(strings.TrimPrefix(t, "|"), "|")
+	cells := strings.Split(t, "|")
+	for i := range cells {
+		cells[i] = Inline(strings.TrimSpace(cells[i]))
+	}
+	return cells
+}
+
+// flattenTable 把表格每一行展开为“列名：值，列名：值。”
+func flattenTable(header string, rows []string) []string {
+	names := splitRow(header)
+	out := make([]string, 0, len(rows))
+	for _, row := range rows {
+		cells := splitRow(row)
+		var fields []string
+		for j, c := range cells {
+			if c == "" || c == "-" {
+				continue
+			}
+			if j < len(names) && names[j] != "" {
+				fields = append(fields, names[j]+"："+c)
+			} else {
+				fields = append(fields, c)
+			}
+		}
+		if len(fields) > 0 {
+			out = append(out, strings.Join(fields, "，")+"。")
+		}
+	}
+	return out
+}
@@ -0,0 +1,22 @@
+package plaintext
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	md := "## 结论 ##\n\n" +
+		"> **建议**：*持有* [贵州茅台](https://example.com/600519)，详见 https://example.com/a 。\n\n" +
+		"| 指标 | 数值 |\n|:---|---:|\n| `PE` | 25.3 |\n| 股息率 | - |\n\n" +
+		"---\n" +
+		"1. 关注 ~~分红~~ 公告\n- [x] 设置止损\n\n\n" +
+		"```json\n{\"a\": 1}\n```\n" +
+		"![K线](https://example.com/k.png)"
+	want := "结论\n\n" +
+		"建议：持有 贵州茅台，详见。\n\n" +
+		"指标：PE，数值：25.3。\n指标：股息率。\n\n" +
+		"1、关注 分红 公告\n设置止损\n\n" +
+		"{\"a\": 1}\n" +
+		"K线"
+	if got := Render(md); got != want {
+		t.Errorf("Render() =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,162 @@
+// Package postprocess 专家发言保存前的后处理流水线
+// 各阶段只处理代码块之外的文本，阶段可通过 Register 扩展
+package postprocess
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var log = logger.New("postprocess")
+
+// 内置阶段
+const (
+	StageMarkdown = "markdown" // Markdown 规范化：统一换行、去除行尾空白、合并多余空行、标题补空格
+	StageTables   = "tables"   // 表格列对齐
+	StageNumbers  = "numbers"  // 金额/数量添加千分位
+	StageLinks    = "links"    // 链接改写
+)
+
+// DefaultStages 未配置阶段时使用的默认流水线
+var DefaultStages = []string{StageMarkdown, StageTables, StageNumbers}
+
+// Processor 一个处理阶段：输入不含代码块的文本片段，返回处理后的文本
+type Processor func(text string, cfg *models.PostProcessConfig) string
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Processor{
+		StageMarkdown: normalizeMarkdown,
+		StageTables:   alignTables,
+		StageNumbers:  formatNumbers,
+		StageLinks:    rewriteLinks,
+	}
+)
+
+// Register 注册自定义处理阶段（同名覆盖）
+func Register(name string, p Processor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = p
+}
+
+// codeFenceRe 匹配 ``` 代码块（含未闭合的结尾代码块）
+var codeFenceRe = regexp.MustCompile("(?s)```.*?(```|$)")
+
+// Apply 按配置依次执行处理阶段，未启用时原样返回
+func Apply(text string, cfg *models.PostProcessConfig) string {
+	if cfg == nil || !cfg.Enabled || text == "" {
+		return text
+	}
+	// 结构化输出专家的整条发言为 JSON，不做处理
+	if t := strings.TrimSpace(text); (strings.HasPrefix(t, "{") || strings.HasPrefix(t, "[")) && json.Valid([]byte(t)) {
+		return text
+	}
+	stages := cfg.Stages
+	if len(stages) == 0 {
+		stages = DefaultStages
+	}
+	for _, name := range stages {
+		registryMu.RLock()
+		p, ok := registry[name]
+		registryMu.RUnlock()
+		if !ok {
+			log.Warn("未知的后处理阶段: %s", name)
+			continue
+		}
+		text = outsideCode(text, func(s string) string { return p(s, cfg) })
+	}
+	return text
+}
+
+// outsideCode 只对代码块之外的文本执行 fn
+func outsideCode(text string, fn func(string) string) string {
+	locs := codeFenceRe.FindAllStringIndex(text, -1)
+	if len(locs) == 0 {
+		return fn(text)
+	}
+	var sb strings.Builder
+	last := 0
+	for _, loc := range locs {
+		sb.WriteString(fn(text[last:loc[0]]))
+		sb.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(fn(text[last:]))
+	return sb.String()
+}
+
+var (
+	blankLinesRe  = regexp.MustCompile(`\n{3,}`)
+	headingNoSpRe = regexp.MustCompile(`(?m)^(#{1,6})([^#\s])`)
+)
+
+// normalizeMarkdown Markdown 规范化
+func normalizeMarkdown(text string, _ *models.PostProcessConfig) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	text = strings.Join(lines, "\n")
+	text = headingNoSpRe.ReplaceAllString(text, "$1 $2")
+	return blankLinesRe.ReplaceAllString(text, "\n\n")
+}
+
+// numberRe 匹配带单位的整数或 4 位以上整数部分的小数（不匹配股票代码、年份、日期）
+var numberRe = regexp.MustCompile(`(^|[^\w.\-/:,])(\d{4,})(\.\d+)?(\s*(?:元|股|手|万|亿|美元|港元)|)`)
+
+// formatNumbers 为金额、数量添加千分位
+func formatNumbers(text string, _ *models.PostProcessConfig) string {
+	return numberRe.ReplaceAllStringFunc(text, func(m string) string {
+		sub := numberRe.FindStringSubmatch(m)
+		prefix, intPart, frac, unit := sub[1], sub[2], sub[3], sub[4]
+		// 无单位的整数可能是代码、编号，不处理
+		if frac == "" && unit == "" {
+			return m
+		}
+		return prefix + groupThousands(intPart) + frac + unit
+	})
+}
+
+// groupThousands 整数部分每三位加逗号
+func groupThousands(digits string) string {
+	n := len(digits)
+	if n <= 3 {
+		return digits
+	}
+	var sb strings.Builder
+	head := n % 3
+	if head > 0 {
+		sb.WriteString(digits[:head])
+	}
+	for i := head; i < n; i += 3 {
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(digits[i : i+3])
+	}
+	return sb.String()
+}
+
+var urlRe = regexp.MustCompile(`https?://[^\s)\]>"'，。]+`)
+
+// rewriteLinks 按配置改写链接前缀
+func rewriteLinks(text string, cfg *models.PostProcessConfig) string {
+	if cfg == nil || len(cfg.LinkRewrites) == 0 {
+		return text
+	}
+	return urlRe.ReplaceAllStringFunc(text, func(u string) string {
+		for _, r := range cfg.LinkRewrites {
+			if r.From != "" && strings.HasPrefix(u, r.From) {
+				return r.To + strings.TrimPrefix(u, r.From)
+			}
+		}
+		return u
+	})
+}
@@ -0,0 +1,39 @@
+package postprocess
+
+import (
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestApply(t *testing.T) {
+	cfg := &models.PostProcessConfig{
+		Enabled:      true,
+		Stages:       []string{StageMarkdown, StageTables, StageNumbers, StageLinks},
+		LinkRewrites: []models.LinkRewrite{{From: "http://old.example.com", To: "https://new.example.com"}},
+	}
+	in := "##结论  \r\n\r\n\r\n\r\n营收 1234567.8 元，成交 250000股，代码 600519，2024年\n" +
+		"| 指标 | 值 |\n|---|--:|\n| 营收 | 12 |\n| 净利润 | 3 |\n" +
+		"```\nprice=1234567.8\n```\n详见 http://old.example.com/a"
+	want := "## 结论\n\n营收 1,234,567.8 元，成交 250,000股，代码 600519，2024年\n" +
+		"| 指标   |  值 |\n| ------ | --: |\n| 营收   |  12 |\n| 净利润 |   3 |\n" +
+		"```\nprice=1234567.8\n```\n详见 https://new.example.com/a"
+	if got := Apply(in, cfg); got != want {
+		t.Errorf("Apply =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := Apply(in, &models.PostProcessConfig{}); got != in {
+		t.Error("未启用时应原样返回")
+	}
+	if js := `{"targetPrice": 1800.50, "volume": 123456}`; Apply(js, cfg) != js {
+		t.Error("JSON 输出不应被处理")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("upper_test", func(s string, _ *models.PostProcessConfig) string { return s + "!" })
+	cfg := &models.PostProcessConfig{Enabled: true, Stages: []string{"upper_test", "missing"}}
+	if got := Apply("ok", cfg); got != "ok!" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,131 @@
+package postprocess
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var tableSepRe = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+
+// alignTables 对齐 Markdown 表格：按列宽补齐单元格（中文按两个字符宽度计算），保留对齐标记
+func alignTables(text string, _ *models.PostProcessConfig) string {
+	lines := strings.Split(text, "\n")
+	for i := 0; i+1 < len(lines); i++ {
+		header := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(header, "|") || !tableSepRe.MatchString(strings.TrimSpace(lines[i+1])) {
+			continue
+		}
+		end := i + 2
+		for end < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end]), "|") {
+			end++
+		}
+		copy(lines[i:end], formatTable(lines[i:end]))
+		i = end - 1
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatTable 格式化一个表格（第 2 行为分隔行）
+func formatTable(rows []string) []string {
+	cells := make([][]string, len(rows))
+	cols := 0
+	for i, r := range rows {
+		r = strings.TrimSpace(r)
+		r = strings.TrimSuffix(strings.TrimPrefix(r, "|"), "|")
+		parts := strings.Split(r, "|")
+		for j := range parts {
+			parts[j] = strings.TrimSpace(parts[j])
+		}
+		cells[i] = parts
+		cols = max(cols, len(parts))
+	}
+
+	// 对齐方式来自分隔行
+	aligns := make([]string, cols)
+	for j, c := range cells[1] {
+		left, right := strings.HasPrefix(c, ":"), strings.HasSuffix(c, ":")
+		switch {
+		case left && right:
+			aligns[j] = "center"
+		case right:
+			aligns[j] = "right"
+		case left:
+			aligns[j] = "left"
+		}
+	}
+
+	widths := make([]int, cols)
+	for i, row := range cells {
+		if i == 1 {
+			continue
+		}
+		for j, c := range row {
+			widths[j] = max(widths[j], displayWidth(c))
+		}
+	}
+	for j := range widths {
+		widths[j] = max(widths[j], 3)
+	}
+
+	out := make([]string, len(rows))
+	for i, row := range cells {
+		var sb strings.Builder
+		sb.WriteString("|")
+		for j := 0; j < cols; j++ {
+			c := ""
+			if j < len(row) {
+				c = row[j]
+			}
+			sb.WriteString(" ")
+			if i == 1 {
+				sb.WriteString(separatorCell(widths[j], aligns[j]))
+			} else {
+				sb.WriteString(padCell(c, widths[j], aligns[j]))
+			}
+			sb.WriteString(" |")
+		}
+		out[i] = sb.String()
+	}
+	return out
+}
+
+// separatorCell 生成指定宽度的分隔单元格
+func separatorCell(width int, align string) string {
+	switch align {
+	case "center":
+		return ":" + strings.Repeat("-", width-2) + ":"
+	case "right":
+		return strings.Repeat("-", width-1) + ":"
+	case "left":
+		return ":" + strings.Repeat("-", width-1)
+	}
+	return strings.Repeat("-", width)
+}
+
+// padCell 按对齐方式补齐单元格
+func padCell(c string, width int, align string) string {
+	pad := width - displayWidth(c)
+	switch align {
+	case "right":
+		return strings.Repeat(" ", pad) + c
+	case "center":
+		return strings.Repeat(" ", pad/2) + c + strings.Repeat(" ", pad-pad/2)
+	}
+	return c + strings.Repeat(" ", pad)
+}
+
+// displayWidth 等宽字体下的显示宽度：中日韩文字与全角符号按 2 计算
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) || (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFF60) {
+			w += 2
+		} else {
+			w++
+		}
+	}
+	return w
+}
@@ -0,0 +1,292 @@
+// Package redact 在请求发往云端模型前脱敏敏感信息
+// 敏感值替换为 [[规则名_序号]] 占位符，模型输出中的占位符在本地还原
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var log = logger.New("redact")
+
+// 内置规则名
+const (
+	RuleIDCard   = "id_card"   // 居民身份证号（校验位验证）
+	RuleBankCard = "bank_card" // 银行卡号（Luhn 校验）
+	RulePhone    = "phone"     // 手机号
+	RuleEmail    = "email"     // 邮箱
+)
+
+// rule 一条脱敏规则
+type rule struct {
+	name     string
+	re       *regexp.Regexp
+	validate func(string) bool // 可选：二次校验，减少误伤
+	numeric  bool              // 纯数字规则，不用于工具结果（行情数据中的成交额、市值等长数字会被误伤）
+}
+
+// builtinRules 内置规则（按顺序匹配，身份证需先于银行卡）
+var builtinRules = []rule{
+	{name: RuleIDCard, re: regexp.MustCompile(`\b\d{17}[\dXx]\b`), validate: validIDCard, numeric: true},
+	{name: RuleBankCard, re: regexp.MustCompile(`\b\d{16,19}\b`), validate: validLuhn, numeric: true},
+	{name: RulePhone, re: regexp.MustCompile(`\b1[3-9]\d{9}\b`), numeric: true},
+	{name: RuleEmail, re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+}
+
+// placeholderRe 匹配占位符
+var placeholderRe = regexp.MustCompile(`\[\[[A-Z0-9_]+_\d+\]\]`)
+
+// Manager 脱敏配置管理器（单例）
+type Manager struct {
+	mu    sync.RWMutex
+	rules []rule
+}
+
+var (
+	instance *Manager
+	once     sync.Once
+)
+
+// GetManager 获取脱敏管理器单例
+func GetManager() *Manager {
+	once.Do(func() {
+		instance = &Manager{}
+	})
+	return instance
+}
+
+// SetConfig 更新脱敏配置，无效的自定义正则会被跳过
+func (m *Manager) SetConfig(cfg *models.RedactionConfig) {
+	var rules []rule
+	if cfg != nil && cfg.Enabled {
+		for _, r := range builtinRules {
+			if len(cfg.Rules) == 0 || containsFold(cfg.Rules, r.name) {
+				rules = append(rules, r)
+			}
+		}
+		for _, p := range cfg.Patterns {
+			re, err := regexp.Compile(p.Pattern)
+			if err != nil {
+				log.Warn("脱敏规则 %s 正则无效: %v", p.Name, err)
+				continue
+			}
+			rules = append(rules, rule{name: ruleName(p.Name), re: re})
+		}
+	}
+
+	m.mu.Lock()
+	m.rules = rules
+	m.mu.Unlock()
+}
+
+// Enabled 是否启用了任何脱敏规则
+func (m *Manager) Enabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.rules) > 0
+}
+
+// NewRedactor 创建一个脱敏会话，同一会话内相同的值映射到相同的占位符
+func (m *Manager) NewRedactor() *Redactor {
+	m.mu.RLock()
+	rules := m.rules
+	m.mu.RUnlock()
+	return &Redactor{
+		rules:    rules,
+		byValue:  make(map[string]string),
+		byHolder: make(map[string]string),
+		counters: make(map[string]int),
+	}
+}
+
+// Redactor 脱敏会话，保存占位符与原值的双向映射
+type Redactor struct {
+	rules    []rule
+	mu       sync.Mutex
+	byValue  map[string]string
+	byHolder map[string]string
+	counters map[string]int
+}
+
+// Redact 替换文本中的敏感值
+func (r *Redactor) Redact(text string) string {
+	return r.redact(text, false)
+}
+
+// redact 替换文本中的敏感值，skipNumeric 时跳过内置的纯数字规则
+func (r *Redactor) redact(text string, skipNumeric bool) string {
+	if r == nil || text == "" {
+		return text
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rl := range r.rules {
+		if skipNumeric && rl.numeric {
+			continue
+		}
+		text = rl.re.ReplaceAllStringFunc(text, func(value string) string {
+			if rl.validate != nil && !rl.validate(value) {
+				return value
+			}
+			return r.placeholder(rl.name, value)
+		})
+	}
+	return text
+}
+
+// placeholder 获取或分配占位符（调用方需持有锁）
+func (r *Redactor) placeholder(name, value string) string {
+	if holder, ok := r.byValue[value]; ok {
+		return holder
+	}
+	r.counters[name]++
+	holder := fmt.Sprintf("[[%s_%d]]", strings.ToUpper(name), r.counters[name])
+	r.byValue[value] = holder
+	r.byHolder[holder] = value
+	return holder
+}
+
+// Restore 将文本中的占位符还原为原值，未知占位符原样保留
+func (r *Redactor) Restore(text string) string {
+	if r == nil || !strings.Contains(text, "[[") {
+		return text
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return placeholderRe.ReplaceAllStringFunc(text, func(holder string) string {
+		if value, ok := r.byHolder[holder]; ok {
+			return value
+		}
+		return holder
+	})
+}
+
+// RedactValue 递归脱敏 JSON 值中的字符串（函数参数）
+func (r *Redactor) RedactValue(v any) any {
+	return walk(v, r.Redact)
+}
+
+// RedactToolResult 递归脱敏工具结果，跳过内置的纯数字规则（手机号、身份证、银行卡），
+// 避免行情数据中的长数字被替换成占位符；邮箱与自定义规则照常生效
+func (r *Redactor) RedactToolResult(v any) any {
+	return walk(v, func(text string) string { return r.redact(text, true) })
+}
+
+// RestoreValue 递归还原 JSON 值中的占位符
+func (r *Redactor) RestoreValue(v any) any {
+	return walk(v, r.Restore)
+}
+
+// walk 对 map/slice 中的字符串应用 fn，返回新值，不修改原值
+func walk(v any, fn func(string) string) any {
+	switch val := v.(type) {
+	case string:
+		return fn(val)
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = walk(item, fn)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = walk(item, fn)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// StreamRestorer 流式输出的占位符还原
+// 占位符可能被拆分到多个片段中，未闭合的 "[[" 之后的内容暂缓输出
+type StreamRestorer struct {
+	r       *Redactor
+	pending string
+}
+
+// NewStreamRestorer 创建流式还原器
+func (r *Redactor) NewStreamRestorer() *StreamRestorer {
+	return &StreamRestorer{r: r}
+}
+
+// Write 写入一个片段，返回可以安全输出的还原文本
+func (s *StreamRestorer) Write(chunk string) string {
+	text := s.pending + chunk
+	s.pending = ""
+	if idx := strings.LastIndex(text, "[["); idx >= 0 && !strings.Contains(text[idx:], "]]") {
+		s.pending = text[idx:]
+		text = text[:idx]
+	} else if strings.HasSuffix(text, "[") {
+		s.pending = "["
+		text = text[:len(text)-1]
+	}
+	return s.r.Restore(text)
+}
+
+// Flush 输出剩余暂缓的内容
+func (s *StreamRestorer) Flush() string {
+	text := s.pending
+	s.pending = ""
+	return s.r.Restore(text)
+}
+
+// ruleName 规范化自定义规则名，用于占位符
+func ruleName(name string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToUpper(name) {
+		if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			sb.WriteRune(c)
+		}
+	}
+	if sb.Len() == 0 {
+		return "CUSTOM"
+	}
+	return sb.String()
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// validIDCard 校验 18 位身份证校验码
+func validIDCard(id string) bool {
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	const checkCodes = "10X98765432"
+	sum := 0
+	for i := 0; i < 17; i++ {
+		sum += int(id[i]-'0') * weights[i]
+	}
+	return checkCodes[sum%11] == strings.ToUpper(id[17:])[0]
+}
+
+// validLuhn Luhn 校验（银行卡号）
+func validLuhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
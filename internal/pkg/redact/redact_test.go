@@ -0,0 +1,71 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestRedactor_RoundTrip(t *testing.T) {
+	m := &Manager{}
+	m.SetConfig(&models.RedactionConfig{
+		Enabled:  true,
+		Patterns: []models.RedactionPattern{{Name: "account", Pattern: `A\d{10}`}},
+	})
+	r := m.NewRedactor()
+
+	// 11010519491231002X 为校验位正确的示例身份证号，6222021234567890128 满足 Luhn
+	input := "身份证 11010519491231002X，卡号 6222021234567890128，股东账户 A1234567890，电话 13800138000，股票 600519"
+	redacted := r.Redact(input)
+	for _, secret := range []string{"11010519491231002X", "6222021234567890128", "A1234567890", "13800138000"} {
+		if strings.Contains(redacted, secret) {
+			t.Errorf("secret %q not redacted: %s", secret, redacted)
+		}
+	}
+	if !strings.Contains(redacted, "600519") || !strings.Contains(redacted, "[[ID_CARD_1]]") {
+		t.Errorf("unexpected redaction: %s", redacted)
+	}
+	if got := r.Redact("再次提到 13800138000"); got != "再次提到 [[PHONE_1]]" {
+		t.Errorf("placeholder not stable: %s", got)
+	}
+	if got := r.Restore(redacted); got != input {
+		t.Errorf("restore mismatch:\n got %s\nwant %s", got, input)
+	}
+
+	// 占位符被拆分到多个流式片段
+	s := r.NewStreamRestorer()
+	out := s.Write("联系 [[PHO") + s.Write("NE_1]] 确认") + s.Flush()
+	if out != "联系 13800138000 确认" {
+		t.Errorf("stream restore = %q", out)
+	}
+}
+
+func TestRedactor_ToolResultKeepsQuoteNumbers(t *testing.T) {
+	m := &Manager{}
+	m.SetConfig(&models.RedactionConfig{
+		Enabled:  true,
+		Patterns: []models.RedactionPattern{{Name: "account", Pattern: `A\d{10}`}},
+	})
+	r := m.NewRedactor()
+
+	quote := map[string]any{
+		"summary": "成交额 15234567890，成交量 13800138000 股，总市值 6222021234567890128",
+		"rows":    []any{"15234567890", map[string]any{"amount": "18612345678.00"}},
+		"price":   1688.5,
+	}
+	got := r.RedactToolResult(quote).(map[string]any)
+	if got["summary"] != quote["summary"] {
+		t.Errorf("quote text changed: %v", got["summary"])
+	}
+	rows := got["rows"].([]any)
+	if rows[0] != "15234567890" || rows[1].(map[string]any)["amount"] != "18612345678.00" {
+		t.Errorf("quote rows changed: %v", rows)
+	}
+
+	// 邮箱与自定义规则仍对工具结果生效
+	res := r.RedactToolResult(map[string]any{"text": "联系 ir@example.com，账户 A1234567890"}).(map[string]any)
+	if text := res["text"].(string); strings.Contains(text, "ir@example.com") || strings.Contains(text, "A1234567890") {
+		t.Errorf("tool result not redacted: %s", text)
+	}
+}
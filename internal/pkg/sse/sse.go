@@ -0,0 +1,148 @@
+// Package sse 解析 Server-Sent Events 流，供各供应商适配层共用
+// 遵循 WHATWG 规范：支持 CRLF/LF/CR 换行、注释行、多行 data、事件 ID 与 retry 字段
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxLineSize 单行最大长度（超长工具参数可能在一行 data 中）
+const DefaultMaxLineSize = 4 << 20
+
+// DoneMarker OpenAI 兼容接口的流结束标记
+const DoneMarker = "[DONE]"
+
+// Event 一个完整的 SSE 事件
+type Event struct {
+	ID    string        // 最近一次的事件 ID（跨事件保留）
+	Event string        // 事件类型，未声明时为空
+	Data  string        // 多行 data 以 \n 拼接
+	Retry time.Duration // 服务端建议的重连间隔，未声明时为 0
+}
+
+// Done 是否为 [DONE] 结束标记
+func (e *Event) Done() bool {
+	return strings.TrimSpace(e.Data) == DoneMarker
+}
+
+// Type 返回事件类型：优先取 event 行，缺失时回退到 JSON data 的 type 字段
+// 部分 OpenAI 兼容网关只发送 data 行（Responses/Anthropic 的事件 JSON 自带 type）
+func (e *Event) Type() string {
+	if e.Event != "" {
+		return e.Event
+	}
+	var payload struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal([]byte(e.Data), &payload) == nil {
+		return payload.Type
+	}
+	return ""
+}
+
+// Reader 从字节流中逐个读取 SSE 事件
+type Reader struct {
+	scanner *bufio.Scanner
+	lastID  string
+}
+
+// NewReader 创建 SSE 读取器，maxLineSize <= 0 时使用 DefaultMaxLineSize
+func NewReader(r io.Reader, maxLineSize int) *Reader {
+	if maxLineSize <= 0 {
+		maxLineSize = DefaultMaxLineSize
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(64*1024, maxLineSize)), maxLineSize)
+	scanner.Split(scanLines)
+	return &Reader{scanner: scanner}
+}
+
+// Next 读取下一个事件，流结束时返回 io.EOF
+// 只含注释或空 data 的事件会被跳过；流末尾缺少空行的事件仍会返回（部分网关不发送结尾空行）
+func (r *Reader) Next() (*Event, error) {
+	var ev Event
+	var data []string
+	hasData := false
+
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if hasData {
+				ev.ID = r.lastID
+				ev.Data = strings.Join(data, "\n")
+				return &ev, nil
+			}
+			ev = Event{}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // 注释（常用作心跳）
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Event = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				r.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				ev.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("SSE 行超过长度上限: %w", err)
+		}
+		return nil, err
+	}
+	if hasData {
+		ev.ID = r.lastID
+		ev.Data = strings.Join(data, "\n")
+		return &ev, nil
+	}
+	return nil, io.EOF
+}
+
+// scanLines 按 CRLF、LF 或单独的 CR 切分行
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// CR：需要看下一个字节是否为 LF
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
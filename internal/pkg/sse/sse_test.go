@@ -0,0 +1,48 @@
+package sse
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReader(t *testing.T) {
+	stream := ": ping\r\n" +
+		"event: message_start\r\nid: 1\r\nretry: 3000\r\ndata: {\"a\":\r\ndata: 1}\r\n\r\n" +
+		"data: second\r\r" + // 单独的 CR 换行
+		"event: empty\n\n" + // 无 data 的事件被跳过
+		"data:" + DoneMarker // 结尾缺少空行
+
+	r := NewReader(strings.NewReader(stream), 0)
+
+	ev, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Event != "message_start" || ev.ID != "1" || ev.Data != "{\"a\":\n1}" || ev.Retry != 3*time.Second {
+		t.Fatalf("first event = %#v", ev)
+	}
+
+	ev, err = r.Next()
+	if err != nil || ev.Data != "second" || ev.Event != "" || ev.ID != "1" {
+		t.Fatalf("second event = %#v, err = %v", ev, err)
+	}
+
+	ev, err = r.Next()
+	if err != nil || !ev.Done() {
+		t.Fatalf("done event = %#v, err = %v", ev, err)
+	}
+
+	if _, err = r.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("want EOF, got %v", err)
+	}
+}
+
+func TestReader_LineTooLong(t *testing.T) {
+	r := NewReader(strings.NewReader("data: "+strings.Repeat("x", 100)+"\n\n"), 32)
+	if _, err := r.Next(); err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("want too long error, got %v", err)
+	}
+}
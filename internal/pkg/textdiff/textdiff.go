@@ -0,0 +1,76 @@
+// Package textdiff 逐行文本差异（最长公共子序列），用于对比模型回答
+package textdiff
+
+import (
+	"strings"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// maxLines 单侧参与对比的最大行数，超出部分按整段差异输出，避免 O(n*m) 内存过大
+const maxLines = 2000
+
+// Lines 计算 a → b 的逐行差异
+func Lines(a, b string) []models.DiffLine {
+	al, bl := splitLines(a), splitLines(b)
+	var tailA, tailB []string
+	if len(al) > maxLines {
+		al, tailA = al[:maxLines], al[maxLines:]
+	}
+	if len(bl) > maxLines {
+		bl, tailB = bl[:maxLines], bl[maxLines:]
+	}
+
+	// lcs[i][j] 为 al[i:] 与 bl[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []models.DiffLine
+	i, j := 0, 0
+	for i < len(al) && j < len(bl) {
+		switch {
+		case al[i] == bl[j]:
+			out = append(out, models.DiffLine{Op: " ", Text: al[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, models.DiffLine{Op: "-", Text: al[i]})
+			i++
+		default:
+			out = append(out, models.DiffLine{Op: "+", Text: bl[j]})
+			j++
+		}
+	}
+	for ; i < len(al); i++ {
+		out = append(out, models.DiffLine{Op: "-", Text: al[i]})
+	}
+	for ; j < len(bl); j++ {
+		out = append(out, models.DiffLine{Op: "+", Text: bl[j]})
+	}
+	for _, l := range tailA {
+		out = append(out, models.DiffLine{Op: "-", Text: l})
+	}
+	for _, l := range tailB {
+		out = append(out, models.DiffLine{Op: "+", Text: l})
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,24 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	a := "结论：看多\n目标价 1800\n风险：估值偏高"
+	b := "结论：中性\n目标价 1800\n风险：估值偏高\n建议观望"
+
+	var got []string
+	for _, l := range Lines(a, b) {
+		got = append(got, l.Op+l.Text)
+	}
+	want := []string{"-结论：看多", "+结论：中性", " 目标价 1800", " 风险：估值偏高", "+建议观望"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("diff = %q, want %q", got, want)
+	}
+
+	if d := Lines("", ""); len(d) != 0 {
+		t.Errorf("empty diff = %v", d)
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/pkg/proxy"
+)
+
+const eastmoneyAnnouncementAPI = "https://np-anotice-stock.eastmoney.com/api/security/ann"
+
+// Announcement 上市公司公告
+type Announcement struct {
+	ArtCode    string `json:"artCode"`    // 公告唯一标识
+	Title      string `json:"title"`      // 公告标题
+	NoticeDate string `json:"noticeDate"` // 公告日期
+	Category   string `json:"category"`   // 公告类型
+	PDFUrl     string `json:"pdfUrl"`     // PDF 下载链接
+}
+
+// announcementResponse 东方财富公告接口响应
+type announcementResponse struct {
+	Data struct {
+		List []struct {
+			ArtCode    string `json:"art_code"`
+			Title      string `json:"title"`
+			NoticeDate string `json:"notice_date"`
+			Columns    []struct {
+				ColumnName string `json:"column_name"`
+			} `json:"columns"`
+		} `json:"list"`
+	} `json:"data"`
+}
+
+// AnnouncementService 公告服务
+type AnnouncementService struct {
+	client *http.Client
+}
+
+// NewAnnouncementService 创建公告服务
+func NewAnnouncementService() *AnnouncementService {
+	return &AnnouncementService{
+		client: proxy.GetManager().GetClientWithTimeout(15 * time.Second),
+	}
+}
+
+// GetAnnouncements 获取个股最新公告
+// stockCode: 股票代码（支持带 sh/sz 前缀）
+func (s *AnnouncementService) GetAnnouncements(stockCode string, pageSize int) ([]Announcement, error) {
+	code := strings.TrimPrefix(strings.TrimPrefix(stockCode, "sz"), "sh")
+	url := fmt.Sprintf("%s?sr=-1&page_size=%d&page_index=1&ann_type=A&client_source=web&f_node=0&s_node=0&stock_list=%s",
+		eastmoneyAnnouncementAPI, pageSize, code)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("Referer", "https://data.eastmoney.com/")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	var result announcementResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	list := make([]Announcement, 0, len(result.Data.List))
+	for _, item := range result.Data.List {
+		a := Announcement{
+			ArtCode:    item.ArtCode,
+			Title:      item.Title,
+			NoticeDate: strings.TrimSpace(strings.Split(item.NoticeDate, " ")[0]),
+			PDFUrl:     announcementPDFUrl(item.ArtCode),
+		}
+		if len(item.Columns) > 0 {
+			a.Category = item.Columns[0].ColumnName
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+// announcementPDFUrl 根据公告标识生成 PDF 下载链接
+func announcementPDFUrl(artCode string) string {
+	if artCode == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://pdf.dfcfw.com/pdf/H2_%s_1.pdf", artCode)
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var artifactLog = logger.New("artifacts")
+
+// maxArtifactsPerStock 每只股票最多保留的产物数（超出时删除最早的）
+const maxArtifactsPerStock = 200
+
+var (
+	tableSepRe   = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?$`)
+	jsonFenceRe  = regexp.MustCompile("(?s)```json\\s*\\n(.*?)\\n```")
+	headingStrip = strings.NewReplacer("#", "", "*", "", "：", "", ":", "")
+)
+
+// ArtifactService 分析产物存储：表格、图表、结构化建议、生成文件，按股票持久化
+type ArtifactService struct {
+	dir   string
+	cache map[string][]models.Artifact
+	mu    sync.Mutex
+}
+
+// NewArtifactService 创建分析产物服务
+func NewArtifactService(dataDir string) *ArtifactService {
+	s := &ArtifactService{
+		dir:   filepath.Join(dataDir, "artifacts"),
+		cache: make(map[string][]models.Artifact),
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		artifactLog.Error("创建artifacts目录失败: %v", err)
+	}
+	return s
+}
+
+func (s *ArtifactService) path(stockCode string) string {
+	return filepath.Join(s.dir, stockCode+".json")
+}
+
+// loadNoLock 读取个股产物（优先缓存）
+func (s *ArtifactService) loadNoLock(stockCode string) []models.Artifact {
+	if list, ok := s.cache[stockCode]; ok {
+		return list
+	}
+	var list []models.Artifact
+	if data, err := os.ReadFile(s.path(stockCode)); err == nil {
+		if err := json.Unmarshal(data, &list); err != nil {
+			artifactLog.Error("解析分析产物失败 [%s]: %v", stockCode, err)
+		}
+	}
+	s.cache[stockCode] = list
+	return list
+}
+
+// saveNoLock 保存个股产物
+func (s *ArtifactService) saveNoLock(stockCode string, list []models.Artifact) error {
+	if len(list) > maxArtifactsPerStock {
+		list = list[len(list)-maxArtifactsPerStock:]
+	}
+	s.cache[stockCode] = list
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(stockCode), data, 0644)
+}
+
+// validateArtifact 校验产物内容与类型是否匹配
+func validateArtifact(a *models.Artifact) error {
+	if a.StockCode == "" {
+		return fmt.Errorf("股票代码不能为空")
+	}
+	switch a.Kind {
+	case models.ArtifactKindTable:
+		if a.Table == nil || len(a.Table.Columns) == 0 {
+			return fmt.Errorf("表格缺少列定义")
+		}
+	case models.ArtifactKindChart, models.ArtifactKindJSON:
+		if !json.Valid(a.Data) {
+			return fmt.Errorf("产物数据不是合法的 JSON")
+		}
+	case models.ArtifactKindFile:
+		if a.FileName == "" {
+			return fmt.Errorf("文件产物缺少文件名")
+		}
+	default:
+		return fmt.Errorf("未知的产物类型: %s", a.Kind)
+	}
+	return nil
+}
+
+// Add 保存产物，返回带 ID 的产物
+func (s *ArtifactService) Add(artifacts ...models.Artifact) ([]models.Artifact, error) {
+	if len(artifacts) == 0 {
+		return nil, nil
+	}
+	now := time.Now().UnixMilli()
+	for i := range artifacts {
+		if err := validateArtifact(&artifacts[i]); err != nil {
+			return nil, err
+		}
+		if artifacts[i].ID == "" {
+			artifacts[i].ID = uuid.New().String()
+		}
+		if artifacts[i].CreatedAt == 0 {
+			artifacts[i].CreatedAt = now
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 按股票分组写入
+	byStock := make(map[string][]models.Artifact)
+	for _, a := range artifacts {
+		byStock[a.StockCode] = append(byStock[a.StockCode], a)
+	}
+	for code, added := range byStock {
+		list := append(append([]models.Artifact(nil), s.loadNoLock(code)...), added...)
+		if err := s.saveNoLock(code, list); err != nil {
+			return nil, err
+		}
+	}
+	return artifacts, nil
+}
+
+// List 列出个股产物（新的在前），messageID 非空时只返回该消息的产物
+func (s *ArtifactService) List(stockCode, messageID string) []models.Artifact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := []models.Artifact{}
+	for _, a := range s.loadNoLock(stockCode) {
+		if messageID == "" || a.MessageID == messageID {
+			result = append(result, a)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CreatedAt > result[j].CreatedAt })
+	return result
+}
+
+// Get 获取单个产物
+func (s *ArtifactService) Get(stockCode, id string) (models.Artifact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.loadNoLock(stockCode) {
+		if a.ID == id {
+			return a, nil
+		}
+	}
+	return models.Artifact{}, fmt.Errorf("产物不存在: %s", id)
+}
+
+// Delete 删除单个产物
+func (s *ArtifactService) Delete(stockCode, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := s.loadNoLock(stockCode)
+	for i, a := range list {
+		if a.ID == id {
+			rest := append(append([]models.Artifact(nil), list[:i]...), list[i+1:]...)
+			return s.saveNoLock(stockCode, rest)
+		}
+	}
+	return fmt.Errorf("产物不存在: %s", id)
+}
+
+// Export 导出产物：表格为 CSV，图表与结构化数据为格式化 JSON，文件原样导出
+func (s *ArtifactService) Export(stockCode, id string) (models.ArtifactExport, error) {
+	a, err := s.Get(stockCode, id)
+	if err != nil {
+		return models.ArtifactExport{}, err
+	}
+	name := exportBaseName(a)
+	switch a.Kind {
+	case models.ArtifactKindTable:
+		var buf bytes.Buffer
+		buf.WriteString("\ufeff") // BOM，便于 Excel 正确识别中文
+		w := csv.NewWriter(&buf)
+		_ = w.Write(a.Table.Columns)
+		_ = w.WriteAll(a.Table.Rows)
+		if err := w.Error(); err != nil {
+			return models.ArtifactExport{}, err
+		}
+		return models.ArtifactExport{FileName: name + ".csv", MimeType: "text/csv", Content: buf.String()}, nil
+	case models.ArtifactKindChart, models.ArtifactKindJSON:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, a.Data, "", "  "); err != nil {
+			return models.ArtifactExport{}, err
+		}
+		return models.ArtifactExport{FileName: name + ".json", MimeType: "application/json", Content: buf.String()}, nil
+	default:
+		mimeType := a.MimeType
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		return models.ArtifactExport{FileName: a.FileName, MimeType: mimeType, Content: a.Content, Base64: a.Base64}, nil
+	}
+}
+
+// exportBaseName 导出文件名：股票代码_标题（去掉文件名非法字符）
+func exportBaseName(a models.Artifact) string {
+	title := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\/:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, a.Title)
+	if title == "" {
+		title = a.Kind
+	}
+	return a.StockCode + "_" + title
+}
+
+// ExtractArtifacts 从专家发言中提取 Markdown 表格与 JSON 结构化输出
+// 整条发言为 JSON（结构化输出专家）或包含 ```json 代码块时提取为 json 产物
+func ExtractArtifacts(stockCode string, msg models.ChatMessage) []models.Artifact {
+	base := models.Artifact{
+		StockCode: stockCode,
+		MessageID: msg.ID,
+		AgentID:   msg.AgentID,
+		AgentName: msg.AgentName,
+	}
+	if msg.Attribution != nil {
+		base.Model = msg.Attribution.Model
+	}
+	var result []models.Artifact
+
+	content := strings.TrimSpace(msg.Content)
+	if (strings.HasPrefix(content, "{") || strings.HasPrefix(content, "[")) && json.Valid([]byte(content)) {
+		a := base
+		a.Kind = models.ArtifactKindJSON
+		a.Title = msg.AgentName + " 结构化输出"
+		a.Data = json.RawMessage(content)
+		return append(result, a)
+	}
+
+	for i, m := range jsonFenceRe.FindAllStringSubmatch(content, -1) {
+		block := strings.TrimSpace(m[1])
+		if !json.Valid([]byte(block)) {
+			continue
+		}
+		a := base
+		a.Kind = models.ArtifactKindJSON
+		a.Title = fmt.Sprintf("%s 数据 %d", msg.AgentName, i+1)
+		a.Data = json.RawMessage(block)
+		result = append(result, a)
+	}
+
+	for i, t := range parseMarkdownTables(content) {
+		a := base
+		a.Kind = models.ArtifactKindTable
+		a.Title = t.title
+		if a.Title == "" {
+			a.Title = fmt.Sprintf("%s 表格 %d", msg.AgentName, i+1)
+		}
+		a.Table = &models.ArtifactTable{Columns: t.columns, Rows: t.rows}
+		result = append(result, a)
+	}
+	return result
+}
+
+// markdownTable 解析出的 Markdown 表格
+type markdownTable struct {
+	title   string // 表格上方最近的标题行
+	columns []string
+	rows    [][]string
+}
+
+// parseMarkdownTables 解析文本中的 Markdown 表格（表头 + 分隔行 + 数据行）
+func parseMarkdownTables(text string) []markdownTable {
+	lines := strings.Split(text, "\n")
+	var tables []markdownTable
+	title := ""
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "|") || i+1 >= len(lines) || !tableSepRe.MatchString(strings.TrimSpace(lines[i+1])) {
+			if line != "" && !strings.HasPrefix(line, "|") {
+				title = line
+			}
+			continue
+		}
+		t := markdownTable{title: tableTitle(title), columns: splitTableRow(line)}
+		i += 2
+		for ; i < len(lines); i++ {
+			row := strings.TrimSpace(lines[i])
+			if !strings.HasPrefix(row, "|") {
+				break
+			}
+			cells := splitTableRow(row)
+			// 补齐或截断到列数
+			cells = append(cells, make([]string, max(0, len(t.columns)-len(cells)))...)
+			t.rows = append(t.rows, cells[:len(t.columns)])
+		}
+		if t.rows == nil {
+			t.rows = [][]string{}
+		}
+		tables = append(tables, t)
+		title = ""
+	}
+	return tables
+}
+
+// splitTableRow 拆分表格行单元格
+func splitTableRow(line string) []string {
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	cells := strings.Split(line, "|")
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(c)
+	}
+	return cells
+}
+
+// tableTitle 取标题行作为表格标题：只接受 Markdown 标题或较短的说明行
+func tableTitle(line string) string {
+	isHeading := strings.HasPrefix(line, "#") || strings.HasPrefix(line, "**")
+	title := strings.TrimSpace(headingStrip.Replace(line))
+	if !isHeading && len([]rune(title)) > 30 {
+		return ""
+	}
+	if r := []rune(title); len(r) > 40 {
+		title = string(r[:40])
+	}
+	return title
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestArtifactService_ExtractAndExport(t *testing.T) {
+	msg := models.ChatMessage{
+		ID:        "msg-1",
+		AgentID:   "tech",
+		AgentName: "技术分析师",
+		Content: "### 关键价位\n" +
+			"| 价位 | 说明 |\n|---|:---:|\n| 1680 | 支撑 |\n| 1750 | 压力, 前高 |\n\n" +
+			"操作建议：\n```json\n{\"action\":\"hold\",\"stopLoss\":1650}\n```",
+	}
+	extracted := ExtractArtifacts("sh600519", msg)
+	if len(extracted) != 2 {
+		t.Fatalf("extracted %d artifacts, want 2: %#v", len(extracted), extracted)
+	}
+
+	s := NewArtifactService(t.TempDir())
+	if _, err := s.Add(extracted...); err != nil {
+		t.Fatal(err)
+	}
+	list := s.List("sh600519", "msg-1")
+	if len(list) != 2 {
+		t.Fatalf("listed %d artifacts", len(list))
+	}
+
+	var table models.Artifact
+	for _, a := range list {
+		if a.Kind == models.ArtifactKindTable {
+			table = a
+		}
+	}
+	if table.Title != "关键价位" || len(table.Table.Rows) != 2 {
+		t.Fatalf("table = %#v", table)
+	}
+
+	export, err := s.Export("sh600519", table.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.FileName != "sh600519_关键价位.csv" || !strings.Contains(export.Content, "1750,\"压力, 前高\"") {
+		t.Errorf("export = %#v", export)
+	}
+
+	// 整条 JSON 发言（结构化输出专家）作为一个 json 产物
+	msg.Content = `{"signal":"buy"}`
+	if got := ExtractArtifacts("sh600519", msg); len(got) != 1 || got[0].Kind != models.ArtifactKindJSON {
+		t.Errorf("structured output artifacts = %#v", got)
+	}
+
+	if _, err := s.Add(models.Artifact{StockCode: "sh600519", Kind: models.ArtifactKindChart, Data: []byte("{")}); err == nil {
+		t.Error("expected invalid chart data error")
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// batchKeepCount 最多保留的批量任务记录数
+const batchKeepCount = 200
+
+// DefaultBatchQuery 未配置分析要求时使用的盘后复盘要求
+const DefaultBatchQuery = "请对今日走势做盘后复盘：概括量价表现、关键支撑与压力位，并给出明日操作建议。"
+
+// BatchService 批量分析任务记录：提交、状态更新与结果写回由调用方完成，本服务只负责持久化
+type BatchService struct {
+	mu   sync.Mutex
+	path string
+	jobs []models.BatchJob
+}
+
+// NewBatchService 创建批量任务服务
+func NewBatchService(dataDir string) *BatchService {
+	s := &BatchService{path: filepath.Join(dataDir, "batches.json")}
+	if data, err := os.ReadFile(s.path); err == nil {
+		if err := json.Unmarshal(data, &s.jobs); err != nil {
+			log.Warn("加载批量任务失败: %v", err)
+		}
+	}
+	return s
+}
+
+// Create 新建任务记录
+func (s *BatchService) Create(job models.BatchJob) (models.BatchJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UnixMilli()
+	job.ID = uuid.New().String()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	job.Total = len(job.StockCodes)
+	s.jobs = append(s.jobs, job)
+	if len(s.jobs) > batchKeepCount {
+		s.jobs = s.jobs[len(s.jobs)-batchKeepCount:]
+	}
+	return job, s.saveLocked()
+}
+
+// Update 更新任务记录
+func (s *BatchService) Update(job models.BatchJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.jobs {
+		if s.jobs[i].ID == job.ID {
+			job.UpdatedAt = time.Now().UnixMilli()
+			s.jobs[i] = job
+			return s.saveLocked()
+		}
+	}
+	return fmt.Errorf("批量任务不存在: %s", job.ID)
+}
+
+// Get 获取任务
+func (s *BatchService) Get(id string) (models.BatchJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.ID == id {
+			return j, true
+		}
+	}
+	return models.BatchJob{}, false
+}
+
+// List 列出任务，最新的在前
+func (s *BatchService) List() []models.BatchJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]models.BatchJob, len(s.jobs))
+	copy(jobs, s.jobs)
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].CreatedAt > jobs[j].CreatedAt })
+	return jobs
+}
+
+// Pending 需要继续轮询或写回结果的任务
+func (s *BatchService) Pending() []models.BatchJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []models.BatchJob
+	for _, j := range s.jobs {
+		if j.BatchID != "" && !j.Applied {
+			jobs = append(jobs, j)
+		}
+	}
+	return jobs
+}
+
+// saveLocked 写入文件（调用方需持有锁）
+func (s *BatchService) saveLocked() error {
+	data, err := json.MarshalIndent(s.jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(s.path, data)
+}
+
+// batchSystemPrompt 批量分析的系统提示词（批量请求不执行工具，所需数据随提示词提供）
+const batchSystemPrompt = "你是专业的A股投资分析师。请仅依据提供的行情与持仓数据作答，数据不足时明确说明，不要编造数据。使用 Markdown 输出，结论简洁明确。"
+
+// BuildBatchPrompt 构建单只股票的批量分析提示词，返回系统提示词与用户提示词
+func BuildBatchPrompt(stock models.Stock, position *models.StockPosition, query string) (string, string) {
+	if query == "" {
+		query = DefaultBatchQuery
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## 股票\n%s(%s)\n", stock.Name, stock.Symbol)
+	fmt.Fprintf(&sb, "现价 %.2f，涨跌幅 %.2f%%，开盘 %.2f，最高 %.2f，最低 %.2f，昨收 %.2f，成交额 %.0f\n",
+		stock.Price, stock.ChangePercent, stock.Open, stock.High, stock.Low, stock.PreClose, stock.Amount)
+	if position != nil && position.Shares > 0 {
+		fmt.Fprintf(&sb, "\n## 持仓\n%d 股，成本价 %.2f\n", position.Shares, position.CostPrice)
+	}
+	fmt.Fprintf(&sb, "\n## 要求\n%s", query)
+	return batchSystemPrompt, sb.String()
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// maxCheckpointsPerStock 每只股票保留的检查点上限，超出时淘汰最早的
+const maxCheckpointsPerStock = 20
+
+// CheckpointService 会话检查点服务
+// 每只股票一个文件：dataDir/checkpoints/{stockCode}.json
+type CheckpointService struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewCheckpointService 创建检查点服务
+func NewCheckpointService(dataDir string) *CheckpointService {
+	cs := &CheckpointService{dir: filepath.Join(dataDir, "checkpoints")}
+	if err := os.MkdirAll(cs.dir, 0755); err != nil {
+		fmt.Printf("创建checkpoints目录失败: %v\n", err)
+	}
+	return cs
+}
+
+func (cs *CheckpointService) path(stockCode string) string {
+	return filepath.Join(cs.dir, stockCode+".json")
+}
+
+// load 读取股票的全部检查点（调用方需持有锁）
+func (cs *CheckpointService) load(stockCode string) ([]models.Checkpoint, error) {
+	data, err := os.ReadFile(cs.path(stockCode))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var checkpoints []models.Checkpoint
+	if err := json.Unmarshal(data, &checkpoints); err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+// save 写入股票的全部检查点（调用方需持有锁）
+func (cs *CheckpointService) save(stockCode string, checkpoints []models.Checkpoint) error {
+	data, err := json.MarshalIndent(checkpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cs.path(stockCode), data, 0644)
+}
+
+// Create 创建检查点
+func (cs *CheckpointService) Create(stockCode, name string, messages []models.ChatMessage, memory json.RawMessage) (*models.Checkpoint, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	checkpoints, err := cs.load(stockCode)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if name == "" {
+		name = now.Format("01-02 15:04:05")
+	}
+	cp := models.Checkpoint{
+		ID:        uuid.New().String(),
+		Name:      name,
+		StockCode: stockCode,
+		Messages:  append([]models.ChatMessage{}, messages...),
+		Memory:    memory,
+		CreatedAt: now.UnixMilli(),
+	}
+	checkpoints = append(checkpoints, cp)
+	if len(checkpoints) > maxCheckpointsPerStock {
+		checkpoints = checkpoints[len(checkpoints)-maxCheckpointsPerStock:]
+	}
+	if err := cs.save(stockCode, checkpoints); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// List 列出股票的检查点概要（按创建时间升序）
+func (cs *CheckpointService) List(stockCode string) []models.CheckpointInfo {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	checkpoints, err := cs.load(stockCode)
+	if err != nil {
+		fmt.Printf("读取检查点失败: %v\n", err)
+	}
+	result := make([]models.CheckpointInfo, 0, len(checkpoints))
+	for _, cp := range checkpoints {
+		result = append(result, models.CheckpointInfo{
+			ID:           cp.ID,
+			Name:         cp.Name,
+			MessageCount: len(cp.Messages),
+			HasMemory:    len(cp.Memory) > 0,
+			CreatedAt:    cp.CreatedAt,
+		})
+	}
+	return result
+}
+
+// Get 获取检查点
+func (cs *CheckpointService) Get(stockCode, id string) (*models.Checkpoint, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	checkpoints, err := cs.load(stockCode)
+	if err != nil {
+		return nil, err
+	}
+	for i := range checkpoints {
+		if checkpoints[i].ID == id {
+			return &checkpoints[i], nil
+		}
+	}
+	return nil, fmt.Errorf("checkpoint not found: %s", id)
+}
+
+// Delete 删除检查点
+func (cs *CheckpointService) Delete(stockCode, id string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	checkpoints, err := cs.load(stockCode)
+	if err != nil {
+		return err
+	}
+	for i := range checkpoints {
+		if checkpoints[i].ID == id {
+			checkpoints = append(checkpoints[:i], checkpoints[i+1:]...)
+			return cs.save(stockCode, checkpoints)
+		}
+	}
+	return fmt.Errorf("checkpoint not found: %s", id)
+}
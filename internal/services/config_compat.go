@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// ConfigSchemaVersion 当前版本写入的配置结构版本，新增字段的含义变化需要迁移时递增
+const ConfigSchemaVersion = 1
+
+// unknownFields 配置 JSON 中当前版本不认识的字段（通常由更新版本写入，如降级运行旧版本时），保存时原样写回
+// 嵌套结构体按字段名记录，结构体数组按元素的 id 记录（如 aiConfigs 中每个 AI 配置的新字段）
+type unknownFields struct {
+	fields   map[string]json.RawMessage
+	children map[string]*unknownFields            // 结构体字段
+	items    map[string]map[string]*unknownFields // 结构体数组字段 -> 元素 id -> 未知字段
+}
+
+// collectUnknownFields 收集 data 中类型 t 没有声明的字段，没有时返回 nil
+func collectUnknownFields(data []byte, t reflect.Type) *unknownFields {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(data, &obj) != nil {
+		return nil
+	}
+	known := jsonFields(t)
+	u := &unknownFields{}
+	for key, value := range obj {
+		ft, ok := known[key]
+		if !ok {
+			if u.fields == nil {
+				u.fields = make(map[string]json.RawMessage)
+			}
+			u.fields[key] = value
+			continue
+		}
+		switch {
+		case ft.Kind() == reflect.Struct:
+			if child := collectUnknownFields(value, ft); child != nil {
+				if u.children == nil {
+					u.children = make(map[string]*unknownFields)
+				}
+				u.children[key] = child
+			}
+		case ft.Kind() == reflect.Slice && hasIDField(ft.Elem()):
+			var elems []json.RawMessage
+			if json.Unmarshal(value, &elems) != nil {
+				continue
+			}
+			for _, elem := range elems {
+				id := elementID(elem)
+				child := collectUnknownFields(elem, ft.Elem())
+				if id == "" || child == nil {
+					continue
+				}
+				if u.items == nil {
+					u.items = make(map[string]map[string]*unknownFields)
+				}
+				if u.items[key] == nil {
+					u.items[key] = make(map[string]*unknownFields)
+				}
+				u.items[key][id] = child
+			}
+		}
+	}
+	if u.fields == nil && u.children == nil && u.items == nil {
+		return nil
+	}
+	return u
+}
+
+// restore 把未知字段写回序列化后的 JSON：已删除的数组元素不再恢复，当前版本已写入的字段不覆盖
+func (u *unknownFields) restore(data []byte) ([]byte, error) {
+	if u == nil {
+		return data, nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	for key, value := range u.fields {
+		if _, ok := obj[key]; !ok {
+			obj[key] = value
+		}
+	}
+	for key, child := range u.children {
+		value, ok := obj[key]
+		if !ok {
+			continue
+		}
+		restored, err := child.restore(value)
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = restored
+	}
+	for key, byID := range u.items {
+		var elems []json.RawMessage
+		if json.Unmarshal(obj[key], &elems) != nil {
+			continue
+		}
+		for i, elem := range elems {
+			child, ok := byID[elementID(elem)]
+			if !ok {
+				continue
+			}
+			restored, err := child.restore(elem)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = restored
+		}
+		value, err := json.Marshal(elems)
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = value
+	}
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, out, "", "  "); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonFields 返回结构体的 JSON 字段名及字段类型（指针取其元素类型）
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		fields[name] = ft
+	}
+	return fields
+}
+
+// hasIDField 结构体是否有 JSON 名为 id 的字段（数组元素据此匹配）
+func hasIDField(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	_, ok := jsonFields(t)["id"]
+	return ok
+}
+
+// elementID 读取数组元素的 id
+func elementID(elem json.RawMessage) string {
+	var v struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(elem, &v) != nil {
+		return ""
+	}
+	return v.ID
+}
@@ -1,16 +1,26 @@
 package services
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/run-bigpig/jcp/internal/embed"
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/memory"
 	"github.com/run-bigpig/jcp/internal/models"
 )
 
+var configLog = logger.New("config")
+
 // ConfigService 配置服务
 type ConfigService struct {
 	configPath    string
@@ -18,6 +28,10 @@ type ConfigService struct {
 	config        *models.AppConfig
 	watchlist     []models.Stock
 	mu            sync.RWMutex
+
+	// 更新版本写入而当前版本不认识的字段与结构版本，保存时原样保留，降级运行后再升级不会丢失新功能的设置
+	unknown       *unknownFields
+	schemaVersion int
 }
 
 // NewConfigService 创建配置服务
@@ -42,6 +56,8 @@ func NewConfigService(dataDir string) (*ConfigService, error) {
 }
 
 // loadConfig 加载配置
+// 配置文件无法解析时尝试从上一版本备份恢复，避免应用无法启动；
+// 能解析但校验不通过（旧版本或手工编辑、更新版本写入）时只修复或剔除有问题的条目，不覆盖文件
 func (cs *ConfigService) loadConfig() error {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
@@ -55,9 +71,71 @@ func (cs *ConfigService) loadConfig() error {
 		return err
 	}
 
+	config, parseErr := cs.parseConfig(data)
+	if parseErr == nil {
+		if err := validateConfig(config); err != nil {
+			configLog.Warn("配置校验未通过: %v，修复有问题的条目后加载", err)
+			for _, fix := range repairConfig(config) {
+				configLog.Warn("配置修复: %s", fix)
+			}
+		}
+		cs.config = config
+		cs.rememberUnknownFields(data, config)
+		return nil
+	}
+
+	configLog.Error("配置文件无法解析: %v，尝试从备份恢复", parseErr)
+	// 保留损坏的文件便于排查，再用备份（或默认配置）覆盖
+	brokenPath := fmt.Sprintf("%s.broken-%d", cs.configPath, time.Now().Unix())
+	if err := os.WriteFile(brokenPath, data, 0644); err != nil {
+		configLog.Warn("保存损坏配置失败: %v", err)
+	}
+
+	backup, config, err := cs.loadBackup()
+	if err != nil {
+		configLog.Error("备份不可用: %v，使用默认配置，损坏的文件已保存为 %s", err, brokenPath)
+		cs.config = cs.defaultConfig()
+		return cs.saveConfigLocked()
+	}
+	if err := atomicWriteFile(cs.configPath, backup); err != nil {
+		return err
+	}
+	configLog.Warn("已从备份恢复配置，损坏的文件已保存为 %s", brokenPath)
+	cs.config = config
+	cs.rememberUnknownFields(backup, config)
+	return nil
+}
+
+// loadBackup 读取并校验上一版本备份，校验规则与 UpdateConfig 一致
+func (cs *ConfigService) loadBackup() ([]byte, *models.AppConfig, error) {
+	backup, err := os.ReadFile(cs.backupPath())
+	if err != nil {
+		return nil, nil, err
+	}
+	config, err := cs.parseConfig(backup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("配置备份无法解析: %w", err)
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, nil, fmt.Errorf("配置备份无效: %w", err)
+	}
+	return backup, config, nil
+}
+
+// rememberUnknownFields 记录配置中当前版本不认识的字段，配置由更新版本写入时提示
+func (cs *ConfigService) rememberUnknownFields(data []byte, config *models.AppConfig) {
+	cs.unknown = collectUnknownFields(data, reflect.TypeOf(models.AppConfig{}))
+	cs.schemaVersion = config.SchemaVersion
+	if config.SchemaVersion > ConfigSchemaVersion {
+		configLog.Warn("配置由更新版本写入（结构版本 %d，当前 %d），未识别的设置将原样保留", config.SchemaVersion, ConfigSchemaVersion)
+	}
+}
+
+// parseConfig 解析配置并补全缺失的默认值
+func (cs *ConfigService) parseConfig(data []byte) (*models.AppConfig, error) {
 	var config models.AppConfig
 	if err := json.Unmarshal(data, &config); err != nil {
-		return err
+		return nil, err
 	}
 
 	// 用于识别字段是否在 JSON 中显式存在（避免把用户明确设置的 false 当成缺失字段）
@@ -82,9 +160,16 @@ func (cs *ConfigService) loadConfig() error {
 				Enabled *bool `json:"enabled"`
 			} `json:"kdj"`
 		} `json:"indicators"`
+		Storage struct {
+			StripThinking *bool `json:"stripThinking"`
+		} `json:"storage"`
 	}
 	if err := json.Unmarshal(data, &raw); err != nil {
-		return err
+		return nil, err
+	}
+
+	if raw.Storage.StripThinking == nil {
+		config.Storage.StripThinking = cs.defaultConfig().Storage.StripThinking
 	}
 
 	// 旧配置文件可能缺少 indicators 字段，Go 零值（nil/0/0.0）会导致前端异常
@@ -142,8 +227,7 @@ func (cs *ConfigService) loadConfig() error {
 	if ind.KDJ.D == 0 {
 		ind.KDJ.D = d.KDJ.D
 	}
-	cs.config = &config
-	return nil
+	return &config, nil
 }
 
 // defaultConfig 默认配置
@@ -168,16 +252,163 @@ func (cs *ConfigService) defaultConfig() *models.AppConfig {
 			RSI:  models.RSIConfig{Enabled: false, Period: 14},
 			KDJ:  models.KDJConfig{Enabled: false, Period: 9, K: 3, D: 3},
 		},
+		Storage: models.StorageConfig{
+			StripThinking: true,
+		},
 	}
 }
 
+// backupPath 上一版本配置备份路径
+func (cs *ConfigService) backupPath() string {
+	return cs.configPath + ".bak"
+}
+
 // saveConfigLocked 保存配置(需要已持有锁)
+// 两阶段写入：先写临时文件，再将当前文件备份为上一版本，最后原子替换
 func (cs *ConfigService) saveConfigLocked() error {
+	// 写入的结构版本不低于文件中原有的版本，避免更新版本误以为配置需要迁移
+	cs.config.SchemaVersion = max(cs.schemaVersion, ConfigSchemaVersion)
 	data, err := json.MarshalIndent(cs.config, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(cs.configPath, data, 0644)
+	if data, err = cs.unknown.restore(data); err != nil {
+		return err
+	}
+	if current, err := os.ReadFile(cs.configPath); err == nil && !bytes.Equal(current, data) {
+		if err := atomicWriteFile(cs.backupPath(), current); err != nil {
+			return fmt.Errorf("备份配置失败: %w", err)
+		}
+	}
+	return atomicWriteFile(cs.configPath, data)
+}
+
+// MemoryPromptTemplates 转换为记忆模块的提示词配置
+func MemoryPromptTemplates(p models.MemoryPromptConfig) memory.PromptTemplates {
+	return memory.PromptTemplates{Summarize: p.Summarize, ExtractFacts: p.ExtractFacts}
+}
+
+// atomicWriteFile 原子写文件：写入同目录临时文件并同步后重命名
+func atomicWriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// validateConfig 校验配置合法性
+func validateConfig(config *models.AppConfig) error {
+	if config == nil {
+		return fmt.Errorf("配置为空")
+	}
+	ids := make(map[string]bool, len(config.AIConfigs))
+	for _, ai := range config.AIConfigs {
+		if ai.ID == "" {
+			return fmt.Errorf("AI配置缺少ID: %s", ai.Name)
+		}
+		if ids[ai.ID] {
+			return fmt.Errorf("AI配置ID重复: %s", ai.ID)
+		}
+		ids[ai.ID] = true
+		if ai.Provider == "" {
+			return fmt.Errorf("AI配置 %s 缺少服务商", ai.Name)
+		}
+	}
+	for _, ref := range []string{config.DefaultAIID, config.StrategyAIID, config.ModeratorAIID, config.Memory.AIConfigID} {
+		if ref != "" && !ids[ref] {
+			configLog.Warn("配置引用了不存在的AI配置: %s", ref)
+		}
+	}
+	if err := memory.ValidatePromptTemplates(MemoryPromptTemplates(config.Memory.Prompts)); err != nil {
+		return err
+	}
+	for _, m := range config.MCPServers {
+		switch m.TransportType {
+		case models.MCPTransportHTTP, models.MCPTransportSSE, models.MCPTransportCommand:
+		default:
+			return fmt.Errorf("MCP服务器 %s 传输类型无效: %s", m.Name, m.TransportType)
+		}
+	}
+	if config.OpenClaw.Port < 0 || config.OpenClaw.Port > 65535 {
+		return fmt.Errorf("OpenClaw 端口无效: %d", config.OpenClaw.Port)
+	}
+	if config.Proxy.Mode == models.ProxyModeCustom && config.Proxy.CustomURL != "" {
+		if _, err := url.Parse(config.Proxy.CustomURL); err != nil {
+			return fmt.Errorf("代理地址无效: %w", err)
+		}
+	}
+	return nil
+}
+
+// repairConfig 就地修复 validateConfig 拒绝的条目，返回修复说明
+// AI 配置补全 ID 和服务商以保留设置，无法识别的 MCP 服务器和无效的提示词、端口、代理地址被剔除
+func repairConfig(config *models.AppConfig) []string {
+	var fixes []string
+	ids := make(map[string]bool, len(config.AIConfigs))
+	for i := range config.AIConfigs {
+		ai := &config.AIConfigs[i]
+		if ai.ID == "" || ids[ai.ID] {
+			old := ai.ID
+			ai.ID = uuid.New().String()
+			fixes = append(fixes, fmt.Sprintf("AI配置 %s 的ID %q 缺失或重复，已重新生成为 %s", ai.Name, old, ai.ID))
+		}
+		ids[ai.ID] = true
+		if ai.Provider == "" {
+			ai.Provider = models.AIProviderOpenAI
+			fixes = append(fixes, fmt.Sprintf("AI配置 %s 缺少服务商，按 %s 处理", ai.Name, models.AIProviderOpenAI))
+		}
+	}
+
+	prompts := &config.Memory.Prompts
+	if err := memory.ValidatePromptTemplates(memory.PromptTemplates{Summarize: prompts.Summarize}); err != nil {
+		prompts.Summarize = ""
+		fixes = append(fixes, fmt.Sprintf("摘要提示词无效，改用内置提示词: %v", err))
+	}
+	if err := memory.ValidatePromptTemplates(memory.PromptTemplates{ExtractFacts: prompts.ExtractFacts}); err != nil {
+		prompts.ExtractFacts = ""
+		fixes = append(fixes, fmt.Sprintf("事实提取提示词无效，改用内置提示词: %v", err))
+	}
+
+	kept := config.MCPServers[:0]
+	for _, m := range config.MCPServers {
+		switch m.TransportType {
+		case models.MCPTransportHTTP, models.MCPTransportSSE, models.MCPTransportCommand:
+			kept = append(kept, m)
+		default:
+			fixes = append(fixes, fmt.Sprintf("MCP服务器 %s 传输类型 %q 无法识别，本次不加载", m.Name, m.TransportType))
+		}
+	}
+	config.MCPServers = kept
+
+	if config.OpenClaw.Port < 0 || config.OpenClaw.Port > 65535 {
+		fixes = append(fixes, fmt.Sprintf("OpenClaw 端口 %d 无效，已清空", config.OpenClaw.Port))
+		config.OpenClaw.Port = 0
+	}
+	if config.Proxy.Mode == models.ProxyModeCustom && config.Proxy.CustomURL != "" {
+		if _, err := url.Parse(config.Proxy.CustomURL); err != nil {
+			fixes = append(fixes, fmt.Sprintf("代理地址无效，已清空: %v", err))
+			config.Proxy.CustomURL = ""
+		}
+	}
+	return fixes
 }
 
 // GetConfig 获取配置
@@ -189,10 +420,70 @@ func (cs *ConfigService) GetConfig() *models.AppConfig {
 
 // UpdateConfig 更新配置
 func (cs *ConfigService) UpdateConfig(config *models.AppConfig) error {
+	if err := validateConfig(config); err != nil {
+		return err
+	}
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
+	prev := cs.config
 	cs.config = config
-	return cs.saveConfigLocked()
+	if err := cs.saveConfigLocked(); err != nil {
+		cs.config = prev
+		return err
+	}
+	return nil
+}
+
+// SyncUISettings 将界面设置同步到配置中的主题与涨跌颜色字段（兼容仍从配置读取主题的前端）
+func (cs *ConfigService) SyncUISettings(settings models.UISettings) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.config.Theme == settings.Theme && cs.config.CandleColorMode == settings.CandleColorMode {
+		return nil
+	}
+	prev := cs.config
+	next := *cs.config
+	next.Theme, next.CandleColorMode = settings.Theme, settings.CandleColorMode
+	cs.config = &next
+	if err := cs.saveConfigLocked(); err != nil {
+		cs.config = prev
+		return err
+	}
+	return nil
+}
+
+// HasConfigBackup 是否存在可回滚的上一版本配置
+func (cs *ConfigService) HasConfigBackup() bool {
+	_, err := os.Stat(cs.backupPath())
+	return err == nil
+}
+
+// RollbackConfig 回滚到上一版本配置，当前配置成为新的备份
+func (cs *ConfigService) RollbackConfig() (*models.AppConfig, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	data, err := os.ReadFile(cs.backupPath())
+	if err != nil {
+		return nil, fmt.Errorf("没有可回滚的配置备份")
+	}
+	config, err := cs.parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("配置备份无法解析: %w", err)
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, fmt.Errorf("配置备份无效: %w", err)
+	}
+
+	prev, prevUnknown, prevVersion := cs.config, cs.unknown, cs.schemaVersion
+	cs.config = config
+	cs.rememberUnknownFields(data, config)
+	if err := cs.saveConfigLocked(); err != nil {
+		cs.config, cs.unknown, cs.schemaVersion = prev, prevUnknown, prevVersion
+		return nil, err
+	}
+	configLog.Info("配置已回滚到上一版本")
+	return config, nil
 }
 
 // loadWatchlist 加载自选股列表
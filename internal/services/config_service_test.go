@@ -0,0 +1,184 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestConfigService_RollbackAndRecover(t *testing.T) {
+	dir := t.TempDir()
+	cs, err := NewConfigService(dir)
+	if err != nil {
+		t.Fatalf("创建配置服务失败: %v", err)
+	}
+
+	cfg := *cs.GetConfig()
+	cfg.Theme = "ocean"
+	if err := cs.UpdateConfig(&cfg); err != nil {
+		t.Fatalf("更新配置失败: %v", err)
+	}
+	if !cs.HasConfigBackup() {
+		t.Fatal("更新后应存在备份")
+	}
+
+	// 非法配置不应写入
+	bad := cfg
+	bad.AIConfigs = []models.AIConfig{{Name: "no-id", Provider: models.AIProviderOpenAI}}
+	if err := cs.UpdateConfig(&bad); err == nil {
+		t.Fatal("缺少ID的AI配置应校验失败")
+	}
+
+	rolled, err := cs.RollbackConfig()
+	if err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+	if rolled.Theme != "military" {
+		t.Errorf("回滚后 theme = %q, 期望 military", rolled.Theme)
+	}
+
+	// 手工改坏配置文件后，重启应从备份恢复
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte("{broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cs2, err := NewConfigService(dir)
+	if err != nil {
+		t.Fatalf("损坏配置应从备份恢复: %v", err)
+	}
+	if cs2.GetConfig().Theme != "ocean" {
+		t.Errorf("恢复后 theme = %q, 期望 ocean", cs2.GetConfig().Theme)
+	}
+}
+
+func TestConfigService_InvalidBackupFallsBackToDefaults(t *testing.T) {
+	dir := t.TempDir()
+	// 备份能解析但校验不通过（AI 配置缺少 ID），不应被恢复
+	badBackup := `{"theme":"ocean","aiConfigs":[{"name":"no-id","provider":"openai"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json.bak"), []byte(badBackup), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte("{broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := NewConfigService(dir)
+	if err != nil {
+		t.Fatalf("备份无效时应回退到默认配置: %v", err)
+	}
+	cfg := cs.GetConfig()
+	if cfg.Theme != "military" || len(cfg.AIConfigs) != 0 {
+		t.Errorf("应使用默认配置，得到 theme=%q aiConfigs=%d", cfg.Theme, len(cfg.AIConfigs))
+	}
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("回退后的配置应合法: %v", err)
+	}
+	broken, _ := filepath.Glob(filepath.Join(dir, "config.json.broken-*"))
+	if len(broken) != 1 {
+		t.Errorf("损坏的配置应保留，得到 %v", broken)
+	}
+}
+
+func TestConfigService_RepairsInvalidEntriesWithoutOverwriting(t *testing.T) {
+	dir := t.TempDir()
+	// 能解析但校验不通过：旧版本缺少 ID/服务商的 AI 配置、更新版本的 MCP 传输类型、写错变量的提示词
+	raw := `{
+  "theme": "ocean",
+  "aiConfigs": [{"name": "legacy", "baseUrl": "https://api.example.com", "modelName": "m"}],
+  "mcpServers": [
+    {"id": "m1", "name": "local", "transportType": "command", "command": "mcp"},
+    {"id": "m2", "name": "future", "transportType": "websocket", "endpoint": "wss://x"}
+  ],
+  "memory": {"prompts": {"summarize": "{{.Nope}}", "extractFacts": "提取 {{.Content}}"}}
+}`
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := NewConfigService(dir)
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	cfg := cs.GetConfig()
+	if cfg.Theme != "ocean" {
+		t.Errorf("theme = %q，不应回退到默认配置", cfg.Theme)
+	}
+	if len(cfg.AIConfigs) != 1 || cfg.AIConfigs[0].ID == "" || cfg.AIConfigs[0].Provider != models.AIProviderOpenAI || cfg.AIConfigs[0].ModelName != "m" {
+		t.Errorf("AI配置应被修复并保留: %+v", cfg.AIConfigs)
+	}
+	if len(cfg.MCPServers) != 1 || cfg.MCPServers[0].ID != "m1" {
+		t.Errorf("只应剔除无法识别的 MCP 服务器: %+v", cfg.MCPServers)
+	}
+	if cfg.Memory.Prompts.Summarize != "" || cfg.Memory.Prompts.ExtractFacts != "提取 {{.Content}}" {
+		t.Errorf("只应清空无效的提示词: %+v", cfg.Memory.Prompts)
+	}
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("修复后的配置应能通过校验: %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != raw {
+		t.Error("加载时不应覆盖配置文件")
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Error("加载时不应生成备份")
+	}
+}
+
+func TestConfigService_PreservesUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	// 更新版本写入的配置：顶层、嵌套结构与 AI 配置中都有当前版本不认识的字段
+	newer := `{
+  "schemaVersion": 9,
+  "theme": "dark",
+  "futureBudget": {"daily": 5},
+  "memory": {"enabled": true, "futureTier": "gold"},
+  "aiConfigs": [
+    {"id": "a", "provider": "openai", "modelName": "gpt-4o", "fallbackChain": ["b"]},
+    {"id": "b", "provider": "openai", "modelName": "gpt-4o-mini"}
+  ]
+}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(newer), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := NewConfigService(dir)
+	if err != nil {
+		t.Fatalf("创建配置服务失败: %v", err)
+	}
+
+	cfg := *cs.GetConfig()
+	cfg.Theme = "ocean"
+	cfg.AIConfigs = append([]models.AIConfig(nil), cfg.AIConfigs...)
+	cfg.AIConfigs[0].ModelName = "gpt-4.1"
+	if err := cs.UpdateConfig(&cfg); err != nil {
+		t.Fatalf("更新配置失败: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved struct {
+		SchemaVersion int            `json:"schemaVersion"`
+		Theme         string         `json:"theme"`
+		FutureBudget  map[string]int `json:"futureBudget"`
+		Memory        struct {
+			FutureTier string `json:"futureTier"`
+		} `json:"memory"`
+		AIConfigs []struct {
+			ModelName     string   `json:"modelName"`
+			FallbackChain []string `json:"fallbackChain"`
+		} `json:"aiConfigs"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.SchemaVersion != 9 || saved.Theme != "ocean" || saved.FutureBudget["daily"] != 5 || saved.Memory.FutureTier != "gold" {
+		t.Errorf("saved = %+v", saved)
+	}
+	if len(saved.AIConfigs) != 2 || saved.AIConfigs[0].ModelName != "gpt-4.1" || len(saved.AIConfigs[0].FallbackChain) != 1 || saved.AIConfigs[1].FallbackChain != nil {
+		t.Errorf("aiConfigs = %+v", saved.AIConfigs)
+	}
+}
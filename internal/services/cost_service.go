@@ -0,0 +1,232 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var costLog = logger.New("cost")
+
+// DefaultCNYRate 未配置汇率时使用的美元兑人民币汇率
+const DefaultCNYRate = 7.2
+
+// DefaultModelPrices 内置价格表（美元 / 百万 token），按模型名最长前缀匹配
+var DefaultModelPrices = []models.ModelPrice{
+	{Model: "gpt-4o", Input: 2.5, CachedInput: 1.25, Output: 10},
+	{Model: "gpt-4o-mini", Input: 0.15, CachedInput: 0.075, Output: 0.6},
+	{Model: "gpt-4.1", Input: 2, CachedInput: 0.5, Output: 8},
+	{Model: "gpt-4.1-mini", Input: 0.4, CachedInput: 0.1, Output: 1.6},
+	{Model: "gpt-4.1-nano", Input: 0.1, CachedInput: 0.025, Output: 0.4},
+	{Model: "gpt-5", Input: 1.25, CachedInput: 0.125, Output: 10},
+	{Model: "gpt-5-mini", Input: 0.25, CachedInput: 0.025, Output: 2},
+	{Model: "gpt-5-nano", Input: 0.05, CachedInput: 0.005, Output: 0.4},
+	{Model: "o3", Input: 2, CachedInput: 0.5, Output: 8},
+	{Model: "o4-mini", Input: 1.1, CachedInput: 0.275, Output: 4.4},
+	{Model: "claude-opus", Input: 15, CachedInput: 1.5, Output: 75},
+	{Model: "claude-sonnet", Input: 3, CachedInput: 0.3, Output: 15},
+	{Model: "claude-3-5-sonnet", Input: 3, CachedInput: 0.3, Output: 15},
+	{Model: "claude-3-7-sonnet", Input: 3, CachedInput: 0.3, Output: 15},
+	{Model: "claude-haiku", Input: 1, CachedInput: 0.1, Output: 5},
+	{Model: "claude-3-5-haiku", Input: 0.8, CachedInput: 0.08, Output: 4},
+	{Model: "gemini-2.5-pro", Input: 1.25, CachedInput: 0.31, Output: 10},
+	{Model: "gemini-2.5-flash", Input: 0.3, CachedInput: 0.075, Output: 2.5},
+	{Model: "gemini-2.5-flash-lite", Input: 0.1, CachedInput: 0.025, Output: 0.4},
+	{Model: "deepseek-chat", Input: 0.27, CachedInput: 0.07, Output: 1.1},
+	{Model: "deepseek-reasoner", Input: 0.55, CachedInput: 0.14, Output: 2.19},
+}
+
+// costFile 费用累计文件
+type costFile struct {
+	Sessions map[string]models.CostTotals `json:"sessions"`
+	Configs  map[string]models.CostTotals `json:"configs"`
+}
+
+// CostService 按价格表把用量换算为费用，并累计每个会话、每个 AI 配置的总费用
+type CostService struct {
+	mu     sync.Mutex
+	path   string
+	config func() *models.CostConfig
+	data   costFile
+}
+
+// NewCostService 创建费用服务，config 返回当前的费用配置（可为 nil）
+func NewCostService(dataDir string, config func() *models.CostConfig) *CostService {
+	s := &CostService{
+		path:   filepath.Join(dataDir, "costs.json"),
+		config: config,
+		data: costFile{
+			Sessions: make(map[string]models.CostTotals),
+			Configs:  make(map[string]models.CostTotals),
+		},
+	}
+	if data, err := os.ReadFile(s.path); err == nil {
+		if err := json.Unmarshal(data, &s.data); err != nil {
+			costLog.Warn("加载费用累计失败: %v", err)
+		}
+		if s.data.Sessions == nil {
+			s.data.Sessions = make(map[string]models.CostTotals)
+		}
+		if s.data.Configs == nil {
+			s.data.Configs = make(map[string]models.CostTotals)
+		}
+	}
+	return s
+}
+
+func (s *CostService) costConfig() *models.CostConfig {
+	if s.config == nil {
+		return nil
+	}
+	return s.config()
+}
+
+// cnyRate 当前汇率
+func (s *CostService) cnyRate() float64 {
+	if cfg := s.costConfig(); cfg != nil && cfg.CNYRate > 0 {
+		return cfg.CNYRate
+	}
+	return DefaultCNYRate
+}
+
+// PriceFor 查找模型单价：AI 配置自定义单价 > 配置价格表 > 内置价格表
+func (s *CostService) PriceFor(aiConfig *models.AIConfig, modelName string) (models.ModelPrice, bool) {
+	if aiConfig != nil && aiConfig.Price != nil {
+		return *aiConfig.Price, true
+	}
+	if cfg := s.costConfig(); cfg != nil {
+		if p, ok := matchPrice(cfg.Prices, modelName); ok {
+			return p, true
+		}
+	}
+	return matchPrice(DefaultModelPrices, modelName)
+}
+
+// matchPrice 按模型名最长前缀匹配（忽略大小写与 "models/"、"openai/" 等路径前缀）
+func matchPrice(prices []models.ModelPrice, modelName string) (models.ModelPrice, bool) {
+	name := strings.ToLower(modelName)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	var best models.ModelPrice
+	found := false
+	for _, p := range prices {
+		prefix := strings.ToLower(p.Model)
+		if prefix == "" || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if !found || len(prefix) > len(best.Model) {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// Calculate 计算一次响应的费用，没有匹配价格时返回 nil
+func (s *CostService) Calculate(aiConfig *models.AIConfig, trace *models.TurnTrace) *models.Cost {
+	if trace == nil {
+		return nil
+	}
+	price, ok := s.PriceFor(aiConfig, trace.Model)
+	if !ok {
+		return nil
+	}
+	cached := min(trace.CachedTokens, trace.PromptTokens)
+	cachedPrice := price.CachedInput
+	if cachedPrice <= 0 {
+		cachedPrice = price.Input
+	}
+	usd := (float64(trace.PromptTokens-cached)*price.Input +
+		float64(cached)*cachedPrice +
+		float64(outputTokens(trace))*price.Output) / 1e6
+	return &models.Cost{USD: usd, CNY: usd * s.cnyRate()}
+}
+
+// outputTokens 计费的输出 token：总量大于输入时取差值（包含思考 token），否则为输出与思考之和
+func outputTokens(trace *models.TurnTrace) int {
+	if trace.TotalTokens > trace.PromptTokens {
+		return trace.TotalTokens - trace.PromptTokens
+	}
+	return trace.CompletionTokens + trace.ThoughtTokens
+}
+
+// Record 累计一次响应的用量与费用（cost 为 nil 时只累计用量）
+func (s *CostService) Record(trace *models.TurnTrace, cost *models.Cost) error {
+	if trace == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	add := func(t models.CostTotals) models.CostTotals {
+		t.Responses++
+		t.PromptTokens += trace.PromptTokens
+		t.CachedTokens += trace.CachedTokens
+		t.CompletionTokens += outputTokens(trace)
+		if cost != nil {
+			t.USD += cost.USD
+			t.CNY += cost.CNY
+		} else {
+			t.Unpriced++
+		}
+		t.UpdatedAt = now
+		return t
+	}
+	if trace.StockCode != "" {
+		s.data.Sessions[trace.StockCode] = add(s.data.Sessions[trace.StockCode])
+	}
+	if trace.AIConfigID != "" {
+		s.data.Configs[trace.AIConfigID] = add(s.data.Configs[trace.AIConfigID])
+	}
+	return s.saveLocked()
+}
+
+// saveLocked 写入累计文件（调用方需持有锁）
+func (s *CostService) saveLocked() error {
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(s.path, data)
+}
+
+// GetSessionCost 获取会话的累计费用
+func (s *CostService) GetSessionCost(stockCode string) models.CostTotals {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Sessions[stockCode]
+}
+
+// Summary 汇总所有会话与 AI 配置的累计费用
+func (s *CostService) Summary() models.CostSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	summary := models.CostSummary{
+		Sessions: make(map[string]models.CostTotals, len(s.data.Sessions)),
+		Configs:  make(map[string]models.CostTotals, len(s.data.Configs)),
+	}
+	for k, v := range s.data.Sessions {
+		summary.Sessions[k] = v
+	}
+	// 总计按 AI 配置累加，每次响应都带配置 ID
+	for k, v := range s.data.Configs {
+		summary.Configs[k] = v
+		t := &summary.Total
+		t.Responses += v.Responses
+		t.PromptTokens += v.PromptTokens
+		t.CachedTokens += v.CachedTokens
+		t.CompletionTokens += v.CompletionTokens
+		t.USD += v.USD
+		t.CNY += v.CNY
+		t.Unpriced += v.Unpriced
+		t.UpdatedAt = max(t.UpdatedAt, v.UpdatedAt)
+	}
+	return summary
+}
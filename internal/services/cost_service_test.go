@@ -0,0 +1,54 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestCostServiceCalculateAndRecord(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &models.CostConfig{
+		Prices:  []models.ModelPrice{{Model: "my-model", Input: 1, CachedInput: 0.5, Output: 2}},
+		CNYRate: 7,
+	}
+	s := NewCostService(dir, func() *models.CostConfig { return cfg })
+
+	if p, ok := s.PriceFor(nil, "models/GPT-4o-mini-2024-07-18"); !ok || p.Model != "gpt-4o-mini" {
+		t.Fatalf("longest prefix match failed: %+v %v", p, ok)
+	}
+	if _, ok := s.PriceFor(nil, "unknown-model"); ok {
+		t.Fatal("unknown model should have no price")
+	}
+
+	trace := &models.TurnTrace{
+		StockCode: "sh600519", AIConfigID: "ai1", Model: "my-model",
+		PromptTokens: 1_000_000, CachedTokens: 400_000, CompletionTokens: 500_000, TotalTokens: 1_500_000,
+	}
+	cost := s.Calculate(nil, trace)
+	// 0.6M*1 + 0.4M*0.5 + 0.5M*2 = 1.8 USD
+	if cost == nil || math.Abs(cost.USD-1.8) > 1e-9 || math.Abs(cost.CNY-12.6) > 1e-9 {
+		t.Fatalf("unexpected cost: %+v", cost)
+	}
+	override := &models.AIConfig{Price: &models.ModelPrice{Input: 0, Output: 0}}
+	if c := s.Calculate(override, trace); c == nil || c.USD != 0 {
+		t.Fatalf("per-config price should override: %+v", c)
+	}
+
+	if err := s.Record(trace, cost); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Record(&models.TurnTrace{StockCode: "sh600519", AIConfigID: "ai1", Model: "x", PromptTokens: 10}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := NewCostService(dir, nil)
+	got := reloaded.GetSessionCost("sh600519")
+	if got.Responses != 2 || got.Unpriced != 1 || got.PromptTokens != 1_000_010 || math.Abs(got.USD-1.8) > 1e-9 {
+		t.Fatalf("unexpected session totals: %+v", got)
+	}
+	if sum := reloaded.Summary(); sum.Total.Responses != 2 || sum.Configs["ai1"].CompletionTokens != 500_000 {
+		t.Fatalf("unexpected summary: %+v", sum)
+	}
+}
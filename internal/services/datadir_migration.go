@@ -0,0 +1,217 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+	"github.com/run-bigpig/jcp/internal/pkg/paths"
+)
+
+var dataDirLog = logger.New("datadir")
+
+// dataDirFile 待迁移的文件
+type dataDirFile struct {
+	rel  string
+	size int64
+	mode fs.FileMode
+}
+
+// DataDirUsage 统计目录下的文件数与总大小
+func DataDirUsage(dir string) (int, int64) {
+	files, err := listDataDirFiles(dir)
+	if err != nil {
+		return 0, 0
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	return len(files), total
+}
+
+// CheckDataDirTarget 校验迁移目标：需为绝对路径、与当前目录互不包含，且不存在或为空目录
+func CheckDataDirTarget(src, dst string) error {
+	if dst == "" || !filepath.IsAbs(dst) {
+		return fmt.Errorf("目标目录必须是绝对路径")
+	}
+	src, dst = filepath.Clean(src), filepath.Clean(dst)
+	if src == dst {
+		return fmt.Errorf("目标目录与当前数据目录相同")
+	}
+	if isSubPath(src, dst) || isSubPath(dst, src) {
+		return fmt.Errorf("目标目录不能位于当前数据目录内，也不能包含当前数据目录")
+	}
+	entries, err := os.ReadDir(dst)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("无法读取目标目录: %w", err)
+	}
+	for _, e := range entries {
+		if !paths.IsLocationFile(e.Name()) {
+			return fmt.Errorf("目标目录不为空")
+		}
+	}
+	return nil
+}
+
+// isSubPath 判断 child 是否位于 parent 之内
+func isSubPath(parent, child string) bool {
+	rel, err := filepath.Rel(parent, child)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// MigrateDataDir 把数据目录复制到 dst，逐个文件校验 SHA-256 后原子更新目录记录
+// 原目录保留不删除；迁移期间的新写入不会被复制，完成后应立即重启应用
+// 任一步骤失败时清理已复制的文件，目录记录保持不变
+func MigrateDataDir(src, dst string, progress func(done, total int)) (*models.DataDirMigration, error) {
+	if err := CheckDataDirTarget(src, dst); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	src, dst = filepath.Clean(src), filepath.Clean(dst)
+	files, err := listDataDirFiles(src)
+	if err != nil {
+		return nil, fmt.Errorf("读取数据目录失败: %w", err)
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return nil, fmt.Errorf("创建目标目录失败: %w", err)
+	}
+	if err := probeWritable(dst); err != nil {
+		return nil, fmt.Errorf("目标目录不可写: %w", err)
+	}
+
+	result := &models.DataDirMigration{From: src, To: dst}
+	fail := func(err error) (*models.DataDirMigration, error) {
+		cleanupDataDir(dst)
+		return nil, err
+	}
+	for i, f := range files {
+		sum, err := copyDataFile(filepath.Join(src, f.rel), filepath.Join(dst, f.rel), f.mode)
+		if err != nil {
+			return fail(fmt.Errorf("复制 %s 失败: %w", f.rel, err))
+		}
+		got, err := fileSHA256(filepath.Join(dst, f.rel))
+		if err != nil || !bytes.Equal(sum, got) {
+			return fail(fmt.Errorf("校验 %s 失败，文件内容不一致", f.rel))
+		}
+		result.Files++
+		result.Bytes += f.size
+		if progress != nil {
+			progress(i+1, len(files))
+		}
+	}
+
+	if err := paths.SetCustomDataDir(dst); err != nil {
+		return fail(fmt.Errorf("更新数据目录记录失败: %w", err))
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	dataDirLog.Info("数据目录已迁移: %s -> %s，%d 个文件，%d 字节", src, dst, result.Files, result.Bytes)
+	return result, nil
+}
+
+// listDataDirFiles 列出目录下的普通文件（跳过目录记录文件与临时文件）
+func listDataDirFiles(dir string) ([]dataDirFile, error) {
+	var files []dataDirFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if paths.IsLocationFile(rel) || strings.Contains(d.Name(), ".tmp-") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, dataDirFile{rel: rel, size: info.Size(), mode: info.Mode().Perm()})
+		return nil
+	})
+	return files, err
+}
+
+// copyDataFile 复制单个文件并返回源内容的 SHA-256
+func copyDataFile(src, dst string, mode fs.FileMode) ([]byte, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(out, io.TeeReader(in, h)); err != nil {
+		out.Close()
+		return nil, err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// fileSHA256 计算文件的 SHA-256
+func fileSHA256(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// probeWritable 在目录中写入并删除一个探测文件
+func probeWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".probe-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// cleanupDataDir 迁移失败时清理目标目录中已复制的内容（目标目录迁移前为空）
+func cleanupDataDir(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if paths.IsLocationFile(e.Name()) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			dataDirLog.Warn("清理目标目录失败: %v", err)
+		}
+	}
+}
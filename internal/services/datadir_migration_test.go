@@ -0,0 +1,54 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/pkg/paths"
+)
+
+func TestMigrateDataDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	src := paths.DefaultDataDir()
+	for name, content := range map[string]string{
+		"config.json":             `{"theme":"dark"}`,
+		"sessions/sh600519.json":  `{"stockCode":"sh600519"}`,
+		"sessions/x.json.tmp-123": "partial",
+	} {
+		path := filepath.Join(src, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	if err := CheckDataDirTarget(src, filepath.Join(src, "sub")); err == nil {
+		t.Fatal("nested target should be rejected")
+	}
+	occupied := t.TempDir()
+	os.WriteFile(filepath.Join(occupied, "a"), []byte("x"), 0644)
+	if _, err := MigrateDataDir(src, occupied, nil); err == nil {
+		t.Fatal("non-empty target should be rejected")
+	}
+
+	dst := filepath.Join(t.TempDir(), "jcp-data")
+	var last int
+	result, err := MigrateDataDir(src, dst, func(done, total int) { last = done })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Files != 2 || last != 2 {
+		t.Fatalf("unexpected result: %+v, progress %d", result, last)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "sessions", "sh600519.json")); string(data) != `{"stockCode":"sh600519"}` {
+		t.Fatalf("session not copied: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "sessions", "x.json.tmp-123")); !os.IsNotExist(err) {
+		t.Fatal("temp file should be skipped")
+	}
+	if got := paths.CustomDataDir(); got != dst {
+		t.Fatalf("custom data dir = %q, want %q", got, dst)
+	}
+	if files, _ := DataDirUsage(src); files != 2 {
+		t.Fatalf("source should keep its files (location file excluded), got %d", files)
+	}
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+
+	"github.com/google/uuid"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+var digestLog = logger.New("digest")
+
+const (
+	// digestKeepCount 保留的周报期数
+	digestKeepCount = 52
+	// digestSummaryRunes 每条结论注入提示词的最大长度
+	digestSummaryRunes = 300
+)
+
+// DigestSession 一只股票本周的讨论情况
+type DigestSession struct {
+	StockCode string
+	StockName string
+	Messages  int      // 本周消息数
+	Summaries []string // 本周小韭菜的总结结论（按时间顺序）
+}
+
+// DigestInput 生成周报所需的数据，由调用方从各服务收集
+type DigestInput struct {
+	Start     time.Time
+	End       time.Time
+	Sessions  []DigestSession
+	Alerts    []models.TriggeredAnalysis
+	Signals   []models.Signal
+	Portfolio *models.Portfolio
+}
+
+// DigestService 每周摘要：汇总各会话的新结论、组合盈亏变化与触发的提醒，由记忆模型生成周报
+type DigestService struct {
+	mu      sync.Mutex
+	path    string
+	digests []models.Digest
+}
+
+// NewDigestService 创建周报服务
+func NewDigestService(dataDir string) *DigestService {
+	s := &DigestService{path: filepath.Join(dataDir, "digests.json")}
+	if data, err := os.ReadFile(s.path); err == nil {
+		if err := json.Unmarshal(data, &s.digests); err != nil {
+			digestLog.Warn("加载周报失败: %v", err)
+		}
+	}
+	return s
+}
+
+// Due 判断本周是否应生成周报：已启用、到达生成日与时间，且距上期超过 6 天
+func (s *DigestService) Due(cfg models.DigestConfig, now time.Time) bool {
+	if !cfg.Enabled {
+		return false
+	}
+	now = now.In(time.FixedZone("CST", 8*60*60))
+	weekday := cfg.Weekday
+	if weekday < 0 || weekday > 6 {
+		weekday = int(time.Friday)
+	}
+	at, err := time.Parse("15:04", cfg.Time)
+	if err != nil {
+		at, _ = time.Parse("15:04", "20:00")
+	}
+	if int(now.Weekday()) != weekday || now.Hour()*60+now.Minute() < at.Hour()*60+at.Minute() {
+		return false
+	}
+	last, ok := s.Last()
+	return !ok || now.Sub(time.UnixMilli(last.CreatedAt)) > 6*24*time.Hour
+}
+
+// Last 最近一期周报
+func (s *DigestService) Last() (models.Digest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.digests) == 0 {
+		return models.Digest{}, false
+	}
+	return s.digests[len(s.digests)-1], true
+}
+
+// List 列出周报，最新的在前
+func (s *DigestService) List() []models.Digest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]models.Digest, 0, len(s.digests))
+	for i := len(s.digests) - 1; i >= 0; i-- {
+		result = append(result, s.digests[i])
+	}
+	return result
+}
+
+// Save 保存一期周报（同 ID 覆盖，用于回写推送结果）
+func (s *DigestService) Save(d models.Digest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	replaced := false
+	for i := range s.digests {
+		if s.digests[i].ID == d.ID {
+			s.digests[i] = d
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		s.digests = append(s.digests, d)
+		if len(s.digests) > digestKeepCount {
+			s.digests = s.digests[len(s.digests)-digestKeepCount:]
+		}
+	}
+	data, err := json.MarshalIndent(s.digests, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(s.path, data)
+}
+
+// Generate 生成周报：统计数据直接计算，正文由 llm 撰写；llm 为空或调用失败时使用数据清单作为正文
+func (s *DigestService) Generate(ctx context.Context, llm model.LLM, in DigestInput) models.Digest {
+	d := models.Digest{
+		ID:          uuid.New().String(),
+		PeriodStart: in.Start.UnixMilli(),
+		PeriodEnd:   in.End.UnixMilli(),
+		Title:       fmt.Sprintf("韭菜盘周报 %s ~ %s", in.Start.Format("01-02"), in.End.Format("01-02")),
+		Stats:       s.stats(in),
+		CreatedAt:   time.Now().UnixMilli(),
+	}
+	facts := buildDigestFacts(in, d.Stats)
+	d.Content = facts
+	if llm == nil {
+		return d
+	}
+	content, err := generateDigestText(ctx, llm, facts)
+	if err != nil {
+		digestLog.Warn("周报生成失败，使用数据清单: %v", err)
+		d.Error = "模型生成失败: " + err.Error()
+		return d
+	}
+	d.Content = content
+	return d
+}
+
+// stats 计算统计数据，盈亏变化与上一期比较
+func (s *DigestService) stats(in DigestInput) models.DigestStats {
+	st := models.DigestStats{Alerts: len(in.Alerts), Signals: len(in.Signals)}
+	for _, sess := range in.Sessions {
+		if sess.Messages > 0 {
+			st.ActiveSessions++
+		}
+		st.Recommendations += len(sess.Summaries)
+	}
+	if p := in.Portfolio; p != nil {
+		st.BaseCurrency = p.BaseCurrency
+		st.PortfolioValue = p.TotalMarketValue
+		st.PortfolioPnL = p.TotalPnL
+		if last, ok := s.Last(); ok && last.Stats.BaseCurrency == p.BaseCurrency {
+			st.PnLChange = p.TotalPnL - last.Stats.PortfolioPnL
+			st.HasPreviousPnL = true
+		}
+	}
+	return st
+}
+
+// buildDigestFacts 把本周数据整理为 Markdown 清单（既是提示词素材，也是无模型时的正文）
+func buildDigestFacts(in DigestInput, st models.DigestStats) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "统计区间: %s ~ %s\n\n", in.Start.Format("2006-01-02"), in.End.Format("2006-01-02"))
+
+	if in.Portfolio != nil && len(in.Portfolio.Positions) > 0 {
+		fmt.Fprintf(&sb, "## 持仓组合（%s）\n", st.BaseCurrency)
+		fmt.Fprintf(&sb, "市值 %.2f，浮动盈亏 %.2f (%.2f%%)", st.PortfolioValue, st.PortfolioPnL, in.Portfolio.TotalPnLPercent)
+		if st.HasPreviousPnL {
+			fmt.Fprintf(&sb, "，较上期 %+.2f", st.PnLChange)
+		}
+		sb.WriteString("\n")
+		for _, p := range in.Portfolio.Positions {
+			fmt.Fprintf(&sb, "- %s(%s): %d 股，盈亏 %.2f%%\n", p.StockName, p.StockCode, p.Shares, p.PnLPercent)
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## 本周结论\n")
+	if st.Recommendations == 0 {
+		sb.WriteString("本周没有新的会议结论。\n")
+	}
+	for _, sess := range in.Sessions {
+		for _, summary := range sess.Summaries {
+			fmt.Fprintf(&sb, "- %s(%s): %s\n", sess.StockName, sess.StockCode, truncateRunes(oneLine(summary), digestSummaryRunes))
+		}
+	}
+
+	if len(in.Alerts) > 0 {
+		sb.WriteString("\n## 事件提醒\n")
+		for _, a := range in.Alerts {
+			fmt.Fprintf(&sb, "- %s %s(%s) [%s]: %s\n", time.UnixMilli(a.FiredAt).Format("01-02"), a.StockName, a.StockCode, a.TriggerName, a.Reason)
+		}
+	}
+	if len(in.Signals) > 0 {
+		sb.WriteString("\n## 规则信号\n")
+		for _, sig := range in.Signals {
+			fmt.Fprintf(&sb, "- %s %s(%s): %s\n", sig.Date, sig.StockName, sig.StockCode, sig.Title)
+		}
+	}
+	return sb.String()
+}
+
+// digestInstruction 周报撰写要求
+const digestInstruction = `你是用户的投资助理，请根据以下本周数据撰写一份简洁的中文周报（Markdown）：
+1. 开头用两三句话概括本周组合表现与盈亏变化
+2. 按股票列出本周的主要结论与建议变化
+3. 列出需要关注的提醒与信号，以及下周的观察要点
+只使用提供的数据，不要编造行情或结论，全文控制在 600 字以内。
+
+`
+
+// generateDigestText 调用模型撰写周报正文
+func generateDigestText(ctx context.Context, llm model.LLM, facts string) (string, error) {
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			{Role: "user", Parts: []*genai.Part{{Text: digestInstruction + facts}}},
+		},
+	}
+	var text strings.Builder
+	for resp, err := range llm.GenerateContent(ctx, req, false) {
+		if err != nil {
+			return "", err
+		}
+		if resp != nil && resp.Content != nil {
+			for _, part := range resp.Content.Parts {
+				if !part.Thought && part.Text != "" {
+					text.WriteString(part.Text)
+				}
+			}
+		}
+	}
+	if strings.TrimSpace(text.String()) == "" {
+		return "", fmt.Errorf("模型返回为空")
+	}
+	return text.String(), nil
+}
+
+// oneLine 合并多行文本
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
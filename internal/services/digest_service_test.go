@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestDigestGenerateAndDue(t *testing.T) {
+	dir := t.TempDir()
+	s := NewDigestService(dir)
+	cst := time.FixedZone("CST", 8*60*60)
+	friday := time.Date(2026, 3, 6, 21, 0, 0, 0, cst)
+	cfg := models.DigestConfig{Enabled: true, Weekday: int(time.Friday), Time: "20:00"}
+	if !s.Due(cfg, friday) || s.Due(cfg, friday.Add(-2*time.Hour)) || s.Due(cfg, friday.AddDate(0, 0, 1)) {
+		t.Fatal("unexpected due result")
+	}
+
+	in := DigestInput{
+		Start: friday.AddDate(0, 0, -7),
+		End:   friday,
+		Sessions: []DigestSession{
+			{StockCode: "sh600519", StockName: "贵州茅台", Messages: 5, Summaries: []string{"建议\n持有"}},
+		},
+		Alerts:    []models.TriggeredAnalysis{{StockCode: "sh600519", StockName: "贵州茅台", TriggerName: "跳空", Reason: "高开 6%"}},
+		Portfolio: &models.Portfolio{BaseCurrency: "CNY", TotalMarketValue: 1000, TotalPnL: 100, Positions: []models.PortfolioPosition{{StockCode: "sh600519"}}},
+	}
+	d := s.Generate(context.Background(), nil, in)
+	if d.Stats.ActiveSessions != 1 || d.Stats.Recommendations != 1 || d.Stats.Alerts != 1 || d.Stats.HasPreviousPnL {
+		t.Fatalf("unexpected stats: %+v", d.Stats)
+	}
+	for _, want := range []string{"贵州茅台(sh600519): 建议 持有", "[跳空]: 高开 6%", "浮动盈亏 100.00"} {
+		if !strings.Contains(d.Content, want) {
+			t.Fatalf("content missing %q:\n%s", want, d.Content)
+		}
+	}
+	d.CreatedAt = friday.UnixMilli()
+	if err := s.Save(d); err != nil {
+		t.Fatal(err)
+	}
+	if s.Due(cfg, friday.Add(time.Hour)) {
+		t.Fatal("digest already generated this week")
+	}
+
+	in.Portfolio.TotalPnL = 150
+	next := NewDigestService(dir).Generate(context.Background(), nil, in)
+	if !next.Stats.HasPreviousPnL || next.Stats.PnLChange != 50 {
+		t.Fatalf("pnl change not computed: %+v", next.Stats)
+	}
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	cfg := &models.NotifyConfig{Webhooks: []models.WebhookSink{
+		{Name: "wx", Kind: models.WebhookWeCom, URL: srv.URL, Enabled: true},
+		{Name: "off", URL: srv.URL},
+	}}
+	s := NewNotifyService(func() *models.NotifyConfig { return cfg })
+	results := s.Send(context.Background(), "周报", "正文")
+	if len(results) != 1 || results[0].Error != "" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	md, _ := got["markdown"].(map[string]any)
+	if got["msgtype"] != "markdown" || md["content"] != "## 周报\n正文" {
+		t.Fatalf("unexpected payload: %v", got)
+	}
+}
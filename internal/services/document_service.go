@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+	"github.com/run-bigpig/jcp/internal/pkg/pdftext"
+	"github.com/run-bigpig/jcp/internal/pkg/proxy"
+)
+
+var docLog = logger.New("documents")
+
+// 文档索引限制
+const (
+	maxDocumentsPerStock  = 50               // 每只股票最多保留的文档数
+	maxPDFSize            = 30 << 20         // PDF 下载大小上限
+	documentChunkRunes    = 600              // 切片长度（字符）
+	maxDocumentPromptLen  = 1800             // 注入提示词的文档片段最大字符数
+	documentPromptHits    = 3                // 注入提示词的片段数
+	documentDownloadLimit = 60 * time.Second // 单篇下载超时
+)
+
+// DocumentService 个股文档索引：下载公告/研报 PDF，提取文本并切片，供会议检索引用
+type DocumentService struct {
+	dir       string
+	client    *http.Client
+	cache     map[string][]models.StockDocument
+	ingesting map[string]bool // 正在入库的 URL，避免重复下载
+	queue     *JobQueue       // 设置后异步入库通过持久化任务队列执行
+	mu        sync.Mutex
+}
+
+// documentIngestPayload 文档入库任务参数
+type documentIngestPayload struct {
+	StockCode   string `json:"stockCode"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Source      string `json:"source"`
+	PublishDate string `json:"publishDate"`
+}
+
+// SetJobQueue 使用任务队列执行异步入库（失败重试、重启后继续）
+func (s *DocumentService) SetJobQueue(q *JobQueue) {
+	q.Register(models.JobKindDocumentIngest, func(ctx context.Context, payload json.RawMessage) error {
+		var p documentIngestPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return err
+		}
+		if s.Has(p.StockCode, p.URL) {
+			return nil
+		}
+		return s.IngestPDF(p.StockCode, p.Title, p.URL, p.Source, p.PublishDate)
+	})
+	s.mu.Lock()
+	s.queue = q
+	s.mu.Unlock()
+}
+
+// NewDocumentService 创建文档索引服务
+func NewDocumentService(dataDir string) *DocumentService {
+	s := &DocumentService{
+		dir:       filepath.Join(dataDir, "documents"),
+		client:    proxy.GetManager().GetClientWithTimeout(documentDownloadLimit),
+		cache:     make(map[string][]models.StockDocument),
+		ingesting: make(map[string]bool),
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		docLog.Error("创建documents目录失败: %v", err)
+	}
+	return s
+}
+
+// documentID 以 URL 摘要作为文档 ID
+func documentID(url string) string {
+	sum := sha1.Sum([]byte(url))
+	return hex.EncodeToString(sum[:6])
+}
+
+// documentKey 统一股票代码（去掉 sh/sz/bj 前缀），工具与会议传入的格式可能不同
+func documentKey(stockCode string) string {
+	code := strings.ToLower(strings.TrimSpace(stockCode))
+	for _, prefix := range []string{"sh", "sz", "bj"} {
+		code = strings.TrimPrefix(code, prefix)
+	}
+	return code
+}
+
+func (s *DocumentService) path(stockCode string) string {
+	return filepath.Join(s.dir, documentKey(stockCode)+".json")
+}
+
+// loadNoLock 读取个股文档（优先缓存）
+func (s *DocumentService) loadNoLock(stockCode string) []models.StockDocument {
+	if docs, ok := s.cache[documentKey(stockCode)]; ok {
+		return docs
+	}
+	var docs []models.StockDocument
+	if data, err := os.ReadFile(s.path(stockCode)); err == nil {
+		if err := json.Unmarshal(data, &docs); err != nil {
+			docLog.Error("解析文档索引失败 [%s]: %v", stockCode, err)
+		}
+	}
+	s.cache[documentKey(stockCode)] = docs
+	return docs
+}
+
+func (s *DocumentService) saveNoLock(stockCode string, docs []models.StockDocument) error {
+	s.cache[documentKey(stockCode)] = docs
+	data, err := json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(stockCode), data, 0644)
+}
+
+// Has 判断文档是否已入库
+func (s *DocumentService) Has(stockCode, url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := documentID(url)
+	for _, d := range s.loadNoLock(stockCode) {
+		if d.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// List 列出个股已入库的文档（不含切片内容）
+func (s *DocumentService) List(stockCode string) []models.StockDocument {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	docs := s.loadNoLock(stockCode)
+	result := make([]models.StockDocument, 0, len(docs))
+	for _, d := range docs {
+		d.Chunks = nil
+		result = append(result, d)
+	}
+	return result
+}
+
+// Delete 删除个股文档
+func (s *DocumentService) Delete(stockCode, docID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	docs := s.loadNoLock(stockCode)
+	for i, d := range docs {
+		if d.ID == docID {
+			return s.saveNoLock(stockCode, append(docs[:i:i], docs[i+1:]...))
+		}
+	}
+	return fmt.Errorf("文档不存在: %s", docID)
+}
+
+// IngestAsync 后台下载并入库 PDF，已入库或正在入库时跳过；设置了任务队列时加入队列
+func (s *DocumentService) IngestAsync(stockCode, title, url, source, publishDate string) {
+	if stockCode == "" || url == "" || s.Has(stockCode, url) {
+		return
+	}
+	s.mu.Lock()
+	if q := s.queue; q != nil {
+		s.mu.Unlock()
+		payload := documentIngestPayload{StockCode: stockCode, Title: title, URL: url, Source: source, PublishDate: publishDate}
+		if _, err := q.Enqueue(models.JobKindDocumentIngest, "文档入库: "+title, "doc:"+stockCode+":"+url, payload, 0); err != nil {
+			docLog.Warn("文档入库任务保存失败 [%s] %s: %v", stockCode, title, err)
+		}
+		return
+	}
+	if s.ingesting[url] {
+		s.mu.Unlock()
+		return
+	}
+	s.ingesting[url] = true
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.ingesting, url)
+			s.mu.Unlock()
+		}()
+		if err := s.IngestPDF(stockCode, title, url, source, publishDate); err != nil {
+			docLog.Warn("文档入库失败 [%s] %s: %v", stockCode, title, err)
+		}
+	}()
+}
+
+// IngestPDF 下载 PDF、提取文本并写入个股文档索引
+func (s *DocumentService) IngestPDF(stockCode, title, url, source, publishDate string) error {
+	data, err := s.download(url)
+	if err != nil {
+		return err
+	}
+	text, err := pdftext.Extract(data)
+	if err != nil {
+		return err
+	}
+	return s.AddText(stockCode, title, url, source, publishDate, text)
+}
+
+// AddText 将已提取的文本切片后写入索引，同一 URL 会覆盖旧内容
+func (s *DocumentService) AddText(stockCode, title, url, source, publishDate, text string) error {
+	chunks := chunkText(text, documentChunkRunes)
+	if len(chunks) == 0 {
+		return fmt.Errorf("文档内容为空")
+	}
+	doc := models.StockDocument{
+		ID:          documentID(url),
+		StockCode:   stockCode,
+		Title:       title,
+		URL:         url,
+		Source:      source,
+		PublishDate: publishDate,
+		IngestedAt:  time.Now().UnixMilli(),
+		Chunks:      chunks,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	docs := s.loadNoLock(stockCode)
+	kept := make([]models.StockDocument, 0, len(docs)+1)
+	for _, d := range docs {
+		if d.ID != doc.ID {
+			kept = append(kept, d)
+		}
+	}
+	kept = append(kept, doc)
+	if len(kept) > maxDocumentsPerStock {
+		kept = kept[len(kept)-maxDocumentsPerStock:]
+	}
+	docLog.Info("文档已入库 [%s] %s，%d 个切片", stockCode, title, len(chunks))
+	return s.saveNoLock(stockCode, kept)
+}
+
+// download 下载 PDF 原文，超过 maxPDFSize 的文件直接拒绝
+func (s *DocumentService) download(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载失败: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPDFSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取失败: %w", err)
+	}
+	if len(data) > maxPDFSize {
+		return nil, fmt.Errorf("PDF 超过 %dMB", maxPDFSize>>20)
+	}
+	return data, nil
+}
+
+// Search 按查询词检索个股文档切片，按相关度和发布日期排序
+func (s *DocumentService) Search(stockCode, query string, limit int) []models.DocumentHit {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	docs := s.loadNoLock(stockCode)
+	s.mu.Unlock()
+
+	var hits []models.DocumentHit
+	for _, d := range docs {
+		for _, c := range d.Chunks {
+			score := 0.0
+			for _, t := range terms {
+				if n := strings.Count(c.Text, t); n > 0 {
+					// 长词权重更高，重复出现边际递减
+					score += float64(len([]rune(t))) * (1 + 0.2*float64(min(n, 5)-1))
+				}
+			}
+			if score > 0 {
+				hits = append(hits, models.DocumentHit{
+					DocID: d.ID, Title: d.Title, URL: d.URL, PublishDate: d.PublishDate,
+					Seq: c.Seq, Text: c.Text, Score: score,
+				})
+			}
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].PublishDate > hits[j].PublishDate
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// FormatForPrompt 检索与问题相关的文档片段，格式化为带编号的引用上下文
+func (s *DocumentService) FormatForPrompt(stockCode, query string) string {
+	hits := s.Search(stockCode, query, documentPromptHits)
+	if len(hits) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("【已收录公告/研报摘录】引用时请标注来源编号，如［文1］\n")
+	budget := maxDocumentPromptLen
+	for i, h := range hits {
+		text := []rune(h.Text)
+		if len(text) > budget {
+			text = text[:budget]
+		}
+		budget -= len(text)
+		fmt.Fprintf(&sb, "［文%d］《%s》%s\n%s\n", i+1, h.Title, h.PublishDate, string(text))
+		if budget <= 0 {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// chunkText 按段落切片，超长段落按长度硬切
+func chunkText(text string, size int) []models.DocumentChunk {
+	var chunks []models.DocumentChunk
+	var cur []rune
+	flush := func() {
+		if s := strings.TrimSpace(string(cur)); s != "" {
+			chunks = append(chunks, models.DocumentChunk{Seq: len(chunks), Text: s})
+		}
+		cur = cur[:0]
+	}
+	for _, para := range strings.Split(text, "\n") {
+		p := []rune(strings.TrimSpace(para))
+		if len(p) == 0 {
+			continue
+		}
+		if len(cur)+len(p) > size {
+			flush()
+		}
+		for len(p) > size {
+			chunks = append(chunks, models.DocumentChunk{Seq: len(chunks), Text: string(p[:size])})
+			p = p[size:]
+		}
+		if len(cur) > 0 {
+			cur = append(cur, '\n')
+		}
+		cur = append(cur, p...)
+	}
+	flush()
+	return chunks
+}
+
+// queryTerms 从问题中提取检索词：英文/数字整词，中文按二元组切分
+func queryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(t string) {
+		if t != "" && !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	var word []rune
+	var han []rune
+	flushWord := func() {
+		if len(word) >= 2 {
+			add(string(word))
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		for i := 0; i+1 < len(han); i++ {
+			add(string(han[i : i+2]))
+		}
+		han = han[:0]
+	}
+	for _, r := range query {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return terms
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocumentService_AddTextAndSearch(t *testing.T) {
+	dir := t.TempDir()
+	ds := NewDocumentService(dir)
+
+	text := strings.Repeat("公司经营情况正常。\n", 80) + "本公司拟以每10股派发现金红利5元，回购股份用于员工持股计划。\n"
+	if err := ds.AddText("sh600519", "2025年年度报告", "https://example.com/a.pdf", "announcement", "2026-03-30", text); err != nil {
+		t.Fatal(err)
+	}
+	// 重复收录同一 URL 覆盖旧内容
+	if err := ds.AddText("600519", "2025年年度报告", "https://example.com/a.pdf", "announcement", "2026-03-30", text); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载，验证持久化与代码归一
+	ds = NewDocumentService(dir)
+	docs := ds.List("sh600519")
+	if len(docs) != 1 || docs[0].Chunks != nil {
+		t.Fatalf("List = %+v", docs)
+	}
+
+	hits := ds.Search("600519", "分红和回购计划", 2)
+	if len(hits) == 0 || !strings.Contains(hits[0].Text, "现金红利") {
+		t.Fatalf("Search hits = %+v", hits)
+	}
+	prompt := ds.FormatForPrompt("sh600519", "回购")
+	if !strings.Contains(prompt, "［文1］《2025年年度报告》") {
+		t.Fatalf("FormatForPrompt = %q", prompt)
+	}
+	if ds.FormatForPrompt("sh600519", "") != "" {
+		t.Fatal("空问题不应注入文档")
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+	"github.com/run-bigpig/jcp/internal/pkg/proxy"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/transform"
+)
+
+var fxLog = logger.New("fx")
+
+const (
+	// fxRefreshInterval 汇率缓存有效期
+	fxRefreshInterval = time.Hour
+
+	// 新浪外汇行情：字段 1 为买入价，字段 8 为最新价
+	sinaFXURL = "http://hq.sinajs.cn/rn=%d&list=fx_susdcny,fx_shkdcny"
+)
+
+// defaultFXRates 拉取失败且无缓存时使用的参考汇率（每单位外币折合人民币）
+var defaultFXRates = map[string]float64{
+	models.CurrencyCNY: 1,
+	models.CurrencyUSD: 7.2,
+	models.CurrencyHKD: 0.92,
+}
+
+// sinaFXSymbols 新浪外汇代码与币种的对应关系
+var sinaFXSymbols = map[string]string{
+	"fx_susdcny": models.CurrencyUSD,
+	"fx_shkdcny": models.CurrencyHKD,
+}
+
+// FXService 汇率服务：缓存各币种兑人民币的汇率，过期后惰性刷新
+type FXService struct {
+	mu     sync.Mutex
+	path   string
+	client *http.Client
+	rates  models.FXRates
+
+	// fetch 拉取最新汇率，测试时可替换
+	fetch func() (map[string]float64, error)
+}
+
+// NewFXService 创建汇率服务
+func NewFXService(dataDir string) *FXService {
+	s := &FXService{
+		path:   filepath.Join(dataDir, "fx_rates.json"),
+		client: proxy.GetManager().GetClientWithTimeout(10 * time.Second),
+		rates:  models.FXRates{Rates: make(map[string]float64)},
+	}
+	s.fetch = s.fetchSina
+	if data, err := os.ReadFile(s.path); err == nil {
+		if err := json.Unmarshal(data, &s.rates); err != nil {
+			fxLog.Warn("加载汇率缓存失败: %v", err)
+		}
+		if s.rates.Rates == nil {
+			s.rates.Rates = make(map[string]float64)
+		}
+	}
+	return s
+}
+
+// Rates 返回当前汇率快照，缓存过期时先尝试刷新，刷新失败沿用缓存或参考汇率
+func (s *FXService) Rates() models.FXRates {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(time.UnixMilli(s.rates.UpdatedAt)) > fxRefreshInterval {
+		if err := s.refreshLocked(); err != nil {
+			fxLog.Warn("刷新汇率失败，使用缓存汇率: %v", err)
+		}
+	}
+	result := models.FXRates{Rates: make(map[string]float64, len(defaultFXRates)), UpdatedAt: s.rates.UpdatedAt}
+	for cur, rate := range defaultFXRates {
+		result.Rates[cur] = rate
+	}
+	for cur, rate := range s.rates.Rates {
+		result.Rates[cur] = rate
+	}
+	return result
+}
+
+// Convert 按汇率快照把金额从 from 币种换算为 to 币种，未知币种返回 false
+func Convert(rates models.FXRates, amount float64, from, to string) (float64, bool) {
+	rate, ok := CrossRate(rates, from, to)
+	if !ok {
+		return 0, false
+	}
+	return amount * rate, true
+}
+
+// CrossRate 1 单位 from 币种折合的 to 币种（经人民币交叉换算）
+func CrossRate(rates models.FXRates, from, to string) (float64, bool) {
+	from, to = normalizeCurrency(from), normalizeCurrency(to)
+	if from == to {
+		return 1, true
+	}
+	fromCNY, ok1 := rates.Rates[from]
+	toCNY, ok2 := rates.Rates[to]
+	if !ok1 || !ok2 || toCNY <= 0 {
+		return 0, false
+	}
+	return fromCNY / toCNY, true
+}
+
+// normalizeCurrency 统一币种写法，空值视为人民币
+func normalizeCurrency(cur string) string {
+	cur = strings.ToUpper(strings.TrimSpace(cur))
+	if cur == "" || cur == "RMB" {
+		return models.CurrencyCNY
+	}
+	return cur
+}
+
+// refreshLocked 拉取并保存最新汇率（调用方需持有锁）
+func (s *FXService) refreshLocked() error {
+	rates, err := s.fetch()
+	if err != nil {
+		return err
+	}
+	for cur, rate := range rates {
+		s.rates.Rates[cur] = rate
+	}
+	s.rates.UpdatedAt = time.Now().UnixMilli()
+	data, err := json.MarshalIndent(s.rates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(s.path, data)
+}
+
+// fetchSina 从新浪外汇行情获取美元、港元兑人民币汇率
+func (s *FXService) fetchSina() (map[string]float64, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf(sinaFXURL, time.Now().UnixNano()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Referer", "http://finance.sina.com.cn")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(transform.NewReader(resp.Body, simplifiedchinese.GBK.NewDecoder()))
+	if err != nil {
+		return nil, err
+	}
+	rates := parseSinaFX(string(body))
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("汇率数据为空")
+	}
+	return rates, nil
+}
+
+// parseSinaFX 解析新浪外汇行情，优先取最新价，缺失时取买入价
+func parseSinaFX(data string) map[string]float64 {
+	rates := make(map[string]float64)
+	for _, m := range sinaStockRegex.FindAllStringSubmatch(data, -1) {
+		cur, ok := sinaFXSymbols[m[1]]
+		if !ok {
+			continue
+		}
+		parts := strings.Split(m[2], ",")
+		for _, idx := range []int{8, 1} {
+			if idx >= len(parts) {
+				continue
+			}
+			if rate, err := strconv.ParseFloat(parts[idx], 64); err == nil && rate > 0 {
+				rates[cur] = rate
+				break
+			}
+		}
+	}
+	return rates
+}
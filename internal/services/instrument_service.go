@@ -0,0 +1,382 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/embed"
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+	"github.com/run-bigpig/jcp/internal/pkg/proxy"
+)
+
+var instrumentLog = logger.New("instrument")
+
+const (
+	// InstrumentRefreshInterval 证券主表刷新周期
+	InstrumentRefreshInterval = 7 * 24 * time.Hour
+
+	// 东方财富沪深京 A 股列表（f12 代码、f13 市场、f14 名称、f100 行业、f26 上市日期）
+	instrumentListURL      = "https://push2.eastmoney.com/api/qt/clist/get?pn=%d&pz=%d&po=0&np=1&fltt=2&invt=2&fid=f12&fs=m:0+t:6,m:0+t:80,m:1+t:2,m:1+t:23,m:0+t:81+s:2048&fields=f12,f13,f14,f100,f26"
+	instrumentListPageSize = 100
+
+	// 远端列表数量低于本地在市数量的该比例时视为不完整，不做退市判断
+	instrumentMinCoverage = 0.9
+)
+
+// instrumentCache 证券主表缓存文件
+type instrumentCache struct {
+	UpdatedAt int64               `json:"updatedAt"`
+	Items     []models.Instrument `json:"items"`
+}
+
+// InstrumentService 证券主表服务：名称、行业、上市日期的本地缓存，每周刷新并检测退市
+type InstrumentService struct {
+	mu        sync.RWMutex
+	path      string
+	client    *http.Client
+	updatedAt int64
+	items     map[string]models.Instrument
+
+	// fetch 拉取远端证券列表，测试时可替换
+	fetch func() ([]models.Instrument, error)
+}
+
+// NewInstrumentService 创建证券主表服务，本地无缓存时使用内置的证券基础数据
+func NewInstrumentService(dataDir string) *InstrumentService {
+	s := &InstrumentService{
+		path:   filepath.Join(dataDir, "instruments.json"),
+		client: proxy.GetManager().GetClientWithTimeout(15 * time.Second),
+		items:  make(map[string]models.Instrument),
+	}
+	s.fetch = s.fetchInstruments
+	if err := s.load(); err != nil {
+		if !os.IsNotExist(err) {
+			instrumentLog.Warn("加载证券主表缓存失败，使用内置数据: %v", err)
+		}
+		for _, inst := range parseStockBasic(embed.StockBasicJSON) {
+			s.items[inst.Code] = inst
+		}
+	}
+	return s
+}
+
+// load 读取缓存文件
+func (s *InstrumentService) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var cache instrumentCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return err
+	}
+	s.updatedAt = cache.UpdatedAt
+	for _, inst := range cache.Items {
+		s.items[inst.Code] = inst
+	}
+	return nil
+}
+
+// saveLocked 写入缓存文件（调用方需持有锁）
+func (s *InstrumentService) saveLocked() error {
+	cache := instrumentCache{UpdatedAt: s.updatedAt, Items: make([]models.Instrument, 0, len(s.items))}
+	for _, inst := range s.items {
+		cache.Items = append(cache.Items, inst)
+	}
+	sort.Slice(cache.Items, func(i, j int) bool { return cache.Items[i].Code < cache.Items[j].Code })
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(s.path, data)
+}
+
+// Get 查询证券信息，code 可带或不带市场前缀
+func (s *InstrumentService) Get(code string) (models.Instrument, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if inst, ok := s.items[code]; ok {
+		return inst, true
+	}
+	for _, prefix := range []string{"sh", "sz", "bj"} {
+		if inst, ok := s.items[prefix+code]; ok {
+			return inst, true
+		}
+	}
+	return models.Instrument{}, false
+}
+
+// Name 返回证券简称，未知时返回空字符串
+func (s *InstrumentService) Name(code string) string {
+	inst, _ := s.Get(code)
+	return inst.Name
+}
+
+// Industry 返回所属行业，未知时返回空字符串
+func (s *InstrumentService) Industry(code string) string {
+	inst, _ := s.Get(code)
+	return inst.Industry
+}
+
+// IsDelisted 是否已退市
+func (s *InstrumentService) IsDelisted(code string) bool {
+	inst, ok := s.Get(code)
+	return ok && inst.Delisted()
+}
+
+// RefreshDue 距上次刷新是否已超过刷新周期
+func (s *InstrumentService) RefreshDue(now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return now.Sub(time.UnixMilli(s.updatedAt)) >= InstrumentRefreshInterval
+}
+
+// Refresh 拉取远端证券列表并合并到本地主表
+// 本地在市但远端列表中消失的证券标记为退市（远端列表明显不完整时跳过）
+func (s *InstrumentService) Refresh() (models.InstrumentRefreshReport, error) {
+	fetched, err := s.fetch()
+	if err != nil {
+		return models.InstrumentRefreshReport{}, err
+	}
+	if len(fetched) == 0 {
+		return models.InstrumentRefreshReport{}, fmt.Errorf("证券列表为空")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var report models.InstrumentRefreshReport
+	seen := make(map[string]bool, len(fetched))
+	for _, f := range fetched {
+		seen[f.Code] = true
+		inst, ok := s.items[f.Code]
+		if !ok {
+			report.Added++
+			s.items[f.Code] = f
+			continue
+		}
+		if f.Name != "" && f.Name != inst.Name {
+			report.Renamed++
+			inst.Name = f.Name
+		}
+		if f.Industry != "" {
+			inst.Industry = f.Industry
+		}
+		if f.ListDate != "" {
+			inst.ListDate = f.ListDate
+		}
+		if inst.Market == "" {
+			inst.Market = f.Market
+		}
+		inst.Status = models.InstrumentListed
+		inst.DelistDate = ""
+		s.items[f.Code] = inst
+	}
+
+	listed := 0
+	for _, inst := range s.items {
+		if !inst.Delisted() {
+			listed++
+		}
+	}
+	if float64(len(fetched)) >= float64(listed)*instrumentMinCoverage {
+		today := time.Now().Format("20060102")
+		for code, inst := range s.items {
+			if seen[code] || inst.Delisted() {
+				continue
+			}
+			inst.Status = models.InstrumentDelisted
+			inst.DelistDate = today
+			s.items[code] = inst
+			report.Delisted = append(report.Delisted, code)
+		}
+		sort.Strings(report.Delisted)
+	} else {
+		instrumentLog.Warn("远端证券列表不完整（%d/%d），跳过退市检测", len(fetched), listed)
+	}
+
+	s.updatedAt = time.Now().UnixMilli()
+	report.Total = len(s.items)
+	if err := s.saveLocked(); err != nil {
+		instrumentLog.Warn("保存证券主表失败: %v", err)
+	}
+	instrumentLog.Info("证券主表已刷新: 共 %d，新增 %d，更名 %d，退市 %d", report.Total, report.Added, report.Renamed, len(report.Delisted))
+	return report, nil
+}
+
+// instrumentListResponse 东方财富列表响应
+type instrumentListResponse struct {
+	Data *struct {
+		Total int `json:"total"`
+		Diff  []struct {
+			Code     string `json:"f12"`
+			Market   int    `json:"f13"`
+			Name     string `json:"f14"`
+			Industry string `json:"f100"`
+			ListDate any    `json:"f26"`
+		} `json:"diff"`
+	} `json:"data"`
+}
+
+// fetchInstruments 分页拉取沪深京 A 股列表
+func (s *InstrumentService) fetchInstruments() ([]models.Instrument, error) {
+	var all []models.Instrument
+	for page := 1; ; page++ {
+		items, total, err := s.fetchInstrumentPage(page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) == 0 || len(all) >= total {
+			return all, nil
+		}
+	}
+}
+
+// fetchInstrumentPage 拉取一页证券列表
+func (s *InstrumentService) fetchInstrumentPage(page int) ([]models.Instrument, int, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf(instrumentListURL, page, instrumentListPageSize), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("Referer", "https://quote.eastmoney.com/")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return parseInstrumentList(body)
+}
+
+// parseInstrumentList 解析东方财富列表响应
+func parseInstrumentList(body []byte) ([]models.Instrument, int, error) {
+	var resp instrumentListResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, 0, fmt.Errorf("解析证券列表失败: %w", err)
+	}
+	if resp.Data == nil {
+		return nil, 0, nil
+	}
+	items := make([]models.Instrument, 0, len(resp.Data.Diff))
+	for _, d := range resp.Data.Diff {
+		if d.Code == "" || d.Name == "" {
+			continue
+		}
+		prefix := "sz"
+		switch {
+		case d.Market == 1:
+			prefix = "sh"
+		case strings.HasPrefix(d.Code, "8"), strings.HasPrefix(d.Code, "4"), strings.HasPrefix(d.Code, "92"):
+			prefix = "bj"
+		}
+		inst := models.Instrument{
+			Code:   prefix + d.Code,
+			Symbol: d.Code,
+			Name:   d.Name,
+			Market: boardOf(prefix, d.Code),
+			Status: models.InstrumentListed,
+		}
+		if d.Industry != "-" {
+			inst.Industry = d.Industry
+		}
+		switch v := d.ListDate.(type) {
+		case float64:
+			if v > 0 {
+				inst.ListDate = strconv.Itoa(int(v))
+			}
+		case string:
+			if v != "-" {
+				inst.ListDate = v
+			}
+		}
+		items = append(items, inst)
+	}
+	return items, resp.Data.Total, nil
+}
+
+// boardOf 根据代码判断板块
+func boardOf(prefix, symbol string) string {
+	switch {
+	case prefix == "bj":
+		return "北交所"
+	case strings.HasPrefix(symbol, "688"), strings.HasPrefix(symbol, "689"):
+		return "科创板"
+	case strings.HasPrefix(symbol, "300"), strings.HasPrefix(symbol, "301"):
+		return "创业板"
+	}
+	return "主板"
+}
+
+// parseStockBasic 解析内置的证券基础数据（Tushare stock_basic 格式）
+func parseStockBasic(data []byte) []models.Instrument {
+	var basic stockBasicData
+	if err := json.Unmarshal(data, &basic); err != nil {
+		instrumentLog.Warn("解析内置证券数据失败: %v", err)
+		return nil
+	}
+	idx := make(map[string]int, len(basic.Data.Fields))
+	for i, f := range basic.Data.Fields {
+		idx[f] = i
+	}
+	field := func(item []interface{}, name string) string {
+		i, ok := idx[name]
+		if !ok || i >= len(item) {
+			return ""
+		}
+		s, _ := item[i].(string)
+		return s
+	}
+
+	items := make([]models.Instrument, 0, len(basic.Data.Items))
+	for _, item := range basic.Data.Items {
+		tsCode, symbol := field(item, "ts_code"), field(item, "symbol")
+		var prefix string
+		switch {
+		case strings.HasSuffix(tsCode, ".SH"):
+			prefix = "sh"
+		case strings.HasSuffix(tsCode, ".SZ"):
+			prefix = "sz"
+		case strings.HasSuffix(tsCode, ".BJ"):
+			prefix = "bj"
+		default:
+			continue
+		}
+		status := field(item, "list_status")
+		if status == "" {
+			status = models.InstrumentListed
+		}
+		market := field(item, "market")
+		if market == "" {
+			market = boardOf(prefix, symbol)
+		}
+		items = append(items, models.Instrument{
+			Code:       prefix + symbol,
+			Symbol:     symbol,
+			Name:       field(item, "name"),
+			Industry:   field(item, "industry"),
+			Area:       field(item, "area"),
+			Market:     market,
+			ListDate:   field(item, "list_date"),
+			DelistDate: field(item, "delist_date"),
+			Status:     status,
+		})
+	}
+	return items
+}
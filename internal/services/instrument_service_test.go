@@ -0,0 +1,87 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/embed"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestParseStockBasic(t *testing.T) {
+	items := parseStockBasic(embed.StockBasicJSON)
+	if len(items) < 1000 {
+		t.Fatalf("parsed %d instruments", len(items))
+	}
+	for _, inst := range items {
+		if inst.Code == "sz000001" {
+			if inst.Name != "平安银行" || inst.Industry != "银行" || inst.ListDate != "19910403" || inst.Status != models.InstrumentListed {
+				t.Fatalf("sz000001 = %+v", inst)
+			}
+			return
+		}
+	}
+	t.Fatal("sz000001 not found")
+}
+
+func TestParseInstrumentList(t *testing.T) {
+	body := `{"data":{"total":3,"diff":[
+		{"f12":"600519","f13":1,"f14":"贵州茅台","f100":"酿酒行业","f26":20010827},
+		{"f12":"300750","f13":0,"f14":"宁德时代","f100":"电池","f26":20180611},
+		{"f12":"830799","f13":0,"f14":"艾融软件","f100":"-","f26":"-"}]}}`
+	items, total, err := parseInstrumentList([]byte(body))
+	if err != nil || total != 3 || len(items) != 3 {
+		t.Fatalf("items=%v total=%d err=%v", items, total, err)
+	}
+	if items[0].Code != "sh600519" || items[0].ListDate != "20010827" || items[0].Industry != "酿酒行业" {
+		t.Errorf("items[0] = %+v", items[0])
+	}
+	if items[1].Code != "sz300750" || items[1].Market != "创业板" {
+		t.Errorf("items[1] = %+v", items[1])
+	}
+	if items[2].Code != "bj830799" || items[2].Industry != "" || items[2].ListDate != "" {
+		t.Errorf("items[2] = %+v", items[2])
+	}
+}
+
+func TestInstrumentService_Refresh(t *testing.T) {
+	dir := t.TempDir()
+	s := &InstrumentService{path: filepath.Join(dir, "instruments.json"), items: map[string]models.Instrument{}}
+	for _, code := range []string{"sh600001", "sh600002", "sh600003", "sh600004", "sh600005", "sh600006", "sh600007", "sh600008", "sh600009", "sh600010"} {
+		s.items[code] = models.Instrument{Code: code, Symbol: code[2:], Name: "旧" + code, Status: models.InstrumentListed}
+	}
+
+	// 只缺一只：判定退市
+	var fetched []models.Instrument
+	for code := range s.items {
+		if code != "sh600010" {
+			fetched = append(fetched, models.Instrument{Code: code, Name: "旧" + code, Industry: "银行", Status: models.InstrumentListed})
+		}
+	}
+	fetched = append(fetched, models.Instrument{Code: "sh600011", Name: "新股", Status: models.InstrumentListed})
+	s.fetch = func() ([]models.Instrument, error) { return fetched, nil }
+
+	report, err := s.Refresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Added != 1 || len(report.Delisted) != 1 || report.Delisted[0] != "sh600010" {
+		t.Fatalf("report = %+v", report)
+	}
+	if !s.IsDelisted("sh600010") || s.Industry("600001") != "银行" || s.RefreshDue(time.Now()) {
+		t.Fatal("refresh not applied")
+	}
+
+	// 缓存持久化后可重新加载
+	reloaded := &InstrumentService{path: s.path, items: map[string]models.Instrument{}}
+	if err := reloaded.load(); err != nil || !reloaded.IsDelisted("sh600010") || reloaded.Name("sh600011") != "新股" {
+		t.Fatalf("reload err=%v", err)
+	}
+
+	// 远端列表明显不完整时不判定退市
+	s.fetch = func() ([]models.Instrument, error) { return fetched[:2], nil }
+	if report, _ := s.Refresh(); len(report.Delisted) != 0 {
+		t.Fatalf("partial list delisted %v", report.Delisted)
+	}
+}
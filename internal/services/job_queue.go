@@ -0,0 +1,420 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var jobLog = logger.New("jobs")
+
+const (
+	// jobKeepFinished 保留的已结束任务数
+	jobKeepFinished = 200
+	// jobDefaultAttempts 默认最大尝试次数
+	jobDefaultAttempts = 3
+	// jobBaseBackoff 首次重试等待时间，之后每次翻倍
+	jobBaseBackoff = 30 * time.Second
+	// jobMaxBackoff 重试等待上限
+	jobMaxBackoff = 30 * time.Minute
+	// jobPollInterval 空闲时检查到期重试任务的间隔
+	jobPollInterval = 10 * time.Second
+)
+
+// JobHandler 处理一种任务，返回错误时按退避策略重试
+type JobHandler func(ctx context.Context, payload json.RawMessage) error
+
+// JobQueue 持久化的后台任务队列：任务写入 jobs.json，失败按指数退避重试，
+// 应用重启后未完成的任务继续执行（中断时正在执行的任务重新排队）
+type JobQueue struct {
+	mu       sync.Mutex
+	path     string
+	jobs     []models.Job
+	handlers map[string]JobHandler
+	wake     chan struct{}
+	onChange func(models.Job)
+}
+
+// NewJobQueue 创建任务队列并恢复上次未完成的任务
+func NewJobQueue(dataDir string) *JobQueue {
+	q := &JobQueue{
+		path:     filepath.Join(dataDir, "jobs.json"),
+		handlers: make(map[string]JobHandler),
+		wake:     make(chan struct{}, 1),
+	}
+	if data, err := os.ReadFile(q.path); err == nil {
+		if err := json.Unmarshal(data, &q.jobs); err != nil {
+			jobLog.Warn("加载任务队列失败: %v", err)
+		}
+	}
+	recovered := 0
+	for i := range q.jobs {
+		if j := &q.jobs[i]; j.Status == models.JobRunning {
+			if j.Attempts >= j.MaxAttempts {
+				q.finishLocked(j, fmt.Errorf("应用退出时任务中断"))
+			} else {
+				j.Status = models.JobPending
+				j.LastError = "应用退出时任务中断"
+			}
+			recovered++
+		}
+	}
+	if recovered > 0 {
+		jobLog.Info("恢复 %d 个中断的任务", recovered)
+		q.saveLocked()
+	}
+	return q
+}
+
+// Register 注册任务处理函数
+func (q *JobQueue) Register(kind string, h JobHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = h
+}
+
+// SetOnChange 设置任务状态变化回调（用于推送到前端）
+func (q *JobQueue) SetOnChange(fn func(models.Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.onChange = fn
+}
+
+// Enqueue 添加任务；dedupeKey 非空且已有同键任务（已取消的除外）时返回已有任务
+// maxAttempts 为 0 时使用默认值
+func (q *JobQueue) Enqueue(kind, title, dedupeKey string, payload any, maxAttempts int) (models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return models.Job{}, err
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = jobDefaultAttempts
+	}
+	q.mu.Lock()
+	if dedupeKey != "" {
+		for _, j := range q.jobs {
+			if j.DedupeKey == dedupeKey && j.Status != models.JobCancelled {
+				q.mu.Unlock()
+				return j, nil
+			}
+		}
+	}
+	now := time.Now().UnixMilli()
+	job := models.Job{
+		ID:          uuid.New().String(),
+		Kind:        kind,
+		Title:       title,
+		Payload:     data,
+		DedupeKey:   dedupeKey,
+		Status:      models.JobPending,
+		MaxAttempts: maxAttempts,
+		NextRunAt:   now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	q.jobs = append(q.jobs, job)
+	err = q.saveLocked()
+	onChange := q.onChange
+	q.mu.Unlock()
+
+	q.notify(onChange, job)
+	q.signal()
+	return job, err
+}
+
+// Run 启动 workers 个工作协程执行任务，直到 ctx 结束
+func (q *JobQueue) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// work 单个工作协程：取出到期任务执行，没有任务时等待唤醒或定时检查
+func (q *JobQueue) work(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	for {
+		for ctx.Err() == nil {
+			job, handler, ok := q.claim()
+			if !ok {
+				break
+			}
+			err := runJob(ctx, handler, job)
+			if ctx.Err() != nil {
+				// 应用退出打断的任务不计失败，留给重启后继续执行
+				q.interrupt(job.ID)
+				return
+			}
+			q.finish(job.ID, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJob 执行处理函数，panic 视为失败
+func runJob(ctx context.Context, h JobHandler, job models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("任务执行异常: %v", r)
+		}
+	}()
+	return h(ctx, job.Payload)
+}
+
+// claim 取出一个已到期且有处理函数的任务并标记为执行中
+func (q *JobQueue) claim() (models.Job, JobHandler, bool) {
+	q.mu.Lock()
+	now := time.Now().UnixMilli()
+	for i := range q.jobs {
+		j := &q.jobs[i]
+		h, ok := q.handlers[j.Kind]
+		if j.Status != models.JobPending || j.NextRunAt > now || !ok {
+			continue
+		}
+		j.Status = models.JobRunning
+		j.Attempts++
+		j.UpdatedAt = now
+		q.saveLocked()
+		job, onChange := *j, q.onChange
+		q.mu.Unlock()
+		q.notify(onChange, job)
+		return job, h, true
+	}
+	q.mu.Unlock()
+	return models.Job{}, nil, false
+}
+
+// finish 记录任务执行结果，失败且未达最大次数时按退避重新排队
+func (q *JobQueue) finish(id string, err error) {
+	q.mu.Lock()
+	j := q.findLocked(id)
+	if j == nil || j.Status != models.JobRunning {
+		q.mu.Unlock()
+		return
+	}
+	if err != nil && j.Attempts < j.MaxAttempts {
+		backoff := min(jobBaseBackoff<<(j.Attempts-1), jobMaxBackoff)
+		j.Status = models.JobPending
+		j.LastError = err.Error()
+		j.NextRunAt = time.Now().Add(backoff).UnixMilli()
+		j.UpdatedAt = time.Now().UnixMilli()
+		jobLog.Warn("任务失败，%s 后重试 [%s] %s: %v", backoff, j.Kind, j.Title, err)
+	} else {
+		q.finishLocked(j, err)
+		if err != nil {
+			jobLog.Error("任务失败 [%s] %s: %v", j.Kind, j.Title, err)
+		}
+	}
+	job, onChange := *j, q.onChange
+	q.pruneLocked()
+	q.saveLocked()
+	q.mu.Unlock()
+	q.notify(onChange, job)
+}
+
+// interrupt 把因应用退出而中断的任务放回等待队列，退还本次尝试次数
+func (q *JobQueue) interrupt(id string) {
+	q.mu.Lock()
+	j := q.findLocked(id)
+	if j == nil || j.Status != models.JobRunning {
+		q.mu.Unlock()
+		return
+	}
+	now := time.Now().UnixMilli()
+	j.Status = models.JobPending
+	j.Attempts = max(j.Attempts-1, 0)
+	j.LastError = "应用退出时任务中断"
+	j.NextRunAt = now
+	j.UpdatedAt = now
+	job, onChange := *j, q.onChange
+	q.saveLocked()
+	q.mu.Unlock()
+	jobLog.Info("任务因应用退出中断，重启后继续 [%s] %s", job.Kind, job.Title)
+	q.notify(onChange, job)
+}
+
+// finishLocked 把任务标记为已结束（调用方需持有锁）
+func (q *JobQueue) finishLocked(j *models.Job, err error) {
+	now := time.Now().UnixMilli()
+	j.Status = models.JobSucceeded
+	if err != nil {
+		j.Status = models.JobFailed
+		j.LastError = err.Error()
+	}
+	j.UpdatedAt = now
+	j.FinishedAt = now
+}
+
+// List 列出任务，最新的在前
+func (q *JobQueue) List() []models.Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	result := make([]models.Job, 0, len(q.jobs))
+	for i := len(q.jobs) - 1; i >= 0; i-- {
+		result = append(result, q.jobs[i])
+	}
+	return result
+}
+
+// Stats 统计各状态的任务数
+func (q *JobQueue) Stats() models.JobStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var st models.JobStats
+	for _, j := range q.jobs {
+		switch j.Status {
+		case models.JobPending:
+			st.Pending++
+		case models.JobRunning:
+			st.Running++
+		case models.JobSucceeded:
+			st.Succeeded++
+		case models.JobFailed:
+			st.Failed++
+		case models.JobCancelled:
+			st.Cancelled++
+		}
+	}
+	return st
+}
+
+// Retry 重新执行失败或已取消的任务（重置尝试次数）
+func (q *JobQueue) Retry(id string) error {
+	return q.update(id, func(j *models.Job) error {
+		if j.Status != models.JobFailed && j.Status != models.JobCancelled {
+			return fmt.Errorf("只能重试失败或已取消的任务")
+		}
+		j.Status = models.JobPending
+		j.Attempts = 0
+		j.NextRunAt = time.Now().UnixMilli()
+		j.FinishedAt = 0
+		return nil
+	})
+}
+
+// Cancel 取消等待中的任务（执行中的任务无法取消）
+func (q *JobQueue) Cancel(id string) error {
+	return q.update(id, func(j *models.Job) error {
+		if j.Status != models.JobPending {
+			return fmt.Errorf("只能取消等待中的任务")
+		}
+		j.Status = models.JobCancelled
+		j.FinishedAt = time.Now().UnixMilli()
+		return nil
+	})
+}
+
+// ClearFinished 删除已结束的任务，返回删除数量
+func (q *JobQueue) ClearFinished() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := q.jobs[:0]
+	for _, j := range q.jobs {
+		if j.Status == models.JobPending || j.Status == models.JobRunning {
+			kept = append(kept, j)
+		}
+	}
+	removed := len(q.jobs) - len(kept)
+	q.jobs = kept
+	q.saveLocked()
+	return removed
+}
+
+// update 修改任务并保存
+func (q *JobQueue) update(id string, fn func(j *models.Job) error) error {
+	q.mu.Lock()
+	j := q.findLocked(id)
+	if j == nil {
+		q.mu.Unlock()
+		return fmt.Errorf("任务不存在: %s", id)
+	}
+	if err := fn(j); err != nil {
+		q.mu.Unlock()
+		return err
+	}
+	j.UpdatedAt = time.Now().UnixMilli()
+	job, onChange := *j, q.onChange
+	err := q.saveLocked()
+	q.mu.Unlock()
+	q.notify(onChange, job)
+	q.signal()
+	return err
+}
+
+func (q *JobQueue) findLocked(id string) *models.Job {
+	for i := range q.jobs {
+		if q.jobs[i].ID == id {
+			return &q.jobs[i]
+		}
+	}
+	return nil
+}
+
+// pruneLocked 已结束的任务只保留最近 jobKeepFinished 个
+func (q *JobQueue) pruneLocked() {
+	var finished []int64
+	for _, j := range q.jobs {
+		if j.FinishedAt > 0 {
+			finished = append(finished, j.FinishedAt)
+		}
+	}
+	if len(finished) <= jobKeepFinished {
+		return
+	}
+	sort.Slice(finished, func(i, k int) bool { return finished[i] > finished[k] })
+	cutoff := finished[jobKeepFinished-1]
+	kept := q.jobs[:0]
+	for _, j := range q.jobs {
+		if j.FinishedAt == 0 || j.FinishedAt >= cutoff {
+			kept = append(kept, j)
+		}
+	}
+	q.jobs = kept
+}
+
+func (q *JobQueue) saveLocked() error {
+	data, err := json.MarshalIndent(q.jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicWriteFile(q.path, data); err != nil {
+		jobLog.Warn("保存任务队列失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// signal 唤醒一个空闲的工作协程
+func (q *JobQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *JobQueue) notify(onChange func(models.Job), job models.Job) {
+	if onChange != nil {
+		onChange(job)
+	}
+}
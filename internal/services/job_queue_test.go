@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestJobQueue_RetryAndRecovery(t *testing.T) {
+	dir := t.TempDir()
+	q := NewJobQueue(dir)
+	var got []string
+	q.Register("echo", func(ctx context.Context, payload json.RawMessage) error {
+		var s string
+		json.Unmarshal(payload, &s)
+		got = append(got, s)
+		if len(got) == 1 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+
+	job, _ := q.Enqueue("echo", "测试", "key", "hello", 3)
+	if dup, _ := q.Enqueue("echo", "测试", "key", "again", 3); dup.ID != job.ID {
+		t.Fatal("dedupe key should return the existing job")
+	}
+
+	claimed, h, ok := q.claim()
+	if !ok {
+		t.Fatal("job should be claimable")
+	}
+	q.finish(claimed.ID, runJob(context.Background(), h, claimed))
+	if j := q.List()[0]; j.Status != models.JobPending || j.LastError != "temporary" || j.NextRunAt <= time.Now().UnixMilli() {
+		t.Fatalf("failed job should be rescheduled with backoff: %+v", j)
+	}
+	if _, _, ok := q.claim(); ok {
+		t.Fatal("job should wait for backoff")
+	}
+
+	// 模拟应用在执行中退出：重启后任务重新排队
+	q.jobs[0].NextRunAt = 0
+	q.claim()
+	restarted := NewJobQueue(dir)
+	if j := restarted.List()[0]; j.Status != models.JobPending || j.Attempts != 2 {
+		t.Fatalf("interrupted job should be pending again: %+v", j)
+	}
+	restarted.handlers = q.handlers
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	restarted.SetOnChange(func(j models.Job) {
+		if j.Status == models.JobSucceeded {
+			cancel()
+		}
+	})
+	go func() {
+		restarted.Run(ctx, 1)
+		close(done)
+	}()
+	restarted.signal()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not finish")
+	}
+	if st := restarted.Stats(); st.Succeeded != 1 || len(got) != 2 {
+		t.Fatalf("stats = %+v, calls = %v", st, got)
+	}
+}
+
+func TestJobQueue_ShutdownDoesNotConsumeAttempt(t *testing.T) {
+	dir := t.TempDir()
+	q := NewJobQueue(dir)
+	started := make(chan struct{})
+	q.Register("slow", func(ctx context.Context, payload json.RawMessage) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	// 最后一次尝试时被关闭打断，也不应标记为失败
+	if _, err := q.Enqueue("slow", "长任务", "", nil, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx, 1)
+		close(done)
+	}()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not start")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queue did not stop")
+	}
+
+	for _, queue := range []*JobQueue{q, NewJobQueue(dir)} {
+		j := queue.List()[0]
+		if j.Status != models.JobPending || j.Attempts != 0 || j.NextRunAt > time.Now().UnixMilli() {
+			t.Fatalf("interrupted job should be pending without consuming an attempt: %+v", j)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// 盘前预热的 K 线周期与条数
+var warmKLinePeriods = []struct {
+	period string
+	days   int
+}{
+	{"1d", SignalKLineDays},
+	{"1w", 120},
+}
+
+// marketSnapshot 行情快照：最近一次预热获取的数据，网络不可用时作为离线兜底
+type marketSnapshot struct {
+	dir    string
+	quotes map[string]quoteSnapshot
+	mu     sync.Mutex
+}
+
+// quoteSnapshot 单只股票的行情快照
+type quoteSnapshot struct {
+	Stock     models.Stock `json:"stock"`
+	UpdatedAt int64        `json:"updatedAt"`
+}
+
+// klineSnapshot 单只股票单个周期的 K 线快照
+type klineSnapshot struct {
+	KLines    []models.KLineData `json:"klines"`
+	UpdatedAt int64              `json:"updatedAt"`
+}
+
+// EnableSnapshot 启用行情快照（预热结果写入 dataDir/market_cache，拉取失败时读取）
+func (ms *MarketService) EnableSnapshot(dataDir string) {
+	dir := filepath.Join(dataDir, "market_cache")
+	if err := os.MkdirAll(filepath.Join(dir, "kline"), 0755); err != nil {
+		log.Error("创建行情快照目录失败: %v", err)
+		return
+	}
+	snap := &marketSnapshot{dir: dir, quotes: make(map[string]quoteSnapshot)}
+	if data, err := os.ReadFile(snap.quotesPath()); err == nil {
+		if err := json.Unmarshal(data, &snap.quotes); err != nil {
+			log.Warn("解析行情快照失败: %v", err)
+		}
+	}
+	ms.snapshot = snap
+}
+
+func (s *marketSnapshot) quotesPath() string {
+	return filepath.Join(s.dir, "quotes.json")
+}
+
+func (s *marketSnapshot) klinePath(code, period string) string {
+	return filepath.Join(s.dir, "kline", code+"_"+period+".json")
+}
+
+// saveQuotes 写入行情快照
+func (s *marketSnapshot) saveQuotes(stocks []models.Stock) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UnixMilli()
+	for _, st := range stocks {
+		s.quotes[st.Symbol] = quoteSnapshot{Stock: st, UpdatedAt: now}
+	}
+	data, err := json.Marshal(s.quotes)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.quotesPath(), data, 0644)
+}
+
+// loadQuotes 读取行情快照，只返回存在快照的股票
+func (s *marketSnapshot) loadQuotes(codes []string) []models.Stock {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var stocks []models.Stock
+	for _, code := range codes {
+		if q, ok := s.quotes[code]; ok {
+			stocks = append(stocks, q.Stock)
+		}
+	}
+	return stocks
+}
+
+// saveKLines 写入 K 线快照
+func (s *marketSnapshot) saveKLines(code, period string, klines []models.KLineData) error {
+	data, err := json.Marshal(klineSnapshot{KLines: klines, UpdatedAt: time.Now().UnixMilli()})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.klinePath(code, period), data, 0644)
+}
+
+// loadKLines 读取 K 线快照的最后 days 条，since 非零时忽略早于该时间的快照
+func (s *marketSnapshot) loadKLines(code, period string, days int, since time.Time) ([]models.KLineData, bool) {
+	data, err := os.ReadFile(s.klinePath(code, period))
+	if err != nil {
+		return nil, false
+	}
+	var snap klineSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil || len(snap.KLines) == 0 {
+		return nil, false
+	}
+	if !since.IsZero() && time.UnixMilli(snap.UpdatedAt).Before(since) {
+		return nil, false
+	}
+	klines := snap.KLines
+	if days > 0 && len(klines) > days {
+		klines = klines[len(klines)-days:]
+	}
+	return klines, true
+}
+
+// quotesFallback 行情拉取失败时使用快照
+func (ms *MarketService) quotesFallback(codes []string, fetchErr error) ([]models.Stock, error) {
+	if ms.snapshot == nil {
+		return nil, fetchErr
+	}
+	stocks := ms.snapshot.loadQuotes(codes)
+	if len(stocks) == 0 {
+		return nil, fetchErr
+	}
+	log.Warn("行情拉取失败，使用离线快照: %v", fetchErr)
+	return stocks, nil
+}
+
+// klineFallback K 线拉取失败时使用快照（分时数据不做兜底）
+func (ms *MarketService) klineFallback(code, period string, days int, fetchErr error) ([]models.KLineData, error) {
+	if ms.snapshot == nil || period == "1m" {
+		return nil, fetchErr
+	}
+	klines, ok := ms.snapshot.loadKLines(code, period, days, time.Time{})
+	if !ok {
+		return nil, fetchErr
+	}
+	log.Warn("K线拉取失败，使用离线快照 [%s %s]: %v", code, period, fetchErr)
+	return klines, nil
+}
+
+// premarketKLines 盘前直接使用当天预热的日/周 K 线快照（开盘前数据不会变化），无需等待网络
+func (ms *MarketService) premarketKLines(code, period string, days int) ([]models.KLineData, bool) {
+	if ms.snapshot == nil || (period != "1d" && period != "1w") {
+		return nil, false
+	}
+	if ms.GetMarketStatus().Status != "pre_market" {
+		return nil, false
+	}
+	now := time.Now().In(time.FixedZone("CST", 8*60*60))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	klines, ok := ms.snapshot.loadKLines(code, period, days, today)
+	if !ok || len(klines) < days {
+		return nil, false
+	}
+	return klines, true
+}
+
+// WarmCache 预热行情缓存：拉取股票的实时行情与日/周 K 线，写入内存缓存与离线快照
+// 数据源暂无基本面接口，基本面数据仍在分析时由工具实时获取
+func (ms *MarketService) WarmCache(codes []string) models.WarmCacheReport {
+	report := models.WarmCacheReport{StartedAt: time.Now().UnixMilli(), Stocks: len(codes)}
+	if len(codes) == 0 {
+		report.FinishedAt = time.Now().UnixMilli()
+		return report
+	}
+
+	if stocks, err := ms.fetchQuotes(codes); err != nil {
+		report.Errors = append(report.Errors, "行情: "+err.Error())
+	} else {
+		report.Quotes = len(stocks)
+		if ms.snapshot != nil {
+			if err := ms.snapshot.saveQuotes(stocks); err != nil {
+				report.Errors = append(report.Errors, "保存行情快照: "+err.Error())
+			}
+		}
+	}
+
+	for _, code := range codes {
+		for _, p := range warmKLinePeriods {
+			klines, err := ms.fetchKLineData(code, p.period, p.days)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("K线 %s %s: %v", code, p.period, err))
+				continue
+			}
+			ms.storeKLineCache(fmt.Sprintf("%s:%s:%d", code, p.period, p.days), p.period, klines)
+			if ms.snapshot != nil {
+				if err := ms.snapshot.saveKLines(code, p.period, klines); err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("保存K线快照 %s: %v", code, err))
+					continue
+				}
+			}
+			report.KLines++
+		}
+	}
+
+	report.FinishedAt = time.Now().UnixMilli()
+	log.Info("行情预热完成: %d 只股票, 行情 %d, K线 %d, 失败 %d, 耗时 %dms",
+		report.Stocks, report.Quotes, report.KLines, len(report.Errors), report.FinishedAt-report.StartedAt)
+	return report
+}
+
+// WarmCacheDue 判断今天是否需要预热：交易日、已到预热时间且今天尚未预热
+// 返回今天的日期（YYYY-MM-DD，北京时间），调用方预热后记录以避免重复
+func (ms *MarketService) WarmCacheDue(cfg models.WarmCacheConfig, now time.Time, lastDate string) (string, bool) {
+	return ms.DailyTaskDue(cfg.Enabled, cfg.Time, "09:00", now, lastDate)
+}
+
+// DailyTaskDue 判断每日任务是否应执行：启用、今天未执行过、是交易日且已过指定时间（北京时间 HH:MM，无效时用 fallback）
+// 返回今天的日期，供调用方记录
+func (ms *MarketService) DailyTaskDue(enabled bool, hhmm, fallback string, now time.Time, lastDate string) (string, bool) {
+	now = now.In(time.FixedZone("CST", 8*60*60))
+	today := now.Format("2006-01-02")
+	if !enabled || today == lastDate {
+		return today, false
+	}
+	if ok, _ := ms.isTradeDay(now); !ok {
+		return today, false
+	}
+	at, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		at, _ = time.Parse("15:04", fallback)
+	}
+	return today, now.Hour()*60+now.Minute() >= at.Hour()*60+at.Minute()
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestMarketSnapshotFallback(t *testing.T) {
+	dataDir := t.TempDir()
+	ms := &MarketService{klineCache: make(map[string]*klineCache)}
+	ms.EnableSnapshot(dataDir)
+
+	klines := make([]models.KLineData, 10)
+	for i := range klines {
+		klines[i].Close = float64(i)
+	}
+	if err := ms.snapshot.saveKLines("sh600519", "1d", klines); err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.snapshot.saveQuotes([]models.Stock{{Symbol: "sh600519", Price: 1500}}); err != nil {
+		t.Fatal(err)
+	}
+
+	fetchErr := errors.New("network down")
+	got, err := ms.klineFallback("sh600519", "1d", 3, fetchErr)
+	if err != nil || len(got) != 3 || got[0].Close != 7 {
+		t.Fatalf("K线兜底应返回最后 3 条, got %v err %v", got, err)
+	}
+	if _, err := ms.klineFallback("sh600519", "1m", 3, fetchErr); err != fetchErr {
+		t.Fatalf("分时数据不应兜底, err %v", err)
+	}
+	if _, err := ms.klineFallback("sz000001", "1d", 3, fetchErr); err != fetchErr {
+		t.Fatalf("无快照时应返回原错误, err %v", err)
+	}
+
+	// 重新加载后行情快照仍可用
+	reloaded := &MarketService{}
+	reloaded.EnableSnapshot(dataDir)
+	stocks, err := reloaded.quotesFallback([]string{"sh600519", "sz000001"}, fetchErr)
+	if err != nil || len(stocks) != 1 || stocks[0].Price != 1500 {
+		t.Fatalf("行情兜底失败: %v %v", stocks, err)
+	}
+}
+
+func TestWarmCacheDue(t *testing.T) {
+	holidayCacheMu.Lock()
+	if _, ok := holidayCacheData[2025]; !ok {
+		holidayCacheData[2025] = map[string]bool{}
+	}
+	holidayCacheMu.Unlock()
+
+	ms := &MarketService{}
+	cst := time.FixedZone("CST", 8*60*60)
+	cfg := models.WarmCacheConfig{Enabled: true, Time: "08:30"}
+	wednesday := time.Date(2025, 3, 12, 8, 45, 0, 0, cst)
+
+	if _, due := ms.WarmCacheDue(cfg, wednesday.Add(-time.Hour), ""); due {
+		t.Error("未到预热时间不应预热")
+	}
+	today, due := ms.WarmCacheDue(cfg, wednesday, "")
+	if !due || today != "2025-03-12" {
+		t.Errorf("交易日到达预热时间应预热, got %s %v", today, due)
+	}
+	if _, due := ms.WarmCacheDue(cfg, wednesday, today); due {
+		t.Error("同一天不应重复预热")
+	}
+	if _, due := ms.WarmCacheDue(cfg, wednesday.AddDate(0, 0, 3), ""); due {
+		t.Error("周末不应预热")
+	}
+	if _, due := ms.WarmCacheDue(models.WarmCacheConfig{}, wednesday, ""); due {
+		t.Error("未启用时不应预热")
+	}
+}
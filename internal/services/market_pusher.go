@@ -25,6 +25,9 @@ const (
 	EventOrderBookSubscribe  = "market:orderbook:subscribe"
 	EventKLineUpdate         = "market:kline:update"
 	EventKLineSubscribe      = "market:kline:subscribe"
+	// 行情增量同步：前端发送 resync 后切换为字段级增量推送，之后收到跳号时再次发送 resync 获取全量
+	EventStockDiff   = "market:stock:diff"
+	EventStockResync = "market:stock:resync"
 )
 
 // 推送频率常量
@@ -74,6 +77,15 @@ type MarketDataPusher struct {
 	// 盘口缓存（用于diff检测）
 	lastOrderBookHash string
 
+	// 行情增量同步：前端完成 resync 握手后启用，未握手的前端继续接收全量推送
+	diffMode bool
+	differ   quoteDiffer
+	diffMu   sync.Mutex
+
+	// 交易时段行情刷新间隔，运行中修改通过 intervalCh 通知推送循环
+	refreshInterval time.Duration
+	intervalCh      chan time.Duration
+
 	// 控制
 	stopChan  chan struct{}
 	stopped   bool
@@ -92,6 +104,8 @@ func NewMarketDataPusher(marketService *MarketService, configService *ConfigServ
 		configService:   configService,
 		newsService:     newsService,
 		subscribedCodes: make([]string, 0),
+		refreshInterval: tickerNormal,
+		intervalCh:      make(chan time.Duration, 1),
 		stopChan:        make(chan struct{}),
 		readyChan:       make(chan struct{}),
 	}
@@ -124,6 +138,39 @@ func (p *MarketDataPusher) SetReady() {
 	pusherLog.Info("前端已就绪，开始推送数据")
 }
 
+// SetRefreshInterval 设置交易时段股票、指数与分时K线的刷新间隔，非交易时段按该间隔的倍数降频
+func (p *MarketDataPusher) SetRefreshInterval(d time.Duration) {
+	if d <= 0 {
+		d = tickerNormal
+	}
+	p.ctrlMu.Lock()
+	changed := p.refreshInterval != d
+	p.refreshInterval = d
+	p.ctrlMu.Unlock()
+	if !changed {
+		return
+	}
+	// 只保留最新的间隔，推送循环未启动时也不阻塞
+	for {
+		select {
+		case p.intervalCh <- d:
+			return
+		default:
+		}
+		select {
+		case <-p.intervalCh:
+		default:
+		}
+	}
+}
+
+// currentRefreshInterval 当前刷新间隔
+func (p *MarketDataPusher) currentRefreshInterval() time.Duration {
+	p.ctrlMu.Lock()
+	defer p.ctrlMu.Unlock()
+	return p.refreshInterval
+}
+
 // Stop 停止推送服务
 func (p *MarketDataPusher) Stop() {
 	p.ctrlMu.Lock()
@@ -137,6 +184,7 @@ func (p *MarketDataPusher) Stop() {
 	runtime.EventsOff(p.ctx, EventMarketSubscribe)
 	runtime.EventsOff(p.ctx, EventOrderBookSubscribe)
 	runtime.EventsOff(p.ctx, EventKLineSubscribe)
+	runtime.EventsOff(p.ctx, EventStockResync)
 }
 
 // setupEventListeners 设置事件监听
@@ -161,6 +209,15 @@ func (p *MarketDataPusher) setupEventListeners() {
 		}
 	})
 
+	// 监听行情全量同步请求（握手或前端检测到跳号）
+	runtime.EventsOn(p.ctx, EventStockResync, func(data ...any) {
+		p.diffMu.Lock()
+		p.diffMode = true
+		p.differ.last = nil // 下一次推送为全量
+		p.diffMu.Unlock()
+		go safeCall(p.pushStockData)
+	})
+
 	// 监听K线订阅请求
 	runtime.EventsOn(p.ctx, EventKLineSubscribe, func(data ...any) {
 		if len(data) >= 2 {
@@ -218,7 +275,7 @@ func (p *MarketDataPusher) pushLoop() {
 	}
 
 	fastTicker := time.NewTicker(tickerFast)
-	normalTicker := time.NewTicker(tickerNormal)
+	normalTicker := time.NewTicker(p.currentRefreshInterval())
 	slowTicker := time.NewTicker(tickerSlow)
 	klineDayTicker := time.NewTicker(tickerKLineDay)
 
@@ -268,6 +325,8 @@ func (p *MarketDataPusher) pushLoop() {
 						p.pushOrderBookData, p.pushKLineData)
 				}
 			}
+		case d := <-p.intervalCh:
+			normalTicker.Reset(d)
 		case <-slowTicker.C:
 			p.runParallel(8*time.Second, p.pushTelegraphData)
 		case <-klineDayTicker.C:
@@ -341,6 +400,17 @@ func (p *MarketDataPusher) pushStockData() {
 		return
 	}
 
+	p.diffMu.Lock()
+	if p.diffMode {
+		// 持锁发送，保证序号按顺序到达前端
+		if diff, changed := p.differ.diff(stocks, false); changed {
+			runtime.EventsEmit(p.ctx, EventStockDiff, diff)
+		}
+		p.diffMu.Unlock()
+		return
+	}
+	p.diffMu.Unlock()
+
 	// 推送到前端
 	runtime.EventsEmit(p.ctx, EventStockUpdate, stocks)
 }
@@ -103,6 +103,9 @@ type MarketService struct {
 	klineCache    map[string]*klineCache
 	klineCacheMu  sync.RWMutex
 	klineCacheTTL time.Duration
+
+	// 离线快照（盘前预热写入，拉取失败时兜底），未启用时为 nil
+	snapshot *marketSnapshot
 }
 
 // NewMarketService 创建市场数据服务
@@ -254,7 +257,15 @@ func (ms *MarketService) GetStockRealTimeData(codes ...string) ([]models.Stock,
 	if len(codes) == 0 {
 		return nil, nil
 	}
+	stocks, err := ms.fetchQuotes(codes)
+	if err != nil {
+		return ms.quotesFallback(codes, err)
+	}
+	return stocks, nil
+}
 
+// fetchQuotes 从新浪接口获取股票实时行情
+func (ms *MarketService) fetchQuotes(codes []string) ([]models.Stock, error) {
 	codeList := strings.Join(codes, ",")
 	url := fmt.Sprintf(sinaStockURL, time.Now().UnixNano(), codeList)
 
@@ -289,12 +300,14 @@ func (ms *MarketService) parseSinaStockData(data string, codes []string) ([]mode
 			continue
 		}
 		parts := strings.Split(match[2], ",")
-		if len(parts) < 32 {
-			continue
+		switch {
+		case strings.HasPrefix(match[1], "hk") && len(parts) >= 9:
+			stocks = append(stocks, parseHKStockFields(match[1], parts))
+		case strings.HasPrefix(match[1], "gb_") && len(parts) >= 27:
+			stocks = append(stocks, parseUSStockFields(match[1], parts))
+		case len(parts) >= 32:
+			stocks = append(stocks, ms.parseStockFields(match[1], parts))
 		}
-
-		stock := ms.parseStockFields(match[1], parts)
-		stocks = append(stocks, stock)
 	}
 	return stocks, nil
 }
@@ -330,6 +343,59 @@ func (ms *MarketService) parseStockFields(code string, parts []string) models.St
 	}
 }
 
+// parseHKStockFields 解析港股行情
+// 新浪港股格式: 英文名,中文名,今开,昨收,最高,最低,现价,涨跌额,涨跌幅,买一,卖一,成交额,成交量,...
+func parseHKStockFields(code string, parts []string) models.Stock {
+	open, _ := strconv.ParseFloat(parts[2], 64)
+	preClose, _ := strconv.ParseFloat(parts[3], 64)
+	high, _ := strconv.ParseFloat(parts[4], 64)
+	low, _ := strconv.ParseFloat(parts[5], 64)
+	price, _ := strconv.ParseFloat(parts[6], 64)
+	change, _ := strconv.ParseFloat(parts[7], 64)
+	changePercent, _ := strconv.ParseFloat(parts[8], 64)
+	stock := models.Stock{
+		Symbol:        code,
+		Name:          parts[1],
+		Price:         price,
+		Open:          open,
+		High:          high,
+		Low:           low,
+		PreClose:      preClose,
+		Change:        change,
+		ChangePercent: changePercent,
+	}
+	if len(parts) > 12 {
+		stock.Amount, _ = strconv.ParseFloat(parts[11], 64)
+		stock.Volume, _ = strconv.ParseInt(parts[12], 10, 64)
+	}
+	return stock
+}
+
+// parseUSStockFields 解析美股行情
+// 新浪美股格式: 名称,现价,涨跌幅,时间,涨跌额,今开,最高,最低,52周最高,52周最低,成交量,...,昨收(第27项)
+func parseUSStockFields(code string, parts []string) models.Stock {
+	price, _ := strconv.ParseFloat(parts[1], 64)
+	changePercent, _ := strconv.ParseFloat(parts[2], 64)
+	change, _ := strconv.ParseFloat(parts[4], 64)
+	open, _ := strconv.ParseFloat(parts[5], 64)
+	high, _ := strconv.ParseFloat(parts[6], 64)
+	low, _ := strconv.ParseFloat(parts[7], 64)
+	volume, _ := strconv.ParseInt(parts[10], 10, 64)
+	preClose, _ := strconv.ParseFloat(parts[26], 64)
+	return models.Stock{
+		Symbol:        code,
+		Name:          parts[0],
+		Price:         price,
+		Open:          open,
+		High:          high,
+		Low:           low,
+		PreClose:      preClose,
+		Change:        change,
+		ChangePercent: changePercent,
+		Volume:        volume,
+	}
+}
+
 // parseStockWithOrderBook 解析股票字段和真实盘口数据
 // 新浪API返回数据格式: 名称,今开,昨收,当前价,最高,最低,买一价,卖一价,成交量,成交额,
 // 买一量,买一价,买二量,买二价,买三量,买三价,买四量,买四价,买五量,买五价,
@@ -428,22 +494,30 @@ func (ms *MarketService) GetKLineData(code string, period string, days int) ([]m
 	}
 	ms.klineCacheMu.RUnlock()
 
+	if klines, ok := ms.premarketKLines(code, period, days); ok {
+		ms.storeKLineCache(cacheKey, period, klines)
+		return klines, nil
+	}
+
 	// 从API获取数据
 	klines, err := ms.fetchKLineData(code, period, days)
 	if err != nil {
-		return nil, err
+		return ms.klineFallback(code, period, days, err)
 	}
 
-	// 更新缓存
+	ms.storeKLineCache(cacheKey, period, klines)
+	return klines, nil
+}
+
+// storeKLineCache 更新K线缓存
+func (ms *MarketService) storeKLineCache(cacheKey, period string, klines []models.KLineData) {
 	ms.klineCacheMu.Lock()
 	ms.klineCache[cacheKey] = &klineCache{
 		data:      klines,
 		timestamp: time.Now(),
-		ttl:       ttl,
+		ttl:       ms.getKLineCacheTTL(period),
 	}
 	ms.klineCacheMu.Unlock()
-
-	return klines, nil
 }
 
 // fetchKLineData 从API获取K线数据
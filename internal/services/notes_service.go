@@ -0,0 +1,195 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var notesLog = logger.New("notes")
+
+// 笔记章节编辑模式
+const (
+	NoteModeAppend  = "append"  // 追加到章节末尾（章节不存在则创建）
+	NoteModeReplace = "replace" // 覆盖章节内容
+	NoteModeDelete  = "delete"  // 删除章节
+)
+
+// maxNotesPromptLength 注入提示词的笔记最大字符数
+const maxNotesPromptLength = 3000
+
+// stockCodeRe 股票代码（如 sh600519、hk00700、usAAPL），只含字母数字及 . _ -，不以点开头
+var stockCodeRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,31}$`)
+
+// validStockCode 股票代码可安全用作文件名（拒绝 ../config 之类的路径）
+func validStockCode(code string) bool {
+	return stockCodeRe.MatchString(code) && filepath.Base(code) == code
+}
+
+// NotesService 个股研究笔记服务
+type NotesService struct {
+	notesDir string
+	cache    map[string]*models.ResearchNotes
+	mu       sync.RWMutex
+}
+
+// NewNotesService 创建研究笔记服务
+func NewNotesService(dataDir string) *NotesService {
+	s := &NotesService{
+		notesDir: filepath.Join(dataDir, "notes"),
+		cache:    make(map[string]*models.ResearchNotes),
+	}
+	if err := os.MkdirAll(s.notesDir, 0755); err != nil {
+		notesLog.Error("创建notes目录失败: %v", err)
+	}
+	return s
+}
+
+// notesPath 获取笔记文件路径
+func (s *NotesService) notesPath(stockCode string) string {
+	return filepath.Join(s.notesDir, stockCode+".json")
+}
+
+// getNoLock 读取笔记（优先缓存，不存在返回空笔记）
+func (s *NotesService) getNoLock(stockCode string) *models.ResearchNotes {
+	if notes, ok := s.cache[stockCode]; ok {
+		return notes
+	}
+	notes := &models.ResearchNotes{StockCode: stockCode, Sections: []models.NoteSection{}}
+	if !validStockCode(stockCode) {
+		return notes
+	}
+	if data, err := os.ReadFile(s.notesPath(stockCode)); err == nil {
+		if err := json.Unmarshal(data, notes); err != nil {
+			notesLog.Error("解析研究笔记失败 [%s]: %v", stockCode, err)
+		}
+	}
+	s.cache[stockCode] = notes
+	return notes
+}
+
+// saveNoLock 保存笔记
+func (s *NotesService) saveNoLock(notes *models.ResearchNotes) error {
+	notes.UpdatedAt = time.Now().UnixMilli()
+	data, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.notesPath(notes.StockCode), data, 0644)
+}
+
+// GetNotes 获取个股研究笔记
+func (s *NotesService) GetNotes(stockCode string) models.ResearchNotes {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	notes := *s.getNoLock(stockCode)
+	notes.Sections = append([]models.NoteSection(nil), notes.Sections...)
+	return notes
+}
+
+// SaveNotes 整体保存研究笔记（前端编辑器使用）
+func (s *NotesService) SaveNotes(notes models.ResearchNotes) error {
+	if notes.StockCode == "" {
+		return fmt.Errorf("股票代码不能为空")
+	}
+	if !validStockCode(notes.StockCode) {
+		return fmt.Errorf("无效的股票代码: %s", notes.StockCode)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved := notes
+	s.cache[notes.StockCode] = &saved
+	return s.saveNoLock(&saved)
+}
+
+// EditSection 按章节编辑研究笔记
+func (s *NotesService) EditSection(stockCode, stockName, title, content, mode, author string) error {
+	title = strings.TrimSpace(title)
+	if stockCode == "" || title == "" {
+		return fmt.Errorf("股票代码和章节标题不能为空")
+	}
+	if !validStockCode(stockCode) {
+		return fmt.Errorf("无效的股票代码: %s", stockCode)
+	}
+	if mode == "" {
+		mode = NoteModeAppend
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notes := s.getNoLock(stockCode)
+	if stockName != "" {
+		notes.StockName = stockName
+	}
+	now := time.Now().UnixMilli()
+
+	idx := -1
+	for i, sec := range notes.Sections {
+		if sec.Title == title {
+			idx = i
+			break
+		}
+	}
+
+	switch mode {
+	case NoteModeDelete:
+		if idx < 0 {
+			return fmt.Errorf("章节不存在: %s", title)
+		}
+		notes.Sections = append(notes.Sections[:idx], notes.Sections[idx+1:]...)
+	case NoteModeReplace, NoteModeAppend:
+		if idx < 0 {
+			notes.Sections = append(notes.Sections, models.NoteSection{Title: title})
+			idx = len(notes.Sections) - 1
+		}
+		sec := &notes.Sections[idx]
+		if mode == NoteModeAppend && sec.Content != "" {
+			sec.Content += "\n" + content
+		} else {
+			sec.Content = content
+		}
+		sec.UpdatedBy = author
+		sec.UpdatedAt = now
+	default:
+		return fmt.Errorf("不支持的编辑模式: %s", mode)
+	}
+
+	return s.saveNoLock(notes)
+}
+
+// FormatForPrompt 将研究笔记格式化为提示词上下文
+func (s *NotesService) FormatForPrompt(stockCode string) string {
+	notes := s.GetNotes(stockCode)
+	if len(notes.Sections) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("【研究笔记】\n")
+	for _, sec := range notes.Sections {
+		fmt.Fprintf(&sb, "## %s\n%s\n", sec.Title, sec.Content)
+	}
+	return truncateRunes(sb.String(), maxNotesPromptLength)
+}
+
+// DeleteNotes 删除个股研究笔记
+func (s *NotesService) DeleteNotes(stockCode string) error {
+	if !validStockCode(stockCode) {
+		return fmt.Errorf("无效的股票代码: %s", stockCode)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, stockCode)
+	if err := os.Remove(s.notesPath(stockCode)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestNotesService_RejectsInvalidStockCode(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"theme":"military"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s := NewNotesService(dir)
+
+	for _, code := range []string{"../config", "..", "a/b", `a\b`, ".hidden", ""} {
+		if err := s.EditSection(code, "", "投资逻辑", "x", NoteModeReplace, "user"); err == nil {
+			t.Errorf("EditSection(%q) 应返回错误", code)
+		}
+		if err := s.SaveNotes(models.ResearchNotes{StockCode: code}); err == nil {
+			t.Errorf("SaveNotes(%q) 应返回错误", code)
+		}
+		if err := s.DeleteNotes(code); err == nil {
+			t.Errorf("DeleteNotes(%q) 应返回错误", code)
+		}
+	}
+	if data, err := os.ReadFile(configPath); err != nil || string(data) != `{"theme":"military"}` {
+		t.Errorf("config.json 被修改: %q, %v", data, err)
+	}
+
+	if err := s.EditSection("sh600519", "贵州茅台", "投资逻辑", "x", NoteModeReplace, "user"); err != nil {
+		t.Errorf("合法代码编辑失败: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+	"github.com/run-bigpig/jcp/internal/pkg/plaintext"
+	"github.com/run-bigpig/jcp/internal/pkg/proxy"
+)
+
+var notifyLog = logger.New("notify")
+
+// NotifyResult 单个渠道的推送结果
+type NotifyResult struct {
+	Sink  string `json:"sink"`
+	Error string `json:"error,omitempty"`
+}
+
+// NotifyService 把 Markdown 消息推送到已配置的 Webhook 与邮件渠道
+type NotifyService struct {
+	client *http.Client
+	config func() *models.NotifyConfig
+
+	// sendMail 发送邮件，测试时可替换
+	sendMail func(cfg models.EmailSink, subject, body string) error
+}
+
+// NewNotifyService 创建通知服务，config 返回当前通知配置
+func NewNotifyService(config func() *models.NotifyConfig) *NotifyService {
+	return &NotifyService{
+		client:   proxy.GetManager().GetClientWithTimeout(15 * time.Second),
+		config:   config,
+		sendMail: sendSMTP,
+	}
+}
+
+// Send 推送到全部启用的渠道，返回每个渠道的结果；没有启用任何渠道时返回空
+func (s *NotifyService) Send(ctx context.Context, title, markdown string) []NotifyResult {
+	cfg := s.config()
+	if cfg == nil {
+		return nil
+	}
+	var results []NotifyResult
+	for _, w := range cfg.Webhooks {
+		if !w.Enabled || w.URL == "" {
+			continue
+		}
+		name := w.Name
+		if name == "" {
+			name = w.Kind
+		}
+		r := NotifyResult{Sink: "webhook:" + name}
+		if err := s.postWebhook(ctx, w, title, markdown); err != nil {
+			notifyLog.Warn("Webhook 推送失败 [%s]: %v", name, err)
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	if cfg.Email.Enabled && cfg.Email.Host != "" && len(cfg.Email.To) > 0 {
+		r := NotifyResult{Sink: "email"}
+		if err := s.sendMail(cfg.Email, title, plaintext.Render(markdown)); err != nil {
+			notifyLog.Warn("邮件推送失败: %v", err)
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// webhookPayload 按机器人类型构造消息体（飞书文本消息不支持 Markdown，使用纯文本；通用 Webhook 同时附带两种格式）
+func webhookPayload(kind, title, markdown string) any {
+	switch kind {
+	case models.WebhookWeCom:
+		return map[string]any{"msgtype": "markdown", "markdown": map[string]string{"content": "## " + title + "\n" + markdown}}
+	case models.WebhookDingTalk:
+		return map[string]any{"msgtype": "markdown", "markdown": map[string]string{"title": title, "text": "## " + title + "\n" + markdown}}
+	case models.WebhookFeishu:
+		return map[string]any{"msg_type": "text", "content": map[string]string{"text": title + "\n" + plaintext.Render(markdown)}}
+	}
+	return map[string]string{"title": title, "content": markdown, "text": plaintext.Render(markdown)}
+}
+
+// postWebhook 发送一条 Webhook 消息
+func (s *NotifyService) postWebhook(ctx context.Context, w models.WebhookSink, title, markdown string) error {
+	body, err := json.Marshal(webhookPayload(w.Kind, title, markdown))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendSMTP 通过 SMTP 发送纯文本邮件
+func sendSMTP(cfg models.EmailSink, subject, body string) error {
+	port := cfg.Port
+	if port == 0 {
+		port = 465
+	}
+	from := cfg.From
+	if from == "" {
+		from = cfg.Username
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	msg := buildMail(from, cfg.To, subject, body)
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	if port != 465 {
+		// SendMail 在服务端支持时自动升级 STARTTLS
+		return smtp.SendMail(addr, auth, from, cfg.To, msg)
+	}
+
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 15 * time.Second}, "tcp", addr, &tls.Config{ServerName: cfg.Host})
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, to := range cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMail 构造 UTF-8 邮件（主题按 RFC 2047 编码）
+func buildMail(from string, to []string, subject, body string) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", from)
+	fmt.Fprintf(&sb, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&sb, "Subject: =?UTF-8?B?%s?=\r\n", base64.StdEncoding.EncodeToString([]byte(subject)))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		sb.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	sb.WriteString(encoded + "\r\n")
+	return []byte(sb.String())
+}
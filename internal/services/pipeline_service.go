@@ -0,0 +1,172 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+	"go.yaml.in/yaml/v3"
+)
+
+var pipelineLog = logger.New("pipeline")
+
+// PipelineService 声明式流水线服务
+// 从 dataDir/pipelines 目录加载 *.yaml / *.yml / *.json，支持运行时重新加载
+type PipelineService struct {
+	dir       string
+	pipelines map[string]*models.Pipeline
+	errors    map[string]string // 文件名 -> 加载错误
+	mu        sync.RWMutex
+}
+
+// NewPipelineService 创建流水线服务
+func NewPipelineService(dataDir string) *PipelineService {
+	ps := &PipelineService{
+		dir:       filepath.Join(dataDir, "pipelines"),
+		pipelines: make(map[string]*models.Pipeline),
+		errors:    make(map[string]string),
+	}
+	if err := os.MkdirAll(ps.dir, 0755); err != nil {
+		pipelineLog.Warn("创建流水线目录失败: %v", err)
+	}
+	ps.Reload()
+	return ps
+}
+
+// Dir 返回流水线目录
+func (ps *PipelineService) Dir() string {
+	return ps.dir
+}
+
+// Reload 重新扫描目录加载全部流水线，单个文件出错不影响其他文件
+func (ps *PipelineService) Reload() {
+	pipelines := make(map[string]*models.Pipeline)
+	loadErrors := make(map[string]string)
+
+	entries, err := os.ReadDir(ps.dir)
+	if err != nil && !os.IsNotExist(err) {
+		pipelineLog.Warn("读取流水线目录失败: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+			continue
+		}
+		p, err := ps.loadFile(filepath.Join(ps.dir, name))
+		if err != nil {
+			pipelineLog.Warn("加载流水线 %s 失败: %v", name, err)
+			loadErrors[name] = err.Error()
+			continue
+		}
+		if existing, ok := pipelines[p.ID]; ok {
+			loadErrors[name] = fmt.Sprintf("流水线 ID 重复: %s（已由 %s 定义）", p.ID, existing.Source)
+			continue
+		}
+		p.Source = name
+		pipelines[p.ID] = p
+	}
+
+	ps.mu.Lock()
+	ps.pipelines = pipelines
+	ps.errors = loadErrors
+	ps.mu.Unlock()
+	pipelineLog.Info("已加载 %d 条流水线", len(pipelines))
+}
+
+// loadFile 解析单个流水线文件
+func (ps *PipelineService) loadFile(path string) (*models.Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p models.Pipeline
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &p)
+	} else {
+		err = yaml.Unmarshal(data, &p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析失败: %w", err)
+	}
+	if p.ID == "" {
+		p.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := ValidatePipeline(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ValidatePipeline 校验流水线定义
+func ValidatePipeline(p *models.Pipeline) error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("流水线 %s 没有步骤", p.ID)
+	}
+	seen := make(map[string]bool)
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.ID == "" {
+			step.ID = fmt.Sprintf("step%d", i+1)
+		}
+		if seen[step.ID] {
+			return fmt.Errorf("步骤 ID 重复: %s", step.ID)
+		}
+		seen[step.ID] = true
+		if step.Name == "" {
+			step.Name = step.ID
+		}
+		if strings.TrimSpace(step.Instruction) == "" {
+			return fmt.Errorf("步骤 %s 缺少 instruction", step.ID)
+		}
+	}
+	return nil
+}
+
+// List 获取全部流水线（按 ID 排序）
+func (ps *PipelineService) List() []models.Pipeline {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	result := make([]models.Pipeline, 0, len(ps.pipelines))
+	for _, p := range ps.pipelines {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Get 按 ID 获取流水线
+func (ps *PipelineService) Get(id string) (*models.Pipeline, bool) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	p, ok := ps.pipelines[id]
+	if !ok {
+		return nil, false
+	}
+	cp := *p
+	cp.Steps = append([]models.PipelineStep(nil), p.Steps...)
+	return &cp, true
+}
+
+// LoadErrors 获取最近一次加载失败的文件及原因
+func (ps *PipelineService) LoadErrors() map[string]string {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	result := make(map[string]string, len(ps.errors))
+	for k, v := range ps.errors {
+		result[k] = v
+	}
+	return result
+}
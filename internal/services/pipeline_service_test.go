@@ -0,0 +1,45 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPipelineService_LoadYAMLAndJSON(t *testing.T) {
+	dir := t.TempDir()
+	pipelineDir := filepath.Join(dir, "pipelines")
+	if err := os.MkdirAll(pipelineDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	yamlDef := `name: 估值复核
+steps:
+  - id: fundamental
+    instruction: 你是基本面研究员
+    tools: [get_research_report]
+  - instruction: 汇总上一步结论
+    prompt: "{{.Prev}}"
+    outputSchema:
+      type: object
+      required: [rating]
+`
+	os.WriteFile(filepath.Join(pipelineDir, "valuation.yaml"), []byte(yamlDef), 0644)
+	os.WriteFile(filepath.Join(pipelineDir, "broken.json"), []byte(`{"id":"broken","steps":[{"id":"a"}]}`), 0644)
+
+	ps := NewPipelineService(dir)
+
+	p, ok := ps.Get("valuation")
+	if !ok {
+		t.Fatalf("未加载 valuation 流水线, errors=%v", ps.LoadErrors())
+	}
+	if len(p.Steps) != 2 || p.Steps[1].ID != "step2" {
+		t.Fatalf("步骤解析异常: %+v", p.Steps)
+	}
+	if p.Steps[1].OutputSchema["type"] != "object" {
+		t.Errorf("outputSchema 解析异常: %+v", p.Steps[1].OutputSchema)
+	}
+	if _, ok := ps.LoadErrors()["broken.json"]; !ok {
+		t.Error("缺少 instruction 的流水线应记录加载错误")
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"math"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// BuildPortfolio 按汇率把各币种持仓折算为本位币并汇总盈亏
+// positions 需已填写代码、数量、成本价与现价，Currency 为空时按股票代码推断；没有现价的持仓市值按成本计
+func BuildPortfolio(positions []models.PortfolioPosition, baseCurrency string, rates models.FXRates) models.Portfolio {
+	base := normalizeCurrency(baseCurrency)
+	p := models.Portfolio{
+		BaseCurrency:   base,
+		Positions:      make([]models.PortfolioPosition, 0, len(positions)),
+		Rates:          make(map[string]float64),
+		RatesUpdatedAt: rates.UpdatedAt,
+	}
+	for _, pos := range positions {
+		if pos.Currency == "" {
+			pos.Currency = models.CurrencyForCode(pos.StockCode)
+		}
+		pos.Currency = normalizeCurrency(pos.Currency)
+		rate, ok := CrossRate(rates, pos.Currency, base)
+		if !ok {
+			fxLog.Warn("缺少汇率 %s/%s，持仓 %s 未计入组合", pos.Currency, base, pos.StockCode)
+			continue
+		}
+		p.Rates[pos.Currency] = rate
+
+		shares := float64(pos.Shares)
+		pos.Cost = shares * pos.CostPrice
+		price := pos.Price
+		if price <= 0 {
+			price = pos.CostPrice
+			pos.Stale = true
+		}
+		pos.MarketValue = shares * price
+		pos.PnL = pos.MarketValue - pos.Cost
+		pos.PnLPercent = percent(pos.PnL, pos.Cost)
+		pos.FXRate = rate
+		pos.BaseCost = pos.Cost * rate
+		pos.BaseMarketValue = pos.MarketValue * rate
+		pos.BasePnL = pos.BaseMarketValue - pos.BaseCost
+
+		p.TotalCost += pos.BaseCost
+		p.TotalMarketValue += pos.BaseMarketValue
+		p.Positions = append(p.Positions, pos)
+	}
+	p.TotalPnL = p.TotalMarketValue - p.TotalCost
+	p.TotalPnLPercent = percent(p.TotalPnL, p.TotalCost)
+	return p
+}
+
+// percent 计算百分比并保留两位小数，分母为 0 时返回 0
+func percent(v, base float64) float64 {
+	if base == 0 {
+		return 0
+	}
+	return math.Round(v/base*10000) / 100
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestBuildPortfolioMultiCurrency(t *testing.T) {
+	rates := models.FXRates{Rates: map[string]float64{"CNY": 1, "USD": 7, "HKD": 0.9}}
+	positions := []models.PortfolioPosition{
+		{StockCode: "sh600519", Shares: 100, CostPrice: 1500, Price: 1600},
+		{StockCode: "hk00700", Shares: 200, CostPrice: 300, Price: 350},
+		{StockCode: "gb_aapl", Shares: 10, CostPrice: 200, Price: 0},
+	}
+
+	p := BuildPortfolio(positions, "", rates)
+	if p.BaseCurrency != "CNY" || len(p.Positions) != 3 {
+		t.Fatalf("unexpected portfolio: %+v", p)
+	}
+	hk := p.Positions[1]
+	if hk.Currency != "HKD" || hk.PnL != 10000 || math.Abs(hk.BasePnL-9000) > 1e-6 {
+		t.Fatalf("unexpected HK position: %+v", hk)
+	}
+	if us := p.Positions[2]; !us.Stale || us.PnL != 0 || us.BaseCost != 14000 {
+		t.Fatalf("unexpected US position: %+v", us)
+	}
+	// 10000 + 9000 + 0
+	if math.Abs(p.TotalPnL-19000) > 1e-6 {
+		t.Fatalf("unexpected total pnl: %v", p.TotalPnL)
+	}
+
+	usd := BuildPortfolio(positions[:1], "usd", rates)
+	if usd.BaseCurrency != "USD" || math.Abs(usd.TotalCost-150000.0/7) > 1e-6 {
+		t.Fatalf("unexpected USD portfolio: %+v", usd)
+	}
+}
+
+func TestParseSinaFX(t *testing.T) {
+	data := `var hq_str_fx_susdcny="10:29:56,7.1230,7.1240,7.1200,0,7.1100,7.1300,7.1000,7.1235,在岸人民币";
+var hq_str_fx_shkdcny="10:29:56,0.9120,,,,,,,,港元人民币";`
+	rates := parseSinaFX(data)
+	if rates["USD"] != 7.1235 || rates["HKD"] != 0.912 {
+		t.Fatalf("unexpected rates: %v", rates)
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// quoteDiffer 记录已推送给前端的行情，计算字段级增量（调用方负责加锁）
+type quoteDiffer struct {
+	seq  uint64
+	last map[string]map[string]any // 股票代码 -> 已推送的字段
+}
+
+// diff 与上次推送比较；full 为 true 时重置基线并返回全量
+// 没有任何变化时返回 false，不消耗序号
+func (d *quoteDiffer) diff(stocks []models.Stock, full bool) (models.QuoteDiff, bool) {
+	if full || d.last == nil {
+		d.last = make(map[string]map[string]any)
+		full = true
+	}
+	result := models.QuoteDiff{Full: full, Changes: []models.QuotePatch{}}
+	seen := make(map[string]bool, len(stocks))
+	for _, s := range stocks {
+		if s.Symbol == "" || seen[s.Symbol] {
+			continue
+		}
+		seen[s.Symbol] = true
+		fields := stockFields(s)
+		prev := d.last[s.Symbol]
+		changed := make(map[string]any)
+		for k, v := range fields {
+			if old, ok := prev[k]; !ok || !reflect.DeepEqual(old, v) {
+				changed[k] = v
+			}
+		}
+		d.last[s.Symbol] = fields
+		if len(changed) > 0 {
+			result.Changes = append(result.Changes, models.QuotePatch{Symbol: s.Symbol, Fields: changed})
+		}
+	}
+	for code := range d.last {
+		if !seen[code] {
+			delete(d.last, code)
+			result.Removed = append(result.Removed, code)
+		}
+	}
+	sort.Strings(result.Removed)
+	if !full && len(result.Changes) == 0 && len(result.Removed) == 0 {
+		return result, false
+	}
+	d.seq++
+	result.Seq = d.seq
+	return result, true
+}
+
+// stockFields 把行情转换为 JSON 字段表（与前端收到的字段名一致）
+func stockFields(s models.Stock) map[string]any {
+	data, _ := json.Marshal(s)
+	var fields map[string]any
+	_ = json.Unmarshal(data, &fields)
+	delete(fields, "symbol")
+	return fields
+}
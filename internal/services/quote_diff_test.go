@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestQuoteDiffer(t *testing.T) {
+	var d quoteDiffer
+	a := models.Stock{Symbol: "sh600519", Name: "贵州茅台", Price: 1500, Volume: 100}
+	b := models.Stock{Symbol: "sz000001", Name: "平安银行", Price: 10}
+
+	first, ok := d.diff([]models.Stock{a, b}, false)
+	if !ok || !first.Full || first.Seq != 1 || len(first.Changes) != 2 || len(first.Changes[0].Fields) < 10 {
+		t.Fatalf("first = %+v", first)
+	}
+
+	// 无变化不推送、不消耗序号
+	if _, ok := d.diff([]models.Stock{a, b}, false); ok {
+		t.Fatal("unchanged quotes produced a diff")
+	}
+
+	a.Price, a.Volume = 1501, 120
+	next, ok := d.diff([]models.Stock{a}, false)
+	if !ok || next.Full || next.Seq != 2 {
+		t.Fatalf("next = %+v", next)
+	}
+	if len(next.Changes) != 1 || len(next.Changes[0].Fields) != 2 || next.Changes[0].Fields["price"] != 1501.0 {
+		t.Fatalf("changes = %+v", next.Changes)
+	}
+	if len(next.Removed) != 1 || next.Removed[0] != "sz000001" {
+		t.Fatalf("removed = %v", next.Removed)
+	}
+
+	// 重新同步返回全量
+	full, _ := d.diff([]models.Stock{a}, true)
+	if !full.Full || full.Seq != 3 || len(full.Changes[0].Fields) < 10 {
+		t.Fatalf("full = %+v", full)
+	}
+}
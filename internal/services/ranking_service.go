@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+var rankingLog = logger.New("ranking")
+
+// rankingConcurrency 并发评分数
+const rankingConcurrency = 3
+
+// DefaultRubric 默认评分标准
+var DefaultRubric = []models.RubricCriterion{
+	{Key: "fundamental", Name: "基本面", Description: "盈利能力、估值水平、行业地位", Weight: 0.3},
+	{Key: "technical", Name: "技术面", Description: "趋势、量价配合、关键支撑压力", Weight: 0.25},
+	{Key: "capital", Name: "资金面", Description: "主力资金动向、成交活跃度", Weight: 0.2},
+	{Key: "sentiment", Name: "情绪面", Description: "市场关注度、题材热度", Weight: 0.15},
+	{Key: "risk", Name: "风险控制", Description: "分数越高风险越低：波动、利空、估值泡沫", Weight: 0.1},
+}
+
+// RankingService 自选股批量评分服务
+type RankingService struct {
+	reportsDir string
+	mu         sync.RWMutex
+}
+
+// NewRankingService 创建批量评分服务
+func NewRankingService(dataDir string) *RankingService {
+	s := &RankingService{
+		reportsDir: filepath.Join(dataDir, "rankings"),
+	}
+	if err := os.MkdirAll(s.reportsDir, 0755); err != nil {
+		rankingLog.Error("创建rankings目录失败: %v", err)
+	}
+	return s
+}
+
+// RankingInput 批量评分输入
+type RankingInput struct {
+	Stocks    []models.Stock
+	Rubric    []models.RubricCriterion // 空则使用默认标准
+	ModelName string
+	// SessionFor 返回股票已有的会话ID（没有则为空），用于报告下钻
+	SessionFor func(code, name string) string
+	// OnProgress 每完成一只股票回调一次
+	OnProgress func(done, total int, row models.RankingRow)
+}
+
+// stockScoreResult LLM 结构化输出
+type stockScoreResult struct {
+	Scores  []models.CriterionScore `json:"scores"`
+	Summary string                  `json:"summary"`
+}
+
+// Run 对所有股票按评分标准打分并生成排名报告
+func (s *RankingService) Run(ctx context.Context, llm model.LLM, input RankingInput) (*models.RankingReport, error) {
+	if llm == nil {
+		return nil, fmt.Errorf("LLM未配置")
+	}
+	if len(input.Stocks) == 0 {
+		return nil, fmt.Errorf("自选股为空")
+	}
+	rubric := input.Rubric
+	if len(rubric) == 0 {
+		rubric = DefaultRubric
+	}
+	if err := validateRubric(rubric); err != nil {
+		return nil, err
+	}
+
+	rows := make([]models.RankingRow, len(input.Stocks))
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+		sem  = make(chan struct{}, rankingConcurrency)
+	)
+	for i, stock := range input.Stocks {
+		wg.Add(1)
+		go func(i int, stock models.Stock) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				rows[i] = models.RankingRow{StockCode: stock.Symbol, StockName: stock.Name, Error: ctx.Err().Error()}
+				return
+			}
+			defer func() { <-sem }()
+
+			row := s.scoreStock(ctx, llm, stock, rubric)
+			if input.SessionFor != nil {
+				row.SessionID = input.SessionFor(stock.Symbol, stock.Name)
+			}
+			rows[i] = row
+
+			mu.Lock()
+			done++
+			current := done
+			mu.Unlock()
+			if input.OnProgress != nil {
+				input.OnProgress(current, len(input.Stocks), row)
+			}
+		}(i, stock)
+	}
+	wg.Wait()
+
+	sortRankingRows(rows)
+
+	report := &models.RankingReport{
+		ID:        uuid.New().String(),
+		Rubric:    rubric,
+		Rows:      rows,
+		AIConfig:  input.ModelName,
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	if err := s.saveReport(report); err != nil {
+		return report, fmt.Errorf("保存报告失败: %w", err)
+	}
+	rankingLog.Info("批量评分完成: %d 只股票", len(rows))
+	return report, nil
+}
+
+// scoreStock 对单只股票评分
+func (s *RankingService) scoreStock(ctx context.Context, llm model.LLM, stock models.Stock, rubric []models.RubricCriterion) models.RankingRow {
+	row := models.RankingRow{
+		StockCode: stock.Symbol,
+		StockName: stock.Name,
+		Price:     stock.Price,
+		Change:    stock.ChangePercent,
+	}
+
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{
+			{Role: "user", Parts: []*genai.Part{{Text: buildRankingPrompt(stock, rubric)}}},
+		},
+		Config: &genai.GenerateContentConfig{
+			ResponseMIMEType:   "application/json",
+			ResponseJsonSchema: rankingSchema(rubric),
+		},
+	}
+
+	var text strings.Builder
+	for resp, err := range llm.GenerateContent(ctx, req, false) {
+		if err != nil {
+			row.Error = err.Error()
+			return row
+		}
+		if resp != nil && resp.Content != nil {
+			for _, part := range resp.Content.Parts {
+				if !part.Thought && part.Text != "" {
+					text.WriteString(part.Text)
+				}
+			}
+		}
+	}
+
+	var result stockScoreResult
+	jsonStr := extractJSON(text.String())
+	if jsonStr == "" {
+		row.Error = "未找到有效JSON"
+		return row
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+		row.Error = fmt.Sprintf("JSON解析失败: %v", err)
+		return row
+	}
+
+	row.Scores = result.Scores
+	row.Summary = result.Summary
+	row.TotalScore = weightedScore(rubric, result.Scores)
+	return row
+}
+
+// buildRankingPrompt 构建评分提示词
+func buildRankingPrompt(stock models.Stock, rubric []models.RubricCriterion) string {
+	var sb strings.Builder
+	sb.WriteString("你是专业的A股分析师，请根据评分标准对以下股票逐项打分（0-10分）。\n\n")
+	fmt.Fprintf(&sb, "## 股票\n%s(%s)\n", stock.Name, stock.Symbol)
+	fmt.Fprintf(&sb, "现价 %.2f，涨跌幅 %.2f%%，开盘 %.2f，最高 %.2f，最低 %.2f，昨收 %.2f，成交额 %.0f\n\n",
+		stock.Price, stock.ChangePercent, stock.Open, stock.High, stock.Low, stock.PreClose, stock.Amount)
+	sb.WriteString("## 评分标准\n")
+	for _, c := range rubric {
+		fmt.Fprintf(&sb, "- %s(%s): %s\n", c.Key, c.Name, c.Description)
+	}
+	sb.WriteString("\n## 输出格式（纯JSON）\n")
+	sb.WriteString(`{"scores":[{"key":"维度key","score":0-10,"reason":"简短理由"}],"summary":"一句话结论"}`)
+	return sb.String()
+}
+
+// rankingSchema 构建结构化输出 JSON Schema
+func rankingSchema(rubric []models.RubricCriterion) map[string]any {
+	keys := make([]any, len(rubric))
+	for i, c := range rubric {
+		keys[i] = c.Key
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"scores": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"key":    map[string]any{"type": "string", "enum": keys},
+						"score":  map[string]any{"type": "number", "minimum": 0, "maximum": 10},
+						"reason": map[string]any{"type": "string"},
+					},
+					"required": []any{"key", "score", "reason"},
+				},
+			},
+			"summary": map[string]any{"type": "string"},
+		},
+		"required": []any{"scores", "summary"},
+	}
+}
+
+// validateRubric 校验评分标准：维度 key 不能为空或重复，权重必须大于 0
+func validateRubric(rubric []models.RubricCriterion) error {
+	keys := make(map[string]bool, len(rubric))
+	for _, c := range rubric {
+		if c.Key == "" {
+			return fmt.Errorf("评分维度 %s 缺少 key", c.Name)
+		}
+		if keys[c.Key] {
+			return fmt.Errorf("评分维度重复: %s", c.Key)
+		}
+		keys[c.Key] = true
+		if c.Weight <= 0 {
+			return fmt.Errorf("评分维度 %s 的权重必须大于 0", c.Name)
+		}
+	}
+	return nil
+}
+
+// weightedScore 计算加权总分（0-100），权重已由 validateRubric 保证为正
+func weightedScore(rubric []models.RubricCriterion, scores []models.CriterionScore) float64 {
+	byKey := make(map[string]float64, len(scores))
+	for _, sc := range scores {
+		byKey[sc.Key] = math.Max(0, math.Min(10, sc.Score))
+	}
+	var total, weights float64
+	for _, c := range rubric {
+		total += byKey[c.Key] * c.Weight
+		weights += c.Weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return math.Round(total/weights*10*100) / 100
+}
+
+// sortRankingRows 按总分降序排列，失败项排在最后
+func sortRankingRows(rows []models.RankingRow) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if (rows[i].Error == "") != (rows[j].Error == "") {
+			return rows[i].Error == ""
+		}
+		return rows[i].TotalScore > rows[j].TotalScore
+	})
+	for i := range rows {
+		rows[i].Rank = i + 1
+	}
+}
+
+// reportPath 获取报告文件路径，报告 ID 必须是 Run 生成的 UUID（拒绝 ../config 之类的路径）
+func (s *RankingService) reportPath(id string) (string, error) {
+	if _, err := uuid.Parse(id); err != nil || len(id) != 36 {
+		return "", fmt.Errorf("无效的报告ID: %s", id)
+	}
+	return filepath.Join(s.reportsDir, id+".json"), nil
+}
+
+// saveReport 保存报告
+func (s *RankingService) saveReport(report *models.RankingReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path, err := s.reportPath(report.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// GetReport 获取报告
+func (s *RankingService) GetReport(id string) (*models.RankingReport, error) {
+	path, err := s.reportPath(id)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("report not found: %s", id)
+	}
+	var report models.RankingReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports 列出所有报告（按时间倒序）
+func (s *RankingService) ListReports() []models.RankingReportSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, err := os.ReadDir(s.reportsDir)
+	if err != nil {
+		return []models.RankingReportSummary{}
+	}
+	result := make([]models.RankingReportSummary, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.reportsDir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var report models.RankingReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			continue
+		}
+		summary := models.RankingReportSummary{
+			ID:         report.ID,
+			StockCount: len(report.Rows),
+			CreatedAt:  report.CreatedAt,
+		}
+		if len(report.Rows) > 0 {
+			summary.TopStock = report.Rows[0].StockName
+		}
+		result = append(result, summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt > result[j].CreatedAt })
+	return result
+}
+
+// DeleteReport 删除报告
+func (s *RankingService) DeleteReport(id string) error {
+	path, err := s.reportPath(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
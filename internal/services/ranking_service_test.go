@@ -0,0 +1,65 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestRankingService_RejectsNonUUIDReportID(t *testing.T) {
+	dir := t.TempDir()
+	s := NewRankingService(dir)
+	victim := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(victim, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"../config", "..", "", "abc", uuid.New().String() + "/../../config"} {
+		if err := s.DeleteReport(id); err == nil {
+			t.Errorf("DeleteReport(%q) should fail", id)
+		}
+		if _, err := s.GetReport(id); err == nil {
+			t.Errorf("GetReport(%q) should fail", id)
+		}
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Fatalf("config.json should be untouched: %v", err)
+	}
+
+	report := &models.RankingReport{ID: uuid.New().String()}
+	if err := s.saveReport(report); err != nil {
+		t.Fatalf("saveReport: %v", err)
+	}
+	if _, err := s.GetReport(report.ID); err != nil {
+		t.Fatalf("GetReport(valid id): %v", err)
+	}
+	if err := s.DeleteReport(report.ID); err != nil {
+		t.Fatalf("DeleteReport(valid id): %v", err)
+	}
+}
+
+func TestValidateRubric_RejectsNonPositiveWeight(t *testing.T) {
+	if err := validateRubric(DefaultRubric); err != nil {
+		t.Fatalf("default rubric should be valid: %v", err)
+	}
+	cases := [][]models.RubricCriterion{
+		{{Key: "a", Name: "A", Weight: 1}, {Key: "b", Name: "B", Weight: 0}},
+		{{Key: "a", Name: "A", Weight: -0.5}},
+		{{Key: "a", Name: "A", Weight: 1}, {Key: "a", Name: "A2", Weight: 1}},
+		{{Name: "A", Weight: 1}},
+	}
+	for i, rubric := range cases {
+		if err := validateRubric(rubric); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+
+	rubric := []models.RubricCriterion{{Key: "a", Weight: 3}, {Key: "b", Weight: 1}}
+	scores := []models.CriterionScore{{Key: "a", Score: 10}, {Key: "b", Score: 0}}
+	if got := weightedScore(rubric, scores); got != 75 {
+		t.Errorf("weightedScore = %v, want 75", got)
+	}
+}
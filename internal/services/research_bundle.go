@@ -0,0 +1,399 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var bundleLog = logger.New("bundle")
+
+const (
+	// bundleMagic 文件头标识（含格式版本）
+	bundleMagic = "JCPBNDL1"
+	// bundleSaltSize 口令派生密钥的盐长度
+	bundleSaltSize = 16
+	// bundleKDFIterations PBKDF2-SHA256 迭代次数
+	bundleKDFIterations = 600000
+	// bundleMinPassphrase 口令最小长度
+	bundleMinPassphrase = 8
+	// bundleManifestName 清单在归档中的文件名
+	bundleManifestName = "manifest.json"
+	// bundleConfigName 去除密钥后的配置在归档中的文件名
+	bundleConfigName = "config.json"
+)
+
+// researchBundleEntries 快照包含的数据（相对数据目录）：会话与持仓、研究笔记、分析产物、记忆与关键事实、排行报告、自选股、触发器、策略与周报
+// 行情缓存、日志、追踪与任务队列等运行数据不包含在内
+var researchBundleEntries = []string{
+	"sessions", "notes", "artifacts", "memories", "rankings", "pipelines",
+	"watchlist.json", "triggers.json", "strategies.json", "digests.json",
+}
+
+// ExportResearchBundle 把研究状态打包为加密的只读快照：ZIP 归档（含 SHA-256 清单）经 AES-256-GCM 加密，密钥由口令经 PBKDF2 派生
+// cfg 非空时附带去除全部密钥与口令后的配置
+func ExportResearchBundle(dataDir string, cfg *models.AppConfig, dest, passphrase string) (models.ResearchBundleInfo, error) {
+	info := models.ResearchBundleInfo{Path: dest}
+	if len([]rune(passphrase)) < bundleMinPassphrase {
+		return info, fmt.Errorf("口令至少需要 %d 个字符", bundleMinPassphrase)
+	}
+	if dest == "" || !filepath.IsAbs(dest) {
+		return info, fmt.Errorf("导出路径必须是绝对路径")
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	manifest := models.ResearchBundleManifest{Version: models.ResearchBundleVersion, CreatedAt: time.Now().UnixMilli()}
+	add := func(rel string, data []byte) error {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: rel, Method: zip.Deflate, Modified: time.UnixMilli(manifest.CreatedAt)})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		manifest.Files = append(manifest.Files, models.ResearchBundleFile{Path: rel, Size: int64(len(data)), SHA256: hex.EncodeToString(sum[:])})
+		return nil
+	}
+
+	for _, entry := range researchBundleEntries {
+		root := filepath.Join(dataDir, entry)
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(dataDir, p)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			return add(filepath.ToSlash(rel), data)
+		})
+		if err != nil {
+			return info, fmt.Errorf("读取 %s 失败: %w", entry, err)
+		}
+	}
+	if cfg != nil {
+		data, err := json.MarshalIndent(RedactConfigSecrets(*cfg), "", "  ")
+		if err != nil {
+			return info, err
+		}
+		if err := add(bundleConfigName, data); err != nil {
+			return info, err
+		}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return info, err
+	}
+	w, err := zw.Create(bundleManifestName)
+	if err != nil {
+		return info, err
+	}
+	if _, err := w.Write(manifestData); err != nil {
+		return info, err
+	}
+	if err := zw.Close(); err != nil {
+		return info, err
+	}
+
+	sealed, err := sealBundle(buf.Bytes(), passphrase)
+	if err != nil {
+		return info, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return info, err
+	}
+	if err := atomicWriteFile(dest, sealed); err != nil {
+		return info, err
+	}
+	fillBundleInfo(&info, &manifest)
+	bundleLog.Info("研究快照已导出: %s（%d 个文件）", dest, info.Files)
+	return info, nil
+}
+
+// VerifyResearchBundle 解密并校验快照：口令、清单版本、文件完整性与路径安全，不写入任何文件
+func VerifyResearchBundle(src, passphrase string) (models.ResearchBundleInfo, error) {
+	info := models.ResearchBundleInfo{Path: src}
+	_, manifest, err := openResearchBundle(src, passphrase)
+	if err != nil {
+		return info, err
+	}
+	fillBundleInfo(&info, manifest)
+	return info, nil
+}
+
+// ExtractResearchBundle 校验通过后把快照解包到 target（需不存在或为空目录），文件设为只读，不影响当前数据目录
+func ExtractResearchBundle(src, passphrase, target string) (models.ResearchBundleInfo, error) {
+	info := models.ResearchBundleInfo{Path: src}
+	if target == "" || !filepath.IsAbs(target) {
+		return info, fmt.Errorf("解包目录必须是绝对路径")
+	}
+	zr, manifest, err := openResearchBundle(src, passphrase)
+	if err != nil {
+		return info, err
+	}
+	if entries, err := os.ReadDir(target); err == nil && len(entries) > 0 {
+		return info, fmt.Errorf("解包目录不为空: %s", target)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	for _, mf := range manifest.Files {
+		data, err := readZipFile(files[mf.Path])
+		if err != nil {
+			os.RemoveAll(target)
+			return info, err
+		}
+		dst := filepath.Join(target, filepath.FromSlash(mf.Path))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			os.RemoveAll(target)
+			return info, err
+		}
+		if err := os.WriteFile(dst, data, 0444); err != nil {
+			os.RemoveAll(target)
+			return info, err
+		}
+	}
+	fillBundleInfo(&info, manifest)
+	info.Extracted = target
+	bundleLog.Info("研究快照已解包: %s -> %s", src, target)
+	return info, nil
+}
+
+// openResearchBundle 解密快照并逐个校验清单中的文件
+func openResearchBundle(src, passphrase string) (*zip.Reader, *models.ResearchBundleManifest, error) {
+	sealed, err := os.ReadFile(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	plain, err := openBundle(sealed, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(plain), int64(len(plain)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("快照归档损坏: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	data, err := readZipFile(files[bundleManifestName])
+	if err != nil {
+		return nil, nil, fmt.Errorf("快照缺少清单: %w", err)
+	}
+	var manifest models.ResearchBundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("快照清单无效: %w", err)
+	}
+	if manifest.Version < 1 || manifest.Version > models.ResearchBundleVersion {
+		return nil, nil, fmt.Errorf("不支持的快照版本: %d", manifest.Version)
+	}
+	if len(manifest.Files)+1 != len(zr.File) {
+		return nil, nil, fmt.Errorf("快照文件数与清单不一致")
+	}
+	for _, mf := range manifest.Files {
+		if !safeBundlePath(mf.Path) {
+			return nil, nil, fmt.Errorf("快照包含非法路径: %s", mf.Path)
+		}
+		data, err := readZipFile(files[mf.Path])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", mf.Path, err)
+		}
+		sum := sha256.Sum256(data)
+		if int64(len(data)) != mf.Size || hex.EncodeToString(sum[:]) != mf.SHA256 {
+			return nil, nil, fmt.Errorf("文件校验失败: %s", mf.Path)
+		}
+	}
+	return zr, &manifest, nil
+}
+
+// sealBundle 加密：magic | salt | nonce | AES-256-GCM 密文（文件头作为附加认证数据）
+func sealBundle(plain []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, bundleSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header := append(append([]byte(bundleMagic), salt...), nonce...)
+	return gcm.Seal(header, nonce, plain, header), nil
+}
+
+// openBundle 解密，口令错误与数据被篡改均无法通过认证
+func openBundle(sealed []byte, passphrase string) ([]byte, error) {
+	if len(sealed) < len(bundleMagic)+bundleSaltSize || string(sealed[:len(bundleMagic)]) != bundleMagic {
+		return nil, fmt.Errorf("不是有效的研究快照文件")
+	}
+	salt := sealed[len(bundleMagic) : len(bundleMagic)+bundleSaltSize]
+	gcm, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	headerLen := len(bundleMagic) + bundleSaltSize + gcm.NonceSize()
+	if len(sealed) < headerLen+gcm.Overhead() {
+		return nil, fmt.Errorf("研究快照文件不完整")
+	}
+	header := sealed[:headerLen]
+	plain, err := gcm.Open(nil, header[len(bundleMagic)+bundleSaltSize:], sealed[headerLen:], header)
+	if err != nil {
+		return nil, fmt.Errorf("口令错误或文件已损坏")
+	}
+	return plain, nil
+}
+
+func bundleCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, bundleKDFIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f == nil {
+		return nil, fmt.Errorf("文件不存在")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// safeBundlePath 路径必须是相对路径且不能跳出解包目录
+func safeBundlePath(p string) bool {
+	if p == "" || p == bundleManifestName || strings.HasPrefix(p, "/") || strings.Contains(p, "\\") || strings.Contains(p, ":") {
+		return false
+	}
+	clean := path.Clean(p)
+	return clean == p && clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// fillBundleInfo 按清单填充统计信息
+func fillBundleInfo(info *models.ResearchBundleInfo, manifest *models.ResearchBundleManifest) {
+	info.CreatedAt = manifest.CreatedAt
+	info.Files = len(manifest.Files)
+	info.Counts = make(map[string]int)
+	for _, f := range manifest.Files {
+		info.Bytes += f.Size
+		category, _, _ := strings.Cut(f.Path, "/")
+		info.Counts[strings.TrimSuffix(category, ".json")]++
+	}
+}
+
+// RedactConfigSecrets 返回去除 API Key、口令、令牌、签名密钥、凭证与 Webhook 地址后的配置副本
+// 地址只保留协议、主机和路径（查询参数只保留参数名），MCP 命令行参数常携带密钥，整体去除
+func RedactConfigSecrets(cfg models.AppConfig) models.AppConfig {
+	var out models.AppConfig
+	data, _ := json.Marshal(cfg)
+	_ = json.Unmarshal(data, &out)
+
+	for i := range out.AIConfigs {
+		c := &out.AIConfigs[i]
+		c.APIKey, c.APIKeys = "", nil
+		c.CredentialsJSON = ""
+		c.AccessKeyID, c.SecretAccessKey, c.SessionToken = "", "", ""
+		c.HttpProxy = redactURL(c.HttpProxy)
+		c.BaseURL = redactURL(c.BaseURL)
+		if c.Signing != nil {
+			c.Signing.Secret = ""
+			c.Signing.Headers = redactMapValues(c.Signing.Headers)
+		}
+	}
+	for i := range out.MCPServers {
+		s := &out.MCPServers[i]
+		s.Endpoint = redactURL(s.Endpoint)
+		s.Args = nil
+		if s.Sandbox != nil {
+			s.Sandbox.Env = redactMapValues(s.Sandbox.Env)
+		}
+	}
+	out.Proxy.CustomURL = redactURL(out.Proxy.CustomURL)
+	out.OpenClaw.APIKey = ""
+	for i := range out.OpenClaw.Tokens {
+		out.OpenClaw.Tokens[i].Token = ""
+	}
+	for i := range out.Notify.Webhooks {
+		out.Notify.Webhooks[i].URL = ""
+	}
+	out.Notify.Email.Password = ""
+	return out
+}
+
+// redactMapValues 保留键名，清空值
+func redactMapValues(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return m
+	}
+	out := make(map[string]string, len(m))
+	for k := range m {
+		out[k] = ""
+	}
+	return out
+}
+
+// redactURL 去掉地址中的用户名、口令、查询参数值与片段，无法解析的地址整体清空
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.Fragment, u.RawFragment = "", ""
+	if u.RawQuery != "" {
+		query := u.Query()
+		for k := range query {
+			query[k] = []string{""}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
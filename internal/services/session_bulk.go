@@ -0,0 +1,158 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// ListSessionCodes 列出已持久化的会话股票代码
+func (ss *SessionService) ListSessionCodes() ([]string, error) {
+	entries, err := os.ReadDir(ss.sessionsDir)
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".json" {
+			codes = append(codes, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+	sort.Strings(codes)
+	return codes, nil
+}
+
+// DeleteSession 删除会话文件及缓存
+func (ss *SessionService) DeleteSession(stockCode string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.deleteSession(stockCode)
+}
+
+// deleteSession 删除会话（调用方需持有锁）
+func (ss *SessionService) deleteSession(stockCode string) error {
+	delete(ss.sessions, stockCode)
+	if err := os.Remove(ss.getSessionPath(stockCode)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ArchiveSession 将会话文件移入 sessions/archive 目录（如证券退市），不再出现在会话列表中
+func (ss *SessionService) ArchiveSession(stockCode string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	src := ss.getSessionPath(stockCode)
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	archiveDir := filepath.Join(ss.sessionsDir, "archive")
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return err
+	}
+	delete(ss.sessions, stockCode)
+	return os.Rename(src, filepath.Join(archiveDir, stockCode+".json"))
+}
+
+// DeleteInactiveSessions 删除无持仓且最后活动早于 before 的会话
+// dryRun 为 true 时只返回将被删除的会话
+func (ss *SessionService) DeleteInactiveSessions(before time.Time, dryRun bool) (models.BulkReport, error) {
+	report := models.BulkReport{Action: models.BulkDeleteInactiveSessions, DryRun: dryRun, Items: []models.BulkItem{}}
+	cutoff := before.UnixMilli()
+
+	err := ss.eachSession(&report, func(session *models.StockSession) {
+		if session.Position != nil && session.Position.Shares > 0 {
+			return
+		}
+		last := lastActivity(session)
+		if last >= cutoff {
+			return
+		}
+		item := models.BulkItem{
+			StockCode: session.StockCode,
+			StockName: session.StockName,
+			Count:     len(session.Messages),
+			Detail:    "最后活动: " + time.UnixMilli(last).Format("2006-01-02"),
+		}
+		if !dryRun {
+			if err := ss.deleteSession(session.StockCode); err != nil {
+				item.Error = err.Error()
+			}
+		}
+		report.Items = append(report.Items, item)
+		report.Total += item.Count
+	})
+	return report, err
+}
+
+// ClearMessagesBefore 清除所有会话中时间早于 before 的消息
+// dryRun 为 true 时只统计将被清除的消息数
+func (ss *SessionService) ClearMessagesBefore(before time.Time, dryRun bool) (models.BulkReport, error) {
+	report := models.BulkReport{Action: models.BulkClearOldMessages, DryRun: dryRun, Items: []models.BulkItem{}}
+	cutoff := before.UnixMilli()
+
+	err := ss.eachSession(&report, func(session *models.StockSession) {
+		kept := make([]models.ChatMessage, 0, len(session.Messages))
+		for _, msg := range session.Messages {
+			if msg.Timestamp >= cutoff {
+				kept = append(kept, msg)
+			}
+		}
+		removed := len(session.Messages) - len(kept)
+		if removed == 0 {
+			return
+		}
+		item := models.BulkItem{StockCode: session.StockCode, StockName: session.StockName, Count: removed}
+		if !dryRun {
+			session.Messages = kept
+			session.UpdatedAt = time.Now().UnixMilli()
+			if err := ss.saveSession(session); err != nil {
+				item.Error = err.Error()
+			}
+		}
+		report.Items = append(report.Items, item)
+		report.Total += removed
+	})
+	return report, err
+}
+
+// eachSession 持锁遍历所有会话，加载失败的会话记入报告
+func (ss *SessionService) eachSession(report *models.BulkReport, fn func(session *models.StockSession)) error {
+	codes, err := ss.ListSessionCodes()
+	if err != nil {
+		return fmt.Errorf("读取会话目录失败: %w", err)
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	for _, code := range codes {
+		session, ok := ss.sessions[code]
+		if !ok {
+			session, err = ss.loadSession(code)
+			if err != nil {
+				report.Items = append(report.Items, models.BulkItem{StockCode: code, Error: err.Error()})
+				continue
+			}
+		}
+		report.Scanned++
+		fn(session)
+	}
+	return nil
+}
+
+// lastActivity 会话最后活动时间：取更新时间与最新消息时间的较大值
+func lastActivity(session *models.StockSession) int64 {
+	last := session.UpdatedAt
+	for _, msg := range session.Messages {
+		if msg.Timestamp > last {
+			last = msg.Timestamp
+		}
+	}
+	return last
+}
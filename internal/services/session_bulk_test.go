@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestSessionService_BulkOperations(t *testing.T) {
+	ss := NewSessionService(t.TempDir())
+	old := time.Now().AddDate(0, 0, -120).UnixMilli()
+
+	for _, code := range []string{"sh600000", "sh600519", "sz000001"} {
+		session, err := ss.GetOrCreateSession(code, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session.UpdatedAt = old
+		session.Messages = []models.ChatMessage{{ID: "1", Content: "旧消息", Timestamp: old}}
+	}
+	ss.sessions["sh600519"].Position = &models.StockPosition{Shares: 100, CostPrice: 1500}
+	ss.sessions["sz000001"].Messages = append(ss.sessions["sz000001"].Messages, models.ChatMessage{ID: "2", Timestamp: time.Now().UnixMilli()})
+	for _, s := range ss.sessions {
+		ss.saveSession(s)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -90)
+	report, err := ss.DeleteInactiveSessions(cutoff, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Scanned != 3 || len(report.Items) != 1 || report.Items[0].StockCode != "sh600000" {
+		t.Fatalf("dry run report = %+v", report)
+	}
+	if codes, _ := ss.ListSessionCodes(); len(codes) != 3 {
+		t.Fatalf("dry run should not delete, codes = %v", codes)
+	}
+
+	if _, err := ss.DeleteInactiveSessions(cutoff, false); err != nil {
+		t.Fatal(err)
+	}
+	if codes, _ := ss.ListSessionCodes(); len(codes) != 2 {
+		t.Fatalf("codes after delete = %v", codes)
+	}
+
+	report, err = ss.ClearMessagesBefore(cutoff, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 2 {
+		t.Fatalf("cleared = %d, want 2", report.Total)
+	}
+	if msgs := ss.GetMessages("sz000001"); len(msgs) != 1 || msgs[0].ID != "2" {
+		t.Fatalf("remaining messages = %+v", msgs)
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,11 +14,15 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrDuplicateMessage 相同幂等键的消息已存在（重复提交）
+var ErrDuplicateMessage = errors.New("重复提交的消息")
+
 // SessionService Session服务
 type SessionService struct {
-	sessionsDir string
-	sessions    map[string]*models.StockSession
-	mu          sync.RWMutex
+	sessionsDir   string
+	sessions      map[string]*models.StockSession
+	stripThinking bool // 持久化前移除思考过程
+	mu            sync.RWMutex
 }
 
 // NewSessionService 创建Session服务
@@ -30,6 +35,13 @@ func NewSessionService(dataDir string) *SessionService {
 	return ss
 }
 
+// SetStripThinking 设置是否在持久化前移除思考过程
+func (ss *SessionService) SetStripThinking(strip bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.stripThinking = strip
+}
+
 // ensureDir 确保目录存在
 func (ss *SessionService) ensureDir() {
 	if err := os.MkdirAll(ss.sessionsDir, 0755); err != nil {
@@ -119,6 +131,14 @@ func (ss *SessionService) GetSession(stockCode string) *models.StockSession {
 	return session
 }
 
+// ExistingSessionID 返回已有Session的ID，不存在时返回空字符串（不会创建Session）
+func (ss *SessionService) ExistingSessionID(stockCode string) string {
+	if session := ss.GetSession(stockCode); session != nil {
+		return session.ID
+	}
+	return ""
+}
+
 // AddMessage 添加消息到Session
 func (ss *SessionService) AddMessage(stockCode string, msg models.ChatMessage) error {
 	ss.mu.Lock()
@@ -135,13 +155,45 @@ func (ss *SessionService) AddMessage(stockCode string, msg models.ChatMessage) e
 		ss.sessions[stockCode] = session
 	}
 
-	msg.ID = uuid.New().String()
+	if msg.IdempotencyKey != "" && findIdempotencyKey(session.Messages, msg.IdempotencyKey) >= 0 {
+		return ErrDuplicateMessage
+	}
+	if msg.ID == "" {
+		msg.ID = uuid.New().String()
+	}
 	msg.Timestamp = time.Now().UnixMilli()
+	if ss.stripThinking {
+		msg.Thinking = ""
+	}
 	session.Messages = append(session.Messages, msg)
 	session.UpdatedAt = time.Now().UnixMilli()
 	return ss.saveSession(session)
 }
 
+// HasIdempotencyKey 检查会话中是否已有相同幂等键的消息
+func (ss *SessionService) HasIdempotencyKey(stockCode, key string) bool {
+	if key == "" {
+		return false
+	}
+	session := ss.GetSession(stockCode)
+	if session == nil {
+		return false
+	}
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return findIdempotencyKey(session.Messages, key) >= 0
+}
+
+// findIdempotencyKey 从后往前查找幂等键，重复提交通常紧随原消息
+func findIdempotencyKey(msgs []models.ChatMessage, key string) int {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].IdempotencyKey == key {
+			return i
+		}
+	}
+	return -1
+}
+
 // AddMessages 批量添加消息到Session
 func (ss *SessionService) AddMessages(stockCode string, msgs []models.ChatMessage) error {
 	ss.mu.Lock()
@@ -162,6 +214,9 @@ func (ss *SessionService) AddMessages(stockCode string, msgs []models.ChatMessag
 	for i := range msgs {
 		msgs[i].ID = uuid.New().String()
 		msgs[i].Timestamp = now
+		if ss.stripThinking {
+			msgs[i].Thinking = ""
+		}
 	}
 	session.Messages = append(session.Messages, msgs...)
 	session.UpdatedAt = now
@@ -189,6 +244,56 @@ func (ss *SessionService) GetMessages(stockCode string) []models.ChatMessage {
 	return session.Messages
 }
 
+// UpdateMessage 按ID替换已有消息（保留原ID和时间戳）
+func (ss *SessionService) UpdateMessage(stockCode string, msg models.ChatMessage) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	session, ok := ss.sessions[stockCode]
+	if !ok {
+		var err error
+		session, err = ss.loadSession(stockCode)
+		if err != nil {
+			return fmt.Errorf("session not found: %s", stockCode)
+		}
+		ss.sessions[stockCode] = session
+	}
+
+	for i := range session.Messages {
+		if session.Messages[i].ID != msg.ID {
+			continue
+		}
+		msg.Timestamp = session.Messages[i].Timestamp
+		if ss.stripThinking {
+			msg.Thinking = ""
+		}
+		session.Messages[i] = msg
+		session.UpdatedAt = time.Now().UnixMilli()
+		return ss.saveSession(session)
+	}
+	return fmt.Errorf("message not found: %s", msg.ID)
+}
+
+// ReplaceMessages 整体替换Session消息（检查点回滚）
+func (ss *SessionService) ReplaceMessages(stockCode string, msgs []models.ChatMessage) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	session, ok := ss.sessions[stockCode]
+	if !ok {
+		var err error
+		session, err = ss.loadSession(stockCode)
+		if err != nil {
+			return fmt.Errorf("session not found: %s", stockCode)
+		}
+		ss.sessions[stockCode] = session
+	}
+
+	session.Messages = append([]models.ChatMessage{}, msgs...)
+	session.UpdatedAt = time.Now().UnixMilli()
+	return ss.saveSession(session)
+}
+
 // ClearMessages 清空Session消息
 func (ss *SessionService) ClearMessages(stockCode string) error {
 	ss.mu.Lock()
@@ -206,6 +311,8 @@ func (ss *SessionService) ClearMessages(stockCode string) error {
 	}
 
 	session.Messages = []models.ChatMessage{}
+	// 清空历史时一并断开服务端会话
+	session.Conversations = nil
 	session.UpdatedAt = time.Now().UnixMilli()
 	return ss.saveSession(session)
 }
@@ -226,10 +333,37 @@ func (ss *SessionService) UpdatePosition(stockCode string, shares int64, costPri
 		ss.sessions[stockCode] = session
 	}
 
-	session.Position = &models.StockPosition{
+	position := &models.StockPosition{
 		Shares:    shares,
 		CostPrice: costPrice,
 	}
+	// 保留已设置的币种
+	if session.Position != nil {
+		position.Currency = session.Position.Currency
+	}
+	session.Position = position
+	session.UpdatedAt = time.Now().UnixMilli()
+	return ss.saveSession(session)
+}
+
+// SetPositionCurrency 设置持仓的交易币种，currency 为空时按股票代码推断
+func (ss *SessionService) SetPositionCurrency(stockCode, currency string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	session, ok := ss.sessions[stockCode]
+	if !ok {
+		var err error
+		session, err = ss.loadSession(stockCode)
+		if err != nil {
+			return fmt.Errorf("session not found: %s", stockCode)
+		}
+		ss.sessions[stockCode] = session
+	}
+	if session.Position == nil {
+		session.Position = &models.StockPosition{}
+	}
+	session.Position.Currency = currency
 	session.UpdatedAt = time.Now().UnixMilli()
 	return ss.saveSession(session)
 }
@@ -251,3 +385,130 @@ func (ss *SessionService) GetPosition(stockCode string) *models.StockPosition {
 	}
 	return session.Position
 }
+
+// SetPromptVersion 固定会话使用的提示词版本，传空字符串取消固定
+func (ss *SessionService) SetPromptVersion(stockCode, version string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	session, ok := ss.sessions[stockCode]
+	if !ok {
+		var err error
+		session, err = ss.loadSession(stockCode)
+		if err != nil {
+			return fmt.Errorf("session not found: %s", stockCode)
+		}
+		ss.sessions[stockCode] = session
+	}
+
+	session.PromptVersion = version
+	session.UpdatedAt = time.Now().UnixMilli()
+	return ss.saveSession(session)
+}
+
+// GetPromptVersion 获取会话固定的提示词版本，未固定时返回空字符串
+func (ss *SessionService) GetPromptVersion(stockCode string) string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	session, ok := ss.sessions[stockCode]
+	if !ok {
+		var err error
+		session, err = ss.loadSession(stockCode)
+		if err != nil {
+			return ""
+		}
+		ss.sessions[stockCode] = session
+	}
+	return session.PromptVersion
+}
+
+// SetAIConfigID 固定会话使用的 AI 配置，传空字符串取消固定
+func (ss *SessionService) SetAIConfigID(stockCode, aiConfigID string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	session, ok := ss.sessions[stockCode]
+	if !ok {
+		var err error
+		session, err = ss.loadSession(stockCode)
+		if err != nil {
+			return fmt.Errorf("session not found: %s", stockCode)
+		}
+		ss.sessions[stockCode] = session
+	}
+
+	session.AIConfigID = aiConfigID
+	session.UpdatedAt = time.Now().UnixMilli()
+	return ss.saveSession(session)
+}
+
+// GetAIConfigID 获取会话固定的 AI 配置 ID，未固定时返回空字符串
+func (ss *SessionService) GetAIConfigID(stockCode string) string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	session, ok := ss.sessions[stockCode]
+	if !ok {
+		var err error
+		session, err = ss.loadSession(stockCode)
+		if err != nil {
+			return ""
+		}
+		ss.sessions[stockCode] = session
+	}
+	return session.AIConfigID
+}
+
+// ConversationStore 返回股票会话的服务端 conversation 映射存储
+func (ss *SessionService) ConversationStore(stockCode string) *SessionConversationStore {
+	return &SessionConversationStore{ss: ss, stockCode: stockCode}
+}
+
+// SessionConversationStore 将服务端 conversation ID 保存在股票会话中
+type SessionConversationStore struct {
+	ss        *SessionService
+	stockCode string
+}
+
+// GetConversation 获取 conversation ID，不存在时返回空字符串
+func (cs *SessionConversationStore) GetConversation(key string) string {
+	cs.ss.mu.Lock()
+	defer cs.ss.mu.Unlock()
+
+	session, ok := cs.ss.sessions[cs.stockCode]
+	if !ok {
+		var err error
+		session, err = cs.ss.loadSession(cs.stockCode)
+		if err != nil {
+			return ""
+		}
+		cs.ss.sessions[cs.stockCode] = session
+	}
+	return session.Conversations[key]
+}
+
+// SetConversation 保存 conversation ID，为空时删除映射
+func (cs *SessionConversationStore) SetConversation(key, conversationID string) error {
+	cs.ss.mu.Lock()
+	defer cs.ss.mu.Unlock()
+
+	session, ok := cs.ss.sessions[cs.stockCode]
+	if !ok {
+		var err error
+		session, err = cs.ss.loadSession(cs.stockCode)
+		if err != nil {
+			return fmt.Errorf("session not found: %s", cs.stockCode)
+		}
+		cs.ss.sessions[cs.stockCode] = session
+	}
+	if session.Conversations == nil {
+		session.Conversations = make(map[string]string)
+	}
+	if conversationID == "" {
+		delete(session.Conversations, key)
+	} else {
+		session.Conversations[key] = conversationID
+	}
+	return cs.ss.saveSession(session)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestSessionService_PinAIConfig(t *testing.T) {
+	dir := t.TempDir()
+	ss := NewSessionService(dir)
+	if _, err := ss.GetOrCreateSession("sh600519", "贵州茅台"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ss.SetAIConfigID("sh600519", "claude"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载后固定配置仍然保留
+	if got := NewSessionService(dir).GetAIConfigID("sh600519"); got != "claude" {
+		t.Fatalf("GetAIConfigID() = %q, want claude", got)
+	}
+	if err := ss.SetAIConfigID("sz000001", "claude"); err == nil {
+		t.Fatal("want error for missing session")
+	}
+}
+
+func TestSessionService_IdempotencyKey(t *testing.T) {
+	dir := t.TempDir()
+	ss := NewSessionService(dir)
+	if _, err := ss.GetOrCreateSession("sh600519", "贵州茅台"); err != nil {
+		t.Fatal(err)
+	}
+	msg := models.ChatMessage{AgentID: "user", Content: "怎么看", IdempotencyKey: "k1"}
+	if err := ss.AddMessage("sh600519", msg); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后重复提交仍能识别
+	reloaded := NewSessionService(dir)
+	if !reloaded.HasIdempotencyKey("sh600519", "k1") || reloaded.HasIdempotencyKey("sh600519", "k2") {
+		t.Fatal("HasIdempotencyKey mismatch")
+	}
+	if err := reloaded.AddMessage("sh600519", msg); !errors.Is(err, ErrDuplicateMessage) {
+		t.Fatalf("duplicate AddMessage err = %v", err)
+	}
+	if n := len(reloaded.GetMessages("sh600519")); n != 1 {
+		t.Fatalf("messages = %d, want 1", n)
+	}
+}
+
+func TestSessionService_ExistingSessionIDDoesNotCreate(t *testing.T) {
+	dir := t.TempDir()
+	ss := NewSessionService(dir)
+	if got := ss.ExistingSessionID("sh600519"); got != "" {
+		t.Fatalf("ExistingSessionID() = %q, want empty", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sessions", "sh600519.json")); !os.IsNotExist(err) {
+		t.Fatalf("session file should not be created, stat err = %v", err)
+	}
+
+	session, err := ss.GetOrCreateSession("sh600519", "贵州茅台")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := NewSessionService(dir).ExistingSessionID("sh600519"); got != session.ID {
+		t.Fatalf("ExistingSessionID() = %q, want %q", got, session.ID)
+	}
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var settingsLog = logger.New("settings")
+
+// EventSettingsChanged 界面设置变更事件，携带生效后的 UISettings
+const EventSettingsChanged = "settings:changed"
+
+const (
+	// DefaultSettingsProfile 默认配置档名称
+	DefaultSettingsProfile = "default"
+	// DefaultRefreshInterval 默认行情刷新间隔（秒）
+	DefaultRefreshInterval = 3
+	minRefreshInterval     = 1
+	maxRefreshInterval     = 60
+	maxProfileNameLength   = 32
+)
+
+var (
+	// validThemes 与前端 ThemeContext.tsx 的 ThemeType 保持一致
+	validThemes = map[string]bool{
+		"military": true, "ocean": true, "purple": true, "orange": true, "dark": true, // 深色主题
+		"light": true, "light-blue": true, "light-green": true, "light-rose": true, // 浅色主题
+	}
+	validCandleModes = map[string]bool{"red-up": true, "green-up": true}
+)
+
+// DefaultUISettings 默认界面设置
+func DefaultUISettings() models.UISettings {
+	return models.UISettings{Theme: "military", CandleColorMode: "red-up", RefreshInterval: DefaultRefreshInterval}
+}
+
+// ValidateUISettings 补全默认值并校验取值范围
+func ValidateUISettings(s models.UISettings) (models.UISettings, error) {
+	d := DefaultUISettings()
+	if s.Theme == "" {
+		s.Theme = d.Theme
+	}
+	if s.CandleColorMode == "" {
+		s.CandleColorMode = d.CandleColorMode
+	}
+	if s.RefreshInterval == 0 {
+		s.RefreshInterval = d.RefreshInterval
+	}
+	if !validThemes[s.Theme] {
+		return s, fmt.Errorf("不支持的主题: %s", s.Theme)
+	}
+	if !validCandleModes[s.CandleColorMode] {
+		return s, fmt.Errorf("不支持的涨跌颜色模式: %s", s.CandleColorMode)
+	}
+	if s.RefreshInterval < minRefreshInterval || s.RefreshInterval > maxRefreshInterval {
+		return s, fmt.Errorf("行情刷新间隔需在 %d~%d 秒之间: %d", minRefreshInterval, maxRefreshInterval, s.RefreshInterval)
+	}
+	return s, nil
+}
+
+// SettingsService 界面设置服务：校验、按配置档持久化（settings.json），变更时通知订阅者
+type SettingsService struct {
+	path      string
+	state     models.UISettingsProfiles
+	listeners []func(models.UISettings)
+	mu        sync.RWMutex
+}
+
+// NewSettingsService 创建界面设置服务；首次运行时从旧配置的主题与涨跌颜色迁移出默认配置档
+func NewSettingsService(dataDir string, legacy *models.AppConfig) *SettingsService {
+	s := &SettingsService{path: filepath.Join(dataDir, "settings.json")}
+	if err := s.load(); err == nil {
+		return s
+	} else if !os.IsNotExist(err) {
+		settingsLog.Warn("读取界面设置失败，使用默认值: %v", err)
+	}
+
+	initial := DefaultUISettings()
+	if legacy != nil {
+		migrated := initial
+		migrated.Theme, migrated.CandleColorMode = legacy.Theme, legacy.CandleColorMode
+		if v, err := ValidateUISettings(migrated); err == nil {
+			initial = v
+		}
+	}
+	s.state = models.UISettingsProfiles{
+		Active:   DefaultSettingsProfile,
+		Profiles: map[string]models.UISettings{DefaultSettingsProfile: initial},
+	}
+	if err := s.saveLocked(); err != nil {
+		settingsLog.Warn("保存界面设置失败: %v", err)
+	}
+	return s
+}
+
+// load 读取 settings.json，无效的配置档按默认值补全
+func (s *SettingsService) load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var state models.UISettingsProfiles
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if len(state.Profiles) == 0 {
+		state.Profiles = map[string]models.UISettings{DefaultSettingsProfile: DefaultUISettings()}
+	}
+	for name, p := range state.Profiles {
+		v, err := ValidateUISettings(p)
+		if err != nil {
+			settingsLog.Warn("配置档 %s 无效，已重置: %v", name, err)
+			v = DefaultUISettings()
+		}
+		state.Profiles[name] = v
+	}
+	if _, ok := state.Profiles[state.Active]; !ok {
+		state.Active = firstProfile(state.Profiles)
+	}
+	s.state = state
+	return nil
+}
+
+// saveLocked 保存设置(需要已持有锁)
+func (s *SettingsService) saveLocked() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(s.path, data)
+}
+
+// OnChange 订阅生效设置的变更（修改当前配置档或切换配置档）
+func (s *SettingsService) OnChange(fn func(models.UISettings)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// notify 在锁外通知订阅者
+func (s *SettingsService) notify(settings models.UISettings) {
+	s.mu.RLock()
+	listeners := append([]func(models.UISettings){}, s.listeners...)
+	s.mu.RUnlock()
+	for _, fn := range listeners {
+		fn(settings)
+	}
+}
+
+// Get 获取当前配置档的设置
+func (s *SettingsService) Get() models.UISettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state.Profiles[s.state.Active]
+}
+
+// Update 校验并保存到当前配置档
+func (s *SettingsService) Update(settings models.UISettings) (models.UISettings, error) {
+	settings, err := ValidateUISettings(settings)
+	if err != nil {
+		return settings, err
+	}
+	s.mu.Lock()
+	prev := s.state.Profiles[s.state.Active]
+	if prev == settings {
+		s.mu.Unlock()
+		return settings, nil
+	}
+	s.state.Profiles[s.state.Active] = settings
+	if err := s.saveLocked(); err != nil {
+		s.state.Profiles[s.state.Active] = prev
+		s.mu.Unlock()
+		return prev, err
+	}
+	s.mu.Unlock()
+	s.notify(settings)
+	return settings, nil
+}
+
+// Profiles 返回全部配置档（副本）
+func (s *SettingsService) Profiles() models.UISettingsProfiles {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := models.UISettingsProfiles{Active: s.state.Active, Profiles: make(map[string]models.UISettings, len(s.state.Profiles))}
+	for name, p := range s.state.Profiles {
+		out.Profiles[name] = p
+	}
+	return out
+}
+
+// SwitchProfile 切换到已有配置档
+func (s *SettingsService) SwitchProfile(name string) (models.UISettings, error) {
+	s.mu.Lock()
+	settings, ok := s.state.Profiles[name]
+	if !ok {
+		s.mu.Unlock()
+		return models.UISettings{}, fmt.Errorf("配置档不存在: %s", name)
+	}
+	prev := s.state.Active
+	s.state.Active = name
+	if err := s.saveLocked(); err != nil {
+		s.state.Active = prev
+		s.mu.Unlock()
+		return models.UISettings{}, err
+	}
+	s.mu.Unlock()
+	if name != prev {
+		s.notify(settings)
+	}
+	return settings, nil
+}
+
+// SaveProfileAs 把当前设置另存为新配置档（同名时覆盖）并切换过去
+func (s *SettingsService) SaveProfileAs(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > maxProfileNameLength {
+		return fmt.Errorf("配置档名称需为 1~%d 个字符", maxProfileNameLength)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prevActive := s.state.Active
+	prevProfile, existed := s.state.Profiles[name]
+	s.state.Profiles[name] = s.state.Profiles[prevActive]
+	s.state.Active = name
+	if err := s.saveLocked(); err != nil {
+		s.state.Active = prevActive
+		if existed {
+			s.state.Profiles[name] = prevProfile
+		} else {
+			delete(s.state.Profiles, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteProfile 删除配置档，当前使用中的配置档不能删除
+func (s *SettingsService) DeleteProfile(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	settings, ok := s.state.Profiles[name]
+	if !ok {
+		return fmt.Errorf("配置档不存在: %s", name)
+	}
+	if name == s.state.Active {
+		return fmt.Errorf("不能删除正在使用的配置档: %s", name)
+	}
+	delete(s.state.Profiles, name)
+	if err := s.saveLocked(); err != nil {
+		s.state.Profiles[name] = settings
+		return err
+	}
+	return nil
+}
+
+// firstProfile 按名称排序的第一个配置档（优先默认配置档）
+func firstProfile(profiles map[string]models.UISettings) string {
+	if _, ok := profiles[DefaultSettingsProfile]; ok {
+		return DefaultSettingsProfile
+	}
+	first := ""
+	for name := range profiles {
+		if first == "" || name < first {
+			first = name
+		}
+	}
+	return first
+}
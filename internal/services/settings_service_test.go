@@ -0,0 +1,83 @@
+package services
+
+import (
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestSettingsService_ProfilesAndValidation(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSettingsService(dir, &models.AppConfig{Theme: "ocean", CandleColorMode: "green-up"})
+	if got := s.Get(); got.Theme != "ocean" || got.CandleColorMode != "green-up" || got.RefreshInterval != DefaultRefreshInterval {
+		t.Fatalf("migrated settings = %+v", got)
+	}
+
+	var changes []models.UISettings
+	s.OnChange(func(v models.UISettings) { changes = append(changes, v) })
+
+	if _, err := s.Update(models.UISettings{Theme: "neon"}); err == nil {
+		t.Fatal("invalid theme accepted")
+	}
+	if _, err := s.Update(models.UISettings{Theme: "dark", RefreshInterval: 120}); err == nil {
+		t.Fatal("out-of-range refresh interval accepted")
+	}
+	if _, err := s.Update(models.UISettings{Theme: "dark", CandleColorMode: "red-up", RefreshInterval: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveProfileAs("复盘"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Update(models.UISettings{Theme: "purple", RefreshInterval: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.SwitchProfile(DefaultSettingsProfile); got.Theme != "dark" {
+		t.Fatalf("default profile = %+v", got)
+	}
+	if err := s.DeleteProfile(DefaultSettingsProfile); err == nil {
+		t.Fatal("deleted active profile")
+	}
+	if len(changes) != 3 || changes[2].Theme != "dark" {
+		t.Fatalf("changes = %+v", changes)
+	}
+
+	// 重新加载后保留配置档与当前选择
+	reloaded := NewSettingsService(dir, nil)
+	p := reloaded.Profiles()
+	if p.Active != DefaultSettingsProfile || p.Profiles["复盘"].Theme != "purple" || p.Profiles["复盘"].RefreshInterval != 10 {
+		t.Fatalf("reloaded = %+v", p)
+	}
+}
+
+func TestValidThemes_MatchFrontendThemeType(t *testing.T) {
+	src, err := os.ReadFile("../../frontend/src/contexts/ThemeContext.tsx")
+	if err != nil {
+		t.Skipf("前端源码不可用: %v", err)
+	}
+	union := regexp.MustCompile(`(?s)export type ThemeType =(.*?);`).FindSubmatch(src)
+	if union == nil {
+		t.Fatal("未找到 ThemeType 定义")
+	}
+	frontend := map[string]bool{}
+	for _, m := range regexp.MustCompile(`'([a-z-]+)'`).FindAllSubmatch(union[1], -1) {
+		frontend[string(m[1])] = true
+	}
+	for theme := range frontend {
+		if !validThemes[theme] {
+			t.Errorf("后端缺少前端主题 %q", theme)
+		}
+		if _, err := ValidateUISettings(models.UISettings{Theme: theme}); err != nil {
+			t.Errorf("主题 %q 校验失败: %v", theme, err)
+		}
+	}
+	if got := NewSettingsService(t.TempDir(), &models.AppConfig{Theme: "light-rose"}).Get(); got.Theme != "light-rose" {
+		t.Errorf("迁移浅色主题 = %q, 期望 light-rose", got.Theme)
+	}
+	for theme := range validThemes {
+		if !frontend[theme] {
+			t.Errorf("前端没有后端允许的主题 %q", theme)
+		}
+	}
+}
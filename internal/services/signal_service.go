@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var signalLog = logger.New("signals")
+
+const (
+	// SignalKLineDays 规则信号需要的日 K 条数（52 周约 250 个交易日）
+	SignalKLineDays = 260
+
+	high52WBars       = 250 // 52 周新高回看的交易日数
+	high52WMinBars    = 120 // 历史不足时至少需要的交易日数（次新股）
+	volumeSpikeBars   = 20  // 放量对比的均量周期
+	volumeSpikeFactor = 2.5 // 放量倍数
+)
+
+// SignalService 规则信号引擎：在缓存的日 K 上计算金叉、52 周新高、放量等确定性信号
+// 结果注入专家提示词并推送提醒，减少只为确认简单形态而调用大模型
+type SignalService struct {
+	latest map[string][]models.Signal // 股票代码 -> 最近一次计算的信号
+	seen   map[string]bool            // 已提醒过的信号（代码:类型:日期）
+	mu     sync.RWMutex
+}
+
+// NewSignalService 创建规则信号服务
+func NewSignalService() *SignalService {
+	return &SignalService{
+		latest: make(map[string][]models.Signal),
+		seen:   make(map[string]bool),
+	}
+}
+
+// Refresh 用最新 K 线重新计算个股信号，返回首次出现的信号（用于提醒）
+func (s *SignalService) Refresh(stock models.Stock, klines []models.KLineData) []models.Signal {
+	signals := EvaluateSignals(stock.Symbol, klines)
+	for i := range signals {
+		signals[i].StockName = stock.Name
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest[stock.Symbol] = signals
+	var fresh []models.Signal
+	for _, sig := range signals {
+		key := fmt.Sprintf("%s:%s:%s", sig.StockCode, sig.Type, sig.Date)
+		if !s.seen[key] {
+			s.seen[key] = true
+			fresh = append(fresh, sig)
+		}
+	}
+	if len(fresh) > 0 {
+		signalLog.Info("新信号 [%s]: %d 个", stock.Symbol, len(fresh))
+	}
+	return fresh
+}
+
+// GetSignals 获取个股最近一次计算的信号
+func (s *SignalService) GetSignals(stockCode string) []models.Signal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.Signal{}, s.latest[stockCode]...)
+}
+
+// FormatForPrompt 生成注入提示词的信号摘要，无信号时返回空
+func (s *SignalService) FormatForPrompt(stockCode string) string {
+	signals := s.GetSignals(stockCode)
+	if len(signals) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("【规则信号】（程序根据日K计算，可直接引用，无需重复推导）\n")
+	for _, sig := range signals {
+		fmt.Fprintf(&sb, "- %s %s：%s\n", sig.Date, sig.Title, sig.Detail)
+	}
+	return sb.String()
+}
+
+// EvaluateSignals 在日 K 上计算最新一根 K 线触发的信号（K 线按时间升序）
+func EvaluateSignals(stockCode string, klines []models.KLineData) []models.Signal {
+	n := len(klines)
+	if n == 0 {
+		return nil
+	}
+	last := klines[n-1]
+	date, _, _ := strings.Cut(last.Time, " ")
+	now := time.Now().UnixMilli()
+	newSignal := func(t models.SignalType, title, detail string) models.Signal {
+		return models.Signal{StockCode: stockCode, Type: t, Title: title, Detail: detail, Date: date, DetectedAt: now}
+	}
+
+	var signals []models.Signal
+
+	// 金叉：MA5 由下方上穿 MA20
+	if n >= 21 {
+		prev5, prev20 := closeMA(klines[:n-1], 5), closeMA(klines[:n-1], 20)
+		cur5, cur20 := closeMA(klines, 5), closeMA(klines, 20)
+		if prev5 <= prev20 && cur5 > cur20 {
+			signals = append(signals, newSignal(models.SignalGoldenCross, "均线金叉",
+				fmt.Sprintf("MA5(%.2f) 上穿 MA20(%.2f)", cur5, cur20)))
+		}
+	}
+
+	// 52 周新高：最高价突破此前 250 个交易日的最高价
+	if n-1 >= high52WMinBars {
+		history := klines[max(0, n-1-high52WBars) : n-1]
+		prevHigh := 0.0
+		for _, k := range history {
+			prevHigh = max(prevHigh, k.High)
+		}
+		if prevHigh > 0 && last.High > prevHigh {
+			signals = append(signals, newSignal(models.SignalHigh52W, "创52周新高",
+				fmt.Sprintf("最高价 %.2f 突破前高 %.2f（回看 %d 个交易日）", last.High, prevHigh, len(history))))
+		}
+	}
+
+	// 放量：成交量达到前 20 日均量的 2.5 倍
+	if n > volumeSpikeBars {
+		var sum int64
+		for _, k := range klines[n-1-volumeSpikeBars : n-1] {
+			sum += k.Volume
+		}
+		avg := float64(sum) / volumeSpikeBars
+		if avg > 0 && float64(last.Volume) >= avg*volumeSpikeFactor {
+			signals = append(signals, newSignal(models.SignalVolumeSpike, "放量",
+				fmt.Sprintf("成交量为前 %d 日均量的 %.1f 倍", volumeSpikeBars, float64(last.Volume)/avg)))
+		}
+	}
+	return signals
+}
+
+// closeMA 计算最后 period 根 K 线的收盘均价
+func closeMA(klines []models.KLineData, period int) float64 {
+	if len(klines) < period {
+		return 0
+	}
+	sum := 0.0
+	for _, k := range klines[len(klines)-period:] {
+		sum += k.Close
+	}
+	return sum / float64(period)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// flatKLines 生成价格、成交量平稳的日K
+func flatKLines(n int) []models.KLineData {
+	klines := make([]models.KLineData, n)
+	for i := range klines {
+		klines[i] = models.KLineData{
+			Time: fmt.Sprintf("2025-01-%03d", i),
+			Open: 10, High: 10.5, Low: 9.5, Close: 10, Volume: 1000,
+		}
+	}
+	return klines
+}
+
+func TestEvaluateSignals(t *testing.T) {
+	if got := EvaluateSignals("sh600000", flatKLines(SignalKLineDays)); len(got) != 0 {
+		t.Fatalf("平稳走势不应有信号: %+v", got)
+	}
+
+	klines := flatKLines(SignalKLineDays)
+	last := &klines[len(klines)-1]
+	last.Close, last.High, last.Volume = 13, 13.2, 3000
+
+	types := map[models.SignalType]bool{}
+	for _, sig := range EvaluateSignals("sh600000", klines) {
+		types[sig.Type] = true
+	}
+	for _, want := range []models.SignalType{models.SignalGoldenCross, models.SignalHigh52W, models.SignalVolumeSpike} {
+		if !types[want] {
+			t.Errorf("缺少信号 %s, got %v", want, types)
+		}
+	}
+
+	// 历史不足时不判断 52 周新高
+	short := klines[len(klines)-60:]
+	for _, sig := range EvaluateSignals("sh600000", short) {
+		if sig.Type == models.SignalHigh52W {
+			t.Error("历史不足时不应产生52周新高信号")
+		}
+	}
+}
+
+func TestSignalServiceRefresh(t *testing.T) {
+	s := NewSignalService()
+	klines := flatKLines(30)
+	klines[29].Volume = 5000
+	stock := models.Stock{Symbol: "sz000001", Name: "平安银行"}
+
+	if fresh := s.Refresh(stock, klines); len(fresh) != 1 || fresh[0].StockName != "平安银行" {
+		t.Fatalf("首次计算应返回放量信号: %+v", fresh)
+	}
+	if fresh := s.Refresh(stock, klines); len(fresh) != 0 {
+		t.Fatalf("同一信号不应重复提醒: %+v", fresh)
+	}
+	if prompt := s.FormatForPrompt("sz000001"); !strings.Contains(prompt, "放量") {
+		t.Errorf("prompt = %q", prompt)
+	}
+	if s.FormatForPrompt("sh600519") != "" {
+		t.Error("无信号时应返回空")
+	}
+}
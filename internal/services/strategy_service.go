@@ -580,16 +580,17 @@ func (s *StrategyService) getAgentConfigsFromStrategy() []models.AgentConfig {
 	agents := make([]models.AgentConfig, len(strategy.Agents))
 	for i, sa := range strategy.Agents {
 		agents[i] = models.AgentConfig{
-			ID:          sa.ID,
-			Name:        sa.Name,
-			Role:        sa.Role,
-			Avatar:      sa.Avatar,
-			Color:       sa.Color,
-			Instruction: sa.Instruction,
-			Tools:       sa.Tools,
-			MCPServers:  sa.MCPServers,
-			Enabled:     sa.Enabled,
-			AIConfigID:  sa.AIConfigID,
+			ID:             sa.ID,
+			Name:           sa.Name,
+			Role:           sa.Role,
+			Avatar:         sa.Avatar,
+			Color:          sa.Color,
+			Instruction:    sa.Instruction,
+			Tools:          sa.Tools,
+			MCPServers:     sa.MCPServers,
+			Enabled:        sa.Enabled,
+			AIConfigID:     sa.AIConfigID,
+			ResponseSchema: sa.ResponseSchema,
 		}
 	}
 	return agents
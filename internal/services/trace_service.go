@@ -0,0 +1,298 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+// 单只股票轨迹文件超过上限时裁剪为最近的 traceKeepCount 条
+const (
+	traceMaxFileSize = 4 << 20
+	traceKeepCount   = 2000
+)
+
+// requestKeepCount 最多保留的请求快照数（每条发言一个文件，含完整上下文，体积较大）
+const requestKeepCount = 500
+
+// TraceQuery 轨迹查询条件
+type TraceQuery struct {
+	StockCode string
+	AgentID   string // 为空不过滤
+	Since     int64  // 毫秒时间戳，0 不过滤
+	Limit     int    // <=0 不限制
+}
+
+// TraceService 专家发言执行轨迹服务
+// 每只股票一个 JSONL 文件：dataDir/traces/{stockCode}.jsonl，追加写入
+type TraceService struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewTraceService 创建轨迹服务
+func NewTraceService(dataDir string) *TraceService {
+	ts := &TraceService{dir: filepath.Join(dataDir, "traces")}
+	if err := os.MkdirAll(ts.dir, 0755); err != nil {
+		fmt.Printf("创建traces目录失败: %v\n", err)
+	}
+	return ts
+}
+
+func (ts *TraceService) path(stockCode string) string {
+	return filepath.Join(ts.dir, stockCode+".jsonl")
+}
+
+// Append 追加一条轨迹
+func (ts *TraceService) Append(trace *models.TurnTrace) error {
+	if trace == nil || trace.StockCode == "" {
+		return fmt.Errorf("轨迹缺少股票代码")
+	}
+	line, err := json.Marshal(trace)
+	if err != nil {
+		return err
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	f, err := os.OpenFile(ts.path(trace.StockCode), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if len(trace.Request) > 0 {
+		if err := ts.saveRequest(trace.ID, trace.Request); err != nil {
+			return err
+		}
+	}
+	return ts.trimIfNeeded(trace.StockCode)
+}
+
+func (ts *TraceService) requestDir() string {
+	return filepath.Join(ts.dir, "requests")
+}
+
+// saveRequest 保存请求快照，超出上限时删除最早的快照（调用方需持有锁）
+func (ts *TraceService) saveRequest(traceID string, request json.RawMessage) error {
+	dir := ts.requestDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, traceID+".json"), request, 0644); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) <= requestKeepCount {
+		return err
+	}
+	type fileAge struct {
+		name string
+		mod  int64
+	}
+	files := make([]fileAge, 0, len(entries))
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil {
+			files = append(files, fileAge{e.Name(), info.ModTime().UnixNano()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod < files[j].mod })
+	for _, f := range files[:max(0, len(files)-requestKeepCount)] {
+		_ = os.Remove(filepath.Join(dir, f.name))
+	}
+	return nil
+}
+
+// GetRequest 获取轨迹对应的请求快照
+func (ts *TraceService) GetRequest(traceID string) (json.RawMessage, error) {
+	if traceID == "" || strings.ContainsAny(traceID, `/\`) {
+		return nil, fmt.Errorf("无效的轨迹 ID: %s", traceID)
+	}
+	data, err := os.ReadFile(filepath.Join(ts.requestDir(), traceID+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("该消息没有保存请求快照")
+	}
+	return data, err
+}
+
+// trimIfNeeded 文件过大时只保留最近的轨迹（调用方需持有锁）
+func (ts *TraceService) trimIfNeeded(stockCode string) error {
+	info, err := os.Stat(ts.path(stockCode))
+	if err != nil || info.Size() <= traceMaxFileSize {
+		return err
+	}
+	traces, err := ts.load(stockCode)
+	if err != nil {
+		return err
+	}
+	if len(traces) > traceKeepCount {
+		traces = traces[len(traces)-traceKeepCount:]
+	}
+	var buf bytes.Buffer
+	for i := range traces {
+		line, err := json.Marshal(&traces[i])
+		if err != nil {
+			continue
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return os.WriteFile(ts.path(stockCode), buf.Bytes(), 0644)
+}
+
+// load 读取股票的全部轨迹，按写入顺序（调用方需持有锁）
+func (ts *TraceService) load(stockCode string) ([]models.TurnTrace, error) {
+	f, err := os.Open(ts.path(stockCode))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var traces []models.TurnTrace
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	for scanner.Scan() {
+		var t models.TurnTrace
+		// 跳过写入中断产生的残缺行
+		if json.Unmarshal(scanner.Bytes(), &t) == nil {
+			traces = append(traces, t)
+		}
+	}
+	return traces, scanner.Err()
+}
+
+// Query 按条件查询轨迹，最新的在前
+func (ts *TraceService) Query(q TraceQuery) ([]models.TurnTrace, error) {
+	ts.mu.Lock()
+	traces, err := ts.load(q.StockCode)
+	ts.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.TurnTrace, 0)
+	for i := len(traces) - 1; i >= 0; i-- {
+		t := traces[i]
+		if q.AgentID != "" && t.AgentID != q.AgentID {
+			continue
+		}
+		if q.Since > 0 && t.StartedAt < q.Since {
+			continue
+		}
+		result = append(result, t)
+		if q.Limit > 0 && len(result) >= q.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Get 按 ID 获取轨迹
+func (ts *TraceService) Get(stockCode, traceID string) (*models.TurnTrace, error) {
+	ts.mu.Lock()
+	traces, err := ts.load(stockCode)
+	ts.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	for i := range traces {
+		if traces[i].ID == traceID {
+			return &traces[i], nil
+		}
+	}
+	return nil, fmt.Errorf("轨迹不存在: %s", traceID)
+}
+
+// ToolStats 汇总工具调用统计：调用次数、耗时、错误率与结果 token 开销
+// stockCode 为空时统计全部股票，since 为毫秒时间戳（0 不过滤）；按调用次数降序
+func (ts *TraceService) ToolStats(stockCode string, since int64) ([]models.ToolStats, error) {
+	codes := []string{stockCode}
+	if stockCode == "" {
+		entries, err := os.ReadDir(ts.dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		codes = codes[:0]
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), ".jsonl") {
+				codes = append(codes, strings.TrimSuffix(e.Name(), ".jsonl"))
+			}
+		}
+	}
+
+	type acc struct {
+		stats     models.ToolStats
+		latencies []int64
+	}
+	byName := make(map[string]*acc)
+	for _, code := range codes {
+		ts.mu.Lock()
+		traces, err := ts.load(code)
+		ts.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range traces {
+			if since > 0 && t.StartedAt < since {
+				continue
+			}
+			for _, tool := range t.Tools {
+				a := byName[tool.Name]
+				if a == nil {
+					a = &acc{stats: models.ToolStats{Name: tool.Name}}
+					byName[tool.Name] = a
+				}
+				if tool.Source != "" {
+					a.stats.Source = tool.Source
+				}
+				a.stats.Calls++
+				if tool.Error {
+					a.stats.Errors++
+				}
+				a.stats.ResultTokens += tool.ResultTokens
+				a.stats.LastUsedAt = max(a.stats.LastUsedAt, t.StartedAt)
+				a.latencies = append(a.latencies, tool.DurationMs)
+			}
+		}
+	}
+
+	result := make([]models.ToolStats, 0, len(byName))
+	for _, a := range byName {
+		s := a.stats
+		sort.Slice(a.latencies, func(i, j int) bool { return a.latencies[i] < a.latencies[j] })
+		var total int64
+		for _, l := range a.latencies {
+			total += l
+		}
+		s.AvgLatencyMs = total / int64(s.Calls)
+		s.P95LatencyMs = a.latencies[(len(a.latencies)*95-1)/100]
+		s.MaxLatencyMs = a.latencies[len(a.latencies)-1]
+		s.ErrorRate = float64(s.Errors) / float64(s.Calls)
+		s.AvgResultTokens = s.ResultTokens / s.Calls
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Calls != result[j].Calls {
+			return result[i].Calls > result[j].Calls
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestTraceService_AppendAndQuery(t *testing.T) {
+	ts := NewTraceService(t.TempDir())
+
+	for i, agentID := range []string{"a", "b", "a"} {
+		trace := &models.TurnTrace{
+			ID:        string(rune('1' + i)),
+			StockCode: "sh600519",
+			AgentID:   agentID,
+			StartedAt: int64(1000 * (i + 1)),
+		}
+		if err := ts.Append(trace); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := ts.Query(TraceQuery{StockCode: "sh600519"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].ID != "3" {
+		t.Fatalf("want 3 traces newest first, got %+v", all)
+	}
+
+	filtered, _ := ts.Query(TraceQuery{StockCode: "sh600519", AgentID: "a", Since: 2000})
+	if len(filtered) != 1 || filtered[0].ID != "3" {
+		t.Fatalf("unexpected filter result: %+v", filtered)
+	}
+
+	if _, err := ts.Get("sh600519", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if empty, _ := ts.Query(TraceQuery{StockCode: "sz000001"}); len(empty) != 0 {
+		t.Fatalf("want no traces, got %d", len(empty))
+	}
+}
+
+func TestTraceService_ToolStats(t *testing.T) {
+	ts := NewTraceService(t.TempDir())
+	ts.Append(&models.TurnTrace{ID: "1", StockCode: "sh600519", StartedAt: 1000, Tools: []models.ToolTrace{
+		{Name: "get_kline", Source: models.ToolSourceBuiltin, DurationMs: 100, ResultTokens: 300},
+		{Name: "mcp_search", Source: models.ToolSourceMCP, DurationMs: 900, Error: true},
+	}})
+	ts.Append(&models.TurnTrace{ID: "2", StockCode: "sz000001", StartedAt: 2000, Tools: []models.ToolTrace{
+		{Name: "get_kline", Source: models.ToolSourceBuiltin, DurationMs: 300, ResultTokens: 100},
+	}})
+
+	stats, err := ts.ToolStats("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Name != "get_kline" {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	k := stats[0]
+	if k.Calls != 2 || k.AvgLatencyMs != 200 || k.MaxLatencyMs != 300 || k.AvgResultTokens != 200 || k.LastUsedAt != 2000 {
+		t.Fatalf("get_kline stats = %+v", k)
+	}
+	if m := stats[1]; m.ErrorRate != 1 || m.Source != models.ToolSourceMCP {
+		t.Fatalf("mcp_search stats = %+v", m)
+	}
+
+	if recent, _ := ts.ToolStats("sh600519", 1500); len(recent) != 0 {
+		t.Fatalf("want no stats after since filter, got %+v", recent)
+	}
+}
+
+func TestTraceService_RequestSnapshot(t *testing.T) {
+	ts := NewTraceService(t.TempDir())
+	req := []byte(`{"contents":[{"role":"user","parts":[{"text":"分析"}]}]}`)
+	if err := ts.Append(&models.TurnTrace{ID: "t1", StockCode: "sh600519", Request: req}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ts.GetRequest("t1")
+	if err != nil || string(got) != string(req) {
+		t.Fatalf("GetRequest = %s, %v", got, err)
+	}
+	// 快照不写入轨迹 JSONL
+	trace, _ := ts.Get("sh600519", "t1")
+	if trace == nil || trace.Request != nil {
+		t.Errorf("trace = %+v", trace)
+	}
+	if _, err := ts.GetRequest("missing"); err == nil {
+		t.Error("缺少快照时应返回错误")
+	}
+	if _, err := ts.GetRequest("../t1"); err == nil {
+		t.Error("应拒绝路径穿越")
+	}
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var triggerLog = logger.New("trigger")
+
+const (
+	defaultTriggerDailyCap  = 10  // 默认每日自动分析上限
+	defaultPriceGapPercent  = 5.0 // 默认跳空阈值（%）
+	triggerFiringRetainDays = 7   // 触发记录保留天数
+	triggerQueueSize        = 32
+)
+
+// 默认分析模板
+var defaultTriggerTemplates = map[models.TriggerType]string{
+	models.TriggerAnnouncement: "{{name}}({{code}}) 发布新公告：{{reason}}。请分析该公告对股价的影响及应对策略。",
+	models.TriggerEarnings:     "{{name}}({{code}}) 发布业绩信息：{{reason}}。请解读业绩并评估对后市的影响。",
+	models.TriggerPriceGap:     "{{name}}({{code}}) 出现跳空：{{reason}}。请分析跳空原因及后续走势。",
+}
+
+// 业绩相关关键词
+var earningsKeywords = []string{"业绩", "财报", "年报", "季报", "半年报", "净利润", "营收"}
+
+// TriggerService 事件触发服务
+type TriggerService struct {
+	configPath string
+	store      models.TriggerStore
+	queue      chan models.TriggeredAnalysis
+	mu         sync.RWMutex
+}
+
+// NewTriggerService 创建事件触发服务
+func NewTriggerService(dataDir string) *TriggerService {
+	s := &TriggerService{
+		configPath: filepath.Join(dataDir, "triggers.json"),
+		queue:      make(chan models.TriggeredAnalysis, triggerQueueSize),
+	}
+	s.load()
+	return s
+}
+
+// load 加载触发器配置
+func (s *TriggerService) load() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = models.TriggerStore{DailyCap: defaultTriggerDailyCap}
+	data, err := os.ReadFile(s.configPath)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &s.store); err != nil {
+		triggerLog.Error("解析触发器配置失败: %v", err)
+		s.store = models.TriggerStore{DailyCap: defaultTriggerDailyCap}
+		return
+	}
+	if s.store.DailyCap <= 0 {
+		s.store.DailyCap = defaultTriggerDailyCap
+	}
+}
+
+// saveNoLock 保存配置（不带锁）
+func (s *TriggerService) saveNoLock() error {
+	data, err := json.MarshalIndent(s.store, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.configPath, data, 0644)
+}
+
+// Queue 返回待执行分析队列
+func (s *TriggerService) Queue() <-chan models.TriggeredAnalysis {
+	return s.queue
+}
+
+// GetTriggers 获取所有触发器
+func (s *TriggerService) GetTriggers() []models.AnalysisTrigger {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]models.AnalysisTrigger, len(s.store.Triggers))
+	copy(result, s.store.Triggers)
+	return result
+}
+
+// AddTrigger 添加触发器
+func (s *TriggerService) AddTrigger(trigger models.AnalysisTrigger) error {
+	if err := validateTrigger(&trigger); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if trigger.ID == "" {
+		trigger.ID = uuid.New().String()
+	}
+	for _, t := range s.store.Triggers {
+		if t.ID == trigger.ID {
+			return fmt.Errorf("触发器已存在: %s", trigger.ID)
+		}
+	}
+	s.store.Triggers = append(s.store.Triggers, trigger)
+	return s.saveNoLock()
+}
+
+// UpdateTrigger 更新触发器
+func (s *TriggerService) UpdateTrigger(trigger models.AnalysisTrigger) error {
+	if err := validateTrigger(&trigger); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, t := range s.store.Triggers {
+		if t.ID == trigger.ID {
+			s.store.Triggers[i] = trigger
+			return s.saveNoLock()
+		}
+	}
+	return fmt.Errorf("触发器不存在: %s", trigger.ID)
+}
+
+// DeleteTrigger 删除触发器
+func (s *TriggerService) DeleteTrigger(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, t := range s.store.Triggers {
+		if t.ID == id {
+			s.store.Triggers = append(s.store.Triggers[:i], s.store.Triggers[i+1:]...)
+			return s.saveNoLock()
+		}
+	}
+	return fmt.Errorf("触发器不存在: %s", id)
+}
+
+// GetDailyCap 获取每日上限
+func (s *TriggerService) GetDailyCap() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.store.DailyCap
+}
+
+// SetDailyCap 设置每日上限
+func (s *TriggerService) SetDailyCap(cap int) error {
+	if cap <= 0 {
+		return fmt.Errorf("每日上限必须大于0")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store.DailyCap = cap
+	return s.saveNoLock()
+}
+
+// validateTrigger 校验并补全触发器默认值
+func validateTrigger(t *models.AnalysisTrigger) error {
+	switch t.Type {
+	case models.TriggerAnnouncement, models.TriggerEarnings:
+	case models.TriggerPriceGap:
+		if t.Threshold <= 0 {
+			t.Threshold = defaultPriceGapPercent
+		}
+	default:
+		return fmt.Errorf("不支持的触发器类型: %s", t.Type)
+	}
+	if t.QueryTemplate == "" {
+		t.QueryTemplate = defaultTriggerTemplates[t.Type]
+	}
+	return nil
+}
+
+// Evaluate 根据最新行情和快讯评估触发器，命中的分析任务经去重和每日上限过滤后入队
+func (s *TriggerService) Evaluate(stocks []models.Stock, telegraphs []Telegraph) []models.TriggeredAnalysis {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	s.pruneFiringsNoLock(now)
+
+	firedToday := 0
+	seen := make(map[string]bool, len(s.store.Firings))
+	for _, f := range s.store.Firings {
+		seen[f.DedupeKey] = true
+		if f.Date == today {
+			firedToday++
+		}
+	}
+
+	var result []models.TriggeredAnalysis
+	for _, trigger := range s.store.Triggers {
+		if !trigger.Enabled {
+			continue
+		}
+		for _, stock := range stocks {
+			if !triggerWatches(trigger, stock.Symbol) {
+				continue
+			}
+			reason, dedupeKey, ok := matchTrigger(trigger, stock, telegraphs, today, seen)
+			if !ok {
+				continue
+			}
+			if firedToday >= s.store.DailyCap {
+				triggerLog.Warn("今日自动分析已达上限(%d)，跳过: %s %s", s.store.DailyCap, trigger.Name, stock.Symbol)
+				continue
+			}
+
+			task := models.TriggeredAnalysis{
+				TriggerID:   trigger.ID,
+				TriggerName: trigger.Name,
+				StockCode:   stock.Symbol,
+				StockName:   stock.Name,
+				Reason:      reason,
+				Query:       renderTriggerTemplate(trigger.QueryTemplate, stock, reason),
+				FiredAt:     now.UnixMilli(),
+			}
+
+			// 只有真正入队的任务才记录触发并计入每日上限，队列已满时下一轮可重新触发
+			select {
+			case s.queue <- task:
+			default:
+				triggerLog.Warn("分析队列已满，暂不入队: %s %s", trigger.Name, stock.Symbol)
+				continue
+			}
+
+			seen[dedupeKey] = true
+			firedToday++
+			s.store.Firings = append(s.store.Firings, models.TriggerFiring{
+				TriggerID: trigger.ID,
+				StockCode: stock.Symbol,
+				DedupeKey: dedupeKey,
+				Date:      today,
+				FiredAt:   now.UnixMilli(),
+				StockName: stock.Name,
+				Reason:    reason,
+			})
+			result = append(result, task)
+		}
+	}
+
+	if len(result) > 0 {
+		if err := s.saveNoLock(); err != nil {
+			triggerLog.Error("保存触发记录失败: %v", err)
+		}
+	}
+	return result
+}
+
+// RecentFirings 返回 since 之后的触发记录（最多保留 7 天），按触发时间排列
+func (s *TriggerService) RecentFirings(since time.Time) []models.TriggeredAnalysis {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make(map[string]string, len(s.store.Triggers))
+	for _, t := range s.store.Triggers {
+		names[t.ID] = t.Name
+	}
+	var result []models.TriggeredAnalysis
+	for _, f := range s.store.Firings {
+		if f.FiredAt < since.UnixMilli() {
+			continue
+		}
+		result = append(result, models.TriggeredAnalysis{
+			TriggerID:   f.TriggerID,
+			TriggerName: names[f.TriggerID],
+			StockCode:   f.StockCode,
+			StockName:   f.StockName,
+			Reason:      f.Reason,
+			FiredAt:     f.FiredAt,
+		})
+	}
+	return result
+}
+
+// pruneFiringsNoLock 清理过期触发记录
+func (s *TriggerService) pruneFiringsNoLock(now time.Time) {
+	cutoff := now.AddDate(0, 0, -triggerFiringRetainDays).Format("2006-01-02")
+	kept := s.store.Firings[:0]
+	for _, f := range s.store.Firings {
+		if f.Date >= cutoff {
+			kept = append(kept, f)
+		}
+	}
+	s.store.Firings = kept
+}
+
+// triggerWatches 判断触发器是否监控该股票
+func triggerWatches(t models.AnalysisTrigger, code string) bool {
+	if len(t.StockCodes) == 0 {
+		return true
+	}
+	for _, c := range t.StockCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTrigger 判断单个股票是否命中触发器，返回触发原因和去重键，已触发过（去重键在 seen 中）的不算命中
+func matchTrigger(t models.AnalysisTrigger, stock models.Stock, telegraphs []Telegraph, today string, seen map[string]bool) (string, string, bool) {
+	switch t.Type {
+	case models.TriggerPriceGap:
+		if stock.PreClose <= 0 || stock.Open <= 0 {
+			return "", "", false
+		}
+		gap := (stock.Open - stock.PreClose) / stock.PreClose * 100
+		threshold := t.Threshold
+		if threshold <= 0 {
+			threshold = defaultPriceGapPercent
+		}
+		if math.Abs(gap) < threshold {
+			return "", "", false
+		}
+		direction := "高开"
+		if gap < 0 {
+			direction = "低开"
+		}
+		key := fmt.Sprintf("%s:%s:%s", t.ID, stock.Symbol, today)
+		if seen[key] {
+			return "", "", false
+		}
+		reason := fmt.Sprintf("%s %.2f%%（开盘 %.2f，昨收 %.2f）", direction, math.Abs(gap), stock.Open, stock.PreClose)
+		return reason, key, true
+
+	case models.TriggerAnnouncement, models.TriggerEarnings:
+		if stock.Name == "" {
+			return "", "", false
+		}
+		for _, tg := range telegraphs {
+			if !strings.Contains(tg.Content, stock.Name) {
+				continue
+			}
+			if t.Type == models.TriggerAnnouncement && !strings.Contains(tg.Content, "公告") {
+				continue
+			}
+			if t.Type == models.TriggerEarnings && !containsAny(tg.Content, earningsKeywords) {
+				continue
+			}
+			sum := sha1.Sum([]byte(tg.Content))
+			key := fmt.Sprintf("%s:%s:%s", t.ID, stock.Symbol, hex.EncodeToString(sum[:8]))
+			if seen[key] {
+				continue
+			}
+			return truncateRunes(tg.Content, 120), key, true
+		}
+	}
+	return "", "", false
+}
+
+// renderTriggerTemplate 渲染分析模板
+func renderTriggerTemplate(tpl string, stock models.Stock, reason string) string {
+	return strings.NewReplacer(
+		"{{name}}", stock.Name,
+		"{{code}}", stock.Symbol,
+		"{{reason}}", reason,
+	).Replace(tpl)
+}
+
+// containsAny 判断文本是否包含任一关键词
+func containsAny(s string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(s, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateRunes 按字符截断
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestTriggerService_PriceGapDedupeAndCap(t *testing.T) {
+	s := NewTriggerService(t.TempDir())
+	if err := s.AddTrigger(models.AnalysisTrigger{ID: "gap", Name: "跳空", Type: models.TriggerPriceGap, Enabled: true}); err != nil {
+		t.Fatalf("添加触发器失败: %v", err)
+	}
+	if err := s.SetDailyCap(1); err != nil {
+		t.Fatalf("设置上限失败: %v", err)
+	}
+
+	stocks := []models.Stock{
+		{Symbol: "sh600000", Name: "浦发银行", Open: 10.6, PreClose: 10},
+		{Symbol: "sz000001", Name: "平安银行", Open: 9.4, PreClose: 10},
+		{Symbol: "sz000002", Name: "万科A", Open: 10.1, PreClose: 10},
+	}
+
+	got := s.Evaluate(stocks, nil)
+	if len(got) != 1 {
+		t.Fatalf("首次评估触发 %d 个，期望 1 个（受每日上限限制）", len(got))
+	}
+	if got[0].StockCode != "sh600000" {
+		t.Errorf("触发股票 = %s, 期望 sh600000", got[0].StockCode)
+	}
+
+	// 同一天重复评估应被去重
+	if again := s.Evaluate(stocks[:1], nil); len(again) != 0 {
+		t.Errorf("重复评估触发 %d 个，期望 0 个", len(again))
+	}
+}
+
+func TestTriggerService_Announcement(t *testing.T) {
+	s := NewTriggerService(t.TempDir())
+	s.AddTrigger(models.AnalysisTrigger{ID: "ann", Type: models.TriggerAnnouncement, Enabled: true})
+
+	stocks := []models.Stock{{Symbol: "sh600000", Name: "浦发银行"}}
+	telegraphs := []Telegraph{
+		{Content: "浦发银行股价异动"},
+		{Content: "浦发银行公告：拟回购股份"},
+	}
+
+	got := s.Evaluate(stocks, telegraphs)
+	if len(got) != 1 {
+		t.Fatalf("触发 %d 个，期望 1 个", len(got))
+	}
+	if got[0].Reason != "浦发银行公告：拟回购股份" {
+		t.Errorf("触发原因 = %q", got[0].Reason)
+	}
+}
+
+func TestTriggerService_AnnouncementSkipsSeenTelegraph(t *testing.T) {
+	s := NewTriggerService(t.TempDir())
+	s.AddTrigger(models.AnalysisTrigger{ID: "ann", Type: models.TriggerAnnouncement, Enabled: true})
+
+	stocks := []models.Stock{{Symbol: "sh600000", Name: "浦发银行"}}
+	first := Telegraph{Content: "浦发银行公告：拟回购股份"}
+	second := Telegraph{Content: "浦发银行公告：董事会换届"}
+
+	if got := s.Evaluate(stocks, []Telegraph{first}); len(got) != 1 {
+		t.Fatalf("首次触发 %d 个，期望 1 个", len(got))
+	}
+
+	// 已触发过的快讯排在前面，不应挡住后面新的公告
+	got := s.Evaluate(stocks, []Telegraph{first, second})
+	if len(got) != 1 {
+		t.Fatalf("触发 %d 个，期望 1 个", len(got))
+	}
+	if got[0].Reason != second.Content {
+		t.Errorf("触发原因 = %q，期望 %q", got[0].Reason, second.Content)
+	}
+
+	if got := s.Evaluate(stocks, []Telegraph{first, second}); len(got) != 0 {
+		t.Errorf("重复快讯不应再次触发，得到 %d 个", len(got))
+	}
+}
+
+func TestTriggerService_CapCountsOnlyEnqueued(t *testing.T) {
+	s := NewTriggerService(t.TempDir())
+	s.AddTrigger(models.AnalysisTrigger{ID: "gap", Name: "跳空", Type: models.TriggerPriceGap, Enabled: true})
+	if err := s.SetDailyCap(triggerQueueSize + 1); err != nil {
+		t.Fatalf("设置上限失败: %v", err)
+	}
+
+	// 填满队列：超出队列容量的任务被丢弃，不应计入上限，也不应被去重
+	stocks := make([]models.Stock, triggerQueueSize+2)
+	for i := range stocks {
+		stocks[i] = models.Stock{Symbol: fmt.Sprintf("sh6%05d", i), Name: fmt.Sprintf("股票%d", i), Open: 10.6, PreClose: 10}
+	}
+	if got := s.Evaluate(stocks, nil); len(got) != triggerQueueSize {
+		t.Fatalf("首次评估入队 %d 个，期望 %d 个", len(got), triggerQueueSize)
+	}
+
+	// 消费队列后再次评估，被丢弃的股票应重新触发，直到达到上限
+	for range triggerQueueSize {
+		<-s.Queue()
+	}
+	got := s.Evaluate(stocks, nil)
+	if len(got) != 1 {
+		t.Fatalf("再次评估入队 %d 个，期望 1 个（剩余上限）", len(got))
+	}
+	if got[0].StockCode != stocks[triggerQueueSize].Symbol {
+		t.Errorf("触发股票 = %s, 期望 %s", got[0].StockCode, stocks[triggerQueueSize].Symbol)
+	}
+}
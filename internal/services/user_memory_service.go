@@ -0,0 +1,168 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/run-bigpig/jcp/internal/logger"
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+var userMemoryLog = logger.New("user-memory")
+
+const (
+	// maxUserMemoryEntries 全局记忆条目上限
+	maxUserMemoryEntries = 100
+	// maxUserMemoryContentLength 单条全局记忆的最大字符数
+	maxUserMemoryContentLength = 300
+	// maxUserMemoryPromptLength 注入提示词的全局记忆最大字符数
+	maxUserMemoryPromptLength = 1500
+)
+
+// UserMemoryService 用户级全局记忆（投资风格、风险承受、仓位习惯等），跨股票会话共享
+type UserMemoryService struct {
+	path    string
+	entries []models.UserMemoryEntry
+	mu      sync.RWMutex
+}
+
+// NewUserMemoryService 创建全局记忆服务
+func NewUserMemoryService(dataDir string) *UserMemoryService {
+	s := &UserMemoryService{path: filepath.Join(dataDir, "user_memory.json")}
+	if data, err := os.ReadFile(s.path); err == nil {
+		if err := json.Unmarshal(data, &s.entries); err != nil {
+			userMemoryLog.Error("解析全局记忆失败: %v", err)
+		}
+	}
+	return s
+}
+
+// saveNoLock 保存全局记忆
+func (s *UserMemoryService) saveNoLock() error {
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWriteFile(s.path, data)
+}
+
+// normalizeUserMemory 校验分类与内容
+func normalizeUserMemory(category models.UserMemoryCategory, content string) (string, error) {
+	if !slices.Contains(models.UserMemoryCategories, category) {
+		return "", fmt.Errorf("不支持的记忆分类: %s", category)
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("记忆内容不能为空")
+	}
+	if len([]rune(content)) > maxUserMemoryContentLength {
+		return "", fmt.Errorf("记忆内容不能超过 %d 字", maxUserMemoryContentLength)
+	}
+	return content, nil
+}
+
+// List 列出全部全局记忆（按分类、创建时间排序）
+func (s *UserMemoryService) List() []models.UserMemoryEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries := slices.Clone(s.entries)
+	slices.SortStableFunc(entries, func(a, b models.UserMemoryEntry) int {
+		return slices.Index(models.UserMemoryCategories, a.Category) - slices.Index(models.UserMemoryCategories, b.Category)
+	})
+	return entries
+}
+
+// Add 添加全局记忆，内容重复时返回已有条目
+func (s *UserMemoryService) Add(category models.UserMemoryCategory, content, source string) (models.UserMemoryEntry, error) {
+	content, err := normalizeUserMemory(category, content)
+	if err != nil {
+		return models.UserMemoryEntry{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.Category == category && e.Content == content {
+			return e, nil
+		}
+	}
+	if len(s.entries) >= maxUserMemoryEntries {
+		return models.UserMemoryEntry{}, fmt.Errorf("全局记忆最多 %d 条，请先删除不再适用的条目", maxUserMemoryEntries)
+	}
+	now := time.Now().UnixMilli()
+	entry := models.UserMemoryEntry{
+		ID:        uuid.New().String(),
+		Category:  category,
+		Content:   content,
+		Source:    source,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	s.entries = append(s.entries, entry)
+	if err := s.saveNoLock(); err != nil {
+		s.entries = s.entries[:len(s.entries)-1]
+		return models.UserMemoryEntry{}, err
+	}
+	return entry, nil
+}
+
+// Update 编辑全局记忆
+func (s *UserMemoryService) Update(id string, category models.UserMemoryCategory, content string) error {
+	content, err := normalizeUserMemory(category, content)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := slices.IndexFunc(s.entries, func(e models.UserMemoryEntry) bool { return e.ID == id })
+	if idx < 0 {
+		return fmt.Errorf("记忆不存在: %s", id)
+	}
+	old := s.entries[idx]
+	s.entries[idx].Category = category
+	s.entries[idx].Content = content
+	s.entries[idx].Source = "user"
+	s.entries[idx].UpdatedAt = time.Now().UnixMilli()
+	if err := s.saveNoLock(); err != nil {
+		s.entries[idx] = old
+		return err
+	}
+	return nil
+}
+
+// Delete 删除全局记忆
+func (s *UserMemoryService) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := slices.IndexFunc(s.entries, func(e models.UserMemoryEntry) bool { return e.ID == id })
+	if idx < 0 {
+		return fmt.Errorf("记忆不存在: %s", id)
+	}
+	old := s.entries
+	s.entries = slices.Delete(slices.Clone(s.entries), idx, idx+1)
+	if err := s.saveNoLock(); err != nil {
+		s.entries = old
+		return err
+	}
+	return nil
+}
+
+// FormatForPrompt 将全局记忆按分类格式化为提示词上下文
+func (s *UserMemoryService) FormatForPrompt() string {
+	entries := s.List()
+	if len(entries) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("【用户长期偏好】\n")
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "- [%s] %s\n", e.Category.Label(), e.Content)
+	}
+	return truncateRunes(sb.String(), maxUserMemoryPromptLength)
+}
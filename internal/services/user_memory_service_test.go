@@ -0,0 +1,44 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/run-bigpig/jcp/internal/models"
+)
+
+func TestUserMemoryService_CRUDAndPrompt(t *testing.T) {
+	dir := t.TempDir()
+	s := NewUserMemoryService(dir)
+
+	if _, err := s.Add("unknown", "随便", "user"); err == nil {
+		t.Fatal("未知分类不应被接受")
+	}
+	pref, err := s.Add(models.UserMemoryPreference, "不碰 ST 股", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	style, err := s.Add(models.UserMemoryStyle, "偏好低估值红利股", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dup, _ := s.Add(models.UserMemoryStyle, "偏好低估值红利股", "user"); dup.ID != style.ID {
+		t.Error("重复内容应返回已有条目")
+	}
+	if err := s.Update(style.ID, models.UserMemoryPosition, "单只股票仓位不超过 20%"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(pref.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载验证持久化
+	reloaded := NewUserMemoryService(dir)
+	entries := reloaded.List()
+	if len(entries) != 1 || entries[0].Category != models.UserMemoryPosition {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if prompt := reloaded.FormatForPrompt(); !strings.Contains(prompt, "[仓位习惯] 单只股票仓位不超过 20%") {
+		t.Errorf("prompt = %q", prompt)
+	}
+}